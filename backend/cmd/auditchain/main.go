@@ -0,0 +1,92 @@
+// Command auditchain checks and maintains the audit_log hash chain outside
+// the server, e.g. from cron or before handing a payment dispute over:
+//
+//	auditchain verify      walk the chain; exit 1 on the first broken link
+//	auditchain seal        link rows written without a hash onto the tip
+//	auditchain checkpoint  sign the current tip now (needs AUDIT_SIGNING_KEY)
+//
+// It reads the same environment as the server.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/audit"
+	"github.com/ferjunior7/parasempre/backend/internal/config"
+	"github.com/ferjunior7/parasempre/backend/internal/database"
+)
+
+const usage = "usage: auditchain verify|seal|checkpoint"
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("config error", "error", err)
+		os.Exit(2)
+	}
+	signer, err := cfg.AuditSigner()
+	if err != nil {
+		slog.Error("audit signing key config error", "error", err)
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	pool, err := database.Connect(ctx, cfg.DB)
+	if err != nil {
+		slog.Error("failed to connect to database", "error", err)
+		os.Exit(2)
+	}
+	defer pool.Close()
+
+	chain := audit.NewChain(pool, signer)
+	switch os.Args[1] {
+	case "verify":
+		if signer == nil {
+			slog.Warn("AUDIT_SIGNING_KEY not set: checkpoint signatures will not be checked")
+		}
+		report, err := chain.Verify(ctx)
+		if err != nil {
+			slog.Error("verify failed", "error", err)
+			os.Exit(2)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+		if !report.OK {
+			os.Exit(1)
+		}
+	case "seal":
+		sealed, err := chain.Seal(ctx)
+		if err != nil {
+			slog.Error("seal failed", "error", err)
+			os.Exit(2)
+		}
+		fmt.Printf("sealed %d rows\n", sealed)
+	case "checkpoint":
+		cp, err := chain.Checkpoint(ctx)
+		if err != nil {
+			slog.Error("checkpoint failed", "error", err)
+			os.Exit(2)
+		}
+		if cp == nil {
+			fmt.Println("chain tip already checkpointed")
+			return
+		}
+		fmt.Printf("checkpoint %d: last_id=%d last_hash=%x\n", cp.ID, cp.LastID, cp.LastHash)
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
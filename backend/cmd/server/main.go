@@ -8,23 +8,35 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/time/rate"
 
+	"github.com/ferjunior7/parasempre/backend/internal/audit"
 	"github.com/ferjunior7/parasempre/backend/internal/auth"
+	"github.com/ferjunior7/parasempre/backend/internal/checkin"
 	"github.com/ferjunior7/parasempre/backend/internal/config"
 	"github.com/ferjunior7/parasempre/backend/internal/database"
 	"github.com/ferjunior7/parasempre/backend/internal/gift"
 	"github.com/ferjunior7/parasempre/backend/internal/giftmessage"
 	"github.com/ferjunior7/parasempre/backend/internal/guest"
+	"github.com/ferjunior7/parasempre/backend/internal/guestbook"
+	"github.com/ferjunior7/parasempre/backend/internal/household"
+	"github.com/ferjunior7/parasempre/backend/internal/importmap"
+	"github.com/ferjunior7/parasempre/backend/internal/invitation"
 	"github.com/ferjunior7/parasempre/backend/internal/middleware"
 	"github.com/ferjunior7/parasempre/backend/internal/payment"
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
 	"github.com/ferjunior7/parasempre/backend/internal/user"
+	"github.com/ferjunior7/parasempre/backend/internal/wall"
 )
 
 func main() {
+	slog.SetDefault(slog.New(reqctx.NewLogHandler(slog.NewTextHandler(os.Stderr, nil))))
+
 	cfg, err := config.Load()
 	if err != nil {
 		slog.Error("config error", "error", err)
@@ -41,6 +53,7 @@ func main() {
 	}
 	defer pool.Close()
 	slog.Info("connected to database")
+	slog.Info("rate limiter backend", "backend", cfg.RateLimitBackend)
 
 	guestRepo := guest.NewPostgresRepository(pool)
 	giftRepo := gift.NewPostgresRepository(pool)
@@ -57,20 +70,59 @@ func main() {
 	jwtSvc := auth.NewJWTService(cfg.JWTSecret, jwtExpiry)
 
 	userSvc := user.NewServiceWithTx(userRepo, guestRepo)
-	guestSvc := guest.NewService(guestRepo, userSvc, txRunner)
+	importMappings := importmap.NewPostgresRepository(pool)
+	guestSvc := guest.NewService(guestRepo, userSvc, txRunner, userRepo, importMappings)
 	guestHandler := guest.NewHandler(guestSvc)
+	householdHandler := household.NewHandler(household.NewService(household.NewPostgresRepository(pool), txRunner, userRepo))
 
-	var firecrawlClient gift.ProductScraper
-	const defaultFirecrawlURL = "https://api.firecrawl.dev"
-	if cfg.FirecrawlAPIKey != "" || cfg.FirecrawlURL != defaultFirecrawlURL {
-		firecrawlClient = gift.NewFirecrawlClient(cfg.FirecrawlAPIKey, cfg.FirecrawlURL)
-		slog.Info("firecrawl: enabled", "url", cfg.FirecrawlURL, "auth", cfg.FirecrawlAPIKey != "")
-	} else {
-		slog.Warn("firecrawl: disabled (set FIRECRAWL_API_KEY for cloud or FIRECRAWL_URL for self-host)")
-	}
-	giftSvc := gift.NewService(giftRepo, txRunner, firecrawlClient)
+	productScraper := newProductScraper(cfg)
+	giftSvc := gift.NewService(giftRepo, txRunner, productScraper, userRepo, importMappings)
 	giftHandler := gift.NewHandler(giftSvc)
+
+	var priceMonitor *gift.PriceMonitor
+	priceCtx, priceCancel := context.WithCancel(context.Background())
+	defer priceCancel()
+	if productScraper != nil {
+		interval, _ := time.ParseDuration(cfg.PriceMonitorInterval)
+		recheck, _ := time.ParseDuration(cfg.PriceMonitorRecheckAfter)
+		priceMonitor = gift.NewPriceMonitor(giftSvc, giftRepo, productScraper, gift.PriceMonitorConfig{
+			Interval:         interval,
+			RecheckAfter:     recheck,
+			DailyBudget:      cfg.PriceMonitorDailyBudget,
+			ThresholdPercent: cfg.PriceMonitorThresholdPercent,
+		})
+		if cfg.PriceMonitorDailyBudget > 0 {
+			go priceMonitor.Run(priceCtx)
+			slog.Info("price monitor: enabled", "interval", interval, "daily_budget", cfg.PriceMonitorDailyBudget)
+		} else {
+			slog.Warn("price monitor: disabled (PRICE_MONITOR_DAILY_BUDGET=0)")
+		}
+	}
 	userHandler := user.NewHandler(userSvc, cfg.AppEnv)
+	auditSigner, err := cfg.AuditSigner()
+	if err != nil {
+		slog.Error("audit signing key config error", "error", err)
+		os.Exit(1)
+	}
+	auditChain := audit.NewChain(pool, auditSigner)
+	sealCtx, sealCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	if sealed, err := auditChain.Seal(sealCtx); err != nil {
+		slog.Error("audit chain: seal failed", "error", err)
+	} else if sealed > 0 {
+		slog.Info("audit chain: sealed unchained rows", "count", sealed)
+	}
+	sealCancel()
+
+	checkpointCtx, checkpointCancel := context.WithCancel(context.Background())
+	defer checkpointCancel()
+	if auditSigner != nil {
+		interval, _ := time.ParseDuration(cfg.AuditCheckpointInterval)
+		go auditChain.RunCheckpoints(checkpointCtx, interval)
+		slog.Info("audit chain: signed checkpoints enabled", "interval", interval)
+	} else {
+		slog.Warn("audit chain: checkpoints disabled (set AUDIT_SIGNING_KEY to enable)")
+	}
+	auditHandler := audit.NewHandler(audit.NewService(audit.NewPostgresRepository(pool), auditChain))
 
 	var paymentHandler *payment.Handler
 	var purchaseLimiterMW, webhookLimiterMW func(http.Handler) http.Handler
@@ -84,8 +136,8 @@ func main() {
 		paymentSvc := payment.NewService(paymentRepo, txRunner, mpClient, giftFinderAdapter{repo: giftRepo}, userRepo)
 		paymentHandler = payment.NewHandler(paymentSvc, mpClient)
 
-		purchaseLimiter := middleware.NewRateLimiter(rate.Every(12*time.Second), 5)
-		webhookLimiter := middleware.NewRateLimiter(rate.Limit(30), 60)
+		purchaseLimiter := newRateLimiter(cfg, pool, "purchase", rate.Every(12*time.Second), 5)
+		webhookLimiter := newRateLimiter(cfg, pool, "webhook", rate.Limit(30), 60)
 		purchaseLimiterMW = purchaseLimiter.MiddlewareWithKey(func(r *http.Request) string {
 			if uid := middleware.UserIDFromContext(r.Context()); uid != 0 {
 				return fmt.Sprintf("user:%d", uid)
@@ -100,28 +152,45 @@ func main() {
 	}
 
 	var giftMessageHandler *giftmessage.Handler
+	var guestbookHandler *guestbook.Handler
+	var wallHub *wall.Hub
+	// Cancelled on shutdown so open wall streams end instead of stalling it.
+	wallCtx, wallCancel := context.WithCancel(context.Background())
+	defer wallCancel()
+	var localMedia *giftmessage.LocalStorage
+	var resumableUploads *giftmessage.ResumableUploads
 	var messageLimiterMW func(http.Handler) http.Handler
 	{
 		var storage giftmessage.Storage
-		if cfg.SupabaseURL != "" && cfg.SupabaseServiceRoleKey != "" {
-			ss := giftmessage.NewSupabaseStorage(cfg.SupabaseURL, cfg.SupabaseStorageBucket, cfg.SupabaseServiceRoleKey)
-			bucketCtx, bucketCancel := context.WithTimeout(context.Background(), 5*time.Second)
-			if err := ss.BucketExists(bucketCtx); err != nil {
-				slog.Error("supabase storage: bucket inacessível — uploads de mídia vão falhar",
-					"bucket", cfg.SupabaseStorageBucket, "error", err)
-			}
-			bucketCancel()
-			storage = ss
-			slog.Info("supabase storage: enabled", "bucket", cfg.SupabaseStorageBucket)
-		} else {
-			slog.Warn("supabase storage: disabled (set SUPABASE_URL and SUPABASE_SERVICE_ROLE_KEY to enable media uploads)")
-		}
+		storage, localMedia = newMediaStorage(cfg)
 		ttl := time.Duration(cfg.GiftMessageSignedURLTTLSecs) * time.Second
 		txFinder := payment.NewMessageTxFinder(paymentRepo)
-		giftMessageSvc := giftmessage.NewService(giftMessageRepo, txFinder, storage, userRepo, ttl)
+		giftMessageSvc := giftmessage.NewService(giftMessageRepo, txFinder, storage, userRepo, ttl,
+			giftmessage.ModerationMode(cfg.GiftMessageModeration))
 		giftMessageHandler = giftmessage.NewHandler(giftMessageSvc)
+		guestbookSvc := guestbook.NewService(guestbook.NewPostgresRepository(pool), userRepo, storage, userRepo, ttl,
+			giftmessage.ModerationMode(cfg.GiftMessageModeration), guestbook.SignScope(cfg.GuestbookSignScope))
+		guestbookHandler = guestbook.NewHandler(guestbookSvc)
+		sweepCtx, sweepCancel := context.WithCancel(context.Background())
+		defer sweepCancel()
+		go giftMessageSvc.RunUploadSlotSweeper(sweepCtx, 10*time.Minute)
+
+		// Wall links must outlive a whole slideshow loop, not a page view.
+		wallHub = wall.NewHub(wall.NewPostgresRepository(pool), storage, 6*time.Hour)
+		go wallHub.Listen(wallCtx, pool)
+
+		if storage != nil {
+			uploads := giftmessage.NewResumableUploads(giftMessageSvc, cfg.ResumableUploadDir)
+			if err := uploads.EnsureDir(); err != nil {
+				slog.Error("resumable uploads: disabled", "dir", cfg.ResumableUploadDir, "error", err)
+			} else {
+				resumableUploads = uploads
+				go uploads.RunSweeper(sweepCtx, 10*time.Minute)
+				slog.Info("resumable uploads: enabled", "dir", cfg.ResumableUploadDir)
+			}
+		}
 
-		messageLimiter := middleware.NewRateLimiter(rate.Every(12*time.Second), 5)
+		messageLimiter := newRateLimiter(cfg, pool, "message", rate.Every(12*time.Second), 5)
 		messageLimiterMW = messageLimiter.MiddlewareWithKey(func(r *http.Request) string {
 			if uid := middleware.UserIDFromContext(r.Context()); uid != 0 {
 				return fmt.Sprintf("user:%d", uid)
@@ -139,6 +208,29 @@ func main() {
 	otpSvc := auth.NewOTPService(otpRepo, whatsappSender)
 	authHandler := auth.NewHandler(otpSvc, jwtSvc, userSvc, userSvc, userSvc)
 
+	var invitationHandler *invitation.Handler
+	if cfg.InvitationSigningKey != "" {
+		expiresAt, _ := cfg.InvitationExpiry()
+		invitationSvc := invitation.NewService(invitation.NewPostgresRepository(pool),
+			invitation.NewSigner([]byte(cfg.InvitationSigningKey)),
+			invitation.Config{ExpiresAt: expiresAt, BaseURL: cfg.InvitationBaseURL},
+			userSvc, userRepo)
+		invitationHandler = invitation.NewHandler(invitationSvc, jwtSvc)
+		slog.Info("invitations: enabled", "expires_at", expiresAt, "base_url", cfg.InvitationBaseURL)
+	} else {
+		slog.Warn("invitations: disabled (set INVITATION_SIGNING_KEY to enable)")
+	}
+
+	var checkinHandler *checkin.Handler
+	if cfg.CheckinSigningKey != "" {
+		checkinSvc := checkin.NewService(checkin.NewPostgresRepository(pool),
+			checkin.NewSigner([]byte(cfg.CheckinSigningKey)), userRepo)
+		checkinHandler = checkin.NewHandler(checkinSvc)
+		slog.Info("checkin: enabled")
+	} else {
+		slog.Warn("checkin: disabled (set CHECKIN_SIGNING_KEY to enable)")
+	}
+
 	var devLoginHandler *auth.DevLoginHandler
 	if cfg.AppEnv != "production" {
 		devLoginHandler = auth.NewDevLoginHandler(jwtSvc, userSvc, userSvc)
@@ -152,27 +244,52 @@ func main() {
 		user.CoupleData{URACF: cfg.Couple.Bride.URACF, Phone: cfg.Couple.Bride.Phone},
 	)
 
+	cacheCfg := middleware.ResponseCacheConfig{
+		TTL:    time.Duration(cfg.HTTPCacheTTLSecs) * time.Second,
+		MaxAge: time.Duration(cfg.HTTPCacheMaxAgeSecs) * time.Second,
+	}
+	slog.Info("http cache", "ttl", cacheCfg.TTL, "max_age", cacheCfg.MaxAge)
+
 	mux := http.NewServeMux()
 	registerRoutes(mux, routeDeps{
 		auth:            authHandler,
 		devLogin:        devLoginHandler,
 		guest:           guestHandler,
+		household:       householdHandler,
+		invitation:      invitationHandler,
+		checkin:         checkinHandler,
 		gift:            giftHandler,
 		user:            userHandler,
+		audit:           auditHandler,
 		payment:         paymentHandler,
 		giftMessage:     giftMessageHandler,
+		guestbook:       guestbookHandler,
+		wall:            wallHub,
+		priceMonitor:    priceMonitor,
 		jwt:             jwtSvc,
 		appEnv:          cfg.AppEnv,
 		purchaseLimiter: purchaseLimiterMW,
 		webhookLimiter:  webhookLimiterMW,
 		messageLimiter:  messageLimiterMW,
+		localMedia:      localMedia,
+		uploads:         resumableUploads,
+		giftCache:       middleware.NewResponseCache(cacheCfg),
+		messageCache:    middleware.NewResponseCache(cacheCfg),
 	})
 
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		slog.Error("trusted proxies config error", "error", err)
+		os.Exit(1)
+	}
+
 	handler := middleware.Chain(mux,
+		middleware.RequestID,
+		middleware.ClientIP(trustedProxies),
 		middleware.Recovery,
 		middleware.Logger,
 		middleware.SecurityHeaders(cfg.AppEnv),
-		middleware.CORS(cfg.CORSOrigin),
+		middleware.CORS(cfg.CORSOrigin, giftmessage.ResumableUploadRoute),
 	)
 
 	server := &http.Server{
@@ -183,6 +300,7 @@ func main() {
 		WriteTimeout:      120 * time.Second,
 		IdleTimeout:       60 * time.Second,
 	}
+	server.RegisterOnShutdown(wallCancel)
 
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGTERM)
@@ -207,6 +325,95 @@ func main() {
 	slog.Info("server stopped")
 }
 
+func newRateLimiter(cfg config.Config, pool *pgxpool.Pool, name string, r rate.Limit, burst int) *middleware.RateLimiter {
+	if cfg.RateLimitBackend == config.RateLimitBackendPostgres {
+		return middleware.NewRateLimiterWithBackend(middleware.NewPostgresRateLimitBackend(pool, name, r, burst))
+	}
+	return middleware.NewRateLimiter(r, burst)
+}
+
+// newProductScraper chains the scrapers listed in PRODUCT_SCRAPERS, leaving
+// out Firecrawl when it is not configured. Nil disables scraping.
+func newProductScraper(cfg config.Config) gift.ProductScraper {
+	const defaultFirecrawlURL = "https://api.firecrawl.dev"
+	firecrawlEnabled := cfg.FirecrawlAPIKey != "" || cfg.FirecrawlURL != defaultFirecrawlURL
+
+	var chain gift.ChainScraper
+	for _, name := range cfg.ProductScrapers {
+		switch name {
+		case config.ProductScraperNative:
+			chain = append(chain, gift.NewHTMLScraper())
+		case config.ProductScraperFirecrawl:
+			if !firecrawlEnabled {
+				slog.Warn("firecrawl: disabled (set FIRECRAWL_API_KEY for cloud or FIRECRAWL_URL for self-host)")
+				continue
+			}
+			chain = append(chain, gift.NewFirecrawlClient(cfg.FirecrawlAPIKey, cfg.FirecrawlURL))
+			slog.Info("firecrawl: enabled", "url", cfg.FirecrawlURL, "auth", cfg.FirecrawlAPIKey != "")
+		}
+	}
+	switch len(chain) {
+	case 0:
+		return nil
+	case 1:
+		return chain[0]
+	}
+	slog.Info("product scraper: chained", "order", strings.Join(cfg.ProductScrapers, ","))
+	return chain
+}
+
+// newMediaStorage builds the gift message storage selected by
+// STORAGE_BACKEND. The LocalStorage is also returned so its signed URLs can
+// be served; storage is nil when media messages are disabled.
+func newMediaStorage(cfg config.Config) (giftmessage.Storage, *giftmessage.LocalStorage) {
+	switch cfg.StorageBackend {
+	case config.StorageBackendLocal:
+		ls := giftmessage.NewLocalStorage(cfg.LocalStorageDir, cfg.LocalStoragePublicURL, []byte(cfg.LocalStorageSigningKey))
+		if err := ls.EnsureDir(); err != nil {
+			slog.Error("local storage: diretório inacessível — mídia desabilitada", "dir", cfg.LocalStorageDir, "error", err)
+			return nil, nil
+		}
+		slog.Info("local storage: enabled", "dir", cfg.LocalStorageDir, "public_url", cfg.LocalStoragePublicURL)
+		return ls, ls
+
+	case config.StorageBackendS3:
+		s3, err := giftmessage.NewS3Storage(giftmessage.S3Config{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			PathStyle:       cfg.S3ForcePathStyle,
+		})
+		if err != nil {
+			slog.Error("s3 storage: config error — mídia desabilitada", "error", err)
+			return nil, nil
+		}
+		bucketCtx, bucketCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := s3.BucketExists(bucketCtx); err != nil {
+			slog.Error("s3 storage: bucket inacessível — uploads de mídia vão falhar",
+				"bucket", cfg.S3Bucket, "error", err)
+		}
+		bucketCancel()
+		slog.Info("s3 storage: enabled", "endpoint", cfg.S3Endpoint, "bucket", cfg.S3Bucket, "path_style", cfg.S3ForcePathStyle)
+		return s3, nil
+	}
+
+	if cfg.SupabaseURL == "" || cfg.SupabaseServiceRoleKey == "" {
+		slog.Warn("supabase storage: disabled (set SUPABASE_URL and SUPABASE_SERVICE_ROLE_KEY, or STORAGE_BACKEND=local|s3, to enable media uploads)")
+		return nil, nil
+	}
+	ss := giftmessage.NewSupabaseStorage(cfg.SupabaseURL, cfg.SupabaseStorageBucket, cfg.SupabaseServiceRoleKey)
+	bucketCtx, bucketCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := ss.BucketExists(bucketCtx); err != nil {
+		slog.Error("supabase storage: bucket inacessível — uploads de mídia vão falhar",
+			"bucket", cfg.SupabaseStorageBucket, "error", err)
+	}
+	bucketCancel()
+	slog.Info("supabase storage: enabled", "bucket", cfg.SupabaseStorageBucket)
+	return ss, nil
+}
+
 type logSender struct{}
 
 func (s *logSender) SendMessage(phone, message string) error {
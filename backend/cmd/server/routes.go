@@ -3,28 +3,45 @@ package main
 import (
 	"net/http"
 
+	"github.com/ferjunior7/parasempre/backend/internal/audit"
 	"github.com/ferjunior7/parasempre/backend/internal/auth"
+	"github.com/ferjunior7/parasempre/backend/internal/checkin"
 	"github.com/ferjunior7/parasempre/backend/internal/gift"
 	"github.com/ferjunior7/parasempre/backend/internal/giftmessage"
 	"github.com/ferjunior7/parasempre/backend/internal/guest"
+	"github.com/ferjunior7/parasempre/backend/internal/guestbook"
+	"github.com/ferjunior7/parasempre/backend/internal/household"
+	"github.com/ferjunior7/parasempre/backend/internal/invitation"
 	"github.com/ferjunior7/parasempre/backend/internal/middleware"
 	"github.com/ferjunior7/parasempre/backend/internal/payment"
 	"github.com/ferjunior7/parasempre/backend/internal/user"
+	"github.com/ferjunior7/parasempre/backend/internal/wall"
 )
 
 type routeDeps struct {
 	auth            *auth.Handler
 	devLogin        *auth.DevLoginHandler
 	guest           *guest.Handler
+	household       *household.Handler
+	invitation      *invitation.Handler
+	checkin         *checkin.Handler
 	gift            *gift.Handler
 	user            *user.Handler
+	audit           *audit.Handler
 	payment         *payment.Handler
 	giftMessage     *giftmessage.Handler
+	guestbook       *guestbook.Handler
+	wall            *wall.Hub
+	priceMonitor    *gift.PriceMonitor
 	jwt             *auth.JWTService
 	appEnv          string
 	purchaseLimiter func(http.Handler) http.Handler
 	webhookLimiter  func(http.Handler) http.Handler
 	messageLimiter  func(http.Handler) http.Handler
+	localMedia      *giftmessage.LocalStorage
+	uploads         *giftmessage.ResumableUploads
+	giftCache       *middleware.ResponseCache
+	messageCache    *middleware.ResponseCache
 }
 
 type routeGroup struct {
@@ -47,6 +64,9 @@ func (g routeGroup) handle(pattern string, fn http.HandlerFunc) {
 func registerRoutes(mux *http.ServeMux, d routeDeps) {
 	authMW := middleware.RequireAuth(d.jwt)
 	coupleMW := middleware.RequireRole("groom", "bride")
+	// Sessions opened by a printed invitation link reach RSVP and gift
+	// routes only; every other authMW route turns them away.
+	rsvpGiftMW := middleware.RequireScopedAuth(d.jwt, auth.ScopeInvitation)
 
 	otp := newGroup(mux)
 	otp.handle("POST /api/auth/otp/send", d.auth.HandleSendOTP)
@@ -57,7 +77,12 @@ func registerRoutes(mux *http.ServeMux, d routeDeps) {
 		dev.handle("POST /api/auth/dev-login", d.devLogin.Handle)
 	}
 
-	guests := newGroup(mux, authMW)
+	if d.invitation != nil {
+		invitationLogin := newGroup(mux)
+		invitationLogin.handle("POST /api/auth/invitation", d.invitation.HandleLogin)
+	}
+
+	guests := newGroup(mux, rsvpGiftMW)
 	guests.handle("GET /api/guests/my-family", d.guest.HandleListMyFamily)
 	guests.handle("PATCH /api/guests/{id}/confirm", d.guest.HandleConfirm)
 	guests.handle("PATCH /api/guests/{id}/cancel", d.guest.HandleCancel)
@@ -74,32 +99,94 @@ func registerRoutes(mux *http.ServeMux, d routeDeps) {
 	// created_by used to scope these implicitly; gate them on role explicitly.
 	guestsAdmin.handle("GET /api/guests", d.guest.HandleList)
 	guestsAdmin.handle("GET /api/guests/stats", d.guest.HandleStats)
+	guestsAdmin.handle("GET /api/guests/duplicates", d.guest.HandleListDuplicates)
 	guestsAdmin.handle("GET /api/guests/{id}", d.guest.HandleGet)
 	guestsAdmin.handle("POST /api/guests", d.guest.HandleCreate)
 	guestsAdmin.handle("PUT /api/guests/{id}", d.guest.HandleUpdate)
 	guestsAdmin.handle("DELETE /api/guests/{id}", d.guest.HandleDelete)
+	guestsAdmin.handle("POST /api/guests/{id}/merge", d.guest.HandleMerge)
+	guestsAdmin.handle("POST /api/guests/import/preview", d.guest.HandlePreviewImport)
 	guestsAdmin.handle("POST /api/guests/import", d.guest.HandleImport)
 
-	giftsPublic := newGroup(mux)
+	// Households are family_group numbers with a name, head and address;
+	// the guest family routes above work on them unchanged.
+	householdsAdmin := newGroup(mux, authMW, coupleMW)
+	householdsAdmin.handle("GET /api/households", d.household.HandleList)
+	householdsAdmin.handle("GET /api/households/{id}", d.household.HandleGet)
+	householdsAdmin.handle("POST /api/households", d.household.HandleCreate)
+	householdsAdmin.handle("PUT /api/households/{id}", d.household.HandleUpdate)
+	householdsAdmin.handle("DELETE /api/households/{id}", d.household.HandleDelete)
+	householdsAdmin.handle("POST /api/households/{id}/members", d.household.HandleMoveMembers)
+	householdsAdmin.handle("POST /api/households/{id}/split", d.household.HandleSplit)
+	householdsAdmin.handle("POST /api/households/{id}/merge", d.household.HandleMerge)
+
+	if d.invitation != nil {
+		invitationsAdmin := newGroup(mux, authMW, coupleMW)
+		invitationsAdmin.handle("GET /api/invitations", d.invitation.HandleList)
+		invitationsAdmin.handle("POST /api/invitations", d.invitation.HandleIssue)
+		invitationsAdmin.handle("POST /api/invitations/{id}/revoke", d.invitation.HandleRevoke)
+		invitationsAdmin.handle("GET /api/invitations/print", d.invitation.HandlePrint)
+	}
+
+	if d.checkin != nil {
+		// Staff sessions carry auth.ScopeCheckin, so these are the only
+		// routes they reach besides /api/users/me.
+		desk := newGroup(mux, middleware.RequireScopedAuth(d.jwt, auth.ScopeCheckin), middleware.RequireRole("groom", "bride", auth.RoleStaff))
+		desk.handle("POST /api/checkin/lookup", d.checkin.HandleLookup)
+		desk.handle("GET /api/checkin/search", d.checkin.HandleSearch)
+		desk.handle("POST /api/checkin/households/{id}", d.checkin.HandleCheckInHousehold)
+		desk.handle("POST /api/checkin/guests/{id}", d.checkin.HandleCheckInGuest)
+		desk.handle("DELETE /api/checkin/guests/{id}", d.checkin.HandleUndoCheckIn)
+		desk.handle("GET /api/checkin/stats", d.checkin.HandleStats)
+
+		codesAdmin := newGroup(mux, authMW, coupleMW)
+		codesAdmin.handle("GET /api/checkin/codes/guests/{id}", d.checkin.HandleGuestCode)
+		codesAdmin.handle("GET /api/checkin/codes/households/{id}", d.checkin.HandleHouseholdCode)
+
+		myCode := newGroup(mux, rsvpGiftMW)
+		myCode.handle("GET /api/me/checkin-code", d.checkin.HandleMyCode)
+	}
+
+	// Shared in WhatsApp groups, so hit in bursts: served from giftCache,
+	// which every successful gift or category mutation invalidates.
+	giftsPublic := newGroup(mux, d.giftCache.Middleware)
 	giftsPublic.handle("GET /api/gifts", d.gift.HandleList)
 	giftsPublic.handle("GET /api/gifts/{id}", d.gift.HandleGet)
+	giftsPublic.handle("GET /api/gift-categories", d.gift.HandleListCategories)
+	giftsPublic.handle("GET /api/gift-tags", d.gift.HandleListTags)
 
-	giftsAdmin := newGroup(mux, authMW, coupleMW)
+	giftsAdmin := newGroup(mux, authMW, coupleMW, d.giftCache.InvalidateOnSuccess)
 	giftsAdmin.handle("POST /api/gifts", d.gift.HandleCreate)
 	giftsAdmin.handle("PUT /api/gifts/{id}", d.gift.HandleUpdate)
 	giftsAdmin.handle("DELETE /api/gifts/{id}", d.gift.HandleDelete)
 	giftsAdmin.handle("POST /api/gifts/import/preview", d.gift.HandlePreviewImport)
 	giftsAdmin.handle("POST /api/gifts/import/commit", d.gift.HandleCommitImport)
 	giftsAdmin.handle("POST /api/gifts/scrape-preview", d.gift.HandleScrapePreview)
+	// URL import jobs live in the memory of the replica that started them;
+	// polling needs sticky sessions when there are several.
+	giftsAdmin.handle("POST /api/gifts/import/urls", d.gift.HandleURLImport)
+	giftsAdmin.handle("GET /api/gifts/import/urls/{jobID}", d.gift.HandleGetURLImport)
+	giftsAdmin.handle("PUT /api/gifts/order", d.gift.HandleReorder)
+	giftsAdmin.handle("POST /api/gift-categories", d.gift.HandleCreateCategory)
+	giftsAdmin.handle("PUT /api/gift-categories/{id}", d.gift.HandleUpdateCategory)
+	giftsAdmin.handle("DELETE /api/gift-categories/{id}", d.gift.HandleDeleteCategory)
+
+	if d.priceMonitor != nil {
+		// Sync rewrites listed prices, so it drops cached gift responses too.
+		pricesAdmin := newGroup(mux, authMW, coupleMW, d.giftCache.InvalidateOnSuccess)
+		pricesAdmin.handle("GET /api/admin/gift-prices", d.priceMonitor.HandleReport)
+		pricesAdmin.handle("POST /api/admin/gift-prices/sync", d.priceMonitor.HandleSync)
+		pricesAdmin.handle("GET /api/admin/gifts/{id}/price-history", d.priceMonitor.HandleHistory)
+	}
 
 	if d.payment != nil {
-		purchases := newGroup(mux, authMW, d.purchaseLimiter)
+		purchases := newGroup(mux, rsvpGiftMW, d.purchaseLimiter)
 		purchases.handle("POST /api/gifts/{id}/purchase", d.payment.HandleCreatePurchase)
 
 		webhooks := newGroup(mux, d.webhookLimiter)
 		webhooks.handle("POST /api/webhooks/mercadopago", d.payment.HandleWebhook)
 
-		me := newGroup(mux, authMW)
+		me := newGroup(mux, rsvpGiftMW)
 		me.handle("GET /api/me/purchases", d.payment.HandleListMyPurchases)
 		me.handle("GET /api/me/purchases/{id}", d.payment.HandleGetMyPurchase)
 
@@ -109,25 +196,86 @@ func registerRoutes(mux *http.ServeMux, d routeDeps) {
 	}
 
 	if d.giftMessage != nil {
-		messagesPublic := newGroup(mux)
+		messagesPublic := newGroup(mux, d.messageCache.Middleware)
 		messagesPublic.handle("GET /api/gifts/{id}/messages", d.giftMessage.HandleListByGift)
 
-		messagesAuth := newGroup(mux, authMW, d.messageLimiter)
+		messagesAuth := newGroup(mux, rsvpGiftMW, d.messageLimiter, d.messageCache.InvalidateOnSuccess)
 		messagesAuth.handle("POST /api/transactions/{id}/message", d.giftMessage.HandleCreate)
+		messagesAuth.handle("POST /api/transactions/{id}/message/upload-slot", d.giftMessage.HandleCreateUploadSlot)
 
-		messagesGet := newGroup(mux, authMW)
+		messagesGet := newGroup(mux, rsvpGiftMW)
 		messagesGet.handle("GET /api/transactions/{id}/message", d.giftMessage.HandleGetMine)
 
-		messagesAdmin := newGroup(mux, authMW, coupleMW)
+		messagesAdmin := newGroup(mux, authMW, coupleMW, d.messageCache.InvalidateOnSuccess)
 		messagesAdmin.handle("GET /api/admin/gift-messages", d.giftMessage.HandleAdminList)
 		messagesAdmin.handle("DELETE /api/admin/gift-messages/{id}", d.giftMessage.HandleAdminDelete)
+		messagesAdmin.handle("GET /api/admin/gift-messages/moderation", d.giftMessage.HandleModerationQueue)
+		messagesAdmin.handle("POST /api/admin/gift-messages/moderation", d.giftMessage.HandleModerate)
+		messagesAdmin.handle("POST /api/admin/gift-messages/{id}/replies", d.giftMessage.HandleCreateReply)
+		messagesAdmin.handle("PUT /api/admin/gift-message-replies/{id}", d.giftMessage.HandleUpdateReply)
+		messagesAdmin.handle("DELETE /api/admin/gift-message-replies/{id}", d.giftMessage.HandleDeleteReply)
+		messagesAdmin.handle("POST /api/admin/gift-messages/{id}/reactions", d.giftMessage.HandleAddReaction)
+		messagesAdmin.handle("DELETE /api/admin/gift-message-reactions/{id}", d.giftMessage.HandleDeleteReaction)
+	}
+
+	if d.guestbook != nil {
+		guestbookPublic := newGroup(mux)
+		guestbookPublic.handle("GET /api/guestbook", d.guestbook.HandleList)
+
+		guestbookSign := newGroup(mux, authMW, d.messageLimiter)
+		guestbookSign.handle("POST /api/guestbook", d.guestbook.HandleSign)
+
+		guestbookAuth := newGroup(mux, authMW)
+		guestbookAuth.handle("GET /api/guestbook/mine", d.guestbook.HandleGetMine)
+
+		guestbookAdmin := newGroup(mux, authMW, coupleMW)
+		guestbookAdmin.handle("GET /api/admin/guestbook/moderation", d.guestbook.HandleModerationQueue)
+		guestbookAdmin.handle("POST /api/admin/guestbook/moderation", d.guestbook.HandleModerate)
+		guestbookAdmin.handle("DELETE /api/admin/guestbook/{id}", d.guestbook.HandleAdminDelete)
+		// Guestbook entries and gift messages side by side.
+		guestbookAdmin.handle("GET /api/admin/messages", d.guestbook.HandleFeed)
+	}
+
+	if d.wall != nil {
+		// Public like the approved messages it shows: the projector browser
+		// has no session, and EventSource cannot send Authorization anyway.
+		wallPublic := newGroup(mux)
+		wallPublic.handle("GET "+wall.StreamRoute, d.wall.HandleStream)
 	}
 
-	users := newGroup(mux, authMW)
+	if d.uploads != nil {
+		// tus discovery only describes the server, so it needs no session.
+		uploadsPublic := newGroup(mux)
+		uploadsPublic.handle("OPTIONS "+giftmessage.ResumableUploadRoute, d.uploads.HandleOptions)
+
+		// Not behind messageLimiter: a single video takes many PATCHes.
+		uploads := newGroup(mux, rsvpGiftMW)
+		uploads.handle("POST "+giftmessage.ResumableUploadRoute, d.uploads.HandleCreate)
+		uploads.handle("HEAD "+giftmessage.ResumableUploadRoute+"/{uploadID}", d.uploads.HandleHead)
+		uploads.handle("PATCH "+giftmessage.ResumableUploadRoute+"/{uploadID}", d.uploads.HandlePatch)
+		uploads.handle("DELETE "+giftmessage.ResumableUploadRoute+"/{uploadID}", d.uploads.HandleDelete)
+	}
+
+	if d.localMedia != nil {
+		// Access is granted by the HMAC signature in the URL, not a session:
+		// read links are embedded in <img>/<video> tags and upload links are
+		// handed out by the upload-slot endpoint.
+		media := newGroup(mux)
+		media.handle("GET "+giftmessage.LocalMediaRoute+"{key...}", d.localMedia.HandleServe)
+		media.handle("PUT "+giftmessage.LocalMediaRoute+"{key...}", d.localMedia.HandleUpload)
+	}
+
+	users := newGroup(mux, middleware.RequireScopedAuth(d.jwt, auth.ScopeInvitation, auth.ScopeCheckin))
 	users.handle("GET /api/users/me", d.user.HandleMe)
 
 	usersAdmin := newGroup(mux, authMW, coupleMW)
 	usersAdmin.handle("GET /api/users/check", d.user.HandleCheck)
+	usersAdmin.handle("GET /api/users/staff", d.user.HandleListStaff)
+	usersAdmin.handle("POST /api/users/staff", d.user.HandleCreateStaff)
 	usersAdmin.handle("PATCH /api/users/{id}", d.user.HandleUpdate)
 	usersAdmin.handle("DELETE /api/users/{id}", d.user.HandleDelete)
+
+	auditAdmin := newGroup(mux, authMW, coupleMW)
+	auditAdmin.handle("GET /api/admin/audit", d.audit.HandleList)
+	auditAdmin.handle("GET /api/admin/audit/verify", d.audit.HandleVerify)
 }
@@ -0,0 +1,479 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ferjunior7/parasempre/backend/internal/database"
+)
+
+// Every audit_log row stores hash = sha256(prev_hash || payload), where
+// payload is the canonical JSON of the row's content and prev_hash is the
+// previous row's hash (32 zero bytes for the first one). Editing, deleting or
+// reordering a row breaks every link after it. audit_chain_head holds the
+// tip so appends serialize on a single row lock, and audit_checkpoints
+// periodically signs the tip with an Ed25519 key kept outside the database,
+// so even a rewrite of the whole chain is caught up to the last checkpoint.
+
+const verifyBatchSize = 1000
+
+var genesisHash = make([]byte, sha256.Size)
+
+type txBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type chainRow struct {
+	ID         int64
+	UserID     int64
+	Action     string
+	EntityType *string
+	EntityID   *int64
+	Details    []byte
+	CreatedAt  time.Time
+	PrevHash   []byte
+	Hash       []byte
+}
+
+type hashPayload struct {
+	ID         int64           `json:"id"`
+	UserID     int64           `json:"user_id"`
+	Action     string          `json:"action"`
+	EntityType *string         `json:"entity_type"`
+	EntityID   *int64          `json:"entity_id"`
+	Details    json.RawMessage `json:"details"`
+	CreatedAt  int64           `json:"created_at"`
+}
+
+// rowHash reads details as stored by Postgres, so the writer and the
+// verifier hash exactly the same bytes regardless of how the caller encoded
+// them.
+func rowHash(prev []byte, r chainRow) ([]byte, error) {
+	details, err := canonicalJSON(r.Details)
+	if err != nil {
+		return nil, fmt.Errorf("canonicalize details of row %d: %w", r.ID, err)
+	}
+	payload, err := json.Marshal(hashPayload{
+		ID:         r.ID,
+		UserID:     r.UserID,
+		Action:     r.Action,
+		EntityType: r.EntityType,
+		EntityID:   r.EntityID,
+		Details:    details,
+		CreatedAt:  r.CreatedAt.UnixMicro(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	h.Write(prev)
+	h.Write(payload)
+	return h.Sum(nil), nil
+}
+
+func canonicalJSON(raw []byte) (json.RawMessage, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// Append inserts an audit_log row and links it into the hash chain in one
+// transaction. db must be able to begin a transaction (*pgxpool.Pool, or a
+// pgx.Tx, which opens a savepoint). Unhashed rows past the head, such as
+// those an older replica writes during a rollout, are linked first, so the
+// head never moves past them.
+func Append(ctx context.Context, db database.DBTX, userID int64, action string, details map[string]any) error {
+	var detailsJSON []byte
+	if details != nil {
+		var err error
+		if detailsJSON, err = json.Marshal(details); err != nil {
+			return err
+		}
+	}
+	entityType, entityID := EntityOf(details)
+
+	b, ok := db.(txBeginner)
+	if !ok {
+		return errors.New("audit append: database handle cannot begin a transaction")
+	}
+	tx, err := b.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("audit append: begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var lastID int64
+	var prev []byte
+	if err := tx.QueryRow(ctx,
+		`SELECT last_id, last_hash FROM audit_chain_head WHERE id = 1 FOR UPDATE`,
+	).Scan(&lastID, &prev); err != nil {
+		return fmt.Errorf("audit append: lock chain head: %w", err)
+	}
+	if prev, _, err = sealPending(ctx, tx, lastID, prev); err != nil {
+		return err
+	}
+
+	row := chainRow{UserID: userID, Action: action, EntityType: entityType, EntityID: entityID}
+	if err := tx.QueryRow(ctx,
+		`INSERT INTO audit_log (user_id, action, details, entity_type, entity_id, prev_hash)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING id, details, created_at`,
+		userID, action, detailsJSON, entityType, entityID, prev,
+	).Scan(&row.ID, &row.Details, &row.CreatedAt); err != nil {
+		return fmt.Errorf("audit append: insert: %w", err)
+	}
+
+	hash, err := rowHash(prev, row)
+	if err != nil {
+		return err
+	}
+	if err := linkRow(ctx, tx, row.ID, prev, hash); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func linkRow(ctx context.Context, tx pgx.Tx, id int64, prev, hash []byte) error {
+	if _, err := tx.Exec(ctx,
+		`UPDATE audit_log SET prev_hash = $1, hash = $2 WHERE id = $3`, prev, hash, id,
+	); err != nil {
+		return fmt.Errorf("audit chain: store hash of row %d: %w", id, err)
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE audit_chain_head SET last_id = $1, last_hash = $2, updated_at = now() WHERE id = 1`, id, hash,
+	); err != nil {
+		return fmt.Errorf("audit chain: advance head to row %d: %w", id, err)
+	}
+	return nil
+}
+
+type Chain struct {
+	pool   *pgxpool.Pool
+	signer ed25519.PrivateKey
+}
+
+// NewChain manages sealing, checkpoints and verification. signer may be nil,
+// in which case checkpoints are disabled and existing ones are only checked
+// against the chain, not their signatures.
+func NewChain(pool *pgxpool.Pool, signer ed25519.PrivateKey) *Chain {
+	return &Chain{pool: pool, signer: signer}
+}
+
+// Seal links rows written without a hash onto the tip: those that predate
+// the chain, or that an older replica wrote during a rollout after the last
+// Append. Only rows past the head are touched; a missing hash behind it is
+// tampering and is left for Verify to report.
+func (c *Chain) Seal(ctx context.Context) (int, error) {
+	tx, err := c.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("audit seal: begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var lastID int64
+	var prev []byte
+	if err := tx.QueryRow(ctx,
+		`SELECT last_id, last_hash FROM audit_chain_head WHERE id = 1 FOR UPDATE`,
+	).Scan(&lastID, &prev); err != nil {
+		return 0, fmt.Errorf("audit seal: lock chain head: %w", err)
+	}
+	_, sealed, err := sealPending(ctx, tx, lastID, prev)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("audit seal: commit: %w", err)
+	}
+	return sealed, nil
+}
+
+// sealPending links the unhashed rows after lastID, in id order, onto prev.
+// The caller holds the audit_chain_head lock. It returns the new tip hash
+// and how many rows it linked.
+func sealPending(ctx context.Context, tx pgx.Tx, lastID int64, prev []byte) ([]byte, int, error) {
+	rows, err := tx.Query(ctx,
+		`SELECT id, user_id, action, entity_type, entity_id, details, created_at
+		   FROM audit_log
+		  WHERE id > $1 AND hash IS NULL
+		  ORDER BY id`, lastID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("audit chain: query unsealed rows: %w", err)
+	}
+	pending, err := pgx.CollectRows(rows, func(r pgx.CollectableRow) (chainRow, error) {
+		var cr chainRow
+		err := r.Scan(&cr.ID, &cr.UserID, &cr.Action, &cr.EntityType, &cr.EntityID, &cr.Details, &cr.CreatedAt)
+		return cr, err
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("audit chain: scan unsealed rows: %w", err)
+	}
+
+	for _, r := range pending {
+		hash, err := rowHash(prev, r)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := linkRow(ctx, tx, r.ID, prev, hash); err != nil {
+			return nil, 0, err
+		}
+		prev = hash
+	}
+	return prev, len(pending), nil
+}
+
+// Checkpoint signs the current tip. It returns nil when the tip has not
+// moved since the last checkpoint.
+func (c *Chain) Checkpoint(ctx context.Context) (*Checkpoint, error) {
+	if c.signer == nil {
+		return nil, errors.New("audit checkpoint: no signing key configured")
+	}
+
+	var cp Checkpoint
+	if err := c.pool.QueryRow(ctx,
+		`SELECT last_id, last_hash FROM audit_chain_head WHERE id = 1`,
+	).Scan(&cp.LastID, &cp.LastHash); err != nil {
+		return nil, fmt.Errorf("audit checkpoint: read head: %w", err)
+	}
+	if cp.LastID == 0 {
+		return nil, nil
+	}
+
+	var latest int64
+	if err := c.pool.QueryRow(ctx,
+		`SELECT COALESCE(MAX(last_id), 0) FROM audit_checkpoints`,
+	).Scan(&latest); err != nil {
+		return nil, fmt.Errorf("audit checkpoint: read latest: %w", err)
+	}
+	if latest >= cp.LastID {
+		return nil, nil
+	}
+
+	cp.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	cp.PublicKey = c.signer.Public().(ed25519.PublicKey)
+	cp.Signature = ed25519.Sign(c.signer, cp.message())
+	if err := c.pool.QueryRow(ctx,
+		`INSERT INTO audit_checkpoints (last_id, last_hash, public_key, signature, created_at)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id`,
+		cp.LastID, cp.LastHash, []byte(cp.PublicKey), cp.Signature, cp.CreatedAt,
+	).Scan(&cp.ID); err != nil {
+		return nil, fmt.Errorf("audit checkpoint: insert: %w", err)
+	}
+	return &cp, nil
+}
+
+// RunCheckpoints signs the tip every interval until ctx is done. Each
+// checkpoint is also logged so a copy survives outside the database.
+func (c *Chain) RunCheckpoints(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			cpCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			cp, err := c.Checkpoint(cpCtx)
+			cancel()
+			if err != nil {
+				slog.Error("audit.chain checkpoint failed", "error", err)
+				continue
+			}
+			if cp != nil {
+				slog.Info("audit.chain checkpoint signed", "checkpoint_id", cp.ID, "last_id", cp.LastID,
+					"last_hash", fmt.Sprintf("%x", cp.LastHash), "signature", fmt.Sprintf("%x", cp.Signature))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Verify walks the whole chain and the checkpoints and reports the first
+// broken link.
+func (c *Chain) Verify(ctx context.Context) (*VerifyReport, error) {
+	var headID int64
+	var headHash []byte
+	if err := c.pool.QueryRow(ctx,
+		`SELECT last_id, last_hash FROM audit_chain_head WHERE id = 1`,
+	).Scan(&headID, &headHash); err != nil {
+		return nil, fmt.Errorf("audit verify: read head: %w", err)
+	}
+
+	cpRows, err := c.pool.Query(ctx,
+		`SELECT id, last_id, last_hash, public_key, signature, created_at
+		   FROM audit_checkpoints ORDER BY last_id, id`)
+	if err != nil {
+		return nil, fmt.Errorf("audit verify: query checkpoints: %w", err)
+	}
+	checkpoints, err := pgx.CollectRows(cpRows, func(r pgx.CollectableRow) (Checkpoint, error) {
+		var cp Checkpoint
+		var pub []byte
+		err := r.Scan(&cp.ID, &cp.LastID, &cp.LastHash, &pub, &cp.Signature, &cp.CreatedAt)
+		cp.PublicKey = pub
+		return cp, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("audit verify: scan checkpoints: %w", err)
+	}
+
+	var trusted ed25519.PublicKey
+	if c.signer != nil {
+		trusted = c.signer.Public().(ed25519.PublicKey)
+	}
+	v := newVerifier(headID, checkpoints, trusted)
+
+	var afterID int64
+	for v.broken == nil {
+		rows, err := c.pool.Query(ctx,
+			`SELECT id, user_id, action, entity_type, entity_id, details, created_at, prev_hash, hash
+			   FROM audit_log
+			  WHERE id > $1
+			  ORDER BY id
+			  LIMIT $2`, afterID, verifyBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("audit verify: query rows: %w", err)
+		}
+		batch, err := pgx.CollectRows(rows, func(r pgx.CollectableRow) (chainRow, error) {
+			var cr chainRow
+			err := r.Scan(&cr.ID, &cr.UserID, &cr.Action, &cr.EntityType, &cr.EntityID, &cr.Details, &cr.CreatedAt, &cr.PrevHash, &cr.Hash)
+			return cr, err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("audit verify: scan rows: %w", err)
+		}
+		for _, r := range batch {
+			if err := v.check(r); err != nil {
+				return nil, err
+			}
+			if v.broken != nil {
+				break
+			}
+		}
+		if len(batch) < verifyBatchSize {
+			break
+		}
+		afterID = batch[len(batch)-1].ID
+	}
+	return v.finish(headHash), nil
+}
+
+// verifier holds the walk state so the linking rules can be tested without
+// a database.
+type verifier struct {
+	headID      int64
+	checkpoints []Checkpoint
+	trusted     ed25519.PublicKey
+	prev        []byte
+	lastID      int64
+	report      VerifyReport
+	broken      *BrokenLink
+}
+
+func newVerifier(headID int64, checkpoints []Checkpoint, trusted ed25519.PublicKey) *verifier {
+	return &verifier{headID: headID, checkpoints: checkpoints, trusted: trusted, prev: genesisHash}
+}
+
+func (v *verifier) fail(id int64, reason string) {
+	if v.broken == nil {
+		v.broken = &BrokenLink{ID: id, Reason: reason}
+	}
+}
+
+func (v *verifier) check(r chainRow) error {
+	if r.ID > v.headID {
+		if r.Hash == nil {
+			v.report.Unsealed++
+			return nil
+		}
+		v.fail(r.ID, "row is hashed but lies past the chain head")
+		return nil
+	}
+	if r.Hash == nil {
+		v.fail(r.ID, "row is missing its hash")
+		return nil
+	}
+	if !bytes.Equal(r.PrevHash, v.prev) {
+		v.fail(r.ID, "prev_hash does not match the previous row (row deleted, inserted or reordered)")
+		return nil
+	}
+	want, err := rowHash(v.prev, r)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(r.Hash, want) {
+		v.fail(r.ID, "hash does not match row content (row edited)")
+		return nil
+	}
+
+	for len(v.checkpoints) > 0 && v.checkpoints[0].LastID <= r.ID {
+		cp := v.checkpoints[0]
+		v.checkpoints = v.checkpoints[1:]
+		if cp.LastID != r.ID {
+			v.fail(cp.LastID, fmt.Sprintf("checkpoint %d refers to a row that no longer exists", cp.ID))
+			return nil
+		}
+		v.checkCheckpoint(cp, r.Hash)
+		if v.broken != nil {
+			return nil
+		}
+	}
+
+	v.prev = r.Hash
+	v.lastID = r.ID
+	v.report.Checked++
+	return nil
+}
+
+func (v *verifier) checkCheckpoint(cp Checkpoint, hash []byte) {
+	if !bytes.Equal(cp.LastHash, hash) {
+		v.fail(cp.LastID, fmt.Sprintf("checkpoint %d does not match the chain at this row", cp.ID))
+		return
+	}
+	if v.trusted == nil {
+		return
+	}
+	if !bytes.Equal(cp.PublicKey, v.trusted) {
+		v.fail(cp.LastID, fmt.Sprintf("checkpoint %d is signed by an unknown key", cp.ID))
+		return
+	}
+	if !ed25519.Verify(v.trusted, cp.message(), cp.Signature) {
+		v.fail(cp.LastID, fmt.Sprintf("checkpoint %d has an invalid signature", cp.ID))
+		return
+	}
+	v.report.CheckpointsVerified++
+	signed := cp
+	v.report.LastCheckpoint = &signed
+}
+
+func (v *verifier) finish(headHash []byte) *VerifyReport {
+	if v.broken == nil && len(v.checkpoints) > 0 {
+		cp := v.checkpoints[0]
+		v.fail(cp.LastID, fmt.Sprintf("checkpoint %d refers to a row that no longer exists", cp.ID))
+	}
+	if v.broken == nil && v.lastID != v.headID {
+		v.fail(v.headID, fmt.Sprintf("chain ends at row %d but the head points to row %d (rows deleted)", v.lastID, v.headID))
+	}
+	if v.broken == nil && v.headID > 0 && !bytes.Equal(v.prev, headHash) {
+		v.fail(v.headID, "head hash does not match the last row")
+	}
+	v.report.Broken = v.broken
+	v.report.OK = v.broken == nil
+	return &v.report
+}
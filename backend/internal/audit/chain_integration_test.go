@@ -0,0 +1,173 @@
+//go:build integration
+// +build integration
+
+package audit
+
+import (
+	"context"
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ferjunior7/parasempre/backend/internal/database"
+)
+
+func setupChain(t *testing.T) (*pgxpool.Pool, context.Context, int64) {
+	t.Helper()
+	pool := database.NewTestPool(t)
+	database.CleanTable(t, pool, "audit_checkpoints")
+	database.CleanTable(t, pool, "audit_log")
+	database.CleanTable(t, pool, "users")
+	ctx := context.Background()
+
+	if _, err := pool.Exec(ctx,
+		`UPDATE audit_chain_head SET last_id = 0, last_hash = decode(repeat('00', 32), 'hex') WHERE id = 1`,
+	); err != nil {
+		t.Fatalf("reset chain head failed: %v", err)
+	}
+
+	var userID int64
+	if err := pool.QueryRow(ctx,
+		`INSERT INTO users (uracf, role) VALUES ('TST01', 'groom') RETURNING id`,
+	).Scan(&userID); err != nil {
+		t.Fatalf("seed user failed: %v", err)
+	}
+
+	for i, action := range []string{"login", "guest.created", "gift.updated"} {
+		details := Entity(EntityGuest, int64(i+1), map[string]any{"name": "Ana", "n": 1.5})
+		if err := Append(ctx, pool, userID, action, details); err != nil {
+			t.Fatalf("Append %s failed: %v", action, err)
+		}
+	}
+	return pool, ctx, userID
+}
+
+func TestIntegrationChainVerify(t *testing.T) {
+	pool, ctx, _ := setupChain(t)
+	chain := NewChain(pool, nil)
+
+	report, err := chain.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !report.OK || report.Checked != 3 {
+		t.Fatalf("expected intact chain of 3 rows, got %+v", report)
+	}
+
+	var id int64
+	if err := pool.QueryRow(ctx, `SELECT id FROM audit_log WHERE action = 'guest.created'`).Scan(&id); err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	if _, err := pool.Exec(ctx,
+		`UPDATE audit_log SET details = jsonb_set(details, '{name}', '"Bia"') WHERE id = $1`, id,
+	); err != nil {
+		t.Fatalf("tamper failed: %v", err)
+	}
+
+	report, err = chain.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if report.OK || report.Broken == nil || report.Broken.ID != id {
+		t.Fatalf("expected break at row %d, got %+v", id, report)
+	}
+}
+
+func TestIntegrationChainSealAndCheckpoint(t *testing.T) {
+	pool, ctx, userID := setupChain(t)
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := NewChain(pool, priv)
+
+	// A row written by a pre-chain replica.
+	if _, err := pool.Exec(ctx,
+		`INSERT INTO audit_log (user_id, action, details) VALUES ($1, 'login', '{}')`, userID,
+	); err != nil {
+		t.Fatalf("insert unsealed row failed: %v", err)
+	}
+
+	report, err := chain.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !report.OK || report.Unsealed != 1 {
+		t.Fatalf("expected 1 unsealed row on an intact chain, got %+v", report)
+	}
+
+	sealed, err := chain.Seal(ctx)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if sealed != 1 {
+		t.Errorf("expected 1 sealed row, got %d", sealed)
+	}
+
+	cp, err := chain.Checkpoint(ctx)
+	if err != nil {
+		t.Fatalf("Checkpoint failed: %v", err)
+	}
+	if cp == nil {
+		t.Fatal("expected a checkpoint")
+	}
+	again, err := chain.Checkpoint(ctx)
+	if err != nil {
+		t.Fatalf("Checkpoint failed: %v", err)
+	}
+	if again != nil {
+		t.Errorf("expected no checkpoint when the tip has not moved, got %+v", again)
+	}
+
+	report, err = chain.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !report.OK || report.Checked != 4 || report.Unsealed != 0 || report.CheckpointsVerified != 1 {
+		t.Fatalf("expected sealed, checkpointed chain, got %+v", report)
+	}
+
+	if _, err := pool.Exec(ctx, `DELETE FROM audit_log WHERE id = $1`, cp.LastID); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	report, err = chain.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if report.OK {
+		t.Fatal("expected deleting the checkpointed row to break the chain")
+	}
+}
+
+func TestIntegrationChainAppendLinksRolloutRows(t *testing.T) {
+	pool, ctx, userID := setupChain(t)
+	chain := NewChain(pool, nil)
+
+	// A row written by an older replica between two appends of a newer one.
+	var legacyID int64
+	if err := pool.QueryRow(ctx,
+		`INSERT INTO audit_log (user_id, action, details) VALUES ($1, 'login', '{}') RETURNING id`, userID,
+	).Scan(&legacyID); err != nil {
+		t.Fatalf("insert unsealed row failed: %v", err)
+	}
+	if err := Append(ctx, pool, userID, "guest.updated", Entity(EntityGuest, 1, nil)); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	var hash []byte
+	if err := pool.QueryRow(ctx, `SELECT hash FROM audit_log WHERE id = $1`, legacyID).Scan(&hash); err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	if hash == nil {
+		t.Fatal("expected Append to link the older replica's row")
+	}
+
+	report, err := chain.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !report.OK || report.Checked != 5 || report.Unsealed != 0 {
+		t.Fatalf("expected intact chain of 5 rows, got %+v", report)
+	}
+}
@@ -0,0 +1,217 @@
+package audit
+
+import (
+	"crypto/ed25519"
+	"strings"
+	"testing"
+	"time"
+)
+
+// buildChain returns n correctly linked rows and the tip hash.
+func buildChain(t *testing.T, n int) ([]chainRow, []byte) {
+	t.Helper()
+	base := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	prev := genesisHash
+	rows := make([]chainRow, n)
+	for i := range rows {
+		entityID := int64(100 + i)
+		entityType := EntityTransaction
+		r := chainRow{
+			ID:         int64(i + 1),
+			UserID:     7,
+			Action:     "payment.purchase_created",
+			EntityType: &entityType,
+			EntityID:   &entityID,
+			Details:    []byte(`{"tx_id": 100, "amount_cents": 19990}`),
+			CreatedAt:  base.Add(time.Duration(i) * time.Minute),
+			PrevHash:   prev,
+		}
+		hash, err := rowHash(prev, r)
+		if err != nil {
+			t.Fatalf("rowHash: %v", err)
+		}
+		r.Hash = hash
+		rows[i] = r
+		prev = hash
+	}
+	return rows, prev
+}
+
+func runVerifier(t *testing.T, rows []chainRow, headID int64, headHash []byte, cps []Checkpoint, trusted ed25519.PublicKey) *VerifyReport {
+	t.Helper()
+	v := newVerifier(headID, cps, trusted)
+	for _, r := range rows {
+		if err := v.check(r); err != nil {
+			t.Fatalf("check: %v", err)
+		}
+		if v.broken != nil {
+			break
+		}
+	}
+	return v.finish(headHash)
+}
+
+func signedCheckpoint(key ed25519.PrivateKey, id, lastID int64, lastHash []byte) Checkpoint {
+	cp := Checkpoint{ID: id, LastID: lastID, LastHash: lastHash, CreatedAt: time.Now().UTC().Truncate(time.Microsecond)}
+	cp.PublicKey = key.Public().(ed25519.PublicKey)
+	cp.Signature = ed25519.Sign(key, cp.message())
+	return cp
+}
+
+func TestRowHashIgnoresDetailsFormatting(t *testing.T) {
+	r := chainRow{ID: 1, UserID: 1, Action: "login", CreatedAt: time.Unix(0, 0)}
+	r.Details = []byte(`{"b": 1, "a": "x"}`)
+	h1, err := rowHash(genesisHash, r)
+	if err != nil {
+		t.Fatalf("rowHash: %v", err)
+	}
+	r.Details = []byte(`{"a":"x","b":1}`)
+	h2, _ := rowHash(genesisHash, r)
+	if string(h1) != string(h2) {
+		t.Fatal("expected equivalent details to hash identically")
+	}
+
+	r.Details = []byte(`{"a":"y","b":1}`)
+	h3, _ := rowHash(genesisHash, r)
+	if string(h1) == string(h3) {
+		t.Fatal("expected different details to change the hash")
+	}
+}
+
+func TestVerifierValidChain(t *testing.T) {
+	rows, tip := buildChain(t, 5)
+	report := runVerifier(t, rows, 5, tip, nil, nil)
+	if !report.OK || report.Checked != 5 {
+		t.Fatalf("expected valid chain of 5, got %+v", report)
+	}
+}
+
+func TestVerifierDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name       string
+		mutate     func(rows []chainRow) ([]chainRow, int64)
+		wantID     int64
+		wantReason string
+	}{
+		{
+			name: "edited details",
+			mutate: func(rows []chainRow) ([]chainRow, int64) {
+				rows[2].Details = []byte(`{"tx_id": 100, "amount_cents": 1}`)
+				return rows, 5
+			},
+			wantID: 3, wantReason: "row edited",
+		},
+		{
+			name: "edited action",
+			mutate: func(rows []chainRow) ([]chainRow, int64) {
+				rows[0].Action = "payment.purchase_failed"
+				return rows, 5
+			},
+			wantID: 1, wantReason: "row edited",
+		},
+		{
+			name: "deleted middle row",
+			mutate: func(rows []chainRow) ([]chainRow, int64) {
+				return append(rows[:1], rows[2:]...), 5
+			},
+			wantID: 3, wantReason: "prev_hash does not match",
+		},
+		{
+			name: "hash removed",
+			mutate: func(rows []chainRow) ([]chainRow, int64) {
+				rows[3].Hash = nil
+				return rows, 5
+			},
+			wantID: 4, wantReason: "missing its hash",
+		},
+		{
+			name: "tail deleted",
+			mutate: func(rows []chainRow) ([]chainRow, int64) {
+				return rows[:3], 5
+			},
+			wantID: 5, wantReason: "rows deleted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, tip := buildChain(t, 5)
+			rows, headID := tt.mutate(rows)
+			report := runVerifier(t, rows, headID, tip, nil, nil)
+			if report.OK || report.Broken == nil {
+				t.Fatalf("expected broken chain, got %+v", report)
+			}
+			if report.Broken.ID != tt.wantID || !strings.Contains(report.Broken.Reason, tt.wantReason) {
+				t.Fatalf("expected break at %d (%s), got %+v", tt.wantID, tt.wantReason, report.Broken)
+			}
+		})
+	}
+}
+
+func TestVerifierCountsUnsealedTail(t *testing.T) {
+	rows, tip := buildChain(t, 3)
+	rows = append(rows, chainRow{ID: 4, UserID: 7, Action: "login", CreatedAt: time.Now()})
+	report := runVerifier(t, rows, 3, tip, nil, nil)
+	if !report.OK || report.Unsealed != 1 || report.Checked != 3 {
+		t.Fatalf("expected 3 checked and 1 unsealed, got %+v", report)
+	}
+}
+
+func TestVerifierCheckpoints(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	otherSeed := make([]byte, ed25519.SeedSize)
+	otherSeed[0] = 1
+	other := ed25519.NewKeyFromSeed(otherSeed)
+	trusted := key.Public().(ed25519.PublicKey)
+
+	t.Run("valid", func(t *testing.T) {
+		rows, tip := buildChain(t, 4)
+		cps := []Checkpoint{signedCheckpoint(key, 1, 2, rows[1].Hash), signedCheckpoint(key, 2, 4, tip)}
+		report := runVerifier(t, rows, 4, tip, cps, trusted)
+		if !report.OK || report.CheckpointsVerified != 2 || report.LastCheckpoint.ID != 2 {
+			t.Fatalf("expected 2 verified checkpoints, got %+v", report)
+		}
+	})
+
+	t.Run("chain rewritten after checkpoint", func(t *testing.T) {
+		rows, _ := buildChain(t, 4)
+		cp := signedCheckpoint(key, 1, 2, rows[1].Hash)
+		// An attacker edits row 2 and recomputes every hash from there on.
+		rows[1].Details = []byte(`{"tx_id": 100, "amount_cents": 1}`)
+		prev := rows[0].Hash
+		for i := 1; i < len(rows); i++ {
+			rows[i].PrevHash = prev
+			rows[i].Hash, _ = rowHash(prev, rows[i])
+			prev = rows[i].Hash
+		}
+		report := runVerifier(t, rows, 4, prev, []Checkpoint{cp}, trusted)
+		if report.OK || report.Broken.ID != 2 || !strings.Contains(report.Broken.Reason, "checkpoint 1") {
+			t.Fatalf("expected checkpoint mismatch at row 2, got %+v", report)
+		}
+	})
+
+	t.Run("forged signature", func(t *testing.T) {
+		rows, tip := buildChain(t, 2)
+		cp := signedCheckpoint(other, 1, 2, tip)
+		report := runVerifier(t, rows, 2, tip, []Checkpoint{cp}, trusted)
+		if report.OK || !strings.Contains(report.Broken.Reason, "unknown key") {
+			t.Fatalf("expected unknown key, got %+v", report)
+		}
+
+		cp = signedCheckpoint(key, 1, 2, tip)
+		cp.Signature[0] ^= 0xff
+		report = runVerifier(t, rows, 2, tip, []Checkpoint{cp}, trusted)
+		if report.OK || !strings.Contains(report.Broken.Reason, "invalid signature") {
+			t.Fatalf("expected invalid signature, got %+v", report)
+		}
+	})
+
+	t.Run("checkpointed row deleted", func(t *testing.T) {
+		rows, tip := buildChain(t, 3)
+		cp := signedCheckpoint(key, 1, 5, tip)
+		report := runVerifier(t, rows, 3, tip, []Checkpoint{cp}, trusted)
+		if report.OK || !strings.Contains(report.Broken.Reason, "no longer exists") {
+			t.Fatalf("expected missing checkpoint row, got %+v", report)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Entity tags details with the entity an action applies to, allocating the
+// map when nil. user.PostgresRepository.LogAction lifts these keys into the
+// indexed entity_type/entity_id columns the read API filters on.
+func Entity(entityType string, id int64, details map[string]any) map[string]any {
+	if details == nil {
+		details = map[string]any{}
+	}
+	details[detailEntityType] = entityType
+	details[detailEntityID] = id
+	return details
+}
+
+// EntityOf reads back the tags set by Entity. Both are nil for untagged
+// actions such as "login".
+func EntityOf(details map[string]any) (*string, *int64) {
+	entityType, ok := details[detailEntityType].(string)
+	if !ok || entityType == "" {
+		return nil, nil
+	}
+	var id int64
+	switch v := details[detailEntityID].(type) {
+	case int64:
+		id = v
+	case int:
+		id = int64(v)
+	default:
+		return &entityType, nil
+	}
+	return &entityType, &id
+}
+
+// Diff compares the JSON representations of before and after and returns the
+// fields whose values differ, keyed by JSON name. Either side may be nil, in
+// which case every field of the other side is reported. Fields listed in
+// ignore (e.g. "updated_at") are skipped.
+func Diff(before, after any, ignore ...string) map[string]Change {
+	b, a := toFields(before), toFields(after)
+	skip := make(map[string]bool, len(ignore))
+	for _, f := range ignore {
+		skip[f] = true
+	}
+
+	changes := map[string]Change{}
+	for k, bv := range b {
+		if skip[k] {
+			continue
+		}
+		av, ok := a[k]
+		if !ok || !reflect.DeepEqual(bv, av) {
+			changes[k] = Change{Before: bv, After: av}
+		}
+	}
+	for k, av := range a {
+		if skip[k] {
+			continue
+		}
+		if _, ok := b[k]; !ok {
+			changes[k] = Change{After: av}
+		}
+	}
+	return changes
+}
+
+func toFields(v any) map[string]any {
+	if v == nil {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+	return fields
+}
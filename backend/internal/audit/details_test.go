@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+type snapshot struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Note      *string   `json:"note,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func TestDiff(t *testing.T) {
+	note := "frágil"
+	before := &snapshot{ID: 1, Name: "Panela", UpdatedAt: time.Now()}
+	after := &snapshot{ID: 1, Name: "Panela Inox", Note: &note, UpdatedAt: time.Now().Add(time.Minute)}
+
+	got := Diff(before, after, "updated_at")
+	if len(got) != 2 {
+		t.Fatalf("expected 2 changes, got %v", got)
+	}
+	if got["name"].Before != "Panela" || got["name"].After != "Panela Inox" {
+		t.Errorf("unexpected name change: %+v", got["name"])
+	}
+	if got["note"].Before != nil || got["note"].After != "frágil" {
+		t.Errorf("unexpected note change: %+v", got["note"])
+	}
+}
+
+func TestDiffNilSides(t *testing.T) {
+	s := &snapshot{ID: 7, Name: "Jogo de taças"}
+
+	created := Diff(nil, s, "updated_at")
+	if created["id"].Before != nil || created["id"].After != float64(7) {
+		t.Errorf("unexpected create diff: %v", created)
+	}
+
+	var none *snapshot
+	deleted := Diff(s, none, "updated_at")
+	if deleted["name"].Before != "Jogo de taças" || deleted["name"].After != nil {
+		t.Errorf("unexpected delete diff: %v", deleted)
+	}
+	if _, ok := deleted["updated_at"]; ok {
+		t.Error("expected ignored field to be skipped")
+	}
+}
+
+func TestDiffNoChanges(t *testing.T) {
+	s := snapshot{ID: 1, Name: "Panela"}
+	if got := Diff(s, s); len(got) != 0 {
+		t.Fatalf("expected no changes, got %v", got)
+	}
+}
+
+func TestEntityRoundTrip(t *testing.T) {
+	details := Entity(EntityGift, 42, nil)
+	entityType, entityID := EntityOf(details)
+	if entityType == nil || *entityType != EntityGift {
+		t.Fatalf("expected entity_type gift, got %v", entityType)
+	}
+	if entityID == nil || *entityID != 42 {
+		t.Fatalf("expected entity_id 42, got %v", entityID)
+	}
+
+	if et, id := EntityOf(map[string]any{"tx_id": 1}); et != nil || id != nil {
+		t.Fatalf("expected untagged details to yield nils, got %v %v", et, id)
+	}
+	if et, id := EntityOf(nil); et != nil || id != nil {
+		t.Fatalf("expected nil details to yield nils, got %v %v", et, id)
+	}
+}
@@ -0,0 +1,99 @@
+package audit
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/httputil"
+)
+
+const dateLayout = "2006-01-02"
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// HandleList serves GET /api/admin/audit. Filters: user_id, action (prefix,
+// e.g. "payment." or "guest.updated"), entity_type, entity_id, from and to.
+// Dates take RFC 3339 or YYYY-MM-DD; "to" is exclusive, and a bare date
+// covers that whole day.
+func (h *Handler) HandleList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var filter ListFilter
+	if v := q.Get("user_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			httputil.WriteError(w, r, apperror.Validation("invalid user_id"))
+			return
+		}
+		filter.UserID = &id
+	}
+	if v := strings.TrimSpace(q.Get("action")); v != "" {
+		filter.ActionPrefix = &v
+	}
+	if v := strings.TrimSpace(q.Get("entity_type")); v != "" {
+		filter.EntityType = &v
+	}
+	if v := q.Get("entity_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			httputil.WriteError(w, r, apperror.Validation("invalid entity_id"))
+			return
+		}
+		filter.EntityID = &id
+	}
+	if v := q.Get("from"); v != "" {
+		t, _, err := parseTime(v)
+		if err != nil {
+			httputil.WriteError(w, r, apperror.Validation("invalid from date"))
+			return
+		}
+		filter.From = &t
+	}
+	if v := q.Get("to"); v != "" {
+		t, dateOnly, err := parseTime(v)
+		if err != nil {
+			httputil.WriteError(w, r, apperror.Validation("invalid to date"))
+			return
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		filter.To = &t
+	}
+	limit, _ := strconv.Atoi(q.Get("limit"))
+
+	resp, err := h.svc.List(r.Context(), filter, q.Get("cursor"), limit)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to list audit log", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+func parseTime(v string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse(dateLayout, v)
+	return t, true, err
+}
+
+// HandleVerify serves GET /api/admin/audit/verify. A broken chain is still a
+// 200: the report is the answer, with ok=false and the first broken link.
+func (h *Handler) HandleVerify(w http.ResponseWriter, r *http.Request) {
+	report, err := h.svc.VerifyChain(r.Context())
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to verify audit chain", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandlerListParsesFilters(t *testing.T) {
+	var got ListFilter
+	h := NewHandler(NewService(&mockRepository{listFn: func(_ context.Context, f ListFilter, _ int) ([]Entry, error) {
+		got = f
+		return entries(3), nil
+	}}, nil))
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/api/admin/audit?user_id=4&action=guest.&entity_type=guest&entity_id=12&from=2026-05-01&to=2026-05-02", nil)
+	w := httptest.NewRecorder()
+	h.HandleList(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got.UserID == nil || *got.UserID != 4 {
+		t.Errorf("expected user_id 4, got %v", got.UserID)
+	}
+	if got.ActionPrefix == nil || *got.ActionPrefix != "guest." {
+		t.Errorf("expected action prefix, got %v", got.ActionPrefix)
+	}
+	if got.EntityType == nil || *got.EntityType != "guest" || got.EntityID == nil || *got.EntityID != 12 {
+		t.Errorf("expected entity guest/12, got %v/%v", got.EntityType, got.EntityID)
+	}
+	wantTo := time.Date(2026, 5, 3, 0, 0, 0, 0, time.UTC)
+	if got.To == nil || !got.To.Equal(wantTo) {
+		t.Errorf("expected date-only to to cover the whole day, got %v", got.To)
+	}
+
+	var resp PagedResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if len(resp.Data) != 1 || resp.NextCursor != nil {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestHandlerListRejectsBadParams(t *testing.T) {
+	h := NewHandler(NewService(&mockRepository{listFn: func(context.Context, ListFilter, int) ([]Entry, error) {
+		return []Entry{}, nil
+	}}, nil))
+
+	for _, q := range []string{"user_id=abc", "entity_id=x", "from=yesterday", "to=2026-13-01"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/audit?"+q, nil)
+		w := httptest.NewRecorder()
+		h.HandleList(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, w.Code)
+		}
+	}
+}
+
+func TestHandlerVerify(t *testing.T) {
+	h := NewHandler(NewService(nil, stubVerifier{report: &VerifyReport{OK: true, Checked: 12}}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/audit/verify", nil)
+	w := httptest.NewRecorder()
+	h.HandleVerify(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var report VerifyReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if !report.OK || report.Checked != 12 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
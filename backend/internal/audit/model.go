@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Entity types stored in audit_log.entity_type.
+const (
+	EntityGuest       = "guest"
+	EntityGift        = "gift"
+	EntityUser        = "user"
+	EntityTransaction = "gift_transaction"
+	EntityGiftMessage = "gift_message"
+	EntityGuestbook   = "guestbook_entry"
+	EntityHousehold   = "household"
+)
+
+const (
+	detailEntityType = "entity_type"
+	detailEntityID   = "entity_id"
+)
+
+type Entry struct {
+	ID         int64           `json:"id"`
+	UserID     int64           `json:"user_id"`
+	UserURACF  string          `json:"user_uracf"`
+	Action     string          `json:"action"`
+	EntityType *string         `json:"entity_type,omitempty"`
+	EntityID   *int64          `json:"entity_id,omitempty"`
+	Details    json.RawMessage `json:"details,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type ListFilter struct {
+	UserID       *int64
+	ActionPrefix *string
+	EntityType   *string
+	EntityID     *int64
+	From         *time.Time
+	To           *time.Time
+	BeforeID     *int64
+}
+
+type PagedResponse struct {
+	Data       []Entry `json:"data"`
+	Limit      int     `json:"limit"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+// Change is one field of a before/after diff. A nil side means the entity
+// did not exist (create) or no longer exists (delete).
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Checkpoint is a signed snapshot of the chain tip.
+type Checkpoint struct {
+	ID        int64     `json:"id"`
+	LastID    int64     `json:"last_id"`
+	LastHash  []byte    `json:"last_hash"`
+	PublicKey []byte    `json:"public_key"`
+	Signature []byte    `json:"signature"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (c Checkpoint) message() []byte {
+	return fmt.Appendf(nil, "parasempre.audit.checkpoint.v1\n%d\n%x\n%d", c.LastID, c.LastHash, c.CreatedAt.UnixMicro())
+}
+
+type BrokenLink struct {
+	ID     int64  `json:"id"`
+	Reason string `json:"reason"`
+}
+
+type VerifyReport struct {
+	OK                  bool        `json:"ok"`
+	Checked             int         `json:"checked"`
+	Unsealed            int         `json:"unsealed"`
+	CheckpointsVerified int         `json:"checkpoints_verified"`
+	LastCheckpoint      *Checkpoint `json:"last_checkpoint,omitempty"`
+	Broken              *BrokenLink `json:"broken,omitempty"`
+}
@@ -0,0 +1,33 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
+)
+
+// Logger writes one audit_log row. user.PostgresRepository implements it;
+// services take it as a dependency and write through Record.
+type Logger interface {
+	LogAction(ctx context.Context, userID int64, action string, details map[string]any) error
+}
+
+// Record writes an entry attributed to the user in the request context (see
+// reqctx.UserID). Auditing is best effort: nothing is written for a nil
+// logger or an anonymous request, and a failed write is logged instead of
+// returned, so it never fails the operation being audited.
+func Record(ctx context.Context, l Logger, action string, details map[string]any) {
+	RecordAs(ctx, l, reqctx.UserID(ctx), action, details)
+}
+
+// RecordAs is Record for flows whose actor is not the session user, such as
+// payment webhooks.
+func RecordAs(ctx context.Context, l Logger, userID int64, action string, details map[string]any) {
+	if l == nil || userID == 0 {
+		return
+	}
+	if err := l.LogAction(ctx, userID, action, reqctx.AuditDetails(ctx, details)); err != nil {
+		slog.ErrorContext(ctx, "audit.record: write failed", "action", action, "user_id", userID, "error", err)
+	}
+}
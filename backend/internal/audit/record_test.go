@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
+)
+
+type recordedAction struct {
+	userID  int64
+	action  string
+	details map[string]any
+}
+
+type fakeLogger struct {
+	calls []recordedAction
+	err   error
+}
+
+func (f *fakeLogger) LogAction(_ context.Context, userID int64, action string, details map[string]any) error {
+	f.calls = append(f.calls, recordedAction{userID, action, details})
+	return f.err
+}
+
+func TestRecord(t *testing.T) {
+	l := &fakeLogger{}
+	ctx := reqctx.WithRequestID(reqctx.WithUserID(context.Background(), 7), "req-1")
+
+	Record(ctx, l, "gift.created", map[string]any{"name": "Panela"})
+	if len(l.calls) != 1 || l.calls[0].userID != 7 || l.calls[0].action != "gift.created" {
+		t.Fatalf("expected one gift.created entry by user 7, got %+v", l.calls)
+	}
+	if l.calls[0].details["request_id"] != "req-1" {
+		t.Errorf("expected the request id stamped on details, got %v", l.calls[0].details)
+	}
+
+	Record(context.Background(), l, "gift.created", nil)
+	if len(l.calls) != 1 {
+		t.Fatalf("expected nothing recorded for an anonymous request, got %+v", l.calls)
+	}
+
+	Record(ctx, nil, "gift.created", nil)
+
+	l.err = errors.New("db down")
+	RecordAs(context.Background(), l, 9, "payment.approved", nil)
+	if len(l.calls) != 2 || l.calls[1].userID != 9 {
+		t.Fatalf("expected an entry attributed to user 9, got %+v", l.calls)
+	}
+}
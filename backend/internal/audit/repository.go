@@ -0,0 +1,7 @@
+package audit
+
+import "context"
+
+type Repository interface {
+	List(ctx context.Context, filter ListFilter, limit int) ([]Entry, error)
+}
@@ -0,0 +1,97 @@
+//go:build integration
+// +build integration
+
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ferjunior7/parasempre/backend/internal/database"
+)
+
+func setup(t *testing.T) (*PostgresRepository, context.Context, int64) {
+	t.Helper()
+	pool := database.NewTestPool(t)
+	database.CleanTable(t, pool, "audit_log")
+	database.CleanTable(t, pool, "users")
+	ctx := context.Background()
+
+	var userID int64
+	if err := pool.QueryRow(ctx,
+		`INSERT INTO users (uracf, role) VALUES ('TST01', 'groom') RETURNING id`,
+	).Scan(&userID); err != nil {
+		t.Fatalf("seed user failed: %v", err)
+	}
+
+	seed := []struct {
+		action     string
+		entityType *string
+		entityID   *int64
+	}{
+		{"login", nil, nil},
+		{"guest.created", ptr(EntityGuest), ptr(int64(1))},
+		{"guest.updated", ptr(EntityGuest), ptr(int64(1))},
+		{"gift.created", ptr(EntityGift), ptr(int64(1))},
+		{"payment.purchase_created", ptr(EntityTransaction), ptr(int64(5))},
+	}
+	for _, s := range seed {
+		if _, err := pool.Exec(ctx,
+			`INSERT INTO audit_log (user_id, action, details, entity_type, entity_id) VALUES ($1, $2, '{}', $3, $4)`,
+			userID, s.action, s.entityType, s.entityID); err != nil {
+			t.Fatalf("seed audit_log failed: %v", err)
+		}
+	}
+
+	return NewPostgresRepository(pool), ctx, userID
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestIntegrationListFilters(t *testing.T) {
+	repo, ctx, userID := setup(t)
+
+	all, err := repo.List(ctx, ListFilter{}, 10)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(all) != 5 {
+		t.Fatalf("expected 5 entries, got %d", len(all))
+	}
+	if all[0].Action != "payment.purchase_created" || all[0].UserURACF != "TST01" {
+		t.Errorf("expected newest first with uracf, got %+v", all[0])
+	}
+
+	byPrefix, err := repo.List(ctx, ListFilter{ActionPrefix: ptr("guest.")}, 10)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(byPrefix) != 2 {
+		t.Errorf("expected 2 guest entries, got %d", len(byPrefix))
+	}
+
+	// "_" must match literally, not as a LIKE wildcard.
+	wildcard, err := repo.List(ctx, ListFilter{ActionPrefix: ptr("payment.purchase_")}, 10)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(wildcard) != 1 {
+		t.Errorf("expected 1 payment entry, got %d", len(wildcard))
+	}
+
+	byEntity, err := repo.List(ctx, ListFilter{EntityType: ptr(EntityGuest), EntityID: ptr(int64(1)), UserID: &userID}, 10)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(byEntity) != 2 {
+		t.Errorf("expected 2 entries for guest 1, got %d", len(byEntity))
+	}
+
+	page, err := repo.List(ctx, ListFilter{BeforeID: &all[1].ID}, 10)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(page) != 3 || page[0].ID != all[2].ID {
+		t.Errorf("expected keyset page to resume after id %d, got %+v", all[1].ID, page)
+	}
+}
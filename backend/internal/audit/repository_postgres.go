@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ferjunior7/parasempre/backend/internal/database"
+)
+
+type PostgresRepository struct {
+	db database.DBTX
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{db: pool}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (r *PostgresRepository) List(ctx context.Context, filter ListFilter, limit int) ([]Entry, error) {
+	query := `SELECT a.id, a.user_id, COALESCE(u.uracf, ''), a.action, a.entity_type, a.entity_id, a.details, a.created_at
+	            FROM audit_log a
+	            LEFT JOIN users u ON u.id = a.user_id
+	           WHERE true`
+	args := []any{}
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		query += ` AND a.user_id = $` + fmt.Sprint(len(args))
+	}
+	if filter.ActionPrefix != nil {
+		args = append(args, likeEscaper.Replace(*filter.ActionPrefix)+"%")
+		query += ` AND a.action LIKE $` + fmt.Sprint(len(args)) + ` ESCAPE '\'`
+	}
+	if filter.EntityType != nil {
+		args = append(args, *filter.EntityType)
+		query += ` AND a.entity_type = $` + fmt.Sprint(len(args))
+	}
+	if filter.EntityID != nil {
+		args = append(args, *filter.EntityID)
+		query += ` AND a.entity_id = $` + fmt.Sprint(len(args))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		query += ` AND a.created_at >= $` + fmt.Sprint(len(args))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		query += ` AND a.created_at < $` + fmt.Sprint(len(args))
+	}
+	if filter.BeforeID != nil {
+		args = append(args, *filter.BeforeID)
+		query += ` AND a.id < $` + fmt.Sprint(len(args))
+	}
+	args = append(args, limit)
+	query += ` ORDER BY a.id DESC LIMIT $` + fmt.Sprint(len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "audit.repo list: query failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.ID, &e.UserID, &e.UserURACF, &e.Action, &e.EntityType, &e.EntityID, &e.Details, &e.CreatedAt); err != nil {
+			slog.ErrorContext(ctx, "audit.repo list: scan failed", "error", err)
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+// ChainVerifier walks the audit_log hash chain (see Chain.Verify).
+type ChainVerifier interface {
+	Verify(ctx context.Context) (*VerifyReport, error)
+}
+
+type Service struct {
+	repo  Repository
+	chain ChainVerifier
+}
+
+func NewService(repo Repository, chain ChainVerifier) *Service {
+	return &Service{repo: repo, chain: chain}
+}
+
+// List pages audit_log newest first. The cursor is opaque to clients; it
+// encodes the last id of the previous page so paging stays stable while new
+// entries are appended.
+func (s *Service) List(ctx context.Context, filter ListFilter, cursor string, limit int) (*PagedResponse, error) {
+	if limit < 1 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, apperror.Validation("from must be before to")
+	}
+	if cursor != "" {
+		id, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, apperror.Validation("invalid cursor")
+		}
+		filter.BeforeID = &id
+	}
+
+	entries, err := s.repo.List(ctx, filter, limit+1)
+	if err != nil {
+		slog.ErrorContext(ctx, "audit.service list: failed", "error", err)
+		return nil, apperror.Internal("failed to list audit log", err)
+	}
+
+	resp := &PagedResponse{Data: entries, Limit: limit}
+	if len(entries) > limit {
+		resp.Data = entries[:limit]
+		next := encodeCursor(resp.Data[limit-1].ID)
+		resp.NextCursor = &next
+	}
+	return resp, nil
+}
+
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if id < 1 {
+		return 0, errors.New("cursor id out of range")
+	}
+	return id, nil
+}
+
+func (s *Service) VerifyChain(ctx context.Context) (*VerifyReport, error) {
+	report, err := s.chain.Verify(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "audit.service verify_chain: failed", "error", err)
+		return nil, apperror.Internal("failed to verify audit chain", err)
+	}
+	if !report.OK {
+		slog.WarnContext(ctx, "audit.service verify_chain: broken link", "id", report.Broken.ID, "reason", report.Broken.Reason)
+	}
+	return report, nil
+}
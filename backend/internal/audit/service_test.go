@@ -0,0 +1,148 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+)
+
+type mockRepository struct {
+	listFn func(ctx context.Context, filter ListFilter, limit int) ([]Entry, error)
+}
+
+func (m *mockRepository) List(ctx context.Context, filter ListFilter, limit int) ([]Entry, error) {
+	return m.listFn(ctx, filter, limit)
+}
+
+func entries(ids ...int64) []Entry {
+	out := make([]Entry, len(ids))
+	for i, id := range ids {
+		out[i] = Entry{ID: id, UserID: 1, Action: "guest.updated", CreatedAt: time.Now()}
+	}
+	return out
+}
+
+func assertAppError(t *testing.T, err error, wantCode int, wantMsg string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected error containing %q, got nil", wantMsg)
+	}
+	ae, ok := apperror.IsAppError(err)
+	if !ok {
+		t.Fatalf("expected AppError, got %T: %v", err, err)
+	}
+	if ae.Code != wantCode {
+		t.Fatalf("expected code %d, got %d", wantCode, ae.Code)
+	}
+	if !strings.Contains(ae.Message, wantMsg) {
+		t.Fatalf("expected message containing %q, got %q", wantMsg, ae.Message)
+	}
+}
+
+func TestServiceListPaginates(t *testing.T) {
+	var gotLimit int
+	var gotBefore *int64
+	svc := NewService(&mockRepository{listFn: func(_ context.Context, f ListFilter, limit int) ([]Entry, error) {
+		gotLimit, gotBefore = limit, f.BeforeID
+		if f.BeforeID == nil {
+			return entries(10, 9, 8), nil
+		}
+		return entries(7), nil
+	}}, nil)
+
+	first, err := svc.List(context.Background(), ListFilter{}, "", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotLimit != 3 {
+		t.Errorf("expected repo to be asked for limit+1, got %d", gotLimit)
+	}
+	if len(first.Data) != 2 || first.NextCursor == nil {
+		t.Fatalf("expected 2 entries and a next cursor, got %d / %v", len(first.Data), first.NextCursor)
+	}
+
+	second, err := svc.List(context.Background(), ListFilter{}, *first.NextCursor, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotBefore == nil || *gotBefore != 9 {
+		t.Fatalf("expected cursor to resume before id 9, got %v", gotBefore)
+	}
+	if len(second.Data) != 1 || second.NextCursor != nil {
+		t.Fatalf("expected last page without cursor, got %d / %v", len(second.Data), second.NextCursor)
+	}
+}
+
+func TestServiceListClampsLimit(t *testing.T) {
+	var gotLimit int
+	svc := NewService(&mockRepository{listFn: func(_ context.Context, _ ListFilter, limit int) ([]Entry, error) {
+		gotLimit = limit
+		return []Entry{}, nil
+	}}, nil)
+
+	if _, err := svc.List(context.Background(), ListFilter{}, "", 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotLimit != defaultLimit+1 {
+		t.Errorf("expected default limit, got %d", gotLimit)
+	}
+	if _, err := svc.List(context.Background(), ListFilter{}, "", 10000); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotLimit != maxLimit+1 {
+		t.Errorf("expected max limit, got %d", gotLimit)
+	}
+}
+
+func TestServiceListValidation(t *testing.T) {
+	svc := NewService(&mockRepository{listFn: func(context.Context, ListFilter, int) ([]Entry, error) {
+		t.Fatal("repo should not be called")
+		return nil, nil
+	}}, nil)
+
+	_, err := svc.List(context.Background(), ListFilter{}, "not-a-cursor!", 10)
+	assertAppError(t, err, http.StatusBadRequest, "invalid cursor")
+
+	from := time.Date(2026, 5, 2, 0, 0, 0, 0, time.UTC)
+	to := from.Add(-time.Hour)
+	_, err = svc.List(context.Background(), ListFilter{From: &from, To: &to}, "", 10)
+	assertAppError(t, err, http.StatusBadRequest, "from must be before to")
+}
+
+func TestServiceListRepoError(t *testing.T) {
+	svc := NewService(&mockRepository{listFn: func(context.Context, ListFilter, int) ([]Entry, error) {
+		return nil, errors.New("db down")
+	}}, nil)
+	_, err := svc.List(context.Background(), ListFilter{}, "", 10)
+	assertAppError(t, err, http.StatusInternalServerError, "failed to list audit log")
+}
+
+type stubVerifier struct {
+	report *VerifyReport
+	err    error
+}
+
+func (s stubVerifier) Verify(context.Context) (*VerifyReport, error) {
+	return s.report, s.err
+}
+
+func TestServiceVerifyChain(t *testing.T) {
+	broken := &VerifyReport{Checked: 3, Broken: &BrokenLink{ID: 4, Reason: "hash does not match row content (row edited)"}}
+	svc := NewService(nil, stubVerifier{report: broken})
+	got, err := svc.VerifyChain(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.OK || got.Broken.ID != 4 {
+		t.Fatalf("expected broken report to pass through, got %+v", got)
+	}
+
+	svc = NewService(nil, stubVerifier{err: errors.New("db down")})
+	_, err = svc.VerifyChain(context.Background())
+	assertAppError(t, err, http.StatusInternalServerError, "failed to verify audit chain")
+}
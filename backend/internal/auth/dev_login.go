@@ -37,11 +37,11 @@ func (h *DevLoginHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	slog.Info("dev-login: authenticated", "user_id", userID, "role", role)
+	slog.InfoContext(r.Context(), "dev-login: authenticated", "user_id", userID, "role", role)
 
 	token, err := h.jwtSvc.Generate(userID, resolvedURACF, role)
 	if err != nil {
-		slog.Error("dev-login: jwt generation failed", "user_id", userID, "error", err)
+		slog.ErrorContext(r.Context(), "dev-login: jwt generation failed", "user_id", userID, "error", err)
 		httputil.WriteError(w, r, apperror.Internal("failed to generate token", err))
 		return
 	}
@@ -52,6 +52,7 @@ func (h *DevLoginHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		Token: token,
 		Role:  role,
 		URACF: resolvedURACF,
+		Scope: ScopeForRole(role),
 	})
 }
 
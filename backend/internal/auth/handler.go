@@ -55,7 +55,7 @@ func (h *Handler) HandleSendOTP(w http.ResponseWriter, r *http.Request) {
 
 	exists, err := h.phoneCheck.PhoneExists(r.Context(), input.Phone)
 	if err != nil {
-		slog.Error("auth: phone check failed", "phone", input.Phone, "error", err)
+		slog.ErrorContext(r.Context(), "auth: phone check failed", "phone", input.Phone, "error", err)
 		httputil.WriteError(w, r, apperror.Internal("failed to verify phone", err))
 		return
 	}
@@ -96,7 +96,7 @@ func (h *Handler) HandleVerifyOTP(w http.ResponseWriter, r *http.Request) {
 
 	token, err := h.jwtSvc.Generate(userID, uracf, role)
 	if err != nil {
-		slog.Error("auth: jwt generation failed", "user_id", userID, "error", err)
+		slog.ErrorContext(r.Context(), "auth: jwt generation failed", "user_id", userID, "error", err)
 		httputil.WriteError(w, r, apperror.Internal("failed to generate token", err))
 		return
 	}
@@ -107,6 +107,7 @@ func (h *Handler) HandleVerifyOTP(w http.ResponseWriter, r *http.Request) {
 		Token: token,
 		Role:  role,
 		URACF: uracf,
+		Scope: ScopeForRole(role),
 	})
 }
 
@@ -8,10 +8,32 @@ import (
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
 )
 
+const (
+	// ScopeInvitation marks sessions opened by a printed invitation link;
+	// they reach only the RSVP and gift routes (see middleware.RequireScopedAuth).
+	ScopeInvitation = "invitation"
+	// ScopeCheckin limits staff sessions to the reception desk routes.
+	ScopeCheckin = "checkin"
+)
+
+// RoleStaff runs the reception desk on the wedding day.
+const RoleStaff = "staff"
+
+// ScopeForRole is the scope every session of role is limited to, whatever
+// it was opened with. Only staff have one.
+func ScopeForRole(role string) string {
+	if role == RoleStaff {
+		return ScopeCheckin
+	}
+	return ""
+}
+
 type Claims struct {
 	UserID int64  `json:"user_id"`
 	URACF  string `json:"uracf"`
 	Role   string `json:"role"`
+	// Scope is empty for full sessions.
+	Scope string `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -25,14 +47,29 @@ func NewJWTService(secret string, expiry time.Duration) *JWTService {
 }
 
 func (s *JWTService) Generate(userID int64, uracf, role string) (string, error) {
+	return s.GenerateScoped(userID, uracf, role, ScopeForRole(role), time.Time{})
+}
+
+// GenerateScoped issues a session limited to scope that also ends at notAfter
+// when that comes before the usual expiry. A zero notAfter sets no bound.
+// The scope of role, if any, takes precedence.
+func (s *JWTService) GenerateScoped(userID int64, uracf, role, scope string, notAfter time.Time) (string, error) {
+	if rs := ScopeForRole(role); rs != "" {
+		scope = rs
+	}
 	now := time.Now()
+	expiresAt := now.Add(s.expiry)
+	if !notAfter.IsZero() && notAfter.Before(expiresAt) {
+		expiresAt = notAfter
+	}
 	claims := Claims{
 		UserID: userID,
 		URACF:  uracf,
 		Role:   role,
+		Scope:  scope,
 		RegisteredClaims: jwt.RegisteredClaims{
 			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(now.Add(s.expiry)),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 		},
 	}
 
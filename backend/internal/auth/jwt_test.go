@@ -64,3 +64,41 @@ func TestJWTWrongSecret(t *testing.T) {
 		t.Fatal("expected error for wrong secret")
 	}
 }
+
+func TestJWTGenerateScoped(t *testing.T) {
+	svc := NewJWTService("test-secret", 1*time.Hour)
+
+	notAfter := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+	token, err := svc.GenerateScoped(7, "USR07", "guest", ScopeInvitation, notAfter)
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	claims, err := svc.Parse(token)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if claims.Scope != ScopeInvitation || !claims.ExpiresAt.Time.Equal(notAfter) {
+		t.Fatalf("expected invitation scope ending at %v, got %q / %v", notAfter, claims.Scope, claims.ExpiresAt)
+	}
+
+	token, _ = svc.GenerateScoped(7, "USR07", "guest", ScopeInvitation, time.Now().Add(48*time.Hour))
+	claims, err = svc.Parse(token)
+	if err != nil || claims.ExpiresAt.Time.After(time.Now().Add(time.Hour)) {
+		t.Fatalf("expected the usual expiry to bound a later notAfter, got %v (%v)", claims, err)
+	}
+}
+
+func TestJWTStaffSessionsAreScoped(t *testing.T) {
+	svc := NewJWTService("test-secret", 1*time.Hour)
+
+	token, _ := svc.Generate(3, "STF01", RoleStaff)
+	claims, err := svc.Parse(token)
+	if err != nil || claims.Scope != ScopeCheckin {
+		t.Fatalf("expected a check-in scoped staff session, got %+v (%v)", claims, err)
+	}
+
+	token, _ = svc.GenerateScoped(3, "STF01", RoleStaff, ScopeInvitation, time.Time{})
+	if claims, _ := svc.Parse(token); claims.Scope != ScopeCheckin {
+		t.Fatalf("expected the staff scope to win, got %q", claims.Scope)
+	}
+}
@@ -13,4 +13,5 @@ type TokenResponse struct {
 	Token string `json:"token"`
 	Role  string `json:"role"`
 	URACF string `json:"uracf"`
+	Scope string `json:"scope,omitempty"`
 }
@@ -0,0 +1,41 @@
+package checkin
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSignerRoundTrip(t *testing.T) {
+	s := NewSigner([]byte("checkin-signing-key-for-the-tests"))
+
+	code := s.Code(KindGuest, 123)
+	if !strings.HasPrefix(code, "PS1:G:123:") || code != strings.ToUpper(code) {
+		t.Fatalf("unexpected code %q", code)
+	}
+	got, err := s.Parse(code)
+	if err != nil || got != (Code{Kind: KindGuest, ID: 123}) {
+		t.Fatalf("expected guest 123 back, got %+v (%v)", got, err)
+	}
+	if got, err := s.Parse(" " + strings.ToLower(s.Code(KindHousehold, 7)) + "\n"); err != nil || got != (Code{Kind: KindHousehold, ID: 7}) {
+		t.Fatalf("expected household 7 from a lowercased scan, got %+v (%v)", got, err)
+	}
+}
+
+func TestSignerRejectsForgedCodes(t *testing.T) {
+	s := NewSigner([]byte("checkin-signing-key-for-the-tests"))
+	code := s.Code(KindGuest, 123)
+	mac := code[strings.LastIndexByte(code, ':'):]
+
+	for name, c := range map[string]string{
+		"other id":     "PS1:G:124" + mac,
+		"other kind":   "PS1:H:123" + mac,
+		"other key":    NewSigner([]byte("another-signing-key-for-the-tests")).Code(KindGuest, 123),
+		"truncated":    code[:len(code)-2],
+		"no signature": "PS1:G:123",
+		"empty":        "",
+	} {
+		if _, err := s.Parse(c); err == nil {
+			t.Fatalf("%s: expected %q to be rejected", name, c)
+		}
+	}
+}
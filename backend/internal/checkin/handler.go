@@ -0,0 +1,157 @@
+package checkin
+
+import (
+	"net/http"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/httputil"
+	"github.com/ferjunior7/parasempre/backend/internal/middleware"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// HandleLookup serves POST /api/checkin/lookup with the payload of a
+// scanned QR code.
+func (h *Handler) HandleLookup(w http.ResponseWriter, r *http.Request) {
+	var input LookupInput
+	if err := httputil.DecodeJSON(r, &input); err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid lookup payload", err))
+		return
+	}
+
+	party, err := h.svc.Lookup(r.Context(), input)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to look up code", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, party)
+}
+
+// HandleSearch serves GET /api/checkin/search?q=, by name or phone digits.
+func (h *Handler) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	parties, err := h.svc.Search(r.Context(), r.URL.Query().Get("q"))
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to search guests", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, parties)
+}
+
+// HandleCheckInHousehold serves POST /api/checkin/households/{id}. An empty
+// body checks in the whole household.
+func (h *Handler) HandleCheckInHousehold(w http.ResponseWriter, r *http.Request) {
+	userRACF := middleware.UserRACFFromContext(r.Context())
+
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid household id", err))
+		return
+	}
+
+	var input CheckInInput
+	if r.ContentLength != 0 {
+		if err := httputil.DecodeJSON(r, &input); err != nil {
+			httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid check-in payload", err))
+			return
+		}
+	}
+
+	party, err := h.svc.CheckIn(r.Context(), id, input, userRACF)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to check in", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, party)
+}
+
+func (h *Handler) HandleCheckInGuest(w http.ResponseWriter, r *http.Request) {
+	userRACF := middleware.UserRACFFromContext(r.Context())
+
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid guest id", err))
+		return
+	}
+
+	party, err := h.svc.CheckInGuest(r.Context(), id, userRACF)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to check in", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, party)
+}
+
+func (h *Handler) HandleUndoCheckIn(w http.ResponseWriter, r *http.Request) {
+	userRACF := middleware.UserRACFFromContext(r.Context())
+
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid guest id", err))
+		return
+	}
+
+	party, err := h.svc.UndoCheckIn(r.Context(), id, userRACF)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to undo check-in", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, party)
+}
+
+func (h *Handler) HandleStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.svc.Stats(r.Context())
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to get check-in stats", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, stats)
+}
+
+func (h *Handler) HandleGuestCode(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid guest id", err))
+		return
+	}
+
+	code, err := h.svc.GuestCode(r.Context(), id)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to get check-in code", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, code)
+}
+
+func (h *Handler) HandleHouseholdCode(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid household id", err))
+		return
+	}
+
+	code, err := h.svc.HouseholdCode(r.Context(), id)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to get check-in code", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, code)
+}
+
+// HandleMyCode serves GET /api/me/checkin-code, the code a guest shows at
+// the reception desk.
+func (h *Handler) HandleMyCode(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+
+	code, err := h.svc.MyCode(r.Context(), userID)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to get check-in code", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, code)
+}
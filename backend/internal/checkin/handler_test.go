@@ -0,0 +1,97 @@
+package checkin
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ferjunior7/parasempre/backend/internal/auth"
+	"github.com/ferjunior7/parasempre/backend/internal/middleware"
+)
+
+func newTestMux() *http.ServeMux {
+	svc, _, _ := newTestService()
+	h := NewHandler(svc)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/checkin/lookup", h.HandleLookup)
+	mux.HandleFunc("GET /api/checkin/search", h.HandleSearch)
+	mux.HandleFunc("POST /api/checkin/households/{id}", h.HandleCheckInHousehold)
+	mux.HandleFunc("POST /api/checkin/guests/{id}", h.HandleCheckInGuest)
+	mux.HandleFunc("DELETE /api/checkin/guests/{id}", h.HandleUndoCheckIn)
+	mux.HandleFunc("GET /api/checkin/stats", h.HandleStats)
+	mux.HandleFunc("GET /api/me/checkin-code", h.HandleMyCode)
+	return mux
+}
+
+func serve(mux *http.ServeMux, method, target, body string, claims *auth.Claims) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(middleware.WithClaims(req.Context(), claims))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+var staffClaims = &auth.Claims{UserID: 5, URACF: "STF01", Role: auth.RoleStaff, Scope: auth.ScopeCheckin}
+
+func TestHandlerLookupAndCheckIn(t *testing.T) {
+	mux := newTestMux()
+
+	w := serve(mux, http.MethodPost, "/api/checkin/lookup", `{"code":"`+testSigner.Code(KindGuest, 10)+`"}`, staffClaims)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = serve(mux, http.MethodPost, "/api/checkin/households/1", "", staffClaims)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var p Party
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil || len(p.Guests) != 2 || p.Guests[1].CheckedInBy == nil || *p.Guests[1].CheckedInBy != "STF01" {
+		t.Fatalf("expected the household checked in by STF01, got %+v (%v)", p, err)
+	}
+
+	w = serve(mux, http.MethodGet, "/api/checkin/stats", "", staffClaims)
+	var stats Stats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil || stats.Arrived != 2 || stats.Remaining != 0 {
+		t.Fatalf("unexpected stats %+v (%v)", stats, err)
+	}
+
+	if w := serve(mux, http.MethodDelete, "/api/checkin/guests/10", "", staffClaims); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(mux, http.MethodPost, "/api/checkin/lookup", `{"code":"PS1:H:1:FORGED"}`, staffClaims); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a forged code, got %d", w.Code)
+	}
+}
+
+func TestHandlerCheckInSomeGuests(t *testing.T) {
+	mux := newTestMux()
+
+	w := serve(mux, http.MethodPost, "/api/checkin/households/1", `{"guest_ids":[11]}`, staffClaims)
+	var p Party
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil || p.Guests[0].CheckedInAt != nil || p.Guests[1].CheckedInAt == nil {
+		t.Fatalf("expected only guest 11 checked in, got %+v (%v)", p, err)
+	}
+	if w := serve(mux, http.MethodPost, "/api/checkin/guests/20", "", staffClaims); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandlerSearchAndMyCode(t *testing.T) {
+	mux := newTestMux()
+
+	w := serve(mux, http.MethodGet, "/api/checkin/search?q=4321", "", staffClaims)
+	var parties []Party
+	if err := json.NewDecoder(w.Body).Decode(&parties); err != nil || len(parties) != 1 || parties[0].HouseholdName != "Família Souza" {
+		t.Fatalf("expected Família Souza, got %+v (%v)", parties, err)
+	}
+
+	w = serve(mux, http.MethodGet, "/api/me/checkin-code", "", &auth.Claims{UserID: 100, URACF: "SOU01", Role: "guest"})
+	var code CodeResponse
+	if err := json.NewDecoder(w.Body).Decode(&code); err != nil || code.Code != testSigner.Code(KindHousehold, 1) {
+		t.Fatalf("expected household 1's code, got %+v (%v)", code, err)
+	}
+}
@@ -0,0 +1,47 @@
+package checkin
+
+import "time"
+
+// Guest is a guest as the reception desk sees them.
+type Guest struct {
+	ID          int64      `json:"id"`
+	FirstName   string     `json:"first_name"`
+	LastName    string     `json:"last_name"`
+	Attending   *bool      `json:"attending"`
+	CheckedInAt *time.Time `json:"checked_in_at"`
+	CheckedInBy *string    `json:"checked_in_by"`
+}
+
+// Party is a household arriving together.
+type Party struct {
+	HouseholdID   int64   `json:"household_id"`
+	HouseholdName string  `json:"household_name"`
+	Guests        []Guest `json:"guests"`
+	// GuestID is the guest a scanned guest code named; the desk still
+	// sees the whole household.
+	GuestID *int64 `json:"guest_id,omitempty"`
+}
+
+// Stats are the live arrival counts. Remaining is confirmed guests not yet
+// arrived; walk-ins who never confirmed count in Arrived only.
+type Stats struct {
+	Total            int `json:"total"`
+	Confirmed        int `json:"confirmed"`
+	Arrived          int `json:"arrived"`
+	ArrivedConfirmed int `json:"arrived_confirmed"`
+	Remaining        int `json:"remaining"`
+}
+
+type LookupInput struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// CheckInInput picks which members of a household arrived; none means all.
+type CheckInInput struct {
+	GuestIDs []int64 `json:"guest_ids"`
+}
+
+// CodeResponse is the payload to render as a QR code.
+type CodeResponse struct {
+	Code string `json:"code"`
+}
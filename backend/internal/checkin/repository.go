@@ -0,0 +1,20 @@
+package checkin
+
+import "context"
+
+type Repository interface {
+	// ListParties returns the households in ids, in that order, leaving out
+	// those not found.
+	ListParties(ctx context.Context, ids []int64) ([]Party, error)
+	HouseholdOfGuest(ctx context.Context, guestID int64) (int64, error)
+	HouseholdOfUser(ctx context.Context, userID int64) (int64, error)
+	// SearchByName and SearchByPhone return the ids of matching households,
+	// best match first.
+	SearchByName(ctx context.Context, q string, limit int) ([]int64, error)
+	SearchByPhone(ctx context.Context, digits string, limit int) ([]int64, error)
+	// CheckIn marks guestIDs of a household (all of them when empty) as
+	// arrived, keeping earlier arrival times, and returns the ids it marked.
+	CheckIn(ctx context.Context, householdID int64, guestIDs []int64, userRACF string) ([]int64, error)
+	UndoCheckIn(ctx context.Context, guestID int64) error
+	Stats(ctx context.Context) (Stats, error)
+}
@@ -0,0 +1,89 @@
+//go:build integration
+// +build integration
+
+package checkin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ferjunior7/parasempre/backend/internal/database"
+)
+
+func TestIntegrationCheckIn(t *testing.T) {
+	pool := database.NewTestPool(t)
+	tx := database.BeginTestTx(t, pool)
+	repo := NewPostgresRepository(pool).WithTx(tx)
+	ctx := context.Background()
+
+	var householdID, anaID, betoID int64
+	if err := tx.QueryRow(ctx,
+		`INSERT INTO households (name, created_by, updated_by) VALUES ('Família Chegintegra', 'TST01', 'TST01') RETURNING id`).Scan(&householdID); err != nil {
+		t.Fatalf("insert household failed: %v", err)
+	}
+	for _, g := range []struct {
+		name      string
+		attending bool
+		id        *int64
+	}{{"Ana", true, &anaID}, {"Beto", false, &betoID}} {
+		if err := tx.QueryRow(ctx,
+			`INSERT INTO guests (first_name, last_name, relationship, family_group, attending, created_by, updated_by)
+			 VALUES ($1, 'Chegintegra', 'P', $2, $3, 'TST01', 'TST01') RETURNING id`, g.name, householdID, g.attending).Scan(g.id); err != nil {
+			t.Fatalf("insert guest failed: %v", err)
+		}
+	}
+	var userID int64
+	if err := tx.QueryRow(ctx,
+		`INSERT INTO users (guest_id, role, uracf, phone) VALUES ($1, 'guest', 'CHG01', '11912348765') RETURNING id`, anaID).Scan(&userID); err != nil {
+		t.Fatalf("insert user failed: %v", err)
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO users (role, uracf, phone) VALUES ('staff', 'STF01', '11912340000')`); err != nil {
+		t.Fatalf("expected the staff role to be accepted: %v", err)
+	}
+
+	if id, err := repo.HouseholdOfUser(ctx, userID); err != nil || id != householdID {
+		t.Fatalf("expected household %d, got %d (%v)", householdID, id, err)
+	}
+	if ids, err := repo.SearchByPhone(ctx, "8765", 10); err != nil || len(ids) != 1 || ids[0] != householdID {
+		t.Fatalf("expected the household by phone, got %v (%v)", ids, err)
+	}
+	if ids, err := repo.SearchByName(ctx, "chegintegra", 10); err != nil || len(ids) != 1 || ids[0] != householdID {
+		t.Fatalf("expected the household by name, got %v (%v)", ids, err)
+	}
+
+	before, err := repo.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+
+	ids, err := repo.CheckIn(ctx, householdID, []int64{betoID}, "STF01")
+	if err != nil || len(ids) != 1 || ids[0] != betoID {
+		t.Fatalf("expected Beto checked in, got %v (%v)", ids, err)
+	}
+	ids, err = repo.CheckIn(ctx, householdID, nil, "STF01")
+	if err != nil || len(ids) != 1 || ids[0] != anaID {
+		t.Fatalf("expected only Ana left to check in, got %v (%v)", ids, err)
+	}
+
+	parties, err := repo.ListParties(ctx, []int64{householdID, -1})
+	if err != nil || len(parties) != 1 || len(parties[0].Guests) != 2 {
+		t.Fatalf("expected the household with two guests, got %+v (%v)", parties, err)
+	}
+	for _, g := range parties[0].Guests {
+		if g.CheckedInAt == nil || g.CheckedInBy == nil || *g.CheckedInBy != "STF01" {
+			t.Fatalf("expected every guest checked in, got %+v", g)
+		}
+	}
+
+	after, err := repo.Stats(ctx)
+	if err != nil || after.Arrived-before.Arrived != 2 || after.ArrivedConfirmed-before.ArrivedConfirmed != 1 {
+		t.Fatalf("expected two arrivals, one confirmed, got %+v then %+v (%v)", before, after, err)
+	}
+
+	if err := repo.UndoCheckIn(ctx, betoID); err != nil {
+		t.Fatalf("UndoCheckIn failed: %v", err)
+	}
+	if err := repo.UndoCheckIn(ctx, -1); err == nil {
+		t.Fatal("expected an unknown guest to be rejected")
+	}
+}
@@ -0,0 +1,163 @@
+package checkin
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/database"
+	"github.com/ferjunior7/parasempre/backend/internal/search"
+)
+
+// guestFullName is the expression name searches match, as indexed by
+// migration 023.
+const guestFullName = `(g.first_name || ' ' || g.last_name)`
+
+type PostgresRepository struct {
+	db database.DBTX
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{db: pool}
+}
+
+func (r *PostgresRepository) WithTx(tx pgx.Tx) Repository {
+	return &PostgresRepository{db: tx}
+}
+
+func (r *PostgresRepository) ListParties(ctx context.Context, ids []int64) ([]Party, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT h.id, h.name, g.id, g.first_name, g.last_name, g.attending, g.checked_in_at, g.checked_in_by
+		 FROM unnest($1::bigint[]) WITH ORDINALITY AS want(id, pos)
+		 JOIN households h ON h.id = want.id
+		 JOIN guests g ON g.family_group = h.id
+		 ORDER BY want.pos, g.id`, ids)
+	if err != nil {
+		slog.ErrorContext(ctx, "checkin.repo list_parties: query failed", "ids", ids, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	parties := []Party{}
+	for rows.Next() {
+		var householdID int64
+		var householdName string
+		var g Guest
+		if err := rows.Scan(&householdID, &householdName, &g.ID, &g.FirstName, &g.LastName, &g.Attending, &g.CheckedInAt, &g.CheckedInBy); err != nil {
+			return nil, err
+		}
+		if n := len(parties); n == 0 || parties[n-1].HouseholdID != householdID {
+			parties = append(parties, Party{HouseholdID: householdID, HouseholdName: householdName})
+		}
+		p := &parties[len(parties)-1]
+		p.Guests = append(p.Guests, g)
+	}
+	return parties, rows.Err()
+}
+
+func (r *PostgresRepository) HouseholdOfGuest(ctx context.Context, guestID int64) (int64, error) {
+	var id int64
+	err := r.db.QueryRow(ctx, `SELECT family_group FROM guests WHERE id = $1`, guestID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, apperror.NotFound("guest not found")
+		}
+		slog.ErrorContext(ctx, "checkin.repo household_of_guest: query failed", "guest_id", guestID, "error", err)
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *PostgresRepository) HouseholdOfUser(ctx context.Context, userID int64) (int64, error) {
+	var id int64
+	err := r.db.QueryRow(ctx,
+		`SELECT g.family_group FROM users u JOIN guests g ON g.id = u.guest_id WHERE u.id = $1`, userID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, apperror.NotFound("no guest linked to this user")
+		}
+		slog.ErrorContext(ctx, "checkin.repo household_of_user: query failed", "user_id", userID, "error", err)
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *PostgresRepository) SearchByName(ctx context.Context, q string, limit int) ([]int64, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT g.family_group FROM guests g
+		 WHERE `+search.Match(guestFullName, 1, 2)+`
+		 GROUP BY g.family_group
+		 ORDER BY bool_or(search_normalize`+guestFullName+` LIKE search_normalize($2)) DESC,
+		          max(word_similarity(search_normalize($1), search_normalize`+guestFullName+`)) DESC,
+		          g.family_group
+		 LIMIT $3`, q, search.LikePattern(q), limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "checkin.repo search_by_name: query failed", "error", err)
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int64])
+}
+
+func (r *PostgresRepository) SearchByPhone(ctx context.Context, digits string, limit int) ([]int64, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT DISTINCT g.family_group FROM users u JOIN guests g ON g.id = u.guest_id
+		 WHERE u.phone LIKE '%' || $1
+		 ORDER BY g.family_group
+		 LIMIT $2`, digits, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "checkin.repo search_by_phone: query failed", "error", err)
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int64])
+}
+
+func (r *PostgresRepository) CheckIn(ctx context.Context, householdID int64, guestIDs []int64, userRACF string) ([]int64, error) {
+	rows, err := r.db.Query(ctx,
+		`UPDATE guests SET checked_in_at = now(), checked_in_by = $3
+		 WHERE family_group = $1 AND checked_in_at IS NULL
+		   AND (COALESCE(cardinality($2::bigint[]), 0) = 0 OR id = ANY($2))
+		 RETURNING id`, householdID, guestIDs, userRACF)
+	if err != nil {
+		slog.ErrorContext(ctx, "checkin.repo check_in: update failed", "household_id", householdID, "error", err)
+		return nil, err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "checkin.repo check_in: guests checked in", "household_id", householdID, "count", len(ids))
+	return ids, nil
+}
+
+func (r *PostgresRepository) UndoCheckIn(ctx context.Context, guestID int64) error {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE guests SET checked_in_at = NULL, checked_in_by = NULL WHERE id = $1`, guestID)
+	if err != nil {
+		slog.ErrorContext(ctx, "checkin.repo undo_check_in: update failed", "guest_id", guestID, "error", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.NotFound("guest not found")
+	}
+	return nil
+}
+
+func (r *PostgresRepository) Stats(ctx context.Context) (Stats, error) {
+	var s Stats
+	err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*),
+		        COUNT(*) FILTER (WHERE attending IS TRUE),
+		        COUNT(checked_in_at),
+		        COUNT(checked_in_at) FILTER (WHERE attending IS TRUE)
+		 FROM guests`).Scan(&s.Total, &s.Confirmed, &s.Arrived, &s.ArrivedConfirmed)
+	if err != nil {
+		slog.ErrorContext(ctx, "checkin.repo stats: query failed", "error", err)
+		return Stats{}, err
+	}
+	s.Remaining = s.Confirmed - s.ArrivedConfirmed
+	return s, nil
+}
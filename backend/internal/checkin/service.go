@@ -0,0 +1,210 @@
+package checkin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/audit"
+	"github.com/ferjunior7/parasempre/backend/internal/validate"
+)
+
+const (
+	auditCheckedIn     = "checkin.checked_in"
+	auditCheckInUndone = "checkin.undone"
+)
+
+const (
+	searchLimit        = 10
+	minSearchLen       = 2
+	minPhoneDigits     = 4
+	countryCodeBR      = "55"
+	fullPhoneWithCCLen = 13
+)
+
+type Service struct {
+	repo   Repository
+	signer *Signer
+	audit  audit.Logger
+}
+
+func NewService(repo Repository, signer *Signer, audit audit.Logger) *Service {
+	return &Service{repo: repo, signer: signer, audit: audit}
+}
+
+// GuestCode is the QR payload that brings up a guest's household at the desk.
+func (s *Service) GuestCode(ctx context.Context, guestID int64) (*CodeResponse, error) {
+	if _, err := s.repo.HouseholdOfGuest(ctx, guestID); err != nil {
+		return nil, apperror.WrapIfNotApp("failed to get guest", err)
+	}
+	return &CodeResponse{Code: s.signer.Code(KindGuest, guestID)}, nil
+}
+
+func (s *Service) HouseholdCode(ctx context.Context, householdID int64) (*CodeResponse, error) {
+	if _, err := s.party(ctx, householdID); err != nil {
+		return nil, apperror.WrapIfNotApp("failed to get household", err)
+	}
+	return &CodeResponse{Code: s.signer.Code(KindHousehold, householdID)}, nil
+}
+
+// MyCode is the household code of the signed-in guest, to show at the desk.
+func (s *Service) MyCode(ctx context.Context, userID int64) (*CodeResponse, error) {
+	householdID, err := s.repo.HouseholdOfUser(ctx, userID)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("failed to get household", err)
+	}
+	return &CodeResponse{Code: s.signer.Code(KindHousehold, householdID)}, nil
+}
+
+// Lookup resolves a scanned code to the household arriving.
+func (s *Service) Lookup(ctx context.Context, input LookupInput) (*Party, error) {
+	if err := validate.Struct(input); err != nil {
+		return nil, err
+	}
+	code, err := s.signer.Parse(input.Code)
+	if err != nil {
+		return nil, apperror.Validation("invalid check-in code")
+	}
+
+	householdID := code.ID
+	var guestID *int64
+	if code.Kind == KindGuest {
+		if householdID, err = s.repo.HouseholdOfGuest(ctx, code.ID); err != nil {
+			return nil, apperror.WrapIfNotApp("failed to look up guest", err)
+		}
+		guestID = &code.ID
+	}
+	p, err := s.party(ctx, householdID)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("failed to look up household", err)
+	}
+	p.GuestID = guestID
+	return p, nil
+}
+
+// Search finds households by a guest's name or by the digits of a phone
+// number (the last four are enough).
+func (s *Service) Search(ctx context.Context, q string) ([]Party, error) {
+	q = strings.TrimSpace(q)
+	if len([]rune(q)) < minSearchLen {
+		return nil, apperror.Validation(fmt.Sprintf("search must have at least %d characters", minSearchLen))
+	}
+
+	var ids []int64
+	var err error
+	if digits, ok := phoneDigits(q); ok {
+		ids, err = s.repo.SearchByPhone(ctx, digits, searchLimit)
+	} else {
+		ids, err = s.repo.SearchByName(ctx, q, searchLimit)
+	}
+	if err != nil {
+		return nil, apperror.Internal("failed to search guests", err)
+	}
+	if len(ids) == 0 {
+		return []Party{}, nil
+	}
+	parties, err := s.repo.ListParties(ctx, ids)
+	if err != nil {
+		return nil, apperror.Internal("failed to search guests", err)
+	}
+	return parties, nil
+}
+
+// phoneDigits is q as phone digits without the +55 country code, when q
+// has no letters and enough digits to be one.
+func phoneDigits(q string) (string, bool) {
+	if strings.IndexFunc(q, unicode.IsLetter) >= 0 {
+		return "", false
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, q)
+	if len(digits) == fullPhoneWithCCLen && strings.HasPrefix(digits, countryCodeBR) {
+		digits = digits[len(countryCodeBR):]
+	}
+	return digits, len(digits) >= minPhoneDigits
+}
+
+// CheckIn marks members of a household as arrived, all of them unless
+// input names some. Guests already checked in keep their arrival time.
+func (s *Service) CheckIn(ctx context.Context, householdID int64, input CheckInInput, userRACF string) (*Party, error) {
+	p, err := s.party(ctx, householdID)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("failed to check in", err)
+	}
+	for _, id := range input.GuestIDs {
+		if !slices.ContainsFunc(p.Guests, func(g Guest) bool { return g.ID == id }) {
+			return nil, apperror.Validation(fmt.Sprintf("guest %d is not in this household", id))
+		}
+	}
+
+	checkedIn, err := s.repo.CheckIn(ctx, householdID, input.GuestIDs, userRACF)
+	if err != nil {
+		return nil, apperror.Internal("failed to check in", err)
+	}
+	if p, err = s.party(ctx, householdID); err != nil {
+		return nil, apperror.WrapIfNotApp("failed to check in", err)
+	}
+
+	if len(checkedIn) > 0 {
+		audit.Record(ctx, s.audit, auditCheckedIn, audit.Entity(audit.EntityHousehold, householdID, map[string]any{
+			"guest_ids": checkedIn,
+		}))
+	}
+	slog.InfoContext(ctx, "checkin.service check_in: guests checked in", "household_id", householdID, "count", len(checkedIn), "user_racf", userRACF)
+	return p, nil
+}
+
+// CheckInGuest marks a single guest as arrived.
+func (s *Service) CheckInGuest(ctx context.Context, guestID int64, userRACF string) (*Party, error) {
+	householdID, err := s.repo.HouseholdOfGuest(ctx, guestID)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("failed to check in", err)
+	}
+	return s.CheckIn(ctx, householdID, CheckInInput{GuestIDs: []int64{guestID}}, userRACF)
+}
+
+// UndoCheckIn clears a guest's arrival, for check-ins made by mistake.
+func (s *Service) UndoCheckIn(ctx context.Context, guestID int64, userRACF string) (*Party, error) {
+	householdID, err := s.repo.HouseholdOfGuest(ctx, guestID)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("failed to undo check-in", err)
+	}
+	if err := s.repo.UndoCheckIn(ctx, guestID); err != nil {
+		return nil, apperror.WrapIfNotApp("failed to undo check-in", err)
+	}
+	p, err := s.party(ctx, householdID)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("failed to undo check-in", err)
+	}
+
+	audit.Record(ctx, s.audit, auditCheckInUndone, audit.Entity(audit.EntityGuest, guestID, nil))
+	slog.InfoContext(ctx, "checkin.service undo: check-in undone", "guest_id", guestID, "user_racf", userRACF)
+	return p, nil
+}
+
+func (s *Service) Stats(ctx context.Context) (*Stats, error) {
+	stats, err := s.repo.Stats(ctx)
+	if err != nil {
+		return nil, apperror.Internal("failed to get check-in stats", err)
+	}
+	return &stats, nil
+}
+
+func (s *Service) party(ctx context.Context, householdID int64) (*Party, error) {
+	parties, err := s.repo.ListParties(ctx, []int64{householdID})
+	if err != nil {
+		return nil, err
+	}
+	if len(parties) == 0 {
+		return nil, apperror.NotFound("household not found")
+	}
+	return &parties[0], nil
+}
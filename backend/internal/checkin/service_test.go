@@ -0,0 +1,297 @@
+package checkin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
+)
+
+var testNow = time.Date(2026, 11, 21, 19, 0, 0, 0, time.UTC)
+
+func boolPtr(b bool) *bool { return &b }
+
+// memRepository holds household 1 ("Família Souza": guests 10 and 11, both
+// confirmed, guest 10 signed in as user 100, phone 11987654321) and
+// household 2 ("Família Lima": guest 20, not confirmed).
+type memRepository struct {
+	parties   map[int64]*Party
+	userHouse map[int64]int64
+	phones    map[string]int64
+	names     map[string]int64
+}
+
+func newMemRepository() *memRepository {
+	return &memRepository{
+		parties: map[int64]*Party{
+			1: {HouseholdID: 1, HouseholdName: "Família Souza", Guests: []Guest{
+				{ID: 10, FirstName: "Ana", LastName: "Souza", Attending: boolPtr(true)},
+				{ID: 11, FirstName: "João", LastName: "Souza", Attending: boolPtr(true)},
+			}},
+			2: {HouseholdID: 2, HouseholdName: "Família Lima", Guests: []Guest{
+				{ID: 20, FirstName: "Rita", LastName: "Lima"},
+			}},
+		},
+		userHouse: map[int64]int64{100: 1},
+		phones:    map[string]int64{"11987654321": 1},
+		names:     map[string]int64{"souza": 1, "lima": 2},
+	}
+}
+
+func (m *memRepository) ListParties(ctx context.Context, ids []int64) ([]Party, error) {
+	out := []Party{}
+	for _, id := range ids {
+		if p, ok := m.parties[id]; ok {
+			c := *p
+			c.Guests = slices.Clone(p.Guests)
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (m *memRepository) HouseholdOfGuest(ctx context.Context, guestID int64) (int64, error) {
+	for id, p := range m.parties {
+		if slices.ContainsFunc(p.Guests, func(g Guest) bool { return g.ID == guestID }) {
+			return id, nil
+		}
+	}
+	return 0, apperror.NotFound("guest not found")
+}
+
+func (m *memRepository) HouseholdOfUser(ctx context.Context, userID int64) (int64, error) {
+	id, ok := m.userHouse[userID]
+	if !ok {
+		return 0, apperror.NotFound("no guest linked to this user")
+	}
+	return id, nil
+}
+
+func (m *memRepository) SearchByName(ctx context.Context, q string, limit int) ([]int64, error) {
+	if id, ok := m.names[q]; ok {
+		return []int64{id}, nil
+	}
+	return nil, nil
+}
+
+func (m *memRepository) SearchByPhone(ctx context.Context, digits string, limit int) ([]int64, error) {
+	var ids []int64
+	for phone, id := range m.phones {
+		if len(phone) >= len(digits) && phone[len(phone)-len(digits):] == digits {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (m *memRepository) CheckIn(ctx context.Context, householdID int64, guestIDs []int64, userRACF string) ([]int64, error) {
+	var marked []int64
+	p := m.parties[householdID]
+	for i := range p.Guests {
+		g := &p.Guests[i]
+		if g.CheckedInAt != nil || (len(guestIDs) > 0 && !slices.Contains(guestIDs, g.ID)) {
+			continue
+		}
+		now := testNow
+		g.CheckedInAt, g.CheckedInBy = &now, &userRACF
+		marked = append(marked, g.ID)
+	}
+	return marked, nil
+}
+
+func (m *memRepository) UndoCheckIn(ctx context.Context, guestID int64) error {
+	for _, p := range m.parties {
+		for i := range p.Guests {
+			if p.Guests[i].ID == guestID {
+				p.Guests[i].CheckedInAt, p.Guests[i].CheckedInBy = nil, nil
+				return nil
+			}
+		}
+	}
+	return apperror.NotFound("guest not found")
+}
+
+func (m *memRepository) Stats(ctx context.Context) (Stats, error) {
+	var s Stats
+	for _, p := range m.parties {
+		for _, g := range p.Guests {
+			confirmed := g.Attending != nil && *g.Attending
+			s.Total++
+			if confirmed {
+				s.Confirmed++
+			}
+			if g.CheckedInAt != nil {
+				s.Arrived++
+				if confirmed {
+					s.ArrivedConfirmed++
+				}
+			}
+		}
+	}
+	s.Remaining = s.Confirmed - s.ArrivedConfirmed
+	return s, nil
+}
+
+type auditCall struct {
+	userID  int64
+	action  string
+	details map[string]any
+}
+
+type mockAudit struct {
+	calls []auditCall
+}
+
+func (m *mockAudit) LogAction(ctx context.Context, userID int64, action string, details map[string]any) error {
+	m.calls = append(m.calls, auditCall{userID: userID, action: action, details: details})
+	return nil
+}
+
+var testSigner = NewSigner([]byte("checkin-signing-key-for-the-tests"))
+
+func newTestService() (*Service, *memRepository, *mockAudit) {
+	repo, aud := newMemRepository(), &mockAudit{}
+	return NewService(repo, testSigner, aud), repo, aud
+}
+
+func staffCtx() context.Context {
+	return reqctx.WithUserID(context.Background(), 5)
+}
+
+func assertAppError(t *testing.T, err error, code int, msg string) {
+	t.Helper()
+	var appErr *apperror.AppError
+	if !errors.As(err, &appErr) || appErr.Code != code || appErr.Message != msg {
+		t.Fatalf("expected %d %q, got %v", code, msg, err)
+	}
+}
+
+func TestServiceLookup(t *testing.T) {
+	svc, _, _ := newTestService()
+
+	p, err := svc.Lookup(staffCtx(), LookupInput{Code: testSigner.Code(KindGuest, 11)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.HouseholdID != 1 || len(p.Guests) != 2 || p.GuestID == nil || *p.GuestID != 11 {
+		t.Fatalf("expected household 1 with guest 11 picked, got %+v", p)
+	}
+
+	p, err = svc.Lookup(staffCtx(), LookupInput{Code: testSigner.Code(KindHousehold, 2)})
+	if err != nil || p.HouseholdID != 2 || p.GuestID != nil {
+		t.Fatalf("expected household 2, got %+v (%v)", p, err)
+	}
+
+	_, err = svc.Lookup(staffCtx(), LookupInput{Code: "PS1:G:11:AAAAAAAAAAAAAAAA"})
+	assertAppError(t, err, http.StatusBadRequest, "invalid check-in code")
+	_, err = svc.Lookup(staffCtx(), LookupInput{Code: testSigner.Code(KindHousehold, 9)})
+	assertAppError(t, err, http.StatusNotFound, "household not found")
+}
+
+func TestServiceSearch(t *testing.T) {
+	svc, _, _ := newTestService()
+
+	for _, q := range []string{"souza", "4321", "(11) 98765-4321", "+55 11 98765-4321"} {
+		parties, err := svc.Search(staffCtx(), q)
+		if err != nil || len(parties) != 1 || parties[0].HouseholdID != 1 {
+			t.Fatalf("%q: expected household 1, got %+v (%v)", q, parties, err)
+		}
+	}
+	if parties, err := svc.Search(staffCtx(), "nobody"); err != nil || parties == nil || len(parties) != 0 {
+		t.Fatalf("expected an empty list, got %+v (%v)", parties, err)
+	}
+	_, err := svc.Search(staffCtx(), " a ")
+	assertAppError(t, err, http.StatusBadRequest, "search must have at least 2 characters")
+}
+
+func TestServiceCheckInHousehold(t *testing.T) {
+	svc, _, aud := newTestService()
+
+	p, err := svc.CheckIn(staffCtx(), 1, CheckInInput{}, "STF01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, g := range p.Guests {
+		if g.CheckedInAt == nil || g.CheckedInBy == nil || *g.CheckedInBy != "STF01" {
+			t.Fatalf("expected every guest checked in by STF01, got %+v", g)
+		}
+	}
+	if len(aud.calls) != 1 || aud.calls[0].action != auditCheckedIn || aud.calls[0].details["entity_id"] != int64(1) {
+		t.Fatalf("unexpected audit entries %+v", aud.calls)
+	}
+
+	if _, err := svc.CheckIn(staffCtx(), 1, CheckInInput{}, "STF02"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(aud.calls) != 1 {
+		t.Fatalf("expected a repeated check-in not to be audited, got %+v", aud.calls)
+	}
+
+	stats, err := svc.Stats(staffCtx())
+	if err != nil || *stats != (Stats{Total: 3, Confirmed: 2, Arrived: 2, ArrivedConfirmed: 2, Remaining: 0}) {
+		t.Fatalf("unexpected stats %+v (%v)", stats, err)
+	}
+}
+
+func TestServiceCheckInSomeGuests(t *testing.T) {
+	svc, _, _ := newTestService()
+
+	p, err := svc.CheckIn(staffCtx(), 1, CheckInInput{GuestIDs: []int64{11}}, "STF01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Guests[0].CheckedInAt != nil || p.Guests[1].CheckedInAt == nil {
+		t.Fatalf("expected only guest 11 checked in, got %+v", p.Guests)
+	}
+
+	_, err = svc.CheckIn(staffCtx(), 1, CheckInInput{GuestIDs: []int64{20}}, "STF01")
+	assertAppError(t, err, http.StatusBadRequest, "guest 20 is not in this household")
+	_, err = svc.CheckIn(staffCtx(), 9, CheckInInput{}, "STF01")
+	assertAppError(t, err, http.StatusNotFound, "household not found")
+}
+
+func TestServiceCheckInGuestAndUndo(t *testing.T) {
+	svc, _, aud := newTestService()
+
+	p, err := svc.CheckInGuest(staffCtx(), 20, "STF01")
+	if err != nil || p.HouseholdID != 2 || p.Guests[0].CheckedInAt == nil {
+		t.Fatalf("expected guest 20 checked in, got %+v (%v)", p, err)
+	}
+	stats, _ := svc.Stats(staffCtx())
+	if stats.Arrived != 1 || stats.ArrivedConfirmed != 0 || stats.Remaining != 2 {
+		t.Fatalf("expected an unconfirmed walk-in, got %+v", stats)
+	}
+
+	p, err = svc.UndoCheckIn(staffCtx(), 20, "STF01")
+	if err != nil || p.Guests[0].CheckedInAt != nil {
+		t.Fatalf("expected the check-in undone, got %+v (%v)", p, err)
+	}
+	last := aud.calls[len(aud.calls)-1]
+	if last.action != auditCheckInUndone || last.details["entity_id"] != int64(20) {
+		t.Fatalf("unexpected audit entry %+v", last)
+	}
+
+	_, err = svc.CheckInGuest(staffCtx(), 99, "STF01")
+	assertAppError(t, err, http.StatusNotFound, "guest not found")
+}
+
+func TestServiceCodes(t *testing.T) {
+	svc, _, _ := newTestService()
+
+	code, err := svc.MyCode(context.Background(), 100)
+	if err != nil || code.Code != testSigner.Code(KindHousehold, 1) {
+		t.Fatalf("expected household 1's code, got %+v (%v)", code, err)
+	}
+	if code, err := svc.GuestCode(context.Background(), 11); err != nil || code.Code != testSigner.Code(KindGuest, 11) {
+		t.Fatalf("expected guest 11's code, got %+v (%v)", code, err)
+	}
+	_, err = svc.HouseholdCode(context.Background(), 9)
+	assertAppError(t, err, http.StatusNotFound, "household not found")
+	_, err = svc.MyCode(context.Background(), 1)
+	assertAppError(t, err, http.StatusNotFound, "no guest linked to this user")
+}
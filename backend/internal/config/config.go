@@ -1,7 +1,10 @@
 package config
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
 	"fmt"
+	"net/netip"
 	"net/url"
 	"os"
 	"strconv"
@@ -34,6 +37,12 @@ const (
 
 	envFirecrawlAPIKey = "FIRECRAWL_API_KEY"
 	envFirecrawlURL    = "FIRECRAWL_URL"
+	envProductScrapers = "PRODUCT_SCRAPERS"
+
+	envPriceMonitorInterval     = "PRICE_MONITOR_INTERVAL"
+	envPriceMonitorRecheckAfter = "PRICE_MONITOR_RECHECK_AFTER"
+	envPriceMonitorDailyBudget  = "PRICE_MONITOR_DAILY_BUDGET"
+	envPriceMonitorThreshold    = "PRICE_MONITOR_THRESHOLD_PERCENT"
 
 	envMPAccessToken   = "MERCADO_PAGO_ACCESS_TOKEN"
 	envMPPublicKey     = "MERCADO_PAGO_PUBLIC_KEY"
@@ -44,11 +53,42 @@ const (
 	envSupabaseServiceRoleKey  = "SUPABASE_SERVICE_ROLE_KEY"
 	envSupabaseStorageBucket   = "SUPABASE_STORAGE_BUCKET"
 	envGiftMessageSignedURLTTL = "GIFT_MESSAGE_SIGNED_URL_TTL_SECONDS"
+	envGiftMessageModeration   = "GIFT_MESSAGE_MODERATION"
+	envGuestbookSignScope      = "GUESTBOOK_SIGN_SCOPE"
+
+	envStorageBackend         = "STORAGE_BACKEND"
+	envLocalStorageDir        = "LOCAL_STORAGE_DIR"
+	envLocalStoragePublicURL  = "LOCAL_STORAGE_PUBLIC_URL"
+	envLocalStorageSigningKey = "LOCAL_STORAGE_SIGNING_KEY"
+	envS3Endpoint             = "S3_ENDPOINT"
+	envS3Region               = "S3_REGION"
+	envS3Bucket               = "S3_BUCKET"
+	envS3AccessKeyID          = "S3_ACCESS_KEY_ID"
+	envS3SecretAccessKey      = "S3_SECRET_ACCESS_KEY"
+	envS3ForcePathStyle       = "S3_FORCE_PATH_STYLE"
+	envResumableUploadDir     = "RESUMABLE_UPLOAD_DIR"
+
+	envRateLimitBackend = "RATE_LIMIT_BACKEND"
+	envTrustedProxies   = "TRUSTED_PROXIES"
+
+	envAuditSigningKey         = "AUDIT_SIGNING_KEY"
+	envAuditCheckpointInterval = "AUDIT_CHECKPOINT_INTERVAL"
+
+	envInvitationSigningKey = "INVITATION_SIGNING_KEY"
+	envInvitationExpiresAt  = "INVITATION_EXPIRES_AT"
+	envInvitationBaseURL    = "INVITATION_BASE_URL"
+
+	envCheckinSigningKey = "CHECKIN_SIGNING_KEY"
 
 	envDBMaxConns    = "DB_MAX_CONNS"
 	envDBMinConns    = "DB_MIN_CONNS"
 	envDBMaxConnLife = "DB_MAX_CONN_LIFETIME"
 	envDBMaxConnIdle = "DB_MAX_CONN_IDLE_TIME"
+
+	envSearchSimilarity = "SEARCH_SIMILARITY_THRESHOLD"
+
+	envHTTPCacheTTL    = "HTTP_CACHE_TTL_SECONDS"
+	envHTTPCacheMaxAge = "HTTP_CACHE_MAX_AGE_SECONDS"
 )
 
 const (
@@ -61,11 +101,67 @@ const (
 	defaultDBMaxConnLife = "30m"
 	defaultDBMaxConnIdle = "5m"
 
-	defaultFirecrawlURL = "https://api.firecrawl.dev"
-	defaultMPBaseURL    = "https://api.mercadopago.com"
+	defaultSearchSimilarity = "0.5"
+
+	defaultFirecrawlURL    = "https://api.firecrawl.dev"
+	defaultProductScrapers = ProductScraperNative + "," + ProductScraperFirecrawl
+	defaultMPBaseURL       = "https://api.mercadopago.com"
+
+	defaultPriceMonitorInterval     = "6h"
+	defaultPriceMonitorRecheckAfter = "72h"
+	defaultPriceMonitorDailyBudget  = "20"
+	defaultPriceMonitorThreshold    = "10"
 
 	defaultSupabaseStorageBucket   = "gift-messages"
 	defaultGiftMessageSignedURLTTL = "900"
+	defaultGiftMessageModeration   = GiftMessageModerationFlagged
+	defaultGuestbookSignScope      = GuestbookSignScopeFamily
+
+	defaultStorageBackend        = StorageBackendSupabase
+	defaultLocalStorageDir       = "./data/media"
+	defaultLocalStoragePublicURL = "http://localhost:8080"
+	defaultS3Region              = "us-east-1"
+	defaultS3Bucket              = "gift-messages"
+	defaultS3ForcePathStyle      = "true"
+	defaultResumableUploadDir    = "./data/uploads"
+
+	minLocalStorageSigningKeyLen = 32
+	minInvitationSigningKeyLen   = 32
+	minCheckinSigningKeyLen      = 32
+
+	defaultRateLimitBackend = RateLimitBackendMemory
+
+	defaultAuditCheckpointInterval = "1h"
+
+	defaultHTTPCacheTTL    = "30"
+	defaultHTTPCacheMaxAge = "10"
+)
+
+const (
+	RateLimitBackendMemory   = "memory"
+	RateLimitBackendPostgres = "postgres"
+)
+
+const (
+	ProductScraperNative    = "native"
+	ProductScraperFirecrawl = "firecrawl"
+)
+
+const (
+	StorageBackendSupabase = "supabase"
+	StorageBackendLocal    = "local"
+	StorageBackendS3       = "s3"
+)
+
+const (
+	GiftMessageModerationAuto    = "auto"
+	GiftMessageModerationAll     = "all"
+	GiftMessageModerationFlagged = "flagged"
+)
+
+const (
+	GuestbookSignScopeFamily = "family"
+	GuestbookSignScopeUser   = "user"
 )
 
 type DBConfig struct {
@@ -79,6 +175,10 @@ type DBConfig struct {
 	MinConns        int32
 	MaxConnLifetime time.Duration
 	MaxConnIdleTime time.Duration
+
+	// SearchSimilarity is the pg_trgm word similarity (0–1] a name needs
+	// to match a search it does not contain, i.e. the typo tolerance.
+	SearchSimilarity float64
 }
 
 type CoupleUserConfig struct {
@@ -108,10 +208,78 @@ type Config struct {
 	MercadoPagoWebhookSecret string
 	MercadoPagoBaseURL       string
 
+	// ProductScrapers is the order in which store links are scraped:
+	// "native" reads JSON-LD/OpenGraph from the page, "firecrawl" calls
+	// Firecrawl (skipped when not configured). Later ones fill the gaps.
+	ProductScrapers []string
+
+	// The price monitor re-scrapes gift store links every
+	// PriceMonitorInterval, spending at most PriceMonitorDailyBudget scrapes
+	// per 24h (0 disables it), and flags moves above the threshold.
+	PriceMonitorInterval         string
+	PriceMonitorRecheckAfter     string
+	PriceMonitorDailyBudget      int
+	PriceMonitorThresholdPercent float64
+
 	SupabaseURL                 string
 	SupabaseServiceRoleKey      string
 	SupabaseStorageBucket       string
 	GiftMessageSignedURLTTLSecs int
+	// GiftMessageModeration picks which new messages wait for the couple:
+	// "auto" (none), "all", or "flagged" (only those hitting the blocklist).
+	GiftMessageModeration string
+	// GuestbookSignScope limits guestbook signatures to one per "family"
+	// (guests sharing a family_group) or one per "user".
+	GuestbookSignScope string
+
+	// StorageBackend selects where gift message media lives: "supabase",
+	// "local" (filesystem, served by the API with HMAC-signed URLs) or "s3"
+	// (any S3-compatible service, e.g. MinIO).
+	StorageBackend         string
+	LocalStorageDir        string
+	LocalStoragePublicURL  string
+	LocalStorageSigningKey string
+	S3Endpoint             string
+	S3Region               string
+	S3Bucket               string
+	S3AccessKeyID          string
+	S3SecretAccessKey      string
+	S3ForcePathStyle       bool
+	// ResumableUploadDir holds tus upload chunks until they are complete.
+	ResumableUploadDir string
+
+	// RateLimitBackend selects where token buckets live: "memory" (per
+	// process) or "postgres" (shared by all replicas).
+	RateLimitBackend string
+
+	// TrustedProxies lists CIDRs (or bare IPs) whose X-Forwarded-For,
+	// X-Real-IP and Forwarded headers are believed. Empty means the direct
+	// peer address is always the client.
+	TrustedProxies []string
+
+	// AuditSigningKey is a base64 Ed25519 seed used to sign audit_log
+	// checkpoints. Empty disables checkpoints.
+	AuditSigningKey         string
+	AuditCheckpointInterval string
+
+	// InvitationSigningKey signs the per-household links printed on the
+	// invitations (empty disables them). Links stop working at
+	// InvitationExpiresAt (RFC 3339, after the wedding) and point at
+	// InvitationBaseURL, the frontend origin by default.
+	InvitationSigningKey string
+	InvitationExpiresAt  string
+	InvitationBaseURL    string
+
+	// CheckinSigningKey signs the guest and household QR codes scanned at
+	// the reception desk (empty disables check-in).
+	CheckinSigningKey string
+
+	// Public gift and message responses are served from memory for
+	// HTTPCacheTTLSecs (0 always regenerates them) and may be kept by
+	// browsers for HTTPCacheMaxAgeSecs. Message lists embed signed media
+	// URLs, so together they must stay well inside the URLs' lifetime.
+	HTTPCacheTTLSecs    int
+	HTTPCacheMaxAgeSecs int
 }
 
 type envField struct {
@@ -136,6 +304,10 @@ func Load() (Config, error) {
 	if err != nil {
 		return Config{}, fmt.Errorf("invalid %s: %w", envDBMaxConnIdle, err)
 	}
+	searchSimilarity, err := strconv.ParseFloat(getEnvOrDefault(envSearchSimilarity, defaultSearchSimilarity), 64)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid %s: must be a number", envSearchSimilarity)
+	}
 
 	cfg := Config{
 		DB: DBConfig{
@@ -149,6 +321,8 @@ func Load() (Config, error) {
 			MinConns:        int32(minConns),
 			MaxConnLifetime: maxConnLife,
 			MaxConnIdleTime: maxConnIdle,
+
+			SearchSimilarity: searchSimilarity,
 		},
 		CORSOrigin: getEnvOrDefault(envCORSOrigin, defaultCORSOrigin),
 		AppEnv:     getEnvOrDefault(envAppEnv, defaultAppEnv),
@@ -169,15 +343,43 @@ func Load() (Config, error) {
 		EvoAPIInstance:           getEnv(envEvoAPIInstance),
 		FirecrawlAPIKey:          getEnv(envFirecrawlAPIKey),
 		FirecrawlURL:             getEnvOrDefault(envFirecrawlURL, defaultFirecrawlURL),
+		ProductScrapers:          splitList(getEnvOrDefault(envProductScrapers, defaultProductScrapers)),
 		MercadoPagoAccessToken:   getEnv(envMPAccessToken),
 		MercadoPagoPublicKey:     getEnv(envMPPublicKey),
 		MercadoPagoWebhookSecret: getEnv(envMPWebhookSecret),
 		MercadoPagoBaseURL:       getEnvOrDefault(envMPBaseURL, defaultMPBaseURL),
 
+		PriceMonitorInterval:     getEnvOrDefault(envPriceMonitorInterval, defaultPriceMonitorInterval),
+		PriceMonitorRecheckAfter: getEnvOrDefault(envPriceMonitorRecheckAfter, defaultPriceMonitorRecheckAfter),
+
 		SupabaseURL:            getEnv(envSupabaseURL),
 		SupabaseServiceRoleKey: getEnv(envSupabaseServiceRoleKey),
 		SupabaseStorageBucket:  getEnvOrDefault(envSupabaseStorageBucket, defaultSupabaseStorageBucket),
+		GiftMessageModeration:  getEnvOrDefault(envGiftMessageModeration, defaultGiftMessageModeration),
+		GuestbookSignScope:     getEnvOrDefault(envGuestbookSignScope, defaultGuestbookSignScope),
+
+		StorageBackend:         getEnvOrDefault(envStorageBackend, defaultStorageBackend),
+		LocalStorageDir:        getEnvOrDefault(envLocalStorageDir, defaultLocalStorageDir),
+		LocalStoragePublicURL:  getEnvOrDefault(envLocalStoragePublicURL, defaultLocalStoragePublicURL),
+		LocalStorageSigningKey: getEnv(envLocalStorageSigningKey),
+		S3Endpoint:             getEnv(envS3Endpoint),
+		S3Region:               getEnvOrDefault(envS3Region, defaultS3Region),
+		S3Bucket:               getEnvOrDefault(envS3Bucket, defaultS3Bucket),
+		S3AccessKeyID:          getEnv(envS3AccessKeyID),
+		S3SecretAccessKey:      getEnv(envS3SecretAccessKey),
+		ResumableUploadDir:     getEnvOrDefault(envResumableUploadDir, defaultResumableUploadDir),
+
+		RateLimitBackend: getEnvOrDefault(envRateLimitBackend, defaultRateLimitBackend),
+		TrustedProxies:   splitList(getEnv(envTrustedProxies)),
+
+		AuditSigningKey:         getEnv(envAuditSigningKey),
+		AuditCheckpointInterval: getEnvOrDefault(envAuditCheckpointInterval, defaultAuditCheckpointInterval),
+
+		InvitationSigningKey: getEnv(envInvitationSigningKey),
+		InvitationExpiresAt:  getEnv(envInvitationExpiresAt),
 	}
+	cfg.InvitationBaseURL = getEnvOrDefault(envInvitationBaseURL, cfg.CORSOrigin)
+	cfg.CheckinSigningKey = getEnv(envCheckinSigningKey)
 
 	ttlSecs, err := strconv.Atoi(getEnvOrDefault(envGiftMessageSignedURLTTL, defaultGiftMessageSignedURLTTL))
 	if err != nil || ttlSecs <= 0 {
@@ -185,6 +387,36 @@ func Load() (Config, error) {
 	}
 	cfg.GiftMessageSignedURLTTLSecs = ttlSecs
 
+	cacheTTL, err := strconv.Atoi(getEnvOrDefault(envHTTPCacheTTL, defaultHTTPCacheTTL))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid %s: must be an integer (seconds)", envHTTPCacheTTL)
+	}
+	cfg.HTTPCacheTTLSecs = cacheTTL
+
+	cacheMaxAge, err := strconv.Atoi(getEnvOrDefault(envHTTPCacheMaxAge, defaultHTTPCacheMaxAge))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid %s: must be an integer (seconds)", envHTTPCacheMaxAge)
+	}
+	cfg.HTTPCacheMaxAgeSecs = cacheMaxAge
+
+	budget, err := strconv.Atoi(getEnvOrDefault(envPriceMonitorDailyBudget, defaultPriceMonitorDailyBudget))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid %s: must be an integer", envPriceMonitorDailyBudget)
+	}
+	cfg.PriceMonitorDailyBudget = budget
+
+	threshold, err := strconv.ParseFloat(getEnvOrDefault(envPriceMonitorThreshold, defaultPriceMonitorThreshold), 64)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid %s: must be a number", envPriceMonitorThreshold)
+	}
+	cfg.PriceMonitorThresholdPercent = threshold
+
+	pathStyle, err := strconv.ParseBool(getEnvOrDefault(envS3ForcePathStyle, defaultS3ForcePathStyle))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid %s: must be true or false", envS3ForcePathStyle)
+	}
+	cfg.S3ForcePathStyle = pathStyle
+
 	if err := cfg.validate(); err != nil {
 		return Config{}, err
 	}
@@ -226,11 +458,77 @@ func (c Config) validate() error {
 	if err := validateEvoConfig(c.EvoAPIURL); err != nil {
 		issues = append(issues, err.Error())
 	}
+	if err := validateOneOf(envRateLimitBackend, c.RateLimitBackend, []string{RateLimitBackendMemory, RateLimitBackendPostgres}); err != nil {
+		issues = append(issues, err.Error())
+	}
+
+	if err := validateOneOf(envGiftMessageModeration, c.GiftMessageModeration, []string{GiftMessageModerationAuto, GiftMessageModerationAll, GiftMessageModerationFlagged}); err != nil {
+		issues = append(issues, err.Error())
+	}
+	if err := validateOneOf(envGuestbookSignScope, c.GuestbookSignScope, []string{GuestbookSignScopeFamily, GuestbookSignScopeUser}); err != nil {
+		issues = append(issues, err.Error())
+	}
+
+	if err := validateCIDRList(envTrustedProxies, c.TrustedProxies); err != nil {
+		issues = append(issues, err.Error())
+	}
+
+	if err := validatePositiveDuration(envAuditCheckpointInterval, c.AuditCheckpointInterval); err != nil {
+		issues = append(issues, err.Error())
+	}
+	if len(c.ProductScrapers) == 0 {
+		issues = append(issues, fmt.Sprintf("%s must list at least one scraper", envProductScrapers))
+	}
+	for _, s := range c.ProductScrapers {
+		if err := validateOneOf(envProductScrapers, s, []string{ProductScraperNative, ProductScraperFirecrawl}); err != nil {
+			issues = append(issues, err.Error())
+			break
+		}
+	}
+	if err := validatePositiveDuration(envPriceMonitorInterval, c.PriceMonitorInterval); err != nil {
+		issues = append(issues, err.Error())
+	}
+	if err := validatePositiveDuration(envPriceMonitorRecheckAfter, c.PriceMonitorRecheckAfter); err != nil {
+		issues = append(issues, err.Error())
+	}
+	if c.PriceMonitorDailyBudget < 0 {
+		issues = append(issues, fmt.Sprintf("%s must be zero (disabled) or positive", envPriceMonitorDailyBudget))
+	}
+	if c.DB.SearchSimilarity <= 0 || c.DB.SearchSimilarity > 1 {
+		issues = append(issues, fmt.Sprintf("%s must be greater than 0 and at most 1", envSearchSimilarity))
+	}
+	if c.PriceMonitorThresholdPercent <= 0 {
+		issues = append(issues, fmt.Sprintf("%s must be a positive percentage", envPriceMonitorThreshold))
+	}
+	if c.HTTPCacheTTLSecs < 0 {
+		issues = append(issues, fmt.Sprintf("%s must be zero (disabled) or positive", envHTTPCacheTTL))
+	}
+	if c.HTTPCacheMaxAgeSecs < 0 {
+		issues = append(issues, fmt.Sprintf("%s must be zero or positive", envHTTPCacheMaxAge))
+	}
+	// Signed media URLs are reused until a quarter of their lifetime is
+	// left; a cached message list must not outlive the URLs it embeds.
+	if c.GiftMessageSignedURLTTLSecs > 0 && c.HTTPCacheTTLSecs+c.HTTPCacheMaxAgeSecs >= c.GiftMessageSignedURLTTLSecs/4 {
+		issues = append(issues, fmt.Sprintf("%s + %s must be less than a quarter of %s (%d)", envHTTPCacheTTL, envHTTPCacheMaxAge, envGiftMessageSignedURLTTL, c.GiftMessageSignedURLTTLSecs/4))
+	}
+	if c.AuditSigningKey != "" {
+		if _, err := c.AuditSigner(); err != nil {
+			issues = append(issues, err.Error())
+		}
+	}
+
+	issues = append(issues, c.invitationIssues()...)
+
+	if c.CheckinSigningKey != "" && len(c.CheckinSigningKey) < minCheckinSigningKeyLen {
+		issues = append(issues, fmt.Sprintf("%s must have at least %d characters", envCheckinSigningKey, minCheckinSigningKeyLen))
+	}
 
 	if (c.SupabaseURL != "") != (c.SupabaseServiceRoleKey != "") {
 		issues = append(issues, fmt.Sprintf("%s e %s precisam ser definidas juntas", envSupabaseURL, envSupabaseServiceRoleKey))
 	}
 
+	issues = append(issues, c.storageIssues()...)
+
 	if c.MercadoPagoAccessToken != "" {
 		if c.MercadoPagoWebhookSecret == "" {
 			issues = append(issues, fmt.Sprintf("%s is required when %s is set (webhooks would be unverifiable)", envMPWebhookSecret, envMPAccessToken))
@@ -259,6 +557,65 @@ func (c Config) validate() error {
 	return nil
 }
 
+// storageIssues checks the settings of the selected StorageBackend only, so
+// leftover variables for another backend never block startup.
+func (c Config) storageIssues() []string {
+	if err := validateOneOf(envStorageBackend, c.StorageBackend, []string{StorageBackendSupabase, StorageBackendLocal, StorageBackendS3}); err != nil {
+		return []string{err.Error()}
+	}
+
+	var issues []string
+	switch c.StorageBackend {
+	case StorageBackendLocal:
+		if strings.TrimSpace(c.LocalStorageDir) == "" {
+			issues = append(issues, fmt.Sprintf("%s is required when %s=%s", envLocalStorageDir, envStorageBackend, StorageBackendLocal))
+		}
+		if len(c.LocalStorageSigningKey) < minLocalStorageSigningKeyLen {
+			issues = append(issues, fmt.Sprintf("%s must have at least %d characters when %s=%s", envLocalStorageSigningKey, minLocalStorageSigningKeyLen, envStorageBackend, StorageBackendLocal))
+		}
+		if err := validateAbsoluteURL(envLocalStoragePublicURL, c.LocalStoragePublicURL); err != nil {
+			issues = append(issues, err.Error())
+		}
+	case StorageBackendS3:
+		missing := missingRequired([]envField{
+			req(envS3Endpoint, c.S3Endpoint),
+			req(envS3Region, c.S3Region),
+			req(envS3Bucket, c.S3Bucket),
+			req(envS3AccessKeyID, c.S3AccessKeyID),
+			req(envS3SecretAccessKey, c.S3SecretAccessKey),
+		})
+		if len(missing) > 0 {
+			issues = append(issues, fmt.Sprintf("%s=%s requires: %s", envStorageBackend, StorageBackendS3, strings.Join(missing, ", ")))
+		}
+		if c.S3Endpoint != "" {
+			if err := validateAbsoluteURL(envS3Endpoint, c.S3Endpoint); err != nil {
+				issues = append(issues, err.Error())
+			}
+		}
+	}
+	return issues
+}
+
+// invitationIssues checks the invitation settings only when invitations are
+// enabled by INVITATION_SIGNING_KEY.
+func (c Config) invitationIssues() []string {
+	if c.InvitationSigningKey == "" {
+		return nil
+	}
+
+	var issues []string
+	if len(c.InvitationSigningKey) < minInvitationSigningKeyLen {
+		issues = append(issues, fmt.Sprintf("%s must have at least %d characters", envInvitationSigningKey, minInvitationSigningKeyLen))
+	}
+	if _, err := c.InvitationExpiry(); err != nil {
+		issues = append(issues, err.Error())
+	}
+	if err := validateAbsoluteURL(envInvitationBaseURL, c.InvitationBaseURL); err != nil {
+		issues = append(issues, err.Error())
+	}
+	return issues
+}
+
 func req(name, value string) envField {
 	return envField{name: name, value: value}
 }
@@ -288,6 +645,14 @@ func validateDuration(name, value string) error {
 	return nil
 }
 
+func validatePositiveDuration(name, value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("%s must be a positive duration (ex: 1h, 30m)", name)
+	}
+	return nil
+}
+
 func validateOneOf(name, value string, allowed []string) error {
 	for _, item := range allowed {
 		if value == item {
@@ -305,6 +670,60 @@ func validateEvoConfig(evoUrl string) error {
 	return nil
 }
 
+func validateAbsoluteURL(name, value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s must be an absolute http(s) URL", name)
+	}
+	return nil
+}
+
+func validateCIDRList(name string, values []string) error {
+	for _, v := range values {
+		if strings.Contains(v, "/") {
+			if _, err := netip.ParsePrefix(v); err != nil {
+				return fmt.Errorf("%s must be a comma-separated list of CIDRs or IPs: %q is invalid", name, v)
+			}
+			continue
+		}
+		if _, err := netip.ParseAddr(v); err != nil {
+			return fmt.Errorf("%s must be a comma-separated list of CIDRs or IPs: %q is invalid", name, v)
+		}
+	}
+	return nil
+}
+
+// AuditSigner decodes AuditSigningKey. It returns nil, nil when unset.
+func (c Config) AuditSigner() (ed25519.PrivateKey, error) {
+	if c.AuditSigningKey == "" {
+		return nil, nil
+	}
+	seed, err := base64.StdEncoding.DecodeString(c.AuditSigningKey)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%s must be a base64-encoded %d-byte Ed25519 seed", envAuditSigningKey, ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// InvitationExpiry parses InvitationExpiresAt.
+func (c Config) InvitationExpiry() (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, c.InvitationExpiresAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 timestamp (ex: 2027-01-10T00:00:00-03:00) when %s is set", envInvitationExpiresAt, envInvitationSigningKey)
+	}
+	return t, nil
+}
+
+func splitList(value string) []string {
+	var out []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
 func getEnv(key string) string {
 	return os.Getenv(key)
 }
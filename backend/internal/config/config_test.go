@@ -1,6 +1,8 @@
 package config
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
 	"strings"
 	"testing"
 )
@@ -12,6 +14,273 @@ func TestValidate(t *testing.T) {
 	t.Run("Should return error for invalid EVO URL", testValidateEvoInvalidURL)
 	t.Run("Should reject sandbox MP credentials in production", testValidateMPSandboxInProd)
 	t.Run("Should reject production MP credentials in non-prod", testValidateMPProdInTest)
+	t.Run("Should reject unknown rate limit backend", testValidateRateLimitBackend)
+	t.Run("Should validate trusted proxy CIDRs", testValidateTrustedProxies)
+	t.Run("Should validate audit signing settings", testValidateAuditSigning)
+	t.Run("Should reject unknown gift message moderation mode", testValidateGiftMessageModeration)
+	t.Run("Should reject unknown guestbook sign scope", testValidateGuestbookSignScope)
+	t.Run("Should validate the selected storage backend", testValidateStorageBackend)
+	t.Run("Should validate price monitor settings", testValidatePriceMonitor)
+	t.Run("Should validate the product scraper chain", testValidateProductScrapers)
+	t.Run("Should validate the search similarity threshold", testValidateSearchSimilarity)
+	t.Run("Should validate HTTP cache lifetimes", testValidateHTTPCache)
+	t.Run("Should validate invitation settings when enabled", testValidateInvitations)
+	t.Run("Should validate the check-in signing key when set", testValidateCheckin)
+}
+
+func testValidateInvitations(t *testing.T) {
+	cfg := validConfig()
+	cfg.InvitationExpiresAt = "not a date"
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("expected invitation settings ignored without a key, got: %v", err)
+	}
+
+	cfg.InvitationSigningKey = strings.Repeat("k", minInvitationSigningKeyLen)
+	cfg.InvitationExpiresAt = "2027-01-10T00:00:00-03:00"
+	cfg.InvitationBaseURL = "https://parasempre.example"
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("expected valid invitation settings, got: %v", err)
+	}
+
+	short := cfg
+	short.InvitationSigningKey = "short"
+	if err := short.validate(); err == nil || !strings.Contains(err.Error(), envInvitationSigningKey) {
+		t.Fatalf("expected %s validation error, got: %v", envInvitationSigningKey, err)
+	}
+
+	for _, v := range []string{"", "2027-01-10"} {
+		bad := cfg
+		bad.InvitationExpiresAt = v
+		if err := bad.validate(); err == nil || !strings.Contains(err.Error(), envInvitationExpiresAt) {
+			t.Fatalf("expected %s validation error for %q, got: %v", envInvitationExpiresAt, v, err)
+		}
+	}
+
+	bad := cfg
+	bad.InvitationBaseURL = "parasempre.example"
+	if err := bad.validate(); err == nil || !strings.Contains(err.Error(), envInvitationBaseURL) {
+		t.Fatalf("expected %s validation error, got: %v", envInvitationBaseURL, err)
+	}
+}
+
+func testValidateCheckin(t *testing.T) {
+	cfg := validConfig()
+	cfg.CheckinSigningKey = strings.Repeat("k", minCheckinSigningKeyLen)
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("expected a valid check-in key, got: %v", err)
+	}
+
+	cfg.CheckinSigningKey = "short"
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), envCheckinSigningKey) {
+		t.Fatalf("expected %s validation error, got: %v", envCheckinSigningKey, err)
+	}
+}
+
+func testValidateHTTPCache(t *testing.T) {
+	cfg := validConfig()
+	cfg.HTTPCacheTTLSecs, cfg.HTTPCacheMaxAgeSecs = 0, 0
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("expected a zero TTL to just disable the cache, got: %v", err)
+	}
+
+	cfg = validConfig()
+	cfg.HTTPCacheTTLSecs = -1
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), envHTTPCacheTTL) {
+		t.Fatalf("expected %s validation error, got: %v", envHTTPCacheTTL, err)
+	}
+
+	// 900s signed URLs are reused until 225s are left.
+	cfg = validConfig()
+	cfg.HTTPCacheTTLSecs, cfg.HTTPCacheMaxAgeSecs = 200, 25
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), envGiftMessageSignedURLTTL) {
+		t.Fatalf("expected %s validation error, got: %v", envGiftMessageSignedURLTTL, err)
+	}
+}
+
+func testValidateSearchSimilarity(t *testing.T) {
+	for _, v := range []float64{0, -0.2, 1.5} {
+		cfg := validConfig()
+		cfg.DB.SearchSimilarity = v
+		if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), envSearchSimilarity) {
+			t.Fatalf("expected %s validation error for %v, got: %v", envSearchSimilarity, v, err)
+		}
+	}
+}
+
+func testValidateProductScrapers(t *testing.T) {
+	cfg := validConfig()
+	cfg.ProductScrapers = []string{ProductScraperFirecrawl, ProductScraperNative}
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("expected firecrawl-first chain to be valid, got: %v", err)
+	}
+
+	for _, scrapers := range [][]string{nil, {ProductScraperNative, "scrapingbee"}} {
+		cfg.ProductScrapers = scrapers
+		if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), envProductScrapers) {
+			t.Fatalf("expected %s validation error for %v, got: %v", envProductScrapers, scrapers, err)
+		}
+	}
+}
+
+func testValidatePriceMonitor(t *testing.T) {
+	cfg := validConfig()
+	cfg.PriceMonitorDailyBudget = 0
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("expected a zero budget to just disable the monitor, got: %v", err)
+	}
+
+	cfg = validConfig()
+	cfg.PriceMonitorDailyBudget = -1
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), envPriceMonitorDailyBudget) {
+		t.Fatalf("expected %s validation error, got: %v", envPriceMonitorDailyBudget, err)
+	}
+
+	cfg = validConfig()
+	cfg.PriceMonitorThresholdPercent = 0
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), envPriceMonitorThreshold) {
+		t.Fatalf("expected %s validation error, got: %v", envPriceMonitorThreshold, err)
+	}
+
+	cfg = validConfig()
+	cfg.PriceMonitorInterval = "soon"
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), envPriceMonitorInterval) {
+		t.Fatalf("expected %s validation error, got: %v", envPriceMonitorInterval, err)
+	}
+}
+
+func testValidateAuditSigning(t *testing.T) {
+	cfg := validConfig()
+	cfg.AuditSigningKey = base64.StdEncoding.EncodeToString(make([]byte, ed25519.SeedSize))
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("expected valid signing key, got: %v", err)
+	}
+	signer, err := cfg.AuditSigner()
+	if err != nil || len(signer) != ed25519.PrivateKeySize {
+		t.Fatalf("expected decoded signer, got %v / %v", signer, err)
+	}
+
+	cfg.AuditSigningKey = "c2hvcnQ="
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), envAuditSigningKey) {
+		t.Fatalf("expected %s validation error, got: %v", envAuditSigningKey, err)
+	}
+
+	cfg = validConfig()
+	cfg.AuditCheckpointInterval = "0s"
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), envAuditCheckpointInterval) {
+		t.Fatalf("expected %s validation error, got: %v", envAuditCheckpointInterval, err)
+	}
+}
+
+func testValidateTrustedProxies(t *testing.T) {
+	cfg := validConfig()
+	cfg.TrustedProxies = []string{"172.16.0.0/12", "10.0.0.5", "::1"}
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("expected valid trusted proxies, got: %v", err)
+	}
+
+	cfg.TrustedProxies = []string{"172.16.0.0/99"}
+	err := cfg.validate()
+	if err == nil || !strings.Contains(err.Error(), envTrustedProxies) {
+		t.Fatalf("expected %s validation error, got: %v", envTrustedProxies, err)
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	got := splitList(" 10.0.0.0/8, ,172.16.0.0/12 ,")
+	if len(got) != 2 || got[0] != "10.0.0.0/8" || got[1] != "172.16.0.0/12" {
+		t.Fatalf("unexpected split: %v", got)
+	}
+	if got := splitList(""); got != nil {
+		t.Fatalf("expected nil for empty input, got %v", got)
+	}
+}
+
+func testValidateRateLimitBackend(t *testing.T) {
+	cfg := validConfig()
+	cfg.RateLimitBackend = "redis"
+
+	err := cfg.validate()
+	if err == nil {
+		t.Fatal("expected error for unknown rate limit backend")
+	}
+	if !strings.Contains(err.Error(), envRateLimitBackend+" must be one of") {
+		t.Errorf("expected error to mention %s, got: %v", envRateLimitBackend, err)
+	}
+
+	cfg.RateLimitBackend = RateLimitBackendPostgres
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("expected postgres backend to be valid, got: %v", err)
+	}
+}
+
+func testValidateGiftMessageModeration(t *testing.T) {
+	cfg := validConfig()
+	cfg.GiftMessageModeration = "manual"
+
+	err := cfg.validate()
+	if err == nil {
+		t.Fatal("expected error for unknown moderation mode")
+	}
+	if !strings.Contains(err.Error(), envGiftMessageModeration+" must be one of") {
+		t.Errorf("expected error to mention %s, got: %v", envGiftMessageModeration, err)
+	}
+
+	cfg.GiftMessageModeration = GiftMessageModerationAll
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("expected all to be valid, got: %v", err)
+	}
+}
+
+func testValidateGuestbookSignScope(t *testing.T) {
+	cfg := validConfig()
+	cfg.GuestbookSignScope = "household"
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), envGuestbookSignScope+" must be one of") {
+		t.Fatalf("expected %s error, got: %v", envGuestbookSignScope, err)
+	}
+
+	cfg.GuestbookSignScope = GuestbookSignScopeUser
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("expected user scope to be valid, got: %v", err)
+	}
+}
+
+func testValidateStorageBackend(t *testing.T) {
+	cfg := validConfig()
+	cfg.StorageBackend = "gcs"
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), envStorageBackend+" must be one of") {
+		t.Fatalf("expected %s validation error, got: %v", envStorageBackend, err)
+	}
+
+	cfg = validConfig()
+	cfg.StorageBackend = StorageBackendLocal
+	cfg.LocalStorageDir = "./data/media"
+	cfg.LocalStorageSigningKey = "short"
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), envLocalStorageSigningKey) {
+		t.Fatalf("expected %s validation error, got: %v", envLocalStorageSigningKey, err)
+	}
+	cfg.LocalStorageSigningKey = strings.Repeat("k", minLocalStorageSigningKeyLen)
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("expected local backend to be valid, got: %v", err)
+	}
+
+	cfg = validConfig()
+	cfg.StorageBackend = StorageBackendS3
+	cfg.S3Endpoint = "minio:9000"
+	err := cfg.validate()
+	if err == nil {
+		t.Fatal("expected error for incomplete s3 config")
+	}
+	for _, want := range []string{envS3AccessKeyID, envS3SecretAccessKey, envS3Endpoint + " must be an absolute"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got: %v", want, err)
+		}
+	}
+	cfg.S3Endpoint = "http://minio:9000"
+	cfg.S3AccessKeyID = "minio"
+	cfg.S3SecretAccessKey = "minio123"
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("expected s3 backend to be valid, got: %v", err)
+	}
 }
 
 func testValidateMPSandboxInProd(t *testing.T) {
@@ -128,6 +397,8 @@ func validConfig() Config {
 			Password: "postgres",
 			Name:     "parasempre",
 			SSLMode:  "disable",
+
+			SearchSimilarity: 0.5,
 		},
 		CORSOrigin: "http://localhost:3000",
 		AppEnv:     "test",
@@ -146,5 +417,27 @@ func validConfig() Config {
 		EvoAPIURL:      "http://localhost:8081",
 		EvoAPIKey:      "secret",
 		EvoAPIInstance: "instance",
+
+		GiftMessageModeration: GiftMessageModerationFlagged,
+		GuestbookSignScope:    GuestbookSignScopeFamily,
+		StorageBackend:        StorageBackendSupabase,
+		LocalStoragePublicURL: "http://localhost:8080",
+		S3Region:              "us-east-1",
+		S3Bucket:              "gift-messages",
+
+		RateLimitBackend: RateLimitBackendMemory,
+
+		AuditCheckpointInterval: "1h",
+
+		ProductScrapers: []string{ProductScraperNative, ProductScraperFirecrawl},
+
+		PriceMonitorInterval:         "6h",
+		PriceMonitorRecheckAfter:     "72h",
+		PriceMonitorDailyBudget:      20,
+		PriceMonitorThresholdPercent: 10,
+
+		GiftMessageSignedURLTTLSecs: 900,
+		HTTPCacheTTLSecs:            30,
+		HTTPCacheMaxAgeSecs:         10,
 	}
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strconv"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/ferjunior7/parasempre/backend/internal/config"
@@ -18,7 +20,7 @@ func Connect(ctx context.Context, dbCfg config.DBConfig) (*pgxpool.Pool, error)
 
 	pgxCfg, err := pgxpool.ParseConfig(connStr)
 	if err != nil {
-		slog.Error("database connect: parse config failed", "error", err)
+		slog.ErrorContext(ctx, "database connect: parse config failed", "error", err)
 		return nil, fmt.Errorf("unable to parse database config: %w", err)
 	}
 
@@ -27,18 +29,26 @@ func Connect(ctx context.Context, dbCfg config.DBConfig) (*pgxpool.Pool, error)
 	pgxCfg.MaxConnLifetime = dbCfg.MaxConnLifetime
 	pgxCfg.MaxConnIdleTime = dbCfg.MaxConnIdleTime
 
+	// Searches match names by pg_trgm word similarity (see package search);
+	// the threshold is a session setting, so every connection gets it.
+	threshold := strconv.FormatFloat(dbCfg.SearchSimilarity, 'f', -1, 64)
+	pgxCfg.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		_, err := conn.Exec(ctx, `SELECT set_config('pg_trgm.word_similarity_threshold', $1, false)`, threshold)
+		return err
+	}
+
 	pool, err := pgxpool.NewWithConfig(ctx, pgxCfg)
 	if err != nil {
-		slog.Error("database connect: create pool failed", "error", err)
+		slog.ErrorContext(ctx, "database connect: create pool failed", "error", err)
 		return nil, fmt.Errorf("unable to create connection pool: %w", err)
 	}
 
 	if err := pool.Ping(ctx); err != nil {
-		slog.Error("database connect: ping failed", "error", err)
+		slog.ErrorContext(ctx, "database connect: ping failed", "error", err)
 		pool.Close()
 		return nil, fmt.Errorf("unable to ping database: %w", err)
 	}
 
-	slog.Info("database connect: pool ready", "host", dbCfg.Host, "db", dbCfg.Name)
+	slog.InfoContext(ctx, "database connect: pool ready", "host", dbCfg.Host, "db", dbCfg.Name)
 	return pool, nil
 }
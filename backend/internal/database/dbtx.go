@@ -36,7 +36,7 @@ func (r *poolTxRunner) RunInTx(ctx context.Context, fn func(tx pgx.Tx) error) er
 
 	if err := fn(tx); err != nil {
 		if rbErr := tx.Rollback(ctx); rbErr != nil {
-			slog.Error("tx rollback failed", "rollback_error", rbErr, "original_error", err)
+			slog.ErrorContext(ctx, "tx rollback failed", "rollback_error", rbErr, "original_error", err)
 		}
 		return err
 	}
@@ -1,76 +1,60 @@
 package gift
 
 import (
-	"bufio"
-	"bytes"
-	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/ferjunior7/parasempre/backend/internal/importmap"
 )
 
-var csvRequiredColumns = []string{"name", "price_brl"}
+// giftImportFields are the columns a gift spreadsheet can map to. The
+// aliases cover the headers registry exports tend to use.
+var giftImportFields = []importmap.Field{
+	{Key: "name", Label: "Nome", Required: true, Aliases: []string{"nome", "produto", "item", "presente", "titulo", "title"}},
+	{Key: "price_brl", Label: "Preço (R$)", Required: true, Aliases: []string{"preco", "valor", "price", "preco brl", "valor brl"}},
+	{Key: "description", Label: "Descrição", Aliases: []string{"descricao", "detalhes", "observacao", "obs"}},
+	{Key: "image_url", Label: "Imagem", Aliases: []string{"imagem", "foto", "image", "url da imagem", "link da imagem"}},
+	{Key: "store_url", Label: "Link da loja", Aliases: []string{"link", "url", "loja", "link da loja", "store"}},
+	{Key: "category", Label: "Categoria", Aliases: []string{"categoria", "secao", "comodo"}},
+	{Key: "tags", Label: "Tags", Aliases: []string{"etiquetas", "marcadores"}},
+}
 
 var httpsURLRegex = regexp.MustCompile(`^https://[^\s]+$`)
 
+// ParseCSVRows reads a CSV whose headers name the fields (or common
+// aliases of them), without a mapping step.
 func ParseCSVRows(r io.Reader) ([]CSVPreviewRow, error) {
-	bufR := bufio.NewReader(r)
-
-	if head, _ := bufR.Peek(3); len(head) >= 3 && head[0] == 0xEF && head[1] == 0xBB && head[2] == 0xBF {
-		_, _ = bufR.Discard(3)
-	}
-
-	reader := csv.NewReader(bufR)
-	reader.Comma = detectDelimiter(bufR)
-	reader.FieldsPerRecord = -1
-	reader.TrimLeadingSpace = true
-
-	header, err := reader.Read()
-	if err == io.EOF {
-		return nil, errors.New("CSV file is empty")
-	}
+	t, err := importmap.ReadCSV(r)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		return nil, err
 	}
-
-	colIndex, err := mapCSVColumns(header)
+	cols, err := importmap.Resolve(giftImportFields, t.Headers, importmap.Suggest(giftImportFields, t.Headers, nil))
 	if err != nil {
 		return nil, err
 	}
+	return parseTableRows(t, cols)
+}
 
-	var rows []CSVPreviewRow
-	lineNum := 2 // header was line 1
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, fmt.Errorf("failed to read CSV line %d: %w", lineNum, err)
-		}
-
-		rows = append(rows, parseCSVRow(record, colIndex, lineNum))
-		lineNum++
+func parseTableRows(t *importmap.Table, cols importmap.Columns) ([]CSVPreviewRow, error) {
+	if len(t.Rows) == 0 {
+		return nil, errors.New("file has no data rows")
 	}
-
-	if len(rows) == 0 {
-		return nil, errors.New("CSV file has no data rows")
+	rows := make([]CSVPreviewRow, len(t.Rows))
+	for i, row := range t.Rows {
+		rows[i] = parseCSVRow(row, cols)
 	}
 	return rows, nil
 }
 
-func parseCSVRow(record []string, colIndex map[string]int, lineNum int) CSVPreviewRow {
-	row := CSVPreviewRow{LineNumber: lineNum}
+func parseCSVRow(record importmap.Row, cols importmap.Columns) CSVPreviewRow {
+	row := CSVPreviewRow{LineNumber: record.Line}
 
 	col := func(key string) string {
-		idx, ok := colIndex[key]
-		if !ok || idx >= len(record) {
-			return ""
-		}
-		return strings.TrimSpace(record[idx])
+		return cols.Get(record, key)
 	}
 
 	name := col("name")
@@ -78,6 +62,8 @@ func parseCSVRow(record []string, colIndex map[string]int, lineNum int) CSVPrevi
 	description := col("description")
 	imageURL := col("image_url")
 	storeURL := col("store_url")
+	category := strings.Join(strings.Fields(col("category")), " ")
+	tags := splitCSVTags(col("tags"))
 
 	row.Input.Name = name
 	if name == "" {
@@ -123,6 +109,29 @@ func parseCSVRow(record []string, colIndex map[string]int, lineNum int) CSVPrevi
 		}
 	}
 
+	if category != "" {
+		if len([]rune(category)) > 60 {
+			row.Errors = append(row.Errors, "category too long (max 60 chars)")
+		} else {
+			c := category
+			row.Input.Category = &c
+		}
+	}
+
+	if len(tags) > 0 {
+		switch {
+		case len(tags) > 20:
+			row.Errors = append(row.Errors, "too many tags (max 20)")
+		default:
+			for _, t := range tags {
+				if len([]rune(t)) > 40 {
+					row.Errors = append(row.Errors, fmt.Sprintf("tag %q too long (max 40 chars)", t))
+				}
+			}
+			row.Input.Tags = tags
+		}
+	}
+
 	if name != "" {
 		row.DedupeKey = NormalizeDedupeKey(name)
 	}
@@ -130,28 +139,14 @@ func parseCSVRow(record []string, colIndex map[string]int, lineNum int) CSVPrevi
 	return row
 }
 
-func detectDelimiter(bufR *bufio.Reader) rune {
-	peek, _ := bufR.Peek(4096)
-	if idx := bytes.IndexAny(peek, "\r\n"); idx >= 0 {
-		peek = peek[:idx]
-	}
-	if bytes.Count(peek, []byte(";")) > bytes.Count(peek, []byte(",")) {
-		return ';'
-	}
-	return ','
-}
-
-func mapCSVColumns(header []string) (map[string]int, error) {
-	index := make(map[string]int, len(header))
-	for i, col := range header {
-		index[strings.TrimSpace(strings.ToLower(col))] = i
-	}
-	for _, required := range csvRequiredColumns {
-		if _, ok := index[required]; !ok {
-			return nil, fmt.Errorf("missing required column: %q", required)
-		}
+// splitCSVTags reads the tags column. The cell is split on "|" or "," —
+// not ";", which is the delimiter of Brazilian Excel exports.
+func splitCSVTags(cell string) []string {
+	if cell == "" {
+		return nil
 	}
-	return index, nil
+	parts := strings.FieldsFunc(cell, func(r rune) bool { return r == '|' || r == ',' })
+	return NormalizeTags(parts)
 }
 
 func parsePriceBRL(s string) (int64, error) {
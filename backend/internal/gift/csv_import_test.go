@@ -1,10 +1,46 @@
 package gift
 
 import (
+	"bytes"
+	"context"
+	"net/http"
 	"strings"
 	"testing"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/ferjunior7/parasempre/backend/internal/importmap"
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
 )
 
+// memoryMappings is an in-memory importmap.Repository.
+type memoryMappings map[string]importmap.Mapping
+
+func (m memoryMappings) Get(ctx context.Context, userID int64, kind string) (importmap.Mapping, error) {
+	return m[kind], nil
+}
+
+func (m memoryMappings) Save(ctx context.Context, userID int64, kind string, mapping importmap.Mapping) error {
+	m[kind] = mapping
+	return nil
+}
+
+func registryXLSX(t *testing.T, rows ...[]any) *bytes.Reader {
+	t.Helper()
+	f := excelize.NewFile()
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow(f.GetSheetName(0), cell, &row); err != nil {
+			t.Fatalf("set row: %v", err)
+		}
+	}
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("write xlsx: %v", err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
 func TestParsePriceBRL(t *testing.T) {
 	cases := []struct {
 		input   string
@@ -156,3 +192,126 @@ func TestParseCSVRows_Empty(t *testing.T) {
 		t.Fatal("expected error for file with only header")
 	}
 }
+
+func TestParseCSVRows_CategoryAndTags(t *testing.T) {
+	csv := `name;price_brl;category;tags
+Mala de viagem;500;  Lua de  mel ;Viagem|viagem|Praia
+Panela;150;;
+Faqueiro;200;Cozinha;"Inox, Presente"
+`
+	rows, err := ParseCSVRows(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("want 3 rows, got %d", len(rows))
+	}
+
+	mala := rows[0].Input
+	if mala.Category == nil || *mala.Category != "Lua de mel" {
+		t.Errorf("want category 'Lua de mel', got %v", mala.Category)
+	}
+	if len(mala.Tags) != 2 || mala.Tags[0] != "Viagem" || mala.Tags[1] != "Praia" {
+		t.Errorf("want tags [Viagem Praia], got %v", mala.Tags)
+	}
+
+	if rows[1].Input.Category != nil || rows[1].Input.Tags != nil {
+		t.Errorf("want no category/tags for blank cells, got %+v", rows[1].Input)
+	}
+
+	if tags := rows[2].Input.Tags; len(tags) != 2 || tags[1] != "Presente" {
+		t.Errorf("want comma-separated tags [Inox Presente], got %v", tags)
+	}
+}
+
+func TestParseCSVRows_RejectsLongCategory(t *testing.T) {
+	csv := "name,price_brl,category\nPanela,150," + strings.Repeat("c", 61) + "\n"
+	rows, err := ParseCSVRows(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows[0].Errors) != 1 || !strings.Contains(rows[0].Errors[0], "category") {
+		t.Errorf("want category length error, got %v", rows[0].Errors)
+	}
+}
+
+func TestServicePreviewImportXLSXSuggestsMapping(t *testing.T) {
+	svc := NewService(&mockRepository{}, &mockTxRunner{}, nil, nil, memoryMappings{})
+	file := registryXLSX(t,
+		[]any{"Produto", "Valor (R$)", "Link", "Cor"},
+		[]any{"Jogo de Panelas", "1.299,90", "https://loja.example.com/panelas", "cor cinza"},
+		[]any{"Taças", 80},
+	)
+
+	preview, err := svc.PreviewImport(context.Background(), file, "lista.xlsx", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cols := preview.Columns
+	if cols == nil || cols.Mapping["name"] != "Produto" || cols.Mapping["price_brl"] != "Valor (R$)" || cols.Mapping["store_url"] != "Link" {
+		t.Fatalf("unexpected suggested mapping %+v", cols)
+	}
+	if len(cols.Mapping) != 3 {
+		t.Errorf("expected \"Cor\" left unmapped, got %v", cols.Mapping)
+	}
+	if preview.Summary.New != 1 || preview.Summary.Invalid != 1 {
+		t.Fatalf("expected 1 new and 1 invalid row (1.299,90 has a thousands dot), got %+v", preview.Summary)
+	}
+	if r := preview.Rows[1]; r.LineNumber != 3 || r.Input.PriceCents != 8000 {
+		t.Errorf("unexpected second row %+v", r)
+	}
+}
+
+func TestServicePreviewImportAsksForMissingColumns(t *testing.T) {
+	svc := NewService(&mockRepository{}, &mockTxRunner{}, nil, nil, nil)
+	csv := "Item;Quanto\nPanela;150\n"
+
+	preview, err := svc.PreviewImport(context.Background(), strings.NewReader(csv), "lista.csv", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(preview.Rows) != 0 || preview.Columns == nil || len(preview.Columns.Missing) != 1 || preview.Columns.Missing[0] != "price_brl" {
+		t.Fatalf("expected only columns with price_brl missing, got %+v", preview)
+	}
+
+	preview, err = svc.PreviewImport(context.Background(), strings.NewReader(csv), "lista.csv",
+		importmap.Mapping{"name": "Item", "price_brl": "Quanto"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if preview.Summary.New != 1 || preview.Rows[0].Input.PriceCents != 15000 {
+		t.Fatalf("expected the chosen mapping applied, got %+v", preview)
+	}
+
+	_, err = svc.PreviewImport(context.Background(), strings.NewReader(csv), "lista.csv",
+		importmap.Mapping{"name": "Item", "price_brl": "Preço"})
+	assertAppError(t, err, http.StatusBadRequest, "")
+
+	_, err = svc.PreviewImport(context.Background(), strings.NewReader(csv), "lista.ods", nil)
+	assertAppError(t, err, http.StatusBadRequest, "")
+}
+
+func TestServiceImportRemembersMapping(t *testing.T) {
+	mappings := memoryMappings{}
+	svc := NewService(&mockRepository{}, &mockTxRunner{}, nil, nil, mappings)
+	ctx := reqctx.WithUserID(context.Background(), 7)
+	chosen := importmap.Mapping{"name": "Item", "price_brl": "Quanto"}
+
+	if _, err := svc.CommitImport(ctx, []CreateGiftInput{{Name: "Panela", PriceCents: 100}}, chosen, ImportSourceCSV, "TST01"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mappings[importmap.KindGift]["price_brl"] != "Quanto" {
+		t.Fatalf("expected mapping remembered on commit, got %v", mappings)
+	}
+
+	preview, err := svc.PreviewImport(ctx, strings.NewReader("ITEM,QUANTO\nTaças,80\n"), "nova.csv", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if preview.Columns.Mapping["price_brl"] != "QUANTO" || preview.Summary.New != 1 {
+		t.Fatalf("expected the remembered mapping suggested, got %+v", preview.Columns)
+	}
+
+	_, err = svc.CommitImport(ctx, []CreateGiftInput{{Name: "Panela", PriceCents: 100}}, importmap.Mapping{"cor": "Cor"}, ImportSourceCSV, "TST01")
+	assertAppError(t, err, http.StatusBadRequest, "")
+}
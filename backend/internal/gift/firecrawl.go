@@ -119,30 +119,30 @@ func (c *FirecrawlClient) ScrapeProduct(ctx context.Context, url string) (*Scrap
 
 	resp, err := c.http.Do(req)
 	if err != nil {
-		slog.Error("firecrawl: request failed", "url", url, "error", err)
+		slog.ErrorContext(ctx, "firecrawl: request failed", "url", url, "error", err)
 		return nil, apperror.Internal("Falha ao consultar serviço de scraping", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		slog.Error("firecrawl: failed to read response body", "url", url, "error", err)
+		slog.ErrorContext(ctx, "firecrawl: failed to read response body", "url", url, "error", err)
 		return nil, apperror.Internal("Falha ao ler resposta do serviço de scraping", err)
 	}
 
 	if resp.StatusCode >= 400 {
-		slog.Error("firecrawl: bad response", "url", url, "status", resp.StatusCode, "body", truncate(string(respBody), 500))
+		slog.ErrorContext(ctx, "firecrawl: bad response", "url", url, "status", resp.StatusCode, "body", truncate(string(respBody), 500))
 		return nil, apperror.Validation("Não conseguimos buscar dados desta URL.")
 	}
 
 	var parsed firecrawlResponse
 	if err := json.Unmarshal(respBody, &parsed); err != nil {
-		slog.Error("firecrawl: failed to parse response", "url", url, "error", err, "body", truncate(string(respBody), 500))
+		slog.ErrorContext(ctx, "firecrawl: failed to parse response", "url", url, "error", err, "body", truncate(string(respBody), 500))
 		return nil, apperror.Internal("Resposta inválida do serviço de scraping", err)
 	}
 
 	if !parsed.Success && parsed.Error != "" {
-		slog.Error("firecrawl: api returned error", "url", url, "error", parsed.Error)
+		slog.ErrorContext(ctx, "firecrawl: api returned error", "url", url, "error", parsed.Error)
 		return nil, apperror.Validation("Não conseguimos buscar dados desta URL.")
 	}
 
@@ -154,7 +154,7 @@ func (c *FirecrawlClient) ScrapeProduct(ctx context.Context, url string) (*Scrap
 		extraction = parsed.Data.LLMExtraction
 	}
 	if extraction == nil {
-		slog.Warn("firecrawl: no extraction in response",
+		slog.WarnContext(ctx, "firecrawl: no extraction in response",
 			"url", url,
 			"warning", parsed.Data.Warning,
 			"raw_body", truncate(string(respBody), 2000),
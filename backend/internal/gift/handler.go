@@ -9,7 +9,9 @@ import (
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
 	"github.com/ferjunior7/parasempre/backend/internal/httputil"
+	"github.com/ferjunior7/parasempre/backend/internal/importmap"
 	"github.com/ferjunior7/parasempre/backend/internal/middleware"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
 	"github.com/ferjunior7/parasempre/backend/internal/validate"
 )
 
@@ -28,8 +30,11 @@ func NewHandler(svc *Service) *Handler {
 
 func (h *Handler) HandleList(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
-	page, _ := strconv.Atoi(q.Get("page"))
-	limit, _ := strconv.Atoi(q.Get("limit"))
+	req, err := pagination.FromQuery(q)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.Validation("invalid cursor"))
+		return
+	}
 
 	active := statusActive
 	filter := ListFilter{Status: &active}
@@ -42,11 +47,21 @@ func (h *Handler) HandleList(w http.ResponseWriter, r *http.Request) {
 	if v, err := strconv.ParseInt(q.Get("price_max"), 10, 64); err == nil {
 		filter.PriceMax = &v
 	}
+	if s := strings.TrimSpace(q.Get("category")); s != "" {
+		filter.Category = &s
+	}
+	// ?tag=a&tag=b and ?tag=a,b both work; a gift must carry every tag.
+	for _, v := range q["tag"] {
+		filter.Tags = append(filter.Tags, strings.Split(v, ",")...)
+	}
+	if v, err := strconv.ParseBool(q.Get("featured")); err == nil {
+		filter.Featured = &v
+	}
 	if s := q.Get("sort"); s != "" {
 		filter.Sort = &s
 	}
 
-	result, err := h.svc.List(r.Context(), page, limit, filter)
+	result, err := h.svc.List(r.Context(), req, filter)
 	if err != nil {
 		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to list gifts", err))
 		return
@@ -60,7 +75,7 @@ func (h *Handler) HandleList(w http.ResponseWriter, r *http.Request) {
 		Data:  public,
 		Page:  result.Page,
 		Limit: result.Limit,
-		Total: result.Total,
+		Meta:  result.Meta,
 	})
 }
 
@@ -143,26 +158,122 @@ func (h *Handler) HandleDelete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-const maxCSVSize = 5 << 20 // 5MB
+func (h *Handler) HandleReorder(w http.ResponseWriter, r *http.Request) {
+	userRACF := middleware.UserRACFFromContext(r.Context())
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+
+	var req ReorderRequest
+	if err := httputil.DecodeJSON(r, &req); err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid reorder payload", err))
+		return
+	}
+
+	if err := h.svc.Reorder(r.Context(), req, userRACF); err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to reorder gifts", err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) HandleListCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.svc.ListCategories(r.Context())
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to list categories", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, categories)
+}
+
+func (h *Handler) HandleCreateCategory(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+
+	var input CategoryInput
+	if err := httputil.DecodeJSON(r, &input); err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid category payload", err))
+		return
+	}
+
+	c, err := h.svc.CreateCategory(r.Context(), input)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to create category", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusCreated, c)
+}
+
+func (h *Handler) HandleUpdateCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid category id", err))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+
+	var input CategoryInput
+	if err := httputil.DecodeJSON(r, &input); err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid category payload", err))
+		return
+	}
+
+	c, err := h.svc.UpdateCategory(r.Context(), id, input)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to update category", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, c)
+}
+
+func (h *Handler) HandleDeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid category id", err))
+		return
+	}
+
+	if err := h.svc.DeleteCategory(r.Context(), id); err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to delete category", err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) HandleListTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.svc.ListTags(r.Context())
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to list tags", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, tags)
+}
+
+const maxImportSize = 5 << 20 // 5MB
 
 func (h *Handler) HandlePreviewImport(w http.ResponseWriter, r *http.Request) {
-	r.Body = http.MaxBytesReader(w, r.Body, maxCSVSize)
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
 
-	if err := r.ParseMultipartForm(maxCSVSize); err != nil {
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
 		httputil.WriteError(w, r, apperror.Validation("invalid multipart form"))
 		return
 	}
 
-	file, _, err := r.FormFile("file")
+	file, header, err := r.FormFile("file")
 	if err != nil {
 		httputil.WriteError(w, r, apperror.Validation("missing \"file\" field"))
 		return
 	}
 	defer file.Close()
 
-	preview, err := h.svc.PreviewImport(r.Context(), file)
+	mapping, err := importmap.ParseMapping(r.FormValue("mapping"))
 	if err != nil {
-		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to preview CSV", err))
+		httputil.WriteError(w, r, apperror.Validation(err.Error()))
+		return
+	}
+
+	preview, err := h.svc.PreviewImport(r.Context(), file, header.Filename, mapping)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to preview import", err))
 		return
 	}
 
@@ -180,7 +291,7 @@ func (h *Handler) HandleCommitImport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.svc.CommitImport(r.Context(), req.Rows, userRACF)
+	resp, err := h.svc.CommitImport(r.Context(), req.Rows, req.Mapping, req.Source, userRACF)
 	if err != nil {
 		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to import gifts", err))
 		return
@@ -220,3 +331,46 @@ func (h *Handler) HandleScrapePreview(w http.ResponseWriter, r *http.Request) {
 
 	httputil.WriteJSON(w, http.StatusOK, preview)
 }
+
+func (h *Handler) HandleURLImport(w http.ResponseWriter, r *http.Request) {
+	userRACF := middleware.UserRACFFromContext(r.Context())
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+
+	var req URLImportRequest
+	if err := httputil.DecodeJSON(r, &req); err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid url import payload", err))
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+
+	if len(req.URLs) > urlImportSyncMax {
+		job, err := h.svc.StartURLImport(r.Context(), req.URLs, userRACF)
+		if err != nil {
+			httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to start url import", err))
+			return
+		}
+		w.Header().Set("Location", "/api/gifts/import/urls/"+job.ID)
+		httputil.WriteJSON(w, http.StatusAccepted, job)
+		return
+	}
+
+	preview, err := h.svc.PreviewURLImport(r.Context(), req.URLs, nil)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to preview url import", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, preview)
+}
+
+func (h *Handler) HandleGetURLImport(w http.ResponseWriter, r *http.Request) {
+	job, err := h.svc.URLImportJob(r.PathValue("jobID"))
+	if err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, job)
+}
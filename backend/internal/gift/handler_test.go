@@ -12,12 +12,13 @@ import (
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
 	"github.com/ferjunior7/parasempre/backend/internal/auth"
 	"github.com/ferjunior7/parasempre/backend/internal/middleware"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
 )
 
 func newTestHandler() (*Handler, *mockRepository, *mockScraper) {
 	repo := &mockRepository{}
 	scraper := &mockScraper{}
-	svc := NewService(repo, &mockTxRunner{}, scraper)
+	svc := NewService(repo, &mockTxRunner{}, scraper, nil, nil)
 	return NewHandler(svc), repo, scraper
 }
 
@@ -42,9 +43,9 @@ func registerTestRoutes(mux *http.ServeMux, h *Handler) {
 func TestHandlerListGifts(t *testing.T) {
 	h, repo, _ := newTestHandler()
 	var gotStatus *string
-	repo.listFn = func(ctx context.Context, filter ListFilter, limit, offset int) ([]Gift, int, error) {
+	repo.listFn = func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
 		gotStatus = filter.Status
-		return []Gift{sampleGift()}, 1, nil
+		return []Gift{sampleGift()}, pagination.Meta{Total: intPtr(1)}, nil
 	}
 
 	req := httptest.NewRequest(http.MethodGet, "/api/gifts?page=1&limit=20", nil)
@@ -62,8 +63,8 @@ func TestHandlerListGifts(t *testing.T) {
 	if len(result.Data) != 1 {
 		t.Fatalf("expected 1 gift, got %d", len(result.Data))
 	}
-	if result.Total != 1 {
-		t.Fatalf("expected total 1, got %d", result.Total)
+	if result.Total == nil || *result.Total != 1 {
+		t.Fatalf("expected total 1, got %v", result.Total)
 	}
 	if gotStatus == nil || *gotStatus != "active" {
 		t.Fatalf("expected forced 'active' filter on public list, got %v", gotStatus)
@@ -72,8 +73,8 @@ func TestHandlerListGifts(t *testing.T) {
 
 func TestHandlerListGiftsDoesNotLeakInternalFields(t *testing.T) {
 	h, repo, _ := newTestHandler()
-	repo.listFn = func(ctx context.Context, filter ListFilter, limit, offset int) ([]Gift, int, error) {
-		return []Gift{sampleGift()}, 1, nil
+	repo.listFn = func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
+		return []Gift{sampleGift()}, pagination.Meta{Total: intPtr(1)}, nil
 	}
 
 	req := httptest.NewRequest(http.MethodGet, "/api/gifts", nil)
@@ -110,12 +111,44 @@ func TestHandlerGetGiftDoesNotLeakInternalFields(t *testing.T) {
 	}
 }
 
+func TestHandlerListGiftsByCursor(t *testing.T) {
+	h, repo, _ := newTestHandler()
+	var got pagination.Request
+	repo.listFn = func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
+		got = req
+		next := "next"
+		return []Gift{sampleGift()}, pagination.Meta{NextCursor: &next}, nil
+	}
+
+	cursor := pagination.Cursor{Order: SortNewest, Values: []string{"2026-01-01 00:00:00+00", "7"}}.Encode()
+	req := httptest.NewRequest(http.MethodGet, "/api/gifts?limit=5&cursor="+cursor, nil)
+	w := httptest.NewRecorder()
+	h.HandleList(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got.Cursor == nil || got.Cursor.Values[1] != "7" || got.Limit != 5 || got.IncludeTotal {
+		t.Fatalf("unexpected request %+v", got)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `"next_cursor":"next"`) || strings.Contains(body, `"total"`) {
+		t.Fatalf("expected a next cursor and no total, got %s", body)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleList(w, httptest.NewRequest(http.MethodGet, "/api/gifts?cursor=!!", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a malformed cursor, got %d", w.Code)
+	}
+}
+
 func TestHandlerListGiftsIgnoresUserProvidedStatus(t *testing.T) {
 	h, repo, _ := newTestHandler()
 	var gotStatus *string
-	repo.listFn = func(ctx context.Context, filter ListFilter, limit, offset int) ([]Gift, int, error) {
+	repo.listFn = func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
 		gotStatus = filter.Status
-		return []Gift{}, 0, nil
+		return []Gift{}, pagination.Meta{Total: intPtr(0)}, nil
 	}
 
 	req := httptest.NewRequest(http.MethodGet, "/api/gifts?status=inactive", nil)
@@ -130,9 +163,9 @@ func TestHandlerListGiftsIgnoresUserProvidedStatus(t *testing.T) {
 func TestHandlerListGiftsParsesSearchAndPriceFilters(t *testing.T) {
 	h, repo, _ := newTestHandler()
 	var got ListFilter
-	repo.listFn = func(ctx context.Context, filter ListFilter, limit, offset int) ([]Gift, int, error) {
+	repo.listFn = func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
 		got = filter
-		return []Gift{}, 0, nil
+		return []Gift{}, pagination.Meta{Total: intPtr(0)}, nil
 	}
 
 	req := httptest.NewRequest(http.MethodGet, "/api/gifts?search=oster&price_min=10000&price_max=40000", nil)
@@ -153,9 +186,9 @@ func TestHandlerListGiftsParsesSearchAndPriceFilters(t *testing.T) {
 func TestHandlerListGiftsIgnoresInvalidFilters(t *testing.T) {
 	h, repo, _ := newTestHandler()
 	var got ListFilter
-	repo.listFn = func(ctx context.Context, filter ListFilter, limit, offset int) ([]Gift, int, error) {
+	repo.listFn = func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
 		got = filter
-		return []Gift{}, 0, nil
+		return []Gift{}, pagination.Meta{Total: intPtr(0)}, nil
 	}
 
 	req := httptest.NewRequest(http.MethodGet, "/api/gifts?search=+++&price_min=abc", nil)
@@ -170,6 +203,57 @@ func TestHandlerListGiftsIgnoresInvalidFilters(t *testing.T) {
 	}
 }
 
+func TestHandlerListGiftsParsesCurationFilters(t *testing.T) {
+	h, repo, _ := newTestHandler()
+	var got ListFilter
+	repo.listFn = func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
+		got = filter
+		return []Gift{}, pagination.Meta{Total: intPtr(0)}, nil
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/gifts?category=cozinha&tag=inox,presente&tag=casa&featured=true&sort=curated", nil)
+	w := httptest.NewRecorder()
+	h.HandleList(w, req)
+
+	if got.Category == nil || *got.Category != "cozinha" {
+		t.Fatalf("expected category 'cozinha', got %v", got.Category)
+	}
+	if len(got.Tags) != 3 || got.Tags[2] != "casa" {
+		t.Fatalf("expected tags [inox presente casa], got %v", got.Tags)
+	}
+	if got.Featured == nil || !*got.Featured {
+		t.Fatalf("expected featured=true, got %v", got.Featured)
+	}
+	if got.Sort == nil || *got.Sort != SortCurated {
+		t.Fatalf("expected sort 'curated', got %v", got.Sort)
+	}
+}
+
+func TestHandlerListGiftsExposesCategoryAndTags(t *testing.T) {
+	h, repo, _ := newTestHandler()
+	repo.listFn = func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
+		g := sampleGift()
+		g.CategoryID, g.CategoryName, g.CategorySlug = int64Ptr(2), strPtr("Cozinha"), strPtr("cozinha")
+		g.Tags = []string{"Inox"}
+		g.Featured = true
+		g.Position = 4
+		return []Gift{g}, pagination.Meta{Total: intPtr(1)}, nil
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/gifts", nil)
+	w := httptest.NewRecorder()
+	h.HandleList(w, req)
+
+	var resp PublicPagedResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	got := resp.Data[0]
+	if got.Category == nil || got.Category.Slug != "cozinha" || len(got.Tags) != 1 || !got.Featured {
+		t.Fatalf("expected category, tags and featured in public gift, got %+v", got)
+	}
+}
+
 func TestHandlerGetGift(t *testing.T) {
 	h, repo, _ := newTestHandler()
 	repo.getByIDFn = func(ctx context.Context, id int64) (*Gift, error) {
@@ -441,7 +525,7 @@ func TestHandlerScrapePreviewValidationError(t *testing.T) {
 
 func TestHandlerScrapePreviewServiceUnavailableWhenScraperNil(t *testing.T) {
 	repo := &mockRepository{}
-	svc := NewService(repo, &mockTxRunner{}, nil)
+	svc := NewService(repo, &mockTxRunner{}, nil, nil, nil)
 	h := NewHandler(svc)
 
 	body := `{"url":"https://shop.example.com/p/1"}`
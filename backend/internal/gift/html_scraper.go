@@ -0,0 +1,514 @@
+package gift
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+)
+
+const (
+	htmlScraperTimeout      = 15 * time.Second
+	htmlScraperMaxBytes     = 2 << 20 // product data sits in <head>; the rest is not needed
+	htmlScraperMaxRedirects = 5
+	htmlScraperUserAgent    = "Mozilla/5.0 (compatible; ParaSempreBot/1.0; +https://nosparasempre.com.br)"
+)
+
+var errBlockedAddress = errors.New("destination address not allowed")
+
+// reservedPrefixes are non-public ranges netip has no predicate for.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64 can reach IPv4 private space
+}
+
+// HTMLScraper reads product data straight from the store page: JSON-LD
+// (schema.org/Product), microdata and OpenGraph tags, which most Brazilian
+// stores publish for search engines. It needs no external service.
+type HTMLScraper struct {
+	http *http.Client
+}
+
+func NewHTMLScraper() *HTMLScraper {
+	return newHTMLScraper(publicAddrOnly)
+}
+
+// newHTMLScraper takes the dial check so tests can reach httptest servers.
+func newHTMLScraper(control func(network, address string, c syscall.RawConn) error) *HTMLScraper {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: control}
+	transport := &http.Transport{
+		// No proxy: the dial check must see the store's address, not a proxy's.
+		Proxy:                  nil,
+		DialContext:            dialer.DialContext,
+		TLSHandshakeTimeout:    5 * time.Second,
+		ResponseHeaderTimeout:  10 * time.Second,
+		MaxResponseHeaderBytes: 64 << 10,
+		ForceAttemptHTTP2:      true,
+	}
+	return &HTMLScraper{http: &http.Client{
+		Timeout:   htmlScraperTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= htmlScraperMaxRedirects {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "https" && req.URL.Scheme != "http" {
+				return errBlockedAddress
+			}
+			return nil
+		},
+	}}
+}
+
+// publicAddrOnly runs after DNS resolution, for every connection including
+// redirects, so a hostname cannot be pointed at internal services.
+func publicAddrOnly(_, address string, _ syscall.RawConn) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return errBlockedAddress
+	}
+	if port != "443" && port != "80" {
+		return errBlockedAddress
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil || !isPublicAddr(ip.Unmap()) {
+		return errBlockedAddress
+	}
+	return nil
+}
+
+func isPublicAddr(ip netip.Addr) bool {
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range reservedPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *HTMLScraper) ScrapeProduct(ctx context.Context, rawURL string) (*ScrapedProduct, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, apperror.Validation("URL inválida — use uma URL https://")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, apperror.Internal("failed to create page request", err)
+	}
+	req.Header.Set("User-Agent", htmlScraperUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.5")
+	req.Header.Set("Accept-Language", "pt-BR,pt;q=0.9,en;q=0.5")
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		if errors.Is(err, errBlockedAddress) {
+			slog.WarnContext(ctx, "html_scraper: blocked destination", "url", rawURL, "error", err)
+			// Wraps errBlockedAddress so ChainScraper stops here.
+			return nil, &apperror.AppError{Code: http.StatusBadRequest, Message: "Este endereço não pode ser consultado.", Err: errBlockedAddress}
+		}
+		slog.ErrorContext(ctx, "html_scraper: request failed", "url", rawURL, "error", err)
+		return nil, apperror.Validation("Não conseguimos buscar dados desta URL.")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		slog.WarnContext(ctx, "html_scraper: bad response", "url", rawURL, "status", resp.StatusCode)
+		return nil, apperror.Validation("Não conseguimos buscar dados desta URL.")
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" && !strings.Contains(strings.ToLower(contentType), "html") {
+		slog.WarnContext(ctx, "html_scraper: not an html page", "url", rawURL, "content_type", contentType)
+		return nil, apperror.Validation("O link não aponta para uma página de produto.")
+	}
+
+	body, err := charset.NewReader(io.LimitReader(resp.Body, htmlScraperMaxBytes), contentType)
+	if err != nil {
+		return nil, apperror.Validation("Não conseguimos ler esta página.")
+	}
+	// A page cut at the size cap still parses; the tail is only layout.
+	doc, err := html.Parse(body)
+	if err != nil {
+		slog.WarnContext(ctx, "html_scraper: parse failed", "url", rawURL, "error", err)
+		return nil, apperror.Validation("Não conseguimos ler esta página.")
+	}
+
+	p := extractProduct(doc, resp.Request.URL)
+	if p.Name == "" && p.PriceBRL == "" {
+		slog.InfoContext(ctx, "html_scraper: no product data", "url", rawURL)
+	}
+	return p, nil
+}
+
+// extractProduct reads JSON-LD first, then microdata, then meta tags; each
+// later source only fills fields the earlier ones left empty.
+func extractProduct(doc *html.Node, base *url.URL) *ScrapedProduct {
+	var page pageData
+	page.walk(doc, scopeNone)
+
+	p := &ScrapedProduct{}
+	for _, raw := range page.jsonLD {
+		if ld := productFromJSONLD(raw); ld != nil {
+			fillMissing(p, ld)
+			break
+		}
+	}
+	fillMissing(p, &page.micro)
+	fillMissing(p, &ScrapedProduct{
+		Name:        firstNonEmpty(page.meta["og:title"], page.meta["twitter:title"], page.title),
+		Description: firstNonEmpty(page.meta["og:description"], page.meta["description"]),
+		PriceBRL:    normalizePrice(firstNonEmpty(page.meta["product:price:amount"], page.meta["og:price:amount"])),
+		ImageURL:    firstNonEmpty(page.meta["og:image:secure_url"], page.meta["og:image"], page.meta["twitter:image"]),
+	})
+
+	p.Name = collapseSpaces(p.Name)
+	p.Description = collapseSpaces(p.Description)
+	p.ImageURL = resolveURL(base, p.ImageURL)
+	return p
+}
+
+// fillMissing copies into dst the fields of src that dst does not have.
+func fillMissing(dst, src *ScrapedProduct) {
+	if src == nil {
+		return
+	}
+	if dst.Name == "" {
+		dst.Name = strings.TrimSpace(src.Name)
+	}
+	if dst.Description == "" {
+		dst.Description = strings.TrimSpace(src.Description)
+	}
+	if dst.PriceBRL == "" {
+		dst.PriceBRL = strings.TrimSpace(src.PriceBRL)
+	}
+	if dst.ImageURL == "" {
+		dst.ImageURL = strings.TrimSpace(src.ImageURL)
+	}
+}
+
+type itemScope int
+
+const (
+	scopeNone itemScope = iota
+	scopeProduct
+	scopeOffer
+	scopeOther // brand, rating, review… whose "name" is not the product's
+)
+
+type pageData struct {
+	jsonLD []string
+	meta   map[string]string
+	title  string
+	micro  ScrapedProduct
+}
+
+func (d *pageData) walk(n *html.Node, scope itemScope) {
+	if n.Type == html.ElementNode {
+		switch n.DataAtom {
+		case atom.Script:
+			if strings.Contains(strings.ToLower(attr(n, "type")), "ld+json") {
+				d.jsonLD = append(d.jsonLD, textContent(n))
+			}
+			return
+		case atom.Meta:
+			d.addMeta(n)
+		case atom.Title:
+			if d.title == "" {
+				d.title = textContent(n)
+			}
+		}
+
+		if scope != scopeNone {
+			d.addItemprop(n, scope)
+		}
+		if hasAttr(n, "itemscope") {
+			scope = childScope(attr(n, "itemtype"), scope)
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		d.walk(c, scope)
+	}
+}
+
+func childScope(itemtype string, parent itemScope) itemScope {
+	t := strings.ToLower(itemtype)
+	switch {
+	case strings.HasSuffix(t, "/product") || strings.HasSuffix(t, "/productgroup"):
+		if parent == scopeNone {
+			return scopeProduct
+		}
+		return scopeOther // related products inside the main one
+	case strings.HasSuffix(t, "/offer") || strings.HasSuffix(t, "/aggregateoffer"):
+		if parent == scopeProduct {
+			return scopeOffer
+		}
+	}
+	if parent == scopeNone {
+		return scopeNone
+	}
+	return scopeOther
+}
+
+func (d *pageData) addMeta(n *html.Node) {
+	key := strings.ToLower(firstNonEmpty(attr(n, "property"), attr(n, "name")))
+	content := strings.TrimSpace(attr(n, "content"))
+	if key == "" || content == "" {
+		return
+	}
+	if d.meta == nil {
+		d.meta = map[string]string{}
+	}
+	if _, ok := d.meta[key]; !ok {
+		d.meta[key] = content
+	}
+}
+
+func (d *pageData) addItemprop(n *html.Node, scope itemScope) {
+	prop := attr(n, "itemprop")
+	if prop == "" {
+		return
+	}
+	for _, p := range strings.Fields(prop) {
+		switch {
+		case scope == scopeProduct && p == "name" && d.micro.Name == "":
+			d.micro.Name = itempropValue(n)
+		case scope == scopeProduct && p == "description" && d.micro.Description == "":
+			d.micro.Description = itempropValue(n)
+		case scope == scopeProduct && p == "image" && d.micro.ImageURL == "":
+			d.micro.ImageURL = itempropValue(n)
+		case (scope == scopeProduct || scope == scopeOffer) && (p == "price" || p == "lowPrice") && d.micro.PriceBRL == "":
+			d.micro.PriceBRL = normalizePrice(itempropValue(n))
+		}
+	}
+}
+
+func itempropValue(n *html.Node) string {
+	if v, ok := attrOK(n, "content"); ok {
+		return strings.TrimSpace(v)
+	}
+	switch n.DataAtom {
+	case atom.Img, atom.Source:
+		return attr(n, "src")
+	case atom.A, atom.Link:
+		return attr(n, "href")
+	}
+	return textContent(n)
+}
+
+// productFromJSONLD finds the first schema.org Product in a JSON-LD block,
+// whether top-level, in an array, under @graph or nested (mainEntity).
+func productFromJSONLD(raw string) *ScrapedProduct {
+	var v any
+	if err := json.Unmarshal([]byte(strings.TrimSpace(raw)), &v); err != nil {
+		return nil
+	}
+	node := findLDProduct(v)
+	if node == nil {
+		return nil
+	}
+	return &ScrapedProduct{
+		Name:        ldString(node["name"]),
+		Description: ldString(node["description"]),
+		PriceBRL:    ldOfferPrice(node["offers"]),
+		ImageURL:    ldImage(node["image"]),
+	}
+}
+
+func findLDProduct(v any) map[string]any {
+	switch t := v.(type) {
+	case map[string]any:
+		if ldIsType(t["@type"], "Product") || ldIsType(t["@type"], "ProductGroup") {
+			return t
+		}
+		for _, child := range t {
+			if p := findLDProduct(child); p != nil {
+				return p
+			}
+		}
+	case []any:
+		for _, child := range t {
+			if p := findLDProduct(child); p != nil {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+func ldIsType(v any, want string) bool {
+	switch t := v.(type) {
+	case string:
+		return strings.EqualFold(strings.TrimPrefix(strings.TrimPrefix(t, "https://schema.org/"), "http://schema.org/"), want)
+	case []any:
+		for _, item := range t {
+			if ldIsType(item, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func ldString(v any) string {
+	switch t := v.(type) {
+	case string:
+		return html.UnescapeString(t)
+	case []any:
+		if len(t) > 0 {
+			return ldString(t[0])
+		}
+	}
+	return ""
+}
+
+func ldImage(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case []any:
+		for _, item := range t {
+			if s := ldImage(item); s != "" {
+				return s
+			}
+		}
+	case map[string]any:
+		return firstNonEmpty(ldString(t["url"]), ldString(t["contentUrl"]))
+	}
+	return ""
+}
+
+// ldOfferPrice picks the price of the first offer in reais (or without a
+// currency), looking into AggregateOffer and priceSpecification.
+func ldOfferPrice(v any) string {
+	switch t := v.(type) {
+	case []any:
+		for _, item := range t {
+			if p := ldOfferPrice(item); p != "" {
+				return p
+			}
+		}
+	case map[string]any:
+		if cur := ldString(t["priceCurrency"]); cur != "" && !strings.EqualFold(cur, "BRL") {
+			return ""
+		}
+		for _, key := range []string{"price", "lowPrice"} {
+			if p := normalizePrice(ldScalar(t[key])); p != "" {
+				return p
+			}
+		}
+		if p := ldOfferPrice(t["priceSpecification"]); p != "" {
+			return p
+		}
+		return ldOfferPrice(t["offers"])
+	}
+	return ""
+}
+
+func ldScalar(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return ""
+}
+
+// normalizePrice rewrites machine-readable prices ("1234.5", the schema.org
+// format) as "1234.50"; anything else, like "R$ 1.234,56" from page text,
+// is left for scrapedPriceCents to parse.
+func normalizePrice(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		if f <= 0 {
+			return ""
+		}
+		return fmt.Sprintf("%.2f", f)
+	}
+	return s
+}
+
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || base == nil {
+		return ref
+	}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return u.String()
+}
+
+func attrOK(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && strings.EqualFold(a.Key, key) {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func attr(n *html.Node, key string) string {
+	v, _ := attrOK(n, key)
+	return v
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	_, ok := attrOK(n, key)
+	return ok
+}
+
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+	return strings.TrimSpace(sb.String())
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,218 @@
+package gift
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+)
+
+// serveFixture serves testdata/name as the product page.
+func serveFixture(t *testing.T, name, contentType string) *httptest.Server {
+	t.Helper()
+	page, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != htmlScraperUserAgent {
+			t.Errorf("unexpected user agent %q", r.Header.Get("User-Agent"))
+		}
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(page)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHTMLScraperFixtures(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		contentType string
+		want        ScrapedProduct
+		wantImage   string // relative to the test server when it starts with "/"
+	}{
+		{
+			fixture:     "product_jsonld_graph.html",
+			contentType: "text/html; charset=utf-8",
+			want: ScrapedProduct{
+				Name:        "Jogo de Panelas Tramontina Solar Inox 5 Peças",
+				Description: "Conjunto em aço inox com fundo triplo & tampas de vidro.",
+				PriceBRL:    "1299.90",
+			},
+			wantImage: "/images/I/panelas-1.jpg",
+		},
+		{
+			fixture:     "product_microdata.html",
+			contentType: "text/html",
+			want: ScrapedProduct{
+				Name:        "Sofá 3 Lugares Retrátil Cinza",
+				Description: "Sofá com assento retrátil e encosto reclinável.",
+				PriceBRL:    "R$ 3.499,00",
+			},
+			wantImage: "http://cdn.example.com/sofa.jpg",
+		},
+		{
+			fixture:     "product_opengraph.html",
+			contentType: "text/html; charset=iso-8859-1",
+			want: ScrapedProduct{
+				Name:        "Liquidificador Oster 1400W",
+				Description: "Jarra de vidro com 3,2 litros e potência de 1400W",
+				PriceBRL:    "349.90",
+			},
+			wantImage: "https://cdn.example.com/liquidificador.jpg",
+		},
+		{
+			fixture:     "no_product.html",
+			contentType: "text/html",
+			want:        ScrapedProduct{Name: "Página não encontrada"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			srv := serveFixture(t, tt.fixture, tt.contentType)
+			got, err := newHTMLScraper(nil).ScrapeProduct(context.Background(), srv.URL+"/p/1")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := tt.want
+			want.ImageURL = tt.wantImage
+			if len(tt.wantImage) > 0 && tt.wantImage[0] == '/' {
+				want.ImageURL = srv.URL + tt.wantImage
+			}
+			if *got != want {
+				t.Errorf("got  %+v\nwant %+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestHTMLScraperPricesParse(t *testing.T) {
+	for _, raw := range []string{"1299.90", "R$ 3.499,00", "349.90"} {
+		if _, err := scrapedPriceCents(raw); err != nil {
+			t.Errorf("scraped price %q does not parse: %v", raw, err)
+		}
+	}
+}
+
+func TestHTMLScraperRejectsNonHTML(t *testing.T) {
+	srv := serveFixture(t, "no_product.html", "application/pdf")
+	_, err := newHTMLScraper(nil).ScrapeProduct(context.Background(), srv.URL)
+	var ae *apperror.AppError
+	if !errors.As(err, &ae) || ae.Code != http.StatusBadRequest {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+}
+
+func TestHTMLScraperBlocksPrivateDestinations(t *testing.T) {
+	srv := serveFixture(t, "product_opengraph.html", "text/html")
+	_, err := NewHTMLScraper().ScrapeProduct(context.Background(), srv.URL)
+	var ae *apperror.AppError
+	if !errors.As(err, &ae) || ae.Code != http.StatusBadRequest || ae.Message != "Este endereço não pode ser consultado." {
+		t.Fatalf("expected loopback destination blocked, got %v", err)
+	}
+}
+
+func TestPublicAddrOnly(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"8.8.8.8:443", true},
+		{"[2606:4700::1111]:443", true},
+		{"8.8.8.8:80", true},
+		{"8.8.8.8:22", false},
+		{"127.0.0.1:443", false},
+		{"10.0.0.5:443", false},
+		{"172.20.1.1:443", false},
+		{"192.168.0.1:80", false},
+		{"169.254.169.254:80", false},
+		{"100.64.0.1:443", false},
+		{"0.0.0.0:443", false},
+		{"[::1]:443", false},
+		{"[fd00::1]:443", false},
+		{"[::ffff:127.0.0.1]:443", false},
+		{"[64:ff9b::a00:1]:443", false},
+	}
+	for _, tt := range tests {
+		err := publicAddrOnly("tcp", tt.address, nil)
+		if (err == nil) != tt.allowed {
+			t.Errorf("%s: allowed=%v, got err=%v", tt.address, tt.allowed, err)
+		}
+	}
+	if isPublicAddr(netip.MustParseAddr("224.0.0.1")) {
+		t.Error("multicast must not be public")
+	}
+}
+
+func TestChainScraperFillsGaps(t *testing.T) {
+	native := &mockScraper{scrapeProductFn: func(ctx context.Context, url string) (*ScrapedProduct, error) {
+		return &ScrapedProduct{Name: "Panela", ImageURL: "https://img.example.com/p.jpg"}, nil
+	}}
+	calls := 0
+	paid := &mockScraper{scrapeProductFn: func(ctx context.Context, url string) (*ScrapedProduct, error) {
+		calls++
+		return &ScrapedProduct{Name: "Outro nome", PriceBRL: "R$ 99,90"}, nil
+	}}
+
+	got, err := ChainScraper{native, paid}.ScrapeProduct(context.Background(), "https://shop.example.com/p")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Name != "Panela" || got.PriceBRL != "R$ 99,90" || got.ImageURL == "" {
+		t.Fatalf("expected first name kept and price filled, got %+v", got)
+	}
+
+	complete := &mockScraper{scrapeProductFn: func(ctx context.Context, url string) (*ScrapedProduct, error) {
+		return &ScrapedProduct{Name: "Panela", PriceBRL: "10,00"}, nil
+	}}
+	calls = 0
+	if _, err := (ChainScraper{complete, paid}).ScrapeProduct(context.Background(), "https://shop.example.com/p"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 0 {
+		t.Fatalf("expected the fallback skipped once name and price are found, got %d calls", calls)
+	}
+}
+
+func TestChainScraperErrors(t *testing.T) {
+	failing := &mockScraper{scrapeProductFn: func(ctx context.Context, url string) (*ScrapedProduct, error) {
+		return nil, apperror.Validation("Não conseguimos buscar dados desta URL.")
+	}}
+	empty := &mockScraper{}
+
+	got, err := ChainScraper{failing, empty}.ScrapeProduct(context.Background(), "https://shop.example.com/p")
+	if err != nil || got == nil {
+		t.Fatalf("expected a later success to win over an earlier error, got %v / %v", got, err)
+	}
+
+	_, err = ChainScraper{failing, failing}.ScrapeProduct(context.Background(), "https://shop.example.com/p")
+	if err == nil {
+		t.Fatal("expected the last error when every scraper fails")
+	}
+}
+
+func TestChainScraperStopsAtBlockedDestination(t *testing.T) {
+	srv := serveFixture(t, "product_opengraph.html", "text/html")
+	calls := 0
+	fallback := &mockScraper{scrapeProductFn: func(ctx context.Context, url string) (*ScrapedProduct, error) {
+		calls++
+		return &ScrapedProduct{Name: "Interno", PriceBRL: "1,00"}, nil
+	}}
+
+	_, err := ChainScraper{NewHTMLScraper(), fallback}.ScrapeProduct(context.Background(), srv.URL)
+	var ae *apperror.AppError
+	if !errors.As(err, &ae) || ae.Message != "Este endereço não pode ser consultado." {
+		t.Fatalf("expected the blocked destination error, got %v", err)
+	}
+	if calls != 0 {
+		t.Fatalf("expected no fallback for a blocked destination, got %d calls", calls)
+	}
+}
@@ -1,6 +1,12 @@
 package gift
 
-import "time"
+import (
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/importmap"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
+	"github.com/ferjunior7/parasempre/backend/internal/search"
+)
 
 type Gift struct {
 	ID          int64      `json:"id"`
@@ -17,31 +23,54 @@ type Gift struct {
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+
+	CategoryID   *int64   `json:"category_id,omitempty"`
+	CategoryName *string  `json:"category_name,omitempty"`
+	CategorySlug *string  `json:"category_slug,omitempty"`
+	Tags         []string `json:"tags"`
+	Position     int      `json:"position"`
+	Featured     bool     `json:"featured"`
+
+	// Highlight marks where the name matched, on search results only.
+	Highlight *search.Highlight `json:"highlight,omitempty"`
 }
 
+// Category and Tags are given by name: unknown names are created on the
+// fly, so a CSV import can introduce "Lua de mel" without a separate step.
 type CreateGiftInput struct {
-	Name        string  `json:"name"        validate:"required,min=1,max=200"`
-	Description *string `json:"description" validate:"omitempty,max=2000"`
-	PriceCents  int64   `json:"price_cents" validate:"required,gt=0"`
-	ImageURL    *string `json:"image_url"   validate:"omitempty,url,startswith=https://"`
-	StoreURL    *string `json:"store_url"   validate:"omitempty,url,startswith=https://"`
-	Status      *string `json:"status"      validate:"omitempty,giftstatus"`
+	Name        string   `json:"name"        validate:"required,min=1,max=200"`
+	Description *string  `json:"description" validate:"omitempty,max=2000"`
+	PriceCents  int64    `json:"price_cents" validate:"required,gt=0"`
+	ImageURL    *string  `json:"image_url"   validate:"omitempty,url,startswith=https://"`
+	StoreURL    *string  `json:"store_url"   validate:"omitempty,url,startswith=https://"`
+	Status      *string  `json:"status"      validate:"omitempty,giftstatus"`
+	Category    *string  `json:"category"    validate:"omitempty,max=60"`
+	Tags        []string `json:"tags"        validate:"omitempty,max=20,dive,max=40"`
+	Position    *int     `json:"position"    validate:"omitempty,gte=0"`
+	Featured    *bool    `json:"featured"`
 }
 
+// UpdateGiftInput leaves nil fields untouched. An empty Category removes
+// the gift from its category; Tags, when present, replaces the whole set.
 type UpdateGiftInput struct {
-	Name        *string `json:"name"        validate:"omitempty,min=1,max=200"`
-	Description *string `json:"description" validate:"omitempty,max=2000"`
-	PriceCents  *int64  `json:"price_cents" validate:"omitempty,gt=0"`
-	ImageURL    *string `json:"image_url"   validate:"omitempty,url,startswith=https://"`
-	StoreURL    *string `json:"store_url"   validate:"omitempty,url,startswith=https://"`
-	Status      *string `json:"status"      validate:"omitempty,giftstatus"`
+	Name        *string   `json:"name"        validate:"omitempty,min=1,max=200"`
+	Description *string   `json:"description" validate:"omitempty,max=2000"`
+	PriceCents  *int64    `json:"price_cents" validate:"omitempty,gt=0"`
+	ImageURL    *string   `json:"image_url"   validate:"omitempty,url,startswith=https://"`
+	StoreURL    *string   `json:"store_url"   validate:"omitempty,url,startswith=https://"`
+	Status      *string   `json:"status"      validate:"omitempty,giftstatus"`
+	Category    *string   `json:"category"    validate:"omitempty,max=60"`
+	Tags        *[]string `json:"tags"        validate:"omitempty,max=20,dive,max=40"`
+	Position    *int      `json:"position"    validate:"omitempty,gte=0"`
+	Featured    *bool     `json:"featured"`
 }
 
+// PagedResponse is a page of gifts. Page is 0 when paging by cursor.
 type PagedResponse struct {
 	Data  []Gift `json:"data"`
-	Page  int    `json:"page"`
+	Page  int    `json:"page,omitempty"`
 	Limit int    `json:"limit"`
-	Total int    `json:"total"`
+	pagination.Meta
 }
 
 type ListFilter struct {
@@ -49,14 +78,65 @@ type ListFilter struct {
 	Search   *string
 	PriceMin *int64
 	PriceMax *int64
+	Category *string  // category slug
+	Tags     []string // tag slugs; a gift must carry all of them
+	Featured *bool
 	Sort     *string
 }
 
 const (
 	SortPriceAsc  = "price_asc"
 	SortPriceDesc = "price_desc"
+	// SortCurated is the couple's order: featured first, then position.
+	SortCurated = "curated"
+	SortNameAsc = "name_asc"
+	SortNewest  = "newest"
+	// SortRelevance ranks search results best match first; it is the
+	// default when searching.
+	SortRelevance = "relevance"
 )
 
+var validSorts = map[string]bool{
+	SortPriceAsc:  true,
+	SortPriceDesc: true,
+	SortCurated:   true,
+	SortNameAsc:   true,
+	SortNewest:    true,
+	SortRelevance: true,
+}
+
+type Category struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	Position  int       `json:"position"`
+	GiftCount int       `json:"gift_count"` // active gifts only
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CategoryInput struct {
+	Name     string `json:"name"     validate:"required,min=1,max=60"`
+	Position *int   `json:"position" validate:"omitempty,gte=0"`
+}
+
+type Tag struct {
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	GiftCount int    `json:"gift_count"` // active gifts only
+}
+
+// ReorderRequest sets position = index for each gift, in the given order.
+type ReorderRequest struct {
+	IDs []int64 `json:"ids" validate:"required,min=1,max=1000,dive,gt=0"`
+}
+
+type PublicCategory struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
 type PublicGift struct {
 	ID          int64     `json:"id"`
 	Name        string    `json:"name"`
@@ -67,9 +147,21 @@ type PublicGift struct {
 	Status      string    `json:"status"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	Category *PublicCategory `json:"category,omitempty"`
+	Tags     []string        `json:"tags"`
+	Featured bool            `json:"featured"`
 }
 
 func (g Gift) ToPublic() PublicGift {
+	var category *PublicCategory
+	if g.CategoryID != nil && g.CategoryName != nil && g.CategorySlug != nil {
+		category = &PublicCategory{ID: *g.CategoryID, Name: *g.CategoryName, Slug: *g.CategorySlug}
+	}
+	tags := g.Tags
+	if tags == nil {
+		tags = []string{}
+	}
 	return PublicGift{
 		ID:          g.ID,
 		Name:        g.Name,
@@ -80,14 +172,17 @@ func (g Gift) ToPublic() PublicGift {
 		Status:      g.Status,
 		CreatedAt:   g.CreatedAt,
 		UpdatedAt:   g.UpdatedAt,
+		Category:    category,
+		Tags:        tags,
+		Featured:    g.Featured,
 	}
 }
 
 type PublicPagedResponse struct {
 	Data  []PublicGift `json:"data"`
-	Page  int          `json:"page"`
+	Page  int          `json:"page,omitempty"`
 	Limit int          `json:"limit"`
-	Total int          `json:"total"`
+	pagination.Meta
 }
 
 type CSVRowStatus string
@@ -104,6 +199,8 @@ type CSVPreviewRow struct {
 	Errors     []string        `json:"errors,omitempty"`
 	Input      CreateGiftInput `json:"input"`
 	DedupeKey  string          `json:"dedupe_key,omitempty"`
+	// SourceURL is the product link a URL import row was scraped from.
+	SourceURL string `json:"source_url,omitempty"`
 }
 
 type CSVSummary struct {
@@ -116,10 +213,25 @@ type CSVSummary struct {
 type CSVPreview struct {
 	Rows    []CSVPreviewRow `json:"rows"`
 	Summary CSVSummary      `json:"summary"`
+	// Columns is the file's header mapping; absent for URL imports.
+	Columns *importmap.Plan `json:"columns,omitempty"`
 }
 
+// ImportSource is the kind of preview a committed import came from.
+type ImportSource string
+
+const (
+	ImportSourceCSV  ImportSource = "csv"
+	ImportSourceXLSX ImportSource = "xlsx"
+	ImportSourceURLs ImportSource = "urls"
+)
+
 type CommitImportRequest struct {
-	Rows []CreateGiftInput `json:"rows" validate:"required,min=1,dive"`
+	Rows    []CreateGiftInput `json:"rows" validate:"required,min=1,dive"`
+	Mapping importmap.Mapping `json:"mapping,omitempty"`
+	// Source is recorded in the audit log; clients that do not send it
+	// get no source there.
+	Source ImportSource `json:"source,omitempty"`
 }
 
 type CommitImportResponse struct {
@@ -127,6 +239,29 @@ type CommitImportResponse struct {
 	Skipped []string `json:"skipped,omitempty"`
 }
 
+const (
+	URLImportStatusRunning = "running"
+	URLImportStatusDone    = "done"
+	URLImportStatusFailed  = "failed"
+)
+
+type URLImportRequest struct {
+	URLs []string `json:"urls" validate:"required,min=1,max=100"`
+}
+
+// URLImportJob tracks an asynchronous URL import. Preview is set once the
+// job is done and has the same shape as a CSV import preview.
+type URLImportJob struct {
+	ID         string      `json:"id"`
+	Status     string      `json:"status"`
+	Total      int         `json:"total"`
+	Done       int         `json:"done"`
+	Preview    *CSVPreview `json:"preview,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+}
+
 type ScrapePreviewRequest struct {
 	URL string `json:"url" validate:"required,url,startswith=https://"`
 }
@@ -138,3 +273,58 @@ type ScrapePreviewResponse struct {
 	ImageURL    string `json:"image_url,omitempty"`
 	StoreURL    string `json:"store_url"`
 }
+
+// Outcomes of a scheduled store-link check (see PriceMonitor).
+const (
+	PriceOK        = "ok"
+	PriceChanged   = "price_changed"
+	PriceNoProduct = "no_product" // page answered but no product/price was found
+	PriceError     = "error"      // the scrape itself failed
+)
+
+type PriceCheck struct {
+	ID                int64     `json:"id"`
+	GiftID            int64     `json:"gift_id"`
+	Outcome           string    `json:"outcome"`
+	ListedPriceCents  int64     `json:"listed_price_cents"`
+	ScrapedPriceCents *int64    `json:"scraped_price_cents,omitempty"`
+	ChangePercent     *float64  `json:"change_percent,omitempty"`
+	Error             *string   `json:"error,omitempty"`
+	CheckedAt         time.Time `json:"checked_at"`
+}
+
+// PriceCheckTarget is an active gift with a store link that is due.
+type PriceCheckTarget struct {
+	GiftID     int64
+	Name       string
+	StoreURL   string
+	PriceCents int64
+	Failures   int // consecutive error/no_product checks so far
+}
+
+type PriceWatchItem struct {
+	GiftID              int64       `json:"gift_id"`
+	Name                string      `json:"name"`
+	StoreURL            *string     `json:"store_url,omitempty"`
+	PriceCents          int64       `json:"price_cents"`
+	Status              string      `json:"status"`
+	ConsecutiveFailures int         `json:"consecutive_failures"`
+	NextCheckAt         time.Time   `json:"next_check_at"`
+	LastCheck           *PriceCheck `json:"last_check,omitempty"`
+}
+
+type PriceReport struct {
+	Items            []PriceWatchItem `json:"items"`
+	ThresholdPercent float64          `json:"threshold_percent"`
+	DailyBudget      int              `json:"daily_budget"`
+	UsedLast24h      int              `json:"used_last_24h"`
+}
+
+type PriceSyncRequest struct {
+	GiftIDs []int64 `json:"gift_ids" validate:"required,min=1,max=200,dive,gt=0"`
+}
+
+type PriceSyncResponse struct {
+	Synced  int      `json:"synced"`
+	Skipped []string `json:"skipped,omitempty"`
+}
@@ -22,3 +22,39 @@ func NormalizeDedupeKey(name string) string {
 func isMark(r rune) bool {
 	return unicode.Is(unicode.Mn, r)
 }
+
+// Slugify turns a category or tag name into its URL key:
+// "Lua de Mel" → "lua-de-mel". Accents are dropped like in dedupe keys.
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range NormalizeDedupeKey(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if b.Len() > 0 && !dash {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// NormalizeTags trims and collapses whitespace, drops empty names and keeps
+// the first spelling of names that share a slug.
+func NormalizeTags(tags []string) []string {
+	out := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		name := strings.Join(strings.Fields(t), " ")
+		slug := Slugify(name)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		out = append(out, name)
+	}
+	return out
+}
@@ -26,3 +26,34 @@ func TestNormalizeDedupeKey(t *testing.T) {
 		})
 	}
 }
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "Lua de Mel", want: "lua-de-mel"},
+		{input: "  Cozinha  ", want: "cozinha"},
+		{input: "Cama, Mesa & Banho", want: "cama-mesa-banho"},
+		{input: "Eletrodomésticos!", want: "eletrodomesticos"},
+		{input: "---", want: ""},
+	}
+	for _, tt := range tests {
+		if got := Slugify(tt.input); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got := NormalizeTags([]string{" Viagem ", "viagem", "", "Café  da manhã", "Cafe da Manha", "!!"})
+	want := []string{"Viagem", "Café da manhã"}
+	if len(got) != len(want) {
+		t.Fatalf("NormalizeTags = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("NormalizeTags[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
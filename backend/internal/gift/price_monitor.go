@@ -0,0 +1,301 @@
+package gift
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/httputil"
+	"github.com/ferjunior7/parasempre/backend/internal/middleware"
+	"github.com/ferjunior7/parasempre/backend/internal/validate"
+)
+
+const (
+	// maxChecksPerRun spreads the daily budget over several runs instead
+	// of spending it all at once.
+	maxChecksPerRun = 25
+	// A run stops after this many scrape errors in a row: the scraper is
+	// probably down or out of credits, and every further call is wasted.
+	maxConsecutiveScrapeErrors = 3
+	maxRunBackoff              = 24 * time.Hour
+	maxRecheckBackoff          = 30 * 24 * time.Hour
+	priceRunTimeout            = 30 * time.Minute
+	priceHistoryLimit          = 100
+	maxCheckErrorLen           = 500
+)
+
+type PriceMonitorConfig struct {
+	// Interval between runs when the previous one went fine.
+	Interval time.Duration
+	// RecheckAfter is how long a checked gift rests before the next check;
+	// it doubles with each consecutive failure of that gift.
+	RecheckAfter time.Duration
+	// DailyBudget caps scrapes over any rolling 24h window.
+	DailyBudget int
+	// ThresholdPercent is the price move that flags a gift.
+	ThresholdPercent float64
+}
+
+// PriceMonitor re-scrapes the store links of active gifts on a schedule,
+// records the price history and flags gifts whose price moved or whose
+// page no longer shows the product.
+type PriceMonitor struct {
+	svc     *Service
+	repo    Repository
+	scraper ProductScraper
+	cfg     PriceMonitorConfig
+	now     func() time.Time
+}
+
+func NewPriceMonitor(svc *Service, repo Repository, scraper ProductScraper, cfg PriceMonitorConfig) *PriceMonitor {
+	return &PriceMonitor{svc: svc, repo: repo, scraper: scraper, cfg: cfg, now: time.Now}
+}
+
+type PriceRunResult struct {
+	Checked         int
+	Flagged         int
+	BudgetExhausted bool
+	// Aborted is set when the run stopped on repeated scrape errors.
+	Aborted bool
+}
+
+// Run checks prices every Interval until ctx is done. A failed or aborted
+// run doubles the wait, up to a day; a clean run resets it.
+func (m *PriceMonitor) Run(ctx context.Context) {
+	delay := m.cfg.Interval
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			runCtx, cancel := context.WithTimeout(ctx, priceRunTimeout)
+			res, err := m.RunOnce(runCtx)
+			cancel()
+			switch {
+			case err != nil:
+				slog.Error("gift.price_monitor run failed", "error", err)
+				delay = min(delay*2, maxRunBackoff)
+			case res.Aborted:
+				delay = min(delay*2, maxRunBackoff)
+				slog.Warn("gift.price_monitor run aborted on scrape errors", "checked", res.Checked, "retry_in", delay)
+			default:
+				delay = m.cfg.Interval
+				if res.Checked > 0 {
+					slog.Info("gift.price_monitor run finished", "checked", res.Checked, "flagged", res.Flagged)
+				}
+			}
+			timer.Reset(delay)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// RunOnce checks the gifts that are due, within what is left of the
+// daily budget.
+func (m *PriceMonitor) RunOnce(ctx context.Context) (PriceRunResult, error) {
+	var res PriceRunResult
+	used, err := m.repo.CountPriceChecksSince(ctx, m.now().Add(-24*time.Hour))
+	if err != nil {
+		return res, err
+	}
+	remaining := m.cfg.DailyBudget - used
+	if remaining <= 0 {
+		res.BudgetExhausted = true
+		slog.InfoContext(ctx, "gift.price_monitor budget exhausted", "used", used, "budget", m.cfg.DailyBudget)
+		return res, nil
+	}
+
+	targets, err := m.repo.ListPriceCheckTargets(ctx, min(remaining, maxChecksPerRun))
+	if err != nil {
+		return res, err
+	}
+
+	errorsInRow := 0
+	for _, t := range targets {
+		check := m.check(ctx, t)
+		if ctx.Err() != nil {
+			// Cancelled mid-scrape: not the store's fault, do not record.
+			return res, nil
+		}
+
+		failures := 0
+		if check.Outcome == PriceError || check.Outcome == PriceNoProduct {
+			failures = t.Failures + 1
+		}
+		if _, err := m.repo.RecordPriceCheck(ctx, check, failures, m.nextCheckAt(failures)); err != nil {
+			return res, err
+		}
+		res.Checked++
+		if check.Outcome != PriceOK {
+			res.Flagged++
+		}
+
+		if check.Outcome == PriceError {
+			errorsInRow++
+			if errorsInRow >= maxConsecutiveScrapeErrors {
+				res.Aborted = true
+				return res, nil
+			}
+		} else {
+			errorsInRow = 0
+		}
+	}
+	return res, nil
+}
+
+func (m *PriceMonitor) check(ctx context.Context, t PriceCheckTarget) PriceCheck {
+	check := PriceCheck{GiftID: t.GiftID, ListedPriceCents: t.PriceCents}
+
+	scraped, err := m.scraper.ScrapeProduct(ctx, t.StoreURL)
+	if err != nil {
+		msg := truncateRunes(err.Error(), maxCheckErrorLen)
+		check.Outcome, check.Error = PriceError, &msg
+		slog.WarnContext(ctx, "gift.price_monitor check: scrape failed", "gift_id", t.GiftID, "error", err)
+		return check
+	}
+
+	cents, err := scrapedPriceCents(scraped.PriceBRL)
+	if err != nil {
+		// Sold-out and removed pages usually keep the title but drop the
+		// price, so either way the gift cannot be bought there.
+		msg := "produto ou preço não encontrado na página"
+		check.Outcome, check.Error = PriceNoProduct, &msg
+		return check
+	}
+
+	change := math.Round(float64(cents-t.PriceCents)/float64(t.PriceCents)*10000) / 100
+	check.ScrapedPriceCents, check.ChangePercent = &cents, &change
+	check.Outcome = PriceOK
+	if math.Abs(change) > m.cfg.ThresholdPercent {
+		check.Outcome = PriceChanged
+	}
+	return check
+}
+
+// nextCheckAt backs off failing links: RecheckAfter × 2^failures, capped.
+func (m *PriceMonitor) nextCheckAt(failures int) time.Time {
+	wait := m.cfg.RecheckAfter
+	for range min(failures, 10) {
+		wait *= 2
+		if wait >= maxRecheckBackoff {
+			wait = maxRecheckBackoff
+			break
+		}
+	}
+	return m.now().Add(wait)
+}
+
+func (m *PriceMonitor) Report(ctx context.Context) (*PriceReport, error) {
+	items, err := m.repo.ListPriceWatches(ctx, nil)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("failed to load price report", err)
+	}
+	used, err := m.repo.CountPriceChecksSince(ctx, m.now().Add(-24*time.Hour))
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("failed to load price report", err)
+	}
+	return &PriceReport{
+		Items:            items,
+		ThresholdPercent: m.cfg.ThresholdPercent,
+		DailyBudget:      m.cfg.DailyBudget,
+		UsedLast24h:      used,
+	}, nil
+}
+
+func (m *PriceMonitor) History(ctx context.Context, giftID int64) ([]PriceCheck, error) {
+	checks, err := m.repo.ListPriceHistory(ctx, giftID, priceHistoryLimit)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("failed to load price history", err)
+	}
+	return checks, nil
+}
+
+// Sync sets each flagged gift's price to the one last found in its store,
+// through Service.Update so the change is audited like a manual edit.
+func (m *PriceMonitor) Sync(ctx context.Context, req PriceSyncRequest, userRACF string) (*PriceSyncResponse, error) {
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+	watches, err := m.repo.ListPriceWatches(ctx, req.GiftIDs)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("failed to load price watches", err)
+	}
+	byID := make(map[int64]PriceWatchItem, len(watches))
+	for _, w := range watches {
+		byID[w.GiftID] = w
+	}
+
+	resp := &PriceSyncResponse{}
+	for _, id := range req.GiftIDs {
+		w, ok := byID[id]
+		switch {
+		case !ok:
+			resp.Skipped = append(resp.Skipped, fmt.Sprintf("presente %d não monitorado", id))
+			continue
+		case w.Status != PriceChanged || w.LastCheck == nil || w.LastCheck.ScrapedPriceCents == nil:
+			resp.Skipped = append(resp.Skipped, fmt.Sprintf("%q sem alteração de preço pendente", w.Name))
+			continue
+		}
+
+		price := *w.LastCheck.ScrapedPriceCents
+		if _, err := m.svc.Update(ctx, id, UpdateGiftInput{PriceCents: &price}, userRACF); err != nil {
+			return nil, err
+		}
+		if err := m.repo.MarkPriceSynced(ctx, id); err != nil {
+			return nil, apperror.WrapIfNotApp("failed to mark price synced", err)
+		}
+		resp.Synced++
+	}
+
+	slog.InfoContext(ctx, "gift.price_monitor sync: done",
+		"requested", len(req.GiftIDs), "synced", resp.Synced, "skipped", len(resp.Skipped), "user_racf", userRACF)
+	return resp, nil
+}
+
+func (m *PriceMonitor) HandleReport(w http.ResponseWriter, r *http.Request) {
+	report, err := m.Report(r.Context())
+	if err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, report)
+}
+
+func (m *PriceMonitor) HandleHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid gift id", err))
+		return
+	}
+	checks, err := m.History(r.Context(), id)
+	if err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, checks)
+}
+
+func (m *PriceMonitor) HandleSync(w http.ResponseWriter, r *http.Request) {
+	userRACF := middleware.UserRACFFromContext(r.Context())
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+
+	var req PriceSyncRequest
+	if err := httputil.DecodeJSON(r, &req); err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid price sync payload", err))
+		return
+	}
+
+	resp, err := m.Sync(r.Context(), req, userRACF)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to sync prices", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,188 @@
+package gift
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var priceTestNow = time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestPriceMonitor(repo *mockRepository, scrapeFn func(ctx context.Context, url string) (*ScrapedProduct, error)) *PriceMonitor {
+	svc := NewService(repo, &mockTxRunner{}, nil, nil, nil)
+	m := NewPriceMonitor(svc, repo, &mockScraper{scrapeProductFn: scrapeFn}, PriceMonitorConfig{
+		Interval:         6 * time.Hour,
+		RecheckAfter:     72 * time.Hour,
+		DailyBudget:      20,
+		ThresholdPercent: 10,
+	})
+	m.now = func() time.Time { return priceTestNow }
+	return m
+}
+
+func priceTarget(id int64, priceCents int64) PriceCheckTarget {
+	return PriceCheckTarget{GiftID: id, Name: "Presente", StoreURL: "https://shop.example.com/p", PriceCents: priceCents}
+}
+
+func TestPriceMonitorRunOnceOutcomes(t *testing.T) {
+	tests := []struct {
+		name        string
+		listed      int64
+		scraped     *ScrapedProduct
+		scrapeErr   error
+		wantOutcome string
+		wantChange  float64
+	}{
+		{name: "within threshold", listed: 10000, scraped: &ScrapedProduct{Name: "Panela", PriceBRL: "105,00"}, wantOutcome: PriceOK, wantChange: 5},
+		{name: "price went up", listed: 10000, scraped: &ScrapedProduct{Name: "Panela", PriceBRL: "R$ 125,50"}, wantOutcome: PriceChanged, wantChange: 25.5},
+		{name: "price went down", listed: 30000, scraped: &ScrapedProduct{Name: "Panela", PriceBRL: "199,99"}, wantOutcome: PriceChanged, wantChange: -33.34},
+		{name: "no price on page", listed: 10000, scraped: &ScrapedProduct{Name: "Panela"}, wantOutcome: PriceNoProduct},
+		{name: "empty page", listed: 10000, scraped: &ScrapedProduct{}, wantOutcome: PriceNoProduct},
+		{name: "scrape error", listed: 10000, scrapeErr: errors.New("firecrawl: 500"), wantOutcome: PriceError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRepository{priceTargets: []PriceCheckTarget{priceTarget(1, tt.listed)}}
+			m := newTestPriceMonitor(repo, func(ctx context.Context, url string) (*ScrapedProduct, error) {
+				return tt.scraped, tt.scrapeErr
+			})
+
+			res, err := m.RunOnce(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Checked != 1 || len(repo.priceChecks) != 1 {
+				t.Fatalf("expected one recorded check, got %+v", res)
+			}
+			c := repo.priceChecks[0]
+			if c.Outcome != tt.wantOutcome {
+				t.Fatalf("expected outcome %q, got %q", tt.wantOutcome, c.Outcome)
+			}
+			if tt.wantOutcome == PriceOK || tt.wantOutcome == PriceChanged {
+				if c.ChangePercent == nil || *c.ChangePercent != tt.wantChange {
+					t.Errorf("expected change %.2f%%, got %v", tt.wantChange, c.ChangePercent)
+				}
+				if repo.priceFailures[1] != 0 {
+					t.Errorf("expected failures reset, got %d", repo.priceFailures[1])
+				}
+			} else {
+				if c.ScrapedPriceCents != nil || c.Error == nil {
+					t.Errorf("expected error detail and no scraped price, got %+v", c)
+				}
+				if repo.priceFailures[1] != 1 {
+					t.Errorf("expected one failure, got %d", repo.priceFailures[1])
+				}
+			}
+		})
+	}
+}
+
+func TestPriceMonitorRespectsDailyBudget(t *testing.T) {
+	repo := &mockRepository{priceUsed: 18}
+	for i := range 5 {
+		repo.priceTargets = append(repo.priceTargets, priceTarget(int64(i+1), 10000))
+	}
+	calls := 0
+	m := newTestPriceMonitor(repo, func(ctx context.Context, url string) (*ScrapedProduct, error) {
+		calls++
+		return &ScrapedProduct{Name: "Panela", PriceBRL: "100,00"}, nil
+	})
+
+	res, err := m.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 || res.Checked != 2 {
+		t.Fatalf("expected the 2 remaining credits spent, got %d scrapes", calls)
+	}
+
+	res, err = m.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.BudgetExhausted || calls != 2 {
+		t.Fatalf("expected no scrapes once the budget is spent, got %+v after %d scrapes", res, calls)
+	}
+}
+
+func TestPriceMonitorAbortsOnRepeatedErrors(t *testing.T) {
+	repo := &mockRepository{}
+	for i := range 6 {
+		repo.priceTargets = append(repo.priceTargets, priceTarget(int64(i+1), 10000))
+	}
+	m := newTestPriceMonitor(repo, func(ctx context.Context, url string) (*ScrapedProduct, error) {
+		return nil, errors.New("firecrawl: 402 payment required")
+	})
+
+	res, err := m.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.Aborted || res.Checked != maxConsecutiveScrapeErrors {
+		t.Fatalf("expected abort after %d errors, got %+v", maxConsecutiveScrapeErrors, res)
+	}
+}
+
+func TestPriceMonitorBacksOffFailingLinks(t *testing.T) {
+	target := priceTarget(1, 10000)
+	target.Failures = 4
+	repo := &mockRepository{priceTargets: []PriceCheckTarget{target}}
+	m := newTestPriceMonitor(repo, func(ctx context.Context, url string) (*ScrapedProduct, error) {
+		return &ScrapedProduct{Name: "Panela"}, nil
+	})
+
+	if _, err := m.RunOnce(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.priceFailures[1] != 5 {
+		t.Fatalf("expected failures to grow to 5, got %d", repo.priceFailures[1])
+	}
+	if want := priceTestNow.Add(maxRecheckBackoff); !repo.priceNext[1].Equal(want) {
+		t.Errorf("expected next check capped at %v, got %v", want, repo.priceNext[1])
+	}
+
+	if got := m.nextCheckAt(0); !got.Equal(priceTestNow.Add(72 * time.Hour)) {
+		t.Errorf("expected a healthy link rechecked after 72h, got %v", got)
+	}
+	if got := m.nextCheckAt(1); !got.Equal(priceTestNow.Add(144 * time.Hour)) {
+		t.Errorf("expected one failure to double the wait, got %v", got)
+	}
+}
+
+func TestPriceMonitorSync(t *testing.T) {
+	newPrice := int64(12990)
+	var updated []int64
+	repo := &mockRepository{
+		priceWatches: []PriceWatchItem{
+			{GiftID: 1, Name: "Panela", Status: PriceChanged, LastCheck: &PriceCheck{ScrapedPriceCents: &newPrice}},
+			{GiftID: 2, Name: "Mixer", Status: PriceNoProduct, LastCheck: &PriceCheck{}},
+		},
+		updateFn: func(ctx context.Context, id int64, input UpdateGiftInput, dedupeKey *string, userRACF string) (*Gift, error) {
+			if input.PriceCents == nil || *input.PriceCents != newPrice || input.Name != nil {
+				t.Fatalf("expected only the price updated, got %+v", input)
+			}
+			updated = append(updated, id)
+			g := sampleGift()
+			g.ID = id
+			return &g, nil
+		},
+	}
+	m := newTestPriceMonitor(repo, nil)
+
+	resp, err := m.Sync(context.Background(), PriceSyncRequest{GiftIDs: []int64{1, 2, 3}}, "TST01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Synced != 1 || len(resp.Skipped) != 2 {
+		t.Fatalf("expected 1 synced and 2 skipped, got %+v", resp)
+	}
+	if len(updated) != 1 || updated[0] != 1 || len(repo.priceSynced) != 1 || repo.priceSynced[0] != 1 {
+		t.Fatalf("expected gift 1 updated and marked synced, got updated=%v synced=%v", updated, repo.priceSynced)
+	}
+
+	_, err = m.Sync(context.Background(), PriceSyncRequest{}, "TST01")
+	assertAppError(t, err, http.StatusBadRequest, "")
+}
@@ -2,18 +2,41 @@ package gift
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
 )
 
 type Repository interface {
-	List(ctx context.Context, filter ListFilter, limit, offset int) ([]Gift, int, error)
+	List(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error)
 	GetByID(ctx context.Context, id int64) (*Gift, error)
 	Create(ctx context.Context, input CreateGiftInput, dedupeKey, userRACF string) (*Gift, error)
 	Update(ctx context.Context, id int64, input UpdateGiftInput, dedupeKey *string, userRACF string) (*Gift, error)
 	Delete(ctx context.Context, id int64, userRACF string) error
 	FindByDedupeKeys(ctx context.Context, keys []string) (map[string]bool, error)
 	BulkCreate(ctx context.Context, inputs []CreateGiftInput, dedupeKeys []string, userRACF string) ([]Gift, error)
+	// Reorder sets position = index for ids and returns how many gifts
+	// were updated.
+	Reorder(ctx context.Context, ids []int64, userRACF string) (int64, error)
+
+	ListCategories(ctx context.Context) ([]Category, error)
+	CreateCategory(ctx context.Context, input CategoryInput, slug string) (*Category, error)
+	UpdateCategory(ctx context.Context, id int64, input CategoryInput, slug string) (*Category, error)
+	DeleteCategory(ctx context.Context, id int64) error
+	ListTags(ctx context.Context) ([]Tag, error)
+
+	ListPriceCheckTargets(ctx context.Context, limit int) ([]PriceCheckTarget, error)
+	// RecordPriceCheck appends to the price history and moves the gift's
+	// watch to the check's outcome.
+	RecordPriceCheck(ctx context.Context, check PriceCheck, failures int, nextCheckAt time.Time) (*PriceCheck, error)
+	CountPriceChecksSince(ctx context.Context, since time.Time) (int, error)
+	// ListPriceWatches returns the watches of giftIDs, or every flagged
+	// (not ok) watch when giftIDs is empty.
+	ListPriceWatches(ctx context.Context, giftIDs []int64) ([]PriceWatchItem, error)
+	ListPriceHistory(ctx context.Context, giftID int64, limit int) ([]PriceCheck, error)
+	MarkPriceSynced(ctx context.Context, giftID int64) error
 }
 
 type TxAwareRepository interface {
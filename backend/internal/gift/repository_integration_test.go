@@ -5,12 +5,14 @@ package gift
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
 	"github.com/ferjunior7/parasempre/backend/internal/database"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
 )
 
 func setupRepo(t *testing.T) (*PostgresRepository, context.Context) {
@@ -18,9 +20,21 @@ func setupRepo(t *testing.T) (*PostgresRepository, context.Context) {
 	pool := database.NewTestPool(t)
 	database.CleanTable(t, pool, "gift_transactions")
 	database.CleanTable(t, pool, "gifts")
+	database.CleanTable(t, pool, "gift_categories")
+	database.CleanTable(t, pool, "gift_tags")
 	return NewPostgresRepository(pool), context.Background()
 }
 
+// listPage lists the first page by number, with the total, as the admin
+// table does.
+func listPage(ctx context.Context, repo *PostgresRepository, filter ListFilter, limit int) ([]Gift, int, error) {
+	gifts, meta, err := repo.List(ctx, filter, pagination.Request{Page: 1, Limit: limit, IncludeTotal: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	return gifts, *meta.Total, nil
+}
+
 func TestIntegrationCreateAndGet(t *testing.T) {
 	repo, ctx := setupRepo(t)
 
@@ -261,7 +275,7 @@ func TestIntegrationListExcludesDeleted(t *testing.T) {
 		t.Fatalf("Delete failed: %v", err)
 	}
 
-	gifts, total, err := repo.List(ctx, ListFilter{}, 10, 0)
+	gifts, total, err := listPage(ctx, repo, ListFilter{}, 10)
 	if err != nil {
 		t.Fatalf("List failed: %v", err)
 	}
@@ -361,7 +375,7 @@ func TestIntegrationListPaginationAndStatusFilter(t *testing.T) {
 		}
 	}
 
-	gifts, total, err := repo.List(ctx, ListFilter{}, 2, 0)
+	gifts, total, err := listPage(ctx, repo, ListFilter{}, 2)
 	if err != nil {
 		t.Fatalf("List failed: %v", err)
 	}
@@ -372,7 +386,7 @@ func TestIntegrationListPaginationAndStatusFilter(t *testing.T) {
 		t.Fatalf("expected 2 gifts in page, got %d", len(gifts))
 	}
 
-	inactiveGifts, inactiveTotal, err := repo.List(ctx, ListFilter{Status: &inactive}, 10, 0)
+	inactiveGifts, inactiveTotal, err := listPage(ctx, repo, ListFilter{Status: &inactive}, 10)
 	if err != nil {
 		t.Fatalf("List with status filter failed: %v", err)
 	}
@@ -389,6 +403,124 @@ func TestIntegrationListPaginationAndStatusFilter(t *testing.T) {
 	}
 }
 
+func TestIntegrationListCursorPaging(t *testing.T) {
+	repo, ctx := setupRepo(t)
+
+	// Ties on price make the id tie-breaker matter.
+	for i, price := range []int64{3000, 1000, 2000, 1000, 2000} {
+		input := CreateGiftInput{Name: fmt.Sprintf("Gift %d", i), PriceCents: price}
+		if _, err := repo.Create(ctx, input, fmt.Sprintf("gift %d", i), "TST01"); err != nil {
+			t.Fatalf("Create %d failed: %v", i, err)
+		}
+	}
+	sort := SortPriceAsc
+	filter := ListFilter{Sort: &sort}
+	all, _, err := listPage(ctx, repo, filter, 10)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+
+	var walked []Gift
+	req := pagination.Request{Page: 1, Limit: 2}
+	var pages []pagination.Meta
+	for {
+		gifts, meta, err := repo.List(ctx, filter, req)
+		if err != nil {
+			t.Fatalf("List page %d failed: %v", len(pages)+1, err)
+		}
+		if meta.Total != nil {
+			t.Fatalf("expected no total unless asked, got %d", *meta.Total)
+		}
+		walked = append(walked, gifts...)
+		pages = append(pages, meta)
+		if meta.NextCursor == nil {
+			break
+		}
+		// A gift created meanwhile must not shift the pages.
+		if len(pages) == 1 {
+			if _, err := repo.Create(ctx, CreateGiftInput{Name: "Novo", PriceCents: 500}, "novo", "TST01"); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+		}
+		c, err := pagination.Decode(*meta.NextCursor)
+		if err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		req = pagination.Request{Limit: 2, Cursor: c}
+	}
+
+	if len(pages) != 3 || len(walked) != len(all) {
+		t.Fatalf("expected 3 pages with %d gifts, got %d pages with %d", len(all), len(pages), len(walked))
+	}
+	for i := range all {
+		if walked[i].ID != all[i].ID {
+			t.Fatalf("position %d: expected gift %d, got %d", i, all[i].ID, walked[i].ID)
+		}
+	}
+	if pages[0].PrevCursor != nil {
+		t.Fatal("expected no prev cursor on the first page")
+	}
+
+	c, _ := pagination.Decode(*pages[2].PrevCursor)
+	back, meta, err := repo.List(ctx, filter, pagination.Request{Limit: 2, Cursor: c})
+	if err != nil {
+		t.Fatalf("List backwards failed: %v", err)
+	}
+	if len(back) != 2 || back[0].ID != all[2].ID || back[1].ID != all[3].ID {
+		t.Fatalf("expected the second page going back, got %+v", back)
+	}
+	if meta.NextCursor == nil || meta.PrevCursor == nil {
+		t.Fatalf("expected cursors both ways, got %+v", meta)
+	}
+
+	newest := SortNewest
+	if _, _, err := repo.List(ctx, ListFilter{Sort: &newest}, pagination.Request{Limit: 2, Cursor: c}); !errors.Is(err, pagination.ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor for a cursor of another sort, got %v", err)
+	}
+	tampered := &pagination.Cursor{Order: SortPriceAsc, Values: []string{"caro", "1"}}
+	if _, _, err := repo.List(ctx, filter, pagination.Request{Limit: 2, Cursor: tampered}); !errors.Is(err, pagination.ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor for a tampered cursor, got %v", err)
+	}
+}
+
+func TestIntegrationListFuzzySearch(t *testing.T) {
+	repo, ctx := setupRepo(t)
+
+	for _, name := range []string{"Panela de Pressão", "Jogo de Panelas Tramontina", "Batedeira Oster", "Cafeteira Elétrica"} {
+		if _, err := repo.Create(ctx, CreateGiftInput{Name: name, PriceCents: 10000}, NormalizeDedupeKey(name), "TST01"); err != nil {
+			t.Fatalf("Create %q failed: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		first string
+		total int
+	}{
+		{"jogo de panela", "Jogo de Panelas Tramontina", 1},
+		{"PRESSAO", "Panela de Pressão", 1},
+		{"eletrica", "Cafeteira Elétrica", 1},
+		{"batedera", "Batedeira Oster", 1},
+		// The exact word outranks "Panelas".
+		{"panela", "Panela de Pressão", 2},
+		{"100%", "", 0},
+	}
+	for _, tt := range tests {
+		q := tt.query
+		gifts, total, err := listPage(ctx, repo, ListFilter{Search: &q}, 10)
+		if err != nil {
+			t.Fatalf("List %q failed: %v", q, err)
+		}
+		if total != tt.total {
+			t.Errorf("%q: expected %d results, got %d (%+v)", q, tt.total, total, gifts)
+			continue
+		}
+		if tt.total > 0 && gifts[0].Name != tt.first {
+			t.Errorf("%q: expected %q first, got %q", q, tt.first, gifts[0].Name)
+		}
+	}
+}
+
 func TestIntegrationListSearchAndPriceFilter(t *testing.T) {
 	repo, ctx := setupRepo(t)
 
@@ -407,7 +539,7 @@ func TestIntegrationListSearchAndPriceFilter(t *testing.T) {
 	}
 
 	search := "oster"
-	gifts, total, err := repo.List(ctx, ListFilter{Search: &search}, 10, 0)
+	gifts, total, err := listPage(ctx, repo, ListFilter{Search: &search}, 10)
 	if err != nil {
 		t.Fatalf("List search failed: %v", err)
 	}
@@ -417,7 +549,7 @@ func TestIntegrationListSearchAndPriceFilter(t *testing.T) {
 
 	min := int64(20000)
 	max := int64(40000)
-	priced, pricedTotal, err := repo.List(ctx, ListFilter{PriceMin: &min, PriceMax: &max}, 10, 0)
+	priced, pricedTotal, err := listPage(ctx, repo, ListFilter{PriceMin: &min, PriceMax: &max}, 10)
 	if err != nil {
 		t.Fatalf("List price filter failed: %v", err)
 	}
@@ -425,7 +557,7 @@ func TestIntegrationListSearchAndPriceFilter(t *testing.T) {
 		t.Fatalf("expected 2 gifts in price range, got total=%d len=%d", pricedTotal, len(priced))
 	}
 
-	combined, combinedTotal, err := repo.List(ctx, ListFilter{Search: &search, PriceMin: &min}, 10, 0)
+	combined, combinedTotal, err := listPage(ctx, repo, ListFilter{Search: &search, PriceMin: &min}, 10)
 	if err != nil {
 		t.Fatalf("List combined filter failed: %v", err)
 	}
@@ -434,7 +566,7 @@ func TestIntegrationListSearchAndPriceFilter(t *testing.T) {
 	}
 
 	asc := SortPriceAsc
-	ascGifts, _, err := repo.List(ctx, ListFilter{Sort: &asc}, 10, 0)
+	ascGifts, _, err := listPage(ctx, repo, ListFilter{Sort: &asc}, 10)
 	if err != nil {
 		t.Fatalf("List sort asc failed: %v", err)
 	}
@@ -443,7 +575,7 @@ func TestIntegrationListSearchAndPriceFilter(t *testing.T) {
 	}
 
 	desc := SortPriceDesc
-	descGifts, _, err := repo.List(ctx, ListFilter{Sort: &desc}, 10, 0)
+	descGifts, _, err := listPage(ctx, repo, ListFilter{Sort: &desc}, 10)
 	if err != nil {
 		t.Fatalf("List sort desc failed: %v", err)
 	}
@@ -451,3 +583,137 @@ func TestIntegrationListSearchAndPriceFilter(t *testing.T) {
 		t.Fatalf("expected descending price order, got %+v", descGifts)
 	}
 }
+
+func TestIntegrationCategoriesTagsAndCuratedOrder(t *testing.T) {
+	repo, ctx := setupRepo(t)
+
+	kitchen := "Cozinha"
+	honeymoon := "Lua de mel"
+	seed := []CreateGiftInput{
+		{Name: "Panela", PriceCents: 10000, Category: &kitchen, Tags: []string{"Inox"}},
+		{Name: "Faqueiro", PriceCents: 20000, Category: &kitchen, Tags: []string{"Inox", "Presente"}},
+		{Name: "Mala", PriceCents: 30000, Category: &honeymoon, Tags: []string{"Viagem"}},
+	}
+	ids := make([]int64, len(seed))
+	for i, in := range seed {
+		g, err := repo.Create(ctx, in, NormalizeDedupeKey(in.Name), "TST01")
+		if err != nil {
+			t.Fatalf("Create %q failed: %v", in.Name, err)
+		}
+		ids[i] = g.ID
+	}
+
+	cozinha := "cozinha"
+	inKitchen, total, err := listPage(ctx, repo, ListFilter{Category: &cozinha}, 10)
+	if err != nil {
+		t.Fatalf("List category failed: %v", err)
+	}
+	if total != 2 || inKitchen[0].CategorySlug == nil || *inKitchen[0].CategorySlug != "cozinha" {
+		t.Fatalf("expected 2 kitchen gifts with category, got total=%d gifts=%+v", total, inKitchen)
+	}
+
+	tagged, total, err := listPage(ctx, repo, ListFilter{Tags: []string{"inox", "presente"}}, 10)
+	if err != nil {
+		t.Fatalf("List tags failed: %v", err)
+	}
+	if total != 1 || tagged[0].Name != "Faqueiro" {
+		t.Fatalf("expected only Faqueiro to carry both tags, got %+v", tagged)
+	}
+
+	featured := true
+	if _, err := repo.Update(ctx, ids[2], UpdateGiftInput{Featured: &featured}, nil, "TST01"); err != nil {
+		t.Fatalf("Update featured failed: %v", err)
+	}
+	if n, err := repo.Reorder(ctx, []int64{ids[1], ids[0]}, "TST01"); err != nil || n != 2 {
+		t.Fatalf("Reorder failed: n=%d err=%v", n, err)
+	}
+	curated := SortCurated
+	ordered, _, err := listPage(ctx, repo, ListFilter{Sort: &curated}, 10)
+	if err != nil {
+		t.Fatalf("List curated failed: %v", err)
+	}
+	if ordered[0].ID != ids[2] || ordered[1].ID != ids[1] || ordered[2].ID != ids[0] {
+		t.Fatalf("expected featured first then manual order, got %d %d %d", ordered[0].ID, ordered[1].ID, ordered[2].ID)
+	}
+
+	empty := ""
+	noTags := []string{}
+	updated, err := repo.Update(ctx, ids[0], UpdateGiftInput{Category: &empty, Tags: &noTags}, nil, "TST01")
+	if err != nil {
+		t.Fatalf("Update clear failed: %v", err)
+	}
+	if updated.CategoryID != nil || len(updated.Tags) != 0 {
+		t.Fatalf("expected category and tags cleared, got %+v", updated)
+	}
+
+	categories, err := repo.ListCategories(ctx)
+	if err != nil {
+		t.Fatalf("ListCategories failed: %v", err)
+	}
+	if len(categories) != 2 {
+		t.Fatalf("expected 2 categories, got %+v", categories)
+	}
+	if _, err := repo.CreateCategory(ctx, CategoryInput{Name: "COZINHA"}, "cozinha"); err == nil {
+		t.Fatal("expected conflict for duplicate category slug")
+	} else if ae, ok := apperror.IsAppError(err); !ok || ae.Code != 409 {
+		t.Fatalf("expected 409, got %v", err)
+	}
+}
+
+func TestIntegrationPriceMonitoring(t *testing.T) {
+	repo, ctx := setupRepo(t)
+
+	url := "https://shop.example.com/p/1"
+	linked, err := repo.Create(ctx, CreateGiftInput{Name: "Panela", PriceCents: 10000, StoreURL: &url}, "panela", "TST01")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := repo.Create(ctx, CreateGiftInput{Name: "Sem link", PriceCents: 5000}, "sem link", "TST01"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	targets, err := repo.ListPriceCheckTargets(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListPriceCheckTargets failed: %v", err)
+	}
+	if len(targets) != 1 || targets[0].GiftID != linked.ID || targets[0].StoreURL != url {
+		t.Fatalf("expected only the gift with a store link, got %+v", targets)
+	}
+
+	scraped, change := int64(12500), 25.0
+	check, err := repo.RecordPriceCheck(ctx, PriceCheck{
+		GiftID: linked.ID, Outcome: PriceChanged, ListedPriceCents: 10000, ScrapedPriceCents: &scraped, ChangePercent: &change,
+	}, 0, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("RecordPriceCheck failed: %v", err)
+	}
+	if check.ID == 0 || check.ChangePercent == nil || *check.ChangePercent != change {
+		t.Fatalf("unexpected recorded check %+v", check)
+	}
+
+	if targets, _ := repo.ListPriceCheckTargets(ctx, 10); len(targets) != 0 {
+		t.Fatalf("expected the checked gift to rest until next_check_at, got %+v", targets)
+	}
+	if n, err := repo.CountPriceChecksSince(ctx, time.Now().Add(-time.Minute)); err != nil || n != 1 {
+		t.Fatalf("expected 1 check in the window, got %d (%v)", n, err)
+	}
+
+	flagged, err := repo.ListPriceWatches(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListPriceWatches failed: %v", err)
+	}
+	if len(flagged) != 1 || flagged[0].Status != PriceChanged || flagged[0].LastCheck == nil || *flagged[0].LastCheck.ScrapedPriceCents != scraped {
+		t.Fatalf("expected the gift flagged with its last check, got %+v", flagged)
+	}
+
+	if err := repo.MarkPriceSynced(ctx, linked.ID); err != nil {
+		t.Fatalf("MarkPriceSynced failed: %v", err)
+	}
+	if flagged, _ := repo.ListPriceWatches(ctx, nil); len(flagged) != 0 {
+		t.Fatalf("expected no flagged gifts after sync, got %+v", flagged)
+	}
+	history, err := repo.ListPriceHistory(ctx, linked.ID, 10)
+	if err != nil || len(history) != 1 {
+		t.Fatalf("expected history kept after sync, got %+v (%v)", history, err)
+	}
+}
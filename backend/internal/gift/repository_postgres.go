@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
@@ -13,13 +15,32 @@ import (
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
 	"github.com/ferjunior7/parasempre/backend/internal/database"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
+	"github.com/ferjunior7/parasempre/backend/internal/search"
 )
 
-const giftColumns = `id, name, description, price_cents, image_url, store_url, status, dedupe_key, created_by, updated_by, deleted_by, created_at, updated_at, deleted_at`
+// giftColumns works both in SELECTs over gifts and in RETURNING clauses,
+// hence the correlated subqueries instead of joins.
+const giftColumns = `id, name, description, price_cents, image_url, store_url, status, dedupe_key, created_by, updated_by, deleted_by, created_at, updated_at, deleted_at,
+	category_id,
+	(SELECT c.name FROM gift_categories c WHERE c.id = gifts.category_id),
+	(SELECT c.slug FROM gift_categories c WHERE c.id = gifts.category_id),
+	COALESCE((SELECT array_agg(t.name ORDER BY t.name)
+	            FROM gift_tag_links l JOIN gift_tags t ON t.id = l.tag_id
+	           WHERE l.gift_id = gifts.id), '{}'),
+	position, featured`
+
+func giftDest(g *Gift) []any {
+	return []any{
+		&g.ID, &g.Name, &g.Description, &g.PriceCents, &g.ImageURL, &g.StoreURL, &g.Status,
+		&g.DedupeKey, &g.CreatedBy, &g.UpdatedBy, &g.DeletedBy, &g.CreatedAt, &g.UpdatedAt, &g.DeletedAt,
+		&g.CategoryID, &g.CategoryName, &g.CategorySlug, &g.Tags, &g.Position, &g.Featured,
+	}
+}
 
 func scanGift(row pgx.Row) (Gift, error) {
 	var g Gift
-	err := row.Scan(&g.ID, &g.Name, &g.Description, &g.PriceCents, &g.ImageURL, &g.StoreURL, &g.Status, &g.DedupeKey, &g.CreatedBy, &g.UpdatedBy, &g.DeletedBy, &g.CreatedAt, &g.UpdatedAt, &g.DeletedAt)
+	err := row.Scan(giftDest(&g)...)
 	return g, err
 }
 
@@ -35,61 +56,129 @@ func (r *PostgresRepository) WithTx(tx pgx.Tx) Repository {
 	return &PostgresRepository{db: tx}
 }
 
-func (r *PostgresRepository) List(ctx context.Context, filter ListFilter, limit, offset int) ([]Gift, int, error) {
-	query := `SELECT ` + giftColumns + `, COUNT(*) OVER() AS total FROM gifts WHERE deleted_at IS NULL`
+func (r *PostgresRepository) List(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
+	where := ` FROM gifts WHERE deleted_at IS NULL`
 	args := []any{}
 	if filter.Status != nil {
 		args = append(args, *filter.Status)
-		query += ` AND status = $` + fmt.Sprint(len(args))
+		where += ` AND status = $` + fmt.Sprint(len(args))
 	}
+	var rank []pagination.Key
 	if filter.Search != nil {
-		args = append(args, "%"+*filter.Search+"%")
-		query += ` AND name ILIKE $` + fmt.Sprint(len(args))
+		args = append(args, *filter.Search, search.LikePattern(*filter.Search))
+		where += ` AND ` + search.Match("name", len(args)-1, len(args))
+		rank = search.RankKeys("name", len(args)-1, len(args))
 	}
 	if filter.PriceMin != nil {
 		args = append(args, *filter.PriceMin)
-		query += ` AND price_cents >= $` + fmt.Sprint(len(args))
+		where += ` AND price_cents >= $` + fmt.Sprint(len(args))
 	}
 	if filter.PriceMax != nil {
 		args = append(args, *filter.PriceMax)
-		query += ` AND price_cents <= $` + fmt.Sprint(len(args))
+		where += ` AND price_cents <= $` + fmt.Sprint(len(args))
+	}
+	if filter.Category != nil {
+		args = append(args, *filter.Category)
+		where += ` AND category_id = (SELECT id FROM gift_categories WHERE slug = $` + fmt.Sprint(len(args)) + `)`
+	}
+	if len(filter.Tags) > 0 {
+		args = append(args, filter.Tags, len(filter.Tags))
+		where += ` AND (SELECT COUNT(*) FROM gift_tag_links l JOIN gift_tags t ON t.id = l.tag_id
+		                 WHERE l.gift_id = gifts.id AND t.slug = ANY($` + fmt.Sprint(len(args)-1) + `)) = $` + fmt.Sprint(len(args))
+	}
+	if filter.Featured != nil {
+		args = append(args, *filter.Featured)
+		where += ` AND featured = $` + fmt.Sprint(len(args))
 	}
 
-	orderBy := "created_at DESC, id DESC"
-	if filter.Sort != nil {
-		switch *filter.Sort {
-		case SortPriceAsc:
-			orderBy = "price_cents ASC, id DESC"
-		case SortPriceDesc:
-			orderBy = "price_cents DESC, id DESC"
+	var meta pagination.Meta
+	if req.IncludeTotal {
+		var total int
+		if err := r.db.QueryRow(ctx, `SELECT COUNT(*)`+where, args...).Scan(&total); err != nil {
+			slog.ErrorContext(ctx, "gift.repo list: count failed", "error", err)
+			return nil, meta, err
 		}
+		meta.Total = &total
+	}
+
+	order := giftOrder(filter, rank)
+	seek, args, err := order.Seek(req.Cursor, args)
+	if err != nil {
+		return nil, meta, err
+	}
+	if seek != "" {
+		where += ` AND ` + seek
 	}
-	query += ` ORDER BY ` + orderBy + ` LIMIT $` + fmt.Sprint(len(args)+1) + ` OFFSET $` + fmt.Sprint(len(args)+2)
-	args = append(args, limit, offset)
+	query := `SELECT ` + giftColumns + order.Columns() + where +
+		` ORDER BY ` + order.OrderBy(req.Cursor) +
+		` LIMIT $` + fmt.Sprint(len(args)+1) + ` OFFSET $` + fmt.Sprint(len(args)+2)
+	args = append(args, req.Limit+1, req.Offset())
 
 	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
-		slog.Error("gift.repo list: query failed", "error", err)
-		return nil, 0, err
+		slog.ErrorContext(ctx, "gift.repo list: query failed", "error", err)
+		return nil, meta, pagination.QueryError(err, req.Cursor)
 	}
 	defer rows.Close()
 
 	var gifts []Gift
-	var total int
+	var keys [][]string
 	for rows.Next() {
 		var g Gift
-		if err := rows.Scan(&g.ID, &g.Name, &g.Description, &g.PriceCents, &g.ImageURL, &g.StoreURL, &g.Status, &g.DedupeKey, &g.CreatedBy, &g.UpdatedBy, &g.DeletedBy, &g.CreatedAt, &g.UpdatedAt, &g.DeletedAt, &total); err != nil {
-			slog.Error("gift.repo list: scan failed", "error", err)
-			return nil, 0, err
+		key := make([]string, len(order.Keys))
+		dest := giftDest(&g)
+		for i := range key {
+			dest = append(dest, &key[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			slog.ErrorContext(ctx, "gift.repo list: scan failed", "error", err)
+			return nil, meta, err
 		}
 		gifts = append(gifts, g)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, meta, pagination.QueryError(err, req.Cursor)
 	}
 
+	gifts, page := pagination.Finish(req, order, gifts, keys)
+	page.Total = meta.Total
 	if gifts == nil {
 		gifts = []Gift{}
 	}
+	return gifts, page, nil
+}
+
+// giftOrder is the listing's ordering for filter.Sort; rank, when
+// searching, orders by relevance unless another sort was asked for. Every
+// ordering ends in id so rows never tie.
+func giftOrder(filter ListFilter, rank []pagination.Key) pagination.Order {
+	id := pagination.Key{Expr: "id", Type: "bigint", Desc: true}
+	sort := SortNewest
+	if filter.Sort != nil {
+		sort = *filter.Sort
+	}
+	if rank != nil && (filter.Sort == nil || sort == SortRelevance) {
+		return pagination.Order{Name: SortRelevance, Keys: append(rank, id)}
+	}
 
-	return gifts, total, rows.Err()
+	switch sort {
+	case SortPriceAsc:
+		return pagination.Order{Name: sort, Keys: []pagination.Key{{Expr: "price_cents", Type: "bigint"}, id}}
+	case SortPriceDesc:
+		return pagination.Order{Name: sort, Keys: []pagination.Key{{Expr: "price_cents", Type: "bigint", Desc: true}, id}}
+	case SortCurated:
+		return pagination.Order{Name: sort, Keys: []pagination.Key{
+			{Expr: "featured", Type: "boolean", Desc: true},
+			{Expr: "position", Type: "int"},
+			{Expr: "created_at", Type: "timestamptz", Desc: true},
+			id,
+		}}
+	case SortNameAsc:
+		return pagination.Order{Name: sort, Keys: []pagination.Key{{Expr: "name", Type: "text"}, id}}
+	default:
+		return pagination.Order{Name: SortNewest, Keys: []pagination.Key{{Expr: "created_at", Type: "timestamptz", Desc: true}, id}}
+	}
 }
 
 func (r *PostgresRepository) GetByID(ctx context.Context, id int64) (*Gift, error) {
@@ -99,35 +188,68 @@ func (r *PostgresRepository) GetByID(ctx context.Context, id int64) (*Gift, erro
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, apperror.NotFound("gift not found")
 		}
-		slog.Error("gift.repo get_by_id: query failed", "id", id, "error", err)
+		slog.ErrorContext(ctx, "gift.repo get_by_id: query failed", "id", id, "error", err)
 		return nil, err
 	}
 	return &g, nil
 }
 
+// Create inserts the gift along with its category and tags. Callers wrap it
+// in a transaction so a failed tag insert does not leave a half-made gift.
 func (r *PostgresRepository) Create(ctx context.Context, input CreateGiftInput, dedupeKey, userRACF string) (*Gift, error) {
 	status := "active"
 	if input.Status != nil {
 		status = *input.Status
 	}
+	position := 0
+	if input.Position != nil {
+		position = *input.Position
+	}
+	featured := input.Featured != nil && *input.Featured
+
+	var categoryID *int64
+	if input.Category != nil {
+		id, err := r.ensureCategory(ctx, *input.Category)
+		if err != nil {
+			return nil, err
+		}
+		categoryID = id
+	}
 
 	g, err := scanGift(r.db.QueryRow(ctx,
-		`INSERT INTO gifts (name, description, price_cents, image_url, store_url, status, dedupe_key, created_by, updated_by)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`INSERT INTO gifts (name, description, price_cents, image_url, store_url, status, dedupe_key, created_by, updated_by, category_id, position, featured)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		 RETURNING `+giftColumns,
-		input.Name, input.Description, input.PriceCents, input.ImageURL, input.StoreURL, status, dedupeKey, userRACF, userRACF))
+		input.Name, input.Description, input.PriceCents, input.ImageURL, input.StoreURL, status, dedupeKey, userRACF, userRACF,
+		categoryID, position, featured))
 	if err != nil {
 		if appErr := mapPgError(err); appErr != nil {
 			return nil, appErr
 		}
-		slog.Error("gift.repo create: insert failed", "error", err)
+		slog.ErrorContext(ctx, "gift.repo create: insert failed", "error", err)
 		return nil, err
 	}
-	slog.Info("gift.repo create: gift stored", "id", g.ID)
+	if len(input.Tags) > 0 {
+		if g.Tags, err = r.setTags(ctx, g.ID, input.Tags); err != nil {
+			return nil, err
+		}
+	}
+	slog.InfoContext(ctx, "gift.repo create: gift stored", "id", g.ID)
 	return &g, nil
 }
 
 func (r *PostgresRepository) Update(ctx context.Context, id int64, input UpdateGiftInput, dedupeKey *string, userRACF string) (*Gift, error) {
+	// An empty category name clears it; nil leaves it alone.
+	setCategory := input.Category != nil
+	var categoryID *int64
+	if setCategory {
+		cid, err := r.ensureCategory(ctx, *input.Category)
+		if err != nil {
+			return nil, err
+		}
+		categoryID = cid
+	}
+
 	g, err := scanGift(r.db.QueryRow(ctx,
 		`UPDATE gifts SET
 			name = COALESCE($1, name),
@@ -137,11 +259,15 @@ func (r *PostgresRepository) Update(ctx context.Context, id int64, input UpdateG
 			store_url = COALESCE($5, store_url),
 			status = COALESCE($6, status),
 			dedupe_key = COALESCE($7, dedupe_key),
+			category_id = CASE WHEN $10 THEN $11 ELSE category_id END,
+			position = COALESCE($12, position),
+			featured = COALESCE($13, featured),
 			updated_by = $8,
 			updated_at = now()
 		 WHERE id = $9 AND deleted_at IS NULL
 		 RETURNING `+giftColumns,
-		input.Name, input.Description, input.PriceCents, input.ImageURL, input.StoreURL, input.Status, dedupeKey, userRACF, id))
+		input.Name, input.Description, input.PriceCents, input.ImageURL, input.StoreURL, input.Status, dedupeKey, userRACF, id,
+		setCategory, categoryID, input.Position, input.Featured))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, apperror.NotFound("gift not found")
@@ -149,26 +275,98 @@ func (r *PostgresRepository) Update(ctx context.Context, id int64, input UpdateG
 		if appErr := mapPgError(err); appErr != nil {
 			return nil, appErr
 		}
-		slog.Error("gift.repo update: update failed", "id", id, "error", err)
+		slog.ErrorContext(ctx, "gift.repo update: update failed", "id", id, "error", err)
 		return nil, err
 	}
-	slog.Info("gift.repo update: gift updated", "id", g.ID)
+	if input.Tags != nil {
+		if g.Tags, err = r.setTags(ctx, g.ID, *input.Tags); err != nil {
+			return nil, err
+		}
+	}
+	slog.InfoContext(ctx, "gift.repo update: gift updated", "id", g.ID)
 	return &g, nil
 }
 
+// ensureCategory finds the category by slug, creating it when missing.
+// A blank name yields nil.
+func (r *PostgresRepository) ensureCategory(ctx context.Context, name string) (*int64, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	slug := Slugify(name)
+	if slug == "" {
+		return nil, nil
+	}
+	var id int64
+	// DO UPDATE (a no-op) instead of DO NOTHING so RETURNING yields the
+	// existing row too.
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO gift_categories (name, slug) VALUES ($1, $2)
+		 ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug
+		 RETURNING id`,
+		name, slug).Scan(&id)
+	if err != nil {
+		if appErr := mapPgError(err); appErr != nil {
+			return nil, appErr
+		}
+		slog.ErrorContext(ctx, "gift.repo ensure_category: failed", "slug", slug, "error", err)
+		return nil, err
+	}
+	return &id, nil
+}
+
+// setTags replaces the gift's tags, creating unknown ones, and returns the
+// names as stored, sorted like giftColumns sorts them.
+func (r *PostgresRepository) setTags(ctx context.Context, giftID int64, tags []string) ([]string, error) {
+	tags = NormalizeTags(tags)
+	if _, err := r.db.Exec(ctx, `DELETE FROM gift_tag_links WHERE gift_id = $1`, giftID); err != nil {
+		slog.ErrorContext(ctx, "gift.repo set_tags: clear failed", "gift_id", giftID, "error", err)
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return []string{}, nil
+	}
+	slugs := make([]string, len(tags))
+	for i, t := range tags {
+		slugs[i] = Slugify(t)
+	}
+	rows, err := r.db.Query(ctx,
+		`WITH t AS (
+		     INSERT INTO gift_tags (name, slug)
+		     SELECT * FROM unnest($2::text[], $3::text[])
+		     ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug
+		     RETURNING id, name),
+		 l AS (
+		     INSERT INTO gift_tag_links (gift_id, tag_id)
+		     SELECT $1, id FROM t)
+		 SELECT name FROM t ORDER BY name`,
+		giftID, tags, slugs)
+	if err != nil {
+		slog.ErrorContext(ctx, "gift.repo set_tags: insert failed", "gift_id", giftID, "error", err)
+		return nil, err
+	}
+	stored, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		if appErr := mapPgError(err); appErr != nil {
+			return nil, appErr
+		}
+		slog.ErrorContext(ctx, "gift.repo set_tags: insert failed", "gift_id", giftID, "error", err)
+		return nil, err
+	}
+	return stored, nil
+}
+
 func (r *PostgresRepository) Delete(ctx context.Context, id int64, userRACF string) error {
 	tag, err := r.db.Exec(ctx,
 		`UPDATE gifts SET deleted_at = now(), deleted_by = $1, updated_by = $1, updated_at = now(), status = 'inactive'
 		 WHERE id = $2 AND deleted_at IS NULL`,
 		userRACF, id)
 	if err != nil {
-		slog.Error("gift.repo delete: soft-delete failed", "id", id, "error", err)
+		slog.ErrorContext(ctx, "gift.repo delete: soft-delete failed", "id", id, "error", err)
 		return err
 	}
 	if tag.RowsAffected() == 0 {
 		return apperror.NotFound("gift not found")
 	}
-	slog.Info("gift.repo delete: gift soft-deleted", "id", id, "by", userRACF)
+	slog.InfoContext(ctx, "gift.repo delete: gift soft-deleted", "id", id, "by", userRACF)
 	return nil
 }
 
@@ -180,15 +378,141 @@ func (r *PostgresRepository) BulkCreate(ctx context.Context, inputs []CreateGift
 	for i, input := range inputs {
 		g, err := r.Create(ctx, input, dedupeKeys[i], userRACF)
 		if err != nil {
-			slog.Error("gift.repo bulk_create: insert failed", "index", i, "error", err)
+			slog.ErrorContext(ctx, "gift.repo bulk_create: insert failed", "index", i, "error", err)
 			return nil, err
 		}
 		created = append(created, *g)
 	}
-	slog.Info("gift.repo bulk_create: gifts stored", "count", len(created))
+	slog.InfoContext(ctx, "gift.repo bulk_create: gifts stored", "count", len(created))
 	return created, nil
 }
 
+func (r *PostgresRepository) Reorder(ctx context.Context, ids []int64, userRACF string) (int64, error) {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE gifts g
+		    SET position = o.ord - 1, updated_by = $2, updated_at = now()
+		   FROM unnest($1::bigint[]) WITH ORDINALITY AS o(id, ord)
+		  WHERE g.id = o.id AND g.deleted_at IS NULL`,
+		ids, userRACF)
+	if err != nil {
+		slog.ErrorContext(ctx, "gift.repo reorder: failed", "count", len(ids), "error", err)
+		return 0, err
+	}
+	slog.InfoContext(ctx, "gift.repo reorder: done", "updated", tag.RowsAffected(), "by", userRACF)
+	return tag.RowsAffected(), nil
+}
+
+const categoryColumns = `c.id, c.name, c.slug, c.position, c.created_at, c.updated_at,
+	(SELECT COUNT(*) FROM gifts g WHERE g.category_id = c.id AND g.deleted_at IS NULL AND g.status = 'active')`
+
+func scanCategory(row pgx.Row) (Category, error) {
+	var c Category
+	err := row.Scan(&c.ID, &c.Name, &c.Slug, &c.Position, &c.CreatedAt, &c.UpdatedAt, &c.GiftCount)
+	return c, err
+}
+
+func (r *PostgresRepository) ListCategories(ctx context.Context) ([]Category, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+categoryColumns+` FROM gift_categories c ORDER BY c.position ASC, c.name ASC`)
+	if err != nil {
+		slog.ErrorContext(ctx, "gift.repo list_categories: query failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []Category{}
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, "gift.repo list_categories: scan failed", "error", err)
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+func (r *PostgresRepository) CreateCategory(ctx context.Context, input CategoryInput, slug string) (*Category, error) {
+	position := 0
+	if input.Position != nil {
+		position = *input.Position
+	}
+	c, err := scanCategory(r.db.QueryRow(ctx,
+		`INSERT INTO gift_categories AS c (name, slug, position) VALUES ($1, $2, $3)
+		 RETURNING `+categoryColumns,
+		input.Name, slug, position))
+	if err != nil {
+		if appErr := mapPgError(err); appErr != nil {
+			return nil, appErr
+		}
+		slog.ErrorContext(ctx, "gift.repo create_category: insert failed", "error", err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "gift.repo create_category: stored", "id", c.ID, "slug", c.Slug)
+	return &c, nil
+}
+
+func (r *PostgresRepository) UpdateCategory(ctx context.Context, id int64, input CategoryInput, slug string) (*Category, error) {
+	c, err := scanCategory(r.db.QueryRow(ctx,
+		`UPDATE gift_categories AS c
+		    SET name = $1, slug = $2, position = COALESCE($3, position), updated_at = now()
+		  WHERE id = $4
+		  RETURNING `+categoryColumns,
+		input.Name, slug, input.Position, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.NotFound("Categoria não encontrada.")
+		}
+		if appErr := mapPgError(err); appErr != nil {
+			return nil, appErr
+		}
+		slog.ErrorContext(ctx, "gift.repo update_category: update failed", "id", id, "error", err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "gift.repo update_category: updated", "id", c.ID, "slug", c.Slug)
+	return &c, nil
+}
+
+func (r *PostgresRepository) DeleteCategory(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM gift_categories WHERE id = $1`, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "gift.repo delete_category: failed", "id", id, "error", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.NotFound("Categoria não encontrada.")
+	}
+	slog.InfoContext(ctx, "gift.repo delete_category: deleted", "id", id)
+	return nil
+}
+
+// ListTags returns the tags in use by active gifts, for the filter bar.
+func (r *PostgresRepository) ListTags(ctx context.Context) ([]Tag, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT t.name, t.slug, COUNT(*)
+		   FROM gift_tags t
+		   JOIN gift_tag_links l ON l.tag_id = t.id
+		   JOIN gifts g ON g.id = l.gift_id AND g.deleted_at IS NULL AND g.status = 'active'
+		  GROUP BY t.id
+		  ORDER BY t.name`)
+	if err != nil {
+		slog.ErrorContext(ctx, "gift.repo list_tags: query failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []Tag{}
+	for rows.Next() {
+		var t Tag
+		if err := rows.Scan(&t.Name, &t.Slug, &t.GiftCount); err != nil {
+			slog.ErrorContext(ctx, "gift.repo list_tags: scan failed", "error", err)
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
 func (r *PostgresRepository) FindByDedupeKeys(ctx context.Context, keys []string) (map[string]bool, error) {
 	found := make(map[string]bool, len(keys))
 	if len(keys) == 0 {
@@ -198,7 +522,7 @@ func (r *PostgresRepository) FindByDedupeKeys(ctx context.Context, keys []string
 	rows, err := r.db.Query(ctx,
 		`SELECT dedupe_key FROM gifts WHERE dedupe_key = ANY($1) AND deleted_at IS NULL`, keys)
 	if err != nil {
-		slog.Error("gift.repo find_by_dedupe_keys: query failed", "error", err)
+		slog.ErrorContext(ctx, "gift.repo find_by_dedupe_keys: query failed", "error", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -206,7 +530,7 @@ func (r *PostgresRepository) FindByDedupeKeys(ctx context.Context, keys []string
 	for rows.Next() {
 		var key string
 		if err := rows.Scan(&key); err != nil {
-			slog.Error("gift.repo find_by_dedupe_keys: scan failed", "error", err)
+			slog.ErrorContext(ctx, "gift.repo find_by_dedupe_keys: scan failed", "error", err)
 			return nil, err
 		}
 		found[key] = true
@@ -215,13 +539,179 @@ func (r *PostgresRepository) FindByDedupeKeys(ctx context.Context, keys []string
 	return found, rows.Err()
 }
 
+func (r *PostgresRepository) ListPriceCheckTargets(ctx context.Context, limit int) ([]PriceCheckTarget, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT g.id, g.name, g.store_url, g.price_cents, COALESCE(w.consecutive_failures, 0)
+		   FROM gifts g
+		   LEFT JOIN gift_price_watch w ON w.gift_id = g.id
+		  WHERE g.deleted_at IS NULL AND g.status = 'active' AND g.store_url IS NOT NULL
+		    AND (w.next_check_at IS NULL OR w.next_check_at <= now())
+		  ORDER BY w.next_check_at ASC NULLS FIRST, g.id ASC
+		  LIMIT $1`,
+		limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "gift.repo list_price_check_targets: query failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []PriceCheckTarget
+	for rows.Next() {
+		var t PriceCheckTarget
+		if err := rows.Scan(&t.GiftID, &t.Name, &t.StoreURL, &t.PriceCents, &t.Failures); err != nil {
+			slog.ErrorContext(ctx, "gift.repo list_price_check_targets: scan failed", "error", err)
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+	return targets, rows.Err()
+}
+
+const priceCheckColumns = `id, gift_id, outcome, listed_price_cents, scraped_price_cents, change_percent::float8, error, checked_at`
+
+func priceCheckDest(c *PriceCheck) []any {
+	return []any{&c.ID, &c.GiftID, &c.Outcome, &c.ListedPriceCents, &c.ScrapedPriceCents, &c.ChangePercent, &c.Error, &c.CheckedAt}
+}
+
+func (r *PostgresRepository) RecordPriceCheck(ctx context.Context, check PriceCheck, failures int, nextCheckAt time.Time) (*PriceCheck, error) {
+	var c PriceCheck
+	err := r.db.QueryRow(ctx,
+		`WITH c AS (
+		     INSERT INTO gift_price_checks (gift_id, outcome, listed_price_cents, scraped_price_cents, change_percent, error)
+		     VALUES ($1, $2, $3, $4, $5, $6)
+		     RETURNING `+priceCheckColumns+`),
+		 w AS (
+		     INSERT INTO gift_price_watch (gift_id, status, last_check_id, consecutive_failures, next_check_at)
+		     SELECT gift_id, outcome, id, $7, $8 FROM c
+		     ON CONFLICT (gift_id) DO UPDATE
+		        SET status = EXCLUDED.status,
+		            last_check_id = EXCLUDED.last_check_id,
+		            consecutive_failures = EXCLUDED.consecutive_failures,
+		            next_check_at = EXCLUDED.next_check_at,
+		            updated_at = now())
+		 SELECT * FROM c`,
+		check.GiftID, check.Outcome, check.ListedPriceCents, check.ScrapedPriceCents, check.ChangePercent, check.Error,
+		failures, nextCheckAt).Scan(priceCheckDest(&c)...)
+	if err != nil {
+		if appErr := mapPgError(err); appErr != nil {
+			return nil, appErr
+		}
+		slog.ErrorContext(ctx, "gift.repo record_price_check: insert failed", "gift_id", check.GiftID, "error", err)
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *PostgresRepository) CountPriceChecksSince(ctx context.Context, since time.Time) (int, error) {
+	var n int
+	if err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM gift_price_checks WHERE checked_at >= $1`, since).Scan(&n); err != nil {
+		slog.ErrorContext(ctx, "gift.repo count_price_checks: query failed", "error", err)
+		return 0, err
+	}
+	return n, nil
+}
+
+func (r *PostgresRepository) ListPriceWatches(ctx context.Context, giftIDs []int64) ([]PriceWatchItem, error) {
+	query := `SELECT g.id, g.name, g.store_url, g.price_cents, w.status, w.consecutive_failures, w.next_check_at,
+	                 c.id, c.gift_id, c.outcome, c.listed_price_cents, c.scraped_price_cents, c.change_percent::float8, c.error, c.checked_at
+	            FROM gift_price_watch w
+	            JOIN gifts g ON g.id = w.gift_id AND g.deleted_at IS NULL
+	            LEFT JOIN gift_price_checks c ON c.id = w.last_check_id`
+	args := []any{}
+	if len(giftIDs) > 0 {
+		args = append(args, giftIDs)
+		query += ` WHERE w.gift_id = ANY($1) ORDER BY g.id`
+	} else {
+		// Price moves first, largest swing on top; then dead links.
+		query += ` WHERE w.status <> 'ok' AND g.status = 'active'
+		           ORDER BY (w.status = 'price_changed') DESC, abs(c.change_percent) DESC NULLS LAST, g.name`
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "gift.repo list_price_watches: query failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []PriceWatchItem{}
+	for rows.Next() {
+		var it PriceWatchItem
+		var c struct {
+			ID, GiftID       *int64
+			Outcome          *string
+			ListedPriceCents *int64
+			Scraped          *int64
+			Change           *float64
+			Error            *string
+			CheckedAt        *time.Time
+		}
+		if err := rows.Scan(&it.GiftID, &it.Name, &it.StoreURL, &it.PriceCents, &it.Status, &it.ConsecutiveFailures, &it.NextCheckAt,
+			&c.ID, &c.GiftID, &c.Outcome, &c.ListedPriceCents, &c.Scraped, &c.Change, &c.Error, &c.CheckedAt); err != nil {
+			slog.ErrorContext(ctx, "gift.repo list_price_watches: scan failed", "error", err)
+			return nil, err
+		}
+		if c.ID != nil {
+			it.LastCheck = &PriceCheck{
+				ID: *c.ID, GiftID: *c.GiftID, Outcome: *c.Outcome, ListedPriceCents: *c.ListedPriceCents,
+				ScrapedPriceCents: c.Scraped, ChangePercent: c.Change, Error: c.Error, CheckedAt: *c.CheckedAt,
+			}
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+func (r *PostgresRepository) ListPriceHistory(ctx context.Context, giftID int64, limit int) ([]PriceCheck, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+priceCheckColumns+` FROM gift_price_checks
+		  WHERE gift_id = $1
+		  ORDER BY checked_at DESC, id DESC
+		  LIMIT $2`,
+		giftID, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "gift.repo list_price_history: query failed", "gift_id", giftID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	checks := []PriceCheck{}
+	for rows.Next() {
+		var c PriceCheck
+		if err := rows.Scan(priceCheckDest(&c)...); err != nil {
+			slog.ErrorContext(ctx, "gift.repo list_price_history: scan failed", "error", err)
+			return nil, err
+		}
+		checks = append(checks, c)
+	}
+	return checks, rows.Err()
+}
+
+// MarkPriceSynced clears a price_changed flag once the listed price has
+// been updated to the scraped one.
+func (r *PostgresRepository) MarkPriceSynced(ctx context.Context, giftID int64) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE gift_price_watch SET status = 'ok', consecutive_failures = 0, updated_at = now()
+		  WHERE gift_id = $1`,
+		giftID)
+	if err != nil {
+		slog.ErrorContext(ctx, "gift.repo mark_price_synced: failed", "gift_id", giftID, "error", err)
+		return err
+	}
+	return nil
+}
+
 var checkViolationMessages = map[string]string{
-	"gifts_name_not_empty":      "O nome do presente nao pode estar vazio.",
-	"gifts_description_max_len": "A descricao excede o tamanho maximo permitido.",
-	"gifts_price_positive":      "O preco deve ser maior que zero.",
-	"gifts_status_check":        "Status invalido (use \"active\" ou \"inactive\").",
-	"gifts_image_url_https":     "A URL da imagem deve comecar com https://.",
-	"gifts_store_url_https":     "A URL da loja deve comecar com https://.",
+	"gifts_name_not_empty":        "O nome do presente nao pode estar vazio.",
+	"gifts_description_max_len":   "A descricao excede o tamanho maximo permitido.",
+	"gifts_price_positive":        "O preco deve ser maior que zero.",
+	"gifts_status_check":          "Status invalido (use \"active\" ou \"inactive\").",
+	"gifts_image_url_https":       "A URL da imagem deve comecar com https://.",
+	"gifts_store_url_https":       "A URL da loja deve comecar com https://.",
+	"gifts_position_non_negative": "A posicao nao pode ser negativa.",
+	"gift_categories_name_len":    "O nome da categoria deve ter entre 1 e 60 caracteres.",
+	"gift_tags_name_len":          "Cada tag deve ter entre 1 e 40 caracteres.",
 }
 
 func mapPgError(err error) *apperror.AppError {
@@ -231,8 +721,11 @@ func mapPgError(err error) *apperror.AppError {
 	}
 	switch pgErr.Code {
 	case pgerrcode.UniqueViolation:
-		if pgErr.ConstraintName == "gifts_dedupe_key_active_unique" {
+		switch pgErr.ConstraintName {
+		case "gifts_dedupe_key_active_unique":
 			return apperror.Conflict("Já existe um presente com esse nome.")
+		case "gift_categories_slug_unique":
+			return apperror.Conflict("Já existe uma categoria com esse nome.")
 		}
 		return apperror.Conflict("Esse presente entra em conflito com outro registro.")
 	case pgerrcode.CheckViolation:
@@ -0,0 +1,42 @@
+package gift
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// ChainScraper asks each scraper in order until the product has both a name
+// and a price. Fields a scraper leaves empty are filled by the next ones,
+// so a cheap scraper can go first and a paid one only covers its gaps.
+// A destination HTMLScraper refused as internal ends the chain: a fetching
+// service further down may sit on the same network.
+type ChainScraper []ProductScraper
+
+func (c ChainScraper) ScrapeProduct(ctx context.Context, url string) (*ScrapedProduct, error) {
+	var (
+		merged  ScrapedProduct
+		found   bool
+		lastErr error
+	)
+	for i, s := range c {
+		p, err := s.ScrapeProduct(ctx, url)
+		if err != nil {
+			lastErr = err
+			if ctx.Err() != nil || errors.Is(err, errBlockedAddress) {
+				return nil, err
+			}
+			slog.InfoContext(ctx, "gift.scraper_chain: scraper failed, trying next", "url", url, "index", i, "error", err)
+			continue
+		}
+		found = true
+		fillMissing(&merged, p)
+		if merged.Name != "" && merged.PriceBRL != "" {
+			break
+		}
+	}
+	if !found && lastErr != nil {
+		return nil, lastErr
+	}
+	return &merged, nil
+}
@@ -2,6 +2,7 @@ package gift
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -10,7 +11,11 @@ import (
 	"github.com/jackc/pgx/v5"
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/audit"
 	"github.com/ferjunior7/parasempre/backend/internal/database"
+	"github.com/ferjunior7/parasempre/backend/internal/importmap"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
+	"github.com/ferjunior7/parasempre/backend/internal/search"
 	"github.com/ferjunior7/parasempre/backend/internal/validate"
 )
 
@@ -23,26 +28,43 @@ type ProductScraper interface {
 	ScrapeProduct(ctx context.Context, url string) (*ScrapedProduct, error)
 }
 
+const (
+	auditGiftCreated         = "gift.created"
+	auditGiftUpdated         = "gift.updated"
+	auditGiftDeleted         = "gift.deleted"
+	auditGiftImportCommitted = "gift.import_committed"
+	auditGiftReordered       = "gift.reordered"
+	auditCategoryCreated     = "gift_category.created"
+	auditCategoryUpdated     = "gift_category.updated"
+	auditCategoryDeleted     = "gift_category.deleted"
+)
+
+const maxTagFilters = 10
+
 type Service struct {
 	repo     TxAwareRepository
 	txRunner database.TxRunner
 	scraper  ProductScraper
+	audit    audit.Logger
+	// mappings remembers each user's spreadsheet column mapping; nil
+	// disables it.
+	mappings importmap.Repository
+
+	urlImports *urlImportJobs
 }
 
-func NewService(repo TxAwareRepository, txRunner database.TxRunner, scraper ProductScraper) *Service {
-	return &Service{repo: repo, txRunner: txRunner, scraper: scraper}
+func NewService(repo TxAwareRepository, txRunner database.TxRunner, scraper ProductScraper, audit audit.Logger, mappings importmap.Repository) *Service {
+	return &Service{repo: repo, txRunner: txRunner, scraper: scraper, audit: audit, mappings: mappings, urlImports: newURLImportJobs()}
 }
 
-func (s *Service) List(ctx context.Context, page, limit int, filter ListFilter) (*PagedResponse, error) {
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 {
-		limit = 20
-	}
-	if limit > 100 {
-		limit = 100
-	}
+// auditChanges diffs two snapshots, ignoring bookkeeping columns that change
+// on every write.
+func auditChanges(before, after *Gift) map[string]audit.Change {
+	return audit.Diff(before, after, "updated_at", "updated_by")
+}
+
+func (s *Service) List(ctx context.Context, req pagination.Request, filter ListFilter) (*PagedResponse, error) {
+	req.Normalize(20, 100)
 
 	if filter.Search != nil {
 		if q := strings.TrimSpace(*filter.Search); q != "" {
@@ -58,21 +80,48 @@ func (s *Service) List(ctx context.Context, page, limit int, filter ListFilter)
 	if filter.PriceMax != nil && *filter.PriceMax < 0 {
 		filter.PriceMax = nil
 	}
-	if filter.Sort != nil && *filter.Sort != SortPriceAsc && *filter.Sort != SortPriceDesc {
+	if filter.Sort != nil && !validSorts[*filter.Sort] {
 		filter.Sort = nil
 	}
+	if filter.Category != nil {
+		if slug := Slugify(*filter.Category); slug != "" {
+			filter.Category = &slug
+		} else {
+			filter.Category = nil
+		}
+	}
+	if len(filter.Tags) > 0 {
+		tags := NormalizeTags(filter.Tags)
+		if len(tags) > maxTagFilters {
+			tags = tags[:maxTagFilters]
+		}
+		slugs := make([]string, len(tags))
+		for i, t := range tags {
+			slugs[i] = Slugify(t)
+		}
+		filter.Tags = slugs
+	}
 
-	offset := (page - 1) * limit
-	gifts, total, err := s.repo.List(ctx, filter, limit, offset)
+	gifts, meta, err := s.repo.List(ctx, filter, req)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		return nil, apperror.Validation("invalid cursor")
+	}
 	if err != nil {
-		slog.Error("gift.service list: failed", "error", err)
+		slog.ErrorContext(ctx, "gift.service list: failed", "error", err)
 		return nil, apperror.Internal("failed to list gifts", err)
 	}
+	if filter.Search != nil {
+		for i := range gifts {
+			if spans := search.Spans(gifts[i].Name, *filter.Search); len(spans) > 0 {
+				gifts[i].Highlight = &search.Highlight{Field: "name", Text: gifts[i].Name, Spans: spans}
+			}
+		}
+	}
 	return &PagedResponse{
 		Data:  gifts,
-		Page:  page,
-		Limit: limit,
-		Total: total,
+		Page:  req.Page,
+		Limit: req.Limit,
+		Meta:  meta,
 	}, nil
 }
 
@@ -90,12 +139,21 @@ func (s *Service) Create(ctx context.Context, input CreateGiftInput, userRACF st
 	}
 
 	dedupeKey := NormalizeDedupeKey(input.Name)
-	g, err := s.repo.Create(ctx, input, dedupeKey, userRACF)
+	// Category and tags are separate statements; keep them with the gift.
+	var g *Gift
+	err := s.txRunner.RunInTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		g, err = s.repo.WithTx(tx).Create(ctx, input, dedupeKey, userRACF)
+		return err
+	})
 	if err != nil {
 		return nil, apperror.WrapIfNotApp("failed to create gift", err)
 	}
 
-	slog.Info("gift.service create: gift created", "id", g.ID, "user_racf", userRACF)
+	audit.Record(ctx, s.audit, auditGiftCreated, audit.Entity(audit.EntityGift, g.ID, map[string]any{
+		"changes": auditChanges(nil, g),
+	}))
+	slog.InfoContext(ctx, "gift.service create: gift created", "id", g.ID, "user_racf", userRACF)
 	return g, nil
 }
 
@@ -104,35 +162,187 @@ func (s *Service) Update(ctx context.Context, id int64, input UpdateGiftInput, u
 		return nil, err
 	}
 
+	var before *Gift
+	if s.audit != nil {
+		var err error
+		if before, err = s.repo.GetByID(ctx, id); err != nil {
+			return nil, apperror.WrapIfNotApp("failed to get gift", err)
+		}
+	}
+
 	var dedupeKey *string
 	if input.Name != nil {
 		k := NormalizeDedupeKey(*input.Name)
 		dedupeKey = &k
 	}
 
-	g, err := s.repo.Update(ctx, id, input, dedupeKey, userRACF)
+	var g *Gift
+	err := s.txRunner.RunInTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		g, err = s.repo.WithTx(tx).Update(ctx, id, input, dedupeKey, userRACF)
+		return err
+	})
 	if err != nil {
 		return nil, apperror.WrapIfNotApp("failed to update gift", err)
 	}
 
-	slog.Info("gift.service update: gift updated", "id", g.ID, "user_racf", userRACF)
+	audit.Record(ctx, s.audit, auditGiftUpdated, audit.Entity(audit.EntityGift, g.ID, map[string]any{
+		"changes": auditChanges(before, g),
+	}))
+	slog.InfoContext(ctx, "gift.service update: gift updated", "id", g.ID, "user_racf", userRACF)
 	return g, nil
 }
 
 func (s *Service) Delete(ctx context.Context, id int64, userRACF string) error {
+	var before *Gift
+	if s.audit != nil {
+		var err error
+		if before, err = s.repo.GetByID(ctx, id); err != nil {
+			return apperror.WrapIfNotApp("failed to get gift", err)
+		}
+	}
+
 	if err := s.repo.Delete(ctx, id, userRACF); err != nil {
 		return apperror.WrapIfNotApp("failed to delete gift", err)
 	}
-	slog.Info("gift.service delete: gift soft-deleted", "id", id, "user_racf", userRACF)
+	audit.Record(ctx, s.audit, auditGiftDeleted, audit.Entity(audit.EntityGift, id, map[string]any{
+		"changes": auditChanges(before, nil),
+	}))
+	slog.InfoContext(ctx, "gift.service delete: gift soft-deleted", "id", id, "user_racf", userRACF)
+	return nil
+}
+
+// Reorder applies a manual order: the gifts in ids get positions 0..n-1.
+// Gifts left out keep their position.
+func (s *Service) Reorder(ctx context.Context, req ReorderRequest, userRACF string) error {
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+	seen := make(map[int64]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		if seen[id] {
+			return apperror.Validation(fmt.Sprintf("Presente %d repetido na lista.", id))
+		}
+		seen[id] = true
+	}
+
+	err := s.txRunner.RunInTx(ctx, func(tx pgx.Tx) error {
+		updated, err := s.repo.WithTx(tx).Reorder(ctx, req.IDs, userRACF)
+		if err != nil {
+			return err
+		}
+		if updated != int64(len(req.IDs)) {
+			return apperror.Validation("A lista contém presentes inexistentes ou removidos.")
+		}
+		return nil
+	})
+	if err != nil {
+		return apperror.WrapIfNotApp("failed to reorder gifts", err)
+	}
+
+	audit.Record(ctx, s.audit, auditGiftReordered, map[string]any{"ids": req.IDs})
+	slog.InfoContext(ctx, "gift.service reorder: done", "count", len(req.IDs), "user_racf", userRACF)
+	return nil
+}
+
+func (s *Service) ListCategories(ctx context.Context) ([]Category, error) {
+	categories, err := s.repo.ListCategories(ctx)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("failed to list categories", err)
+	}
+	return categories, nil
+}
+
+func (s *Service) CreateCategory(ctx context.Context, input CategoryInput) (*Category, error) {
+	slug, err := categorySlug(&input)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.repo.CreateCategory(ctx, input, slug)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("failed to create category", err)
+	}
+	audit.Record(ctx, s.audit, auditCategoryCreated, map[string]any{"category_id": c.ID, "name": c.Name})
+	slog.InfoContext(ctx, "gift.service create_category: created", "id", c.ID, "slug", c.Slug)
+	return c, nil
+}
+
+func (s *Service) UpdateCategory(ctx context.Context, id int64, input CategoryInput) (*Category, error) {
+	slug, err := categorySlug(&input)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.repo.UpdateCategory(ctx, id, input, slug)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("failed to update category", err)
+	}
+	audit.Record(ctx, s.audit, auditCategoryUpdated, map[string]any{"category_id": c.ID, "name": c.Name, "position": c.Position})
+	slog.InfoContext(ctx, "gift.service update_category: updated", "id", c.ID, "slug", c.Slug)
+	return c, nil
+}
+
+// DeleteCategory removes the category; its gifts become uncategorised.
+func (s *Service) DeleteCategory(ctx context.Context, id int64) error {
+	if err := s.repo.DeleteCategory(ctx, id); err != nil {
+		return apperror.WrapIfNotApp("failed to delete category", err)
+	}
+	audit.Record(ctx, s.audit, auditCategoryDeleted, map[string]any{"category_id": id})
+	slog.InfoContext(ctx, "gift.service delete_category: deleted", "id", id)
 	return nil
 }
 
-func (s *Service) PreviewImport(ctx context.Context, r io.Reader) (*CSVPreview, error) {
-	rows, err := ParseCSVRows(r)
+func categorySlug(input *CategoryInput) (string, error) {
+	input.Name = strings.Join(strings.Fields(input.Name), " ")
+	if err := validate.Struct(*input); err != nil {
+		return "", err
+	}
+	slug := Slugify(input.Name)
+	if slug == "" {
+		return "", apperror.Validation("O nome da categoria precisa ter letras ou números.")
+	}
+	return slug, nil
+}
+
+func (s *Service) ListTags(ctx context.Context) ([]Tag, error) {
+	tags, err := s.repo.ListTags(ctx)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("failed to list tags", err)
+	}
+	return tags, nil
+}
+
+// PreviewImport reads a CSV or XLSX file (by filename) and classifies its
+// rows. A nil mapping is suggested from the headers and the user's last
+// import; while required fields stay unmapped the preview carries only the
+// columns, for the client to ask the couple.
+func (s *Service) PreviewImport(ctx context.Context, r io.Reader, filename string, mapping importmap.Mapping) (*CSVPreview, error) {
+	t, err := importmap.Read(r, filename)
+	if err != nil {
+		return nil, apperror.Validation(fmt.Sprintf("file parse error: %s", err.Error()))
+	}
+	plan, cols, err := importmap.Prepare(ctx, s.mappings, importmap.KindGift, giftImportFields, t, mapping)
+	if err != nil {
+		return nil, apperror.Validation(fmt.Sprintf("invalid column mapping: %s", err.Error()))
+	}
+	if cols == nil {
+		return &CSVPreview{Rows: []CSVPreviewRow{}, Columns: &plan}, nil
+	}
+
+	rows, err := parseTableRows(t, cols)
+	if err != nil {
+		return nil, apperror.Validation(fmt.Sprintf("file parse error: %s", err.Error()))
+	}
+	preview, err := s.classifyPreview(ctx, rows)
 	if err != nil {
-		return nil, apperror.Validation(fmt.Sprintf("CSV parse error: %s", err.Error()))
+		return nil, err
 	}
+	preview.Columns = &plan
+	return preview, nil
+}
 
+// classifyPreview marks each row new, duplicate (of a live gift or of an
+// earlier row) or invalid.
+func (s *Service) classifyPreview(ctx context.Context, rows []CSVPreviewRow) (*CSVPreview, error) {
 	var keys []string
 	for _, row := range rows {
 		if len(row.Errors) == 0 && row.DedupeKey != "" {
@@ -144,7 +354,7 @@ func (s *Service) PreviewImport(ctx context.Context, r io.Reader) (*CSVPreview,
 	if len(keys) > 0 {
 		found, err := s.repo.FindByDedupeKeys(ctx, keys)
 		if err != nil {
-			slog.Error("gift.service preview_import: dedupe lookup failed", "error", err)
+			slog.ErrorContext(ctx, "gift.service preview_import: dedupe lookup failed", "error", err)
 			return nil, apperror.Internal("failed to check duplicates", err)
 		}
 		existing = found
@@ -171,10 +381,21 @@ func (s *Service) PreviewImport(ctx context.Context, r io.Reader) (*CSVPreview,
 	return &CSVPreview{Rows: rows, Summary: summary}, nil
 }
 
-func (s *Service) CommitImport(ctx context.Context, inputs []CreateGiftInput, userRACF string) (*CommitImportResponse, error) {
+// CommitImport creates the previewed rows. mapping, when set, is the
+// column mapping the rows were read with and is remembered for the user's
+// next import. source, when set, names the preview the rows came from.
+func (s *Service) CommitImport(ctx context.Context, inputs []CreateGiftInput, mapping importmap.Mapping, source ImportSource, userRACF string) (*CommitImportResponse, error) {
 	if len(inputs) == 0 {
 		return nil, apperror.Validation("no rows to import")
 	}
+	switch source {
+	case "", ImportSourceCSV, ImportSourceXLSX, ImportSourceURLs:
+	default:
+		return nil, apperror.Validation("source must be csv, xlsx or urls")
+	}
+	if err := mapping.Check(giftImportFields); err != nil {
+		return nil, apperror.Validation(fmt.Sprintf("invalid column mapping: %s", err.Error()))
+	}
 	for i, input := range inputs {
 		if err := validate.Struct(input); err != nil {
 			return nil, apperror.Validation(fmt.Sprintf("row %d: %s", i+1, err.Error()))
@@ -188,7 +409,7 @@ func (s *Service) CommitImport(ctx context.Context, inputs []CreateGiftInput, us
 
 	existing, err := s.repo.FindByDedupeKeys(ctx, keys)
 	if err != nil {
-		slog.Error("gift.service commit_import: dedupe lookup failed", "error", err)
+		slog.ErrorContext(ctx, "gift.service commit_import: dedupe lookup failed", "error", err)
 		return nil, apperror.Internal("failed to check duplicates", err)
 	}
 
@@ -210,27 +431,46 @@ func (s *Service) CommitImport(ctx context.Context, inputs []CreateGiftInput, us
 		filteredKeys = append(filteredKeys, key)
 	}
 
-	var createdCount int
+	var created []Gift
 	if len(filteredInputs) > 0 {
 		err = s.txRunner.RunInTx(ctx, func(tx pgx.Tx) error {
 			txRepo := s.repo.WithTx(tx)
-			created, err := txRepo.BulkCreate(ctx, filteredInputs, filteredKeys, userRACF)
-			if err != nil {
-				return err
-			}
-			createdCount = len(created)
-			return nil
+			var err error
+			created, err = txRepo.BulkCreate(ctx, filteredInputs, filteredKeys, userRACF)
+			return err
 		})
 		if err != nil {
 			return nil, apperror.WrapIfNotApp("failed to import gifts", err)
 		}
 	}
 
-	slog.Info("gift.service commit_import: finished",
-		"requested", len(inputs), "created", createdCount, "skipped", len(skipped), "user_racf", userRACF)
+	// One gift.created per gift, as Create records, so each gift's history
+	// shows what the import wrote; the summary ties them to the upload.
+	createdIDs := make([]int64, len(created))
+	for i := range created {
+		g := &created[i]
+		createdIDs[i] = g.ID
+		details := map[string]any{"changes": auditChanges(nil, g)}
+		if source != "" {
+			details["source"] = source
+		}
+		audit.Record(ctx, s.audit, auditGiftCreated, audit.Entity(audit.EntityGift, g.ID, details))
+	}
+	summary := map[string]any{
+		"requested":   len(inputs),
+		"created_ids": createdIDs,
+		"skipped":     skipped,
+	}
+	if source != "" {
+		summary["source"] = source
+	}
+	audit.Record(ctx, s.audit, auditGiftImportCommitted, summary)
+	importmap.Remember(ctx, s.mappings, importmap.KindGift, mapping)
+	slog.InfoContext(ctx, "gift.service commit_import: finished",
+		"requested", len(inputs), "created", len(createdIDs), "skipped", len(skipped), "user_racf", userRACF)
 
 	return &CommitImportResponse{
-		Created: createdCount,
+		Created: len(createdIDs),
 		Skipped: skipped,
 	}, nil
 }
@@ -258,22 +498,21 @@ func (s *Service) ScrapePreview(ctx context.Context, rawURL, userRACF string) (*
 	}
 
 	if name == "" && imageURL == "" {
-		slog.Info("gift.service scrape_preview: empty extraction", "url", url, "user_racf", userRACF)
+		slog.InfoContext(ctx, "gift.service scrape_preview: empty extraction", "url", url, "user_racf", userRACF)
 		return nil, apperror.Validation("Não conseguimos identificar o produto nesta página.")
 	}
 
 	priceCents := int64(0)
 	priceStr := strings.TrimSpace(scraped.PriceBRL)
 	if priceStr != "" {
-		normalized := stripBRThousandsSep(priceStr)
-		if cents, parseErr := parsePriceBRL(normalized); parseErr == nil && cents > 0 {
+		if cents, parseErr := scrapedPriceCents(priceStr); parseErr == nil {
 			priceCents = cents
 		} else {
-			slog.Info("gift.service scrape_preview: price parse failed", "url", url, "raw_price", priceStr, "error", parseErr)
+			slog.InfoContext(ctx, "gift.service scrape_preview: price parse failed", "url", url, "raw_price", priceStr, "error", parseErr)
 		}
 	}
 
-	slog.Info("gift.service scrape_preview: success", "url", url, "user_racf", userRACF, "name_len", len(name), "price_cents", priceCents)
+	slog.InfoContext(ctx, "gift.service scrape_preview: success", "url", url, "user_racf", userRACF, "name_len", len(name), "price_cents", priceCents)
 
 	return &ScrapePreviewResponse{
 		Name:        name,
@@ -292,6 +531,19 @@ func truncateRunes(s string, maxRunes int) string {
 	return string(runes[:maxRunes])
 }
 
+// scrapedPriceCents parses a price as extracted from a store page
+// ("R$1.234,56") and rejects zero.
+func scrapedPriceCents(raw string) (int64, error) {
+	cents, err := parsePriceBRL(stripBRThousandsSep(strings.TrimSpace(raw)))
+	if err != nil {
+		return 0, err
+	}
+	if cents <= 0 {
+		return 0, fmt.Errorf("price must be greater than zero")
+	}
+	return cents, nil
+}
+
 func stripBRThousandsSep(s string) string {
 	if strings.ContainsRune(s, '.') && strings.ContainsRune(s, ',') {
 		return strings.ReplaceAll(s, ".", "")
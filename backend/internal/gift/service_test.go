@@ -11,19 +11,32 @@ import (
 	"github.com/jackc/pgx/v5"
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/audit"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
 )
 
 type mockRepository struct {
-	listFn             func(ctx context.Context, filter ListFilter, limit, offset int) ([]Gift, int, error)
+	listFn             func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error)
 	getByIDFn          func(ctx context.Context, id int64) (*Gift, error)
 	createFn           func(ctx context.Context, input CreateGiftInput, dedupeKey, userRACF string) (*Gift, error)
 	updateFn           func(ctx context.Context, id int64, input UpdateGiftInput, dedupeKey *string, userRACF string) (*Gift, error)
 	deleteFn           func(ctx context.Context, id int64, userRACF string) error
 	findByDedupeKeysFn func(ctx context.Context, keys []string) (map[string]bool, error)
+	reorderFn          func(ctx context.Context, ids []int64, userRACF string) (int64, error)
+	createCategoryFn   func(ctx context.Context, input CategoryInput, slug string) (*Category, error)
+
+	priceTargets  []PriceCheckTarget
+	priceChecks   []PriceCheck
+	priceFailures map[int64]int
+	priceNext     map[int64]time.Time
+	priceUsed     int
+	priceWatches  []PriceWatchItem
+	priceSynced   []int64
 }
 
-func (m *mockRepository) List(ctx context.Context, filter ListFilter, limit, offset int) ([]Gift, int, error) {
-	return m.listFn(ctx, filter, limit, offset)
+func (m *mockRepository) List(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
+	return m.listFn(ctx, filter, req)
 }
 
 func (m *mockRepository) GetByID(ctx context.Context, id int64) (*Gift, error) {
@@ -71,6 +84,84 @@ func (m *mockRepository) BulkCreate(ctx context.Context, inputs []CreateGiftInpu
 	return created, nil
 }
 
+func (m *mockRepository) Reorder(ctx context.Context, ids []int64, userRACF string) (int64, error) {
+	return m.reorderFn(ctx, ids, userRACF)
+}
+
+func (m *mockRepository) ListCategories(_ context.Context) ([]Category, error) {
+	return []Category{}, nil
+}
+
+func (m *mockRepository) CreateCategory(ctx context.Context, input CategoryInput, slug string) (*Category, error) {
+	return m.createCategoryFn(ctx, input, slug)
+}
+
+func (m *mockRepository) UpdateCategory(_ context.Context, id int64, input CategoryInput, slug string) (*Category, error) {
+	return &Category{ID: id, Name: input.Name, Slug: slug}, nil
+}
+
+func (m *mockRepository) DeleteCategory(_ context.Context, _ int64) error {
+	return nil
+}
+
+func (m *mockRepository) ListTags(_ context.Context) ([]Tag, error) {
+	return []Tag{}, nil
+}
+
+func (m *mockRepository) ListPriceCheckTargets(_ context.Context, limit int) ([]PriceCheckTarget, error) {
+	return m.priceTargets[:min(limit, len(m.priceTargets))], nil
+}
+
+func (m *mockRepository) RecordPriceCheck(_ context.Context, check PriceCheck, failures int, nextCheckAt time.Time) (*PriceCheck, error) {
+	if m.priceFailures == nil {
+		m.priceFailures = map[int64]int{}
+		m.priceNext = map[int64]time.Time{}
+	}
+	check.ID = int64(len(m.priceChecks) + 1)
+	m.priceChecks = append(m.priceChecks, check)
+	m.priceFailures[check.GiftID] = failures
+	m.priceNext[check.GiftID] = nextCheckAt
+	return &check, nil
+}
+
+func (m *mockRepository) CountPriceChecksSince(_ context.Context, _ time.Time) (int, error) {
+	return m.priceUsed + len(m.priceChecks), nil
+}
+
+func (m *mockRepository) ListPriceWatches(_ context.Context, giftIDs []int64) ([]PriceWatchItem, error) {
+	return m.priceWatches, nil
+}
+
+func (m *mockRepository) ListPriceHistory(_ context.Context, giftID int64, _ int) ([]PriceCheck, error) {
+	var out []PriceCheck
+	for _, c := range m.priceChecks {
+		if c.GiftID == giftID {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockRepository) MarkPriceSynced(_ context.Context, giftID int64) error {
+	m.priceSynced = append(m.priceSynced, giftID)
+	return nil
+}
+
+type mockAudit struct {
+	calls []auditCall
+}
+
+type auditCall struct {
+	userID  int64
+	action  string
+	details map[string]any
+}
+
+func (m *mockAudit) LogAction(_ context.Context, userID int64, action string, details map[string]any) error {
+	m.calls = append(m.calls, auditCall{userID: userID, action: action, details: details})
+	return nil
+}
+
 type mockTxRunner struct{}
 
 func (m *mockTxRunner) RunInTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
@@ -123,10 +214,14 @@ func strPtr(s string) *string { return &s }
 
 func int64Ptr(v int64) *int64 { return &v }
 
+func intPtr(v int) *int { return &v }
+
+func boolPtr(v bool) *bool { return &v }
+
 func TestServiceList(t *testing.T) {
 	tests := []struct {
 		name      string
-		mockFn    func(ctx context.Context, filter ListFilter, limit, offset int) ([]Gift, int, error)
+		mockFn    func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error)
 		page      int
 		limit     int
 		wantLen   int
@@ -135,36 +230,36 @@ func TestServiceList(t *testing.T) {
 	}{
 		{
 			name: "returns gifts with pagination",
-			mockFn: func(ctx context.Context, filter ListFilter, limit, offset int) ([]Gift, int, error) {
-				return []Gift{sampleGift()}, 5, nil
+			mockFn: func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
+				return []Gift{sampleGift()}, pagination.Meta{Total: intPtr(5)}, nil
 			},
 			page: 1, limit: 20,
 			wantLen: 1, wantTotal: 5,
 		},
 		{
 			name: "defaults for invalid page/limit",
-			mockFn: func(ctx context.Context, filter ListFilter, limit, offset int) ([]Gift, int, error) {
-				if limit != 20 || offset != 0 {
-					return nil, 0, errors.New("expected default limit=20, offset=0")
+			mockFn: func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
+				if req.Limit != 20 || req.Offset() != 0 {
+					return nil, pagination.Meta{}, errors.New("expected default limit=20, offset=0")
 				}
-				return []Gift{}, 0, nil
+				return []Gift{}, pagination.Meta{Total: intPtr(0)}, nil
 			},
 			page: 0, limit: 0,
 		},
 		{
 			name: "caps limit at 100",
-			mockFn: func(ctx context.Context, filter ListFilter, limit, offset int) ([]Gift, int, error) {
-				if limit != 100 {
-					return nil, 0, errors.New("expected limit capped at 100")
+			mockFn: func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
+				if req.Limit != 100 {
+					return nil, pagination.Meta{}, errors.New("expected limit capped at 100")
 				}
-				return []Gift{}, 0, nil
+				return []Gift{}, pagination.Meta{Total: intPtr(0)}, nil
 			},
 			page: 1, limit: 500,
 		},
 		{
 			name: "propagates error",
-			mockFn: func(ctx context.Context, filter ListFilter, limit, offset int) ([]Gift, int, error) {
-				return nil, 0, errors.New("db error")
+			mockFn: func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
+				return nil, pagination.Meta{}, errors.New("db error")
 			},
 			page: 1, limit: 20, wantErr: true,
 		},
@@ -172,8 +267,8 @@ func TestServiceList(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc := NewService(&mockRepository{listFn: tt.mockFn}, &mockTxRunner{}, nil)
-			result, err := svc.List(context.Background(), tt.page, tt.limit, ListFilter{})
+			svc := NewService(&mockRepository{listFn: tt.mockFn}, &mockTxRunner{}, nil, nil, nil)
+			result, err := svc.List(context.Background(), pagination.Request{Page: tt.page, Limit: tt.limit}, ListFilter{})
 			if tt.wantErr {
 				if err == nil {
 					t.Fatal("expected error, got nil")
@@ -186,24 +281,50 @@ func TestServiceList(t *testing.T) {
 			if len(result.Data) != tt.wantLen {
 				t.Fatalf("expected %d gifts, got %d", tt.wantLen, len(result.Data))
 			}
-			if result.Total != tt.wantTotal {
-				t.Fatalf("expected total %d, got %d", tt.wantTotal, result.Total)
+			if result.Total == nil || *result.Total != tt.wantTotal {
+				t.Fatalf("expected total %d, got %v", tt.wantTotal, result.Total)
 			}
 		})
 	}
 }
 
+func TestServiceListByCursor(t *testing.T) {
+	var got pagination.Request
+	repo := &mockRepository{
+		listFn: func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
+			got = req
+			return []Gift{}, pagination.Meta{}, nil
+		},
+	}
+	svc := NewService(repo, &mockTxRunner{}, nil, nil, nil)
+
+	c := &pagination.Cursor{Order: SortNewest, Values: []string{"2026-01-01 00:00:00+00", "7"}}
+	resp, err := svc.List(context.Background(), pagination.Request{Page: 3, Cursor: c}, ListFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Cursor != c || got.Limit != 20 || got.Offset() != 0 || resp.Page != 0 {
+		t.Fatalf("expected the cursor to replace the page, got %+v (page %d)", got, resp.Page)
+	}
+
+	repo.listFn = func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
+		return nil, pagination.Meta{}, pagination.ErrInvalidCursor
+	}
+	_, err = svc.List(context.Background(), pagination.Request{Cursor: c}, ListFilter{})
+	assertAppError(t, err, http.StatusBadRequest, "invalid cursor")
+}
+
 func TestServiceListPassesFilter(t *testing.T) {
 	var got ListFilter
 	repo := &mockRepository{
-		listFn: func(ctx context.Context, filter ListFilter, limit, offset int) ([]Gift, int, error) {
+		listFn: func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
 			got = filter
-			return []Gift{}, 0, nil
+			return []Gift{}, pagination.Meta{Total: intPtr(0)}, nil
 		},
 	}
-	svc := NewService(repo, &mockTxRunner{}, nil)
+	svc := NewService(repo, &mockTxRunner{}, nil, nil, nil)
 
-	_, err := svc.List(context.Background(), 1, 20, ListFilter{
+	_, err := svc.List(context.Background(), pagination.Request{Page: 1, Limit: 20}, ListFilter{
 		Status:   strPtr("active"),
 		Search:   strPtr("  oster  "),
 		PriceMin: int64Ptr(10000),
@@ -227,18 +348,43 @@ func TestServiceListPassesFilter(t *testing.T) {
 	}
 }
 
+func TestServiceListHighlightsSearchMatches(t *testing.T) {
+	repo := &mockRepository{
+		listFn: func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
+			return []Gift{{ID: 1, Name: "Jogo de Panelas"}, {ID: 2, Name: "Panelinha"}, {ID: 3, Name: "Pamela"}}, pagination.Meta{Total: intPtr(3)}, nil
+		},
+	}
+	svc := NewService(repo, &mockTxRunner{}, nil, nil, nil)
+
+	resp, err := svc.List(context.Background(), pagination.Request{Page: 1, Limit: 20}, ListFilter{Search: strPtr("panela")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h := resp.Data[0].Highlight; h == nil || h.Text != "Jogo de Panelas" || len(h.Spans) != 1 || h.Spans[0].Start != 8 || h.Spans[0].End != 14 {
+		t.Fatalf("unexpected highlight %+v", resp.Data[0].Highlight)
+	}
+	if resp.Data[2].Highlight != nil {
+		t.Errorf("expected no highlight for a typo-only match, got %+v", resp.Data[2].Highlight)
+	}
+
+	resp, _ = svc.List(context.Background(), pagination.Request{Page: 1, Limit: 20}, ListFilter{})
+	if resp.Data[0].Highlight != nil {
+		t.Errorf("expected no highlight without a search, got %+v", resp.Data[0].Highlight)
+	}
+}
+
 func TestServiceListNormalizesFilter(t *testing.T) {
 	var got ListFilter
 	repo := &mockRepository{
-		listFn: func(ctx context.Context, filter ListFilter, limit, offset int) ([]Gift, int, error) {
+		listFn: func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
 			got = filter
-			return []Gift{}, 0, nil
+			return []Gift{}, pagination.Meta{Total: intPtr(0)}, nil
 		},
 	}
-	svc := NewService(repo, &mockTxRunner{}, nil)
+	svc := NewService(repo, &mockTxRunner{}, nil, nil, nil)
 
 	// busca só com espaços vira nil; preços negativos são ignorados
-	_, err := svc.List(context.Background(), 1, 20, ListFilter{
+	_, err := svc.List(context.Background(), pagination.Request{Page: 1, Limit: 20}, ListFilter{
 		Search:   strPtr("   "),
 		PriceMin: int64Ptr(-5),
 		PriceMax: int64Ptr(-1),
@@ -254,6 +400,127 @@ func TestServiceListNormalizesFilter(t *testing.T) {
 	}
 }
 
+func TestServiceListNormalizesCurationFilters(t *testing.T) {
+	var got ListFilter
+	repo := &mockRepository{
+		listFn: func(ctx context.Context, filter ListFilter, req pagination.Request) ([]Gift, pagination.Meta, error) {
+			got = filter
+			return []Gift{}, pagination.Meta{Total: intPtr(0)}, nil
+		},
+	}
+	svc := NewService(repo, &mockTxRunner{}, nil, nil, nil)
+
+	_, err := svc.List(context.Background(), pagination.Request{Page: 1, Limit: 20}, ListFilter{
+		Category: strPtr(" Lua de Mel "),
+		Tags:     []string{"Viagem", "viagem", " ", "Café da manhã"},
+		Sort:     strPtr(SortCurated),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Category == nil || *got.Category != "lua-de-mel" {
+		t.Fatalf("expected category slug 'lua-de-mel', got %v", got.Category)
+	}
+	if len(got.Tags) != 2 || got.Tags[0] != "viagem" || got.Tags[1] != "cafe-da-manha" {
+		t.Fatalf("expected deduped tag slugs, got %v", got.Tags)
+	}
+	if got.Sort == nil || *got.Sort != SortCurated {
+		t.Fatalf("expected curated sort to pass through, got %v", got.Sort)
+	}
+
+	_, _ = svc.List(context.Background(), pagination.Request{Page: 1, Limit: 20}, ListFilter{Category: strPtr("!!"), Sort: strPtr("random")})
+	if got.Category != nil || got.Sort != nil {
+		t.Fatalf("expected unusable category and unknown sort dropped, got category=%v sort=%v", got.Category, got.Sort)
+	}
+}
+
+func TestServiceCreateRunsInTransaction(t *testing.T) {
+	var gotInput CreateGiftInput
+	repo := &mockRepository{
+		createFn: func(ctx context.Context, input CreateGiftInput, dedupeKey, userRACF string) (*Gift, error) {
+			gotInput = input
+			g := sampleGift()
+			g.Tags = input.Tags
+			return &g, nil
+		},
+	}
+	svc := NewService(repo, &mockTxRunner{}, nil, nil, nil)
+
+	_, err := svc.Create(context.Background(), CreateGiftInput{
+		Name:       "Mala de viagem",
+		PriceCents: 50000,
+		Category:   strPtr("Lua de mel"),
+		Tags:       []string{"Viagem"},
+		Featured:   boolPtr(true),
+	}, "TST01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotInput.Category == nil || *gotInput.Category != "Lua de mel" || len(gotInput.Tags) != 1 {
+		t.Fatalf("expected category and tags forwarded, got %+v", gotInput)
+	}
+
+	_, err = svc.Create(context.Background(), CreateGiftInput{
+		Name:       "Mala de viagem",
+		PriceCents: 50000,
+		Position:   intPtr(-1),
+	}, "TST01")
+	assertAppError(t, err, http.StatusBadRequest, "")
+}
+
+func TestServiceReorder(t *testing.T) {
+	var gotIDs []int64
+	repo := &mockRepository{
+		reorderFn: func(ctx context.Context, ids []int64, userRACF string) (int64, error) {
+			gotIDs = ids
+			return int64(len(ids)), nil
+		},
+	}
+	log := &mockAudit{}
+	svc := NewService(repo, &mockTxRunner{}, nil, log, nil)
+	ctx := reqctx.WithUserID(context.Background(), 7)
+
+	if err := svc.Reorder(ctx, ReorderRequest{IDs: []int64{3, 1, 2}}, "TST01"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(gotIDs) != 3 || gotIDs[0] != 3 {
+		t.Fatalf("expected ids forwarded in order, got %v", gotIDs)
+	}
+	if len(log.calls) != 1 || log.calls[0].action != auditGiftReordered {
+		t.Fatalf("expected %q audit, got %+v", auditGiftReordered, log.calls)
+	}
+
+	err := svc.Reorder(ctx, ReorderRequest{IDs: []int64{1, 1}}, "TST01")
+	assertAppError(t, err, http.StatusBadRequest, "")
+
+	repo.reorderFn = func(ctx context.Context, ids []int64, userRACF string) (int64, error) {
+		return int64(len(ids) - 1), nil
+	}
+	err = svc.Reorder(ctx, ReorderRequest{IDs: []int64{1, 2}}, "TST01")
+	assertAppError(t, err, http.StatusBadRequest, "")
+}
+
+func TestServiceCreateCategory(t *testing.T) {
+	var gotSlug, gotName string
+	repo := &mockRepository{
+		createCategoryFn: func(ctx context.Context, input CategoryInput, slug string) (*Category, error) {
+			gotName, gotSlug = input.Name, slug
+			return &Category{ID: 1, Name: input.Name, Slug: slug}, nil
+		},
+	}
+	svc := NewService(repo, &mockTxRunner{}, nil, nil, nil)
+
+	if _, err := svc.CreateCategory(context.Background(), CategoryInput{Name: "  Lua   de mel "}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotName != "Lua de mel" || gotSlug != "lua-de-mel" {
+		t.Fatalf("expected cleaned name and slug, got %q / %q", gotName, gotSlug)
+	}
+
+	_, err := svc.CreateCategory(context.Background(), CategoryInput{Name: "???"})
+	assertAppError(t, err, http.StatusBadRequest, "")
+}
+
 func TestServiceGetByID(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -278,7 +545,7 @@ func TestServiceGetByID(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc := NewService(&mockRepository{getByIDFn: tt.mockFn}, &mockTxRunner{}, nil)
+			svc := NewService(&mockRepository{getByIDFn: tt.mockFn}, &mockTxRunner{}, nil, nil, nil)
 			g, err := svc.GetByID(context.Background(), 1)
 			if tt.wantErr {
 				if err == nil {
@@ -353,7 +620,7 @@ func TestServiceCreate(t *testing.T) {
 					return &g, nil
 				},
 			}
-			svc := NewService(repo, &mockTxRunner{}, nil)
+			svc := NewService(repo, &mockTxRunner{}, nil, nil, nil)
 			_, err := svc.Create(context.Background(), tt.input, "TST01")
 			if tt.wantErr {
 				assertAppError(t, err, tt.wantErrCode, tt.wantErrMsg)
@@ -375,7 +642,7 @@ func TestServiceCreateNormalizesDedupeKey(t *testing.T) {
 			return &g, nil
 		},
 	}
-	svc := NewService(repo, &mockTxRunner{}, nil)
+	svc := NewService(repo, &mockTxRunner{}, nil, nil, nil)
 
 	_, err := svc.Create(context.Background(), CreateGiftInput{
 		Name:       "  Máquina  de  Café  ",
@@ -395,7 +662,7 @@ func TestServiceCreateDuplicate(t *testing.T) {
 			return nil, apperror.Conflict("Já existe um presente com esse nome.")
 		},
 	}
-	svc := NewService(repo, &mockTxRunner{}, nil)
+	svc := NewService(repo, &mockTxRunner{}, nil, nil, nil)
 
 	_, err := svc.Create(context.Background(), CreateGiftInput{
 		Name:       "Panela",
@@ -442,7 +709,7 @@ func TestServiceUpdate(t *testing.T) {
 					return &g, nil
 				},
 			}
-			svc := NewService(repo, &mockTxRunner{}, nil)
+			svc := NewService(repo, &mockTxRunner{}, nil, nil, nil)
 			_, err := svc.Update(context.Background(), 1, tt.input, "TST01")
 			if tt.wantErr {
 				assertAppError(t, err, tt.wantErrCode, tt.wantErrMsg)
@@ -485,7 +752,7 @@ func TestServiceUpdateRecalculatesDedupeKeyOnlyWhenNameChanges(t *testing.T) {
 					return &g, nil
 				},
 			}
-			svc := NewService(repo, &mockTxRunner{}, nil)
+			svc := NewService(repo, &mockTxRunner{}, nil, nil, nil)
 			_, err := svc.Update(context.Background(), 1, tt.input, "TST01")
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
@@ -517,7 +784,7 @@ func TestServiceDelete(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc := NewService(&mockRepository{deleteFn: tt.mockFn}, &mockTxRunner{}, nil)
+			svc := NewService(&mockRepository{deleteFn: tt.mockFn}, &mockTxRunner{}, nil, nil, nil)
 			err := svc.Delete(context.Background(), 1, "TST01")
 			if tt.wantErr {
 				if err == nil {
@@ -625,7 +892,7 @@ func TestServiceScrapePreview(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc := NewService(&mockRepository{}, &mockTxRunner{}, &mockScraper{scrapeProductFn: tt.scrapeFn})
+			svc := NewService(&mockRepository{}, &mockTxRunner{}, &mockScraper{scrapeProductFn: tt.scrapeFn}, nil, nil)
 			got, err := svc.ScrapePreview(context.Background(), tt.url, "TST01")
 			if tt.wantErr {
 				assertAppError(t, err, tt.wantErrCode, tt.wantErrMsg)
@@ -654,7 +921,7 @@ func TestServiceScrapePreview(t *testing.T) {
 }
 
 func TestServiceScrapePreviewReturns503WhenScraperNil(t *testing.T) {
-	svc := NewService(&mockRepository{}, &mockTxRunner{}, nil)
+	svc := NewService(&mockRepository{}, &mockTxRunner{}, nil, nil, nil)
 	_, err := svc.ScrapePreview(context.Background(), "https://x.com/p", "TST01")
 	assertAppError(t, err, http.StatusServiceUnavailable, "Busca por link não está configurada")
 }
@@ -665,7 +932,7 @@ func TestServiceScrapePreviewTrimsName(t *testing.T) {
 		scrapeProductFn: func(ctx context.Context, url string) (*ScrapedProduct, error) {
 			return &ScrapedProduct{Name: longName}, nil
 		},
-	})
+	}, nil, nil)
 	got, err := svc.ScrapePreview(context.Background(), "https://x.com/p", "TST01")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -683,7 +950,7 @@ func TestServiceDeletePassesUserRACFToRepo(t *testing.T) {
 			return nil
 		},
 	}
-	svc := NewService(repo, &mockTxRunner{}, nil)
+	svc := NewService(repo, &mockTxRunner{}, nil, nil, nil)
 	if err := svc.Delete(context.Background(), 1, "ABC12"); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -691,3 +958,123 @@ func TestServiceDeletePassesUserRACFToRepo(t *testing.T) {
 		t.Fatalf("expected userRACF 'ABC12' passed to repo, got %q", gotRACF)
 	}
 }
+
+func TestServiceUpdateRecordsAuditDiff(t *testing.T) {
+	before := sampleGift()
+	repo := &mockRepository{
+		getByIDFn: func(ctx context.Context, id int64) (*Gift, error) {
+			g := before
+			return &g, nil
+		},
+		updateFn: func(ctx context.Context, id int64, input UpdateGiftInput, dedupeKey *string, userRACF string) (*Gift, error) {
+			g := before
+			g.PriceCents = *input.PriceCents
+			g.UpdatedAt = time.Now().Add(time.Minute)
+			return &g, nil
+		},
+	}
+	log := &mockAudit{}
+	svc := NewService(repo, &mockTxRunner{}, nil, log, nil)
+
+	ctx := reqctx.WithUserID(context.Background(), 3)
+	if _, err := svc.Update(ctx, 1, UpdateGiftInput{PriceCents: int64Ptr(25000)}, "TST01"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(log.calls) != 1 || log.calls[0].action != auditGiftUpdated || log.calls[0].userID != 3 {
+		t.Fatalf("expected gift.updated audit by user 3, got %+v", log.calls)
+	}
+	changes := log.calls[0].details["changes"].(map[string]audit.Change)
+	if len(changes) != 1 || changes["price_cents"].Before != float64(19990) || changes["price_cents"].After != float64(25000) {
+		t.Errorf("expected only price_cents change, got %v", changes)
+	}
+}
+
+func TestServiceDeleteRecordsAudit(t *testing.T) {
+	repo := &mockRepository{
+		getByIDFn: func(ctx context.Context, id int64) (*Gift, error) {
+			g := sampleGift()
+			return &g, nil
+		},
+		deleteFn: func(ctx context.Context, id int64, userRACF string) error { return nil },
+	}
+	log := &mockAudit{}
+	svc := NewService(repo, &mockTxRunner{}, nil, log, nil)
+
+	if err := svc.Delete(reqctx.WithUserID(context.Background(), 3), 1, "TST01"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(log.calls) != 1 || log.calls[0].action != auditGiftDeleted {
+		t.Fatalf("expected gift.deleted audit, got %+v", log.calls)
+	}
+	if log.calls[0].details["entity_id"] != int64(1) {
+		t.Errorf("expected entity_id 1, got %v", log.calls[0].details["entity_id"])
+	}
+}
+
+func TestServiceCommitImportRecordsAudit(t *testing.T) {
+	var nextID int64
+	repo := &mockRepository{
+		createFn: func(ctx context.Context, input CreateGiftInput, dedupeKey, userRACF string) (*Gift, error) {
+			nextID++
+			g := sampleGift()
+			g.ID = nextID
+			g.Name = input.Name
+			return &g, nil
+		},
+	}
+	log := &mockAudit{}
+	svc := NewService(repo, &mockTxRunner{}, nil, log, nil)
+
+	_, err := svc.CommitImport(reqctx.WithUserID(context.Background(), 3), []CreateGiftInput{
+		{Name: "Panela", PriceCents: 100},
+		{Name: "Taças", PriceCents: 200},
+		{Name: "panela", PriceCents: 100},
+	}, nil, ImportSourceXLSX, "TST01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(log.calls) != 3 {
+		t.Fatalf("expected two gift.created and one gift.import_committed audit, got %+v", log.calls)
+	}
+	for i, name := range []string{"Panela", "Taças"} {
+		call := log.calls[i]
+		changes, _ := call.details["changes"].(map[string]audit.Change)
+		if call.action != auditGiftCreated || call.details["entity_id"] != int64(i+1) || changes["name"].After != name {
+			t.Errorf("expected gift.created with the %q snapshot, got %+v", name, call)
+		}
+		if call.details["source"] != ImportSourceXLSX {
+			t.Errorf("expected source xlsx, got %v", call.details["source"])
+		}
+	}
+	summary := log.calls[2]
+	if summary.action != auditGiftImportCommitted {
+		t.Fatalf("expected gift.import_committed audit last, got %+v", summary)
+	}
+	ids, _ := summary.details["created_ids"].([]int64)
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("expected created_ids [1 2], got %v", summary.details["created_ids"])
+	}
+	if skipped, _ := summary.details["skipped"].([]string); len(skipped) != 1 {
+		t.Errorf("expected 1 skipped row, got %v", summary.details["skipped"])
+	}
+}
+
+func TestServiceCommitImportSource(t *testing.T) {
+	log := &mockAudit{}
+	svc := NewService(&mockRepository{}, &mockTxRunner{}, nil, log, nil)
+	rows := []CreateGiftInput{{Name: "Panela", PriceCents: 100}}
+
+	_, err := svc.CommitImport(context.Background(), rows, nil, "ods", "TST01")
+	assertAppError(t, err, http.StatusBadRequest, "source must be csv, xlsx or urls")
+
+	if _, err := svc.CommitImport(context.Background(), rows, nil, "", "TST01"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, call := range log.calls {
+		if _, ok := call.details["source"]; ok {
+			t.Errorf("expected no source when the client sends none, got %+v", call)
+		}
+	}
+}
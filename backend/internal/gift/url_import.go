@@ -0,0 +1,247 @@
+package gift
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+)
+
+const (
+	maxURLImportBatch = 100
+	// Batches up to this size are scraped within the request; larger ones
+	// become a job the client polls.
+	urlImportSyncMax     = 4
+	urlImportWorkers     = 4
+	urlImportJobTimeout  = 15 * time.Minute
+	urlImportJobTTL      = time.Hour
+	maxRunningURLImports = 2
+)
+
+// urlImportJobs keeps jobs in memory: they are short-lived previews, and
+// nothing is written to the catalog until the couple commits one.
+//
+// A job is only known to the process that started it, so with several
+// replicas GET /api/gifts/import/urls/{jobID} needs sticky sessions.
+type urlImportJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*URLImportJob
+}
+
+func newURLImportJobs() *urlImportJobs {
+	return &urlImportJobs{jobs: map[string]*URLImportJob{}}
+}
+
+// PreviewURLImport scrapes each URL with a bounded worker pool and returns
+// the rows classified like a CSV preview. progress, if set, is called with
+// the number of URLs finished so far.
+func (s *Service) PreviewURLImport(ctx context.Context, urls []string, progress func(done int)) (*CSVPreview, error) {
+	if s.scraper == nil {
+		return nil, apperror.ServiceUnavailable("Busca por link não está configurada neste ambiente.")
+	}
+	urls, err := normalizeImportURLs(urls)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]CSVPreviewRow, len(urls))
+	// Repeated URLs are scraped once; the copies then show up as duplicates.
+	first := map[string]int{}
+	var unique []int
+	for i, u := range urls {
+		if _, ok := first[u]; !ok {
+			first[u] = i
+			unique = append(unique, i)
+		}
+	}
+
+	var done atomic.Int64
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(urlImportWorkers, len(unique)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				rows[i] = s.scrapeImportRow(ctx, i+1, urls[i])
+				if progress != nil {
+					progress(int(done.Add(1)))
+				}
+			}
+		}()
+	}
+	for _, i := range unique {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, apperror.Internal("url import interrupted", err)
+	}
+	for i, u := range urls {
+		if j := first[u]; j != i {
+			rows[i] = rows[j]
+			rows[i].LineNumber = i + 1
+		}
+	}
+
+	preview, err := s.classifyPreview(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "gift.service preview_url_import: done",
+		"urls", len(urls), "new", preview.Summary.New, "duplicate", preview.Summary.Duplicate, "invalid", preview.Summary.Invalid)
+	return preview, nil
+}
+
+func normalizeImportURLs(urls []string) ([]string, error) {
+	out := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if u = strings.TrimSpace(u); u != "" {
+			out = append(out, u)
+		}
+	}
+	if len(out) == 0 {
+		return nil, apperror.Validation("informe ao menos uma URL")
+	}
+	if len(out) > maxURLImportBatch {
+		return nil, apperror.Validation(fmt.Sprintf("máximo de %d URLs por importação", maxURLImportBatch))
+	}
+	return out, nil
+}
+
+// scrapeImportRow turns one URL into a preview row; scrape failures become
+// row errors so one bad link does not sink the batch.
+func (s *Service) scrapeImportRow(ctx context.Context, lineNum int, url string) CSVPreviewRow {
+	row := CSVPreviewRow{LineNumber: lineNum, SourceURL: url}
+	if !httpsURLRegex.MatchString(url) {
+		row.Errors = append(row.Errors, "URL inválida — use uma URL https://")
+		return row
+	}
+	store := url
+	row.Input.StoreURL = &store
+
+	scrapeCtx, cancel := context.WithTimeout(ctx, scrapeRequestTimeout)
+	scraped, err := s.scraper.ScrapeProduct(scrapeCtx, url)
+	cancel()
+	if err != nil {
+		msg := "Não conseguimos buscar dados desta URL."
+		if ae, ok := apperror.IsAppError(err); ok && ae.Code < http.StatusInternalServerError {
+			msg = ae.Message
+		}
+		row.Errors = append(row.Errors, msg)
+		return row
+	}
+
+	name := truncateRunes(strings.TrimSpace(scraped.Name), scrapeMaxNameLen)
+	if name == "" {
+		row.Errors = append(row.Errors, "Não conseguimos identificar o produto nesta página.")
+	} else {
+		row.Input.Name = name
+		row.DedupeKey = NormalizeDedupeKey(name)
+	}
+
+	if cents, err := scrapedPriceCents(scraped.PriceBRL); err == nil {
+		row.Input.PriceCents = cents
+	} else {
+		row.Errors = append(row.Errors, "preço não encontrado na página — informe manualmente")
+	}
+
+	if d := truncateRunes(strings.TrimSpace(scraped.Description), scrapeMaxDescriptionLen); d != "" {
+		row.Input.Description = &d
+	}
+	if img := strings.TrimSpace(scraped.ImageURL); httpsURLRegex.MatchString(img) {
+		row.Input.ImageURL = &img
+	}
+	return row
+}
+
+// StartURLImport runs PreviewURLImport in the background and returns the
+// job to poll with URLImportJob.
+func (s *Service) StartURLImport(ctx context.Context, urls []string, userRACF string) (*URLImportJob, error) {
+	if s.scraper == nil {
+		return nil, apperror.ServiceUnavailable("Busca por link não está configurada neste ambiente.")
+	}
+	urls, err := normalizeImportURLs(urls)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, apperror.Internal("failed to generate job id", err)
+	}
+	job := &URLImportJob{
+		ID:        hex.EncodeToString(buf),
+		Status:    URLImportStatusRunning,
+		Total:     len(urls),
+		CreatedAt: time.Now(),
+	}
+
+	s.urlImports.mu.Lock()
+	running := 0
+	for id, j := range s.urlImports.jobs {
+		switch {
+		case j.Status == URLImportStatusRunning:
+			running++
+		case j.FinishedAt != nil && time.Since(*j.FinishedAt) > urlImportJobTTL:
+			delete(s.urlImports.jobs, id)
+		}
+	}
+	if running >= maxRunningURLImports {
+		s.urlImports.mu.Unlock()
+		return nil, apperror.TooManyRequests("já existe uma importação por links em andamento, aguarde terminar")
+	}
+	s.urlImports.jobs[job.ID] = job
+	snapshot := *job
+	s.urlImports.mu.Unlock()
+
+	// The job outlives the request but keeps its values (request ID, user)
+	// for logs and audit.
+	jobCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), urlImportJobTimeout)
+	go func() {
+		defer cancel()
+		preview, err := s.PreviewURLImport(jobCtx, urls, func(done int) {
+			s.urlImports.mu.Lock()
+			job.Done = max(job.Done, done)
+			s.urlImports.mu.Unlock()
+		})
+
+		s.urlImports.mu.Lock()
+		defer s.urlImports.mu.Unlock()
+		now := time.Now()
+		job.FinishedAt = &now
+		if err != nil {
+			job.Status = URLImportStatusFailed
+			job.Error = "a importação não pôde ser concluída, tente novamente"
+			slog.ErrorContext(jobCtx, "gift.service url_import: job failed", "job_id", job.ID, "error", err)
+			return
+		}
+		job.Status = URLImportStatusDone
+		job.Done = job.Total
+		job.Preview = preview
+	}()
+
+	slog.InfoContext(ctx, "gift.service url_import: job started", "job_id", job.ID, "urls", len(urls), "user_racf", userRACF)
+	return &snapshot, nil
+}
+
+func (s *Service) URLImportJob(id string) (*URLImportJob, error) {
+	s.urlImports.mu.Lock()
+	defer s.urlImports.mu.Unlock()
+	job, ok := s.urlImports.jobs[id]
+	if !ok {
+		return nil, apperror.NotFound("importação não encontrada")
+	}
+	snapshot := *job
+	return &snapshot, nil
+}
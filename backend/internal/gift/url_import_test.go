@@ -0,0 +1,218 @@
+package gift
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+)
+
+// catalogScraper answers from a fixed map of URL → product.
+func catalogScraper(products map[string]*ScrapedProduct) *mockScraper {
+	return &mockScraper{scrapeProductFn: func(ctx context.Context, url string) (*ScrapedProduct, error) {
+		p, ok := products[url]
+		if !ok {
+			return nil, apperror.Validation("Não conseguimos buscar dados desta URL.")
+		}
+		return p, nil
+	}}
+}
+
+func TestServicePreviewURLImport(t *testing.T) {
+	repo := &mockRepository{
+		findByDedupeKeysFn: func(ctx context.Context, keys []string) (map[string]bool, error) {
+			return map[string]bool{NormalizeDedupeKey("Cafeteira"): true}, nil
+		},
+	}
+	scraper := catalogScraper(map[string]*ScrapedProduct{
+		"https://shop.example.com/panela":    {Name: "Panela", PriceBRL: "R$ 199,90", ImageURL: "https://img.example.com/p.jpg"},
+		"https://shop.example.com/cafeteira": {Name: "Cafeteira", PriceBRL: "350.00"},
+		"https://shop.example.com/sem-preco": {Name: "Mixer"},
+	})
+	svc := NewService(repo, &mockTxRunner{}, scraper, nil, nil)
+
+	var lastProgress atomic.Int64
+	preview, err := svc.PreviewURLImport(context.Background(), []string{
+		"https://shop.example.com/panela",
+		" https://shop.example.com/cafeteira ",
+		"https://shop.example.com/sem-preco",
+		"http://shop.example.com/inseguro",
+		"https://shop.example.com/fora-do-ar",
+		"https://shop.example.com/panela",
+		"",
+	}, func(done int) { lastProgress.Store(int64(done)) })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := CSVSummary{Total: 6, New: 1, Duplicate: 2, Invalid: 3}
+	if preview.Summary != want {
+		t.Fatalf("expected summary %+v, got %+v", want, preview.Summary)
+	}
+	first := preview.Rows[0]
+	if first.Status != CSVRowStatusNew || first.Input.PriceCents != 19990 || first.Input.StoreURL == nil || first.Input.ImageURL == nil {
+		t.Errorf("unexpected first row %+v", first)
+	}
+	if preview.Rows[1].Status != CSVRowStatusDuplicate || preview.Rows[5].Status != CSVRowStatusDuplicate || preview.Rows[5].LineNumber != 6 {
+		t.Errorf("expected existing gift and repeated URL flagged duplicate, got %+v / %+v", preview.Rows[1], preview.Rows[5])
+	}
+	if r := preview.Rows[2]; r.Status != CSVRowStatusInvalid || r.Input.Name != "Mixer" {
+		t.Errorf("expected missing price to keep the name and be invalid, got %+v", r)
+	}
+	if r := preview.Rows[4]; r.SourceURL != "https://shop.example.com/fora-do-ar" || len(r.Errors) != 1 {
+		t.Errorf("expected scrape error on the row, got %+v", r)
+	}
+	if lastProgress.Load() != 5 {
+		t.Errorf("expected progress for the 5 distinct URLs, got %d", lastProgress.Load())
+	}
+}
+
+func TestServicePreviewURLImportBoundsConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int64
+	scraper := &mockScraper{scrapeProductFn: func(ctx context.Context, url string) (*ScrapedProduct, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return &ScrapedProduct{Name: url, PriceBRL: "10,00"}, nil
+	}}
+	svc := NewService(&mockRepository{}, &mockTxRunner{}, scraper, nil, nil)
+
+	urls := make([]string, 20)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://shop.example.com/p/%d", i)
+	}
+	preview, err := svc.PreviewURLImport(context.Background(), urls, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if preview.Summary.New != 20 {
+		t.Fatalf("expected 20 new rows, got %+v", preview.Summary)
+	}
+	if peak.Load() > urlImportWorkers {
+		t.Fatalf("expected at most %d concurrent scrapes, got %d", urlImportWorkers, peak.Load())
+	}
+}
+
+func TestServicePreviewURLImportValidation(t *testing.T) {
+	svc := NewService(&mockRepository{}, &mockTxRunner{}, &mockScraper{}, nil, nil)
+	_, err := svc.PreviewURLImport(context.Background(), []string{" ", ""}, nil)
+	assertAppError(t, err, http.StatusBadRequest, "informe ao menos uma URL")
+
+	_, err = NewService(&mockRepository{}, &mockTxRunner{}, nil, nil, nil).PreviewURLImport(context.Background(), []string{"https://x.com/p"}, nil)
+	assertAppError(t, err, http.StatusServiceUnavailable, "")
+}
+
+func TestHandlerURLImportSyncAndAsync(t *testing.T) {
+	h, _, scraper := newTestHandler()
+	release := make(chan struct{})
+	scraper.scrapeProductFn = func(ctx context.Context, url string) (*ScrapedProduct, error) {
+		if strings.Contains(url, "/lento/") {
+			<-release
+		}
+		return &ScrapedProduct{Name: "Produto " + url[strings.LastIndex(url, "/")+1:], PriceBRL: "49,90"}, nil
+	}
+
+	post := func(urls []string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(URLImportRequest{URLs: urls})
+		req := httptest.NewRequest(http.MethodPost, "/api/gifts/import/urls", bytes.NewReader(body))
+		req = withTestClaims(req, "TST01")
+		w := httptest.NewRecorder()
+		h.HandleURLImport(w, req)
+		return w
+	}
+
+	w := post([]string{"https://shop.example.com/p/1", "https://shop.example.com/p/2"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a small batch, got %d: %s", w.Code, w.Body.String())
+	}
+	var preview CSVPreview
+	if err := json.NewDecoder(w.Body).Decode(&preview); err != nil || preview.Summary.New != 2 {
+		t.Fatalf("expected 2 new rows, got %+v (%v)", preview.Summary, err)
+	}
+
+	urls := make([]string, urlImportSyncMax+2)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://shop.example.com/lento/%d", i)
+	}
+	w = post(urls)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202 for a large batch, got %d: %s", w.Code, w.Body.String())
+	}
+	var job URLImportJob
+	if err := json.NewDecoder(w.Body).Decode(&job); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if job.Status != URLImportStatusRunning || job.Total != len(urls) || w.Header().Get("Location") != "/api/gifts/import/urls/"+job.ID {
+		t.Fatalf("unexpected job %+v (location %q)", job, w.Header().Get("Location"))
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/gifts/import/urls/{jobID}", h.HandleGetURLImport)
+	poll := func(id string) (int, URLImportJob) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/gifts/import/urls/"+id, nil))
+		var got URLImportJob
+		_ = json.NewDecoder(w.Body).Decode(&got)
+		return w.Code, got
+	}
+
+	close(release)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		code, got := poll(job.ID)
+		if code != http.StatusOK {
+			t.Fatalf("expected 200 while polling, got %d", code)
+		}
+		if got.Status == URLImportStatusDone {
+			if got.Done != got.Total || got.Preview == nil || got.Preview.Summary.New != len(urls) {
+				t.Fatalf("unexpected finished job %+v", got)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not finish: %+v", got)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if code, _ := poll("desconhecido"); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown job, got %d", code)
+	}
+}
+
+func TestServiceStartURLImportLimitsRunningJobs(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	scraper := &mockScraper{scrapeProductFn: func(ctx context.Context, url string) (*ScrapedProduct, error) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, errors.New("cancelled")
+		}
+		return &ScrapedProduct{}, nil
+	}}
+	svc := NewService(&mockRepository{}, &mockTxRunner{}, scraper, nil, nil)
+
+	for range maxRunningURLImports {
+		if _, err := svc.StartURLImport(context.Background(), []string{"https://shop.example.com/p"}, "TST01"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	_, err := svc.StartURLImport(context.Background(), []string{"https://shop.example.com/p"}, "TST01")
+	assertAppError(t, err, http.StatusTooManyRequests, "")
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
 	"github.com/ferjunior7/parasempre/backend/internal/httputil"
 	"github.com/ferjunior7/parasempre/backend/internal/middleware"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
 )
 
 const (
@@ -51,6 +52,8 @@ func (h *Handler) HandleCreate(w http.ResponseWriter, r *http.Request) {
 	input := CreateInput{
 		AuthorName: r.FormValue("author_name"),
 		Content:    r.FormValue("content"),
+		MediaKey:   r.FormValue("media_key"),
+		UploadID:   r.FormValue("upload_id"),
 	}
 
 	var media *Media
@@ -83,6 +86,30 @@ func (h *Handler) HandleCreate(w http.ResponseWriter, r *http.Request) {
 	httputil.WriteJSON(w, http.StatusCreated, pub)
 }
 
+func (h *Handler) HandleCreateUploadSlot(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+	if userID == 0 {
+		httputil.WriteError(w, r, apperror.Unauthorized("autenticação obrigatória"))
+		return
+	}
+	txID, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("id de transação inválido", err))
+		return
+	}
+	var input UploadSlotInput
+	if err := httputil.DecodeJSON(r, &input); err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	slot, err := h.svc.CreateUploadSlot(r.Context(), txID, userID, input)
+	if err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusCreated, slot)
+}
+
 func (h *Handler) HandleGetMine(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.UserIDFromContext(r.Context())
 	if userID == 0 {
@@ -123,9 +150,43 @@ func (h *Handler) HandleListByGift(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) HandleAdminList(w http.ResponseWriter, r *http.Request) {
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	resp, err := h.svc.ListAll(r.Context(), page, limit)
+	req, err := pagination.FromQuery(r.URL.Query())
+	if err != nil {
+		httputil.WriteError(w, r, apperror.Validation("cursor inválido"))
+		return
+	}
+	resp, err := h.svc.ListAll(r.Context(), req)
+	if err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) HandleModerationQueue(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	resp, err := h.svc.ModerationQueue(r.Context(), q.Get("status"), page, limit)
+	if err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) HandleModerate(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+	if userID == 0 {
+		httputil.WriteError(w, r, apperror.Unauthorized("autenticação obrigatória"))
+		return
+	}
+	var input ModerateInput
+	if err := httputil.DecodeJSON(r, &input); err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	resp, err := h.svc.Moderate(r.Context(), input, userID)
 	if err != nil {
 		httputil.WriteError(w, r, err)
 		return
@@ -150,3 +211,115 @@ func (h *Handler) HandleAdminDelete(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *Handler) HandleCreateReply(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+	if userID == 0 {
+		httputil.WriteError(w, r, apperror.Unauthorized("autenticação obrigatória"))
+		return
+	}
+	messageID, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("id de mensagem inválido", err))
+		return
+	}
+	var input ReplyInput
+	if err := httputil.DecodeJSON(r, &input); err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	reply, err := h.svc.CreateReply(r.Context(), messageID, userID, input)
+	if err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusCreated, reply)
+}
+
+func (h *Handler) HandleUpdateReply(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+	if userID == 0 {
+		httputil.WriteError(w, r, apperror.Unauthorized("autenticação obrigatória"))
+		return
+	}
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("id de resposta inválido", err))
+		return
+	}
+	var input ReplyInput
+	if err := httputil.DecodeJSON(r, &input); err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	reply, err := h.svc.UpdateReply(r.Context(), id, userID, input)
+	if err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, reply)
+}
+
+func (h *Handler) HandleDeleteReply(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+	if userID == 0 {
+		httputil.WriteError(w, r, apperror.Unauthorized("autenticação obrigatória"))
+		return
+	}
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("id de resposta inválido", err))
+		return
+	}
+	if err := h.svc.DeleteReply(r.Context(), id, userID); err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) HandleAddReaction(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+	if userID == 0 {
+		httputil.WriteError(w, r, apperror.Unauthorized("autenticação obrigatória"))
+		return
+	}
+	messageID, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("id de mensagem inválido", err))
+		return
+	}
+	var input ReactionInput
+	if err := httputil.DecodeJSON(r, &input); err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	reaction, created, err := h.svc.AddReaction(r.Context(), messageID, userID, input)
+	if err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	httputil.WriteJSON(w, status, reaction)
+}
+
+func (h *Handler) HandleDeleteReaction(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+	if userID == 0 {
+		httputil.WriteError(w, r, apperror.Unauthorized("autenticação obrigatória"))
+		return
+	}
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("id de reação inválido", err))
+		return
+	}
+	if err := h.svc.RemoveReaction(r.Context(), id, userID); err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -18,7 +18,7 @@ import (
 )
 
 func newTestHandler(repo TxAwareRepository, txns TransactionFinder, storage Storage) *Handler {
-	svc := NewService(repo, txns, storage, &mockAudit{}, time.Minute)
+	svc := NewService(repo, txns, storage, &mockAudit{}, time.Minute, ModerationAuto)
 	return NewHandler(svc)
 }
 
@@ -111,7 +111,8 @@ func TestHandleCreate_WithMedia_Returns201AndUploads(t *testing.T) {
 				UserID: in.UserID, AuthorName: in.AuthorName, Content: in.Content,
 				MediaObjectKey: in.MediaObjectKey, MediaKind: in.MediaKind,
 				MediaSizeBytes: in.MediaSizeBytes, MediaMimeType: in.MediaMimeType,
-				CreatedAt: time.Now(), UpdatedAt: time.Now(),
+				MediaThumbKey: in.MediaThumbKey,
+				CreatedAt:     time.Now(), UpdatedAt: time.Now(),
 			}, nil
 		},
 	}
@@ -121,7 +122,7 @@ func TestHandleCreate_WithMedia_Returns201AndUploads(t *testing.T) {
 	storage := &mockStorage{}
 	h := newTestHandler(repo, txns, storage)
 
-	jpegBytes := []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0, 1, 1, 0, 0, 1, 0, 1, 0, 0}
+	jpegBytes := encodeTestJPEG(32, 32, nil)
 	contentType, body := buildMultipart(t, map[string]string{
 		"author_name": "Maria",
 		"content":     "Felicidades!",
@@ -143,6 +144,9 @@ func TestHandleCreate_WithMedia_Returns201AndUploads(t *testing.T) {
 	if resp.MediaURL == nil {
 		t.Errorf("expected signed media URL on response")
 	}
+	if resp.ThumbnailURL == nil || !strings.Contains(*resp.ThumbnailURL, ".thumb.jpg") {
+		t.Errorf("expected signed thumbnail URL on response, got %v", resp.ThumbnailURL)
+	}
 }
 
 func TestHandleCreate_RejectsAnotherUserTransaction(t *testing.T) {
@@ -301,3 +305,64 @@ func TestHandleAdminDelete_Returns204(t *testing.T) {
 		t.Fatalf("expected 204, got %d", w.Code)
 	}
 }
+
+func TestHandleModerate_ReturnsResult(t *testing.T) {
+	repo := &mockRepo{
+		setStatusFn: func(_ context.Context, ids []int64, status string, _ int64) ([]int64, error) {
+			if status != StatusApproved {
+				t.Errorf("expected approve, got %q", status)
+			}
+			return ids, nil
+		},
+	}
+	h := newTestHandler(repo, &mockTxFinder{}, nil)
+	body := strings.NewReader(`{"ids":[4,5],"action":"approve"}`)
+	r := authedRequest(http.MethodPost, "/api/admin/gift-messages/moderation", body, 99, "application/json", "")
+	w := httptest.NewRecorder()
+	h.HandleModerate(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", w.Code, w.Body.String())
+	}
+	var res ModerateResult
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(res.Updated) != 2 || len(res.Skipped) != 0 {
+		t.Errorf("unexpected result %+v", res)
+	}
+}
+
+func TestHandleAddReaction_ReturnsCreatedThenOK(t *testing.T) {
+	created := true
+	repo := &mockRepo{
+		getByIDFn: func(_ context.Context, id int64) (*GiftMessage, error) { return &GiftMessage{ID: id}, nil },
+		addReactionFn: func(_ context.Context, messageID, userID int64, emoji string) (*Reaction, bool, error) {
+			return &Reaction{ID: 5, MessageID: messageID, UserID: userID, Emoji: emoji}, created, nil
+		},
+	}
+	h := newTestHandler(repo, &mockTxFinder{}, nil)
+	for _, want := range []int{http.StatusCreated, http.StatusOK} {
+		r := authedRequest(http.MethodPost, "/api/admin/gift-messages/7/reactions", strings.NewReader(`{"emoji":"🎉"}`), 99, "application/json", "7")
+		w := httptest.NewRecorder()
+		h.HandleAddReaction(w, r)
+		if w.Code != want {
+			t.Fatalf("expected %d, got %d body=%s", want, w.Code, w.Body.String())
+		}
+		created = false
+	}
+}
+
+func TestHandleDeleteReply_Returns204(t *testing.T) {
+	repo := &mockRepo{
+		deleteReplyFn: func(_ context.Context, id int64) (*Reply, error) {
+			return &Reply{ID: id, MessageID: 7}, nil
+		},
+	}
+	h := newTestHandler(repo, &mockTxFinder{}, nil)
+	r := authedRequest(http.MethodDelete, "/api/admin/gift-message-replies/3", nil, 99, "", "3")
+	w := httptest.NewRecorder()
+	h.HandleDeleteReply(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+}
@@ -0,0 +1,252 @@
+package giftmessage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+)
+
+const (
+	thumbMaxSide     = 480
+	fullJPEGQuality  = 88
+	thumbJPEGQuality = 80
+
+	// Rejects decompression bombs before allocating the pixel buffer.
+	maxImagePixels = 40_000_000
+)
+
+// processedImage is an upload after re-encoding: pixels only, no EXIF/XMP
+// (GPS, camera serials), rotated upright.
+type processedImage struct {
+	full      []byte
+	fullMime  string
+	fullExt   string
+	thumb     []byte
+	thumbMime string
+	thumbExt  string
+}
+
+// processImage decodes a jpeg/png/webp upload, applies its EXIF orientation
+// and re-encodes it along with a thumbnail no larger than thumbMaxSide.
+// JPEG stays JPEG and PNG stays PNG; WebP (no encoder in Go) becomes JPEG,
+// or PNG when it has transparency.
+func processImage(data []byte, mime string) (*processedImage, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, apperror.Validation("imagem inválida ou corrompida")
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, apperror.Validation("imagem com dimensões não suportadas")
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, apperror.Validation("imagem inválida ou corrompida")
+	}
+	img := orient(toNRGBA(src), exifOrientation(data, mime))
+
+	out := &processedImage{}
+	usePNG := mime == "image/png" || (mime == "image/webp" && !img.Opaque())
+	if out.full, out.fullMime, out.fullExt, err = encodeImage(img, usePNG, fullJPEGQuality); err != nil {
+		return nil, err
+	}
+	if out.thumb, out.thumbMime, out.thumbExt, err = encodeImage(thumbnail(img), !img.Opaque(), thumbJPEGQuality); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func encodeImage(img *image.NRGBA, usePNG bool, quality int) ([]byte, string, string, error) {
+	var buf bytes.Buffer
+	if usePNG {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", "", apperror.Internal("falha ao processar imagem", err)
+		}
+		return buf.Bytes(), "image/png", ".png", nil
+	}
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, "", "", apperror.Internal("falha ao processar imagem", err)
+	}
+	return buf.Bytes(), "image/jpeg", ".jpg", nil
+}
+
+func toNRGBA(src image.Image) *image.NRGBA {
+	if n, ok := src.(*image.NRGBA); ok && n.Rect.Min == (image.Point{}) {
+		return n
+	}
+	b := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	return dst
+}
+
+func thumbnail(img *image.NRGBA) *image.NRGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	if w <= thumbMaxSide && h <= thumbMaxSide {
+		return img
+	}
+	tw, th := thumbMaxSide, h*thumbMaxSide/w
+	if h > w {
+		tw, th = w*thumbMaxSide/h, thumbMaxSide
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, max(tw, 1), max(th, 1)))
+	xdraw.CatmullRom.Scale(dst, dst.Rect, img, img.Rect, xdraw.Src, nil)
+	return dst
+}
+
+// orient applies an EXIF orientation (1-8) so the pixels display upright
+// without the tag.
+func orient(img *image.NRGBA, o int) *image.NRGBA {
+	if o < 2 || o > 8 {
+		return img
+	}
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	dw, dh := w, h
+	if o >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch o {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			si := img.PixOffset(x, y)
+			di := dst.PixOffset(dx, dy)
+			copy(dst.Pix[di:di+4], img.Pix[si:si+4])
+		}
+	}
+	return dst
+}
+
+// exifOrientation returns the EXIF Orientation tag of an image, or 1 when
+// absent or unreadable.
+func exifOrientation(data []byte, mime string) int {
+	var tiff []byte
+	switch mime {
+	case "image/jpeg":
+		tiff = jpegEXIF(data)
+	case "image/png":
+		tiff = pngChunk(data, "eXIf")
+	case "image/webp":
+		tiff = bytes.TrimPrefix(webpChunk(data, "EXIF"), []byte("Exif\x00\x00"))
+	}
+	return tiffOrientation(tiff)
+}
+
+func jpegEXIF(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			return nil
+		}
+		n := int(binary.BigEndian.Uint16(data[i+2:]))
+		if n < 2 || i+2+n > len(data) {
+			return nil
+		}
+		seg := data[i+4 : i+2+n]
+		if marker == 0xE1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			return seg[6:]
+		}
+		i += 2 + n
+	}
+	return nil
+}
+
+func pngChunk(data []byte, typ string) []byte {
+	if len(data) < 8 {
+		return nil
+	}
+	for i := 8; i+8 <= len(data); {
+		n := int(binary.BigEndian.Uint32(data[i:]))
+		if n < 0 || i+12+n > len(data) {
+			return nil
+		}
+		if string(data[i+4:i+8]) == typ {
+			return data[i+8 : i+8+n]
+		}
+		if string(data[i+4:i+8]) == "IEND" {
+			return nil
+		}
+		i += 12 + n
+	}
+	return nil
+}
+
+func webpChunk(data []byte, fourCC string) []byte {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil
+	}
+	for i := 12; i+8 <= len(data); {
+		n := int(binary.LittleEndian.Uint32(data[i+4:]))
+		if n < 0 || i+8+n > len(data) {
+			return nil
+		}
+		if string(data[i:i+4]) == fourCC {
+			return data[i+8 : i+8+n]
+		}
+		i += 8 + n + n%2
+	}
+	return nil
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[0:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for e := 0; e < count; e++ {
+		off := ifd + 2 + e*12
+		if off+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[off:]) == 0x0112 && order.Uint16(tiff[off+2:]) == 3 {
+			if o := int(order.Uint16(tiff[off+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+			return 1
+		}
+	}
+	return 1
+}
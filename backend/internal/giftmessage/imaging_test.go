@@ -0,0 +1,148 @@
+package giftmessage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"testing"
+)
+
+// encodeTestJPEG draws a w×h image whose top-left pixel is red and, when
+// exif is given, splices it in as an APP1 segment right after SOI.
+func encodeTestJPEG(w, h int, exif []byte) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{0, 0, 255, 255})
+		}
+	}
+	for y := 0; y < h/4; y++ {
+		for x := 0; x < w/4; x++ {
+			img.Set(x, y, color.RGBA{255, 0, 0, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		panic(err)
+	}
+	data := buf.Bytes()
+	if exif == nil {
+		return data
+	}
+	seg := append([]byte("Exif\x00\x00"), exif...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(seg)+2))
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+	out = append(out, seg...)
+	return append(out, data[2:]...)
+}
+
+// tiffWithOrientation builds a big-endian TIFF block with an Orientation tag
+// and a GPS IFD pointer, like a phone camera would write.
+func tiffWithOrientation(o uint16) []byte {
+	b := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	b = binary.BigEndian.AppendUint16(b, 2)
+	// Orientation, SHORT, 1, value
+	b = binary.BigEndian.AppendUint16(b, 0x0112)
+	b = binary.BigEndian.AppendUint16(b, 3)
+	b = binary.BigEndian.AppendUint32(b, 1)
+	b = binary.BigEndian.AppendUint16(b, o)
+	b = append(b, 0, 0)
+	// GPSInfo, LONG, 1, offset (points past the IFD; content irrelevant)
+	b = binary.BigEndian.AppendUint16(b, 0x8825)
+	b = binary.BigEndian.AppendUint16(b, 4)
+	b = binary.BigEndian.AppendUint32(b, 1)
+	b = binary.BigEndian.AppendUint32(b, 38)
+	b = binary.BigEndian.AppendUint32(b, 0)
+	return append(b, []byte("GPS-23.5505,-46.6333")...)
+}
+
+func TestExifOrientation(t *testing.T) {
+	for o := uint16(1); o <= 8; o++ {
+		data := encodeTestJPEG(8, 4, tiffWithOrientation(o))
+		if got := exifOrientation(data, "image/jpeg"); got != int(o) {
+			t.Errorf("orientation %d: got %d", o, got)
+		}
+	}
+	if got := exifOrientation(encodeTestJPEG(8, 4, nil), "image/jpeg"); got != 1 {
+		t.Errorf("expected 1 without EXIF, got %d", got)
+	}
+	if got := exifOrientation([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF}, "image/jpeg"); got != 1 {
+		t.Errorf("expected 1 for truncated EXIF, got %d", got)
+	}
+}
+
+func TestProcessImage_StripsMetadataAndRotates(t *testing.T) {
+	// 80×40 landscape tagged "rotate 90° CW" → upright it is 40×80 with the
+	// red corner moved to the top-right.
+	data := encodeTestJPEG(80, 40, tiffWithOrientation(6))
+	if !bytes.Contains(data, []byte("GPS-23.5505")) {
+		t.Fatal("fixture should carry the GPS marker")
+	}
+
+	out, err := processImage(data, "image/jpeg")
+	if err != nil {
+		t.Fatalf("processImage: %v", err)
+	}
+	if bytes.Contains(out.full, []byte("Exif")) || bytes.Contains(out.full, []byte("GPS-23.5505")) {
+		t.Error("re-encoded image still carries EXIF/GPS")
+	}
+	if out.fullMime != "image/jpeg" || out.fullExt != ".jpg" {
+		t.Errorf("expected jpeg output, got %s %s", out.fullMime, out.fullExt)
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(out.full))
+	if err != nil {
+		t.Fatalf("decode output: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 40 || b.Dy() != 80 {
+		t.Fatalf("expected 40x80 after rotation, got %dx%d", b.Dx(), b.Dy())
+	}
+	r, _, bl, _ := img.At(36, 3).RGBA()
+	if r < 0xA000 || bl > 0x6000 {
+		t.Errorf("expected red corner at top-right after rotation, got r=%x b=%x", r, bl)
+	}
+}
+
+func TestProcessImage_Thumbnail(t *testing.T) {
+	out, err := processImage(encodeTestJPEG(1200, 600, nil), "image/jpeg")
+	if err != nil {
+		t.Fatalf("processImage: %v", err)
+	}
+	thumb, err := jpeg.Decode(bytes.NewReader(out.thumb))
+	if err != nil {
+		t.Fatalf("decode thumb: %v", err)
+	}
+	if b := thumb.Bounds(); b.Dx() != thumbMaxSide || b.Dy() != thumbMaxSide/2 {
+		t.Errorf("expected %dx%d thumbnail, got %dx%d", thumbMaxSide, thumbMaxSide/2, b.Dx(), b.Dy())
+	}
+	if len(out.thumb) >= len(out.full) {
+		t.Errorf("thumbnail (%d bytes) should be smaller than the full image (%d bytes)", len(out.thumb), len(out.full))
+	}
+}
+
+func TestProcessImage_PNGKeepsAlpha(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	src.Set(1, 1, color.NRGBA{255, 0, 0, 128})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	out, err := processImage(buf.Bytes(), "image/png")
+	if err != nil {
+		t.Fatalf("processImage: %v", err)
+	}
+	if out.fullMime != "image/png" || out.thumbMime != "image/png" {
+		t.Errorf("expected png output for translucent image, got %s / %s", out.fullMime, out.thumbMime)
+	}
+}
+
+func TestProcessImage_RejectsGarbage(t *testing.T) {
+	_, err := processImage([]byte{0xFF, 0xD8, 0xFF, 0xE0, 0, 0x10, 'J', 'F', 'I', 'F'}, "image/jpeg")
+	assertAppError(t, err, http.StatusBadRequest, "imagem inválida")
+}
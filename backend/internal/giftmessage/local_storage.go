@@ -0,0 +1,237 @@
+package giftmessage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/httputil"
+)
+
+// LocalMediaRoute is where LocalStorage serves objects; SignURLs points here.
+const LocalMediaRoute = "/api/media/"
+
+// localMimes maps the extensions the service writes back to a content type,
+// since the filesystem keeps no metadata of its own.
+var localMimes = map[string]string{
+	".jpg":  "image/jpeg",
+	".png":  "image/png",
+	".webp": "image/webp",
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/mp4",
+	".ogg":  "audio/ogg",
+	".mp4":  "video/mp4",
+	".webm": "video/webm",
+	".mov":  "video/quicktime",
+}
+
+// LocalStorage keeps objects under a directory and serves them through
+// HandleServe (and accepts direct uploads through HandleUpload), guarded by
+// HMAC-signed, expiring URLs. Meant for local development and single-node
+// self-hosting.
+type LocalStorage struct {
+	root      string
+	publicURL string
+	secret    []byte
+	now       func() time.Time
+}
+
+func NewLocalStorage(root, publicURL string, secret []byte) *LocalStorage {
+	return &LocalStorage{
+		root:      root,
+		publicURL: strings.TrimRight(publicURL, "/"),
+		secret:    secret,
+		now:       time.Now,
+	}
+}
+
+// EnsureDir creates the root directory so a misconfigured path fails at
+// startup instead of on the first upload.
+func (s *LocalStorage) EnsureDir() error {
+	if err := os.MkdirAll(s.root, 0o750); err != nil {
+		return fmt.Errorf("local storage: create root %q: %w", s.root, err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) Upload(ctx context.Context, key, mime string, r io.Reader, size int64) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return fmt.Errorf("local storage: create dir: %w", err)
+	}
+	// O_EXCL gives the same no-overwrite semantics as the Supabase upload
+	// (x-upsert: false).
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return fmt.Errorf("local storage: create %s: %w", key, err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(p)
+		return fmt.Errorf("local storage: write %s: %w", key, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(p)
+		return fmt.Errorf("local storage: close %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) SignURLs(ctx context.Context, keys []string, ttl time.Duration) (map[string]string, error) {
+	out := make(map[string]string, len(keys))
+	for _, key := range keys {
+		out[key] = s.signedURL(http.MethodGet, key, ttl)
+	}
+	return out, nil
+}
+
+// PresignUpload returns a URL accepting one PUT to HandleUpload.
+func (s *LocalStorage) PresignUpload(ctx context.Context, key, mime string, ttl time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	return s.signedURL(http.MethodPut, key, ttl), nil
+}
+
+func (s *LocalStorage) ReadPrefix(ctx context.Context, key string, n int) ([]byte, int64, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, 0, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("local storage: open %s: %w", key, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, fmt.Errorf("local storage: stat %s: %w", key, err)
+	}
+	prefix, err := io.ReadAll(io.LimitReader(f, int64(n)))
+	if err != nil {
+		return nil, 0, fmt.Errorf("local storage: read %s: %w", key, err)
+	}
+	return prefix, info.Size(), nil
+}
+
+func (s *LocalStorage) signedURL(method, key string, ttl time.Duration) string {
+	expires := strconv.FormatInt(s.now().Add(ttl).Unix(), 10)
+	return s.publicURL + LocalMediaRoute + escapeKey(key) +
+		"?expires=" + expires + "&signature=" + s.sign(method, key, expires)
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("local storage: delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// HandleServe answers GET {LocalMediaRoute}{key...} for URLs minted by
+// SignURLs. Range requests are honoured so videos can seek.
+func (s *LocalStorage) HandleServe(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	if !s.validSignature(r, http.MethodGet, key) {
+		httputil.WriteError(w, r, apperror.Forbidden("link de mídia inválido ou expirado"))
+		return
+	}
+
+	p, err := s.path(key)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.NotFound("mídia não encontrada"))
+		return
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.NotFound("mídia não encontrada"))
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		httputil.WriteError(w, r, apperror.NotFound("mídia não encontrada"))
+		return
+	}
+
+	mime := localMimes[strings.ToLower(path.Ext(key))]
+	if mime == "" {
+		mime = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", mime)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	http.ServeContent(w, r, "", info.ModTime(), f)
+}
+
+// HandleUpload answers PUT {LocalMediaRoute}{key...} for URLs minted by
+// PresignUpload. The body is capped at the largest media limit; the service
+// checks the real size and type before linking the object.
+func (s *LocalStorage) HandleUpload(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	if !s.validSignature(r, http.MethodPut, key) {
+		httputil.WriteError(w, r, apperror.Forbidden("link de envio inválido ou expirado"))
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxVideoBytes)
+	if err := s.Upload(r.Context(), key, r.Header.Get("Content-Type"), body, r.ContentLength); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			httputil.WriteError(w, r, apperror.Validation("arquivo excede o tamanho máximo permitido"))
+		case errors.Is(err, fs.ErrExist):
+			httputil.WriteError(w, r, apperror.Conflict("mídia já enviada"))
+		default:
+			httputil.WriteError(w, r, apperror.Internal("falha ao gravar mídia", err))
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *LocalStorage) validSignature(r *http.Request, method, key string) bool {
+	q := r.URL.Query()
+	expires := q.Get("expires")
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || s.now().Unix() > exp {
+		return false
+	}
+	return hmac.Equal([]byte(q.Get("signature")), []byte(s.sign(method, key, expires)))
+}
+
+// sign covers the method so a read link cannot be replayed as an upload.
+func (s *LocalStorage) sign(method, key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(method + "\n" + key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path maps key onto the root, refusing anything that would escape it.
+func (s *LocalStorage) path(key string) (string, error) {
+	if key == "" || !fs.ValidPath(key) {
+		return "", fmt.Errorf("local storage: invalid key %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
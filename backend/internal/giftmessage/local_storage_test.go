@@ -0,0 +1,155 @@
+package giftmessage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocalStorage(t *testing.T) (*LocalStorage, *http.ServeMux) {
+	t.Helper()
+	s := NewLocalStorage(t.TempDir(), "http://api.test/", []byte("0123456789abcdef0123456789abcdef"))
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+LocalMediaRoute+"{key...}", s.HandleServe)
+	mux.HandleFunc("PUT "+LocalMediaRoute+"{key...}", s.HandleUpload)
+	return s, mux
+}
+
+func serveSigned(t *testing.T, mux *http.ServeMux, signed string) *httptest.ResponseRecorder {
+	t.Helper()
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("parse signed URL: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, u.RequestURI(), nil))
+	return rec
+}
+
+func TestLocalStorage_UploadSignServe(t *testing.T) {
+	s, mux := newTestLocalStorage(t)
+	ctx := context.Background()
+	key := "messages/7/abc.jpg"
+
+	if err := s.Upload(ctx, key, "image/jpeg", strings.NewReader("jpeg-bytes"), 10); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	urls, err := s.SignURLs(ctx, []string{key}, time.Minute)
+	if err != nil {
+		t.Fatalf("SignURLs: %v", err)
+	}
+	if !strings.HasPrefix(urls[key], "http://api.test/api/media/messages/7/abc.jpg?expires=") {
+		t.Fatalf("unexpected signed URL: %s", urls[key])
+	}
+
+	rec := serveSigned(t, mux, urls[key])
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != "image/jpeg" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if body, _ := io.ReadAll(rec.Body); string(body) != "jpeg-bytes" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestLocalStorage_RejectsTamperedAndExpiredURLs(t *testing.T) {
+	s, mux := newTestLocalStorage(t)
+	ctx := context.Background()
+	for _, key := range []string{"messages/1/a.jpg", "messages/1/b.jpg"} {
+		if err := s.Upload(ctx, key, "image/jpeg", strings.NewReader("x"), 1); err != nil {
+			t.Fatalf("Upload: %v", err)
+		}
+	}
+	urls, _ := s.SignURLs(ctx, []string{"messages/1/a.jpg"}, time.Minute)
+
+	swapped := strings.Replace(urls["messages/1/a.jpg"], "a.jpg", "b.jpg", 1)
+	if rec := serveSigned(t, mux, swapped); rec.Code != http.StatusForbidden {
+		t.Errorf("signature reused for another key: status = %d", rec.Code)
+	}
+
+	s.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if rec := serveSigned(t, mux, urls["messages/1/a.jpg"]); rec.Code != http.StatusForbidden {
+		t.Errorf("expired URL: status = %d", rec.Code)
+	}
+}
+
+func TestLocalStorage_UploadDoesNotOverwrite(t *testing.T) {
+	s, _ := newTestLocalStorage(t)
+	ctx := context.Background()
+	if err := s.Upload(ctx, "messages/1/a.jpg", "image/jpeg", strings.NewReader("first"), 5); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if err := s.Upload(ctx, "messages/1/a.jpg", "image/jpeg", strings.NewReader("second"), 6); err == nil {
+		t.Fatal("expected error when the key already exists")
+	}
+	got, _ := os.ReadFile(filepath.Join(s.root, "messages", "1", "a.jpg"))
+	if string(got) != "first" {
+		t.Errorf("file content = %q, want original", got)
+	}
+}
+
+func TestLocalStorage_DeleteAndInvalidKeys(t *testing.T) {
+	s, _ := newTestLocalStorage(t)
+	ctx := context.Background()
+	if err := s.Upload(ctx, "messages/1/a.jpg", "image/jpeg", strings.NewReader("x"), 1); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if err := s.Delete(ctx, "messages/1/a.jpg"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := s.Delete(ctx, "messages/1/a.jpg"); err != nil {
+		t.Fatalf("Delete of a missing key should be a no-op, got: %v", err)
+	}
+	for _, key := range []string{"../escape.jpg", "/etc/passwd", "messages/../../x"} {
+		if err := s.Upload(ctx, key, "image/jpeg", strings.NewReader("x"), 1); err == nil {
+			t.Errorf("Upload(%q) should be rejected", key)
+		}
+	}
+}
+
+func TestLocalStorage_PresignedUpload(t *testing.T) {
+	s, mux := newTestLocalStorage(t)
+	ctx := context.Background()
+	key := "messages/3/clip.mp4"
+
+	uploadURL, err := s.PresignUpload(ctx, key, "video/mp4", time.Minute)
+	if err != nil {
+		t.Fatalf("PresignUpload: %v", err)
+	}
+	u, _ := url.Parse(uploadURL)
+
+	// A read link must not authorize an upload.
+	readURLs, _ := s.SignURLs(ctx, []string{key}, time.Minute)
+	ru, _ := url.Parse(readURLs[key])
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, ru.RequestURI(), strings.NewReader("x")))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("PUT with GET signature: status = %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, u.RequestURI(), strings.NewReader("video-bytes")))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	prefix, size, err := s.ReadPrefix(ctx, key, 5)
+	if err != nil || string(prefix) != "video" || size != int64(len("video-bytes")) {
+		t.Fatalf("ReadPrefix = %q, %d, %v", prefix, size, err)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, u.RequestURI(), strings.NewReader("again")))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("second PUT should conflict, got %d", rec.Code)
+	}
+}
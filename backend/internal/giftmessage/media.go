@@ -2,8 +2,10 @@ package giftmessage
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strings"
 
@@ -108,3 +110,127 @@ func normalizeMime(mime string) string {
 	}
 	return strings.ToLower(strings.TrimSpace(mime))
 }
+
+// hasMediaSignature checks the container magic bytes of an audio or video
+// object uploaded without passing through the server. http.DetectContentType
+// misses QuickTime and most M4A brands, so the check is done by hand.
+func hasMediaSignature(mime string, head []byte) bool {
+	isoBMFF := len(head) >= 8 && bytes.Equal(head[4:8], []byte("ftyp"))
+	switch normalizeMime(mime) {
+	case "video/mp4", "audio/mp4", "audio/x-m4a":
+		return isoBMFF
+	case "video/quicktime":
+		if isoBMFF {
+			return true
+		}
+		if len(head) < 8 {
+			return false
+		}
+		switch string(head[4:8]) {
+		case "moov", "mdat", "wide", "free", "skip", "pnot":
+			return true
+		}
+		return false
+	case "video/webm":
+		return bytes.HasPrefix(head, []byte{0x1A, 0x45, 0xDF, 0xA3})
+	case "audio/ogg":
+		return bytes.HasPrefix(head, []byte("OggS"))
+	case "audio/mpeg":
+		return bytes.HasPrefix(head, []byte("ID3")) ||
+			(len(head) >= 2 && head[0] == 0xFF && head[1]&0xE0 == 0xE0)
+	}
+	return false
+}
+
+// StoredMedia describes the objects written by StoreMedia.
+type StoredMedia struct {
+	Key      string
+	ThumbKey *string
+	Kind     string
+	Mime     string
+	Size     int64
+}
+
+// Keys lists every object written, for cleanup when the row that references
+// them fails to save.
+func (m *StoredMedia) Keys() []string {
+	keys := []string{m.Key}
+	if m.ThumbKey != nil {
+		keys = append(keys, *m.ThumbKey)
+	}
+	return keys
+}
+
+// StoreMedia checks type and size, and writes the file under prefix. Images
+// are re-encoded so GPS/EXIF never reaches storage, and get a thumbnail for
+// list pages.
+func StoreMedia(ctx context.Context, storage Storage, prefix string, media Media) (*StoredMedia, error) {
+	mime, peeked, err := resolveMediaMIME(media.DeclaredMime, media.Reader)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := ValidateMedia(mime, media.Size)
+	if err != nil {
+		return nil, err
+	}
+	key, err := newObjectKey(prefix, spec.ext)
+	if err != nil {
+		return nil, apperror.Internal("falha ao gerar chave de mídia", err)
+	}
+	out := &StoredMedia{Key: key, Kind: spec.kind, Mime: mime, Size: media.Size}
+	body := peeked
+
+	if spec.kind == MediaKindImage {
+		img, err := readImage(peeked, mime, spec.maxBytes)
+		if err != nil {
+			return nil, err
+		}
+		out.Key = strings.TrimSuffix(key, spec.ext) + img.fullExt
+		thumbKey := strings.TrimSuffix(out.Key, img.fullExt) + ".thumb" + img.thumbExt
+		if err := uploadObject(ctx, storage, thumbKey, img.thumbMime, bytes.NewReader(img.thumb), int64(len(img.thumb))); err != nil {
+			return nil, err
+		}
+		out.ThumbKey = &thumbKey
+		out.Mime, body, out.Size = img.fullMime, bytes.NewReader(img.full), int64(len(img.full))
+	}
+
+	if err := uploadObject(ctx, storage, out.Key, out.Mime, body, out.Size); err != nil {
+		if out.ThumbKey != nil {
+			DeleteObjects(ctx, storage, []string{*out.ThumbKey})
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+func uploadObject(ctx context.Context, storage Storage, key, mime string, r io.Reader, size int64) error {
+	if err := storage.Upload(ctx, key, mime, r, size); err != nil {
+		slog.ErrorContext(ctx, "giftmessage.media upload: storage upload failed",
+			"key", key, "error", err)
+		return apperror.ServiceUnavailable("Não foi possível enviar sua mídia agora. Tente novamente.")
+	}
+	return nil
+}
+
+// DeleteObjects removes uploads orphaned by a failed create. Failures are
+// only logged; the objects are unreachable without a row pointing at them.
+func DeleteObjects(ctx context.Context, storage Storage, keys []string) {
+	for _, key := range keys {
+		if err := storage.Delete(context.Background(), key); err != nil {
+			slog.ErrorContext(ctx, "giftmessage.media delete: orphan delete failed",
+				"key", key, "error", err)
+		}
+	}
+}
+
+func readImage(r io.Reader, mime string, maxBytes int64) (*processedImage, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, apperror.Validation("falha ao ler arquivo de mídia")
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, apperror.Validation(fmt.Sprintf(
+			"arquivo de mídia excede o limite de %d MB para %s", maxBytes/(1024*1024), MediaKindImage))
+	}
+	return processImage(data, mime)
+}
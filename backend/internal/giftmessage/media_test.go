@@ -326,3 +326,26 @@ func TestBuildObjectKey_HasTransactionAndExtension(t *testing.T) {
 		t.Errorf("key should end with .jpg, got %q", key)
 	}
 }
+
+func TestHasMediaSignature(t *testing.T) {
+	cases := []struct {
+		mime string
+		head []byte
+		want bool
+	}{
+		{"video/mp4", []byte{0, 0, 0, 0x18, 'f', 't', 'y', 'p', 'i', 's', 'o', 'm'}, true},
+		{"video/quicktime", []byte{0, 0, 0, 0x08, 'w', 'i', 'd', 'e'}, true},
+		{"video/webm", []byte{0x1A, 0x45, 0xDF, 0xA3, 0x01}, true},
+		{"audio/ogg", []byte("OggS\x00"), true},
+		{"audio/mpeg", []byte("ID3\x04"), true},
+		{"audio/mpeg", []byte{0xFF, 0xFB, 0x90}, true},
+		{"video/mp4", []byte("<html><body>"), false},
+		{"video/webm", []byte("OggS\x00"), false},
+		{"image/jpeg", []byte{0xFF, 0xD8, 0xFF}, false},
+	}
+	for _, c := range cases {
+		if got := hasMediaSignature(c.mime, c.head); got != c.want {
+			t.Errorf("hasMediaSignature(%q, % x) = %v, want %v", c.mime, c.head, got, c.want)
+		}
+	}
+}
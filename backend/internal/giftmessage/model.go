@@ -1,6 +1,10 @@
 package giftmessage
 
-import "time"
+import (
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
+)
 
 const (
 	MediaKindImage = "image"
@@ -19,6 +23,11 @@ type GiftMessage struct {
 	MediaKind         *string
 	MediaSizeBytes    *int64
 	MediaMimeType     *string
+	MediaThumbKey     *string
+	Status            string
+	FlaggedTerms      []string
+	ModeratedAt       *time.Time
+	ModeratedBy       *int64
 	CreatedAt         time.Time
 	UpdatedAt         time.Time
 	DeletedAt         *time.Time
@@ -28,6 +37,11 @@ type GiftMessage struct {
 type CreateInput struct {
 	AuthorName string `json:"author_name" validate:"required,min=1,max=120"`
 	Content    string `json:"content"     validate:"required,min=1,max=500"`
+	// MediaKey links an object uploaded through an upload slot instead of
+	// sending the file in the request.
+	MediaKey string `json:"media_key" validate:"omitempty,max=200"`
+	// UploadID links a finished tus upload.
+	UploadID string `json:"upload_id" validate:"omitempty,max=64"`
 }
 
 type CreateRow struct {
@@ -40,29 +54,135 @@ type CreateRow struct {
 	MediaKind         *string
 	MediaSizeBytes    *int64
 	MediaMimeType     *string
+	MediaThumbKey     *string
+	Status            string
+	FlaggedTerms      []string
 }
 
 type PublicMessage struct {
-	ID         int64     `json:"id"`
-	GiftID     int64     `json:"gift_id"`
-	AuthorName string    `json:"author_name"`
-	Content    string    `json:"content"`
-	MediaURL   *string   `json:"media_url"`
-	MediaKind  *string   `json:"media_kind"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID         int64  `json:"id"`
+	GiftID     int64  `json:"gift_id"`
+	AuthorName string `json:"author_name"`
+	Content    string `json:"content"`
+	// MediaURL is the full-size object; ThumbnailURL is set for images
+	// uploaded after thumbnails were introduced.
+	MediaURL     *string    `json:"media_url"`
+	ThumbnailURL *string    `json:"thumbnail_url"`
+	MediaKind    *string    `json:"media_kind"`
+	Status       string     `json:"status"`
+	CreatedAt    time.Time  `json:"created_at"`
+	Replies      []Reply    `json:"replies"`
+	Reactions    []Reaction `json:"reactions"`
 }
 
 type AdminMessage struct {
 	PublicMessage
-	UserID            int64 `json:"user_id"`
-	GiftTransactionID int64 `json:"gift_transaction_id"`
+	UserID            int64      `json:"user_id"`
+	GiftTransactionID int64      `json:"gift_transaction_id"`
+	FlaggedTerms      []string   `json:"flagged_terms"`
+	ModeratedAt       *time.Time `json:"moderated_at"`
+	ModeratedBy       *int64     `json:"moderated_by"`
 }
 
+type ModerateInput struct {
+	IDs    []int64 `json:"ids"    validate:"required,min=1,max=100"`
+	Action string  `json:"action" validate:"required,oneof=approve reject"`
+}
+
+// ModerateResult lists which of the requested messages changed status.
+// Skipped ids were not found, deleted, or already in the target status.
+type ModerateResult struct {
+	Status  string  `json:"status"`
+	Updated []int64 `json:"updated"`
+	Skipped []int64 `json:"skipped"`
+}
+
+// Paged is a page of messages. Page is 0 when paging by cursor, which only
+// the admin listing supports.
 type Paged[T any] struct {
 	Data  []T `json:"data"`
-	Page  int `json:"page"`
+	Page  int `json:"page,omitempty"`
 	Limit int `json:"limit"`
-	Total int `json:"total"`
+	pagination.Meta
+}
+
+const (
+	ReplyPublic  = "public"
+	ReplyPrivate = "private"
+)
+
+// Reply is the couple's answer to a message. Private replies are shown only
+// to the message author (GetMine) and in the admin views.
+type Reply struct {
+	ID         int64     `json:"id"`
+	MessageID  int64     `json:"message_id"`
+	UserID     int64     `json:"user_id"`
+	AuthorRole string    `json:"author_role"`
+	Content    string    `json:"content"`
+	Visibility string    `json:"visibility"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type Reaction struct {
+	ID         int64     `json:"id"`
+	MessageID  int64     `json:"message_id"`
+	UserID     int64     `json:"user_id"`
+	AuthorRole string    `json:"author_role"`
+	Emoji      string    `json:"emoji"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ReplyInput struct {
+	Content    string `json:"content"    validate:"required,min=1,max=500"`
+	Visibility string `json:"visibility" validate:"omitempty,oneof=public private"`
+}
+
+type ReactionInput struct {
+	Emoji string `json:"emoji" validate:"required"`
+}
+
+type UploadSlotInput struct {
+	MimeType  string `json:"mime_type"  validate:"required,max=100"`
+	SizeBytes int64  `json:"size_bytes" validate:"required,gt=0"`
+}
+
+// UploadSlot reserves an object key the browser may PUT to directly.
+type UploadSlot struct {
+	ID                int64
+	ObjectKey         string
+	GiftTransactionID int64
+	UserID            int64
+	MediaKind         string
+	MimeType          string
+	SizeBytes         int64
+	ExpiresAt         time.Time
+	CreatedAt         time.Time
+}
+
+type UploadSlotResponse struct {
+	ObjectKey string            `json:"object_key"`
+	UploadURL string            `json:"upload_url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// ResumableUpload tracks a tus upload. ObjectKey, MediaKind and MimeType are
+// set once every byte arrived and the file was validated and stored.
+type ResumableUpload struct {
+	ID                string
+	GiftTransactionID int64
+	UserID            int64
+	Length            int64
+	Offset            int64
+	DeclaredMime      string
+	ObjectKey         *string
+	MediaKind         *string
+	MimeType          *string
+	CompletedAt       *time.Time
+	ExpiresAt         time.Time
+	CreatedAt         time.Time
 }
 
 type TransactionSnapshot struct {
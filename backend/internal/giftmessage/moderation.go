@@ -0,0 +1,173 @@
+package giftmessage
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/validate"
+)
+
+// ModerationMode decides which new messages wait in the couple's queue
+// before showing up on the public gift page.
+type ModerationMode string
+
+const (
+	ModerationAuto    ModerationMode = "auto"    // publish everything
+	ModerationAll     ModerationMode = "all"     // hold everything
+	ModerationFlagged ModerationMode = "flagged" // hold only what the filter flags
+)
+
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// InitialStatus is the status a new message starts in, given the terms
+// FlagContent found in it.
+func (m ModerationMode) InitialStatus(flagged []string) string {
+	switch m {
+	case ModerationAuto:
+		return StatusApproved
+	case ModerationAll:
+		return StatusPending
+	}
+	if len(flagged) > 0 {
+		return StatusPending
+	}
+	return StatusApproved
+}
+
+// StatusSetter moves ids to status and returns those that changed, as the
+// gift message and guestbook repositories' SetStatus do.
+type StatusSetter func(ctx context.Context, ids []int64, status string, byUserID int64) ([]int64, error)
+
+// ModerateBatch runs a moderation request against setStatus: repeated and
+// non-positive ids are dropped (noIDsMsg when none is left) and ids that did
+// not change are reported as skipped. Callers audit result.Updated.
+func ModerateBatch(ctx context.Context, in ModerateInput, byUserID int64, noIDsMsg string, setStatus StatusSetter) (*ModerateResult, error) {
+	if byUserID == 0 {
+		return nil, apperror.Unauthorized("autenticação obrigatória")
+	}
+	if err := validate.Struct(in); err != nil {
+		return nil, err
+	}
+
+	status := StatusApproved
+	if in.Action == "reject" {
+		status = StatusRejected
+	}
+
+	ids := make([]int64, 0, len(in.IDs))
+	seen := make(map[int64]bool, len(in.IDs))
+	for _, id := range in.IDs {
+		if id > 0 && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, apperror.Validation(noIDsMsg)
+	}
+
+	updated, err := setStatus(ctx, ids, status, byUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ModerateResult{Status: status, Updated: updated, Skipped: []int64{}}
+	if result.Updated == nil {
+		result.Updated = []int64{}
+	}
+	for _, id := range ids {
+		if !slices.Contains(updated, id) {
+			result.Skipped = append(result.Skipped, id)
+		}
+	}
+	return result, nil
+}
+
+// blocklist holds PT-BR profanity and slurs, written in the normalized form
+// produced by normalizeForFilter (no accents, no doubled letters). Entries
+// with spaces match as consecutive words.
+var blocklist = []string{
+	"arombado", "arombada", "babaca", "bosta", "buceta", "boceta",
+	"cacete", "caralho", "corno", "cu", "cuzao", "desgracado", "desgracada",
+	"escroto", "escrota", "fdp", "foda", "fodase", "foder", "fodido", "fodida",
+	"filho da puta", "merda", "otario", "otaria", "pau no cu", "piroca",
+	"pora", "puta", "puto", "putaria", "retardado", "retardada",
+	"tnc", "vadia", "vagabunda", "vagabundo", "vai se foder", "vai tomar no cu",
+	"viado", "vsf", "vtnc", "xoxota",
+}
+
+var accentReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// leet maps the usual character swaps ("p0rr4", "m3rd@") back to letters.
+var leet = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's',
+}
+
+// normalizeForFilter lowercases, strips accents and leetspeak, collapses
+// repeated letters ("porraaa" → "pora") and splits on anything that is not a
+// letter.
+func normalizeForFilter(s string) []string {
+	s = accentReplacer.Replace(strings.ToLower(s))
+
+	var b strings.Builder
+	var last rune
+	for _, r := range s {
+		if l, ok := leet[r]; ok {
+			r = l
+		}
+		if !unicode.IsLetter(r) {
+			r = ' '
+		}
+		if r == last && r != ' ' {
+			continue
+		}
+		b.WriteRune(r)
+		last = r
+	}
+	return strings.Fields(b.String())
+}
+
+// FlagContent returns the blocklisted terms found in texts, in blocklist
+// order and without duplicates. Plurals ("merdas") match their singular.
+func FlagContent(texts ...string) []string {
+	var words []string
+	for _, t := range texts {
+		words = append(words, normalizeForFilter(t)...)
+	}
+	for i, w := range words {
+		if len(w) > 3 && strings.HasSuffix(w, "s") {
+			words[i] = strings.TrimSuffix(w, "s")
+		}
+	}
+
+	var found []string
+	for _, term := range blocklist {
+		if containsPhrase(words, strings.Fields(term)) {
+			found = append(found, term)
+		}
+	}
+	return found
+}
+
+func containsPhrase(words, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(words); i++ {
+		if slices.Equal(words[i:i+len(phrase)], phrase) {
+			return true
+		}
+	}
+	return false
+}
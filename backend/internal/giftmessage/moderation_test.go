@@ -0,0 +1,56 @@
+package giftmessage
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFlagContent(t *testing.T) {
+	cases := []struct {
+		text string
+		want []string
+	}{
+		{"Felicidades ao casal!", nil},
+		{"Que PORRA de festa", []string{"pora"}},
+		{"p0rr4aaa", []string{"pora"}},
+		{"Seu desgraçado", []string{"desgracado"}},
+		{"m-e-r-d-a", nil},
+		{"duas merdas", []string{"merda"}},
+		{"vai se f0der", []string{"foder", "vai se foder"}},
+		{"curso de culinária", nil},
+		{"picanha e cuscuz", nil},
+		{"cu", []string{"cu"}},
+	}
+	for _, c := range cases {
+		got := FlagContent(c.text)
+		if !slices.Equal(got, c.want) {
+			t.Errorf("FlagContent(%q) = %v, want %v", c.text, got, c.want)
+		}
+	}
+}
+
+func TestFlagContentAcrossFields(t *testing.T) {
+	got := FlagContent("Fulano", "Tudo de bom, caralho!")
+	if !slices.Equal(got, []string{"caralho"}) {
+		t.Errorf("expected caralho, got %v", got)
+	}
+}
+
+func TestInitialStatus(t *testing.T) {
+	flagged := []string{"merda"}
+	cases := []struct {
+		mode    ModerationMode
+		flagged []string
+		want    string
+	}{
+		{ModerationAuto, flagged, StatusApproved},
+		{ModerationAll, nil, StatusPending},
+		{ModerationFlagged, nil, StatusApproved},
+		{ModerationFlagged, flagged, StatusPending},
+	}
+	for _, c := range cases {
+		if got := c.mode.InitialStatus(c.flagged); got != c.want {
+			t.Errorf("%s.InitialStatus(%v) = %q, want %q", c.mode, c.flagged, got, c.want)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/jackc/pgx/v5"
+
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
 )
 
 type Repository interface {
@@ -11,8 +13,41 @@ type Repository interface {
 	GetByID(ctx context.Context, id int64) (*GiftMessage, error)
 	GetByTransactionID(ctx context.Context, txID int64) (*GiftMessage, error)
 	ListByGift(ctx context.Context, giftID int64, limit, offset int) ([]GiftMessage, int, error)
-	ListAll(ctx context.Context, limit, offset int) ([]GiftMessage, int, error)
+	ListAll(ctx context.Context, req pagination.Request) ([]GiftMessage, pagination.Meta, error)
+	ListByStatus(ctx context.Context, status string, limit, offset int) ([]GiftMessage, int, error)
+	SetStatus(ctx context.Context, ids []int64, status string, byUserID int64) ([]int64, error)
 	SoftDelete(ctx context.Context, id, byUserID int64) error
+
+	ListReplies(ctx context.Context, messageIDs []int64) ([]Reply, error)
+	CreateReply(ctx context.Context, messageID, userID int64, in ReplyInput) (*Reply, error)
+	UpdateReply(ctx context.Context, id int64, in ReplyInput) (*Reply, error)
+	DeleteReply(ctx context.Context, id int64) (*Reply, error)
+
+	ListReactions(ctx context.Context, messageIDs []int64) ([]Reaction, error)
+	AddReaction(ctx context.Context, messageID, userID int64, emoji string) (*Reaction, bool, error)
+	DeleteReaction(ctx context.Context, id int64) (*Reaction, error)
+
+	CreateUploadSlot(ctx context.Context, in UploadSlot) (*UploadSlot, error)
+	CountOpenUploadSlots(ctx context.Context, txID int64) (int, error)
+	GetUploadSlot(ctx context.Context, key string, txID, userID int64) (*UploadSlot, error)
+	// ClaimUploadSlot deletes and returns the unexpired slot for key owned by
+	// userID and txID, so each slot links at most one message.
+	ClaimUploadSlot(ctx context.Context, key string, txID, userID int64) (*UploadSlot, error)
+	ListExpiredUploadSlots(ctx context.Context, limit int) ([]UploadSlot, error)
+	DeleteUploadSlot(ctx context.Context, id int64) error
+
+	CreateResumableUpload(ctx context.Context, in ResumableUpload) (*ResumableUpload, error)
+	GetResumableUpload(ctx context.Context, id string) (*ResumableUpload, error)
+	// ResumableUploadUsage counts the user's unfinished, unexpired uploads
+	// and the bytes they declared.
+	ResumableUploadUsage(ctx context.Context, userID int64) (count int, bytes int64, err error)
+	SetResumableUploadOffset(ctx context.Context, id string, offset int64) error
+	CompleteResumableUpload(ctx context.Context, id, objectKey, mediaKind, mime string) error
+	// ClaimResumableUpload deletes and returns the finished, unexpired upload
+	// owned by userID and txID.
+	ClaimResumableUpload(ctx context.Context, id string, txID, userID int64) (*ResumableUpload, error)
+	ListExpiredResumableUploads(ctx context.Context, limit int) ([]ResumableUpload, error)
+	DeleteResumableUpload(ctx context.Context, id string) error
 }
 
 type TxAwareRepository interface {
@@ -13,18 +13,25 @@ import (
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
 	"github.com/ferjunior7/parasempre/backend/internal/database"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
+	"github.com/ferjunior7/parasempre/backend/internal/wall"
 )
 
-const messageColumns = `id, gift_transaction_id, gift_id, user_id, author_name, content, media_object_key, media_kind, media_size_bytes, media_mime_type, created_at, updated_at, deleted_at, deleted_by`
+const messageColumns = `id, gift_transaction_id, gift_id, user_id, author_name, content, media_object_key, media_kind, media_size_bytes, media_mime_type, media_thumb_object_key, status, flagged_terms, moderated_at, moderated_by, created_at, updated_at, deleted_at, deleted_by`
 
-func scanMessage(row pgx.Row) (GiftMessage, error) {
-	var m GiftMessage
-	err := row.Scan(
+func messageDest(m *GiftMessage) []any {
+	return []any{
 		&m.ID, &m.GiftTransactionID, &m.GiftID, &m.UserID,
 		&m.AuthorName, &m.Content,
-		&m.MediaObjectKey, &m.MediaKind, &m.MediaSizeBytes, &m.MediaMimeType,
+		&m.MediaObjectKey, &m.MediaKind, &m.MediaSizeBytes, &m.MediaMimeType, &m.MediaThumbKey,
+		&m.Status, &m.FlaggedTerms, &m.ModeratedAt, &m.ModeratedBy,
 		&m.CreatedAt, &m.UpdatedAt, &m.DeletedAt, &m.DeletedBy,
-	)
+	}
+}
+
+func scanMessage(row pgx.Row) (GiftMessage, error) {
+	var m GiftMessage
+	err := row.Scan(messageDest(&m)...)
 	return m, err
 }
 
@@ -44,20 +51,25 @@ func (r *PostgresRepository) Create(ctx context.Context, in CreateRow) (*GiftMes
 	m, err := scanMessage(r.db.QueryRow(ctx,
 		`INSERT INTO gift_messages
 		    (gift_transaction_id, gift_id, user_id, author_name, content,
-		     media_object_key, media_kind, media_size_bytes, media_mime_type)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		     media_object_key, media_kind, media_size_bytes, media_mime_type,
+		     media_thumb_object_key, status, flagged_terms)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		 RETURNING `+messageColumns,
 		in.GiftTransactionID, in.GiftID, in.UserID, in.AuthorName, in.Content,
 		in.MediaObjectKey, in.MediaKind, in.MediaSizeBytes, in.MediaMimeType,
+		in.MediaThumbKey, in.Status, flaggedTerms(in.FlaggedTerms),
 	))
 	if err != nil {
 		if appErr := mapPgError(err); appErr != nil {
 			return nil, appErr
 		}
-		slog.Error("giftmessage.repo create: insert failed", "error", err)
+		slog.ErrorContext(ctx, "giftmessage.repo create: insert failed", "error", err)
 		return nil, err
 	}
-	slog.Info("giftmessage.repo create: stored", "id", m.ID, "tx_id", m.GiftTransactionID)
+	slog.InfoContext(ctx, "giftmessage.repo create: stored", "id", m.ID, "tx_id", m.GiftTransactionID)
+	if m.Status == StatusApproved {
+		r.publishToWall(ctx, []int64{m.ID})
+	}
 	return &m, nil
 }
 
@@ -68,7 +80,7 @@ func (r *PostgresRepository) GetByID(ctx context.Context, id int64) (*GiftMessag
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, apperror.NotFound("mensagem não encontrada")
 		}
-		slog.Error("giftmessage.repo get_by_id: query failed", "id", id, "error", err)
+		slog.ErrorContext(ctx, "giftmessage.repo get_by_id: query failed", "id", id, "error", err)
 		return nil, err
 	}
 	return &m, nil
@@ -82,7 +94,7 @@ func (r *PostgresRepository) GetByTransactionID(ctx context.Context, txID int64)
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, apperror.NotFound("mensagem não encontrada")
 		}
-		slog.Error("giftmessage.repo get_by_tx: query failed", "tx_id", txID, "error", err)
+		slog.ErrorContext(ctx, "giftmessage.repo get_by_tx: query failed", "tx_id", txID, "error", err)
 		return nil, err
 	}
 	return &m, nil
@@ -92,12 +104,12 @@ func (r *PostgresRepository) ListByGift(ctx context.Context, giftID int64, limit
 	rows, err := r.db.Query(ctx,
 		`SELECT `+messageColumns+`, COUNT(*) OVER() AS total
 		   FROM gift_messages
-		  WHERE gift_id = $1 AND deleted_at IS NULL
+		  WHERE gift_id = $1 AND deleted_at IS NULL AND status = 'approved'
 		  ORDER BY created_at DESC
 		  LIMIT $2 OFFSET $3`,
 		giftID, limit, offset)
 	if err != nil {
-		slog.Error("giftmessage.repo list_by_gift: query failed", "gift_id", giftID, "error", err)
+		slog.ErrorContext(ctx, "giftmessage.repo list_by_gift: query failed", "gift_id", giftID, "error", err)
 		return nil, 0, err
 	}
 	defer rows.Close()
@@ -106,14 +118,8 @@ func (r *PostgresRepository) ListByGift(ctx context.Context, giftID int64, limit
 	var total int
 	for rows.Next() {
 		var m GiftMessage
-		if err := rows.Scan(
-			&m.ID, &m.GiftTransactionID, &m.GiftID, &m.UserID,
-			&m.AuthorName, &m.Content,
-			&m.MediaObjectKey, &m.MediaKind, &m.MediaSizeBytes, &m.MediaMimeType,
-			&m.CreatedAt, &m.UpdatedAt, &m.DeletedAt, &m.DeletedBy,
-			&total,
-		); err != nil {
-			slog.Error("giftmessage.repo list_by_gift: scan failed", "error", err)
+		if err := rows.Scan(append(messageDest(&m), &total)...); err != nil {
+			slog.ErrorContext(ctx, "giftmessage.repo list_by_gift: scan failed", "error", err)
 			return nil, 0, err
 		}
 		msgs = append(msgs, m)
@@ -124,16 +130,82 @@ func (r *PostgresRepository) ListByGift(ctx context.Context, giftID int64, limit
 	return msgs, total, rows.Err()
 }
 
-func (r *PostgresRepository) ListAll(ctx context.Context, limit, offset int) ([]GiftMessage, int, error) {
+// adminMessageOrder is the admin listing's ordering, newest first.
+var adminMessageOrder = pagination.Order{Name: "newest", Keys: []pagination.Key{
+	{Expr: "created_at", Type: "timestamptz", Desc: true},
+	{Expr: "id", Type: "bigint", Desc: true},
+}}
+
+func (r *PostgresRepository) ListAll(ctx context.Context, req pagination.Request) ([]GiftMessage, pagination.Meta, error) {
+	var meta pagination.Meta
+	if req.IncludeTotal {
+		var total int
+		if err := r.db.QueryRow(ctx,
+			`SELECT COUNT(*) FROM gift_messages WHERE deleted_at IS NULL`).Scan(&total); err != nil {
+			slog.ErrorContext(ctx, "giftmessage.repo list_all: count failed", "error", err)
+			return nil, meta, err
+		}
+		meta.Total = &total
+	}
+
+	where := `WHERE deleted_at IS NULL`
+	seek, args, err := adminMessageOrder.Seek(req.Cursor, nil)
+	if err != nil {
+		return nil, meta, err
+	}
+	if seek != "" {
+		where += ` AND ` + seek
+	}
+	args = append(args, req.Limit+1, req.Offset())
+
+	rows, err := r.db.Query(ctx,
+		`SELECT `+messageColumns+adminMessageOrder.Columns()+`
+		   FROM gift_messages
+		  `+where+`
+		  ORDER BY `+adminMessageOrder.OrderBy(req.Cursor)+`
+		  `+fmt.Sprintf("LIMIT $%d OFFSET $%d", len(args)-1, len(args)),
+		args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "giftmessage.repo list_all: query failed", "error", err)
+		return nil, meta, pagination.QueryError(err, req.Cursor)
+	}
+	defer rows.Close()
+
+	var msgs []GiftMessage
+	var keys [][]string
+	for rows.Next() {
+		var m GiftMessage
+		key := make([]string, len(adminMessageOrder.Keys))
+		if err := rows.Scan(append(messageDest(&m), &key[0], &key[1])...); err != nil {
+			slog.ErrorContext(ctx, "giftmessage.repo list_all: scan failed", "error", err)
+			return nil, meta, err
+		}
+		msgs = append(msgs, m)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, meta, pagination.QueryError(err, req.Cursor)
+	}
+
+	msgs, page := pagination.Finish(req, adminMessageOrder, msgs, keys)
+	page.Total = meta.Total
+	if msgs == nil {
+		msgs = []GiftMessage{}
+	}
+	return msgs, page, nil
+}
+
+// ListByStatus feeds the moderation queue, oldest first.
+func (r *PostgresRepository) ListByStatus(ctx context.Context, status string, limit, offset int) ([]GiftMessage, int, error) {
 	rows, err := r.db.Query(ctx,
 		`SELECT `+messageColumns+`, COUNT(*) OVER() AS total
 		   FROM gift_messages
-		  WHERE deleted_at IS NULL
-		  ORDER BY created_at DESC
-		  LIMIT $1 OFFSET $2`,
-		limit, offset)
+		  WHERE status = $1 AND deleted_at IS NULL
+		  ORDER BY created_at ASC, id ASC
+		  LIMIT $2 OFFSET $3`,
+		status, limit, offset)
 	if err != nil {
-		slog.Error("giftmessage.repo list_all: query failed", "error", err)
+		slog.ErrorContext(ctx, "giftmessage.repo list_by_status: query failed", "status", status, "error", err)
 		return nil, 0, err
 	}
 	defer rows.Close()
@@ -142,14 +214,8 @@ func (r *PostgresRepository) ListAll(ctx context.Context, limit, offset int) ([]
 	var total int
 	for rows.Next() {
 		var m GiftMessage
-		if err := rows.Scan(
-			&m.ID, &m.GiftTransactionID, &m.GiftID, &m.UserID,
-			&m.AuthorName, &m.Content,
-			&m.MediaObjectKey, &m.MediaKind, &m.MediaSizeBytes, &m.MediaMimeType,
-			&m.CreatedAt, &m.UpdatedAt, &m.DeletedAt, &m.DeletedBy,
-			&total,
-		); err != nil {
-			slog.Error("giftmessage.repo list_all: scan failed", "error", err)
+		if err := rows.Scan(append(messageDest(&m), &total)...); err != nil {
+			slog.ErrorContext(ctx, "giftmessage.repo list_by_status: scan failed", "error", err)
 			return nil, 0, err
 		}
 		msgs = append(msgs, m)
@@ -160,6 +226,61 @@ func (r *PostgresRepository) ListAll(ctx context.Context, limit, offset int) ([]
 	return msgs, total, rows.Err()
 }
 
+// SetStatus moves the given messages to status and returns the ids that
+// actually changed; deleted messages and those already in status are left
+// alone.
+func (r *PostgresRepository) SetStatus(ctx context.Context, ids []int64, status string, byUserID int64) ([]int64, error) {
+	rows, err := r.db.Query(ctx,
+		`UPDATE gift_messages
+		    SET status = $1, moderated_at = now(), moderated_by = $2, updated_at = now()
+		  WHERE id = ANY($3) AND deleted_at IS NULL AND status <> $1
+		  RETURNING id`,
+		status, byUserID, ids)
+	if err != nil {
+		if appErr := mapPgError(err); appErr != nil {
+			return nil, appErr
+		}
+		slog.ErrorContext(ctx, "giftmessage.repo set_status: failed", "status", status, "error", err)
+		return nil, err
+	}
+	updated, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		slog.ErrorContext(ctx, "giftmessage.repo set_status: scan failed", "error", err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "giftmessage.repo set_status: done", "status", status, "updated", len(updated), "by", byUserID)
+	if status == StatusApproved && len(updated) > 0 {
+		r.publishToWall(ctx, updated)
+	}
+	return updated, nil
+}
+
+// publishToWall records the approved messages in wall_events and notifies
+// the reception wall. Best effort: the message is already saved.
+func (r *PostgresRepository) publishToWall(ctx context.Context, ids []int64) {
+	_, err := r.db.Exec(ctx,
+		`WITH `+wall.PublishLock+`, ev AS (
+		     INSERT INTO wall_events (kind, ref_id, payload)
+		     SELECT $2, m.id, jsonb_build_object(
+		                'author_name', m.author_name,
+		                'content', m.content,
+		                'media_kind', m.media_kind,
+		                'media_key', m.media_object_key,
+		                'thumb_key', m.media_thumb_object_key,
+		                'gift_id', m.gift_id,
+		                'gift_name', g.name)
+		       FROM wall_lock, gift_messages m
+		       JOIN gifts g ON g.id = m.gift_id
+		      WHERE m.id = ANY($1) AND m.status = 'approved' AND m.deleted_at IS NULL
+		     ON CONFLICT (kind, ref_id) DO NOTHING
+		     RETURNING id)
+		 SELECT pg_notify($3, id::text) FROM ev`,
+		ids, wall.KindGiftMessage, wall.Channel)
+	if err != nil {
+		slog.WarnContext(ctx, "giftmessage.repo publish_to_wall: failed", "ids", ids, "error", err)
+	}
+}
+
 func (r *PostgresRepository) SoftDelete(ctx context.Context, id, byUserID int64) error {
 	tag, err := r.db.Exec(ctx,
 		`UPDATE gift_messages
@@ -167,16 +288,419 @@ func (r *PostgresRepository) SoftDelete(ctx context.Context, id, byUserID int64)
 		  WHERE id = $2 AND deleted_at IS NULL`,
 		byUserID, id)
 	if err != nil {
-		slog.Error("giftmessage.repo soft_delete: failed", "id", id, "error", err)
+		slog.ErrorContext(ctx, "giftmessage.repo soft_delete: failed", "id", id, "error", err)
 		return err
 	}
 	if tag.RowsAffected() == 0 {
 		return apperror.NotFound("mensagem não encontrada")
 	}
-	slog.Info("giftmessage.repo soft_delete: removed", "id", id, "by", byUserID)
+	slog.InfoContext(ctx, "giftmessage.repo soft_delete: removed", "id", id, "by", byUserID)
 	return nil
 }
 
+const replySelect = `SELECT r.id, r.message_id, r.user_id, u.role, r.content, r.visibility, r.created_at, r.updated_at`
+
+func scanReply(row pgx.Row) (Reply, error) {
+	var rp Reply
+	err := row.Scan(&rp.ID, &rp.MessageID, &rp.UserID, &rp.AuthorRole, &rp.Content, &rp.Visibility, &rp.CreatedAt, &rp.UpdatedAt)
+	return rp, err
+}
+
+func (r *PostgresRepository) ListReplies(ctx context.Context, messageIDs []int64) ([]Reply, error) {
+	rows, err := r.db.Query(ctx,
+		replySelect+`
+		   FROM gift_message_replies r
+		   JOIN users u ON u.id = r.user_id
+		  WHERE r.message_id = ANY($1)
+		  ORDER BY r.created_at, r.id`,
+		messageIDs)
+	if err != nil {
+		slog.ErrorContext(ctx, "giftmessage.repo list_replies: query failed", "error", err)
+		return nil, err
+	}
+	replies, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Reply, error) { return scanReply(row) })
+	if err != nil {
+		slog.ErrorContext(ctx, "giftmessage.repo list_replies: scan failed", "error", err)
+		return nil, err
+	}
+	return replies, nil
+}
+
+func (r *PostgresRepository) CreateReply(ctx context.Context, messageID, userID int64, in ReplyInput) (*Reply, error) {
+	rp, err := scanReply(r.db.QueryRow(ctx,
+		`WITH r AS (
+		     INSERT INTO gift_message_replies (message_id, user_id, content, visibility)
+		     VALUES ($1, $2, $3, $4)
+		     RETURNING *
+		 )
+		 `+replySelect+` FROM r JOIN users u ON u.id = r.user_id`,
+		messageID, userID, in.Content, in.Visibility))
+	if err != nil {
+		if appErr := mapPgError(err); appErr != nil {
+			return nil, appErr
+		}
+		slog.ErrorContext(ctx, "giftmessage.repo create_reply: insert failed", "message_id", messageID, "error", err)
+		return nil, err
+	}
+	return &rp, nil
+}
+
+func (r *PostgresRepository) UpdateReply(ctx context.Context, id int64, in ReplyInput) (*Reply, error) {
+	rp, err := scanReply(r.db.QueryRow(ctx,
+		`WITH r AS (
+		     UPDATE gift_message_replies
+		        SET content = $1, visibility = COALESCE(NULLIF($2, ''), visibility), updated_at = now()
+		      WHERE id = $3
+		     RETURNING *
+		 )
+		 `+replySelect+` FROM r JOIN users u ON u.id = r.user_id`,
+		in.Content, in.Visibility, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.NotFound("resposta não encontrada")
+		}
+		if appErr := mapPgError(err); appErr != nil {
+			return nil, appErr
+		}
+		slog.ErrorContext(ctx, "giftmessage.repo update_reply: failed", "id", id, "error", err)
+		return nil, err
+	}
+	return &rp, nil
+}
+
+func (r *PostgresRepository) DeleteReply(ctx context.Context, id int64) (*Reply, error) {
+	rp, err := scanReply(r.db.QueryRow(ctx,
+		`WITH r AS (
+		     DELETE FROM gift_message_replies WHERE id = $1
+		     RETURNING *
+		 )
+		 `+replySelect+` FROM r JOIN users u ON u.id = r.user_id`,
+		id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.NotFound("resposta não encontrada")
+		}
+		slog.ErrorContext(ctx, "giftmessage.repo delete_reply: failed", "id", id, "error", err)
+		return nil, err
+	}
+	return &rp, nil
+}
+
+const reactionSelect = `SELECT r.id, r.message_id, r.user_id, u.role, r.emoji, r.created_at`
+
+func scanReaction(row pgx.Row) (Reaction, error) {
+	var rc Reaction
+	err := row.Scan(&rc.ID, &rc.MessageID, &rc.UserID, &rc.AuthorRole, &rc.Emoji, &rc.CreatedAt)
+	return rc, err
+}
+
+func (r *PostgresRepository) ListReactions(ctx context.Context, messageIDs []int64) ([]Reaction, error) {
+	rows, err := r.db.Query(ctx,
+		reactionSelect+`
+		   FROM gift_message_reactions r
+		   JOIN users u ON u.id = r.user_id
+		  WHERE r.message_id = ANY($1)
+		  ORDER BY r.created_at, r.id`,
+		messageIDs)
+	if err != nil {
+		slog.ErrorContext(ctx, "giftmessage.repo list_reactions: query failed", "error", err)
+		return nil, err
+	}
+	reactions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Reaction, error) { return scanReaction(row) })
+	if err != nil {
+		slog.ErrorContext(ctx, "giftmessage.repo list_reactions: scan failed", "error", err)
+		return nil, err
+	}
+	return reactions, nil
+}
+
+// AddReaction is idempotent: reacting twice with the same emoji returns the
+// existing reaction with created=false.
+func (r *PostgresRepository) AddReaction(ctx context.Context, messageID, userID int64, emoji string) (*Reaction, bool, error) {
+	rc, err := scanReaction(r.db.QueryRow(ctx,
+		`WITH r AS (
+		     INSERT INTO gift_message_reactions (message_id, user_id, emoji)
+		     VALUES ($1, $2, $3)
+		     ON CONFLICT (message_id, user_id, emoji) DO NOTHING
+		     RETURNING *
+		 )
+		 `+reactionSelect+` FROM r JOIN users u ON u.id = r.user_id`,
+		messageID, userID, emoji))
+	if err == nil {
+		return &rc, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		if appErr := mapPgError(err); appErr != nil {
+			return nil, false, appErr
+		}
+		slog.ErrorContext(ctx, "giftmessage.repo add_reaction: insert failed", "message_id", messageID, "error", err)
+		return nil, false, err
+	}
+
+	rc, err = scanReaction(r.db.QueryRow(ctx,
+		reactionSelect+`
+		   FROM gift_message_reactions r
+		   JOIN users u ON u.id = r.user_id
+		  WHERE r.message_id = $1 AND r.user_id = $2 AND r.emoji = $3`,
+		messageID, userID, emoji))
+	if err != nil {
+		slog.ErrorContext(ctx, "giftmessage.repo add_reaction: lookup failed", "message_id", messageID, "error", err)
+		return nil, false, err
+	}
+	return &rc, false, nil
+}
+
+func (r *PostgresRepository) DeleteReaction(ctx context.Context, id int64) (*Reaction, error) {
+	rc, err := scanReaction(r.db.QueryRow(ctx,
+		`WITH r AS (
+		     DELETE FROM gift_message_reactions WHERE id = $1
+		     RETURNING *
+		 )
+		 `+reactionSelect+` FROM r JOIN users u ON u.id = r.user_id`,
+		id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.NotFound("reação não encontrada")
+		}
+		slog.ErrorContext(ctx, "giftmessage.repo delete_reaction: failed", "id", id, "error", err)
+		return nil, err
+	}
+	return &rc, nil
+}
+
+const uploadSlotColumns = `id, object_key, gift_transaction_id, user_id, media_kind, mime_type, size_bytes, expires_at, created_at`
+
+func scanUploadSlot(row pgx.Row) (UploadSlot, error) {
+	var u UploadSlot
+	err := row.Scan(&u.ID, &u.ObjectKey, &u.GiftTransactionID, &u.UserID,
+		&u.MediaKind, &u.MimeType, &u.SizeBytes, &u.ExpiresAt, &u.CreatedAt)
+	return u, err
+}
+
+func (r *PostgresRepository) CreateUploadSlot(ctx context.Context, in UploadSlot) (*UploadSlot, error) {
+	u, err := scanUploadSlot(r.db.QueryRow(ctx,
+		`INSERT INTO gift_message_upload_slots
+		    (object_key, gift_transaction_id, user_id, media_kind, mime_type, size_bytes, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING `+uploadSlotColumns,
+		in.ObjectKey, in.GiftTransactionID, in.UserID, in.MediaKind, in.MimeType, in.SizeBytes, in.ExpiresAt))
+	if err != nil {
+		if appErr := mapPgError(err); appErr != nil {
+			return nil, appErr
+		}
+		slog.ErrorContext(ctx, "giftmessage.repo create_upload_slot: insert failed", "tx_id", in.GiftTransactionID, "error", err)
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *PostgresRepository) CountOpenUploadSlots(ctx context.Context, txID int64) (int, error) {
+	var n int
+	if err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM gift_message_upload_slots
+		  WHERE gift_transaction_id = $1 AND expires_at > now()`, txID).Scan(&n); err != nil {
+		slog.ErrorContext(ctx, "giftmessage.repo count_upload_slots: query failed", "tx_id", txID, "error", err)
+		return 0, err
+	}
+	return n, nil
+}
+
+func (r *PostgresRepository) GetUploadSlot(ctx context.Context, key string, txID, userID int64) (*UploadSlot, error) {
+	u, err := scanUploadSlot(r.db.QueryRow(ctx,
+		`SELECT `+uploadSlotColumns+` FROM gift_message_upload_slots
+		  WHERE object_key = $1 AND gift_transaction_id = $2 AND user_id = $3
+		    AND expires_at > now()`,
+		key, txID, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.NotFound("envio de mídia não encontrado ou expirado")
+		}
+		slog.ErrorContext(ctx, "giftmessage.repo get_upload_slot: query failed", "tx_id", txID, "error", err)
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *PostgresRepository) ClaimUploadSlot(ctx context.Context, key string, txID, userID int64) (*UploadSlot, error) {
+	u, err := scanUploadSlot(r.db.QueryRow(ctx,
+		`DELETE FROM gift_message_upload_slots
+		  WHERE object_key = $1 AND gift_transaction_id = $2 AND user_id = $3
+		    AND expires_at > now()
+		 RETURNING `+uploadSlotColumns,
+		key, txID, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.NotFound("envio de mídia não encontrado ou expirado")
+		}
+		slog.ErrorContext(ctx, "giftmessage.repo claim_upload_slot: failed", "tx_id", txID, "error", err)
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *PostgresRepository) ListExpiredUploadSlots(ctx context.Context, limit int) ([]UploadSlot, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+uploadSlotColumns+` FROM gift_message_upload_slots
+		  WHERE expires_at <= now()
+		  ORDER BY expires_at
+		  LIMIT $1`, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "giftmessage.repo list_expired_upload_slots: query failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []UploadSlot
+	for rows.Next() {
+		u, err := scanUploadSlot(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, "giftmessage.repo list_expired_upload_slots: scan failed", "error", err)
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+func (r *PostgresRepository) DeleteUploadSlot(ctx context.Context, id int64) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM gift_message_upload_slots WHERE id = $1`, id); err != nil {
+		slog.ErrorContext(ctx, "giftmessage.repo delete_upload_slot: failed", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+const resumableUploadColumns = `id, gift_transaction_id, user_id, upload_length, upload_offset, declared_mime, object_key, media_kind, mime_type, completed_at, expires_at, created_at`
+
+func scanResumableUpload(row pgx.Row) (ResumableUpload, error) {
+	var u ResumableUpload
+	err := row.Scan(&u.ID, &u.GiftTransactionID, &u.UserID, &u.Length, &u.Offset, &u.DeclaredMime,
+		&u.ObjectKey, &u.MediaKind, &u.MimeType, &u.CompletedAt, &u.ExpiresAt, &u.CreatedAt)
+	return u, err
+}
+
+func (r *PostgresRepository) CreateResumableUpload(ctx context.Context, in ResumableUpload) (*ResumableUpload, error) {
+	u, err := scanResumableUpload(r.db.QueryRow(ctx,
+		`INSERT INTO gift_message_resumable_uploads
+		    (id, gift_transaction_id, user_id, upload_length, declared_mime, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING `+resumableUploadColumns,
+		in.ID, in.GiftTransactionID, in.UserID, in.Length, in.DeclaredMime, in.ExpiresAt))
+	if err != nil {
+		if appErr := mapPgError(err); appErr != nil {
+			return nil, appErr
+		}
+		slog.ErrorContext(ctx, "giftmessage.repo create_resumable_upload: insert failed", "tx_id", in.GiftTransactionID, "error", err)
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *PostgresRepository) GetResumableUpload(ctx context.Context, id string) (*ResumableUpload, error) {
+	u, err := scanResumableUpload(r.db.QueryRow(ctx,
+		`SELECT `+resumableUploadColumns+` FROM gift_message_resumable_uploads WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.NotFound("envio não encontrado")
+		}
+		slog.ErrorContext(ctx, "giftmessage.repo get_resumable_upload: query failed", "id", id, "error", err)
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *PostgresRepository) ResumableUploadUsage(ctx context.Context, userID int64) (int, int64, error) {
+	var count int
+	var bytes int64
+	if err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(upload_length), 0)
+		   FROM gift_message_resumable_uploads
+		  WHERE user_id = $1 AND completed_at IS NULL AND expires_at > now()`, userID).Scan(&count, &bytes); err != nil {
+		slog.ErrorContext(ctx, "giftmessage.repo resumable_upload_usage: query failed", "user_id", userID, "error", err)
+		return 0, 0, err
+	}
+	return count, bytes, nil
+}
+
+func (r *PostgresRepository) SetResumableUploadOffset(ctx context.Context, id string, offset int64) error {
+	if _, err := r.db.Exec(ctx,
+		`UPDATE gift_message_resumable_uploads
+		    SET upload_offset = $2, updated_at = now()
+		  WHERE id = $1`, id, offset); err != nil {
+		slog.ErrorContext(ctx, "giftmessage.repo set_resumable_offset: failed", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresRepository) CompleteResumableUpload(ctx context.Context, id, objectKey, mediaKind, mime string) error {
+	if _, err := r.db.Exec(ctx,
+		`UPDATE gift_message_resumable_uploads
+		    SET object_key = $2, media_kind = $3, mime_type = $4,
+		        completed_at = now(), updated_at = now()
+		  WHERE id = $1`, id, objectKey, mediaKind, mime); err != nil {
+		slog.ErrorContext(ctx, "giftmessage.repo complete_resumable_upload: failed", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ClaimResumableUpload(ctx context.Context, id string, txID, userID int64) (*ResumableUpload, error) {
+	u, err := scanResumableUpload(r.db.QueryRow(ctx,
+		`DELETE FROM gift_message_resumable_uploads
+		  WHERE id = $1 AND gift_transaction_id = $2 AND user_id = $3
+		    AND completed_at IS NOT NULL AND expires_at > now()
+		 RETURNING `+resumableUploadColumns,
+		id, txID, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.NotFound("envio não encontrado, incompleto ou expirado")
+		}
+		slog.ErrorContext(ctx, "giftmessage.repo claim_resumable_upload: failed", "id", id, "error", err)
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *PostgresRepository) ListExpiredResumableUploads(ctx context.Context, limit int) ([]ResumableUpload, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+resumableUploadColumns+` FROM gift_message_resumable_uploads
+		  WHERE expires_at <= now()
+		  ORDER BY expires_at
+		  LIMIT $1`, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "giftmessage.repo list_expired_resumable_uploads: query failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ResumableUpload
+	for rows.Next() {
+		u, err := scanResumableUpload(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, "giftmessage.repo list_expired_resumable_uploads: scan failed", "error", err)
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+func (r *PostgresRepository) DeleteResumableUpload(ctx context.Context, id string) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM gift_message_resumable_uploads WHERE id = $1`, id); err != nil {
+		slog.ErrorContext(ctx, "giftmessage.repo delete_resumable_upload: failed", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+func flaggedTerms(terms []string) []string {
+	if terms == nil {
+		return []string{}
+	}
+	return terms
+}
+
 func mapPgError(err error) *apperror.AppError {
 	var pgErr *pgconn.PgError
 	if !errors.As(err, &pgErr) {
@@ -0,0 +1,303 @@
+package giftmessage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm      = "AWS4-HMAC-SHA256"
+	s3Service        = "s3"
+	s3UnsignedBody   = "UNSIGNED-PAYLOAD"
+	s3MaxPresignTTL  = 7 * 24 * time.Hour
+	s3AmzDateLayout  = "20060102T150405Z"
+	s3DateOnlyLayout = "20060102"
+)
+
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle addresses objects as {endpoint}/{bucket}/{key} instead of
+	// {bucket}.{endpoint}/{key}. MinIO and most self-hosted services need it.
+	PathStyle bool
+}
+
+// S3Storage talks to any S3-compatible service with hand-rolled SigV4:
+// header signing for writes and presigned query strings for reads.
+type S3Storage struct {
+	endpoint   *url.URL
+	cfg        S3Config
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+func NewS3Storage(cfg S3Config) (*S3Storage, error) {
+	endpoint, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("s3 storage: invalid endpoint %q", cfg.Endpoint)
+	}
+	return &S3Storage{
+		endpoint:   endpoint,
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		now:        time.Now,
+	}, nil
+}
+
+func (s *S3Storage) Upload(ctx context.Context, key, mime string, r io.Reader, size int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), r)
+	if err != nil {
+		return fmt.Errorf("s3 storage: build upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", mime)
+	if size > 0 {
+		req.ContentLength = size
+	}
+	s.signRequest(req, s3UnsignedBody)
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("s3 storage: upload http error: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 storage: upload status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// SignURLs presigns GETs locally; no request reaches the service.
+func (s *S3Storage) SignURLs(ctx context.Context, keys []string, ttl time.Duration) (map[string]string, error) {
+	out := make(map[string]string, len(keys))
+	for _, key := range keys {
+		out[key] = s.presignGet(key, ttl)
+	}
+	return out, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return fmt.Errorf("s3 storage: build delete request: %w", err)
+	}
+	s.signRequest(req, emptyPayloadHash)
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("s3 storage: delete http error: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 storage: delete status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+func (s *S3Storage) BucketExists(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.objectURL("").String(), nil)
+	if err != nil {
+		return fmt.Errorf("s3 storage: build bucket check request: %w", err)
+	}
+	s.signRequest(req, emptyPayloadHash)
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("s3 storage: bucket check http error: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("s3 storage: bucket check status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// emptyPayloadHash is hex(sha256("")), the payload hash of bodiless requests.
+var emptyPayloadHash = hexSHA256("")
+
+func (s *S3Storage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	base := strings.TrimRight(u.Path, "/")
+	if s.cfg.PathStyle {
+		u.Path = base + "/" + s.cfg.Bucket + "/" + key
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+		u.Path = base + "/" + key
+	}
+	u.RawPath = s3EncodePath(u.Path)
+	return &u
+}
+
+func (s *S3Storage) presignGet(key string, ttl time.Duration) string {
+	return s.presign(http.MethodGet, key, ttl)
+}
+
+// presign builds a query-string signed URL covering only the host header,
+// so the caller may send any Content-Type.
+func (s *S3Storage) presign(method, key string, ttl time.Duration) string {
+	if ttl > s3MaxPresignTTL {
+		ttl = s3MaxPresignTTL
+	}
+	now := s.now().UTC()
+	u := s.objectURL(key)
+	q := url.Values{}
+	q.Set("X-Amz-Algorithm", s3Algorithm)
+	q.Set("X-Amz-Credential", s.cfg.AccessKeyID+"/"+s.scope(now))
+	q.Set("X-Amz-Date", now.Format(s3AmzDateLayout))
+	q.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	q.Set("X-Amz-SignedHeaders", "host")
+
+	canonical := strings.Join([]string{
+		method,
+		s3EncodePath(u.Path),
+		s3CanonicalQuery(q),
+		"host:" + u.Host + "\n",
+		"host",
+		s3UnsignedBody,
+	}, "\n")
+	q.Set("X-Amz-Signature", s.signature(now, canonical))
+	u.RawQuery = s3CanonicalQuery(q)
+	return u.String()
+}
+
+func (s *S3Storage) PresignUpload(ctx context.Context, key, mime string, ttl time.Duration) (string, error) {
+	return s.presign(http.MethodPut, key, ttl), nil
+}
+
+func (s *S3Storage) ReadPrefix(ctx context.Context, key string, n int) ([]byte, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("s3 storage: build read request: %w", err)
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", n-1))
+	s.signRequest(req, emptyPayloadHash)
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("s3 storage: read http error: %w", err)
+	}
+	defer resp.Body.Close()
+	return readRangeResponse(resp, n, "s3 storage")
+}
+
+// signRequest adds SigV4 Authorization headers covering host, the
+// x-amz-* headers and Content-Type when present.
+func (s *S3Storage) signRequest(req *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format(s3AmzDateLayout))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || lower == "range" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		s3EncodePath(req.URL.Path),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.cfg.AccessKeyID, s.scope(now), signedHeaders, s.signature(now, canonical)))
+}
+
+func (s *S3Storage) scope(now time.Time) string {
+	return now.Format(s3DateOnlyLayout) + "/" + s.cfg.Region + "/" + s3Service + "/aws4_request"
+}
+
+func (s *S3Storage) signature(now time.Time, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		now.Format(s3AmzDateLayout),
+		s.scope(now),
+		hexSHA256(canonicalRequest),
+	}, "\n")
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretAccessKey), now.Format(s3DateOnlyLayout))
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// s3EncodePath URI-encodes each path segment the way SigV4 expects for S3:
+// once, keeping "/" as the separator.
+func s3EncodePath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = s3Escape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+func s3CanonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, k := range keys {
+		values := append([]string(nil), q[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs, s3Escape(k)+"="+s3Escape(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// s3Escape percent-encodes everything except the RFC 3986 unreserved set,
+// which differs from url.QueryEscape (spaces, "~").
+func s3Escape(v string) string {
+	var b strings.Builder
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
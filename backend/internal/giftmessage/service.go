@@ -10,8 +10,13 @@ import (
 	"log/slog"
 	"net/http"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/audit"
+	"github.com/ferjunior7/parasempre/backend/internal/media"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
 	"github.com/ferjunior7/parasempre/backend/internal/validate"
 )
 
@@ -27,13 +32,18 @@ const (
 type TransactionFinder interface {
 	GetByID(ctx context.Context, id int64) (*TransactionSnapshot, error)
 }
-type AuditLogger interface {
-	LogAction(ctx context.Context, userID int64, action string, details map[string]any) error
-}
 
 const (
-	auditMessageCreated = "giftmessage.created"
-	auditMessageRemoved = "giftmessage.removed"
+	auditMessageCreated  = "giftmessage.created"
+	auditMessageRemoved  = "giftmessage.removed"
+	auditMessageApproved = "giftmessage.approved"
+	auditMessageRejected = "giftmessage.rejected"
+
+	auditReplyCreated    = "giftmessage.reply_created"
+	auditReplyUpdated    = "giftmessage.reply_updated"
+	auditReplyDeleted    = "giftmessage.reply_deleted"
+	auditReactionAdded   = "giftmessage.reaction_added"
+	auditReactionRemoved = "giftmessage.reaction_removed"
 )
 
 type Media struct {
@@ -46,24 +56,20 @@ type Service struct {
 	repo    TxAwareRepository
 	txns    TransactionFinder
 	storage Storage
-	audit   AuditLogger
+	audit   audit.Logger
 	ttl     time.Duration
+	mode    ModerationMode
+	urls    *urlCache
 }
 
-func NewService(repo TxAwareRepository, txns TransactionFinder, storage Storage, audit AuditLogger, ttl time.Duration) *Service {
+func NewService(repo TxAwareRepository, txns TransactionFinder, storage Storage, audit audit.Logger, ttl time.Duration, mode ModerationMode) *Service {
 	if ttl <= 0 {
 		ttl = 15 * time.Minute
 	}
-	return &Service{repo: repo, txns: txns, storage: storage, audit: audit, ttl: ttl}
-}
-
-func (s *Service) recordAudit(ctx context.Context, userID int64, action string, details map[string]any) {
-	if s.audit == nil || userID == 0 {
-		return
-	}
-	if err := s.audit.LogAction(ctx, userID, action, details); err != nil {
-		slog.Error("giftmessage.service audit failed", "action", action, "user_id", userID, "error", err)
+	if mode == "" {
+		mode = ModerationFlagged
 	}
+	return &Service{repo: repo, txns: txns, storage: storage, audit: audit, ttl: ttl, mode: mode, urls: newURLCache(ttl)}
 }
 
 func (s *Service) Create(ctx context.Context, txID, requesterUserID int64, in CreateInput, media *Media) (*GiftMessage, error) {
@@ -74,75 +80,72 @@ func (s *Service) Create(ctx context.Context, txID, requesterUserID int64, in Cr
 		return nil, err
 	}
 
-	tx, err := s.txns.GetByID(ctx, txID)
-	if err != nil {
-		return nil, err
-	}
-	if tx.UserID != requesterUserID {
-		return nil, apperror.Forbidden("transação não pertence ao usuário")
+	sources := 0
+	for _, set := range []bool{media != nil, in.MediaKey != "", in.UploadID != ""} {
+		if set {
+			sources++
+		}
 	}
-	if tx.Status != approvedStatus {
-		return nil, apperror.Conflict("aguarde a aprovação do pagamento para deixar uma mensagem")
+	if sources > 1 {
+		return nil, apperror.Validation("envie apenas um de: arquivo, media_key ou upload_id")
 	}
 
-	if existing, err := s.repo.GetByTransactionID(ctx, txID); err == nil && existing != nil {
-		return nil, apperror.Conflict("já existe uma mensagem para essa transação")
-	} else if err != nil {
-		var ae *apperror.AppError
-		if !errors.As(err, &ae) || ae.Code != http.StatusNotFound {
-			return nil, err
-		}
+	tx, err := s.messageableTx(ctx, txID, requesterUserID)
+	if err != nil {
+		return nil, err
 	}
 
+	flagged := FlagContent(in.AuthorName, in.Content)
 	row := CreateRow{
 		GiftTransactionID: tx.ID,
 		GiftID:            tx.GiftID,
 		UserID:            requesterUserID,
 		AuthorName:        in.AuthorName,
 		Content:           in.Content,
+		Status:            s.mode.InitialStatus(flagged),
+		FlaggedTerms:      flagged,
 	}
 
-	var uploadedKey string
+	var uploadedKeys []string
 	if media != nil {
 		if s.storage == nil {
 			return nil, apperror.ServiceUnavailable("Mensagens com mídia indisponíveis neste ambiente.")
 		}
-
-		mime, peeked, err := resolveMediaMIME(media.DeclaredMime, media.Reader)
+		stored, err := StoreMedia(ctx, s.storage, fmt.Sprintf("messages/%d", tx.ID), *media)
 		if err != nil {
 			return nil, err
 		}
-		spec, err := ValidateMedia(mime, media.Size)
+		uploadedKeys = stored.Keys()
+		row.MediaObjectKey = &stored.Key
+		row.MediaThumbKey = stored.ThumbKey
+		row.MediaKind = &stored.Kind
+		row.MediaSizeBytes = &stored.Size
+		row.MediaMimeType = &stored.Mime
+	} else if in.MediaKey != "" {
+		slot, err := s.claimUploadSlot(ctx, in.MediaKey, tx.ID, requesterUserID)
 		if err != nil {
 			return nil, err
 		}
-
-		key, err := buildObjectKey(tx.ID, spec.ext)
+		uploadedKeys = append(uploadedKeys, slot.ObjectKey)
+		row.MediaObjectKey = &slot.ObjectKey
+		row.MediaKind = &slot.MediaKind
+		row.MediaSizeBytes = &slot.SizeBytes
+		row.MediaMimeType = &slot.MimeType
+	} else if in.UploadID != "" {
+		up, err := s.repo.ClaimResumableUpload(ctx, in.UploadID, tx.ID, requesterUserID)
 		if err != nil {
-			return nil, apperror.Internal("falha ao gerar chave de mídia", err)
-		}
-		if err := s.storage.Upload(ctx, key, mime, peeked, media.Size); err != nil {
-			slog.Error("giftmessage.service create: storage upload failed",
-				"key", key, "error", err)
-			return nil, apperror.ServiceUnavailable("Não foi possível enviar sua mídia agora. Tente novamente.")
+			return nil, err
 		}
-
-		uploadedKey = key
-		size := media.Size
-		row.MediaObjectKey = &key
-		row.MediaKind = &spec.kind
-		row.MediaSizeBytes = &size
-		row.MediaMimeType = &mime
+		uploadedKeys = append(uploadedKeys, *up.ObjectKey)
+		row.MediaObjectKey = up.ObjectKey
+		row.MediaKind = up.MediaKind
+		row.MediaSizeBytes = &up.Length
+		row.MediaMimeType = up.MimeType
 	}
 
 	created, err := s.repo.Create(ctx, row)
 	if err != nil {
-		if uploadedKey != "" {
-			if delErr := s.storage.Delete(context.Background(), uploadedKey); delErr != nil {
-				slog.Error("giftmessage.service create: orphan delete failed",
-					"key", uploadedKey, "error", delErr)
-			}
-		}
+		s.deleteObjects(ctx, uploadedKeys)
 		return nil, err
 	}
 
@@ -150,24 +153,62 @@ func (s *Service) Create(ctx context.Context, txID, requesterUserID int64, in Cr
 	if created.MediaKind != nil {
 		mediaKindLog = *created.MediaKind
 	}
-	s.recordAudit(ctx, requesterUserID, auditMessageCreated, map[string]any{
+	audit.RecordAs(ctx, s.audit, requesterUserID, auditMessageCreated, audit.Entity(audit.EntityGiftMessage, created.ID, map[string]any{
 		"message_id": created.ID,
 		"tx_id":      created.GiftTransactionID,
 		"gift_id":    created.GiftID,
 		"media_kind": mediaKindLog,
-	})
+		"status":     created.Status,
+		"flagged":    created.FlaggedTerms,
+	}))
 
-	slog.Info("giftmessage.service create: done",
+	slog.InfoContext(ctx, "giftmessage.service create: done",
 		"message_id", created.ID,
 		"tx_id", created.GiftTransactionID,
 		"gift_id", created.GiftID,
 		"user_id", requesterUserID,
 		"media_kind", mediaKindLog,
+		"status", created.Status,
+		"flagged", len(created.FlaggedTerms),
 	)
 
 	return created, nil
 }
 
+// messageableTx loads txID and checks the requester may attach a message to
+// it: they own it, it is paid, and it has no message yet.
+func (s *Service) messageableTx(ctx context.Context, txID, requesterUserID int64) (*TransactionSnapshot, error) {
+	tx, err := s.txns.GetByID(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+	if tx.UserID != requesterUserID {
+		return nil, apperror.Forbidden("transação não pertence ao usuário")
+	}
+	if tx.Status != approvedStatus {
+		return nil, apperror.Conflict("aguarde a aprovação do pagamento para deixar uma mensagem")
+	}
+
+	if existing, err := s.repo.GetByTransactionID(ctx, txID); err == nil && existing != nil {
+		return nil, apperror.Conflict("já existe uma mensagem para essa transação")
+	} else if err != nil {
+		var ae *apperror.AppError
+		if !errors.As(err, &ae) || ae.Code != http.StatusNotFound {
+			return nil, err
+		}
+	}
+	return tx, nil
+}
+
+func (s *Service) upload(ctx context.Context, key, mime string, r io.Reader, size int64) error {
+	return uploadObject(ctx, s.storage, key, mime, r, size)
+}
+
+// deleteObjects removes uploads orphaned by a failed create.
+func (s *Service) deleteObjects(ctx context.Context, keys []string) {
+	DeleteObjects(ctx, s.storage, keys)
+}
+
 func (s *Service) GetMine(ctx context.Context, txID, requesterUserID int64) (*PublicMessage, error) {
 	if requesterUserID == 0 {
 		return nil, apperror.Unauthorized("autenticação obrigatória")
@@ -189,19 +230,21 @@ func (s *Service) GetMine(ctx context.Context, txID, requesterUserID int64) (*Pu
 		return nil, err
 	}
 	pub, _ := s.signSingle(ctx, *msg)
+	replies, reactions, err := s.loadInteractions(ctx, []int64{msg.ID}, true)
+	if err != nil {
+		return nil, err
+	}
+	pub.Replies, pub.Reactions = withDefault(replies[msg.ID]), withDefault(reactions[msg.ID])
 	return &pub, nil
 }
 
 func (s *Service) signSingle(ctx context.Context, m GiftMessage) (PublicMessage, error) {
-	if m.MediaObjectKey == nil || s.storage == nil {
-		return toPublic(m, ""), nil
-	}
-	urls, err := s.storage.SignURLs(ctx, []string{*m.MediaObjectKey}, s.ttl)
+	urls, err := s.signMediaURLs(ctx, []GiftMessage{m})
 	if err != nil {
-		slog.Warn("giftmessage.service sign_single: failed", "key", *m.MediaObjectKey, "error", err)
-		return toPublic(m, ""), err
+		slog.WarnContext(ctx, "giftmessage.service sign_single: failed", "message_id", m.ID, "error", err)
+		return toPublic(m, nil), err
 	}
-	return toPublic(m, urls[*m.MediaObjectKey]), nil
+	return toPublic(m, urls), nil
 }
 
 func (s *Service) ListByGift(ctx context.Context, giftID int64, page, limit int) (*Paged[PublicMessage], error) {
@@ -213,38 +256,104 @@ func (s *Service) ListByGift(ctx context.Context, giftID int64, page, limit int)
 
 	urls, err := s.signMediaURLs(ctx, rows)
 	if err != nil {
-		slog.Warn("giftmessage.service list_by_gift: sign urls failed", "error", err)
+		slog.WarnContext(ctx, "giftmessage.service list_by_gift: sign urls failed", "error", err)
+	}
+
+	replies, reactions, err := s.loadInteractions(ctx, messageIDs(rows), false)
+	if err != nil {
+		return nil, err
 	}
 
 	data := make([]PublicMessage, len(rows))
 	for i, m := range rows {
-		data[i] = toPublic(m, urlFor(m, urls))
+		data[i] = toPublic(m, urls)
+		data[i].Replies, data[i].Reactions = withDefault(replies[m.ID]), withDefault(reactions[m.ID])
 	}
-	return &Paged[PublicMessage]{Data: data, Page: page, Limit: limit, Total: total}, nil
+	return &Paged[PublicMessage]{Data: data, Page: page, Limit: limit, Meta: pagination.Meta{Total: &total}}, nil
 }
 
-func (s *Service) ListAll(ctx context.Context, page, limit int) (*Paged[AdminMessage], error) {
-	page, limit = normalizePaging(page, limit, defaultAdminLimit, maxAdminLimit)
-	rows, total, err := s.repo.ListAll(ctx, limit, (page-1)*limit)
+func (s *Service) ListAll(ctx context.Context, req pagination.Request) (*Paged[AdminMessage], error) {
+	req.Normalize(defaultAdminLimit, maxAdminLimit)
+	rows, meta, err := s.repo.ListAll(ctx, req)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		return nil, apperror.Validation("cursor inválido")
+	}
 	if err != nil {
 		return nil, apperror.WrapIfNotApp("falha ao listar recados (admin)", err)
 	}
 
 	urls, err := s.signMediaURLs(ctx, rows)
 	if err != nil {
-		slog.Warn("giftmessage.service list_all: sign urls failed", "error", err)
+		slog.WarnContext(ctx, "giftmessage.service list_all: sign urls failed", "error", err)
+	}
+
+	replies, reactions, err := s.loadInteractions(ctx, messageIDs(rows), true)
+	if err != nil {
+		return nil, err
 	}
 
 	data := make([]AdminMessage, len(rows))
 	for i, m := range rows {
-		pub := toPublic(m, urlFor(m, urls))
-		data[i] = AdminMessage{
-			PublicMessage:     pub,
-			UserID:            m.UserID,
-			GiftTransactionID: m.GiftTransactionID,
-		}
+		data[i] = toAdmin(m, urls)
+		data[i].Replies, data[i].Reactions = withDefault(replies[m.ID]), withDefault(reactions[m.ID])
+	}
+	return &Paged[AdminMessage]{Data: data, Page: req.Page, Limit: req.Limit, Meta: meta}, nil
+}
+
+// ModerationQueue lists messages in status (pending by default), oldest
+// first.
+func (s *Service) ModerationQueue(ctx context.Context, status string, page, limit int) (*Paged[AdminMessage], error) {
+	if status == "" {
+		status = StatusPending
+	}
+	if status != StatusPending && status != StatusApproved && status != StatusRejected {
+		return nil, apperror.Validation("status deve ser pending, approved ou rejected")
+	}
+	page, limit = normalizePaging(page, limit, defaultAdminLimit, maxAdminLimit)
+	rows, total, err := s.repo.ListByStatus(ctx, status, limit, (page-1)*limit)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("falha ao listar fila de moderação", err)
+	}
+
+	urls, err := s.signMediaURLs(ctx, rows)
+	if err != nil {
+		slog.WarnContext(ctx, "giftmessage.service moderation_queue: sign urls failed", "error", err)
+	}
+
+	replies, reactions, err := s.loadInteractions(ctx, messageIDs(rows), true)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]AdminMessage, len(rows))
+	for i, m := range rows {
+		data[i] = toAdmin(m, urls)
+		data[i].Replies, data[i].Reactions = withDefault(replies[m.ID]), withDefault(reactions[m.ID])
 	}
-	return &Paged[AdminMessage]{Data: data, Page: page, Limit: limit, Total: total}, nil
+	return &Paged[AdminMessage]{Data: data, Page: page, Limit: limit, Meta: pagination.Meta{Total: &total}}, nil
+}
+
+// Moderate approves or rejects a batch of messages. Rejecting an approved
+// message takes it off the public page; the author still sees it.
+func (s *Service) Moderate(ctx context.Context, in ModerateInput, byUserID int64) (*ModerateResult, error) {
+	result, err := ModerateBatch(ctx, in, byUserID, "nenhum id de mensagem válido", s.repo.SetStatus)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("falha ao moderar mensagens", err)
+	}
+
+	action := auditMessageApproved
+	if result.Status == StatusRejected {
+		action = auditMessageRejected
+	}
+	for _, id := range result.Updated {
+		audit.RecordAs(ctx, s.audit, byUserID, action, audit.Entity(audit.EntityGiftMessage, id, map[string]any{
+			"message_id": id,
+		}))
+	}
+
+	slog.InfoContext(ctx, "giftmessage.service moderate: done",
+		"status", result.Status, "updated", len(result.Updated), "skipped", len(result.Skipped), "by_user_id", byUserID)
+	return result, nil
 }
 
 func (s *Service) Remove(ctx context.Context, id, byUserID int64) error {
@@ -254,13 +363,188 @@ func (s *Service) Remove(ctx context.Context, id, byUserID int64) error {
 	if err := s.repo.SoftDelete(ctx, id, byUserID); err != nil {
 		return err
 	}
-	s.recordAudit(ctx, byUserID, auditMessageRemoved, map[string]any{
+	audit.RecordAs(ctx, s.audit, byUserID, auditMessageRemoved, audit.Entity(audit.EntityGiftMessage, id, map[string]any{
 		"message_id": id,
-	})
-	slog.Info("giftmessage.service remove: done", "message_id", id, "by_user_id", byUserID)
+	}))
+	slog.InfoContext(ctx, "giftmessage.service remove: done", "message_id", id, "by_user_id", byUserID)
+	return nil
+}
+
+func (s *Service) CreateReply(ctx context.Context, messageID, byUserID int64, in ReplyInput) (*Reply, error) {
+	if byUserID == 0 {
+		return nil, apperror.Unauthorized("autenticação obrigatória")
+	}
+	if err := validate.Struct(in); err != nil {
+		return nil, err
+	}
+	if in.Visibility == "" {
+		in.Visibility = ReplyPublic
+	}
+	if _, err := s.repo.GetByID(ctx, messageID); err != nil {
+		return nil, err
+	}
+
+	reply, err := s.repo.CreateReply(ctx, messageID, byUserID, in)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("falha ao responder mensagem", err)
+	}
+	audit.RecordAs(ctx, s.audit, byUserID, auditReplyCreated, audit.Entity(audit.EntityGiftMessage, messageID, map[string]any{
+		"message_id": messageID,
+		"reply_id":   reply.ID,
+		"visibility": reply.Visibility,
+	}))
+	return reply, nil
+}
+
+// UpdateReply changes the content and, when given, the visibility of a
+// reply; an empty visibility keeps the current one.
+func (s *Service) UpdateReply(ctx context.Context, id, byUserID int64, in ReplyInput) (*Reply, error) {
+	if byUserID == 0 {
+		return nil, apperror.Unauthorized("autenticação obrigatória")
+	}
+	if err := validate.Struct(in); err != nil {
+		return nil, err
+	}
+
+	reply, err := s.repo.UpdateReply(ctx, id, in)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("falha ao editar resposta", err)
+	}
+	audit.RecordAs(ctx, s.audit, byUserID, auditReplyUpdated, audit.Entity(audit.EntityGiftMessage, reply.MessageID, map[string]any{
+		"message_id": reply.MessageID,
+		"reply_id":   reply.ID,
+		"visibility": reply.Visibility,
+	}))
+	return reply, nil
+}
+
+func (s *Service) DeleteReply(ctx context.Context, id, byUserID int64) error {
+	if byUserID == 0 {
+		return apperror.Unauthorized("autenticação obrigatória")
+	}
+	reply, err := s.repo.DeleteReply(ctx, id)
+	if err != nil {
+		return apperror.WrapIfNotApp("falha ao remover resposta", err)
+	}
+	audit.RecordAs(ctx, s.audit, byUserID, auditReplyDeleted, audit.Entity(audit.EntityGiftMessage, reply.MessageID, map[string]any{
+		"message_id": reply.MessageID,
+		"reply_id":   reply.ID,
+		"content":    reply.Content,
+	}))
 	return nil
 }
 
+// AddReaction reacts to a message. The bool reports whether a new reaction
+// was stored; repeating an existing one is a no-op.
+func (s *Service) AddReaction(ctx context.Context, messageID, byUserID int64, in ReactionInput) (*Reaction, bool, error) {
+	if byUserID == 0 {
+		return nil, false, apperror.Unauthorized("autenticação obrigatória")
+	}
+	if err := validate.Struct(in); err != nil {
+		return nil, false, err
+	}
+	if !validEmoji(in.Emoji) {
+		return nil, false, apperror.Validation("reação deve ser um emoji")
+	}
+	if _, err := s.repo.GetByID(ctx, messageID); err != nil {
+		return nil, false, err
+	}
+
+	reaction, created, err := s.repo.AddReaction(ctx, messageID, byUserID, in.Emoji)
+	if err != nil {
+		return nil, false, apperror.WrapIfNotApp("falha ao reagir à mensagem", err)
+	}
+	if created {
+		audit.RecordAs(ctx, s.audit, byUserID, auditReactionAdded, audit.Entity(audit.EntityGiftMessage, messageID, map[string]any{
+			"message_id":  messageID,
+			"reaction_id": reaction.ID,
+			"emoji":       reaction.Emoji,
+		}))
+	}
+	return reaction, created, nil
+}
+
+func (s *Service) RemoveReaction(ctx context.Context, id, byUserID int64) error {
+	if byUserID == 0 {
+		return apperror.Unauthorized("autenticação obrigatória")
+	}
+	reaction, err := s.repo.DeleteReaction(ctx, id)
+	if err != nil {
+		return apperror.WrapIfNotApp("falha ao remover reação", err)
+	}
+	audit.RecordAs(ctx, s.audit, byUserID, auditReactionRemoved, audit.Entity(audit.EntityGiftMessage, reaction.MessageID, map[string]any{
+		"message_id":  reaction.MessageID,
+		"reaction_id": reaction.ID,
+		"emoji":       reaction.Emoji,
+	}))
+	return nil
+}
+
+// loadInteractions fetches replies and reactions for a page of messages in
+// two queries, grouped by message id. Private replies are dropped unless
+// includePrivate is set.
+func (s *Service) loadInteractions(ctx context.Context, ids []int64, includePrivate bool) (map[int64][]Reply, map[int64][]Reaction, error) {
+	if len(ids) == 0 {
+		return nil, nil, nil
+	}
+	replyRows, err := s.repo.ListReplies(ctx, ids)
+	if err != nil {
+		return nil, nil, apperror.WrapIfNotApp("falha ao carregar respostas", err)
+	}
+	reactionRows, err := s.repo.ListReactions(ctx, ids)
+	if err != nil {
+		return nil, nil, apperror.WrapIfNotApp("falha ao carregar reações", err)
+	}
+
+	replies := make(map[int64][]Reply)
+	for _, rp := range replyRows {
+		if rp.Visibility == ReplyPrivate && !includePrivate {
+			continue
+		}
+		replies[rp.MessageID] = append(replies[rp.MessageID], rp)
+	}
+	reactions := make(map[int64][]Reaction)
+	for _, rc := range reactionRows {
+		reactions[rc.MessageID] = append(reactions[rc.MessageID], rc)
+	}
+	return replies, reactions, nil
+}
+
+func messageIDs(rows []GiftMessage) []int64 {
+	ids := make([]int64, len(rows))
+	for i, m := range rows {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+func withDefault[T any](v []T) []T {
+	if v == nil {
+		return []T{}
+	}
+	return v
+}
+
+// validEmoji accepts a short emoji sequence (ZWJ sequences, skin tones,
+// flags) and rejects letters, digits and whitespace.
+func validEmoji(s string) bool {
+	n := utf8.RuneCountInString(s)
+	if n == 0 || n > 8 {
+		return false
+	}
+	symbols := 0
+	for _, r := range s {
+		switch {
+		case r == '\u200d' || r == '\ufe0f' || (r >= 0x1F3FB && r <= 0x1F3FF):
+		case unicode.Is(unicode.So, r):
+			symbols++
+		default:
+			return false
+		}
+	}
+	return symbols > 0
+}
+
 func (s *Service) signMediaURLs(ctx context.Context, rows []GiftMessage) (map[string]string, error) {
 	if s.storage == nil {
 		return nil, nil
@@ -270,53 +554,62 @@ func (s *Service) signMediaURLs(ctx context.Context, rows []GiftMessage) (map[st
 		if m.MediaObjectKey != nil {
 			keys = append(keys, *m.MediaObjectKey)
 		}
+		if m.MediaThumbKey != nil {
+			keys = append(keys, *m.MediaThumbKey)
+		}
 	}
 	if len(keys) == 0 {
 		return nil, nil
 	}
-	return s.storage.SignURLs(ctx, keys, s.ttl)
+	return s.urls.sign(ctx, s.storage, keys)
 }
 
-func urlFor(m GiftMessage, urls map[string]string) string {
-	if m.MediaObjectKey == nil || urls == nil {
-		return ""
+func toPublic(m GiftMessage, urls map[string]string) PublicMessage {
+	return PublicMessage{
+		ID:           m.ID,
+		GiftID:       m.GiftID,
+		AuthorName:   m.AuthorName,
+		Content:      m.Content,
+		MediaURL:     media.SignedURL(m.MediaObjectKey, urls),
+		ThumbnailURL: media.SignedURL(m.MediaThumbKey, urls),
+		MediaKind:    m.MediaKind,
+		Status:       m.Status,
+		CreatedAt:    m.CreatedAt,
+		Replies:      []Reply{},
+		Reactions:    []Reaction{},
 	}
-	return urls[*m.MediaObjectKey]
 }
 
-func toPublic(m GiftMessage, signedURL string) PublicMessage {
-	out := PublicMessage{
-		ID:         m.ID,
-		GiftID:     m.GiftID,
-		AuthorName: m.AuthorName,
-		Content:    m.Content,
-		MediaKind:  m.MediaKind,
-		CreatedAt:  m.CreatedAt,
+func toAdmin(m GiftMessage, urls map[string]string) AdminMessage {
+	flagged := m.FlaggedTerms
+	if flagged == nil {
+		flagged = []string{}
 	}
-	if signedURL != "" {
-		u := signedURL
-		out.MediaURL = &u
+	return AdminMessage{
+		PublicMessage:     toPublic(m, urls),
+		UserID:            m.UserID,
+		GiftTransactionID: m.GiftTransactionID,
+		FlaggedTerms:      flagged,
+		ModeratedAt:       m.ModeratedAt,
+		ModeratedBy:       m.ModeratedBy,
 	}
-	return out
 }
 
 func normalizePaging(page, limit, defaultLimit, maxLimit int) (int, int) {
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 {
-		limit = defaultLimit
-	}
-	if limit > maxLimit {
-		limit = maxLimit
-	}
-	return page, limit
+	req := pagination.Request{Page: page, Limit: limit}
+	req.Normalize(defaultLimit, maxLimit)
+	return req.Page, req.Limit
 }
 
 func buildObjectKey(txID int64, ext string) (string, error) {
+	return newObjectKey(fmt.Sprintf("messages/%d", txID), ext)
+}
+
+// newObjectKey returns a random, unguessable key under prefix.
+func newObjectKey(prefix, ext string) (string, error) {
 	buf := make([]byte, 12)
 	if _, err := rand.Read(buf); err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("messages/%d/%s%s", txID, hex.EncodeToString(buf), ext), nil
+	return fmt.Sprintf("%s/%s%s", prefix, hex.EncodeToString(buf), ext), nil
 }
@@ -13,6 +13,8 @@ import (
 	"github.com/jackc/pgx/v5"
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
 )
 
 // --- mocks ---
@@ -22,8 +24,22 @@ type mockRepo struct {
 	getByIDFn    func(ctx context.Context, id int64) (*GiftMessage, error)
 	getByTxIDFn  func(ctx context.Context, txID int64) (*GiftMessage, error)
 	listByGiftFn func(ctx context.Context, giftID int64, limit, offset int) ([]GiftMessage, int, error)
-	listAllFn    func(ctx context.Context, limit, offset int) ([]GiftMessage, int, error)
+	listAllFn    func(ctx context.Context, req pagination.Request) ([]GiftMessage, pagination.Meta, error)
+	listByStatFn func(ctx context.Context, status string, limit, offset int) ([]GiftMessage, int, error)
+	setStatusFn  func(ctx context.Context, ids []int64, status string, byUserID int64) ([]int64, error)
 	softDeleteFn func(ctx context.Context, id, byUserID int64) error
+
+	listRepliesFn    func(ctx context.Context, messageIDs []int64) ([]Reply, error)
+	createReplyFn    func(ctx context.Context, messageID, userID int64, in ReplyInput) (*Reply, error)
+	updateReplyFn    func(ctx context.Context, id int64, in ReplyInput) (*Reply, error)
+	deleteReplyFn    func(ctx context.Context, id int64) (*Reply, error)
+	listReactionsFn  func(ctx context.Context, messageIDs []int64) ([]Reaction, error)
+	addReactionFn    func(ctx context.Context, messageID, userID int64, emoji string) (*Reaction, bool, error)
+	deleteReactionFn func(ctx context.Context, id int64) (*Reaction, error)
+
+	uploadSlots      map[string]*UploadSlot
+	uploadSlotNextID int64
+	resumable        map[string]*ResumableUpload
 }
 
 func (m *mockRepo) Create(ctx context.Context, in CreateRow) (*GiftMessage, error) {
@@ -41,12 +57,153 @@ func (m *mockRepo) GetByTransactionID(ctx context.Context, txID int64) (*GiftMes
 func (m *mockRepo) ListByGift(ctx context.Context, giftID int64, limit, offset int) ([]GiftMessage, int, error) {
 	return m.listByGiftFn(ctx, giftID, limit, offset)
 }
-func (m *mockRepo) ListAll(ctx context.Context, limit, offset int) ([]GiftMessage, int, error) {
-	return m.listAllFn(ctx, limit, offset)
+func (m *mockRepo) ListAll(ctx context.Context, req pagination.Request) ([]GiftMessage, pagination.Meta, error) {
+	return m.listAllFn(ctx, req)
+}
+func (m *mockRepo) ListByStatus(ctx context.Context, status string, limit, offset int) ([]GiftMessage, int, error) {
+	return m.listByStatFn(ctx, status, limit, offset)
+}
+func (m *mockRepo) SetStatus(ctx context.Context, ids []int64, status string, byUserID int64) ([]int64, error) {
+	return m.setStatusFn(ctx, ids, status, byUserID)
 }
 func (m *mockRepo) SoftDelete(ctx context.Context, id, byUserID int64) error {
 	return m.softDeleteFn(ctx, id, byUserID)
 }
+func (m *mockRepo) ListReplies(ctx context.Context, messageIDs []int64) ([]Reply, error) {
+	if m.listRepliesFn == nil {
+		return nil, nil
+	}
+	return m.listRepliesFn(ctx, messageIDs)
+}
+func (m *mockRepo) CreateReply(ctx context.Context, messageID, userID int64, in ReplyInput) (*Reply, error) {
+	return m.createReplyFn(ctx, messageID, userID, in)
+}
+func (m *mockRepo) UpdateReply(ctx context.Context, id int64, in ReplyInput) (*Reply, error) {
+	return m.updateReplyFn(ctx, id, in)
+}
+func (m *mockRepo) DeleteReply(ctx context.Context, id int64) (*Reply, error) {
+	return m.deleteReplyFn(ctx, id)
+}
+func (m *mockRepo) ListReactions(ctx context.Context, messageIDs []int64) ([]Reaction, error) {
+	if m.listReactionsFn == nil {
+		return nil, nil
+	}
+	return m.listReactionsFn(ctx, messageIDs)
+}
+func (m *mockRepo) AddReaction(ctx context.Context, messageID, userID int64, emoji string) (*Reaction, bool, error) {
+	return m.addReactionFn(ctx, messageID, userID, emoji)
+}
+func (m *mockRepo) DeleteReaction(ctx context.Context, id int64) (*Reaction, error) {
+	return m.deleteReactionFn(ctx, id)
+}
+func (m *mockRepo) CreateUploadSlot(_ context.Context, in UploadSlot) (*UploadSlot, error) {
+	if m.uploadSlots == nil {
+		m.uploadSlots = map[string]*UploadSlot{}
+	}
+	m.uploadSlotNextID++
+	in.ID = m.uploadSlotNextID
+	m.uploadSlots[in.ObjectKey] = &in
+	return &in, nil
+}
+func (m *mockRepo) CountOpenUploadSlots(_ context.Context, txID int64) (int, error) {
+	n := 0
+	for _, u := range m.uploadSlots {
+		if u.GiftTransactionID == txID && u.ExpiresAt.After(time.Now()) {
+			n++
+		}
+	}
+	return n, nil
+}
+func (m *mockRepo) GetUploadSlot(_ context.Context, key string, txID, userID int64) (*UploadSlot, error) {
+	u, ok := m.uploadSlots[key]
+	if !ok || u.GiftTransactionID != txID || u.UserID != userID || !u.ExpiresAt.After(time.Now()) {
+		return nil, apperror.NotFound("envio de mídia não encontrado ou expirado")
+	}
+	return u, nil
+}
+func (m *mockRepo) ClaimUploadSlot(ctx context.Context, key string, txID, userID int64) (*UploadSlot, error) {
+	u, err := m.GetUploadSlot(ctx, key, txID, userID)
+	if err != nil {
+		return nil, err
+	}
+	delete(m.uploadSlots, key)
+	return u, nil
+}
+func (m *mockRepo) ListExpiredUploadSlots(_ context.Context, limit int) ([]UploadSlot, error) {
+	var out []UploadSlot
+	for _, u := range m.uploadSlots {
+		if !u.ExpiresAt.After(time.Now()) && len(out) < limit {
+			out = append(out, *u)
+		}
+	}
+	return out, nil
+}
+func (m *mockRepo) DeleteUploadSlot(_ context.Context, id int64) error {
+	for k, u := range m.uploadSlots {
+		if u.ID == id {
+			delete(m.uploadSlots, k)
+		}
+	}
+	return nil
+}
+func (m *mockRepo) CreateResumableUpload(_ context.Context, in ResumableUpload) (*ResumableUpload, error) {
+	if m.resumable == nil {
+		m.resumable = map[string]*ResumableUpload{}
+	}
+	in.CreatedAt = time.Now()
+	m.resumable[in.ID] = &in
+	cp := in
+	return &cp, nil
+}
+func (m *mockRepo) GetResumableUpload(_ context.Context, id string) (*ResumableUpload, error) {
+	u, ok := m.resumable[id]
+	if !ok {
+		return nil, apperror.NotFound("envio não encontrado")
+	}
+	cp := *u
+	return &cp, nil
+}
+func (m *mockRepo) ResumableUploadUsage(_ context.Context, userID int64) (int, int64, error) {
+	count, total := 0, int64(0)
+	for _, u := range m.resumable {
+		if u.UserID == userID && u.CompletedAt == nil && u.ExpiresAt.After(time.Now()) {
+			count++
+			total += u.Length
+		}
+	}
+	return count, total, nil
+}
+func (m *mockRepo) SetResumableUploadOffset(_ context.Context, id string, offset int64) error {
+	m.resumable[id].Offset = offset
+	return nil
+}
+func (m *mockRepo) CompleteResumableUpload(_ context.Context, id, objectKey, mediaKind, mime string) error {
+	now := time.Now()
+	u := m.resumable[id]
+	u.ObjectKey, u.MediaKind, u.MimeType, u.CompletedAt = &objectKey, &mediaKind, &mime, &now
+	return nil
+}
+func (m *mockRepo) ClaimResumableUpload(_ context.Context, id string, txID, userID int64) (*ResumableUpload, error) {
+	u, ok := m.resumable[id]
+	if !ok || u.GiftTransactionID != txID || u.UserID != userID || u.CompletedAt == nil {
+		return nil, apperror.NotFound("envio não encontrado, incompleto ou expirado")
+	}
+	delete(m.resumable, id)
+	return u, nil
+}
+func (m *mockRepo) ListExpiredResumableUploads(_ context.Context, limit int) ([]ResumableUpload, error) {
+	var out []ResumableUpload
+	for _, u := range m.resumable {
+		if !u.ExpiresAt.After(time.Now()) && len(out) < limit {
+			out = append(out, *u)
+		}
+	}
+	return out, nil
+}
+func (m *mockRepo) DeleteResumableUpload(_ context.Context, id string) error {
+	delete(m.resumable, id)
+	return nil
+}
 func (m *mockRepo) WithTx(_ pgx.Tx) Repository { return m }
 
 type mockTxFinder struct {
@@ -113,15 +270,14 @@ func validInput() CreateInput {
 	return CreateInput{AuthorName: "Maria", Content: "Felicidades!"}
 }
 
-// jpegMedia gera um Media com bytes que http.DetectContentType identifica
-// como image/jpeg.
-func jpegMedia(size int64) *Media {
-	hdr := []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0, 1}
-	body := make([]byte, size-int64(len(hdr)))
+// jpegMedia gera um Media com um JPEG real (decodificável), já que imagens
+// são reprocessadas antes do upload.
+func jpegMedia() *Media {
+	data := encodeTestJPEG(64, 48, nil)
 	return &Media{
 		DeclaredMime: "image/jpeg",
-		Size:         size,
-		Reader:       io.MultiReader(bytes.NewReader(hdr), bytes.NewReader(body)),
+		Size:         int64(len(data)),
+		Reader:       bytes.NewReader(data),
 	}
 }
 
@@ -145,7 +301,7 @@ func assertAppError(t *testing.T, err error, wantCode int, wantMsg string) {
 // --- tests ---
 
 func TestCreate_RejectsUnauthenticated(t *testing.T) {
-	svc := NewService(&mockRepo{}, &mockTxFinder{}, nil, &mockAudit{}, time.Minute)
+	svc := NewService(&mockRepo{}, &mockTxFinder{}, nil, &mockAudit{}, time.Minute, ModerationAuto)
 	_, err := svc.Create(context.Background(), 1, 0, validInput(), nil)
 	assertAppError(t, err, http.StatusUnauthorized, "autenticação")
 }
@@ -155,7 +311,7 @@ func TestCreate_TransactionNotFound(t *testing.T) {
 		getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) {
 			return nil, apperror.NotFound("transaction not found")
 		},
-	}, nil, &mockAudit{}, time.Minute)
+	}, nil, &mockAudit{}, time.Minute, ModerationAuto)
 	_, err := svc.Create(context.Background(), 999, 42, validInput(), nil)
 	assertAppError(t, err, http.StatusNotFound, "transaction not found")
 }
@@ -167,7 +323,7 @@ func TestCreate_TransactionFromAnotherUser(t *testing.T) {
 			tx.UserID = 999
 			return tx, nil
 		},
-	}, nil, &mockAudit{}, time.Minute)
+	}, nil, &mockAudit{}, time.Minute, ModerationAuto)
 	_, err := svc.Create(context.Background(), 100, 42, validInput(), nil)
 	assertAppError(t, err, http.StatusForbidden, "não pertence")
 }
@@ -179,7 +335,7 @@ func TestCreate_TransactionPending(t *testing.T) {
 			tx.Status = "pending"
 			return tx, nil
 		},
-	}, nil, &mockAudit{}, time.Minute)
+	}, nil, &mockAudit{}, time.Minute, ModerationAuto)
 	_, err := svc.Create(context.Background(), 100, 42, validInput(), nil)
 	assertAppError(t, err, http.StatusConflict, "aguarde")
 }
@@ -187,7 +343,7 @@ func TestCreate_TransactionPending(t *testing.T) {
 func TestCreate_RejectsContentTooLong(t *testing.T) {
 	svc := NewService(&mockRepo{}, &mockTxFinder{
 		getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil },
-	}, nil, &mockAudit{}, time.Minute)
+	}, nil, &mockAudit{}, time.Minute, ModerationAuto)
 	in := validInput()
 	in.Content = strings.Repeat("a", 501)
 	_, err := svc.Create(context.Background(), 100, 42, in, nil)
@@ -202,7 +358,7 @@ func TestCreate_PreCheckFindsExistingMessage(t *testing.T) {
 	}
 	svc := NewService(repo, &mockTxFinder{
 		getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil },
-	}, nil, &mockAudit{}, time.Minute)
+	}, nil, &mockAudit{}, time.Minute, ModerationAuto)
 	_, err := svc.Create(context.Background(), 100, 42, validInput(), nil)
 	assertAppError(t, err, http.StatusConflict, "já existe")
 }
@@ -222,7 +378,7 @@ func TestCreate_HappyPathTextOnly(t *testing.T) {
 	audit := &mockAudit{}
 	svc := NewService(repo, &mockTxFinder{
 		getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil },
-	}, nil, audit, time.Minute)
+	}, nil, audit, time.Minute, ModerationAuto)
 	msg, err := svc.Create(context.Background(), 100, 42, validInput(), nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -260,8 +416,8 @@ func TestCreate_HappyPathWithMedia(t *testing.T) {
 	}
 	svc := NewService(repo, &mockTxFinder{
 		getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil },
-	}, storage, &mockAudit{}, time.Minute)
-	msg, err := svc.Create(context.Background(), 100, 42, validInput(), jpegMedia(2048))
+	}, storage, &mockAudit{}, time.Minute, ModerationAuto)
+	msg, err := svc.Create(context.Background(), 100, 42, validInput(), jpegMedia())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -280,7 +436,7 @@ func TestCreate_RejectsUnsupportedMime(t *testing.T) {
 	storage := &mockStorage{}
 	svc := NewService(&mockRepo{}, &mockTxFinder{
 		getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil },
-	}, storage, &mockAudit{}, time.Minute)
+	}, storage, &mockAudit{}, time.Minute, ModerationAuto)
 	pdfBody := []byte("%PDF-1.4 fake pdf content here")
 	media := &Media{
 		DeclaredMime: "application/pdf",
@@ -300,11 +456,11 @@ func TestCreate_DeletesObjectIfRepoCreateFails(t *testing.T) {
 	}
 	svc := NewService(repo, &mockTxFinder{
 		getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil },
-	}, storage, &mockAudit{}, time.Minute)
-	_, err := svc.Create(context.Background(), 100, 42, validInput(), jpegMedia(2048))
+	}, storage, &mockAudit{}, time.Minute, ModerationAuto)
+	_, err := svc.Create(context.Background(), 100, 42, validInput(), jpegMedia())
 	assertAppError(t, err, http.StatusConflict, "já existe")
-	if storage.deleteCalls != 1 {
-		t.Errorf("expected storage.Delete to be called once for orphan cleanup, got %d", storage.deleteCalls)
+	if storage.deleteCalls != 2 {
+		t.Errorf("expected storage.Delete for the image and its thumbnail, got %d", storage.deleteCalls)
 	}
 }
 
@@ -335,7 +491,7 @@ func TestCreate_HappyPath_MP3NoID3_AcceptedAsAudio(t *testing.T) {
 	storage := &mockStorage{}
 	svc := NewService(repo, &mockTxFinder{
 		getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil },
-	}, storage, &mockAudit{}, time.Minute)
+	}, storage, &mockAudit{}, time.Minute, ModerationAuto)
 
 	msg, err := svc.Create(context.Background(), 100, 42, validInput(), mp3NoID3Media(1024))
 	if err != nil {
@@ -359,7 +515,7 @@ func TestCreate_Rejects_DeclaredAudioMpeg_BytesAreHTML(t *testing.T) {
 	storage := &mockStorage{}
 	svc := NewService(&mockRepo{}, &mockTxFinder{
 		getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil },
-	}, storage, &mockAudit{}, time.Minute)
+	}, storage, &mockAudit{}, time.Minute, ModerationAuto)
 
 	_, err := svc.Create(context.Background(), 100, 42, validInput(), media)
 	assertAppError(t, err, http.StatusBadRequest, "tipo de mídia")
@@ -375,7 +531,7 @@ func TestCreate_Rejects_PDF(t *testing.T) {
 	storage := &mockStorage{}
 	svc := NewService(&mockRepo{}, &mockTxFinder{
 		getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil },
-	}, storage, &mockAudit{}, time.Minute)
+	}, storage, &mockAudit{}, time.Minute, ModerationAuto)
 
 	_, err := svc.Create(context.Background(), 100, 42, validInput(), media)
 	assertAppError(t, err, http.StatusBadRequest, "tipo de mídia")
@@ -396,8 +552,8 @@ func TestCreate_RejectsUploadFailure(t *testing.T) {
 	}
 	svc := NewService(repo, &mockTxFinder{
 		getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil },
-	}, storage, &mockAudit{}, time.Minute)
-	_, err := svc.Create(context.Background(), 100, 42, validInput(), jpegMedia(2048))
+	}, storage, &mockAudit{}, time.Minute, ModerationAuto)
+	_, err := svc.Create(context.Background(), 100, 42, validInput(), jpegMedia())
 	if err == nil {
 		t.Fatal("expected error from upload failure")
 	}
@@ -415,9 +571,9 @@ func TestCreate_StorageUploadFails_Returns503WithFriendlyMessage(t *testing.T) {
 	}
 	svc := NewService(&mockRepo{}, &mockTxFinder{
 		getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil },
-	}, storage, &mockAudit{}, time.Minute)
+	}, storage, &mockAudit{}, time.Minute, ModerationAuto)
 
-	_, err := svc.Create(context.Background(), 100, 42, validInput(), jpegMedia(2048))
+	_, err := svc.Create(context.Background(), 100, 42, validInput(), jpegMedia())
 
 	assertAppError(t, err, http.StatusServiceUnavailable, "Não foi possível enviar sua mídia")
 	ae, _ := apperror.IsAppError(err)
@@ -432,8 +588,8 @@ func TestCreate_StorageUploadFails_Returns503WithFriendlyMessage(t *testing.T) {
 func TestCreate_StorageDisabledRejectsMedia(t *testing.T) {
 	svc := NewService(&mockRepo{}, &mockTxFinder{
 		getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil },
-	}, nil, &mockAudit{}, time.Minute)
-	_, err := svc.Create(context.Background(), 100, 42, validInput(), jpegMedia(2048))
+	}, nil, &mockAudit{}, time.Minute, ModerationAuto)
+	_, err := svc.Create(context.Background(), 100, 42, validInput(), jpegMedia())
 	assertAppError(t, err, http.StatusServiceUnavailable, "indisponíveis")
 }
 
@@ -445,7 +601,7 @@ func TestGetMine_ReturnsNilWhenNoMessage(t *testing.T) {
 	}
 	svc := NewService(repo, &mockTxFinder{
 		getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil },
-	}, nil, &mockAudit{}, time.Minute)
+	}, nil, &mockAudit{}, time.Minute, ModerationAuto)
 	msg, err := svc.GetMine(context.Background(), 100, 42)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -462,7 +618,7 @@ func TestGetMine_RejectsAccessFromAnotherUser(t *testing.T) {
 			tx.UserID = 1
 			return tx, nil
 		},
-	}, nil, &mockAudit{}, time.Minute)
+	}, nil, &mockAudit{}, time.Minute, ModerationAuto)
 	_, err := svc.GetMine(context.Background(), 100, 42)
 	assertAppError(t, err, http.StatusForbidden, "não pertence")
 }
@@ -491,7 +647,7 @@ func TestListByGift_SignsMediaURLs(t *testing.T) {
 			return out, nil
 		},
 	}
-	svc := NewService(repo, &mockTxFinder{}, storage, &mockAudit{}, time.Minute)
+	svc := NewService(repo, &mockTxFinder{}, storage, &mockAudit{}, time.Minute, ModerationAuto)
 	resp, err := svc.ListByGift(context.Background(), 1, 1, 10)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -520,7 +676,7 @@ func TestRemove_RecordsAudit(t *testing.T) {
 		},
 	}
 	audit := &mockAudit{}
-	svc := NewService(repo, &mockTxFinder{}, nil, audit, time.Minute)
+	svc := NewService(repo, &mockTxFinder{}, nil, audit, time.Minute, ModerationAuto)
 	if err := svc.Remove(context.Background(), 7, 99); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -530,7 +686,7 @@ func TestRemove_RecordsAudit(t *testing.T) {
 }
 
 func TestRemove_RejectsUnauthenticated(t *testing.T) {
-	svc := NewService(&mockRepo{}, &mockTxFinder{}, nil, &mockAudit{}, time.Minute)
+	svc := NewService(&mockRepo{}, &mockTxFinder{}, nil, &mockAudit{}, time.Minute, ModerationAuto)
 	err := svc.Remove(context.Background(), 1, 0)
 	assertAppError(t, err, http.StatusUnauthorized, "autenticação")
 }
@@ -555,3 +711,286 @@ func TestNormalizePaging(t *testing.T) {
 }
 
 func ptr[T any](v T) *T { return &v }
+
+func TestRemove_AuditIncludesRequestID(t *testing.T) {
+	repo := &mockRepo{
+		softDeleteFn: func(_ context.Context, id, byUserID int64) error { return nil },
+	}
+	audit := &mockAudit{}
+	svc := NewService(repo, &mockTxFinder{}, nil, audit, time.Minute, ModerationAuto)
+
+	ctx := reqctx.WithRequestID(context.Background(), "req-msg-1")
+	if err := svc.Remove(ctx, 7, 99); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(audit.calls) != 1 || audit.calls[0].details["request_id"] != "req-msg-1" {
+		t.Fatalf("expected request_id in audit details, got %v", audit.calls)
+	}
+}
+
+func TestCreate_ModerationModes(t *testing.T) {
+	cases := []struct {
+		mode    ModerationMode
+		content string
+		want    string
+	}{
+		{ModerationAuto, "Que porra linda!", StatusApproved},
+		{ModerationAll, "Felicidades!", StatusPending},
+		{ModerationFlagged, "Felicidades!", StatusApproved},
+		{ModerationFlagged, "Que porra linda!", StatusPending},
+	}
+	for _, c := range cases {
+		var inserted CreateRow
+		repo := &mockRepo{
+			createFn: func(_ context.Context, in CreateRow) (*GiftMessage, error) {
+				inserted = in
+				return &GiftMessage{ID: 1, Status: in.Status, FlaggedTerms: in.FlaggedTerms}, nil
+			},
+		}
+		svc := NewService(repo, &mockTxFinder{
+			getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil },
+		}, nil, &mockAudit{}, time.Minute, c.mode)
+		in := validInput()
+		in.Content = c.content
+		if _, err := svc.Create(context.Background(), 100, 42, in, nil); err != nil {
+			t.Fatalf("%s/%q: unexpected error: %v", c.mode, c.content, err)
+		}
+		if inserted.Status != c.want {
+			t.Errorf("%s/%q: expected status %q, got %q", c.mode, c.content, c.want, inserted.Status)
+		}
+	}
+}
+
+func TestCreate_RecordsFlaggedTermsEvenWhenAutoApproved(t *testing.T) {
+	var inserted CreateRow
+	repo := &mockRepo{
+		createFn: func(_ context.Context, in CreateRow) (*GiftMessage, error) {
+			inserted = in
+			return &GiftMessage{ID: 1, Status: in.Status, FlaggedTerms: in.FlaggedTerms}, nil
+		},
+	}
+	svc := NewService(repo, &mockTxFinder{
+		getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil },
+	}, nil, &mockAudit{}, time.Minute, ModerationAuto)
+	in := validInput()
+	in.AuthorName = "Fulano Merda"
+	if _, err := svc.Create(context.Background(), 100, 42, in, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(inserted.FlaggedTerms) != 1 || inserted.FlaggedTerms[0] != "merda" {
+		t.Errorf("expected author name to be flagged, got %v", inserted.FlaggedTerms)
+	}
+}
+
+func TestGetMine_ShowsOwnPendingMessage(t *testing.T) {
+	repo := &mockRepo{
+		getByTxIDFn: func(_ context.Context, _ int64) (*GiftMessage, error) {
+			return &GiftMessage{ID: 7, UserID: 42, Content: "oi", Status: StatusPending}, nil
+		},
+	}
+	svc := NewService(repo, &mockTxFinder{
+		getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil },
+	}, nil, &mockAudit{}, time.Minute, ModerationAll)
+	msg, err := svc.GetMine(context.Background(), 100, 42)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msg == nil || msg.Status != StatusPending {
+		t.Fatalf("expected own pending message, got %+v", msg)
+	}
+}
+
+func TestModerationQueue_DefaultsToPending(t *testing.T) {
+	var gotStatus string
+	repo := &mockRepo{
+		listByStatFn: func(_ context.Context, status string, _, _ int) ([]GiftMessage, int, error) {
+			gotStatus = status
+			return []GiftMessage{{ID: 1, Status: status, FlaggedTerms: []string{"merda"}}}, 1, nil
+		},
+	}
+	svc := NewService(repo, &mockTxFinder{}, nil, &mockAudit{}, time.Minute, ModerationFlagged)
+	resp, err := svc.ModerationQueue(context.Background(), "", 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotStatus != StatusPending {
+		t.Errorf("expected pending queue, got %q", gotStatus)
+	}
+	if len(resp.Data) != 1 || len(resp.Data[0].FlaggedTerms) != 1 {
+		t.Errorf("expected flagged terms in admin view, got %+v", resp.Data)
+	}
+
+	_, err = svc.ModerationQueue(context.Background(), "deleted", 0, 0)
+	assertAppError(t, err, http.StatusBadRequest, "status")
+}
+
+func TestModerate_BulkRejectAuditsChangedOnly(t *testing.T) {
+	var gotIDs []int64
+	var gotStatus string
+	repo := &mockRepo{
+		setStatusFn: func(_ context.Context, ids []int64, status string, byUserID int64) ([]int64, error) {
+			gotIDs, gotStatus = ids, status
+			return []int64{1, 3}, nil
+		},
+	}
+	audit := &mockAudit{}
+	svc := NewService(repo, &mockTxFinder{}, nil, audit, time.Minute, ModerationFlagged)
+	res, err := svc.Moderate(context.Background(), ModerateInput{IDs: []int64{1, 2, 3, 1}, Action: "reject"}, 99)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotStatus != StatusRejected || len(gotIDs) != 3 {
+		t.Errorf("expected 3 deduplicated ids rejected, got %v %q", gotIDs, gotStatus)
+	}
+	if len(res.Updated) != 2 || len(res.Skipped) != 1 || res.Skipped[0] != 2 {
+		t.Errorf("unexpected result %+v", res)
+	}
+	if len(audit.calls) != 2 || audit.calls[0].action != auditMessageRejected {
+		t.Fatalf("expected 2 %q audits, got %v", auditMessageRejected, audit.calls)
+	}
+}
+
+func TestModerate_ValidatesAction(t *testing.T) {
+	svc := NewService(&mockRepo{}, &mockTxFinder{}, nil, &mockAudit{}, time.Minute, ModerationFlagged)
+	_, err := svc.Moderate(context.Background(), ModerateInput{IDs: []int64{1}, Action: "delete"}, 99)
+	assertAppError(t, err, http.StatusBadRequest, "Action")
+}
+
+func TestListByGift_HidesPrivateReplies(t *testing.T) {
+	repo := &mockRepo{
+		listByGiftFn: func(_ context.Context, _ int64, _, _ int) ([]GiftMessage, int, error) {
+			return []GiftMessage{{ID: 1, GiftID: 1}, {ID: 2, GiftID: 1}}, 2, nil
+		},
+		listRepliesFn: func(_ context.Context, ids []int64) ([]Reply, error) {
+			if len(ids) != 2 {
+				t.Errorf("expected replies loaded in one batch, got %v", ids)
+			}
+			return []Reply{
+				{ID: 10, MessageID: 1, Content: "Obrigado!", Visibility: ReplyPublic},
+				{ID: 11, MessageID: 1, Content: "só pra você", Visibility: ReplyPrivate},
+			}, nil
+		},
+		listReactionsFn: func(_ context.Context, _ []int64) ([]Reaction, error) {
+			return []Reaction{{ID: 20, MessageID: 2, Emoji: "❤️"}}, nil
+		},
+	}
+	svc := NewService(repo, &mockTxFinder{}, nil, &mockAudit{}, time.Minute, ModerationAuto)
+	resp, err := svc.ListByGift(context.Background(), 1, 1, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Data[0].Replies) != 1 || resp.Data[0].Replies[0].ID != 10 {
+		t.Errorf("expected only the public reply, got %+v", resp.Data[0].Replies)
+	}
+	if len(resp.Data[1].Replies) != 0 || resp.Data[1].Replies == nil {
+		t.Errorf("expected empty (non-nil) replies, got %v", resp.Data[1].Replies)
+	}
+	if len(resp.Data[1].Reactions) != 1 {
+		t.Errorf("expected reaction on message 2, got %+v", resp.Data[1].Reactions)
+	}
+}
+
+func TestGetMine_IncludesPrivateReplies(t *testing.T) {
+	repo := &mockRepo{
+		getByTxIDFn: func(_ context.Context, _ int64) (*GiftMessage, error) {
+			return &GiftMessage{ID: 7, UserID: 42, Status: StatusApproved}, nil
+		},
+		listRepliesFn: func(_ context.Context, _ []int64) ([]Reply, error) {
+			return []Reply{{ID: 11, MessageID: 7, Visibility: ReplyPrivate}}, nil
+		},
+	}
+	svc := NewService(repo, &mockTxFinder{
+		getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil },
+	}, nil, &mockAudit{}, time.Minute, ModerationAuto)
+	msg, err := svc.GetMine(context.Background(), 100, 42)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(msg.Replies) != 1 {
+		t.Fatalf("expected the private reply for the author, got %+v", msg.Replies)
+	}
+}
+
+func TestCreateReply_DefaultsToPublicAndAudits(t *testing.T) {
+	var got ReplyInput
+	repo := &mockRepo{
+		getByIDFn: func(_ context.Context, id int64) (*GiftMessage, error) { return &GiftMessage{ID: id}, nil },
+		createReplyFn: func(_ context.Context, messageID, userID int64, in ReplyInput) (*Reply, error) {
+			got = in
+			return &Reply{ID: 3, MessageID: messageID, UserID: userID, Content: in.Content, Visibility: in.Visibility}, nil
+		},
+	}
+	audit := &mockAudit{}
+	svc := NewService(repo, &mockTxFinder{}, nil, audit, time.Minute, ModerationAuto)
+	if _, err := svc.CreateReply(context.Background(), 7, 99, ReplyInput{Content: "Valeu!"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Visibility != ReplyPublic {
+		t.Errorf("expected public by default, got %q", got.Visibility)
+	}
+	if len(audit.calls) != 1 || audit.calls[0].action != auditReplyCreated || audit.calls[0].details["reply_id"] != int64(3) {
+		t.Fatalf("expected %q audit, got %v", auditReplyCreated, audit.calls)
+	}
+}
+
+func TestCreateReply_MessageNotFound(t *testing.T) {
+	repo := &mockRepo{
+		getByIDFn: func(_ context.Context, _ int64) (*GiftMessage, error) {
+			return nil, apperror.NotFound("mensagem não encontrada")
+		},
+	}
+	svc := NewService(repo, &mockTxFinder{}, nil, &mockAudit{}, time.Minute, ModerationAuto)
+	_, err := svc.CreateReply(context.Background(), 7, 99, ReplyInput{Content: "oi", Visibility: ReplyPrivate})
+	assertAppError(t, err, http.StatusNotFound, "mensagem")
+}
+
+func TestAddReaction_AuditsOnlyNewReactions(t *testing.T) {
+	created := true
+	repo := &mockRepo{
+		getByIDFn: func(_ context.Context, id int64) (*GiftMessage, error) { return &GiftMessage{ID: id}, nil },
+		addReactionFn: func(_ context.Context, messageID, userID int64, emoji string) (*Reaction, bool, error) {
+			return &Reaction{ID: 5, MessageID: messageID, UserID: userID, Emoji: emoji}, created, nil
+		},
+	}
+	audit := &mockAudit{}
+	svc := NewService(repo, &mockTxFinder{}, nil, audit, time.Minute, ModerationAuto)
+	if _, _, err := svc.AddReaction(context.Background(), 7, 99, ReactionInput{Emoji: "❤️"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	created = false
+	if _, _, err := svc.AddReaction(context.Background(), 7, 99, ReactionInput{Emoji: "❤️"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(audit.calls) != 1 || audit.calls[0].action != auditReactionAdded {
+		t.Fatalf("expected a single %q audit, got %v", auditReactionAdded, audit.calls)
+	}
+}
+
+func TestRemoveReaction_Audits(t *testing.T) {
+	repo := &mockRepo{
+		deleteReactionFn: func(_ context.Context, id int64) (*Reaction, error) {
+			return &Reaction{ID: id, MessageID: 7, Emoji: "🎉"}, nil
+		},
+	}
+	audit := &mockAudit{}
+	svc := NewService(repo, &mockTxFinder{}, nil, audit, time.Minute, ModerationAuto)
+	if err := svc.RemoveReaction(context.Background(), 5, 99); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(audit.calls) != 1 || audit.calls[0].action != auditReactionRemoved || audit.calls[0].details["message_id"] != int64(7) {
+		t.Fatalf("expected %q audit on message 7, got %v", auditReactionRemoved, audit.calls)
+	}
+}
+
+func TestValidEmoji(t *testing.T) {
+	for _, ok := range []string{"❤️", "❤", "👍🏽", "🎉", "👨‍👩‍👧", "🇧🇷"} {
+		if !validEmoji(ok) {
+			t.Errorf("expected %q to be accepted", ok)
+		}
+	}
+	for _, bad := range []string{"", "a", "ok", "1", " ", "^^", "❤ ", "🎉🎉🎉🎉🎉🎉🎉🎉🎉"} {
+		if validEmoji(bad) {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
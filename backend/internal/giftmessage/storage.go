@@ -2,7 +2,12 @@ package giftmessage
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,3 +16,50 @@ type Storage interface {
 	SignURLs(ctx context.Context, keys []string, ttl time.Duration) (map[string]string, error)
 	Delete(ctx context.Context, key string) error
 }
+
+// DirectUploader is implemented by storages that let the browser upload
+// straight to them. PresignUpload returns a URL accepting a single PUT of the
+// object body; ReadPrefix reports what actually landed so the server can
+// check it before linking the object to a message.
+type DirectUploader interface {
+	PresignUpload(ctx context.Context, key, mime string, ttl time.Duration) (string, error)
+	ReadPrefix(ctx context.Context, key string, n int) (prefix []byte, size int64, err error)
+}
+
+// ErrObjectNotFound is returned by ReadPrefix when nothing was uploaded.
+var ErrObjectNotFound = errors.New("storage: object not found")
+
+// readRangeResponse extracts the first n bytes and the full object size
+// from the answer to a "Range: bytes=0-{n-1}" GET. Servers that ignore Range
+// reply 200 with the whole object; an empty object yields 416.
+func readRangeResponse(resp *http.Response, n int, label string) ([]byte, int64, error) {
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, 0, ErrObjectNotFound
+	case http.StatusRequestedRangeNotSatisfiable:
+		return nil, 0, nil
+	case http.StatusOK, http.StatusPartialContent:
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, 0, fmt.Errorf("%s: read status %d: %s", label, resp.StatusCode, string(body))
+	}
+
+	prefix, err := io.ReadAll(io.LimitReader(resp.Body, int64(n)))
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: read body: %w", label, err)
+	}
+	size := resp.ContentLength
+	if resp.StatusCode == http.StatusPartialContent {
+		// Content-Range: bytes 0-511/123456
+		cr := resp.Header.Get("Content-Range")
+		i := strings.LastIndex(cr, "/")
+		if i < 0 {
+			return nil, 0, fmt.Errorf("%s: missing Content-Range total", label)
+		}
+		size, err = strconv.ParseInt(cr[i+1:], 10, 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: invalid Content-Range %q", label, cr)
+		}
+	}
+	return prefix, size, nil
+}
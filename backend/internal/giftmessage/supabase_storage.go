@@ -102,7 +102,7 @@ func (s *SupabaseStorage) SignURLs(ctx context.Context, keys []string, ttl time.
 	}
 	for _, it := range items {
 		if it.SignedURL == "" {
-			slog.Warn("supabase storage: sign returned empty URL", "path", it.Path, "error", it.Error, "message", it.ErrorMessage)
+			slog.WarnContext(ctx, "supabase storage: sign returned empty URL", "path", it.Path, "error", it.Error, "message", it.ErrorMessage)
 			continue
 		}
 		// signedURL vem como /object/sign/{bucket}/{path}?token=...
@@ -130,6 +130,58 @@ func (s *SupabaseStorage) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+// PresignUpload asks Supabase for a signed upload URL; the browser PUTs the
+// file body to it without any other credential.
+func (s *SupabaseStorage) PresignUpload(ctx context.Context, key, mime string, ttl time.Duration) (string, error) {
+	endpoint := fmt.Sprintf("%s/storage/v1/object/upload/sign/%s/%s", s.baseURL, s.bucket, escapeKey(key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("supabase storage: build upload sign request: %w", err)
+	}
+	s.setAuthHeaders(req)
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("supabase storage: upload sign http error: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("supabase storage: upload sign status %d: %s", resp.StatusCode, string(body))
+	}
+	var out struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil || out.URL == "" {
+		return "", fmt.Errorf("supabase storage: decode upload sign response: %v", err)
+	}
+	// url vem como /object/upload/sign/{bucket}/{path}?token=...
+	return s.baseURL + "/storage/v1" + out.URL, nil
+}
+
+func (s *SupabaseStorage) ReadPrefix(ctx context.Context, key string, n int) ([]byte, int64, error) {
+	endpoint := fmt.Sprintf("%s/storage/v1/object/authenticated/%s/%s", s.baseURL, s.bucket, escapeKey(key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("supabase storage: build read request: %w", err)
+	}
+	s.setAuthHeaders(req)
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", n-1))
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("supabase storage: read http error: %w", err)
+	}
+	defer resp.Body.Close()
+	// Storage answers a missing object with 400 {"statusCode":"404",...}.
+	if resp.StatusCode == http.StatusBadRequest {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if strings.Contains(string(body), "not_found") || strings.Contains(string(body), `"404"`) {
+			return nil, 0, ErrObjectNotFound
+		}
+		return nil, 0, fmt.Errorf("supabase storage: read status %d: %s", resp.StatusCode, string(body))
+	}
+	return readRangeResponse(resp, n, "supabase storage")
+}
+
 func (s *SupabaseStorage) BucketExists(ctx context.Context) error {
 	endpoint := fmt.Sprintf("%s/storage/v1/bucket/%s", s.baseURL, s.bucket)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
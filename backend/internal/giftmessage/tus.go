@@ -0,0 +1,483 @@
+package giftmessage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/httputil"
+	"github.com/ferjunior7/parasempre/backend/internal/middleware"
+)
+
+const (
+	TusVersion = "1.0.0"
+	// ResumableUploadRoute is the tus creation endpoint; uploads live at
+	// {ResumableUploadRoute}/{uploadID}.
+	ResumableUploadRoute = "/api/uploads/tus"
+
+	tusContentType = "application/offset+octet-stream"
+	tusExtensions  = "creation,termination,expiration"
+
+	resumableUploadTTL        = 24 * time.Hour
+	maxActiveResumableUploads = 3
+	maxResumableBytesPerUser  = 2 * maxVideoBytes
+)
+
+// ResumableUploads serves the tus 1.0.0 core protocol with the creation,
+// termination and expiration extensions, so guests on weak connections can
+// resume a video upload instead of starting over. Chunks are kept in dir on
+// this server; a finished file goes through the same MIME checks as a
+// multipart upload and is handed to the Service's Storage. The message is
+// then created with upload_id referencing it.
+//
+// Chunks are local to the process, so with several replicas the tus routes
+// need sticky sessions.
+type ResumableUploads struct {
+	svc *Service
+	dir string
+
+	mu   sync.Mutex
+	busy map[string]bool
+}
+
+func NewResumableUploads(svc *Service, dir string) *ResumableUploads {
+	return &ResumableUploads{svc: svc, dir: dir, busy: map[string]bool{}}
+}
+
+// EnsureDir creates the chunk directory so a misconfigured path fails at
+// startup instead of on the first upload.
+func (u *ResumableUploads) EnsureDir() error {
+	if err := os.MkdirAll(u.dir, 0o750); err != nil {
+		return fmt.Errorf("resumable uploads: create dir %q: %w", u.dir, err)
+	}
+	return nil
+}
+
+// HandleCreate answers POST {ResumableUploadRoute}. The transaction comes
+// from the transaction_id entry of Upload-Metadata; filetype, when sent, is
+// the declared MIME type.
+func (u *ResumableUploads) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	if !checkTusResumable(w, r) {
+		return
+	}
+	userID := middleware.UserIDFromContext(r.Context())
+	if userID == 0 {
+		httputil.WriteError(w, r, apperror.Unauthorized("autenticação obrigatória"))
+		return
+	}
+
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length <= 0 {
+		httputil.WriteError(w, r, apperror.Validation("Upload-Length inválido"))
+		return
+	}
+	if length > maxVideoBytes {
+		httputil.WriteErrorMsg(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("arquivo excede o limite de %d MB", maxVideoBytes/(1024*1024)))
+		return
+	}
+	meta := parseTusMetadata(r.Header.Get("Upload-Metadata"))
+	txID, err := strconv.ParseInt(meta["transaction_id"], 10, 64)
+	if err != nil || txID <= 0 {
+		httputil.WriteError(w, r, apperror.Validation("Upload-Metadata deve conter transaction_id"))
+		return
+	}
+
+	up, err := u.create(r.Context(), txID, userID, length, normalizeMime(meta["filetype"]))
+	if err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", ResumableUploadRoute+"/"+up.ID)
+	w.Header().Set("Upload-Expires", up.ExpiresAt.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// HandleOptions answers OPTIONS {ResumableUploadRoute}, the tus discovery
+// request: it needs no session or Tus-Resumable header and reports what this
+// server supports.
+func (u *ResumableUploads) HandleOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", TusVersion)
+	w.Header().Set("Tus-Version", TusVersion)
+	w.Header().Set("Tus-Extension", tusExtensions)
+	w.Header().Set("Tus-Max-Size", strconv.FormatInt(maxVideoBytes, 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (u *ResumableUploads) HandleHead(w http.ResponseWriter, r *http.Request) {
+	if !checkTusResumable(w, r) {
+		return
+	}
+	up, ok := u.lookup(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Upload-Offset", strconv.FormatInt(up.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(up.Length, 10))
+	w.Header().Set("Upload-Expires", up.ExpiresAt.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandlePatch appends the body at Upload-Offset. Bytes received before a
+// dropped connection are kept, which is what makes the upload resumable.
+// The chunk that completes the file triggers validation and the hand-off to
+// storage; if storage fails, an empty PATCH at the final offset retries it.
+func (u *ResumableUploads) HandlePatch(w http.ResponseWriter, r *http.Request) {
+	if !checkTusResumable(w, r) {
+		return
+	}
+	if r.Header.Get("Content-Type") != tusContentType {
+		httputil.WriteErrorMsg(w, http.StatusUnsupportedMediaType, "Content-Type deve ser "+tusContentType)
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		httputil.WriteError(w, r, apperror.Validation("Upload-Offset inválido"))
+		return
+	}
+	up, ok := u.lookup(w, r)
+	if !ok {
+		return
+	}
+	if !u.lock(up.ID) {
+		httputil.WriteErrorMsg(w, http.StatusLocked, "outro envio deste arquivo está em andamento")
+		return
+	}
+	defer u.unlock(up.ID)
+
+	// Re-read under the lock: the offset may have moved since lookup.
+	up, err = u.svc.repo.GetResumableUpload(r.Context(), up.ID)
+	if err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	if offset != up.Offset {
+		httputil.WriteError(w, r, apperror.Conflict("Upload-Offset não corresponde ao recebido"))
+		return
+	}
+
+	if up.CompletedAt == nil && up.Offset < up.Length {
+		n, copyErr := u.appendChunk(up, r.Body)
+		if n > 0 {
+			if err := u.svc.repo.SetResumableUploadOffset(r.Context(), up.ID, up.Offset+n); err != nil {
+				httputil.WriteError(w, r, err)
+				return
+			}
+			up.Offset += n
+		}
+		if copyErr != nil {
+			slog.WarnContext(r.Context(), "giftmessage.tus patch: chunk interrupted",
+				"upload_id", up.ID, "offset", up.Offset, "error", copyErr)
+			httputil.WriteError(w, r, apperror.Validation("envio interrompido, retome a partir de Upload-Offset"))
+			return
+		}
+	}
+
+	if up.CompletedAt == nil && up.Offset == up.Length {
+		if err := u.finish(r.Context(), up); err != nil {
+			httputil.WriteError(w, r, err)
+			return
+		}
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(up.Offset, 10))
+	w.Header().Set("Upload-Expires", up.ExpiresAt.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleDelete implements the termination extension.
+func (u *ResumableUploads) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	if !checkTusResumable(w, r) {
+		return
+	}
+	up, ok := u.lookup(w, r)
+	if !ok {
+		return
+	}
+	if !u.lock(up.ID) {
+		httputil.WriteErrorMsg(w, http.StatusLocked, "outro envio deste arquivo está em andamento")
+		return
+	}
+	defer u.unlock(up.ID)
+
+	if err := u.discard(r.Context(), *up); err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (u *ResumableUploads) create(ctx context.Context, txID, userID int64, length int64, declared string) (*ResumableUpload, error) {
+	if u.svc.storage == nil {
+		return nil, apperror.ServiceUnavailable("Mensagens com mídia indisponíveis neste ambiente.")
+	}
+	if declared != "" {
+		spec, err := ValidateMedia(declared, length)
+		if err != nil {
+			return nil, err
+		}
+		if spec.kind == MediaKindImage {
+			return nil, apperror.Validation("fotos devem ser enviadas junto com a mensagem")
+		}
+	}
+	tx, err := u.svc.messageableTx(ctx, txID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	count, used, err := u.svc.repo.ResumableUploadUsage(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxActiveResumableUploads || used+length > maxResumableBytesPerUser {
+		return nil, apperror.TooManyRequests("limite de envios em andamento atingido, conclua ou cancele um envio")
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, apperror.Internal("falha ao gerar id de envio", err)
+	}
+	up, err := u.svc.repo.CreateResumableUpload(ctx, ResumableUpload{
+		ID:                hex.EncodeToString(buf),
+		GiftTransactionID: tx.ID,
+		UserID:            userID,
+		Length:            length,
+		DeclaredMime:      declared,
+		ExpiresAt:         time.Now().Add(resumableUploadTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(u.chunkPath(up.ID), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		_ = u.svc.repo.DeleteResumableUpload(ctx, up.ID)
+		return nil, apperror.Internal("falha ao preparar envio", err)
+	}
+	f.Close()
+
+	slog.InfoContext(ctx, "giftmessage.tus create: done",
+		"upload_id", up.ID, "tx_id", tx.ID, "user_id", userID, "length", length)
+	return up, nil
+}
+
+// lookup loads the upload named in the path for the authenticated user,
+// writing the error response when it cannot be used.
+func (u *ResumableUploads) lookup(w http.ResponseWriter, r *http.Request) (*ResumableUpload, bool) {
+	userID := middleware.UserIDFromContext(r.Context())
+	if userID == 0 {
+		httputil.WriteError(w, r, apperror.Unauthorized("autenticação obrigatória"))
+		return nil, false
+	}
+	up, err := u.svc.repo.GetResumableUpload(r.Context(), r.PathValue("uploadID"))
+	if err != nil {
+		httputil.WriteError(w, r, err)
+		return nil, false
+	}
+	if up.UserID != userID {
+		httputil.WriteError(w, r, apperror.NotFound("envio não encontrado"))
+		return nil, false
+	}
+	if !up.ExpiresAt.After(time.Now()) {
+		httputil.WriteErrorMsg(w, http.StatusGone, "envio expirado")
+		return nil, false
+	}
+	return up, true
+}
+
+func (u *ResumableUploads) appendChunk(up *ResumableUpload, body io.Reader) (int64, error) {
+	f, err := os.OpenFile(u.chunkPath(up.ID), os.O_WRONLY, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	// The file may hold bytes past the recorded offset if a previous PATCH
+	// died between the write and the offset update.
+	if err := f.Truncate(up.Offset); err != nil {
+		return 0, err
+	}
+	if _, err := f.Seek(up.Offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, io.LimitReader(body, up.Length-up.Offset))
+	if syncErr := f.Sync(); err == nil {
+		err = syncErr
+	}
+	return n, err
+}
+
+// finish validates the assembled file and moves it to storage. A file that
+// fails validation is discarded with its upload; a storage failure keeps
+// both so the client can retry.
+func (u *ResumableUploads) finish(ctx context.Context, up *ResumableUpload) error {
+	f, err := os.Open(u.chunkPath(up.ID))
+	if err != nil {
+		return apperror.Internal("falha ao ler envio", err)
+	}
+	defer f.Close()
+
+	mime, peeked, err := resolveMediaMIME(up.DeclaredMime, f)
+	if err == nil {
+		var spec mediaSpec
+		spec, err = ValidateMedia(mime, up.Length)
+		if err == nil && spec.kind == MediaKindImage {
+			err = apperror.Validation("fotos devem ser enviadas junto com a mensagem")
+		}
+		if err == nil {
+			return u.store(ctx, up, spec, mime, peeked)
+		}
+	}
+	slog.WarnContext(ctx, "giftmessage.tus finish: rejected file", "upload_id", up.ID, "error", err)
+	if discardErr := u.discard(ctx, *up); discardErr != nil {
+		slog.ErrorContext(ctx, "giftmessage.tus finish: discard failed", "upload_id", up.ID, "error", discardErr)
+	}
+	return err
+}
+
+func (u *ResumableUploads) store(ctx context.Context, up *ResumableUpload, spec mediaSpec, mime string, r io.Reader) error {
+	key, err := buildObjectKey(up.GiftTransactionID, spec.ext)
+	if err != nil {
+		return apperror.Internal("falha ao gerar chave de mídia", err)
+	}
+	if err := u.svc.upload(ctx, key, mime, r, up.Length); err != nil {
+		return err
+	}
+	if err := u.svc.repo.CompleteResumableUpload(ctx, up.ID, key, spec.kind, mime); err != nil {
+		u.svc.deleteObjects(ctx, []string{key})
+		return err
+	}
+	if err := os.Remove(u.chunkPath(up.ID)); err != nil {
+		slog.ErrorContext(ctx, "giftmessage.tus finish: chunk cleanup failed", "upload_id", up.ID, "error", err)
+	}
+	now := time.Now()
+	up.ObjectKey, up.MediaKind, up.MimeType, up.CompletedAt = &key, &spec.kind, &mime, &now
+
+	slog.InfoContext(ctx, "giftmessage.tus finish: stored",
+		"upload_id", up.ID, "tx_id", up.GiftTransactionID, "key", key, "size", up.Length)
+	return nil
+}
+
+// discard removes the chunk file, the stored object of a finished upload,
+// and the row. The row stays if the object cannot be deleted, so the
+// sweeper retries.
+func (u *ResumableUploads) discard(ctx context.Context, up ResumableUpload) error {
+	if err := os.Remove(u.chunkPath(up.ID)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return apperror.Internal("falha ao remover envio", err)
+	}
+	if up.ObjectKey != nil && u.svc.storage != nil {
+		if err := u.svc.storage.Delete(context.Background(), *up.ObjectKey); err != nil {
+			return apperror.Internal("falha ao remover mídia enviada", err)
+		}
+	}
+	return u.svc.repo.DeleteResumableUpload(ctx, up.ID)
+}
+
+// Sweep removes expired uploads, finished or not.
+func (u *ResumableUploads) Sweep(ctx context.Context) (int, error) {
+	ups, err := u.svc.repo.ListExpiredResumableUploads(ctx, sweepBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	swept := 0
+	for _, up := range ups {
+		if !u.lock(up.ID) {
+			continue
+		}
+		err := u.discard(ctx, up)
+		u.unlock(up.ID)
+		if err != nil {
+			slog.ErrorContext(ctx, "giftmessage.tus sweep: discard failed", "upload_id", up.ID, "error", err)
+			continue
+		}
+		swept++
+	}
+	return swept, nil
+}
+
+func (u *ResumableUploads) RunSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			sweepCtx, cancel := context.WithTimeout(ctx, time.Minute)
+			n, err := u.Sweep(sweepCtx)
+			cancel()
+			if err != nil {
+				slog.Error("giftmessage.tus sweeper failed", "error", err)
+				continue
+			}
+			if n > 0 {
+				slog.Info("giftmessage.tus sweeper removed expired uploads", "count", n)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (u *ResumableUploads) chunkPath(id string) string {
+	return filepath.Join(u.dir, id+".part")
+}
+
+func (u *ResumableUploads) lock(id string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.busy[id] {
+		return false
+	}
+	u.busy[id] = true
+	return true
+}
+
+func (u *ResumableUploads) unlock(id string) {
+	u.mu.Lock()
+	delete(u.busy, id)
+	u.mu.Unlock()
+}
+
+// checkTusResumable enforces the protocol version header and sets it on
+// the response, as every tus response must carry it.
+func checkTusResumable(w http.ResponseWriter, r *http.Request) bool {
+	w.Header().Set("Tus-Resumable", TusVersion)
+	if r.Header.Get("Tus-Resumable") != TusVersion {
+		w.Header().Set("Tus-Version", TusVersion)
+		httputil.WriteErrorMsg(w, http.StatusPreconditionFailed, "versão do protocolo tus não suportada")
+		return false
+	}
+	return true
+}
+
+// parseTusMetadata decodes "key base64value,key2 base64value2". Entries
+// that are not valid base64 are dropped.
+func parseTusMetadata(header string) map[string]string {
+	out := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			continue
+		}
+		out[key] = string(value)
+	}
+	return out
+}
@@ -0,0 +1,289 @@
+package giftmessage
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/auth"
+	"github.com/ferjunior7/parasempre/backend/internal/middleware"
+)
+
+type tusHarness struct {
+	t       *testing.T
+	repo    *mockRepo
+	storage *mockStorage
+	uploads *ResumableUploads
+	mux     *http.ServeMux
+	stored  map[string][]byte
+}
+
+func newTusHarness(t *testing.T) *tusHarness {
+	t.Helper()
+	h := &tusHarness{t: t, stored: map[string][]byte{}}
+	h.repo = &mockRepo{
+		createFn: func(_ context.Context, in CreateRow) (*GiftMessage, error) {
+			return &GiftMessage{
+				ID: 1, GiftTransactionID: in.GiftTransactionID, GiftID: in.GiftID, UserID: in.UserID,
+				MediaObjectKey: in.MediaObjectKey, MediaKind: in.MediaKind,
+				MediaSizeBytes: in.MediaSizeBytes, MediaMimeType: in.MediaMimeType,
+			}, nil
+		},
+	}
+	h.storage = &mockStorage{uploadFn: func(_ context.Context, key, _ string, r io.Reader, _ int64) error {
+		b, err := io.ReadAll(r)
+		h.stored[key] = b
+		return err
+	}}
+	txns := &mockTxFinder{getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil }}
+	svc := NewService(h.repo, txns, h.storage, &mockAudit{}, time.Minute, ModerationAuto)
+	h.uploads = NewResumableUploads(svc, t.TempDir())
+
+	h.mux = http.NewServeMux()
+	h.mux.HandleFunc("OPTIONS "+ResumableUploadRoute, h.uploads.HandleOptions)
+	h.mux.HandleFunc("POST "+ResumableUploadRoute, h.uploads.HandleCreate)
+	h.mux.HandleFunc("HEAD "+ResumableUploadRoute+"/{uploadID}", h.uploads.HandleHead)
+	h.mux.HandleFunc("PATCH "+ResumableUploadRoute+"/{uploadID}", h.uploads.HandlePatch)
+	h.mux.HandleFunc("DELETE "+ResumableUploadRoute+"/{uploadID}", h.uploads.HandleDelete)
+	return h
+}
+
+func (h *tusHarness) do(method, target string, body io.Reader, userID int64, headers map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, body)
+	r.Header.Set("Tus-Resumable", TusVersion)
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	claims := &auth.Claims{UserID: userID, URACF: "ABC12", Role: "guest"}
+	r = r.WithContext(middleware.WithClaims(r.Context(), claims))
+	w := httptest.NewRecorder()
+	h.mux.ServeHTTP(w, r)
+	return w
+}
+
+func (h *tusHarness) create(length int, filetype string) string {
+	h.t.Helper()
+	meta := "transaction_id " + base64.StdEncoding.EncodeToString([]byte("100"))
+	if filetype != "" {
+		meta += ",filetype " + base64.StdEncoding.EncodeToString([]byte(filetype))
+	}
+	w := h.do(http.MethodPost, ResumableUploadRoute, nil, 42, map[string]string{
+		"Upload-Length":   strconv.Itoa(length),
+		"Upload-Metadata": meta,
+	})
+	if w.Code != http.StatusCreated {
+		h.t.Fatalf("create: status %d body %s", w.Code, w.Body.String())
+	}
+	loc := w.Header().Get("Location")
+	if !strings.HasPrefix(loc, ResumableUploadRoute+"/") || w.Header().Get("Upload-Expires") == "" {
+		h.t.Fatalf("create: unexpected headers %v", w.Header())
+	}
+	return loc
+}
+
+func (h *tusHarness) patch(loc string, offset int, chunk []byte) *httptest.ResponseRecorder {
+	return h.do(http.MethodPatch, loc, bytes.NewReader(chunk), 42, map[string]string{
+		"Content-Type":  tusContentType,
+		"Upload-Offset": strconv.Itoa(offset),
+	})
+}
+
+// failingReader delivers n bytes and then errors, like a dropped connection.
+type failingReader struct {
+	data []byte
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if len(f.data) == 0 {
+		return 0, errors.New("connection reset")
+	}
+	n := copy(p, f.data)
+	f.data = f.data[n:]
+	return n, nil
+}
+
+func TestTus_ResumesAfterDroppedChunkAndStoresFile(t *testing.T) {
+	h := newTusHarness(t)
+	file := mp4Bytes(4096)
+	loc := h.create(len(file), "video/mp4")
+
+	w := h.do(http.MethodPatch, loc, &failingReader{data: file[:1500]}, 42, map[string]string{
+		"Content-Type": tusContentType, "Upload-Offset": "0",
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("interrupted patch: status %d", w.Code)
+	}
+
+	w = h.do(http.MethodHead, loc, nil, 42, nil)
+	if w.Code != http.StatusOK || w.Header().Get("Upload-Offset") != "1500" || w.Header().Get("Upload-Length") != "4096" {
+		t.Fatalf("head after drop: %d %v", w.Code, w.Header())
+	}
+
+	if w := h.patch(loc, 0, file); w.Code != http.StatusConflict {
+		t.Fatalf("stale offset: status %d", w.Code)
+	}
+	w = h.patch(loc, 1500, file[1500:])
+	if w.Code != http.StatusNoContent || w.Header().Get("Upload-Offset") != "4096" {
+		t.Fatalf("final patch: %d %v %s", w.Code, w.Header(), w.Body.String())
+	}
+
+	id := strings.TrimPrefix(loc, ResumableUploadRoute+"/")
+	up := h.repo.resumable[id]
+	if up.CompletedAt == nil || up.ObjectKey == nil || *up.MediaKind != MediaKindVideo {
+		t.Fatalf("upload not completed: %+v", up)
+	}
+	if !bytes.Equal(h.stored[*up.ObjectKey], file) {
+		t.Fatal("stored object differs from the uploaded file")
+	}
+	if _, err := os.Stat(h.uploads.chunkPath(id)); !os.IsNotExist(err) {
+		t.Error("chunk file should be removed after completion")
+	}
+
+	in := validInput()
+	in.UploadID = id
+	msg, err := h.uploads.svc.Create(context.Background(), 100, 42, in, nil)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if *msg.MediaObjectKey != *up.ObjectKey || *msg.MediaSizeBytes != 4096 {
+		t.Fatalf("media not linked: %+v", msg)
+	}
+	if _, ok := h.repo.resumable[id]; ok {
+		t.Error("upload should be consumed by the message")
+	}
+}
+
+func TestTus_RejectsInvalidFileAndDiscardsIt(t *testing.T) {
+	h := newTusHarness(t)
+	file := []byte("<html><script>alert(1)</script></html>")
+	loc := h.create(len(file), "video/mp4")
+
+	w := h.patch(loc, 0, file)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d body %s", w.Code, w.Body.String())
+	}
+	if len(h.repo.resumable) != 0 || len(h.stored) != 0 {
+		t.Fatal("rejected upload should be discarded and never stored")
+	}
+}
+
+func TestTus_ProtocolChecks(t *testing.T) {
+	h := newTusHarness(t)
+
+	r := httptest.NewRequest(http.MethodPost, ResumableUploadRoute, nil)
+	w := httptest.NewRecorder()
+	h.mux.ServeHTTP(w, r)
+	if w.Code != http.StatusPreconditionFailed || w.Header().Get("Tus-Version") != TusVersion {
+		t.Fatalf("missing Tus-Resumable: %d %v", w.Code, w.Header())
+	}
+
+	w = h.do(http.MethodPost, ResumableUploadRoute, nil, 42, map[string]string{
+		"Upload-Length":   strconv.FormatInt(maxVideoBytes+1, 10),
+		"Upload-Metadata": "transaction_id " + base64.StdEncoding.EncodeToString([]byte("100")),
+	})
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversize: status %d", w.Code)
+	}
+
+	w = h.do(http.MethodPost, ResumableUploadRoute, nil, 42, map[string]string{"Upload-Length": "10"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("missing transaction_id: status %d", w.Code)
+	}
+
+	loc := h.create(10, "")
+	w = h.do(http.MethodPatch, loc, strings.NewReader("x"), 42, map[string]string{"Upload-Offset": "0"})
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("wrong content type: status %d", w.Code)
+	}
+	if w := h.do(http.MethodHead, loc, nil, 7, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("another user's upload: status %d", w.Code)
+	}
+}
+
+func TestTus_Discovery(t *testing.T) {
+	h := newTusHarness(t)
+
+	r := httptest.NewRequest(http.MethodOptions, ResumableUploadRoute, nil)
+	w := httptest.NewRecorder()
+	h.mux.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status %d", w.Code)
+	}
+	want := map[string]string{
+		"Tus-Resumable": TusVersion,
+		"Tus-Version":   TusVersion,
+		"Tus-Extension": "creation,termination,expiration",
+		"Tus-Max-Size":  strconv.FormatInt(maxVideoBytes, 10),
+	}
+	for k, v := range want {
+		if got := w.Header().Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestTus_PerUserQuota(t *testing.T) {
+	h := newTusHarness(t)
+	for i := 0; i < maxActiveResumableUploads; i++ {
+		h.create(1024, "")
+	}
+	w := h.do(http.MethodPost, ResumableUploadRoute, nil, 42, map[string]string{
+		"Upload-Length":   "1024",
+		"Upload-Metadata": "transaction_id " + base64.StdEncoding.EncodeToString([]byte("100")),
+	})
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("quota: status %d", w.Code)
+	}
+}
+
+func TestTus_TerminationAndSweep(t *testing.T) {
+	h := newTusHarness(t)
+	loc := h.create(10, "")
+	if w := h.do(http.MethodDelete, loc, nil, 42, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("delete: status %d", w.Code)
+	}
+	if len(h.repo.resumable) != 0 {
+		t.Fatal("terminated upload should be removed")
+	}
+
+	file := mp4Bytes(64)
+	loc = h.create(len(file), "video/mp4")
+	if w := h.patch(loc, 0, file); w.Code != http.StatusNoContent {
+		t.Fatalf("patch: status %d", w.Code)
+	}
+	id := strings.TrimPrefix(loc, ResumableUploadRoute+"/")
+	h.repo.resumable[id].ExpiresAt = time.Now().Add(-time.Second)
+
+	if w := h.do(http.MethodHead, loc, nil, 42, nil); w.Code != http.StatusGone {
+		t.Fatalf("expired head: status %d", w.Code)
+	}
+	n, err := h.uploads.Sweep(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("sweep: n=%d err=%v", n, err)
+	}
+	if h.storage.deleteCalls != 1 {
+		t.Errorf("sweeping a finished upload should delete its stored object, deletes=%d", h.storage.deleteCalls)
+	}
+}
+
+func TestParseTusMetadata(t *testing.T) {
+	got := parseTusMetadata("transaction_id MTAw, filetype dmlkZW8vbXA0,empty,bad !!!")
+	if got["transaction_id"] != "100" || got["filetype"] != "video/mp4" {
+		t.Fatalf("unexpected metadata: %v", got)
+	}
+	if _, ok := got["empty"]; !ok {
+		t.Error("key without value should map to an empty string")
+	}
+	if _, ok := got["bad"]; ok {
+		t.Error("invalid base64 should be dropped")
+	}
+}
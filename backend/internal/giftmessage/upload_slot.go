@@ -0,0 +1,195 @@
+package giftmessage
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/validate"
+)
+
+const (
+	uploadSlotTTL      = 30 * time.Minute
+	maxOpenUploadSlots = 3
+	sweepBatchSize     = 100
+)
+
+// CreateUploadSlot reserves an object key for txID and returns a presigned
+// URL the browser PUTs the file to. Only audio and video qualify: images are
+// small and must pass through the server to have their metadata stripped.
+func (s *Service) CreateUploadSlot(ctx context.Context, txID, requesterUserID int64, in UploadSlotInput) (*UploadSlotResponse, error) {
+	if requesterUserID == 0 {
+		return nil, apperror.Unauthorized("autenticação obrigatória")
+	}
+	if err := validate.Struct(in); err != nil {
+		return nil, err
+	}
+	uploader, ok := s.storage.(DirectUploader)
+	if !ok {
+		return nil, apperror.ServiceUnavailable("Envio direto de mídia indisponível neste ambiente.")
+	}
+
+	tx, err := s.messageableTx(ctx, txID, requesterUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	mime := normalizeMime(in.MimeType)
+	spec, err := ValidateMedia(mime, in.SizeBytes)
+	if err != nil {
+		return nil, err
+	}
+	if spec.kind == MediaKindImage {
+		return nil, apperror.Validation("fotos devem ser enviadas junto com a mensagem")
+	}
+
+	open, err := s.repo.CountOpenUploadSlots(ctx, tx.ID)
+	if err != nil {
+		return nil, err
+	}
+	if open >= maxOpenUploadSlots {
+		return nil, apperror.TooManyRequests("muitos envios pendentes para essa transação, aguarde alguns minutos")
+	}
+
+	key, err := buildObjectKey(tx.ID, spec.ext)
+	if err != nil {
+		return nil, apperror.Internal("falha ao gerar chave de mídia", err)
+	}
+	uploadURL, err := uploader.PresignUpload(ctx, key, mime, uploadSlotTTL)
+	if err != nil {
+		slog.ErrorContext(ctx, "giftmessage.service upload_slot: presign failed", "key", key, "error", err)
+		return nil, apperror.ServiceUnavailable("Não foi possível preparar o envio da mídia. Tente novamente.")
+	}
+
+	slot, err := s.repo.CreateUploadSlot(ctx, UploadSlot{
+		ObjectKey:         key,
+		GiftTransactionID: tx.ID,
+		UserID:            requesterUserID,
+		MediaKind:         spec.kind,
+		MimeType:          mime,
+		SizeBytes:         in.SizeBytes,
+		ExpiresAt:         time.Now().Add(uploadSlotTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "giftmessage.service upload_slot: created",
+		"slot_id", slot.ID, "tx_id", tx.ID, "user_id", requesterUserID,
+		"media_kind", spec.kind, "size", in.SizeBytes)
+
+	return &UploadSlotResponse{
+		ObjectKey: slot.ObjectKey,
+		UploadURL: uploadURL,
+		Method:    "PUT",
+		Headers:   map[string]string{"Content-Type": mime},
+		ExpiresAt: slot.ExpiresAt,
+	}, nil
+}
+
+// claimUploadSlot checks the object uploaded for key against its slot and
+// consumes the slot. An upload still in flight leaves the slot untouched;
+// an object that does not match what was declared is discarded.
+func (s *Service) claimUploadSlot(ctx context.Context, key string, txID, userID int64) (*UploadSlot, error) {
+	uploader, ok := s.storage.(DirectUploader)
+	if !ok {
+		return nil, apperror.ServiceUnavailable("Envio direto de mídia indisponível neste ambiente.")
+	}
+	slot, err := s.repo.GetUploadSlot(ctx, key, txID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	head, size, err := uploader.ReadPrefix(ctx, slot.ObjectKey, sniffSize)
+	if errors.Is(err, ErrObjectNotFound) {
+		return nil, apperror.Validation("o envio da mídia ainda não foi concluído")
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "giftmessage.service claim_upload: read failed", "key", slot.ObjectKey, "error", err)
+		return nil, apperror.ServiceUnavailable("Não foi possível verificar sua mídia agora. Tente novamente.")
+	}
+
+	if reason := uploadMismatch(slot, head, size); reason != "" {
+		slog.WarnContext(ctx, "giftmessage.service claim_upload: rejected object",
+			"key", slot.ObjectKey, "reason", reason, "declared_size", slot.SizeBytes, "size", size)
+		s.discardSlot(ctx, *slot)
+		return nil, apperror.Validation("a mídia enviada não corresponde ao arquivo declarado")
+	}
+
+	return s.repo.ClaimUploadSlot(ctx, slot.ObjectKey, txID, userID)
+}
+
+func uploadMismatch(slot *UploadSlot, head []byte, size int64) string {
+	if size != slot.SizeBytes {
+		return "size"
+	}
+	if _, err := ValidateMedia(slot.MimeType, size); err != nil {
+		return "limits"
+	}
+	if !hasMediaSignature(slot.MimeType, head) {
+		return "signature"
+	}
+	return ""
+}
+
+func (s *Service) discardSlot(ctx context.Context, slot UploadSlot) {
+	if err := s.storage.Delete(context.Background(), slot.ObjectKey); err != nil {
+		slog.ErrorContext(ctx, "giftmessage.service upload_slot: object delete failed",
+			"key", slot.ObjectKey, "error", err)
+		return
+	}
+	if err := s.repo.DeleteUploadSlot(ctx, slot.ID); err != nil {
+		slog.ErrorContext(ctx, "giftmessage.service upload_slot: slot delete failed",
+			"slot_id", slot.ID, "error", err)
+	}
+}
+
+// SweepUploadSlots deletes expired slots and whatever was uploaded to them.
+// A slot whose object cannot be deleted is kept for the next run.
+func (s *Service) SweepUploadSlots(ctx context.Context) (int, error) {
+	if s.storage == nil {
+		return 0, nil
+	}
+	slots, err := s.repo.ListExpiredUploadSlots(ctx, sweepBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	swept := 0
+	for _, slot := range slots {
+		if err := s.storage.Delete(ctx, slot.ObjectKey); err != nil {
+			slog.ErrorContext(ctx, "giftmessage.service sweep: object delete failed",
+				"key", slot.ObjectKey, "error", err)
+			continue
+		}
+		if err := s.repo.DeleteUploadSlot(ctx, slot.ID); err != nil {
+			return swept, err
+		}
+		swept++
+	}
+	return swept, nil
+}
+
+func (s *Service) RunUploadSlotSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			sweepCtx, cancel := context.WithTimeout(ctx, time.Minute)
+			n, err := s.SweepUploadSlots(sweepCtx)
+			cancel()
+			if err != nil {
+				slog.Error("giftmessage.sweeper failed", "error", err)
+				continue
+			}
+			if n > 0 {
+				slog.Info("giftmessage.sweeper removed expired upload slots", "count", n)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package giftmessage
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// mp4Bytes is an ISO BMFF header ("ftyp" box) padded to size.
+func mp4Bytes(size int) []byte {
+	b := make([]byte, size)
+	copy(b, []byte{0, 0, 0, 0x18, 'f', 't', 'y', 'p', 'i', 's', 'o', 'm'})
+	return b
+}
+
+func newSlotService(t *testing.T) (*Service, *mockRepo, *LocalStorage) {
+	t.Helper()
+	repo := &mockRepo{
+		createFn: func(_ context.Context, in CreateRow) (*GiftMessage, error) {
+			return &GiftMessage{
+				ID: 1, GiftTransactionID: in.GiftTransactionID, GiftID: in.GiftID, UserID: in.UserID,
+				AuthorName: in.AuthorName, Content: in.Content,
+				MediaObjectKey: in.MediaObjectKey, MediaKind: in.MediaKind,
+				MediaSizeBytes: in.MediaSizeBytes, MediaMimeType: in.MediaMimeType,
+				Status: in.Status,
+			}, nil
+		},
+	}
+	storage := NewLocalStorage(t.TempDir(), "http://api.test", []byte("0123456789abcdef0123456789abcdef"))
+	txns := &mockTxFinder{getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil }}
+	return NewService(repo, txns, storage, &mockAudit{}, time.Minute, ModerationAuto), repo, storage
+}
+
+func TestCreateUploadSlot_RequiresDirectUploadStorage(t *testing.T) {
+	svc := NewService(&mockRepo{}, &mockTxFinder{
+		getFn: func(_ context.Context, _ int64) (*TransactionSnapshot, error) { return approvedTx(), nil },
+	}, &mockStorage{}, &mockAudit{}, time.Minute, ModerationAuto)
+	_, err := svc.CreateUploadSlot(context.Background(), 100, 42, UploadSlotInput{MimeType: "video/mp4", SizeBytes: 1024})
+	assertAppError(t, err, http.StatusServiceUnavailable, "indisponível")
+}
+
+func TestCreateUploadSlot_RejectsImagesAndOversize(t *testing.T) {
+	svc, _, _ := newSlotService(t)
+	ctx := context.Background()
+
+	_, err := svc.CreateUploadSlot(ctx, 100, 42, UploadSlotInput{MimeType: "image/jpeg", SizeBytes: 1024})
+	assertAppError(t, err, http.StatusBadRequest, "fotos")
+
+	_, err = svc.CreateUploadSlot(ctx, 100, 42, UploadSlotInput{MimeType: "video/mp4", SizeBytes: maxVideoBytes + 1})
+	assertAppError(t, err, http.StatusBadRequest, "limite")
+}
+
+func TestCreateUploadSlot_LimitsOpenSlots(t *testing.T) {
+	svc, _, _ := newSlotService(t)
+	ctx := context.Background()
+	for i := 0; i < maxOpenUploadSlots; i++ {
+		if _, err := svc.CreateUploadSlot(ctx, 100, 42, UploadSlotInput{MimeType: "video/mp4", SizeBytes: 1024}); err != nil {
+			t.Fatalf("slot %d: %v", i, err)
+		}
+	}
+	_, err := svc.CreateUploadSlot(ctx, 100, 42, UploadSlotInput{MimeType: "video/mp4", SizeBytes: 1024})
+	assertAppError(t, err, http.StatusTooManyRequests, "pendentes")
+}
+
+func TestCreate_WithUploadSlot_LinksVerifiedObject(t *testing.T) {
+	svc, repo, storage := newSlotService(t)
+	ctx := context.Background()
+
+	slot, err := svc.CreateUploadSlot(ctx, 100, 42, UploadSlotInput{MimeType: "video/mp4", SizeBytes: 2048})
+	if err != nil {
+		t.Fatalf("CreateUploadSlot: %v", err)
+	}
+	if slot.Method != http.MethodPut || slot.Headers["Content-Type"] != "video/mp4" {
+		t.Fatalf("unexpected slot: %+v", slot)
+	}
+
+	in := validInput()
+	in.MediaKey = slot.ObjectKey
+	_, err = svc.Create(ctx, 100, 42, in, nil)
+	assertAppError(t, err, http.StatusBadRequest, "não foi concluído")
+	if len(repo.uploadSlots) != 1 {
+		t.Fatal("slot must survive a create issued before the upload finished")
+	}
+
+	if err := storage.Upload(ctx, slot.ObjectKey, "video/mp4", bytes.NewReader(mp4Bytes(2048)), 2048); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	msg, err := svc.Create(ctx, 100, 42, in, nil)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if msg.MediaObjectKey == nil || *msg.MediaObjectKey != slot.ObjectKey || *msg.MediaKind != MediaKindVideo || *msg.MediaSizeBytes != 2048 {
+		t.Fatalf("media not linked: %+v", msg)
+	}
+	if len(repo.uploadSlots) != 0 {
+		t.Error("slot should be consumed")
+	}
+}
+
+func TestCreate_WithUploadSlot_RejectsMismatchedObject(t *testing.T) {
+	cases := map[string][]byte{
+		"size":      mp4Bytes(1000),
+		"signature": bytes.Repeat([]byte("<html>"), 2048/6+1)[:2048],
+	}
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			svc, repo, storage := newSlotService(t)
+			ctx := context.Background()
+			slot, err := svc.CreateUploadSlot(ctx, 100, 42, UploadSlotInput{MimeType: "video/mp4", SizeBytes: 2048})
+			if err != nil {
+				t.Fatalf("CreateUploadSlot: %v", err)
+			}
+			if err := storage.Upload(ctx, slot.ObjectKey, "video/mp4", bytes.NewReader(body), int64(len(body))); err != nil {
+				t.Fatalf("Upload: %v", err)
+			}
+
+			in := validInput()
+			in.MediaKey = slot.ObjectKey
+			_, err = svc.Create(ctx, 100, 42, in, nil)
+			assertAppError(t, err, http.StatusBadRequest, "não corresponde")
+
+			if _, err := os.Stat(filepath.Join(storage.root, filepath.FromSlash(slot.ObjectKey))); !os.IsNotExist(err) {
+				t.Error("mismatched object should be deleted")
+			}
+			if len(repo.uploadSlots) != 0 {
+				t.Error("mismatched slot should be deleted")
+			}
+		})
+	}
+}
+
+func TestCreate_WithUploadSlot_RejectsForeignKey(t *testing.T) {
+	svc, _, _ := newSlotService(t)
+	in := validInput()
+	in.MediaKey = "messages/100/not-a-slot.mp4"
+	_, err := svc.Create(context.Background(), 100, 42, in, nil)
+	assertAppError(t, err, http.StatusNotFound, "expirado")
+}
+
+func TestSweepUploadSlots_RemovesExpiredSlotsAndObjects(t *testing.T) {
+	svc, repo, storage := newSlotService(t)
+	ctx := context.Background()
+	slot, err := svc.CreateUploadSlot(ctx, 100, 42, UploadSlotInput{MimeType: "audio/mpeg", SizeBytes: 3})
+	if err != nil {
+		t.Fatalf("CreateUploadSlot: %v", err)
+	}
+	if err := storage.Upload(ctx, slot.ObjectKey, "audio/mpeg", bytes.NewReader([]byte("ID3")), 3); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+
+	if n, err := svc.SweepUploadSlots(ctx); err != nil || n != 0 {
+		t.Fatalf("fresh slot swept: n=%d err=%v", n, err)
+	}
+	repo.uploadSlots[slot.ObjectKey].ExpiresAt = time.Now().Add(-time.Second)
+	if n, err := svc.SweepUploadSlots(ctx); err != nil || n != 1 {
+		t.Fatalf("expected 1 swept, got n=%d err=%v", n, err)
+	}
+	if _, _, err := storage.ReadPrefix(ctx, slot.ObjectKey, 8); err != ErrObjectNotFound {
+		t.Errorf("object should be deleted, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package giftmessage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// urlCache remembers signed media URLs so repeated listings reuse them
+// instead of asking Storage every time. Reuse also keeps the response body,
+// and so its ETag, the same between requests. A URL is handed out only while
+// more than a quarter of its lifetime is left, which leaves clients and HTTP
+// caches time to use it.
+type urlCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu   sync.Mutex
+	urls map[string]signedURLEntry
+}
+
+type signedURLEntry struct {
+	url     string
+	expires time.Time
+}
+
+func newURLCache(ttl time.Duration) *urlCache {
+	return &urlCache{ttl: ttl, now: time.Now, urls: make(map[string]signedURLEntry)}
+}
+
+// sign returns URLs for keys, signing with storage only those not cached or
+// too close to expiring.
+func (c *urlCache) sign(ctx context.Context, storage Storage, keys []string) (map[string]string, error) {
+	now := c.now()
+	out := make(map[string]string, len(keys))
+	var missing []string
+
+	c.mu.Lock()
+	for _, k := range keys {
+		if e, ok := c.urls[k]; ok && e.expires.Sub(now) > c.ttl/4 {
+			out[k] = e.url
+			continue
+		}
+		missing = append(missing, k)
+	}
+	c.mu.Unlock()
+
+	if len(missing) == 0 {
+		return out, nil
+	}
+	signed, err := storage.SignURLs(ctx, missing, c.ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	// Counted from before the call, so a URL never outlives its entry.
+	expires := now.Add(c.ttl)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, e := range c.urls {
+		if !e.expires.After(now) {
+			delete(c.urls, k)
+		}
+	}
+	for k, u := range signed {
+		out[k] = u
+		if u != "" {
+			c.urls[k] = signedURLEntry{url: u, expires: expires}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,67 @@
+package giftmessage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestURLCacheReusesUntilLastQuarter(t *testing.T) {
+	var calls [][]string
+	storage := &mockStorage{signFn: func(_ context.Context, keys []string, ttl time.Duration) (map[string]string, error) {
+		calls = append(calls, keys)
+		out := make(map[string]string, len(keys))
+		for _, k := range keys {
+			out[k] = fmt.Sprintf("https://signed.example/%s?n=%d", k, len(calls))
+		}
+		return out, nil
+	}}
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	c := newURLCache(20 * time.Minute)
+	c.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	first, err := c.sign(ctx, storage, []string{"a.jpg"})
+	if err != nil {
+		t.Fatalf("sign failed: %v", err)
+	}
+
+	// 14 minutes in, 6 of 20 are left: still more than a quarter.
+	now = now.Add(14 * time.Minute)
+	second, _ := c.sign(ctx, storage, []string{"a.jpg", "b.jpg"})
+	if second["a.jpg"] != first["a.jpg"] {
+		t.Fatalf("expected the cached URL, got %q", second["a.jpg"])
+	}
+	if len(calls) != 2 || len(calls[1]) != 1 || calls[1][0] != "b.jpg" {
+		t.Fatalf("expected only b.jpg to be signed, got %v", calls)
+	}
+
+	// 16 minutes in only 4 are left, so a.jpg is signed again.
+	now = now.Add(2 * time.Minute)
+	third, _ := c.sign(ctx, storage, []string{"a.jpg"})
+	if third["a.jpg"] == first["a.jpg"] || len(calls) != 3 {
+		t.Fatalf("expected a fresh URL, got %q after %d calls", third["a.jpg"], len(calls))
+	}
+}
+
+func TestURLCacheDoesNotCacheFailures(t *testing.T) {
+	fail := true
+	storage := &mockStorage{signFn: func(_ context.Context, keys []string, _ time.Duration) (map[string]string, error) {
+		if fail {
+			return nil, errors.New("storage down")
+		}
+		return map[string]string{keys[0]: "https://signed.example/" + keys[0]}, nil
+	}}
+	c := newURLCache(time.Minute)
+
+	if _, err := c.sign(context.Background(), storage, []string{"a.jpg"}); err == nil {
+		t.Fatal("expected the storage error")
+	}
+	fail = false
+	urls, err := c.sign(context.Background(), storage, []string{"a.jpg"})
+	if err != nil || urls["a.jpg"] == "" {
+		t.Fatalf("expected a URL once storage recovers, got %v, %v", urls, err)
+	}
+}
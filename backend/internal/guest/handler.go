@@ -1,15 +1,17 @@
 package guest
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
-	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
 	"github.com/ferjunior7/parasempre/backend/internal/httputil"
+	"github.com/ferjunior7/parasempre/backend/internal/importmap"
 	"github.com/ferjunior7/parasempre/backend/internal/middleware"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
 	"github.com/ferjunior7/parasempre/backend/internal/validate"
 )
 
@@ -30,8 +32,11 @@ func NewHandler(svc *Service) *Handler {
 
 func (h *Handler) HandleList(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
-	page, _ := strconv.Atoi(q.Get("page"))
-	limit, _ := strconv.Atoi(q.Get("limit"))
+	req, err := pagination.FromQuery(q)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.Validation("invalid cursor"))
+		return
+	}
 
 	filters := ListFilters{
 		Search:       strings.TrimSpace(q.Get("search")),
@@ -39,7 +44,7 @@ func (h *Handler) HandleList(w http.ResponseWriter, r *http.Request) {
 		Attending:    q.Get("attending"),
 	}
 
-	result, err := h.svc.List(r.Context(), page, limit, filters)
+	result, err := h.svc.List(r.Context(), req, filters)
 	if err != nil {
 		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to list guests", err))
 		return
@@ -162,6 +167,48 @@ func (h *Handler) HandleDelete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (h *Handler) HandleListDuplicates(w http.ResponseWriter, r *http.Request) {
+	var minSimilarity float64
+	if v := r.URL.Query().Get("min_similarity"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			httputil.WriteError(w, r, apperror.Validation("min_similarity must be a number"))
+			return
+		}
+		minSimilarity = f
+	}
+
+	candidates, err := h.svc.FindDuplicates(r.Context(), minSimilarity)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to find duplicate guests", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, candidates)
+}
+
+func (h *Handler) HandleMerge(w http.ResponseWriter, r *http.Request) {
+	userRACF := middleware.UserRACFFromContext(r.Context())
+
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid guest id", err))
+		return
+	}
+
+	var input MergeInput
+	if err := httputil.DecodeJSON(r, &input); err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid merge payload", err))
+		return
+	}
+
+	result, err := h.svc.Merge(r.Context(), id, input, userRACF)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to merge guests", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, result)
+}
+
 func (h *Handler) HandleConfirm(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.UserIDFromContext(r.Context())
 	if userID == 0 {
@@ -328,36 +375,64 @@ func (h *Handler) HandleCancelFamilyByPhone(w http.ResponseWriter, r *http.Reque
 	httputil.WriteJSON(w, http.StatusOK, guests)
 }
 
-func (h *Handler) HandleImport(w http.ResponseWriter, r *http.Request) {
-	userRACF := middleware.UserRACFFromContext(r.Context())
+const maxImportSize = 5 << 20 // 5MB
+
+// readImportUpload reads the multipart "file" (CSV or XLSX) and the
+// optional "mapping" field (JSON object of field → column header).
+func readImportUpload(w http.ResponseWriter, r *http.Request) (*importmap.Table, importmap.Mapping, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
 
 	file, header, err := r.FormFile("file")
 	if err != nil {
-		httputil.WriteError(w, r, apperror.Validation("file is required"))
-		return
+		return nil, nil, apperror.Validation("file is required")
 	}
 	defer file.Close()
 
-	var guests []CreateGuestInput
-	ext := strings.ToLower(filepath.Ext(header.Filename))
+	mapping, err := importmap.ParseMapping(r.FormValue("mapping"))
+	if err != nil {
+		return nil, nil, apperror.Validation(err.Error())
+	}
+
+	t, err := importmap.Read(file, header.Filename)
+	if errors.Is(err, importmap.ErrUnsupportedFormat) {
+		return nil, nil, apperror.Validation(err.Error())
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "import: failed to parse file", "filename", header.Filename, "error", err)
+		return nil, nil, apperror.Validation("failed to parse uploaded file")
+	}
+	return t, mapping, nil
+}
+
+func (h *Handler) HandlePreviewImport(w http.ResponseWriter, r *http.Request) {
+	t, mapping, err := readImportUpload(w, r)
+	if err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
 
-	switch ext {
-	case ".csv":
-		guests, err = ParseCSV(file)
-	case ".xlsx":
-		guests, err = ParseXLSX(file)
-	default:
-		httputil.WriteError(w, r, apperror.Validation("unsupported file format: use .csv or .xlsx"))
+	preview, err := h.svc.PreviewImport(r.Context(), t, mapping)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to preview import", err))
 		return
 	}
+	httputil.WriteJSON(w, http.StatusOK, preview)
+}
 
+func (h *Handler) HandleImport(w http.ResponseWriter, r *http.Request) {
+	userRACF := middleware.UserRACFFromContext(r.Context())
+
+	t, mapping, err := readImportUpload(w, r)
 	if err != nil {
-		slog.Error("import: failed to parse file", "extension", ext, "error", err)
-		httputil.WriteError(w, r, apperror.Validation("failed to parse uploaded file"))
+		httputil.WriteError(w, r, err)
 		return
 	}
 
-	result := h.svc.Import(r.Context(), guests, userRACF)
+	result, err := h.svc.Import(r.Context(), t, mapping, userRACF)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to import guests", err))
+		return
+	}
 
 	status := http.StatusOK
 	if result.ErrorCount > 0 && result.SuccessCount > 0 {
@@ -8,20 +8,28 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/xuri/excelize/v2"
+
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
 	"github.com/ferjunior7/parasempre/backend/internal/auth"
+	"github.com/ferjunior7/parasempre/backend/internal/importmap"
 	"github.com/ferjunior7/parasempre/backend/internal/middleware"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
 )
 
 func registerTestRoutes(mux *http.ServeMux, h *Handler) {
 	mux.HandleFunc("GET /api/guests", h.HandleList)
 	mux.HandleFunc("POST /api/guests", h.HandleCreate)
+	mux.HandleFunc("GET /api/guests/duplicates", h.HandleListDuplicates)
 	mux.HandleFunc("GET /api/guests/{id}", h.HandleGet)
 	mux.HandleFunc("PUT /api/guests/{id}", h.HandleUpdate)
 	mux.HandleFunc("DELETE /api/guests/{id}", h.HandleDelete)
+	mux.HandleFunc("POST /api/guests/{id}/merge", h.HandleMerge)
 	mux.HandleFunc("POST /api/guests/import", h.HandleImport)
 }
 
@@ -43,8 +51,8 @@ func withTestClaims(req *http.Request, uracf string) *http.Request {
 
 func TestHandlerListGuests(t *testing.T) {
 	h, repo := newTestHandler()
-	repo.listFn = func(ctx context.Context, limit, offset int, filters ListFilters) ([]Guest, int, error) {
-		return []Guest{sampleGuest()}, 1, nil
+	repo.listFn = func(ctx context.Context, req pagination.Request, filters ListFilters) ([]Guest, pagination.Meta, error) {
+		return []Guest{sampleGuest()}, pagination.Meta{Total: intPtr(1)}, nil
 	}
 
 	req := httptest.NewRequest(http.MethodGet, "/api/guests?page=1&limit=20", nil)
@@ -63,15 +71,15 @@ func TestHandlerListGuests(t *testing.T) {
 	if len(result.Data) != 1 {
 		t.Fatalf("expected 1 guest, got %d", len(result.Data))
 	}
-	if result.Total != 1 {
-		t.Fatalf("expected total 1, got %d", result.Total)
+	if result.Total == nil || *result.Total != 1 {
+		t.Fatalf("expected total 1, got %v", result.Total)
 	}
 }
 
 func TestHandlerListGuestsError(t *testing.T) {
 	h, repo := newTestHandler()
-	repo.listFn = func(ctx context.Context, limit, offset int, filters ListFilters) ([]Guest, int, error) {
-		return nil, 0, errors.New("db error")
+	repo.listFn = func(ctx context.Context, req pagination.Request, filters ListFilters) ([]Guest, pagination.Meta, error) {
+		return nil, pagination.Meta{}, errors.New("db error")
 	}
 
 	req := httptest.NewRequest(http.MethodGet, "/api/guests", nil)
@@ -84,12 +92,42 @@ func TestHandlerListGuestsError(t *testing.T) {
 	}
 }
 
+func TestHandlerListGuestsByCursor(t *testing.T) {
+	h, repo := newTestHandler()
+	var got pagination.Request
+	repo.listFn = func(ctx context.Context, req pagination.Request, filters ListFilters) ([]Guest, pagination.Meta, error) {
+		got = req
+		return []Guest{sampleGuest()}, pagination.Meta{}, nil
+	}
+
+	cursor := pagination.Cursor{Order: "newest", Values: []string{"2026-01-01 00:00:00+00", "3"}}.Encode()
+	req := withTestClaims(httptest.NewRequest(http.MethodGet, "/api/guests?include_total=true&cursor="+cursor, nil), "TST01")
+	w := httptest.NewRecorder()
+	h.HandleList(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got.Cursor == nil || got.Cursor.Values[1] != "3" || !got.IncludeTotal {
+		t.Fatalf("unexpected request %+v", got)
+	}
+
+	repo.listFn = func(ctx context.Context, req pagination.Request, filters ListFilters) ([]Guest, pagination.Meta, error) {
+		return nil, pagination.Meta{}, pagination.ErrInvalidCursor
+	}
+	w = httptest.NewRecorder()
+	h.HandleList(w, withTestClaims(httptest.NewRequest(http.MethodGet, "/api/guests?cursor="+cursor, nil), "TST01"))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a cursor of another ordering, got %d", w.Code)
+	}
+}
+
 func TestHandlerListGuestsParsesFilters(t *testing.T) {
 	h, repo := newTestHandler()
 	var got ListFilters
-	repo.listFn = func(ctx context.Context, limit, offset int, filters ListFilters) ([]Guest, int, error) {
+	repo.listFn = func(ctx context.Context, req pagination.Request, filters ListFilters) ([]Guest, pagination.Meta, error) {
 		got = filters
-		return []Guest{}, 0, nil
+		return []Guest{}, pagination.Meta{Total: intPtr(0)}, nil
 	}
 
 	req := httptest.NewRequest(http.MethodGet, "/api/guests?search=%20maria%20&relationship=R&attending=pending", nil)
@@ -307,6 +345,76 @@ func TestHandlerDeleteGuestNotFound(t *testing.T) {
 	}
 }
 
+func TestHandlerListDuplicates(t *testing.T) {
+	h, repo := newTestHandler()
+	var gotSimilarity float64
+	repo.findDuplicatesFn = func(ctx context.Context, minSimilarity float64, limit int) ([]DuplicateCandidate, error) {
+		gotSimilarity = minSimilarity
+		return []DuplicateCandidate{{Guest: sampleGuest(), Duplicate: sampleGuest(), Similarity: 0.8, Reasons: []string{DuplicateSharedPhone}}}, nil
+	}
+
+	mux := http.NewServeMux()
+	registerTestRoutes(mux, h)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/guests/duplicates?min_similarity=0.75", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var got []DuplicateCandidate
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil || len(got) != 1 || got[0].Reasons[0] != DuplicateSharedPhone {
+		t.Fatalf("unexpected body %+v (%v)", got, err)
+	}
+	if gotSimilarity != 0.75 {
+		t.Fatalf("expected min_similarity 0.75, got %v", gotSimilarity)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/guests/duplicates?min_similarity=high", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestHandlerMergeGuests(t *testing.T) {
+	h, repo := newTestHandler()
+	repo.getByIDAnyFn = func(ctx context.Context, id int64) (*Guest, error) {
+		g := sampleGuest()
+		g.ID = id
+		return &g, nil
+	}
+	var deleted int64
+	repo.deleteFn = func(ctx context.Context, id int64) error {
+		deleted = id
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	registerTestRoutes(mux, h)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/guests/1/merge", bytes.NewBufferString(`{"duplicate_id":5}`))
+	req.Header.Set("Content-Type", "application/json")
+	req = withTestClaims(req, "TST01")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var got MergeResult
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil || got.Guest.ID != 1 || got.MergedID != 5 || deleted != 5 {
+		t.Fatalf("unexpected merge %+v (deleted %d, %v)", got, deleted, err)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/guests/1/merge", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, withTestClaims(req, "TST01"))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without duplicate_id, got %d", w.Code)
+	}
+}
+
 func TestHandlerConfirmGuest(t *testing.T) {
 	h, repo := newTestHandler()
 	repo.getByIDAnyFn = func(ctx context.Context, id int64) (*Guest, error) {
@@ -676,3 +784,121 @@ func TestHandlerImportNoFile(t *testing.T) {
 		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
 	}
 }
+
+// memoryMappings is an in-memory importmap.Repository.
+type memoryMappings map[string]importmap.Mapping
+
+func (m memoryMappings) Get(ctx context.Context, userID int64, kind string) (importmap.Mapping, error) {
+	return m[kind], nil
+}
+
+func (m memoryMappings) Save(ctx context.Context, userID int64, kind string, mapping importmap.Mapping) error {
+	m[kind] = mapping
+	return nil
+}
+
+func importRequest(t *testing.T, path, filename string, content []byte, mapping string) *http.Request {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, _ := writer.CreateFormFile("file", filename)
+	part.Write(content)
+	if mapping != "" {
+		_ = writer.WriteField("mapping", mapping)
+	}
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, path, &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return withTestClaims(req, "TST01")
+}
+
+func TestHandlerPreviewImportSuggestsMapping(t *testing.T) {
+	h, repo := newTestHandler()
+	repo.createFn = func(ctx context.Context, input CreateGuestInput, userRACF string) (*Guest, error) {
+		t.Fatal("preview must not create guests")
+		return nil, nil
+	}
+
+	csv := "Nome;Sobrenome;Lado;Família;Celular\nJoão;Silva;P;1;\nMaria;Santos;X;abc;\n"
+	w := httptest.NewRecorder()
+	h.HandlePreviewImport(w, importRequest(t, "/api/guests/import/preview", "convidados.csv", []byte(csv), ""))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var preview ImportPreview
+	if err := json.NewDecoder(w.Body).Decode(&preview); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	m := preview.Columns.Mapping
+	if m["first_name"] != "Nome" || m["relationship"] != "Lado" || m["family_group"] != "Família" || m["phone"] != "Celular" {
+		t.Fatalf("unexpected suggested mapping %v", m)
+	}
+	if preview.Valid != 1 || preview.Invalid != 1 || len(preview.Rows[1].Errors) != 2 || preview.Rows[1].Row != 3 {
+		t.Fatalf("expected row 3 invalid on family_group and relationship, got %+v", preview)
+	}
+}
+
+func TestHandlerImportWithMapping(t *testing.T) {
+	repo := &mockRepository{}
+	mappings := memoryMappings{}
+	svc := newTestService(repo, defaultUserBridge())
+	svc.mappings = mappings
+	h := NewHandler(svc)
+
+	var created []CreateGuestInput
+	repo.createFn = func(ctx context.Context, input CreateGuestInput, userRACF string) (*Guest, error) {
+		created = append(created, input)
+		g := sampleGuest()
+		return &g, nil
+	}
+
+	csv := []byte("Convidado,Sobrenome,Lado,Mesa\nJoão,Silva,P,3\n")
+	w := httptest.NewRecorder()
+	h.HandleImport(w, importRequest(t, "/api/guests/import", "convidados.csv", csv, ""))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "first_name") {
+		t.Fatalf("expected 400 naming the unmapped columns, got %d: %s", w.Code, w.Body.String())
+	}
+
+	mapping := `{"first_name":"Convidado","last_name":"Sobrenome","relationship":"Lado","family_group":"Mesa"}`
+	req := importRequest(t, "/api/guests/import", "convidados.csv", csv, mapping)
+	req = req.WithContext(reqctx.WithUserID(req.Context(), 1))
+	w = httptest.NewRecorder()
+	h.HandleImport(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(created) != 1 || created[0].FirstName != "João" || *created[0].FamilyGroup != 3 {
+		t.Fatalf("unexpected created guests %+v", created)
+	}
+	if mappings[importmap.KindGuest]["family_group"] != "Mesa" {
+		t.Fatalf("expected the mapping remembered, got %v", mappings)
+	}
+}
+
+func TestHandlerImportXLSX(t *testing.T) {
+	h, repo := newTestHandler()
+	var created int
+	repo.createFn = func(ctx context.Context, input CreateGuestInput, userRACF string) (*Guest, error) {
+		created++
+		g := sampleGuest()
+		return &g, nil
+	}
+
+	f := excelize.NewFile()
+	sheet := f.GetSheetName(0)
+	_ = f.SetSheetRow(sheet, "A1", &[]any{"first_name", "last_name", "relationship", "family_group"})
+	_ = f.SetSheetRow(sheet, "A2", &[]any{"João", "Silva", "P", 1})
+	_ = f.SetSheetRow(sheet, "A3", &[]any{"Maria", "Santos", "R", 2})
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("write xlsx: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	h.HandleImport(w, importRequest(t, "/api/guests/import", "convidados.xlsx", buf.Bytes(), ""))
+	if w.Code != http.StatusOK || created != 2 {
+		t.Fatalf("expected 2 guests created, got %d (%d): %s", created, w.Code, w.Body.String())
+	}
+}
@@ -1,120 +1,83 @@
 package guest
 
 import (
-	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
 	"strconv"
-	"strings"
 
-	"github.com/xuri/excelize/v2"
+	"github.com/ferjunior7/parasempre/backend/internal/importmap"
+	"github.com/ferjunior7/parasempre/backend/internal/validate"
 )
 
-var requiredColumns = []string{"first_name", "last_name", "relationship", "family_group"}
+// guestImportFields are the columns a guest spreadsheet can map to.
+var guestImportFields = []importmap.Field{
+	{Key: "first_name", Label: "Nome", Required: true, Aliases: []string{"nome", "primeiro nome", "first name"}},
+	{Key: "last_name", Label: "Sobrenome", Required: true, Aliases: []string{"sobrenome", "ultimo nome", "last name"}},
+	{Key: "relationship", Label: "Relacionamento (P/R)", Required: true, Aliases: []string{"relacionamento", "relacao", "lado"}},
+	{Key: "family_group", Label: "Grupo familiar", Required: true, Aliases: []string{"grupo familiar", "familia", "grupo", "family"}},
+	{Key: "phone", Label: "Telefone", Aliases: []string{"telefone", "celular", "whatsapp", "fone"}},
+}
 
+// ParseCSV reads a CSV whose headers name the fields (or common aliases of
+// them). Any invalid row fails the whole file.
 func ParseCSV(r io.Reader) ([]CreateGuestInput, error) {
-	reader := csv.NewReader(r)
-
-	header, err := reader.Read()
-	if err != nil {
-		return nil, errors.New("failed to read CSV header")
-	}
-
-	colIndex, err := mapColumns(header)
+	t, err := importmap.ReadCSV(r)
 	if err != nil {
 		return nil, err
 	}
-
-	var guests []CreateGuestInput
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, fmt.Errorf("failed to read CSV row: %w", err)
-		}
-
-		fg, err := parseFamilyGroup(strings.TrimSpace(record[colIndex["family_group"]]))
-		if err != nil {
-			return nil, fmt.Errorf("invalid family_group value: %w", err)
-		}
-
-		guests = append(guests, CreateGuestInput{
-			FirstName:    strings.TrimSpace(record[colIndex["first_name"]]),
-			LastName:     strings.TrimSpace(record[colIndex["last_name"]]),
-			Relationship: strings.TrimSpace(record[colIndex["relationship"]]),
-			FamilyGroup:  fg,
-		})
-	}
-
-	return guests, nil
+	return parseStrict(t)
 }
 
 func ParseXLSX(r io.Reader) ([]CreateGuestInput, error) {
-	f, err := excelize.OpenReader(r)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open XLSX: %w", err)
-	}
-	defer f.Close()
-
-	sheetName := f.GetSheetName(0)
-	rows, err := f.GetRows(sheetName)
+	t, err := importmap.ReadXLSX(r)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read XLSX rows: %w", err)
-	}
-
-	if len(rows) == 0 {
-		return nil, errors.New("XLSX file is empty")
+		return nil, err
 	}
+	return parseStrict(t)
+}
 
-	colIndex, err := mapColumns(rows[0])
+func parseStrict(t *importmap.Table) ([]CreateGuestInput, error) {
+	cols, err := importmap.Resolve(guestImportFields, t.Headers, importmap.Suggest(guestImportFields, t.Headers, nil))
 	if err != nil {
 		return nil, err
 	}
-
-	var guests []CreateGuestInput
-	for _, row := range rows[1:] {
-		maxIdx := 0
-		for _, idx := range colIndex {
-			if idx > maxIdx {
-				maxIdx = idx
-			}
-		}
-		if len(row) <= maxIdx {
-			continue
-		}
-
-		fg, err := parseFamilyGroup(strings.TrimSpace(row[colIndex["family_group"]]))
-		if err != nil {
-			return nil, fmt.Errorf("invalid family_group value: %w", err)
+	guests := make([]CreateGuestInput, 0, len(t.Rows))
+	for _, row := range parseRows(t, cols) {
+		if len(row.Errors) > 0 {
+			return nil, fmt.Errorf("row %d: %s", row.Row, row.Errors[0])
 		}
-
-		guests = append(guests, CreateGuestInput{
-			FirstName:    strings.TrimSpace(row[colIndex["first_name"]]),
-			LastName:     strings.TrimSpace(row[colIndex["last_name"]]),
-			Relationship: strings.TrimSpace(row[colIndex["relationship"]]),
-			FamilyGroup:  fg,
-		})
+		guests = append(guests, row.Input)
 	}
-
 	return guests, nil
 }
 
-func mapColumns(header []string) (map[string]int, error) {
-	index := make(map[string]int)
-	for i, col := range header {
-		index[strings.TrimSpace(strings.ToLower(col))] = i
-	}
+// parseRows reads every data row, collecting per-row problems instead of
+// stopping at the first.
+func parseRows(t *importmap.Table, cols importmap.Columns) []ImportRow {
+	rows := make([]ImportRow, len(t.Rows))
+	for i, record := range t.Rows {
+		row := ImportRow{Row: record.Line}
+		row.Input = CreateGuestInput{
+			FirstName:    cols.Get(record, "first_name"),
+			LastName:     cols.Get(record, "last_name"),
+			Relationship: cols.Get(record, "relationship"),
+		}
+		if phone := cols.Get(record, "phone"); phone != "" {
+			row.Input.Phone = &phone
+		}
 
-	for _, required := range requiredColumns {
-		if _, ok := index[required]; !ok {
-			return nil, fmt.Errorf("missing required column: %s", required)
+		fg, err := parseFamilyGroup(cols.Get(record, "family_group"))
+		if err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("invalid family_group value: %s", err.Error()))
 		}
+		row.Input.FamilyGroup = fg
+		if err := validate.Struct(row.Input); err != nil {
+			row.Errors = append(row.Errors, err.Error())
+		}
+		rows[i] = row
 	}
-
-	return index, nil
+	return rows
 }
 
 func parseFamilyGroup(s string) (*int64, error) {
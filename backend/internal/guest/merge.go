@@ -0,0 +1,140 @@
+package guest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/audit"
+	"github.com/ferjunior7/parasempre/backend/internal/validate"
+)
+
+const (
+	auditGuestMerged = "guest.merged"
+
+	defaultDuplicateSimilarity = 0.6
+	maxDuplicateCandidates     = 200
+)
+
+// FindDuplicates lists pairs of guests that look like the same person, most
+// likely first. minSimilarity is the name similarity (0–1] a pair needs
+// without a shared phone; zero means the default.
+func (s *Service) FindDuplicates(ctx context.Context, minSimilarity float64) ([]DuplicateCandidate, error) {
+	if minSimilarity == 0 {
+		minSimilarity = defaultDuplicateSimilarity
+	}
+	if minSimilarity < 0 || minSimilarity > 1 {
+		return nil, apperror.Validation("min_similarity must be greater than 0 and at most 1")
+	}
+	candidates, err := s.repo.FindDuplicates(ctx, minSimilarity, maxDuplicateCandidates)
+	if err != nil {
+		slog.ErrorContext(ctx, "guest.service find_duplicates: failed", "error", err)
+		return nil, apperror.Internal("failed to find duplicate guests", err)
+	}
+	return candidates, nil
+}
+
+// Merge folds the guest input.DuplicateID into keepID and deletes it, in one
+// transaction. The duplicate's users are linked to the kept guest, so their
+// gift transactions and messages (which belong to users) now count as the
+// kept guest's. Attendance is reconciled by reconcileAttending, and the
+// earliest venue arrival of the two is kept.
+func (s *Service) Merge(ctx context.Context, keepID int64, input MergeInput, userRACF string) (*MergeResult, error) {
+	if err := validate.Struct(input); err != nil {
+		return nil, err
+	}
+	if input.DuplicateID == keepID {
+		return nil, apperror.Validation("a guest cannot be merged into itself")
+	}
+
+	exists, err := s.users.UserExistsByURACF(ctx, userRACF)
+	if err != nil {
+		slog.ErrorContext(ctx, "guest.service merge: user check failed", "error", err)
+		return nil, apperror.Internal("failed to verify user", err)
+	}
+	if !exists {
+		return nil, apperror.Validation("user-racf does not match any registered user")
+	}
+
+	var keep, dup *Guest
+	result := &MergeResult{MergedID: input.DuplicateID}
+	if err := s.txRunner.RunInTx(ctx, func(tx pgx.Tx) error {
+		txRepo := s.repo.WithTx(tx)
+		if keep, err = txRepo.GetByIDAny(ctx, keepID); err != nil {
+			return err
+		}
+		if dup, err = txRepo.GetByIDAny(ctx, input.DuplicateID); err != nil {
+			return err
+		}
+
+		if result.MovedUserIDs, err = s.users.MoveGuestUsersTx(ctx, tx, dup.ID, keep.ID); err != nil {
+			return err
+		}
+		if result.Transactions, result.Messages, err = txRepo.ActivityCounts(ctx, result.MovedUserIDs); err != nil {
+			return err
+		}
+
+		merged := keep
+		if attending := reconcileAttending(keep.Attending, dup.Attending); attending != nil && !sameAttending(keep.Attending, attending) {
+			if merged, err = txRepo.SetAttending(ctx, keep.ID, *attending, userRACF); err != nil {
+				return err
+			}
+		}
+		if earlierCheckIn(dup.CheckedInAt, merged.CheckedInAt) {
+			if merged, err = txRepo.CopyCheckIn(ctx, keep.ID, dup.ID); err != nil {
+				return err
+			}
+		}
+		result.Guest = *merged
+		return txRepo.Delete(ctx, dup.ID)
+	}); err != nil {
+		return nil, apperror.WrapIfNotApp("failed to merge guests", err)
+	}
+	if result.MovedUserIDs == nil {
+		result.MovedUserIDs = []int64{}
+	}
+
+	audit.Record(ctx, s.audit, auditGuestMerged, audit.Entity(audit.EntityGuest, keep.ID, map[string]any{
+		"merged_id":      dup.ID,
+		"merged_name":    fmt.Sprintf("%s %s", dup.FirstName, dup.LastName),
+		"changes":        auditChanges(keep, &result.Guest),
+		"removed":        auditChanges(dup, nil),
+		"moved_user_ids": result.MovedUserIDs,
+		"transactions":   result.Transactions,
+		"messages":       result.Messages,
+	}))
+	slog.InfoContext(ctx, "guest.service merge: guests merged", "id", keep.ID, "merged_id", dup.ID, "moved_users", len(result.MovedUserIDs), "user_racf", userRACF)
+	return result, nil
+}
+
+// reconcileAttending is the attendance of a merged guest: a confirmation on
+// either record wins, then a decline, and no answer only when neither has
+// one.
+func reconcileAttending(a, b *bool) *bool {
+	switch {
+	case a != nil && *a, b != nil && *b:
+		v := true
+		return &v
+	case a != nil || b != nil:
+		v := false
+		return &v
+	}
+	return nil
+}
+
+// earlierCheckIn reports whether arrival a should replace b: it exists and b
+// is either missing or later.
+func earlierCheckIn(a, b *time.Time) bool {
+	return a != nil && (b == nil || a.Before(*b))
+}
+
+func sameAttending(a, b *bool) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,244 @@
+package guest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
+)
+
+func TestReconcileAttending(t *testing.T) {
+	tests := []struct {
+		a, b, want *bool
+	}{
+		{nil, nil, nil},
+		{nil, boolPtr(false), boolPtr(false)},
+		{boolPtr(false), boolPtr(true), boolPtr(true)},
+		{boolPtr(true), nil, boolPtr(true)},
+		{boolPtr(false), boolPtr(false), boolPtr(false)},
+	}
+	for _, tt := range tests {
+		if got := reconcileAttending(tt.a, tt.b); !sameAttending(got, tt.want) {
+			t.Errorf("reconcileAttending(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestServiceFindDuplicates(t *testing.T) {
+	var gotSimilarity float64
+	repo := &mockRepository{
+		findDuplicatesFn: func(ctx context.Context, minSimilarity float64, limit int) ([]DuplicateCandidate, error) {
+			gotSimilarity = minSimilarity
+			return []DuplicateCandidate{{Similarity: 1, Reasons: []string{DuplicateSimilarName}}}, nil
+		},
+	}
+	svc := newTestService(repo, defaultUserBridge())
+
+	candidates, err := svc.FindDuplicates(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(candidates) != 1 || gotSimilarity != defaultDuplicateSimilarity {
+		t.Fatalf("expected one candidate at the default threshold, got %d at %v", len(candidates), gotSimilarity)
+	}
+
+	_, err = svc.FindDuplicates(context.Background(), 1.5)
+	assertAppError(t, err, http.StatusBadRequest, "min_similarity must be greater than 0 and at most 1")
+}
+
+func TestServiceMerge(t *testing.T) {
+	keep := sampleGuest()
+	keep.Attending = boolPtr(false)
+	dup := sampleGuest()
+	dup.ID, dup.FirstName, dup.LastName, dup.FamilyGroup, dup.Attending = 2, "Joao", "da Silva", 7, boolPtr(true)
+
+	var deleted int64
+	var setTo *bool
+	repo := &mockRepository{
+		getByIDAnyFn: func(ctx context.Context, id int64) (*Guest, error) {
+			switch id {
+			case keep.ID:
+				g := keep
+				return &g, nil
+			case dup.ID:
+				g := dup
+				return &g, nil
+			}
+			return nil, apperror.NotFound("guest not found")
+		},
+		activityCountsFn: func(ctx context.Context, userIDs []int64) (int, int, error) {
+			if !reflect.DeepEqual(userIDs, []int64{42}) {
+				t.Errorf("expected counts for the moved user, got %v", userIDs)
+			}
+			return 2, 1, nil
+		},
+		setAttendingFn: func(ctx context.Context, id int64, attending bool, userRACF string) (*Guest, error) {
+			setTo = &attending
+			g := keep
+			g.Attending = &attending
+			return &g, nil
+		},
+		deleteFn: func(ctx context.Context, id int64) error {
+			deleted = id
+			return nil
+		},
+	}
+	users := defaultUserBridge()
+	users.moveFn = func(ctx context.Context, tx pgx.Tx, fromGuestID, toGuestID int64) ([]int64, error) {
+		if fromGuestID != dup.ID || toGuestID != keep.ID {
+			t.Errorf("expected users moved from %d to %d, got %d to %d", dup.ID, keep.ID, fromGuestID, toGuestID)
+		}
+		return []int64{42}, nil
+	}
+	aud := &mockAudit{}
+	svc := newTestService(repo, users)
+	svc.audit = aud
+
+	ctx := reqctx.WithUserID(context.Background(), 9)
+	result, err := svc.Merge(ctx, keep.ID, MergeInput{DuplicateID: dup.ID}, "TST01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deleted != dup.ID {
+		t.Fatalf("expected guest %d deleted, got %d", dup.ID, deleted)
+	}
+	if setTo == nil || !*setTo || result.Guest.Attending == nil || !*result.Guest.Attending {
+		t.Fatalf("expected the confirmation to win, got %v", result.Guest.Attending)
+	}
+	if result.MergedID != dup.ID || result.Transactions != 2 || result.Messages != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	if len(aud.calls) != 1 || aud.calls[0].action != auditGuestMerged {
+		t.Fatalf("expected one %s audit entry, got %+v", auditGuestMerged, aud.calls)
+	}
+	details := aud.calls[0].details
+	if details["merged_id"] != dup.ID || details["merged_name"] != "Joao da Silva" {
+		t.Fatalf("unexpected audit details %v", details)
+	}
+	if _, ok := details["removed"]; !ok {
+		t.Fatal("expected the removed guest in the audit entry")
+	}
+}
+
+func TestServiceMergeKeepsAttendingWhenReconciled(t *testing.T) {
+	repo := &mockRepository{
+		getByIDAnyFn: func(ctx context.Context, id int64) (*Guest, error) {
+			g := sampleGuest()
+			g.ID, g.Attending = id, boolPtr(true)
+			return &g, nil
+		},
+		setAttendingFn: func(ctx context.Context, id int64, attending bool, userRACF string) (*Guest, error) {
+			t.Fatal("expected no attending update when both already agree")
+			return nil, nil
+		},
+		deleteFn: func(ctx context.Context, id int64) error { return nil },
+	}
+	svc := newTestService(repo, defaultUserBridge())
+
+	result, err := svc.Merge(context.Background(), 1, MergeInput{DuplicateID: 2}, "TST01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.MovedUserIDs == nil || len(result.MovedUserIDs) != 0 {
+		t.Fatalf("expected an empty moved user list, got %v", result.MovedUserIDs)
+	}
+}
+
+func TestServiceMergeKeepsEarliestCheckIn(t *testing.T) {
+	early := time.Date(2026, 11, 21, 16, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		keep, dup  *time.Time
+		wantCopied bool
+	}{
+		{"only duplicate checked in", nil, &early, true},
+		{"duplicate arrived first", &late, &early, true},
+		{"kept guest arrived first", &early, &late, false},
+		{"neither checked in", nil, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var copied bool
+			repo := &mockRepository{
+				getByIDAnyFn: func(ctx context.Context, id int64) (*Guest, error) {
+					g := sampleGuest()
+					g.ID, g.CheckedInAt = id, tt.keep
+					if id == 2 {
+						g.CheckedInAt = tt.dup
+					}
+					return &g, nil
+				},
+				copyCheckInFn: func(ctx context.Context, id, fromID int64) (*Guest, error) {
+					if id != 1 || fromID != 2 {
+						t.Errorf("expected the check-in copied from 2 to 1, got %d to %d", fromID, id)
+					}
+					copied = true
+					g := sampleGuest()
+					g.CheckedInAt = tt.dup
+					return &g, nil
+				},
+				deleteFn: func(ctx context.Context, id int64) error { return nil },
+			}
+			svc := newTestService(repo, defaultUserBridge())
+
+			result, err := svc.Merge(context.Background(), 1, MergeInput{DuplicateID: 2}, "TST01")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if copied != tt.wantCopied {
+				t.Fatalf("expected copied=%v, got %v", tt.wantCopied, copied)
+			}
+			want := tt.keep
+			if tt.wantCopied {
+				want = tt.dup
+			}
+			if !reflect.DeepEqual(result.Guest.CheckedInAt, want) {
+				t.Fatalf("expected check-in %v, got %v", want, result.Guest.CheckedInAt)
+			}
+		})
+	}
+}
+
+func TestServiceMergeRejectsSelf(t *testing.T) {
+	svc := newTestService(&mockRepository{}, defaultUserBridge())
+	_, err := svc.Merge(context.Background(), 3, MergeInput{DuplicateID: 3}, "TST01")
+	assertAppError(t, err, http.StatusBadRequest, "a guest cannot be merged into itself")
+}
+
+func TestServiceMergeStopsWhenUsersFailToMove(t *testing.T) {
+	var deleted bool
+	repo := &mockRepository{
+		getByIDAnyFn: func(ctx context.Context, id int64) (*Guest, error) {
+			g := sampleGuest()
+			g.ID = id
+			return &g, nil
+		},
+		deleteFn: func(ctx context.Context, id int64) error {
+			deleted = true
+			return nil
+		},
+	}
+	users := defaultUserBridge()
+	users.moveFn = func(ctx context.Context, tx pgx.Tx, fromGuestID, toGuestID int64) ([]int64, error) {
+		return nil, errors.New("boom")
+	}
+	aud := &mockAudit{}
+	svc := newTestService(repo, users)
+	svc.audit = aud
+
+	_, err := svc.Merge(reqctx.WithUserID(context.Background(), 9), 1, MergeInput{DuplicateID: 2}, "TST01")
+	assertAppError(t, err, http.StatusInternalServerError, "failed to merge guests")
+	if deleted || len(aud.calls) != 0 {
+		t.Fatalf("expected no delete and no audit entry after a failure, got deleted=%v audits=%d", deleted, len(aud.calls))
+	}
+}
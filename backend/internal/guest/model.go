@@ -1,6 +1,12 @@
 package guest
 
-import "time"
+import (
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/importmap"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
+	"github.com/ferjunior7/parasempre/backend/internal/search"
+)
 
 type Guest struct {
 	ID           int64     `json:"id"`
@@ -13,6 +19,17 @@ type Guest struct {
 	UpdatedBy    string    `json:"updated_by"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+	// CheckedInAt is when the guest arrived at the venue (see package checkin).
+	CheckedInAt *time.Time `json:"checked_in_at"`
+
+	// Highlight marks where the full name matched, on search results only.
+	Highlight *search.Highlight `json:"highlight,omitempty"`
+}
+
+// householdName names the household started by a guest created without a
+// family_group.
+func householdName(lastName string) string {
+	return "Família " + lastName
 }
 
 type CreateGuestInput struct {
@@ -31,11 +48,12 @@ type UpdateGuestInput struct {
 	FamilyGroup  *int64  `json:"family_group"`
 }
 
+// PagedResponse is a page of guests. Page is 0 when paging by cursor.
 type PagedResponse struct {
 	Data  []Guest `json:"data"`
-	Page  int     `json:"page"`
+	Page  int     `json:"page,omitempty"`
 	Limit int     `json:"limit"`
-	Total int     `json:"total"`
+	pagination.Meta
 }
 
 type ListFilters struct {
@@ -49,6 +67,21 @@ type Stats struct {
 	Confirmed int `json:"confirmed"`
 	Pending   int `json:"pending"`
 	Declined  int `json:"declined"`
+	CheckedIn int `json:"checked_in"`
+}
+
+// ImportRow is one spreadsheet row read with the chosen column mapping.
+type ImportRow struct {
+	Row    int              `json:"row"`
+	Input  CreateGuestInput `json:"input"`
+	Errors []string         `json:"errors,omitempty"`
+}
+
+type ImportPreview struct {
+	Columns importmap.Plan `json:"columns"`
+	Rows    []ImportRow    `json:"rows"`
+	Valid   int            `json:"valid"`
+	Invalid int            `json:"invalid"`
 }
 
 type ImportRowError struct {
@@ -67,3 +100,35 @@ type BatchConfirmInput struct {
 	GuestIDs  []int64 `json:"guest_ids" validate:"required,min=1,max=50,dive,gt=0"`
 	Attending bool    `json:"attending"`
 }
+
+// Why two guests were paired as possible duplicates.
+const (
+	DuplicateSimilarName = "similar_name"
+	DuplicateSharedPhone = "shared_phone"
+)
+
+// DuplicateCandidate pairs two guests that may be the same person; Guest is
+// the older of the two. Similarity is that of the normalized names (0–1).
+type DuplicateCandidate struct {
+	Guest      Guest    `json:"guest"`
+	Duplicate  Guest    `json:"duplicate"`
+	Similarity float64  `json:"similarity"`
+	Reasons    []string `json:"reasons"`
+}
+
+// MergeInput names the guest merged into (and then deleted in favour of)
+// the one in the path.
+type MergeInput struct {
+	DuplicateID int64 `json:"duplicate_id" validate:"required,gt=0"`
+}
+
+// MergeResult is the kept guest after a merge. MovedUserIDs are the users
+// that were linked to the removed guest; Transactions and Messages count
+// what they brought along.
+type MergeResult struct {
+	Guest        Guest   `json:"guest"`
+	MergedID     int64   `json:"merged_id"`
+	MovedUserIDs []int64 `json:"moved_user_ids"`
+	Transactions int     `json:"transactions"`
+	Messages     int     `json:"messages"`
+}
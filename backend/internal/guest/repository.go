@@ -4,17 +4,19 @@ import (
 	"context"
 
 	"github.com/jackc/pgx/v5"
+
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
 )
 
 type Repository interface {
-	List(ctx context.Context, limit, offset int, filters ListFilters) ([]Guest, int, error)
+	List(ctx context.Context, req pagination.Request, filters ListFilters) ([]Guest, pagination.Meta, error)
 	Stats(ctx context.Context) (Stats, error)
 	ListByFamilyGroup(ctx context.Context, familyGroup int64) ([]Guest, error)
 	GetByIDAny(ctx context.Context, id int64) (*Guest, error)
 	GetByIDs(ctx context.Context, ids []int64) ([]Guest, error)
 	GetByName(ctx context.Context, firstName, lastName string) (*Guest, error)
 	FamilyGroupExists(ctx context.Context, familyGroup int64) (bool, error)
-	GetNextFamilyGroup(ctx context.Context) (int64, error)
+	CreateHousehold(ctx context.Context, name, userRACF string) (int64, error)
 	Create(ctx context.Context, input CreateGuestInput, userRACF string) (*Guest, error)
 	Update(ctx context.Context, id int64, input UpdateGuestInput, userRACF string) (*Guest, error)
 	Delete(ctx context.Context, id int64) error
@@ -22,6 +24,10 @@ type Repository interface {
 	SetAttendingByFamilyGroup(ctx context.Context, familyGroup int64, attending bool, userRACF string) ([]Guest, error)
 	SetAttendingByIDs(ctx context.Context, ids []int64, attending bool, userRACF string) ([]Guest, error)
 	GetFamilyGroupByPhone(ctx context.Context, phone string) (*int64, error)
+	FindDuplicates(ctx context.Context, minSimilarity float64, limit int) ([]DuplicateCandidate, error)
+	ActivityCounts(ctx context.Context, userIDs []int64) (transactions, messages int, err error)
+	// CopyCheckIn gives guest id the venue arrival recorded on fromID.
+	CopyCheckIn(ctx context.Context, id, fromID int64) (*Guest, error)
 }
 
 type TxAwareRepository interface {
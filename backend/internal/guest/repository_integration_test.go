@@ -5,18 +5,41 @@ package guest
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/ferjunior7/parasempre/backend/internal/database"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
 )
 
+// listPage lists the first page by number, with the total, as the admin
+// table does.
+func listPage(ctx context.Context, repo Repository, filters ListFilters, limit int) ([]Guest, int, error) {
+	guests, meta, err := repo.List(ctx, pagination.Request{Page: 1, Limit: limit, IncludeTotal: true}, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	return guests, *meta.Total, nil
+}
+
+// newHousehold adds an empty household for the guests a test creates, as
+// family_group must reference one.
+func newHousehold(t *testing.T, ctx context.Context, repo Repository) int64 {
+	t.Helper()
+	id, err := repo.CreateHousehold(ctx, "Família Teste", "TST01")
+	if err != nil {
+		t.Fatalf("CreateHousehold failed: %v", err)
+	}
+	return id
+}
+
 func TestIntegrationCreateAndGet(t *testing.T) {
 	pool := database.NewTestPool(t)
 	tx := database.BeginTestTx(t, pool)
 	repo := NewPostgresRepository(pool).WithTx(tx)
 	ctx := context.Background()
 
-	fg := int64(1)
+	fg := newHousehold(t, ctx, repo)
 	input := CreateGuestInput{
 		FirstName:    "João",
 		LastName:     "Integration",
@@ -50,7 +73,7 @@ func TestIntegrationUniqueConstraint(t *testing.T) {
 	repo := NewPostgresRepository(pool).WithTx(tx)
 	ctx := context.Background()
 
-	fg := int64(1)
+	fg := newHousehold(t, ctx, repo)
 	input := CreateGuestInput{
 		FirstName:    "Maria",
 		LastName:     "Duplicada",
@@ -84,7 +107,7 @@ func TestIntegrationCoupleSharesGuestList(t *testing.T) {
 	repo := NewPostgresRepository(pool).WithTx(tx)
 	ctx := context.Background()
 
-	fgGroom := int64(90001)
+	fgGroom := newHousehold(t, ctx, repo)
 	groomGuest, err := repo.Create(ctx, CreateGuestInput{
 		FirstName:    "Convidado",
 		LastName:     "DoNoivo",
@@ -106,7 +129,7 @@ func TestIntegrationCoupleSharesGuestList(t *testing.T) {
 
 	// List é a lista compartilhada do casamento e deve incluir o convidado
 	// criado pelo NOIVO, sem filtrar por created_by.
-	guests, _, err := repo.List(ctx, 1000, 0, ListFilters{})
+	guests, _, err := listPage(ctx, repo, ListFilters{}, 1000)
 	if err != nil {
 		t.Fatalf("List failed: %v", err)
 	}
@@ -131,7 +154,7 @@ func TestIntegrationListPagination(t *testing.T) {
 	ctx := context.Background()
 
 	for i := range 5 {
-		fg := int64(i + 1)
+		fg := newHousehold(t, ctx, repo)
 		input := CreateGuestInput{
 			FirstName:    "Guest",
 			LastName:     string(rune('A' + i)),
@@ -146,7 +169,7 @@ func TestIntegrationListPagination(t *testing.T) {
 	// List is the wedding's shared roster (not scoped by created_by), so total
 	// reflects every guest visible in the transaction — at least the 5 created
 	// here. The assertion stays isolation-safe against pre-existing rows.
-	guests, total, err := repo.List(ctx, 2, 0, ListFilters{})
+	guests, total, err := listPage(ctx, repo, ListFilters{}, 2)
 	if err != nil {
 		t.Fatalf("List failed: %v", err)
 	}
@@ -158,6 +181,65 @@ func TestIntegrationListPagination(t *testing.T) {
 	}
 }
 
+func TestIntegrationListCursorPaging(t *testing.T) {
+	pool := database.NewTestPool(t)
+	tx := database.BeginTestTx(t, pool)
+	repo := NewPostgresRepository(pool).WithTx(tx)
+	ctx := context.Background()
+
+	// Inside one transaction every row gets the same created_at, so only the
+	// id keeps the pages apart.
+	create := func(first string, fg int64) {
+		t.Helper()
+		if _, err := repo.Create(ctx, CreateGuestInput{
+			FirstName:    first,
+			LastName:     "Paginadorzinho",
+			Relationship: "P",
+			FamilyGroup:  &fg,
+		}, "TST01"); err != nil {
+			t.Fatalf("Create %s failed: %v", first, err)
+		}
+	}
+	for _, first := range []string{"Ana", "Bia", "Caio", "Dani", "Edu"} {
+		create(first, newHousehold(t, ctx, repo))
+	}
+	filters := ListFilters{Search: "paginadorzinho"}
+
+	seen := map[int64]bool{}
+	req := pagination.Request{Page: 1, Limit: 2}
+	for pages := 1; ; pages++ {
+		guests, meta, err := repo.List(ctx, req, filters)
+		if err != nil {
+			t.Fatalf("List page %d failed: %v", pages, err)
+		}
+		for _, g := range guests {
+			if seen[g.ID] {
+				t.Fatalf("guest %d listed twice", g.ID)
+			}
+			seen[g.ID] = true
+		}
+		if meta.NextCursor == nil {
+			break
+		}
+		if pages == 1 {
+			create("Fabi", 81009)
+		}
+		c, err := pagination.Decode(*meta.NextCursor)
+		if err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		req = pagination.Request{Limit: 2, Cursor: c, IncludeTotal: pages == 1}
+		if pages == 1 {
+			if _, meta, _ := repo.List(ctx, req, filters); meta.Total == nil || *meta.Total != 6 {
+				t.Fatalf("expected the total of the whole search with a cursor, got %v", meta.Total)
+			}
+		}
+	}
+	if len(seen) != 5 {
+		t.Fatalf("expected the 5 guests listed before paging started, got %d", len(seen))
+	}
+}
+
 func TestIntegrationListSearchSpansAllPages(t *testing.T) {
 	pool := database.NewTestPool(t)
 	tx := database.BeginTestTx(t, pool)
@@ -165,7 +247,7 @@ func TestIntegrationListSearchSpansAllPages(t *testing.T) {
 	ctx := context.Background()
 
 	for i := range 25 {
-		fg := int64(70000 + i)
+		fg := newHousehold(t, ctx, repo)
 		if _, err := repo.Create(ctx, CreateGuestInput{
 			FirstName:    "Filler",
 			LastName:     string(rune('A'+i%26)) + string(rune('a'+i)),
@@ -175,7 +257,7 @@ func TestIntegrationListSearchSpansAllPages(t *testing.T) {
 			t.Fatalf("Create filler %d failed: %v", i, err)
 		}
 	}
-	fgTarget := int64(79999)
+	fgTarget := newHousehold(t, ctx, repo)
 	if _, err := repo.Create(ctx, CreateGuestInput{
 		FirstName:    "Aurelio",
 		LastName:     "Buscavel",
@@ -185,7 +267,7 @@ func TestIntegrationListSearchSpansAllPages(t *testing.T) {
 		t.Fatalf("Create target failed: %v", err)
 	}
 
-	guests, total, err := repo.List(ctx, 20, 0, ListFilters{Search: "aurelio buscavel"})
+	guests, total, err := listPage(ctx, repo, ListFilters{Search: "aurelio buscavel"}, 20)
 	if err != nil {
 		t.Fatalf("List with search failed: %v", err)
 	}
@@ -197,6 +279,38 @@ func TestIntegrationListSearchSpansAllPages(t *testing.T) {
 	}
 }
 
+func TestIntegrationListSearchIgnoresAccentsAndRanks(t *testing.T) {
+	pool := database.NewTestPool(t)
+	tx := database.BeginTestTx(t, pool)
+	repo := NewPostgresRepository(pool).WithTx(tx)
+	ctx := context.Background()
+
+	fg := newHousehold(t, ctx, repo)
+	for _, name := range [][2]string{{"Joãozinho", "Pereira"}, {"João", "Silva"}, {"Maria", "Conceição"}} {
+		if _, err := repo.Create(ctx, CreateGuestInput{
+			FirstName: name[0], LastName: name[1], Relationship: "P", FamilyGroup: &fg,
+		}, "TST01"); err != nil {
+			t.Fatalf("Create %v failed: %v", name, err)
+		}
+	}
+
+	guests, total, err := listPage(ctx, repo, ListFilters{Search: "joao silva"}, 20)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if total == 0 || guests[0].FirstName != "João" || guests[0].LastName != "Silva" {
+		t.Fatalf("expected João Silva first, got %+v", guests)
+	}
+
+	guests, total, err = listPage(ctx, repo, ListFilters{Search: "conceicao"}, 20)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if total != 1 || guests[0].FirstName != "Maria" {
+		t.Fatalf("expected Maria Conceição, got %+v", guests)
+	}
+}
+
 func TestIntegrationStats(t *testing.T) {
 	pool := database.NewTestPool(t)
 	tx := database.BeginTestTx(t, pool)
@@ -208,10 +322,11 @@ func TestIntegrationStats(t *testing.T) {
 		t.Fatalf("Stats (before) failed: %v", err)
 	}
 
-	mk := func(fg int64, attending *bool) {
+	mk := func(last string, attending *bool) {
+		fg := newHousehold(t, ctx, repo)
 		g, err := repo.Create(ctx, CreateGuestInput{
 			FirstName:    "Stat",
-			LastName:     string(rune('A' + fg%26)),
+			LastName:     last,
 			Relationship: "P",
 			FamilyGroup:  &fg,
 		}, "TST01")
@@ -225,10 +340,10 @@ func TestIntegrationStats(t *testing.T) {
 		}
 	}
 	yes, no := true, false
-	mk(60001, &yes)
-	mk(60002, &yes)
-	mk(60003, &no)
-	mk(60004, nil)
+	mk("Statab", &yes)
+	mk("Statcd", &yes)
+	mk("Statef", &no)
+	mk("Statgh", nil)
 
 	after, err := repo.Stats(ctx)
 	if err != nil {
@@ -247,3 +362,90 @@ func TestIntegrationStats(t *testing.T) {
 		t.Fatalf("expected pending +1, got +%d", after.Pending-before.Pending)
 	}
 }
+
+func TestIntegrationFindDuplicates(t *testing.T) {
+	pool := database.NewTestPool(t)
+	tx := database.BeginTestTx(t, pool)
+	repo := NewPostgresRepository(pool).WithTx(tx)
+	ctx := context.Background()
+
+	create := func(first, last string) *Guest {
+		fg := newHousehold(t, ctx, repo)
+		g, err := repo.Create(ctx, CreateGuestInput{FirstName: first, LastName: last, Relationship: "P", FamilyGroup: &fg}, "TST01")
+		if err != nil {
+			t.Fatalf("Create %s %s failed: %v", first, last, err)
+		}
+		return g
+	}
+	jose := create("Josefino", "Silvabeira")
+	joseDa := create("Josefino", "da Silvabeira")
+	zelinda := create("Zelinda", "Marquesini")
+	quirino := create("Quirino", "Alvarenga")
+
+	// The same mobile imported under two area codes.
+	for guestID, phone := range map[int64]string{zelinda.ID: "11987650123", quirino.ID: "21987650123"} {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO users (guest_id, role, uracf, phone) VALUES ($1, 'guest', $2, $3)`,
+			guestID, fmt.Sprintf("D%04d", guestID%10000), phone); err != nil {
+			t.Fatalf("insert user failed: %v", err)
+		}
+	}
+
+	candidates, err := repo.FindDuplicates(ctx, 0.6, 200)
+	if err != nil {
+		t.Fatalf("FindDuplicates failed: %v", err)
+	}
+	reasons := func(a, b int64) []string {
+		for _, c := range candidates {
+			if c.Guest.ID == a && c.Duplicate.ID == b {
+				return c.Reasons
+			}
+		}
+		return nil
+	}
+	if got := reasons(jose.ID, joseDa.ID); len(got) != 1 || got[0] != DuplicateSimilarName {
+		t.Fatalf("expected the names to match after normalizing, got %v", got)
+	}
+	if got := reasons(zelinda.ID, quirino.ID); len(got) != 1 || got[0] != DuplicateSharedPhone {
+		t.Fatalf("expected the shared subscriber number to match, got %v", got)
+	}
+	if got := reasons(jose.ID, zelinda.ID); got != nil {
+		t.Fatalf("expected unrelated guests to be left out, got %v", got)
+	}
+}
+
+func TestIntegrationCopyCheckIn(t *testing.T) {
+	pool := database.NewTestPool(t)
+	tx := database.BeginTestTx(t, pool)
+	repo := NewPostgresRepository(pool).WithTx(tx)
+	ctx := context.Background()
+
+	fg := newHousehold(t, ctx, repo)
+	keep, err := repo.Create(ctx, CreateGuestInput{FirstName: "Ludovina", LastName: "Quaresma", Relationship: "P", FamilyGroup: &fg}, "TST01")
+	if err != nil {
+		t.Fatalf("Create keep failed: %v", err)
+	}
+	dup, err := repo.Create(ctx, CreateGuestInput{FirstName: "Ludovina", LastName: "Quaresma Neto", Relationship: "P", FamilyGroup: &fg}, "TST01")
+	if err != nil {
+		t.Fatalf("Create dup failed: %v", err)
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE guests SET checked_in_at = now(), checked_in_by = 'STF01' WHERE id = $1`, dup.ID); err != nil {
+		t.Fatalf("check in dup failed: %v", err)
+	}
+
+	got, err := repo.CopyCheckIn(ctx, keep.ID, dup.ID)
+	if err != nil {
+		t.Fatalf("CopyCheckIn failed: %v", err)
+	}
+	if got.ID != keep.ID || got.CheckedInAt == nil {
+		t.Fatalf("expected guest %d checked in, got %+v", keep.ID, got)
+	}
+	var by string
+	if err := tx.QueryRow(ctx, `SELECT checked_in_by FROM guests WHERE id = $1`, keep.ID).Scan(&by); err != nil {
+		t.Fatalf("read checked_in_by failed: %v", err)
+	}
+	if by != "STF01" {
+		t.Fatalf("expected checked_in_by STF01, got %q", by)
+	}
+}
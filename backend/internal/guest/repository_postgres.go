@@ -15,13 +15,18 @@ import (
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
 	"github.com/ferjunior7/parasempre/backend/internal/database"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
+	"github.com/ferjunior7/parasempre/backend/internal/search"
 )
 
-const guestColumns = `id, first_name, last_name, relationship, attending, family_group, created_by, updated_by, created_at, updated_at`
+// guestFullName is the expression searches match, as indexed by migration 023.
+const guestFullName = `(first_name || ' ' || last_name)`
+
+const guestColumns = `id, first_name, last_name, relationship, attending, family_group, created_by, updated_by, created_at, updated_at, checked_in_at`
 
 func scanGuest(row pgx.Row) (Guest, error) {
 	var g Guest
-	err := row.Scan(&g.ID, &g.FirstName, &g.LastName, &g.Relationship, &g.Attending, &g.FamilyGroup, &g.CreatedBy, &g.UpdatedBy, &g.CreatedAt, &g.UpdatedAt)
+	err := row.Scan(&g.ID, &g.FirstName, &g.LastName, &g.Relationship, &g.Attending, &g.FamilyGroup, &g.CreatedBy, &g.UpdatedBy, &g.CreatedAt, &g.UpdatedAt, &g.CheckedInAt)
 	return g, err
 }
 
@@ -40,15 +45,21 @@ func (r *PostgresRepository) WithTx(tx pgx.Tx) Repository {
 // List returns the wedding's shared guest list. Guests belong to the wedding
 // (co-administered by groom and bride), not to whoever created the row, so the
 // listing is intentionally NOT scoped by created_by.
-func (r *PostgresRepository) List(ctx context.Context, limit, offset int, filters ListFilters) ([]Guest, int, error) {
+func (r *PostgresRepository) List(ctx context.Context, req pagination.Request, filters ListFilters) ([]Guest, pagination.Meta, error) {
 	var conds []string
 	var args []any
 	n := 1
 
+	newest := []pagination.Key{
+		{Expr: "created_at", Type: "timestamptz", Desc: true},
+		{Expr: "id", Type: "bigint", Desc: true},
+	}
+	order := pagination.Order{Name: "newest", Keys: newest}
 	if filters.Search != "" {
-		conds = append(conds, fmt.Sprintf("(first_name || ' ' || last_name) ILIKE $%d", n))
-		args = append(args, "%"+filters.Search+"%")
-		n++
+		conds = append(conds, search.Match(guestFullName, n, n+1))
+		order = pagination.Order{Name: "relevance", Keys: append(search.RankKeys(guestFullName, n, n+1), newest...)}
+		args = append(args, filters.Search, search.LikePattern(filters.Search))
+		n += 2
 	}
 	if filters.Relationship == "P" || filters.Relationship == "R" {
 		conds = append(conds, fmt.Sprintf("relationship = $%d", n))
@@ -64,38 +75,69 @@ func (r *PostgresRepository) List(ctx context.Context, limit, offset int, filter
 		conds = append(conds, "attending IS NULL")
 	}
 
-	where := ""
-	if len(conds) > 0 {
-		where = "WHERE " + strings.Join(conds, " AND ") + " "
+	var meta pagination.Meta
+	if req.IncludeTotal {
+		var total int
+		if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM guests `+whereClause(conds), args...).Scan(&total); err != nil {
+			slog.ErrorContext(ctx, "guest.repo list: count failed", "error", err)
+			return nil, meta, err
+		}
+		meta.Total = &total
 	}
-	args = append(args, limit, offset)
+
+	seek, args, err := order.Seek(req.Cursor, args)
+	if err != nil {
+		return nil, meta, err
+	}
+	if seek != "" {
+		conds = append(conds, seek)
+	}
+	n = len(args) + 1
+	args = append(args, req.Limit+1, req.Offset())
 
 	rows, err := r.db.Query(ctx,
-		`SELECT `+guestColumns+`, COUNT(*) OVER() AS total
-		 FROM guests `+where+`ORDER BY created_at DESC
+		`SELECT `+guestColumns+order.Columns()+`
+		 FROM guests `+whereClause(conds)+`ORDER BY `+order.OrderBy(req.Cursor)+`
 		 LIMIT $`+strconv.Itoa(n)+` OFFSET $`+strconv.Itoa(n+1), args...)
 	if err != nil {
-		slog.Error("guest.repo list: query failed", "error", err)
-		return nil, 0, err
+		slog.ErrorContext(ctx, "guest.repo list: query failed", "error", err)
+		return nil, meta, pagination.QueryError(err, req.Cursor)
 	}
 	defer rows.Close()
 
 	var guests []Guest
-	var total int
+	var keys [][]string
 	for rows.Next() {
 		var g Guest
-		if err := rows.Scan(&g.ID, &g.FirstName, &g.LastName, &g.Relationship, &g.Attending, &g.FamilyGroup, &g.CreatedBy, &g.UpdatedBy, &g.CreatedAt, &g.UpdatedAt, &total); err != nil {
-			slog.Error("guest.repo list: scan failed", "error", err)
-			return nil, 0, err
+		key := make([]string, len(order.Keys))
+		dest := []any{&g.ID, &g.FirstName, &g.LastName, &g.Relationship, &g.Attending, &g.FamilyGroup, &g.CreatedBy, &g.UpdatedBy, &g.CreatedAt, &g.UpdatedAt, &g.CheckedInAt}
+		for i := range key {
+			dest = append(dest, &key[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			slog.ErrorContext(ctx, "guest.repo list: scan failed", "error", err)
+			return nil, meta, err
 		}
 		guests = append(guests, g)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, meta, pagination.QueryError(err, req.Cursor)
 	}
 
+	guests, page := pagination.Finish(req, order, guests, keys)
+	page.Total = meta.Total
 	if guests == nil {
 		guests = []Guest{}
 	}
+	return guests, page, nil
+}
 
-	return guests, total, rows.Err()
+func whereClause(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conds, " AND ") + " "
 }
 
 func (r *PostgresRepository) Stats(ctx context.Context) (Stats, error) {
@@ -104,10 +146,11 @@ func (r *PostgresRepository) Stats(ctx context.Context) (Stats, error) {
 		`SELECT COUNT(*),
 		        COUNT(*) FILTER (WHERE attending IS TRUE),
 		        COUNT(*) FILTER (WHERE attending IS NULL),
-		        COUNT(*) FILTER (WHERE attending IS FALSE)
-		 FROM guests`).Scan(&s.Total, &s.Confirmed, &s.Pending, &s.Declined)
+		        COUNT(*) FILTER (WHERE attending IS FALSE),
+		        COUNT(checked_in_at)
+		 FROM guests`).Scan(&s.Total, &s.Confirmed, &s.Pending, &s.Declined, &s.CheckedIn)
 	if err != nil {
-		slog.Error("guest.repo stats: query failed", "error", err)
+		slog.ErrorContext(ctx, "guest.repo stats: query failed", "error", err)
 		return Stats{}, err
 	}
 	return s, nil
@@ -120,7 +163,7 @@ func (r *PostgresRepository) GetByIDAny(ctx context.Context, id int64) (*Guest,
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, apperror.NotFound("guest not found")
 		}
-		slog.Error("guest.repo get_by_id_any: query failed", "id", id, "error", err)
+		slog.ErrorContext(ctx, "guest.repo get_by_id_any: query failed", "id", id, "error", err)
 		return nil, err
 	}
 	return &g, nil
@@ -130,7 +173,7 @@ func (r *PostgresRepository) ListByFamilyGroup(ctx context.Context, familyGroup
 	rows, err := r.db.Query(ctx,
 		`SELECT `+guestColumns+` FROM guests WHERE family_group = $1 ORDER BY id`, familyGroup)
 	if err != nil {
-		slog.Error("guest.repo list_by_family_group: query failed", "family_group", familyGroup, "error", err)
+		slog.ErrorContext(ctx, "guest.repo list_by_family_group: query failed", "family_group", familyGroup, "error", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -138,8 +181,8 @@ func (r *PostgresRepository) ListByFamilyGroup(ctx context.Context, familyGroup
 	guests := []Guest{}
 	for rows.Next() {
 		var g Guest
-		if err := rows.Scan(&g.ID, &g.FirstName, &g.LastName, &g.Relationship, &g.Attending, &g.FamilyGroup, &g.CreatedBy, &g.UpdatedBy, &g.CreatedAt, &g.UpdatedAt); err != nil {
-			slog.Error("guest.repo list_by_family_group: scan failed", "error", err)
+		if err := rows.Scan(&g.ID, &g.FirstName, &g.LastName, &g.Relationship, &g.Attending, &g.FamilyGroup, &g.CreatedBy, &g.UpdatedBy, &g.CreatedAt, &g.UpdatedAt, &g.CheckedInAt); err != nil {
+			slog.ErrorContext(ctx, "guest.repo list_by_family_group: scan failed", "error", err)
 			return nil, err
 		}
 		guests = append(guests, g)
@@ -154,7 +197,7 @@ func (r *PostgresRepository) GetByIDs(ctx context.Context, ids []int64) ([]Guest
 	rows, err := r.db.Query(ctx,
 		`SELECT `+guestColumns+` FROM guests WHERE id = ANY($1::bigint[])`, ids)
 	if err != nil {
-		slog.Error("guest.repo get_by_ids: query failed", "ids", ids, "error", err)
+		slog.ErrorContext(ctx, "guest.repo get_by_ids: query failed", "ids", ids, "error", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -162,8 +205,8 @@ func (r *PostgresRepository) GetByIDs(ctx context.Context, ids []int64) ([]Guest
 	guests := []Guest{}
 	for rows.Next() {
 		var g Guest
-		if err := rows.Scan(&g.ID, &g.FirstName, &g.LastName, &g.Relationship, &g.Attending, &g.FamilyGroup, &g.CreatedBy, &g.UpdatedBy, &g.CreatedAt, &g.UpdatedAt); err != nil {
-			slog.Error("guest.repo get_by_ids: scan failed", "error", err)
+		if err := rows.Scan(&g.ID, &g.FirstName, &g.LastName, &g.Relationship, &g.Attending, &g.FamilyGroup, &g.CreatedBy, &g.UpdatedBy, &g.CreatedAt, &g.UpdatedAt, &g.CheckedInAt); err != nil {
+			slog.ErrorContext(ctx, "guest.repo get_by_ids: scan failed", "error", err)
 			return nil, err
 		}
 		guests = append(guests, g)
@@ -178,7 +221,7 @@ func (r *PostgresRepository) GetByName(ctx context.Context, firstName, lastName
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
-		slog.Error("guest.repo get_by_name: query failed", "first_name", firstName, "last_name", lastName, "error", err)
+		slog.ErrorContext(ctx, "guest.repo get_by_name: query failed", "first_name", firstName, "last_name", lastName, "error", err)
 		return nil, err
 	}
 	return &g, nil
@@ -187,25 +230,28 @@ func (r *PostgresRepository) GetByName(ctx context.Context, firstName, lastName
 func (r *PostgresRepository) FamilyGroupExists(ctx context.Context, familyGroup int64) (bool, error) {
 	var exists bool
 	err := r.db.QueryRow(ctx,
-		`SELECT EXISTS(SELECT 1 FROM guests WHERE family_group = $1)`, familyGroup).
+		`SELECT EXISTS(SELECT 1 FROM households WHERE id = $1)`, familyGroup).
 		Scan(&exists)
 	if err != nil {
-		slog.Error("guest.repo family_group_exists: query failed", "family_group", familyGroup, "error", err)
+		slog.ErrorContext(ctx, "guest.repo family_group_exists: query failed", "family_group", familyGroup, "error", err)
 		return false, err
 	}
 
 	return exists, nil
 }
 
-func (r *PostgresRepository) GetNextFamilyGroup(ctx context.Context) (int64, error) {
-	var nextFamilyGroup int64
-	err := r.db.QueryRow(ctx, `SELECT COALESCE(MAX(family_group), 0) + 1 FROM guests`).Scan(&nextFamilyGroup)
+// CreateHousehold adds an empty household and returns its id, the
+// family_group of the guests placed in it.
+func (r *PostgresRepository) CreateHousehold(ctx context.Context, name, userRACF string) (int64, error) {
+	var id int64
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO households (name, created_by, updated_by) VALUES ($1, $2, $2) RETURNING id`,
+		name, userRACF).Scan(&id)
 	if err != nil {
-		slog.Error("guest.repo get_next_family_group: query failed", "error", err)
+		slog.ErrorContext(ctx, "guest.repo create_household: insert failed", "error", err)
 		return 0, err
 	}
-
-	return nextFamilyGroup, nil
+	return id, nil
 }
 
 func (r *PostgresRepository) Create(ctx context.Context, input CreateGuestInput, userRACF string) (*Guest, error) {
@@ -219,34 +265,49 @@ func (r *PostgresRepository) Create(ctx context.Context, input CreateGuestInput,
 		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
 			return nil, apperror.Conflict(fmt.Sprintf("a guest named '%s %s' already exists", input.FirstName, input.LastName))
 		}
-		slog.Error("guest.repo create: insert failed", "error", err)
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return nil, apperror.Validation("family_group not found")
+		}
+		slog.ErrorContext(ctx, "guest.repo create: insert failed", "error", err)
 		return nil, err
 	}
-	slog.Info("guest.repo create: guest stored", "id", g.ID)
+	slog.InfoContext(ctx, "guest.repo create: guest stored", "id", g.ID)
 	return &g, nil
 }
 
+// Update also steps a guest down as head of the household they leave when
+// family_group moves them to another one.
 func (r *PostgresRepository) Update(ctx context.Context, id int64, input UpdateGuestInput, userRACF string) (*Guest, error) {
 	g, err := scanGuest(r.db.QueryRow(ctx,
-		`UPDATE guests SET
-			first_name = COALESCE($1, first_name),
-			last_name = COALESCE($2, last_name),
-			relationship = COALESCE($3, relationship),
-			attending = COALESCE($4, attending),
-			family_group = COALESCE($5, family_group),
-			updated_by = $6,
-			updated_at = now()
-		 WHERE id = $7
-		 RETURNING `+guestColumns,
+		`WITH updated AS (
+			UPDATE guests SET
+				first_name = COALESCE($1, first_name),
+				last_name = COALESCE($2, last_name),
+				relationship = COALESCE($3, relationship),
+				attending = COALESCE($4, attending),
+				family_group = COALESCE($5, family_group),
+				updated_by = $6,
+				updated_at = now()
+			 WHERE id = $7
+			 RETURNING `+guestColumns+`
+		 ), left_head AS (
+			UPDATE households SET head_guest_id = NULL, updated_by = $6, updated_at = now()
+			 WHERE head_guest_id = $7 AND id <> (SELECT family_group FROM updated)
+		 )
+		 SELECT `+guestColumns+` FROM updated`,
 		input.FirstName, input.LastName, input.Relationship, input.Attending, input.FamilyGroup, userRACF, id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, apperror.NotFound("guest not found")
 		}
-		slog.Error("guest.repo update: update failed", "id", id, "error", err)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return nil, apperror.Validation("family_group not found")
+		}
+		slog.ErrorContext(ctx, "guest.repo update: update failed", "id", id, "error", err)
 		return nil, err
 	}
-	slog.Info("guest.repo update: guest updated", "id", g.ID)
+	slog.InfoContext(ctx, "guest.repo update: guest updated", "id", g.ID)
 	return &g, nil
 }
 
@@ -260,10 +321,10 @@ func (r *PostgresRepository) SetAttending(ctx context.Context, id int64, attendi
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, apperror.NotFound("guest not found")
 		}
-		slog.Error("guest.repo set_attending: update failed", "id", id, "error", err)
+		slog.ErrorContext(ctx, "guest.repo set_attending: update failed", "id", id, "error", err)
 		return nil, err
 	}
-	slog.Info("guest.repo set_attending: guest updated", "id", g.ID, "attending", attending)
+	slog.InfoContext(ctx, "guest.repo set_attending: guest updated", "id", g.ID, "attending", attending)
 	return &g, nil
 }
 
@@ -277,7 +338,7 @@ func (r *PostgresRepository) SetAttendingByIDs(ctx context.Context, ids []int64,
 		 RETURNING `+guestColumns,
 		attending, userRACF, ids)
 	if err != nil {
-		slog.Error("guest.repo set_attending_by_ids: update failed", "ids", ids, "error", err)
+		slog.ErrorContext(ctx, "guest.repo set_attending_by_ids: update failed", "ids", ids, "error", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -285,13 +346,13 @@ func (r *PostgresRepository) SetAttendingByIDs(ctx context.Context, ids []int64,
 	guests := []Guest{}
 	for rows.Next() {
 		var g Guest
-		if err := rows.Scan(&g.ID, &g.FirstName, &g.LastName, &g.Relationship, &g.Attending, &g.FamilyGroup, &g.CreatedBy, &g.UpdatedBy, &g.CreatedAt, &g.UpdatedAt); err != nil {
-			slog.Error("guest.repo set_attending_by_ids: scan failed", "error", err)
+		if err := rows.Scan(&g.ID, &g.FirstName, &g.LastName, &g.Relationship, &g.Attending, &g.FamilyGroup, &g.CreatedBy, &g.UpdatedBy, &g.CreatedAt, &g.UpdatedAt, &g.CheckedInAt); err != nil {
+			slog.ErrorContext(ctx, "guest.repo set_attending_by_ids: scan failed", "error", err)
 			return nil, err
 		}
 		guests = append(guests, g)
 	}
-	slog.Info("guest.repo set_attending_by_ids: guests updated", "ids", ids, "attending", attending, "count", len(guests))
+	slog.InfoContext(ctx, "guest.repo set_attending_by_ids: guests updated", "ids", ids, "attending", attending, "count", len(guests))
 	return guests, rows.Err()
 }
 
@@ -302,7 +363,7 @@ func (r *PostgresRepository) SetAttendingByFamilyGroup(ctx context.Context, fami
 		 RETURNING `+guestColumns,
 		attending, userRACF, familyGroup)
 	if err != nil {
-		slog.Error("guest.repo set_attending_by_family_group: update failed", "family_group", familyGroup, "error", err)
+		slog.ErrorContext(ctx, "guest.repo set_attending_by_family_group: update failed", "family_group", familyGroup, "error", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -310,8 +371,8 @@ func (r *PostgresRepository) SetAttendingByFamilyGroup(ctx context.Context, fami
 	var guests []Guest
 	for rows.Next() {
 		var g Guest
-		if err := rows.Scan(&g.ID, &g.FirstName, &g.LastName, &g.Relationship, &g.Attending, &g.FamilyGroup, &g.CreatedBy, &g.UpdatedBy, &g.CreatedAt, &g.UpdatedAt); err != nil {
-			slog.Error("guest.repo set_attending_by_family_group: scan failed", "error", err)
+		if err := rows.Scan(&g.ID, &g.FirstName, &g.LastName, &g.Relationship, &g.Attending, &g.FamilyGroup, &g.CreatedBy, &g.UpdatedBy, &g.CreatedAt, &g.UpdatedAt, &g.CheckedInAt); err != nil {
+			slog.ErrorContext(ctx, "guest.repo set_attending_by_family_group: scan failed", "error", err)
 			return nil, err
 		}
 		guests = append(guests, g)
@@ -321,20 +382,38 @@ func (r *PostgresRepository) SetAttendingByFamilyGroup(ctx context.Context, fami
 		guests = []Guest{}
 	}
 
-	slog.Info("guest.repo set_attending_by_family_group: guests updated", "family_group", familyGroup, "attending", attending, "count", len(guests))
+	slog.InfoContext(ctx, "guest.repo set_attending_by_family_group: guests updated", "family_group", familyGroup, "attending", attending, "count", len(guests))
 	return guests, rows.Err()
 }
 
+func (r *PostgresRepository) CopyCheckIn(ctx context.Context, id, fromID int64) (*Guest, error) {
+	g, err := scanGuest(r.db.QueryRow(ctx,
+		`UPDATE guests SET (checked_in_at, checked_in_by) =
+		   (SELECT checked_in_at, checked_in_by FROM guests WHERE id = $2)
+		 WHERE id = $1
+		 RETURNING `+guestColumns,
+		id, fromID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.NotFound("guest not found")
+		}
+		slog.ErrorContext(ctx, "guest.repo copy_check_in: update failed", "id", id, "from_id", fromID, "error", err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "guest.repo copy_check_in: guest updated", "id", g.ID, "from_id", fromID)
+	return &g, nil
+}
+
 func (r *PostgresRepository) Delete(ctx context.Context, id int64) error {
 	tag, err := r.db.Exec(ctx, `DELETE FROM guests WHERE id = $1`, id)
 	if err != nil {
-		slog.Error("guest.repo delete: delete failed", "id", id, "error", err)
+		slog.ErrorContext(ctx, "guest.repo delete: delete failed", "id", id, "error", err)
 		return err
 	}
 	if tag.RowsAffected() == 0 {
 		return apperror.NotFound("guest not found")
 	}
-	slog.Info("guest.repo delete: guest deleted", "id", id)
+	slog.InfoContext(ctx, "guest.repo delete: guest deleted", "id", id)
 	return nil
 }
 
@@ -348,8 +427,89 @@ func (r *PostgresRepository) GetFamilyGroupByPhone(ctx context.Context, phone st
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
-		slog.Error("guest.repo get_family_group_by_phone: query failed", "phone", phone, "error", err)
+		slog.ErrorContext(ctx, "guest.repo get_family_group_by_phone: query failed", "phone", phone, "error", err)
 		return nil, err
 	}
 	return &familyGroup, nil
 }
+
+// duplicateName is the guest's full name as compared for duplicates:
+// unaccented, lower-case and without the particles ("da", "de", "dos"...)
+// that imports add or drop, so "José da Silva" reads as "jose silva".
+const duplicateName = `btrim(regexp_replace(
+	regexp_replace(search_normalize(first_name || ' ' || last_name), '\m(da|das|de|do|dos|e)\M', ' ', 'g'),
+	'\s+', ' ', 'g'))`
+
+// FindDuplicates pairs guests whose normalized names have a pg_trgm
+// similarity of at least minSimilarity, or whose users share a phone.
+// users.phone is unique, so a shared phone is the same subscriber number
+// under another area code, the usual typo in imported spreadsheets.
+func (r *PostgresRepository) FindDuplicates(ctx context.Context, minSimilarity float64, limit int) ([]DuplicateCandidate, error) {
+	rows, err := r.db.Query(ctx,
+		`WITH g AS (
+		     SELECT `+guestColumns+`, `+duplicateName+` AS norm FROM guests
+		 ), p AS (
+		     SELECT guest_id, right(phone, 9) AS subscriber
+		       FROM users
+		      WHERE guest_id IS NOT NULL AND phone IS NOT NULL
+		 )
+		 SELECT a.id, a.first_name, a.last_name, a.relationship, a.attending, a.family_group, a.created_by, a.updated_by, a.created_at, a.updated_at, a.checked_in_at,
+		        b.id, b.first_name, b.last_name, b.relationship, b.attending, b.family_group, b.created_by, b.updated_by, b.created_at, b.updated_at, b.checked_in_at,
+		        m.sim, m.shared_phone
+		   FROM g a
+		   JOIN g b ON a.id < b.id
+		  CROSS JOIN LATERAL (
+		      SELECT similarity(a.norm, b.norm)::float8 AS sim,
+		             EXISTS (SELECT 1 FROM p pa JOIN p pb ON pb.subscriber = pa.subscriber
+		                      WHERE pa.guest_id = a.id AND pb.guest_id = b.id) AS shared_phone
+		  ) m
+		  WHERE m.sim >= $1 OR m.shared_phone
+		  ORDER BY m.shared_phone DESC, m.sim DESC, a.id, b.id
+		  LIMIT $2`, minSimilarity, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "guest.repo find_duplicates: query failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := []DuplicateCandidate{}
+	for rows.Next() {
+		var c DuplicateCandidate
+		var sharedPhone bool
+		a, b := &c.Guest, &c.Duplicate
+		if err := rows.Scan(
+			&a.ID, &a.FirstName, &a.LastName, &a.Relationship, &a.Attending, &a.FamilyGroup, &a.CreatedBy, &a.UpdatedBy, &a.CreatedAt, &a.UpdatedAt, &a.CheckedInAt,
+			&b.ID, &b.FirstName, &b.LastName, &b.Relationship, &b.Attending, &b.FamilyGroup, &b.CreatedBy, &b.UpdatedBy, &b.CreatedAt, &b.UpdatedAt, &b.CheckedInAt,
+			&c.Similarity, &sharedPhone,
+		); err != nil {
+			slog.ErrorContext(ctx, "guest.repo find_duplicates: scan failed", "error", err)
+			return nil, err
+		}
+		if c.Similarity >= minSimilarity {
+			c.Reasons = append(c.Reasons, DuplicateSimilarName)
+		}
+		if sharedPhone {
+			c.Reasons = append(c.Reasons, DuplicateSharedPhone)
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// ActivityCounts counts the gift transactions and live messages of userIDs,
+// which follow their users when a guest is merged.
+func (r *PostgresRepository) ActivityCounts(ctx context.Context, userIDs []int64) (int, int, error) {
+	if len(userIDs) == 0 {
+		return 0, 0, nil
+	}
+	var transactions, messages int
+	err := r.db.QueryRow(ctx,
+		`SELECT (SELECT COUNT(*) FROM gift_transactions WHERE user_id = ANY($1::bigint[])),
+		        (SELECT COUNT(*) FROM gift_messages WHERE user_id = ANY($1::bigint[]) AND deleted_at IS NULL)`,
+		userIDs).Scan(&transactions, &messages)
+	if err != nil {
+		slog.ErrorContext(ctx, "guest.repo activity_counts: query failed", "user_ids", userIDs, "error", err)
+		return 0, 0, err
+	}
+	return transactions, messages, nil
+}
@@ -2,13 +2,19 @@ package guest
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/audit"
 	"github.com/ferjunior7/parasempre/backend/internal/database"
+	"github.com/ferjunior7/parasempre/backend/internal/importmap"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
+	"github.com/ferjunior7/parasempre/backend/internal/search"
 	"github.com/ferjunior7/parasempre/backend/internal/validate"
 )
 
@@ -23,25 +29,43 @@ type UserBridge interface {
 	UserExistsByURACF(ctx context.Context, uracf string) (bool, error)
 	CreateGuestUserTx(ctx context.Context, tx pgx.Tx, guestID int64, phone *string) error
 	DeleteGuestUserTx(ctx context.Context, tx pgx.Tx, guestID int64) error
+	MoveGuestUsersTx(ctx context.Context, tx pgx.Tx, fromGuestID, toGuestID int64) ([]int64, error)
 	GetGuestIDByPhone(ctx context.Context, phone string) (*int64, error)
 	GetGuestIDByUserID(ctx context.Context, userID int64) (*int64, error)
 	GetURACFByUserID(ctx context.Context, userID int64) (string, error)
 }
 
+const (
+	auditGuestCreated  = "guest.created"
+	auditGuestUpdated  = "guest.updated"
+	auditGuestDeleted  = "guest.deleted"
+	auditGuestImported = "guest.imported"
+)
+
 type Service struct {
 	repo     TxAwareRepository
 	users    UserBridge
 	txRunner database.TxRunner
+	audit    audit.Logger
+	// mappings remembers each user's spreadsheet column mapping; nil
+	// disables it.
+	mappings importmap.Repository
 }
 
-func NewService(repo TxAwareRepository, users UserBridge, txRunner database.TxRunner) *Service {
-	return &Service{repo: repo, users: users, txRunner: txRunner}
+func NewService(repo TxAwareRepository, users UserBridge, txRunner database.TxRunner, audit audit.Logger, mappings importmap.Repository) *Service {
+	return &Service{repo: repo, users: users, txRunner: txRunner, audit: audit, mappings: mappings}
+}
+
+// auditChanges diffs two snapshots, ignoring bookkeeping columns that change
+// on every write.
+func auditChanges(before, after *Guest) map[string]audit.Change {
+	return audit.Diff(before, after, "updated_at", "updated_by")
 }
 
 func (s *Service) ListMyFamily(ctx context.Context, userID int64) ([]Guest, error) {
 	guestID, err := s.users.GetGuestIDByUserID(ctx, userID)
 	if err != nil {
-		slog.Error("guest.service list_my_family: failed to get current user's guest", "user_id", userID, "error", err)
+		slog.ErrorContext(ctx, "guest.service list_my_family: failed to get current user's guest", "user_id", userID, "error", err)
 		return nil, apperror.Internal("failed to verify guest identity", err)
 	}
 	if guestID == nil {
@@ -67,7 +91,7 @@ func (s *Service) SetConfirmedBatch(ctx context.Context, input BatchConfirmInput
 
 	currentUserGuestID, err := s.users.GetGuestIDByUserID(ctx, userID)
 	if err != nil {
-		slog.Error("guest.service set_confirmed_batch: failed to get current user's guest", "user_id", userID, "error", err)
+		slog.ErrorContext(ctx, "guest.service set_confirmed_batch: failed to get current user's guest", "user_id", userID, "error", err)
 		return nil, apperror.Internal("failed to verify guest identity", err)
 	}
 	if currentUserGuestID == nil {
@@ -88,7 +112,7 @@ func (s *Service) SetConfirmedBatch(ctx context.Context, input BatchConfirmInput
 	}
 	for _, target := range targets {
 		if target.FamilyGroup != currentGuest.FamilyGroup {
-			slog.Warn("guest.service set_confirmed_batch: unauthorized cross-family attempt", "user_id", userID, "target_id", target.ID, "caller_family", currentGuest.FamilyGroup, "target_family", target.FamilyGroup)
+			slog.WarnContext(ctx, "guest.service set_confirmed_batch: unauthorized cross-family attempt", "user_id", userID, "target_id", target.ID, "caller_family", currentGuest.FamilyGroup, "target_family", target.FamilyGroup)
 			return nil, apperror.Forbidden("you can only confirm guests in your own family")
 		}
 	}
@@ -111,39 +135,41 @@ func (s *Service) SetConfirmedBatch(ctx context.Context, input BatchConfirmInput
 		return nil, apperror.WrapIfNotApp("failed to update batch confirmation", err)
 	}
 
-	slog.Info("guest.service set_confirmed_batch: success", "ids", input.GuestIDs, "attending", input.Attending, "count", len(updated), "user_id", userID)
+	slog.InfoContext(ctx, "guest.service set_confirmed_batch: success", "ids", input.GuestIDs, "attending", input.Attending, "count", len(updated), "user_id", userID)
 	return updated, nil
 }
 
-func (s *Service) List(ctx context.Context, page, limit int, filters ListFilters) (*PagedResponse, error) {
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 {
-		limit = 20
-	}
-	if limit > 100 {
-		limit = 100
-	}
+func (s *Service) List(ctx context.Context, req pagination.Request, filters ListFilters) (*PagedResponse, error) {
+	req.Normalize(20, 100)
 
-	offset := (page - 1) * limit
-	guests, total, err := s.repo.List(ctx, limit, offset, filters)
+	guests, meta, err := s.repo.List(ctx, req, filters)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		return nil, apperror.Validation("invalid cursor")
+	}
 	if err != nil {
-		slog.Error("guest.service list: failed", "error", err)
+		slog.ErrorContext(ctx, "guest.service list: failed", "error", err)
 		return nil, apperror.Internal("failed to list guests", err)
 	}
+	if filters.Search != "" {
+		for i := range guests {
+			name := guests[i].FirstName + " " + guests[i].LastName
+			if spans := search.Spans(name, filters.Search); len(spans) > 0 {
+				guests[i].Highlight = &search.Highlight{Field: "name", Text: name, Spans: spans}
+			}
+		}
+	}
 	return &PagedResponse{
 		Data:  guests,
-		Page:  page,
-		Limit: limit,
-		Total: total,
+		Page:  req.Page,
+		Limit: req.Limit,
+		Meta:  meta,
 	}, nil
 }
 
 func (s *Service) Stats(ctx context.Context) (*Stats, error) {
 	stats, err := s.repo.Stats(ctx)
 	if err != nil {
-		slog.Error("guest.service stats: failed", "error", err)
+		slog.ErrorContext(ctx, "guest.service stats: failed", "error", err)
 		return nil, apperror.Internal("failed to compute guest stats", err)
 	}
 	return &stats, nil
@@ -164,7 +190,7 @@ func (s *Service) Create(ctx context.Context, input CreateGuestInput, userRACF s
 
 	exists, err := s.users.UserExistsByURACF(ctx, userRACF)
 	if err != nil {
-		slog.Error("guest.service create: user check failed", "user_racf", userRACF, "error", err)
+		slog.ErrorContext(ctx, "guest.service create: user check failed", "user_racf", userRACF, "error", err)
 		return nil, apperror.Internal("failed to verify user", err)
 	}
 	if !exists {
@@ -174,24 +200,25 @@ func (s *Service) Create(ctx context.Context, input CreateGuestInput, userRACF s
 	if input.FamilyGroup != nil {
 		familyGroupExists, err := s.repo.FamilyGroupExists(ctx, *input.FamilyGroup)
 		if err != nil {
-			slog.Error("guest.service create: family_group lookup failed", "error", err)
+			slog.ErrorContext(ctx, "guest.service create: family_group lookup failed", "error", err)
 			return nil, apperror.Internal("failed to validate family_group", err)
 		}
 		if !familyGroupExists {
 			return nil, apperror.Validation("family_group not found")
 		}
-	} else {
-		nextFamilyGroup, err := s.repo.GetNextFamilyGroup(ctx)
-		if err != nil {
-			slog.Error("guest.service create: failed to get next family_group", "error", err)
-			return nil, apperror.Internal("failed to generate family_group", err)
-		}
-		input.FamilyGroup = &nextFamilyGroup
 	}
 
 	var created *Guest
 	if err := s.txRunner.RunInTx(ctx, func(tx pgx.Tx) error {
 		txRepo := s.repo.WithTx(tx)
+		// A guest without a family_group starts a household of their own.
+		if input.FamilyGroup == nil {
+			householdID, err := txRepo.CreateHousehold(ctx, householdName(input.LastName), userRACF)
+			if err != nil {
+				return apperror.Internal("failed to create household", err)
+			}
+			input.FamilyGroup = &householdID
+		}
 		g, err := txRepo.Create(ctx, input, userRACF)
 		if err != nil {
 			return err
@@ -206,7 +233,10 @@ func (s *Service) Create(ctx context.Context, input CreateGuestInput, userRACF s
 		return nil, apperror.WrapIfNotApp("failed to create guest", err)
 	}
 
-	slog.Info("guest.service create: guest+user created", "id", created.ID, "user_racf", userRACF)
+	audit.Record(ctx, s.audit, auditGuestCreated, audit.Entity(audit.EntityGuest, created.ID, map[string]any{
+		"changes": auditChanges(nil, created),
+	}))
+	slog.InfoContext(ctx, "guest.service create: guest+user created", "id", created.ID, "user_racf", userRACF)
 	return created, nil
 }
 
@@ -217,17 +247,26 @@ func (s *Service) Update(ctx context.Context, id int64, input UpdateGuestInput,
 
 	exists, err := s.users.UserExistsByURACF(ctx, userRACF)
 	if err != nil {
-		slog.Error("guest.service update: user check failed", "error", err)
+		slog.ErrorContext(ctx, "guest.service update: user check failed", "error", err)
 		return nil, apperror.Internal("failed to verify user", err)
 	}
 	if !exists {
 		return nil, apperror.Validation("user-racf does not match any registered user")
 	}
 
+	var before *Guest
+	if s.audit != nil {
+		if before, err = s.repo.GetByIDAny(ctx, id); err != nil {
+			return nil, apperror.WrapIfNotApp("failed to get guest", err)
+		}
+	}
+
 	if input.FirstName != nil || input.LastName != nil {
-		current, err := s.repo.GetByIDAny(ctx, id)
-		if err != nil {
-			return nil, err
+		current := before
+		if current == nil {
+			if current, err = s.repo.GetByIDAny(ctx, id); err != nil {
+				return nil, err
+			}
 		}
 		firstName := current.FirstName
 		lastName := current.LastName
@@ -239,7 +278,7 @@ func (s *Service) Update(ctx context.Context, id int64, input UpdateGuestInput,
 		}
 		existing, err := s.repo.GetByName(ctx, firstName, lastName)
 		if err != nil {
-			slog.Error("guest.service update: name lookup failed", "error", err)
+			slog.ErrorContext(ctx, "guest.service update: name lookup failed", "error", err)
 			return nil, apperror.Internal("failed to check name uniqueness", err)
 		}
 		if existing != nil && existing.ID != id {
@@ -251,7 +290,10 @@ func (s *Service) Update(ctx context.Context, id int64, input UpdateGuestInput,
 	if err != nil {
 		return nil, apperror.WrapIfNotApp("failed to update guest", err)
 	}
-	slog.Info("guest.service update: guest updated", "id", guest.ID, "user_racf", userRACF)
+	audit.Record(ctx, s.audit, auditGuestUpdated, audit.Entity(audit.EntityGuest, guest.ID, map[string]any{
+		"changes": auditChanges(before, guest),
+	}))
+	slog.InfoContext(ctx, "guest.service update: guest updated", "id", guest.ID, "user_racf", userRACF)
 	return guest, nil
 }
 
@@ -290,7 +332,7 @@ func (s *Service) CancelFamilyByPhone(ctx context.Context, phone string, userID
 func (s *Service) setAttending(ctx context.Context, id int64, attending bool, userID int64) (*Guest, error) {
 	currentUserGuestID, err := s.users.GetGuestIDByUserID(ctx, userID)
 	if err != nil {
-		slog.Error("guest.service set_attending: failed to get current user's guest", "user_id", userID, "error", err)
+		slog.ErrorContext(ctx, "guest.service set_attending: failed to get current user's guest", "user_id", userID, "error", err)
 		return nil, apperror.Internal("failed to verify guest identity", err)
 	}
 	if currentUserGuestID == nil {
@@ -308,12 +350,12 @@ func (s *Service) setAttending(ctx context.Context, id int64, attending bool, us
 	}
 
 	if target.FamilyGroup != currentGuest.FamilyGroup {
-		slog.Warn("guest.service set_attending: unauthorized cross-family attempt", "user_id", userID, "requested_guest_id", id, "caller_family", currentGuest.FamilyGroup, "target_family", target.FamilyGroup)
+		slog.WarnContext(ctx, "guest.service set_attending: unauthorized cross-family attempt", "user_id", userID, "requested_guest_id", id, "caller_family", currentGuest.FamilyGroup, "target_family", target.FamilyGroup)
 		return nil, apperror.Forbidden("you can only confirm guests in your own family")
 	}
 
 	if target.Attending != nil && *target.Attending == attending {
-		slog.Info("guest.service set_attending: already in desired state, skipping update", "id", id, "attending", attending)
+		slog.InfoContext(ctx, "guest.service set_attending: already in desired state, skipping update", "id", id, "attending", attending)
 		return target, nil
 	}
 
@@ -326,14 +368,14 @@ func (s *Service) setAttending(ctx context.Context, id int64, attending bool, us
 	if err != nil {
 		return nil, apperror.WrapIfNotApp("failed to update attending", err)
 	}
-	slog.Info("guest.service set_attending: success", "id", updated.ID, "attending", attending, "user_id", userID)
+	slog.InfoContext(ctx, "guest.service set_attending: success", "id", updated.ID, "attending", attending, "user_id", userID)
 	return updated, nil
 }
 
 func (s *Service) setAttendingByPhone(ctx context.Context, phone string, attending bool, userID int64) (*Guest, error) {
 	guestID, err := s.users.GetGuestIDByPhone(ctx, phone)
 	if err != nil {
-		slog.Error("guest.service set_attending_by_phone: phone lookup failed", "phone_suffix", lastN(phone, 4), "error", err)
+		slog.ErrorContext(ctx, "guest.service set_attending_by_phone: phone lookup failed", "phone_suffix", lastN(phone, 4), "error", err)
 		return nil, apperror.Internal("failed to find guest by phone", err)
 	}
 	if guestID == nil {
@@ -346,7 +388,7 @@ func (s *Service) setAttendingByPhone(ctx context.Context, phone string, attendi
 func (s *Service) setAttendingFamily(ctx context.Context, familyGroup int64, attending bool, userID int64) ([]Guest, error) {
 	currentUserGuestID, err := s.users.GetGuestIDByUserID(ctx, userID)
 	if err != nil {
-		slog.Error("guest.service set_attending_family: failed to get current user's guest", "user_id", userID, "error", err)
+		slog.ErrorContext(ctx, "guest.service set_attending_family: failed to get current user's guest", "user_id", userID, "error", err)
 		return nil, apperror.Internal("failed to verify guest identity", err)
 	}
 	if currentUserGuestID == nil {
@@ -359,13 +401,13 @@ func (s *Service) setAttendingFamily(ctx context.Context, familyGroup int64, att
 	}
 
 	if currentGuest.FamilyGroup != familyGroup {
-		slog.Warn("guest.service set_attending_family: unauthorized attempt", "user_id", userID, "requested_family", familyGroup, "actual_family", currentGuest.FamilyGroup)
+		slog.WarnContext(ctx, "guest.service set_attending_family: unauthorized attempt", "user_id", userID, "requested_family", familyGroup, "actual_family", currentGuest.FamilyGroup)
 		return nil, apperror.Forbidden("you can only confirm your own family's attendance")
 	}
 
 	familyGroupExists, err := s.repo.FamilyGroupExists(ctx, familyGroup)
 	if err != nil {
-		slog.Error("guest.service set_attending_family: family group lookup failed", "family_group", familyGroup, "error", err)
+		slog.ErrorContext(ctx, "guest.service set_attending_family: family group lookup failed", "family_group", familyGroup, "error", err)
 		return nil, apperror.Internal("failed to validate family_group", err)
 	}
 	if !familyGroupExists {
@@ -382,14 +424,14 @@ func (s *Service) setAttendingFamily(ctx context.Context, familyGroup int64, att
 		return nil, apperror.WrapIfNotApp("failed to update family attending", err)
 	}
 
-	slog.Info("guest.service set_attending_family: success", "family_group", familyGroup, "attending", attending, "count", len(guests), "user_id", userID)
+	slog.InfoContext(ctx, "guest.service set_attending_family: success", "family_group", familyGroup, "attending", attending, "count", len(guests), "user_id", userID)
 	return guests, nil
 }
 
 func (s *Service) setAttendingFamilyByPhone(ctx context.Context, phone string, attending bool, userID int64) ([]Guest, error) {
 	familyGroup, err := s.repo.GetFamilyGroupByPhone(ctx, phone)
 	if err != nil {
-		slog.Error("guest.service set_attending_family_by_phone: phone lookup failed", "phone_suffix", lastN(phone, 4), "error", err)
+		slog.ErrorContext(ctx, "guest.service set_attending_family_by_phone: phone lookup failed", "phone_suffix", lastN(phone, 4), "error", err)
 		return nil, apperror.Internal("failed to find family by phone", err)
 	}
 	if familyGroup == nil {
@@ -399,31 +441,81 @@ func (s *Service) setAttendingFamilyByPhone(ctx context.Context, phone string, a
 	return s.setAttendingFamily(ctx, *familyGroup, attending, userID)
 }
 
-func (s *Service) Import(ctx context.Context, guests []CreateGuestInput, userRACF string) ImportResponse {
+// PreviewImport reads t with mapping (suggested from the headers and the
+// user's last import when nil) without creating anyone. While required
+// fields stay unmapped only the columns are returned.
+func (s *Service) PreviewImport(ctx context.Context, t *importmap.Table, mapping importmap.Mapping) (*ImportPreview, error) {
+	plan, cols, err := importmap.Prepare(ctx, s.mappings, importmap.KindGuest, guestImportFields, t, mapping)
+	if err != nil {
+		return nil, apperror.Validation(fmt.Sprintf("invalid column mapping: %s", err.Error()))
+	}
+	preview := &ImportPreview{Columns: plan, Rows: []ImportRow{}}
+	if cols == nil {
+		return preview, nil
+	}
+	preview.Rows = parseRows(t, cols)
+	for _, row := range preview.Rows {
+		if len(row.Errors) > 0 {
+			preview.Invalid++
+		} else {
+			preview.Valid++
+		}
+	}
+	return preview, nil
+}
+
+// Import creates a guest per row of t. Rows that fail to parse or to save
+// are reported and skipped; the rest are created. The mapping used is
+// remembered for the user's next import.
+func (s *Service) Import(ctx context.Context, t *importmap.Table, mapping importmap.Mapping, userRACF string) (ImportResponse, error) {
+	plan, cols, err := importmap.Prepare(ctx, s.mappings, importmap.KindGuest, guestImportFields, t, mapping)
+	if err != nil {
+		return ImportResponse{}, apperror.Validation(fmt.Sprintf("invalid column mapping: %s", err.Error()))
+	}
+	if cols == nil {
+		return ImportResponse{}, apperror.Validation((&importmap.MissingColumnsError{Fields: plan.Missing}).Error())
+	}
+
+	rows := parseRows(t, cols)
 	var successCount int
-	var rowErrors []ImportRowError
-	const dataRowStart = 2
-	for i, input := range guests {
-		rowNumber := i + dataRowStart
-		if _, err := s.Create(ctx, input, userRACF); err != nil {
-			slog.Warn("guest.service import: row failed", "row", rowNumber, "error", err)
-			rowErrors = append(rowErrors, ImportRowError{Row: rowNumber, Error: err.Error()})
+	rowErrors := []ImportRowError{}
+	for _, row := range rows {
+		if len(row.Errors) > 0 {
+			rowErrors = append(rowErrors, ImportRowError{Row: row.Row, Error: strings.Join(row.Errors, "; ")})
+			continue
+		}
+		if _, err := s.Create(ctx, row.Input, userRACF); err != nil {
+			slog.WarnContext(ctx, "guest.service import: row failed", "row", row.Row, "error", err)
+			rowErrors = append(rowErrors, ImportRowError{Row: row.Row, Error: err.Error()})
 			continue
 		}
 		successCount++
 	}
-	if rowErrors == nil {
-		rowErrors = []ImportRowError{}
+	if successCount > 0 {
+		importmap.Remember(ctx, s.mappings, importmap.KindGuest, plan.Mapping)
 	}
+	audit.Record(ctx, s.audit, auditGuestImported, map[string]any{
+		"total":         len(rows),
+		"success_count": successCount,
+		"error_count":   len(rowErrors),
+	})
 	return ImportResponse{
 		SuccessCount: successCount,
 		ErrorCount:   len(rowErrors),
-		Total:        len(guests),
+		Total:        len(rows),
 		Errors:       rowErrors,
-	}
+	}, nil
 }
 
 func (s *Service) Delete(ctx context.Context, id int64) error {
+	var before *Guest
+	if s.audit != nil {
+		var err error
+		if before, err = s.repo.GetByIDAny(ctx, id); err != nil {
+			return apperror.WrapIfNotApp("failed to get guest", err)
+		}
+	}
+
 	if err := s.txRunner.RunInTx(ctx, func(tx pgx.Tx) error {
 		if err := s.users.DeleteGuestUserTx(ctx, tx, id); err != nil {
 			return err
@@ -433,6 +525,9 @@ func (s *Service) Delete(ctx context.Context, id int64) error {
 	}); err != nil {
 		return apperror.WrapIfNotApp("failed to delete guest", err)
 	}
-	slog.Info("guest.service delete: guest deleted", "id", id)
+	audit.Record(ctx, s.audit, auditGuestDeleted, audit.Entity(audit.EntityGuest, id, map[string]any{
+		"changes": auditChanges(before, nil),
+	}))
+	slog.InfoContext(ctx, "guest.service delete: guest deleted", "id", id)
 	return nil
 }
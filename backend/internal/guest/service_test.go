@@ -11,17 +11,21 @@ import (
 	"github.com/jackc/pgx/v5"
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/audit"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
+	"github.com/ferjunior7/parasempre/backend/internal/search"
 )
 
 type mockRepository struct {
-	listFn                      func(ctx context.Context, limit, offset int, filters ListFilters) ([]Guest, int, error)
+	listFn                      func(ctx context.Context, req pagination.Request, filters ListFilters) ([]Guest, pagination.Meta, error)
 	statsFn                     func(ctx context.Context) (Stats, error)
 	listByFamilyGroupFn         func(ctx context.Context, familyGroup int64) ([]Guest, error)
 	getByIDAnyFn                func(ctx context.Context, id int64) (*Guest, error)
 	getByIDsFn                  func(ctx context.Context, ids []int64) ([]Guest, error)
 	getByNameFn                 func(ctx context.Context, firstName, lastName string) (*Guest, error)
 	familyGroupExistsFn         func(ctx context.Context, familyGroup int64) (bool, error)
-	getNextFamilyGroupFn        func(ctx context.Context) (int64, error)
+	createHouseholdFn           func(ctx context.Context, name, userRACF string) (int64, error)
 	createFn                    func(ctx context.Context, input CreateGuestInput, userRACF string) (*Guest, error)
 	updateFn                    func(ctx context.Context, id int64, input UpdateGuestInput, userRACF string) (*Guest, error)
 	deleteFn                    func(ctx context.Context, id int64) error
@@ -29,10 +33,13 @@ type mockRepository struct {
 	setAttendingByFamilyGroupFn func(ctx context.Context, familyGroup int64, attending bool, userRACF string) ([]Guest, error)
 	setAttendingByIDsFn         func(ctx context.Context, ids []int64, attending bool, userRACF string) ([]Guest, error)
 	getFamilyGroupByPhoneFn     func(ctx context.Context, phone string) (*int64, error)
+	findDuplicatesFn            func(ctx context.Context, minSimilarity float64, limit int) ([]DuplicateCandidate, error)
+	activityCountsFn            func(ctx context.Context, userIDs []int64) (int, int, error)
+	copyCheckInFn               func(ctx context.Context, id, fromID int64) (*Guest, error)
 }
 
-func (m *mockRepository) List(ctx context.Context, limit, offset int, filters ListFilters) ([]Guest, int, error) {
-	return m.listFn(ctx, limit, offset, filters)
+func (m *mockRepository) List(ctx context.Context, req pagination.Request, filters ListFilters) ([]Guest, pagination.Meta, error) {
+	return m.listFn(ctx, req, filters)
 }
 
 func (m *mockRepository) Stats(ctx context.Context) (Stats, error) {
@@ -77,9 +84,9 @@ func (m *mockRepository) FamilyGroupExists(ctx context.Context, familyGroup int6
 	return true, nil
 }
 
-func (m *mockRepository) GetNextFamilyGroup(ctx context.Context) (int64, error) {
-	if m.getNextFamilyGroupFn != nil {
-		return m.getNextFamilyGroupFn(ctx)
+func (m *mockRepository) CreateHousehold(ctx context.Context, name, userRACF string) (int64, error) {
+	if m.createHouseholdFn != nil {
+		return m.createHouseholdFn(ctx, name, userRACF)
 	}
 	return 1, nil
 }
@@ -124,6 +131,27 @@ func (m *mockRepository) GetFamilyGroupByPhone(ctx context.Context, phone string
 	return nil, nil
 }
 
+func (m *mockRepository) FindDuplicates(ctx context.Context, minSimilarity float64, limit int) ([]DuplicateCandidate, error) {
+	if m.findDuplicatesFn != nil {
+		return m.findDuplicatesFn(ctx, minSimilarity, limit)
+	}
+	return []DuplicateCandidate{}, nil
+}
+
+func (m *mockRepository) ActivityCounts(ctx context.Context, userIDs []int64) (int, int, error) {
+	if m.activityCountsFn != nil {
+		return m.activityCountsFn(ctx, userIDs)
+	}
+	return 0, 0, nil
+}
+
+func (m *mockRepository) CopyCheckIn(ctx context.Context, id, fromID int64) (*Guest, error) {
+	if m.copyCheckInFn != nil {
+		return m.copyCheckInFn(ctx, id, fromID)
+	}
+	return nil, nil
+}
+
 func (m *mockRepository) WithTx(_ pgx.Tx) Repository {
 	return m
 }
@@ -135,6 +163,7 @@ type mockUserBridge struct {
 	getGuestIDByPhoneFn  func(ctx context.Context, phone string) (*int64, error)
 	getGuestIDByUserIDFn func(ctx context.Context, userID int64) (*int64, error)
 	getURACFByUserIDFn   func(ctx context.Context, userID int64) (string, error)
+	moveFn               func(ctx context.Context, tx pgx.Tx, fromGuestID, toGuestID int64) ([]int64, error)
 }
 
 func (m *mockUserBridge) UserExistsByURACF(ctx context.Context, uracf string) (bool, error) {
@@ -179,6 +208,28 @@ func (m *mockUserBridge) GetURACFByUserID(ctx context.Context, userID int64) (st
 	return "TST01", nil
 }
 
+func (m *mockUserBridge) MoveGuestUsersTx(ctx context.Context, tx pgx.Tx, fromGuestID, toGuestID int64) ([]int64, error) {
+	if m.moveFn != nil {
+		return m.moveFn(ctx, tx, fromGuestID, toGuestID)
+	}
+	return nil, nil
+}
+
+type mockAudit struct {
+	calls []auditCall
+}
+
+type auditCall struct {
+	userID  int64
+	action  string
+	details map[string]any
+}
+
+func (m *mockAudit) LogAction(_ context.Context, userID int64, action string, details map[string]any) error {
+	m.calls = append(m.calls, auditCall{userID: userID, action: action, details: details})
+	return nil
+}
+
 type mockTxRunner struct{}
 
 func (m *mockTxRunner) RunInTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
@@ -189,6 +240,8 @@ func strPtr(s string) *string { return &s }
 
 func int64Ptr(v int64) *int64 { return &v }
 
+func intPtr(v int) *int { return &v }
+
 func boolPtr(b bool) *bool { return &b }
 
 func sampleGuest() Guest {
@@ -243,7 +296,7 @@ func assertAppError(t *testing.T, err error, wantCode int, wantMsg string) {
 func TestServiceList(t *testing.T) {
 	tests := []struct {
 		name      string
-		mockFn    func(ctx context.Context, limit, offset int, filters ListFilters) ([]Guest, int, error)
+		mockFn    func(ctx context.Context, req pagination.Request, filters ListFilters) ([]Guest, pagination.Meta, error)
 		page      int
 		limit     int
 		wantLen   int
@@ -252,8 +305,8 @@ func TestServiceList(t *testing.T) {
 	}{
 		{
 			name: "returns guests with pagination",
-			mockFn: func(ctx context.Context, limit, offset int, filters ListFilters) ([]Guest, int, error) {
-				return []Guest{sampleGuest()}, 5, nil
+			mockFn: func(ctx context.Context, req pagination.Request, filters ListFilters) ([]Guest, pagination.Meta, error) {
+				return []Guest{sampleGuest()}, pagination.Meta{Total: intPtr(5)}, nil
 			},
 			page:      1,
 			limit:     20,
@@ -262,8 +315,8 @@ func TestServiceList(t *testing.T) {
 		},
 		{
 			name: "returns empty list",
-			mockFn: func(ctx context.Context, limit, offset int, filters ListFilters) ([]Guest, int, error) {
-				return []Guest{}, 0, nil
+			mockFn: func(ctx context.Context, req pagination.Request, filters ListFilters) ([]Guest, pagination.Meta, error) {
+				return []Guest{}, pagination.Meta{Total: intPtr(0)}, nil
 			},
 			page:    1,
 			limit:   20,
@@ -271,30 +324,30 @@ func TestServiceList(t *testing.T) {
 		},
 		{
 			name: "defaults for invalid page/limit",
-			mockFn: func(ctx context.Context, limit, offset int, filters ListFilters) ([]Guest, int, error) {
-				if limit != 20 || offset != 0 {
-					return nil, 0, errors.New("expected default limit=20, offset=0")
+			mockFn: func(ctx context.Context, req pagination.Request, filters ListFilters) ([]Guest, pagination.Meta, error) {
+				if req.Limit != 20 || req.Offset() != 0 {
+					return nil, pagination.Meta{}, errors.New("expected default limit=20, offset=0")
 				}
-				return []Guest{}, 0, nil
+				return []Guest{}, pagination.Meta{Total: intPtr(0)}, nil
 			},
 			page:  0,
 			limit: 0,
 		},
 		{
 			name: "caps limit at 100",
-			mockFn: func(ctx context.Context, limit, offset int, filters ListFilters) ([]Guest, int, error) {
-				if limit != 100 {
-					return nil, 0, errors.New("expected limit capped at 100")
+			mockFn: func(ctx context.Context, req pagination.Request, filters ListFilters) ([]Guest, pagination.Meta, error) {
+				if req.Limit != 100 {
+					return nil, pagination.Meta{}, errors.New("expected limit capped at 100")
 				}
-				return []Guest{}, 0, nil
+				return []Guest{}, pagination.Meta{Total: intPtr(0)}, nil
 			},
 			page:  1,
 			limit: 500,
 		},
 		{
 			name: "propagates error",
-			mockFn: func(ctx context.Context, limit, offset int, filters ListFilters) ([]Guest, int, error) {
-				return nil, 0, errors.New("db error")
+			mockFn: func(ctx context.Context, req pagination.Request, filters ListFilters) ([]Guest, pagination.Meta, error) {
+				return nil, pagination.Meta{}, errors.New("db error")
 			},
 			page:    1,
 			limit:   20,
@@ -305,7 +358,7 @@ func TestServiceList(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			svc := newTestService(&mockRepository{listFn: tt.mockFn}, defaultUserBridge())
-			result, err := svc.List(context.Background(), tt.page, tt.limit, ListFilters{})
+			result, err := svc.List(context.Background(), pagination.Request{Page: tt.page, Limit: tt.limit}, ListFilters{})
 			if tt.wantErr {
 				if err == nil {
 					t.Fatal("expected error, got nil")
@@ -318,8 +371,8 @@ func TestServiceList(t *testing.T) {
 			if len(result.Data) != tt.wantLen {
 				t.Fatalf("expected %d guests, got %d", tt.wantLen, len(result.Data))
 			}
-			if result.Total != tt.wantTotal {
-				t.Fatalf("expected total %d, got %d", tt.wantTotal, result.Total)
+			if result.Total == nil || *result.Total != tt.wantTotal {
+				t.Fatalf("expected total %d, got %v", tt.wantTotal, result.Total)
 			}
 		})
 	}
@@ -328,14 +381,14 @@ func TestServiceList(t *testing.T) {
 func TestServiceListForwardsFilters(t *testing.T) {
 	var got ListFilters
 	svc := newTestService(&mockRepository{
-		listFn: func(ctx context.Context, limit, offset int, filters ListFilters) ([]Guest, int, error) {
+		listFn: func(ctx context.Context, req pagination.Request, filters ListFilters) ([]Guest, pagination.Meta, error) {
 			got = filters
-			return []Guest{}, 0, nil
+			return []Guest{}, pagination.Meta{Total: intPtr(0)}, nil
 		},
 	}, defaultUserBridge())
 
 	want := ListFilters{Search: "maria", Relationship: "R", Attending: "pending"}
-	if _, err := svc.List(context.Background(), 1, 20, want); err != nil {
+	if _, err := svc.List(context.Background(), pagination.Request{Page: 1, Limit: 20}, want); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if got != want {
@@ -343,6 +396,23 @@ func TestServiceListForwardsFilters(t *testing.T) {
 	}
 }
 
+func TestServiceListHighlightsSearchMatches(t *testing.T) {
+	svc := newTestService(&mockRepository{
+		listFn: func(ctx context.Context, req pagination.Request, filters ListFilters) ([]Guest, pagination.Meta, error) {
+			return []Guest{{FirstName: "João", LastName: "Silva"}}, pagination.Meta{Total: intPtr(1)}, nil
+		},
+	}, defaultUserBridge())
+
+	resp, err := svc.List(context.Background(), pagination.Request{Page: 1, Limit: 20}, ListFilters{Search: "joao silva"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := resp.Data[0].Highlight
+	if h == nil || h.Text != "João Silva" || len(h.Spans) != 2 || h.Spans[0] != (search.Span{Start: 0, End: 4}) || h.Spans[1] != (search.Span{Start: 5, End: 10}) {
+		t.Fatalf("unexpected highlight %+v", h)
+	}
+}
+
 func TestServiceStats(t *testing.T) {
 	svc := newTestService(&mockRepository{
 		statsFn: func(ctx context.Context) (Stats, error) {
@@ -577,7 +647,10 @@ func TestServiceCreateAutoAssignFamilyGroup(t *testing.T) {
 		getByNameFn: func(ctx context.Context, firstName, lastName string) (*Guest, error) {
 			return nil, nil
 		},
-		getNextFamilyGroupFn: func(ctx context.Context) (int64, error) {
+		createHouseholdFn: func(ctx context.Context, name, userRACF string) (int64, error) {
+			if name != "Família Santos" {
+				t.Errorf("expected the household named after the guest, got %q", name)
+			}
 			return 42, nil
 		},
 		createFn: func(ctx context.Context, input CreateGuestInput, userRACF string) (*Guest, error) {
@@ -1383,3 +1456,86 @@ func TestServiceDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceUpdateRecordsAuditDiff(t *testing.T) {
+	before := sampleGuest()
+	repo := &mockRepository{
+		getByIDAnyFn: func(ctx context.Context, id int64) (*Guest, error) {
+			g := before
+			return &g, nil
+		},
+		updateFn: func(ctx context.Context, id int64, input UpdateGuestInput, userRACF string) (*Guest, error) {
+			g := before
+			g.Relationship = *input.Relationship
+			g.UpdatedBy = userRACF
+			g.UpdatedAt = time.Now().Add(time.Minute)
+			return &g, nil
+		},
+	}
+	log := &mockAudit{}
+	svc := newTestService(repo, defaultUserBridge())
+	svc.audit = log
+
+	ctx := reqctx.WithUserID(context.Background(), 7)
+	if _, err := svc.Update(ctx, 1, UpdateGuestInput{Relationship: strPtr("R")}, "TST02"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(log.calls) != 1 {
+		t.Fatalf("expected 1 audit call, got %d", len(log.calls))
+	}
+	call := log.calls[0]
+	if call.userID != 7 || call.action != auditGuestUpdated {
+		t.Fatalf("unexpected audit call: %+v", call)
+	}
+	if call.details["entity_type"] != audit.EntityGuest || call.details["entity_id"] != int64(1) {
+		t.Errorf("expected guest entity tags, got %v", call.details)
+	}
+	changes, ok := call.details["changes"].(map[string]audit.Change)
+	if !ok {
+		t.Fatalf("expected changes map, got %T", call.details["changes"])
+	}
+	if len(changes) != 1 || changes["relationship"].Before != "P" || changes["relationship"].After != "R" {
+		t.Errorf("expected only relationship P->R, got %v", changes)
+	}
+}
+
+func TestServiceDeleteRecordsAuditSnapshot(t *testing.T) {
+	repo := &mockRepository{
+		getByIDAnyFn: func(ctx context.Context, id int64) (*Guest, error) {
+			g := sampleGuest()
+			return &g, nil
+		},
+		deleteFn: func(ctx context.Context, id int64) error { return nil },
+	}
+	log := &mockAudit{}
+	svc := newTestService(repo, defaultUserBridge())
+	svc.audit = log
+
+	if err := svc.Delete(reqctx.WithUserID(context.Background(), 7), 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(log.calls) != 1 || log.calls[0].action != auditGuestDeleted {
+		t.Fatalf("expected guest.deleted audit, got %+v", log.calls)
+	}
+	changes := log.calls[0].details["changes"].(map[string]audit.Change)
+	if changes["first_name"].Before != "João" || changes["first_name"].After != nil {
+		t.Errorf("expected deleted snapshot in changes, got %v", changes)
+	}
+}
+
+func TestServiceAuditSkippedWithoutActor(t *testing.T) {
+	repo := &mockRepository{
+		deleteFn: func(ctx context.Context, id int64) error { return nil },
+	}
+	log := &mockAudit{}
+	svc := newTestService(repo, defaultUserBridge())
+	svc.audit = log
+
+	if err := svc.Delete(context.Background(), 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(log.calls) != 0 {
+		t.Fatalf("expected no audit without an authenticated actor, got %+v", log.calls)
+	}
+}
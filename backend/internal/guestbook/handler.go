@@ -0,0 +1,169 @@
+package guestbook
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/giftmessage"
+	"github.com/ferjunior7/parasempre/backend/internal/httputil"
+	"github.com/ferjunior7/parasempre/backend/internal/middleware"
+)
+
+const (
+	maxSignBodyBytes  = 55 << 20
+	multipartInMemory = 1 << 20
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) HandleSign(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+	if userID == 0 {
+		httputil.WriteError(w, r, apperror.Unauthorized("autenticação obrigatória"))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSignBodyBytes)
+	if err := r.ParseMultipartForm(multipartInMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			httputil.WriteError(w, r, apperror.Validation("arquivo excede o tamanho máximo permitido"))
+			return
+		}
+		httputil.WriteError(w, r, apperror.Validation("formato multipart inválido"))
+		return
+	}
+
+	input := SignInput{
+		AuthorName: r.FormValue("author_name"),
+		Content:    r.FormValue("content"),
+	}
+
+	var media *giftmessage.Media
+	file, header, err := r.FormFile("media")
+	switch err {
+	case nil:
+		defer file.Close()
+		if header.Size <= 0 {
+			httputil.WriteError(w, r, apperror.Validation("arquivo de mídia vazio"))
+			return
+		}
+		media = &giftmessage.Media{
+			DeclaredMime: header.Header.Get("Content-Type"),
+			Size:         header.Size,
+			Reader:       file,
+		}
+	case http.ErrMissingFile:
+	default:
+		httputil.WriteError(w, r, apperror.Validation("falha ao ler arquivo de mídia"))
+		return
+	}
+
+	entry, err := h.svc.Sign(r.Context(), userID, input, media)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("falha ao assinar livro", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusCreated, h.svc.signSingle(r.Context(), *entry))
+}
+
+func (h *Handler) HandleGetMine(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+	if userID == 0 {
+		httputil.WriteError(w, r, apperror.Unauthorized("autenticação obrigatória"))
+		return
+	}
+	entry, err := h.svc.GetMine(r.Context(), userID)
+	if err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	if entry == nil {
+		httputil.WriteError(w, r, apperror.NotFound("assinatura não encontrada"))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, entry)
+}
+
+func (h *Handler) HandleList(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	resp, err := h.svc.List(r.Context(), page, limit)
+	if err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) HandleModerationQueue(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	resp, err := h.svc.ModerationQueue(r.Context(), q.Get("status"), page, limit)
+	if err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) HandleModerate(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+	if userID == 0 {
+		httputil.WriteError(w, r, apperror.Unauthorized("autenticação obrigatória"))
+		return
+	}
+	var input ModerateInput
+	if err := httputil.DecodeJSON(r, &input); err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	resp, err := h.svc.Moderate(r.Context(), input, userID)
+	if err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) HandleAdminDelete(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+	if userID == 0 {
+		httputil.WriteError(w, r, apperror.Unauthorized("autenticação obrigatória"))
+		return
+	}
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("id de assinatura inválido", err))
+		return
+	}
+	if err := h.svc.Remove(r.Context(), id, userID); err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleFeed lists guestbook entries and gift messages together for the
+// couple. Filters: source (guestbook|gift_message) and status.
+func (h *Handler) HandleFeed(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	filter := FeedFilter{Source: q.Get("source"), Status: q.Get("status")}
+	resp, err := h.svc.Feed(r.Context(), filter, page, limit)
+	if err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
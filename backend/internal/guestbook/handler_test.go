@@ -0,0 +1,69 @@
+package guestbook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ferjunior7/parasempre/backend/internal/auth"
+	"github.com/ferjunior7/parasempre/backend/internal/giftmessage"
+	"github.com/ferjunior7/parasempre/backend/internal/middleware"
+)
+
+func authedRequest(method, target string, body *bytes.Buffer, userID int64, contentType string) *http.Request {
+	var r *http.Request
+	if body == nil {
+		r = httptest.NewRequest(method, target, nil)
+	} else {
+		r = httptest.NewRequest(method, target, body)
+	}
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	claims := &auth.Claims{UserID: userID, URACF: "ABC12", Role: "guest"}
+	return r.WithContext(middleware.WithClaims(context.Background(), claims))
+}
+
+func TestHandleSign_Returns201ThenConflict(t *testing.T) {
+	h := NewHandler(newTestService(&mockRepo{}, nil, giftmessage.ModerationAuto, ScopeFamily))
+
+	sign := func(userID int64) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		mw := multipart.NewWriter(body)
+		_ = mw.WriteField("author_name", "Ana")
+		_ = mw.WriteField("content", "Parabéns!")
+		_ = mw.Close()
+		w := httptest.NewRecorder()
+		h.HandleSign(w, authedRequest(http.MethodPost, "/api/guestbook", body, userID, mw.FormDataContentType()))
+		return w
+	}
+
+	w := sign(42)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d (body=%s)", w.Code, w.Body.String())
+	}
+	var resp PublicEntry
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.AuthorName != "Ana" || resp.Status != giftmessage.StatusApproved {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	if w := sign(43); w.Code != http.StatusConflict {
+		t.Fatalf("same family should get 409, got %d", w.Code)
+	}
+}
+
+func TestHandleGetMine_Returns404WhenAbsent(t *testing.T) {
+	h := NewHandler(newTestService(&mockRepo{}, nil, giftmessage.ModerationAuto, ScopeFamily))
+	w := httptest.NewRecorder()
+	h.HandleGetMine(w, authedRequest(http.MethodGet, "/api/guestbook/mine", nil, 42, ""))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
@@ -0,0 +1,133 @@
+package guestbook
+
+import (
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/giftmessage"
+)
+
+// SignScope decides who shares a single guestbook signature.
+type SignScope string
+
+const (
+	ScopeFamily SignScope = "family" // one entry per family_group
+	ScopeUser   SignScope = "user"   // one entry per user
+)
+
+const (
+	SourceGuestbook   = "guestbook"
+	SourceGiftMessage = "gift_message"
+)
+
+type Entry struct {
+	ID             int64
+	UserID         int64
+	FamilyGroup    *int64
+	SignerKey      string
+	AuthorName     string
+	Content        string
+	MediaObjectKey *string
+	MediaThumbKey  *string
+	MediaKind      *string
+	MediaSizeBytes *int64
+	MediaMimeType  *string
+	Status         string
+	FlaggedTerms   []string
+	ModeratedAt    *time.Time
+	ModeratedBy    *int64
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      *time.Time
+	DeletedBy      *int64
+}
+
+type SignInput struct {
+	AuthorName string `json:"author_name" validate:"required,min=1,max=120"`
+	Content    string `json:"content"     validate:"required,min=1,max=1000"`
+}
+
+type CreateRow struct {
+	UserID         int64
+	FamilyGroup    *int64
+	SignerKey      string
+	AuthorName     string
+	Content        string
+	MediaObjectKey *string
+	MediaThumbKey  *string
+	MediaKind      *string
+	MediaSizeBytes *int64
+	MediaMimeType  *string
+	Status         string
+	FlaggedTerms   []string
+}
+
+type PublicEntry struct {
+	ID           int64     `json:"id"`
+	AuthorName   string    `json:"author_name"`
+	Content      string    `json:"content"`
+	MediaURL     *string   `json:"media_url"`
+	ThumbnailURL *string   `json:"thumbnail_url"`
+	MediaKind    *string   `json:"media_kind"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type AdminEntry struct {
+	PublicEntry
+	UserID       int64      `json:"user_id"`
+	FamilyGroup  *int64     `json:"family_group"`
+	FlaggedTerms []string   `json:"flagged_terms"`
+	ModeratedAt  *time.Time `json:"moderated_at"`
+	ModeratedBy  *int64     `json:"moderated_by"`
+}
+
+// ModerateInput and ModerateResult are shared with gift messages, which
+// moderate the same way (see giftmessage.ModerateBatch).
+type (
+	ModerateInput  = giftmessage.ModerateInput
+	ModerateResult = giftmessage.ModerateResult
+)
+
+// FeedRow is one row of the couple's combined view of guestbook entries and
+// gift messages.
+type FeedRow struct {
+	Source         string
+	ID             int64
+	UserID         int64
+	GiftID         *int64
+	AuthorName     string
+	Content        string
+	MediaObjectKey *string
+	MediaThumbKey  *string
+	MediaKind      *string
+	Status         string
+	FlaggedTerms   []string
+	CreatedAt      time.Time
+}
+
+type FeedItem struct {
+	Source       string    `json:"source"`
+	ID           int64     `json:"id"`
+	UserID       int64     `json:"user_id"`
+	GiftID       *int64    `json:"gift_id"`
+	AuthorName   string    `json:"author_name"`
+	Content      string    `json:"content"`
+	MediaURL     *string   `json:"media_url"`
+	ThumbnailURL *string   `json:"thumbnail_url"`
+	MediaKind    *string   `json:"media_kind"`
+	Status       string    `json:"status"`
+	FlaggedTerms []string  `json:"flagged_terms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type FeedFilter struct {
+	Source string
+	Status string
+}
+
+type Paged[T any] struct {
+	Data  []T `json:"data"`
+	Page  int `json:"page"`
+	Limit int `json:"limit"`
+	Total int `json:"total"`
+}
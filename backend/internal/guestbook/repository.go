@@ -0,0 +1,14 @@
+package guestbook
+
+import "context"
+
+type Repository interface {
+	Create(ctx context.Context, in CreateRow) (*Entry, error)
+	GetBySigner(ctx context.Context, signerKey string) (*Entry, error)
+	ListApproved(ctx context.Context, limit, offset int) ([]Entry, int, error)
+	ListByStatus(ctx context.Context, status string, limit, offset int) ([]Entry, int, error)
+	SetStatus(ctx context.Context, ids []int64, status string, byUserID int64) ([]int64, error)
+	SoftDelete(ctx context.Context, id, byUserID int64) error
+	// ListFeed merges guestbook entries and gift messages, newest first.
+	ListFeed(ctx context.Context, f FeedFilter, limit, offset int) ([]FeedRow, int, error)
+}
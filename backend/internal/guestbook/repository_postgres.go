@@ -0,0 +1,262 @@
+package guestbook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/database"
+	"github.com/ferjunior7/parasempre/backend/internal/giftmessage"
+	"github.com/ferjunior7/parasempre/backend/internal/wall"
+)
+
+const entryColumns = `id, user_id, family_group, signer_key, author_name, content, media_object_key, media_thumb_object_key, media_kind, media_size_bytes, media_mime_type, status, flagged_terms, moderated_at, moderated_by, created_at, updated_at, deleted_at, deleted_by`
+
+func entryDest(e *Entry) []any {
+	return []any{
+		&e.ID, &e.UserID, &e.FamilyGroup, &e.SignerKey,
+		&e.AuthorName, &e.Content,
+		&e.MediaObjectKey, &e.MediaThumbKey, &e.MediaKind, &e.MediaSizeBytes, &e.MediaMimeType,
+		&e.Status, &e.FlaggedTerms, &e.ModeratedAt, &e.ModeratedBy,
+		&e.CreatedAt, &e.UpdatedAt, &e.DeletedAt, &e.DeletedBy,
+	}
+}
+
+func scanEntry(row pgx.Row) (Entry, error) {
+	var e Entry
+	err := row.Scan(entryDest(&e)...)
+	return e, err
+}
+
+type PostgresRepository struct {
+	db database.DBTX
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{db: pool}
+}
+
+func (r *PostgresRepository) Create(ctx context.Context, in CreateRow) (*Entry, error) {
+	flagged := in.FlaggedTerms
+	if flagged == nil {
+		flagged = []string{}
+	}
+	e, err := scanEntry(r.db.QueryRow(ctx,
+		`INSERT INTO guestbook_entries
+		    (user_id, family_group, signer_key, author_name, content,
+		     media_object_key, media_thumb_object_key, media_kind, media_size_bytes, media_mime_type,
+		     status, flagged_terms)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		 RETURNING `+entryColumns,
+		in.UserID, in.FamilyGroup, in.SignerKey, in.AuthorName, in.Content,
+		in.MediaObjectKey, in.MediaThumbKey, in.MediaKind, in.MediaSizeBytes, in.MediaMimeType,
+		in.Status, flagged,
+	))
+	if err != nil {
+		if appErr := mapPgError(err); appErr != nil {
+			return nil, appErr
+		}
+		slog.ErrorContext(ctx, "guestbook.repo create: insert failed", "error", err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "guestbook.repo create: stored", "id", e.ID, "user_id", e.UserID)
+	if e.Status == giftmessage.StatusApproved {
+		r.publishToWall(ctx, []int64{e.ID})
+	}
+	return &e, nil
+}
+
+func (r *PostgresRepository) GetBySigner(ctx context.Context, signerKey string) (*Entry, error) {
+	e, err := scanEntry(r.db.QueryRow(ctx,
+		`SELECT `+entryColumns+` FROM guestbook_entries
+		  WHERE signer_key = $1 AND deleted_at IS NULL`, signerKey))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.NotFound("assinatura não encontrada")
+		}
+		slog.ErrorContext(ctx, "guestbook.repo get_by_signer: query failed", "signer_key", signerKey, "error", err)
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *PostgresRepository) ListApproved(ctx context.Context, limit, offset int) ([]Entry, int, error) {
+	return r.list(ctx, "list_approved",
+		`SELECT `+entryColumns+`, COUNT(*) OVER() AS total
+		   FROM guestbook_entries
+		  WHERE deleted_at IS NULL AND status = 'approved'
+		  ORDER BY created_at DESC, id DESC
+		  LIMIT $1 OFFSET $2`,
+		limit, offset)
+}
+
+// ListByStatus feeds the moderation queue, oldest first.
+func (r *PostgresRepository) ListByStatus(ctx context.Context, status string, limit, offset int) ([]Entry, int, error) {
+	return r.list(ctx, "list_by_status",
+		`SELECT `+entryColumns+`, COUNT(*) OVER() AS total
+		   FROM guestbook_entries
+		  WHERE status = $1 AND deleted_at IS NULL
+		  ORDER BY created_at ASC, id ASC
+		  LIMIT $2 OFFSET $3`,
+		status, limit, offset)
+}
+
+func (r *PostgresRepository) list(ctx context.Context, op, query string, args ...any) ([]Entry, int, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "guestbook.repo "+op+": query failed", "error", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	var total int
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(append(entryDest(&e), &total)...); err != nil {
+			slog.ErrorContext(ctx, "guestbook.repo "+op+": scan failed", "error", err)
+			return nil, 0, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
+
+// SetStatus moves the given entries to status and returns the ids that
+// actually changed; deleted entries and those already in status are left
+// alone.
+func (r *PostgresRepository) SetStatus(ctx context.Context, ids []int64, status string, byUserID int64) ([]int64, error) {
+	rows, err := r.db.Query(ctx,
+		`UPDATE guestbook_entries
+		    SET status = $1, moderated_at = now(), moderated_by = $2, updated_at = now()
+		  WHERE id = ANY($3) AND deleted_at IS NULL AND status <> $1
+		  RETURNING id`,
+		status, byUserID, ids)
+	if err != nil {
+		if appErr := mapPgError(err); appErr != nil {
+			return nil, appErr
+		}
+		slog.ErrorContext(ctx, "guestbook.repo set_status: failed", "status", status, "error", err)
+		return nil, err
+	}
+	updated, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		slog.ErrorContext(ctx, "guestbook.repo set_status: scan failed", "error", err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "guestbook.repo set_status: done", "status", status, "updated", len(updated), "by", byUserID)
+	if status == giftmessage.StatusApproved && len(updated) > 0 {
+		r.publishToWall(ctx, updated)
+	}
+	return updated, nil
+}
+
+// publishToWall records the approved entries in wall_events and notifies
+// the reception wall. Best effort: the entry is already saved.
+func (r *PostgresRepository) publishToWall(ctx context.Context, ids []int64) {
+	_, err := r.db.Exec(ctx,
+		`WITH `+wall.PublishLock+`, ev AS (
+		     INSERT INTO wall_events (kind, ref_id, payload)
+		     SELECT $2, e.id, jsonb_build_object(
+		                'author_name', e.author_name,
+		                'content', e.content,
+		                'media_kind', e.media_kind,
+		                'media_key', e.media_object_key,
+		                'thumb_key', e.media_thumb_object_key)
+		       FROM wall_lock, guestbook_entries e
+		      WHERE e.id = ANY($1) AND e.status = 'approved' AND e.deleted_at IS NULL
+		     ON CONFLICT (kind, ref_id) DO NOTHING
+		     RETURNING id)
+		 SELECT pg_notify($3, id::text) FROM ev`,
+		ids, wall.KindGuestbook, wall.Channel)
+	if err != nil {
+		slog.WarnContext(ctx, "guestbook.repo publish_to_wall: failed", "ids", ids, "error", err)
+	}
+}
+
+// SoftDelete hides the entry and frees its signer, who may sign again.
+func (r *PostgresRepository) SoftDelete(ctx context.Context, id, byUserID int64) error {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE guestbook_entries
+		    SET deleted_at = now(), deleted_by = $1, updated_at = now()
+		  WHERE id = $2 AND deleted_at IS NULL`,
+		byUserID, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "guestbook.repo soft_delete: failed", "id", id, "error", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.NotFound("assinatura não encontrada")
+	}
+	slog.InfoContext(ctx, "guestbook.repo soft_delete: removed", "id", id, "by", byUserID)
+	return nil
+}
+
+func (r *PostgresRepository) ListFeed(ctx context.Context, f FeedFilter, limit, offset int) ([]FeedRow, int, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT source, id, user_id, gift_id, author_name, content,
+		        media_object_key, media_thumb_object_key, media_kind,
+		        status, flagged_terms, created_at, COUNT(*) OVER() AS total
+		   FROM (
+		         SELECT 'guestbook' AS source, id, user_id, NULL::BIGINT AS gift_id,
+		                author_name, content, media_object_key, media_thumb_object_key, media_kind,
+		                status, flagged_terms, created_at
+		           FROM guestbook_entries
+		          WHERE deleted_at IS NULL
+		         UNION ALL
+		         SELECT 'gift_message', id, user_id, gift_id,
+		                author_name, content, media_object_key, media_thumb_object_key, media_kind,
+		                status, flagged_terms, created_at
+		           FROM gift_messages
+		          WHERE deleted_at IS NULL
+		        ) feed
+		  WHERE ($1 = '' OR source = $1) AND ($2 = '' OR status = $2)
+		  ORDER BY created_at DESC, source, id DESC
+		  LIMIT $3 OFFSET $4`,
+		f.Source, f.Status, limit, offset)
+	if err != nil {
+		slog.ErrorContext(ctx, "guestbook.repo list_feed: query failed", "error", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	items := []FeedRow{}
+	var total int
+	for rows.Next() {
+		var it FeedRow
+		if err := rows.Scan(&it.Source, &it.ID, &it.UserID, &it.GiftID, &it.AuthorName, &it.Content,
+			&it.MediaObjectKey, &it.MediaThumbKey, &it.MediaKind,
+			&it.Status, &it.FlaggedTerms, &it.CreatedAt, &total); err != nil {
+			slog.ErrorContext(ctx, "guestbook.repo list_feed: scan failed", "error", err)
+			return nil, 0, err
+		}
+		items = append(items, it)
+	}
+	return items, total, rows.Err()
+}
+
+func mapPgError(err error) *apperror.AppError {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return nil
+	}
+	switch pgErr.Code {
+	case pgerrcode.UniqueViolation:
+		if pgErr.ConstraintName == "guestbook_entries_signer_idx" {
+			return apperror.Conflict(alreadySignedMsg)
+		}
+		return apperror.Conflict("assinatura em conflito com registro existente")
+	case pgerrcode.CheckViolation:
+		return apperror.Validation(fmt.Sprintf("dados inválidos para assinatura (%s)", pgErr.ConstraintName))
+	case pgerrcode.ForeignKeyViolation:
+		return apperror.Validation("usuário referenciado não existe")
+	}
+	return nil
+}
@@ -0,0 +1,376 @@
+package guestbook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/audit"
+	"github.com/ferjunior7/parasempre/backend/internal/giftmessage"
+	"github.com/ferjunior7/parasempre/backend/internal/media"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
+	"github.com/ferjunior7/parasempre/backend/internal/validate"
+)
+
+const (
+	defaultListLimit  = 20
+	maxListLimit      = 50
+	defaultAdminLimit = 20
+	maxAdminLimit     = 100
+
+	alreadySignedMsg = "o livro de assinaturas já foi assinado por você ou sua família"
+)
+
+// FamilyFinder resolves the family_group of the guest linked to a user; nil
+// when the user has no guest record.
+type FamilyFinder interface {
+	GetFamilyGroupByUserID(ctx context.Context, userID int64) (*int64, error)
+}
+
+const (
+	auditEntrySigned   = "guestbook.signed"
+	auditEntryRemoved  = "guestbook.removed"
+	auditEntryApproved = "guestbook.approved"
+	auditEntryRejected = "guestbook.rejected"
+)
+
+type Service struct {
+	repo     Repository
+	families FamilyFinder
+	storage  giftmessage.Storage
+	audit    audit.Logger
+	ttl      time.Duration
+	mode     giftmessage.ModerationMode
+	scope    SignScope
+}
+
+func NewService(repo Repository, families FamilyFinder, storage giftmessage.Storage, audit audit.Logger, ttl time.Duration, mode giftmessage.ModerationMode, scope SignScope) *Service {
+	if ttl <= 0 {
+		ttl = 15 * time.Minute
+	}
+	if mode == "" {
+		mode = giftmessage.ModerationFlagged
+	}
+	if scope == "" {
+		scope = ScopeFamily
+	}
+	return &Service{repo: repo, families: families, storage: storage, audit: audit, ttl: ttl, mode: mode, scope: scope}
+}
+
+// signer returns the key a user's signature is counted under. In family
+// scope guests sharing a family_group share one key; users without a guest
+// record (the couple) always sign for themselves.
+func (s *Service) signer(ctx context.Context, userID int64) (string, *int64, error) {
+	if s.families == nil {
+		return fmt.Sprintf("user:%d", userID), nil, nil
+	}
+	group, err := s.families.GetFamilyGroupByUserID(ctx, userID)
+	if err != nil {
+		return "", nil, apperror.WrapIfNotApp("falha ao identificar família", err)
+	}
+	if s.scope == ScopeFamily && group != nil {
+		return fmt.Sprintf("family:%d", *group), group, nil
+	}
+	return fmt.Sprintf("user:%d", userID), group, nil
+}
+
+// Sign stores the user's (or their family's) single guestbook entry.
+func (s *Service) Sign(ctx context.Context, userID int64, in SignInput, media *giftmessage.Media) (*Entry, error) {
+	if userID == 0 {
+		return nil, apperror.Unauthorized("autenticação obrigatória")
+	}
+	if err := validate.Struct(in); err != nil {
+		return nil, err
+	}
+
+	key, group, err := s.signer(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetBySigner(ctx, key); err == nil {
+		return nil, apperror.Conflict(alreadySignedMsg)
+	} else if !isNotFound(err) {
+		return nil, err
+	}
+
+	flagged := giftmessage.FlagContent(in.AuthorName, in.Content)
+	row := CreateRow{
+		UserID:       userID,
+		FamilyGroup:  group,
+		SignerKey:    key,
+		AuthorName:   in.AuthorName,
+		Content:      in.Content,
+		Status:       s.mode.InitialStatus(flagged),
+		FlaggedTerms: flagged,
+	}
+
+	var uploadedKeys []string
+	if media != nil {
+		if s.storage == nil {
+			return nil, apperror.ServiceUnavailable("Assinaturas com mídia indisponíveis neste ambiente.")
+		}
+		stored, err := giftmessage.StoreMedia(ctx, s.storage, fmt.Sprintf("guestbook/%d", userID), *media)
+		if err != nil {
+			return nil, err
+		}
+		uploadedKeys = stored.Keys()
+		row.MediaObjectKey = &stored.Key
+		row.MediaThumbKey = stored.ThumbKey
+		row.MediaKind = &stored.Kind
+		row.MediaSizeBytes = &stored.Size
+		row.MediaMimeType = &stored.Mime
+	}
+
+	created, err := s.repo.Create(ctx, row)
+	if err != nil {
+		if len(uploadedKeys) > 0 {
+			giftmessage.DeleteObjects(ctx, s.storage, uploadedKeys)
+		}
+		return nil, err
+	}
+
+	mediaKindLog := ""
+	if created.MediaKind != nil {
+		mediaKindLog = *created.MediaKind
+	}
+	audit.RecordAs(ctx, s.audit, userID, auditEntrySigned, audit.Entity(audit.EntityGuestbook, created.ID, map[string]any{
+		"entry_id":   created.ID,
+		"signer_key": created.SignerKey,
+		"media_kind": mediaKindLog,
+		"status":     created.Status,
+		"flagged":    created.FlaggedTerms,
+	}))
+	slog.InfoContext(ctx, "guestbook.service sign: done",
+		"entry_id", created.ID,
+		"user_id", userID,
+		"signer_key", created.SignerKey,
+		"media_kind", mediaKindLog,
+		"status", created.Status,
+		"flagged", len(created.FlaggedTerms),
+	)
+	return created, nil
+}
+
+// GetMine returns the entry signed by the user or their family, or nil when
+// there is none yet.
+func (s *Service) GetMine(ctx context.Context, userID int64) (*PublicEntry, error) {
+	if userID == 0 {
+		return nil, apperror.Unauthorized("autenticação obrigatória")
+	}
+	key, _, err := s.signer(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.repo.GetBySigner(ctx, key)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	pub := s.signSingle(ctx, *e)
+	return &pub, nil
+}
+
+// signSingle converts e with its media URLs signed.
+func (s *Service) signSingle(ctx context.Context, e Entry) PublicEntry {
+	urls, err := s.signEntries(ctx, []Entry{e})
+	if err != nil {
+		slog.WarnContext(ctx, "guestbook.service sign_single: failed", "entry_id", e.ID, "error", err)
+	}
+	return toPublic(e, urls)
+}
+
+func (s *Service) List(ctx context.Context, page, limit int) (*Paged[PublicEntry], error) {
+	req := pagination.Request{Page: page, Limit: limit}
+	req.Normalize(defaultListLimit, maxListLimit)
+	rows, total, err := s.repo.ListApproved(ctx, req.Limit, req.Offset())
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("falha ao listar livro de assinaturas", err)
+	}
+	urls, err := s.signEntries(ctx, rows)
+	if err != nil {
+		slog.WarnContext(ctx, "guestbook.service list: sign urls failed", "error", err)
+	}
+	data := make([]PublicEntry, len(rows))
+	for i, e := range rows {
+		data[i] = toPublic(e, urls)
+	}
+	return &Paged[PublicEntry]{Data: data, Page: req.Page, Limit: req.Limit, Total: total}, nil
+}
+
+// ModerationQueue lists entries in status (pending by default), oldest
+// first.
+func (s *Service) ModerationQueue(ctx context.Context, status string, page, limit int) (*Paged[AdminEntry], error) {
+	if status == "" {
+		status = giftmessage.StatusPending
+	}
+	if !validStatus(status) {
+		return nil, apperror.Validation("status deve ser pending, approved ou rejected")
+	}
+	req := pagination.Request{Page: page, Limit: limit}
+	req.Normalize(defaultAdminLimit, maxAdminLimit)
+	rows, total, err := s.repo.ListByStatus(ctx, status, req.Limit, req.Offset())
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("falha ao listar fila de moderação", err)
+	}
+	urls, err := s.signEntries(ctx, rows)
+	if err != nil {
+		slog.WarnContext(ctx, "guestbook.service moderation_queue: sign urls failed", "error", err)
+	}
+	data := make([]AdminEntry, len(rows))
+	for i, e := range rows {
+		data[i] = toAdmin(e, urls)
+	}
+	return &Paged[AdminEntry]{Data: data, Page: req.Page, Limit: req.Limit, Total: total}, nil
+}
+
+// Moderate approves or rejects a batch of entries.
+func (s *Service) Moderate(ctx context.Context, in ModerateInput, byUserID int64) (*ModerateResult, error) {
+	result, err := giftmessage.ModerateBatch(ctx, in, byUserID, "nenhum id de assinatura válido", s.repo.SetStatus)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("falha ao moderar assinaturas", err)
+	}
+
+	action := auditEntryApproved
+	if result.Status == giftmessage.StatusRejected {
+		action = auditEntryRejected
+	}
+	for _, id := range result.Updated {
+		audit.RecordAs(ctx, s.audit, byUserID, action, audit.Entity(audit.EntityGuestbook, id, map[string]any{
+			"entry_id": id,
+		}))
+	}
+
+	slog.InfoContext(ctx, "guestbook.service moderate: done",
+		"status", result.Status, "updated", len(result.Updated), "skipped", len(result.Skipped), "by_user_id", byUserID)
+	return result, nil
+}
+
+func (s *Service) Remove(ctx context.Context, id, byUserID int64) error {
+	if byUserID == 0 {
+		return apperror.Unauthorized("autenticação obrigatória")
+	}
+	if err := s.repo.SoftDelete(ctx, id, byUserID); err != nil {
+		return err
+	}
+	audit.RecordAs(ctx, s.audit, byUserID, auditEntryRemoved, audit.Entity(audit.EntityGuestbook, id, map[string]any{
+		"entry_id": id,
+	}))
+	slog.InfoContext(ctx, "guestbook.service remove: done", "entry_id", id, "by_user_id", byUserID)
+	return nil
+}
+
+// Feed is the couple's single view over guestbook entries and gift messages,
+// optionally narrowed to one source and one status.
+func (s *Service) Feed(ctx context.Context, f FeedFilter, page, limit int) (*Paged[FeedItem], error) {
+	if f.Source != "" && f.Source != SourceGuestbook && f.Source != SourceGiftMessage {
+		return nil, apperror.Validation("source deve ser guestbook ou gift_message")
+	}
+	if f.Status != "" && !validStatus(f.Status) {
+		return nil, apperror.Validation("status deve ser pending, approved ou rejected")
+	}
+	req := pagination.Request{Page: page, Limit: limit}
+	req.Normalize(defaultAdminLimit, maxAdminLimit)
+	rows, total, err := s.repo.ListFeed(ctx, f, req.Limit, req.Offset())
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("falha ao listar mensagens", err)
+	}
+
+	var keys []string
+	for _, r := range rows {
+		keys = appendKeys(keys, r.MediaObjectKey, r.MediaThumbKey)
+	}
+	urls, err := s.signKeys(ctx, keys)
+	if err != nil {
+		slog.WarnContext(ctx, "guestbook.service feed: sign urls failed", "error", err)
+	}
+
+	data := make([]FeedItem, len(rows))
+	for i, r := range rows {
+		flagged := r.FlaggedTerms
+		if flagged == nil {
+			flagged = []string{}
+		}
+		data[i] = FeedItem{
+			Source:       r.Source,
+			ID:           r.ID,
+			UserID:       r.UserID,
+			GiftID:       r.GiftID,
+			AuthorName:   r.AuthorName,
+			Content:      r.Content,
+			MediaURL:     media.SignedURL(r.MediaObjectKey, urls),
+			ThumbnailURL: media.SignedURL(r.MediaThumbKey, urls),
+			MediaKind:    r.MediaKind,
+			Status:       r.Status,
+			FlaggedTerms: flagged,
+			CreatedAt:    r.CreatedAt,
+		}
+	}
+	return &Paged[FeedItem]{Data: data, Page: req.Page, Limit: req.Limit, Total: total}, nil
+}
+
+func (s *Service) signEntries(ctx context.Context, rows []Entry) (map[string]string, error) {
+	var keys []string
+	for _, e := range rows {
+		keys = appendKeys(keys, e.MediaObjectKey, e.MediaThumbKey)
+	}
+	return s.signKeys(ctx, keys)
+}
+
+func (s *Service) signKeys(ctx context.Context, keys []string) (map[string]string, error) {
+	if s.storage == nil || len(keys) == 0 {
+		return nil, nil
+	}
+	return s.storage.SignURLs(ctx, keys, s.ttl)
+}
+
+func appendKeys(keys []string, candidates ...*string) []string {
+	for _, k := range candidates {
+		if k != nil {
+			keys = append(keys, *k)
+		}
+	}
+	return keys
+}
+
+func toPublic(e Entry, urls map[string]string) PublicEntry {
+	return PublicEntry{
+		ID:           e.ID,
+		AuthorName:   e.AuthorName,
+		Content:      e.Content,
+		MediaURL:     media.SignedURL(e.MediaObjectKey, urls),
+		ThumbnailURL: media.SignedURL(e.MediaThumbKey, urls),
+		MediaKind:    e.MediaKind,
+		Status:       e.Status,
+		CreatedAt:    e.CreatedAt,
+	}
+}
+
+func toAdmin(e Entry, urls map[string]string) AdminEntry {
+	flagged := e.FlaggedTerms
+	if flagged == nil {
+		flagged = []string{}
+	}
+	return AdminEntry{
+		PublicEntry:  toPublic(e, urls),
+		UserID:       e.UserID,
+		FamilyGroup:  e.FamilyGroup,
+		FlaggedTerms: flagged,
+		ModeratedAt:  e.ModeratedAt,
+		ModeratedBy:  e.ModeratedBy,
+	}
+}
+
+func validStatus(status string) bool {
+	return status == giftmessage.StatusPending || status == giftmessage.StatusApproved || status == giftmessage.StatusRejected
+}
+
+func isNotFound(err error) bool {
+	var ae *apperror.AppError
+	return errors.As(err, &ae) && ae.Code == http.StatusNotFound
+}
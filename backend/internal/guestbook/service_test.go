@@ -0,0 +1,353 @@
+package guestbook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/giftmessage"
+)
+
+// --- mocks ---
+
+type mockRepo struct {
+	entries   []Entry
+	createErr error
+	feed      []FeedRow
+	lastFeed  FeedFilter
+}
+
+func (m *mockRepo) Create(_ context.Context, in CreateRow) (*Entry, error) {
+	if m.createErr != nil {
+		return nil, m.createErr
+	}
+	for _, e := range m.entries {
+		if e.SignerKey == in.SignerKey && e.DeletedAt == nil {
+			return nil, apperror.Conflict(alreadySignedMsg)
+		}
+	}
+	e := Entry{
+		ID: int64(len(m.entries) + 1), UserID: in.UserID, FamilyGroup: in.FamilyGroup, SignerKey: in.SignerKey,
+		AuthorName: in.AuthorName, Content: in.Content,
+		MediaObjectKey: in.MediaObjectKey, MediaThumbKey: in.MediaThumbKey, MediaKind: in.MediaKind,
+		MediaSizeBytes: in.MediaSizeBytes, MediaMimeType: in.MediaMimeType,
+		Status: in.Status, FlaggedTerms: in.FlaggedTerms, CreatedAt: time.Now(),
+	}
+	m.entries = append(m.entries, e)
+	return &e, nil
+}
+
+func (m *mockRepo) GetBySigner(_ context.Context, key string) (*Entry, error) {
+	for _, e := range m.entries {
+		if e.SignerKey == key && e.DeletedAt == nil {
+			return &e, nil
+		}
+	}
+	return nil, apperror.NotFound("assinatura não encontrada")
+}
+
+func (m *mockRepo) ListApproved(ctx context.Context, limit, offset int) ([]Entry, int, error) {
+	return m.ListByStatus(ctx, giftmessage.StatusApproved, limit, offset)
+}
+
+func (m *mockRepo) ListByStatus(_ context.Context, status string, _, _ int) ([]Entry, int, error) {
+	out := []Entry{}
+	for _, e := range m.entries {
+		if e.Status == status && e.DeletedAt == nil {
+			out = append(out, e)
+		}
+	}
+	return out, len(out), nil
+}
+
+func (m *mockRepo) SetStatus(_ context.Context, ids []int64, status string, byUserID int64) ([]int64, error) {
+	var updated []int64
+	for i := range m.entries {
+		for _, id := range ids {
+			if m.entries[i].ID == id && m.entries[i].Status != status && m.entries[i].DeletedAt == nil {
+				m.entries[i].Status = status
+				m.entries[i].ModeratedBy = &byUserID
+				updated = append(updated, id)
+			}
+		}
+	}
+	return updated, nil
+}
+
+func (m *mockRepo) SoftDelete(_ context.Context, id, byUserID int64) error {
+	for i := range m.entries {
+		if m.entries[i].ID == id && m.entries[i].DeletedAt == nil {
+			now := time.Now()
+			m.entries[i].DeletedAt, m.entries[i].DeletedBy = &now, &byUserID
+			return nil
+		}
+	}
+	return apperror.NotFound("assinatura não encontrada")
+}
+
+func (m *mockRepo) ListFeed(_ context.Context, f FeedFilter, _, _ int) ([]FeedRow, int, error) {
+	m.lastFeed = f
+	return m.feed, len(m.feed), nil
+}
+
+// mockFamilies maps user ids to family groups; missing users have none.
+type mockFamilies map[int64]int64
+
+func (m mockFamilies) GetFamilyGroupByUserID(_ context.Context, userID int64) (*int64, error) {
+	g, ok := m[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &g, nil
+}
+
+type mockStorage struct {
+	uploaded    []string
+	deleteCalls int
+}
+
+func (m *mockStorage) Upload(_ context.Context, key, _ string, r io.Reader, _ int64) error {
+	_, _ = io.Copy(io.Discard, r)
+	m.uploaded = append(m.uploaded, key)
+	return nil
+}
+
+func (m *mockStorage) SignURLs(_ context.Context, keys []string, _ time.Duration) (map[string]string, error) {
+	out := make(map[string]string, len(keys))
+	for _, k := range keys {
+		out[k] = "https://signed.example/" + k
+	}
+	return out, nil
+}
+
+func (m *mockStorage) Delete(_ context.Context, _ string) error {
+	m.deleteCalls++
+	return nil
+}
+
+type mockAudit struct {
+	actions []string
+}
+
+func (m *mockAudit) LogAction(_ context.Context, _ int64, action string, _ map[string]any) error {
+	m.actions = append(m.actions, action)
+	return nil
+}
+
+// --- helpers ---
+
+var families = mockFamilies{42: 7, 43: 7, 44: 8}
+
+func newTestService(repo *mockRepo, storage giftmessage.Storage, mode giftmessage.ModerationMode, scope SignScope) *Service {
+	return NewService(repo, families, storage, &mockAudit{}, time.Minute, mode, scope)
+}
+
+func validSign() SignInput {
+	return SignInput{AuthorName: "Família Souza", Content: "Muitas felicidades ao casal!"}
+}
+
+func mp3Media() *giftmessage.Media {
+	body := append([]byte("ID3\x03\x00\x00\x00\x00\x00\x00"), make([]byte, 256)...)
+	return &giftmessage.Media{DeclaredMime: "audio/mpeg", Size: int64(len(body)), Reader: bytes.NewReader(body)}
+}
+
+func assertAppError(t *testing.T, err error, code int, contains string) {
+	t.Helper()
+	var ae *apperror.AppError
+	if !errors.As(err, &ae) {
+		t.Fatalf("expected AppError, got %v", err)
+	}
+	if ae.Code != code {
+		t.Errorf("expected code %d, got %d (%s)", code, ae.Code, ae.Message)
+	}
+	if contains != "" && !strings.Contains(ae.Message, contains) {
+		t.Errorf("expected message to contain %q, got %q", contains, ae.Message)
+	}
+}
+
+// --- tests ---
+
+func TestSign_FamilyScopeAllowsOneEntryPerFamily(t *testing.T) {
+	repo := &mockRepo{}
+	svc := newTestService(repo, nil, giftmessage.ModerationAuto, ScopeFamily)
+
+	first, err := svc.Sign(context.Background(), 42, validSign(), nil)
+	if err != nil {
+		t.Fatalf("first sign: %v", err)
+	}
+	if first.SignerKey != "family:7" || first.FamilyGroup == nil || *first.FamilyGroup != 7 {
+		t.Errorf("expected family signer, got %q %v", first.SignerKey, first.FamilyGroup)
+	}
+
+	_, err = svc.Sign(context.Background(), 43, validSign(), nil)
+	assertAppError(t, err, http.StatusConflict, "já foi assinado")
+
+	if _, err := svc.Sign(context.Background(), 44, validSign(), nil); err != nil {
+		t.Fatalf("another family should sign: %v", err)
+	}
+
+	mine, err := svc.GetMine(context.Background(), 43)
+	if err != nil || mine == nil || mine.ID != first.ID {
+		t.Fatalf("family member should see the family entry, got %+v err=%v", mine, err)
+	}
+}
+
+func TestSign_UserScopeAndUsersWithoutFamily(t *testing.T) {
+	repo := &mockRepo{}
+	svc := newTestService(repo, nil, giftmessage.ModerationAuto, ScopeUser)
+
+	for _, uid := range []int64{42, 43} {
+		if _, err := svc.Sign(context.Background(), uid, validSign(), nil); err != nil {
+			t.Fatalf("user %d: %v", uid, err)
+		}
+	}
+	_, err := svc.Sign(context.Background(), 42, validSign(), nil)
+	assertAppError(t, err, http.StatusConflict, "")
+
+	// The couple has no guest record, so even family scope keys by user.
+	famSvc := newTestService(&mockRepo{}, nil, giftmessage.ModerationAuto, ScopeFamily)
+	e, err := famSvc.Sign(context.Background(), 1, validSign(), nil)
+	if err != nil {
+		t.Fatalf("couple sign: %v", err)
+	}
+	if e.SignerKey != "user:1" || e.FamilyGroup != nil {
+		t.Errorf("expected user signer without family, got %q %v", e.SignerKey, e.FamilyGroup)
+	}
+}
+
+func TestSign_FlaggedEntryWaitsForModeration(t *testing.T) {
+	repo := &mockRepo{}
+	svc := newTestService(repo, nil, giftmessage.ModerationFlagged, ScopeFamily)
+
+	in := validSign()
+	in.Content = "que merda de festa"
+	e, err := svc.Sign(context.Background(), 42, in, nil)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if e.Status != giftmessage.StatusPending || len(e.FlaggedTerms) == 0 {
+		t.Fatalf("expected pending with flagged terms, got %s %v", e.Status, e.FlaggedTerms)
+	}
+
+	feed, err := svc.List(context.Background(), 1, 10)
+	if err != nil || feed.Total != 0 {
+		t.Fatalf("pending entry must not be public: %+v err=%v", feed, err)
+	}
+
+	res, err := svc.Moderate(context.Background(), ModerateInput{IDs: []int64{e.ID, 99}, Action: "approve"}, 1)
+	if err != nil {
+		t.Fatalf("moderate: %v", err)
+	}
+	if len(res.Updated) != 1 || len(res.Skipped) != 1 || res.Skipped[0] != 99 {
+		t.Errorf("unexpected moderation result %+v", res)
+	}
+	feed, _ = svc.List(context.Background(), 1, 10)
+	if feed.Total != 1 {
+		t.Errorf("approved entry should be public, total=%d", feed.Total)
+	}
+}
+
+func TestSign_StoresMediaAndSignsURLs(t *testing.T) {
+	storage := &mockStorage{}
+	repo := &mockRepo{}
+	svc := newTestService(repo, storage, giftmessage.ModerationAuto, ScopeFamily)
+
+	e, err := svc.Sign(context.Background(), 42, validSign(), mp3Media())
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if len(storage.uploaded) != 1 || !strings.HasPrefix(storage.uploaded[0], "guestbook/42/") {
+		t.Fatalf("expected one upload under guestbook/42/, got %v", storage.uploaded)
+	}
+	if e.MediaKind == nil || *e.MediaKind != giftmessage.MediaKindAudio {
+		t.Errorf("expected audio kind, got %v", e.MediaKind)
+	}
+
+	page, err := svc.List(context.Background(), 1, 10)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if page.Data[0].MediaURL == nil || !strings.HasPrefix(*page.Data[0].MediaURL, "https://signed.example/guestbook/42/") {
+		t.Errorf("expected signed media URL, got %v", page.Data[0].MediaURL)
+	}
+}
+
+func TestSign_DeletesMediaWhenCreateFails(t *testing.T) {
+	storage := &mockStorage{}
+	repo := &mockRepo{createErr: errors.New("db down")}
+	svc := newTestService(repo, storage, giftmessage.ModerationAuto, ScopeFamily)
+
+	if _, err := svc.Sign(context.Background(), 42, validSign(), mp3Media()); err == nil {
+		t.Fatal("expected error")
+	}
+	if storage.deleteCalls != 1 {
+		t.Errorf("expected orphaned upload to be deleted, deletes=%d", storage.deleteCalls)
+	}
+}
+
+func TestSign_MediaWithoutStorage(t *testing.T) {
+	svc := newTestService(&mockRepo{}, nil, giftmessage.ModerationAuto, ScopeFamily)
+	_, err := svc.Sign(context.Background(), 42, validSign(), mp3Media())
+	assertAppError(t, err, http.StatusServiceUnavailable, "indisponíveis")
+}
+
+func TestSign_RejectsUnauthenticatedAndInvalid(t *testing.T) {
+	svc := newTestService(&mockRepo{}, nil, giftmessage.ModerationAuto, ScopeFamily)
+	_, err := svc.Sign(context.Background(), 0, validSign(), nil)
+	assertAppError(t, err, http.StatusUnauthorized, "")
+
+	in := validSign()
+	in.Content = strings.Repeat("a", 1001)
+	_, err = svc.Sign(context.Background(), 42, in, nil)
+	assertAppError(t, err, http.StatusBadRequest, "")
+}
+
+func TestRemove_FreesSignerAndAudits(t *testing.T) {
+	repo := &mockRepo{}
+	audit := &mockAudit{}
+	svc := NewService(repo, families, nil, audit, time.Minute, giftmessage.ModerationAuto, ScopeFamily)
+
+	e, _ := svc.Sign(context.Background(), 42, validSign(), nil)
+	if err := svc.Remove(context.Background(), e.ID, 1); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if _, err := svc.Sign(context.Background(), 43, validSign(), nil); err != nil {
+		t.Fatalf("family should be able to sign again after removal: %v", err)
+	}
+	want := []string{auditEntrySigned, auditEntryRemoved, auditEntrySigned}
+	if strings.Join(audit.actions, ",") != strings.Join(want, ",") {
+		t.Errorf("audit actions = %v, want %v", audit.actions, want)
+	}
+}
+
+func TestFeed_ValidatesFiltersAndSignsMedia(t *testing.T) {
+	key := "messages/100/abc.jpg"
+	giftID := int64(3)
+	repo := &mockRepo{feed: []FeedRow{
+		{Source: SourceGiftMessage, ID: 5, GiftID: &giftID, MediaObjectKey: &key, Status: giftmessage.StatusApproved},
+		{Source: SourceGuestbook, ID: 1, Status: giftmessage.StatusPending},
+	}}
+	svc := newTestService(repo, &mockStorage{}, giftmessage.ModerationAuto, ScopeFamily)
+
+	_, err := svc.Feed(context.Background(), FeedFilter{Source: "tweets"}, 1, 10)
+	assertAppError(t, err, http.StatusBadRequest, "source")
+	_, err = svc.Feed(context.Background(), FeedFilter{Status: "hidden"}, 1, 10)
+	assertAppError(t, err, http.StatusBadRequest, "status")
+
+	page, err := svc.Feed(context.Background(), FeedFilter{Status: giftmessage.StatusApproved}, 1, 10)
+	if err != nil {
+		t.Fatalf("feed: %v", err)
+	}
+	if repo.lastFeed.Status != giftmessage.StatusApproved {
+		t.Errorf("filter not forwarded: %+v", repo.lastFeed)
+	}
+	if page.Data[0].MediaURL == nil || page.Data[0].FlaggedTerms == nil || page.Data[1].MediaURL != nil {
+		t.Errorf("unexpected feed items %+v", page.Data)
+	}
+}
@@ -0,0 +1,165 @@
+package household
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/httputil"
+	"github.com/ferjunior7/parasempre/backend/internal/middleware"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) HandleList(w http.ResponseWriter, r *http.Request) {
+	households, err := h.svc.List(r.Context(), strings.TrimSpace(r.URL.Query().Get("search")))
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to list households", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, households)
+}
+
+func (h *Handler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid household id", err))
+		return
+	}
+
+	household, err := h.svc.Get(r.Context(), id)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to get household", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, household)
+}
+
+func (h *Handler) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	userRACF := middleware.UserRACFFromContext(r.Context())
+
+	var input CreateInput
+	if err := httputil.DecodeJSON(r, &input); err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid household payload", err))
+		return
+	}
+
+	household, err := h.svc.Create(r.Context(), input, userRACF)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to create household", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusCreated, household)
+}
+
+func (h *Handler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
+	userRACF := middleware.UserRACFFromContext(r.Context())
+
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid household id", err))
+		return
+	}
+
+	var input UpdateInput
+	if err := httputil.DecodeJSON(r, &input); err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid household payload", err))
+		return
+	}
+
+	household, err := h.svc.Update(r.Context(), id, input, userRACF)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to update household", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, household)
+}
+
+func (h *Handler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid household id", err))
+		return
+	}
+
+	if err := h.svc.Delete(r.Context(), id); err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to delete household", err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) HandleMoveMembers(w http.ResponseWriter, r *http.Request) {
+	userRACF := middleware.UserRACFFromContext(r.Context())
+
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid household id", err))
+		return
+	}
+
+	var input MoveInput
+	if err := httputil.DecodeJSON(r, &input); err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid members payload", err))
+		return
+	}
+
+	household, err := h.svc.MoveMembers(r.Context(), id, input, userRACF)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to move guests", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, household)
+}
+
+func (h *Handler) HandleSplit(w http.ResponseWriter, r *http.Request) {
+	userRACF := middleware.UserRACFFromContext(r.Context())
+
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid household id", err))
+		return
+	}
+
+	var input CreateInput
+	if err := httputil.DecodeJSON(r, &input); err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid split payload", err))
+		return
+	}
+
+	result, err := h.svc.Split(r.Context(), id, input, userRACF)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to split household", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusCreated, result)
+}
+
+func (h *Handler) HandleMerge(w http.ResponseWriter, r *http.Request) {
+	userRACF := middleware.UserRACFFromContext(r.Context())
+
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid household id", err))
+		return
+	}
+
+	var input MergeInput
+	if err := httputil.DecodeJSON(r, &input); err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid merge payload", err))
+		return
+	}
+
+	result, err := h.svc.Merge(r.Context(), id, input, userRACF)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to merge households", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,97 @@
+package household
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/ferjunior7/parasempre/backend/internal/auth"
+	"github.com/ferjunior7/parasempre/backend/internal/middleware"
+)
+
+func newTestMux() (*http.ServeMux, *memRepository) {
+	svc, repo, _ := newTestService()
+	h := NewHandler(svc)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/households", h.HandleList)
+	mux.HandleFunc("GET /api/households/{id}", h.HandleGet)
+	mux.HandleFunc("POST /api/households", h.HandleCreate)
+	mux.HandleFunc("PUT /api/households/{id}", h.HandleUpdate)
+	mux.HandleFunc("DELETE /api/households/{id}", h.HandleDelete)
+	mux.HandleFunc("POST /api/households/{id}/members", h.HandleMoveMembers)
+	mux.HandleFunc("POST /api/households/{id}/split", h.HandleSplit)
+	mux.HandleFunc("POST /api/households/{id}/merge", h.HandleMerge)
+	return mux, repo
+}
+
+func serveJSON(mux *http.ServeMux, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(middleware.WithClaims(req.Context(), &auth.Claims{UserID: 1, URACF: "TST01", Role: "groom"}))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandlerGetHousehold(t *testing.T) {
+	mux, _ := newTestMux()
+
+	w := serveJSON(mux, http.MethodGet, "/api/households/1", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var got Household
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil || got.Name != "Família Souza" || len(got.Members) != 3 {
+		t.Fatalf("unexpected household %+v (%v)", got, err)
+	}
+
+	if w := serveJSON(mux, http.MethodGet, "/api/households/9", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestHandlerCreateHousehold(t *testing.T) {
+	mux, _ := newTestMux()
+
+	w := serveJSON(mux, http.MethodPost, "/api/households",
+		`{"name":"Família Lima","address":{"city":"Recife","state":"pe","postal_code":"50030-230"}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var got Household
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil || *got.Address.State != "PE" || *got.Address.PostalCode != "50030230" {
+		t.Fatalf("unexpected household %+v (%v)", got, err)
+	}
+
+	if w := serveJSON(mux, http.MethodPost, "/api/households", `{"name":""}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a name, got %d", w.Code)
+	}
+}
+
+func TestHandlerSplitAndMergeHousehold(t *testing.T) {
+	mux, repo := newTestMux()
+
+	w := serveJSON(mux, http.MethodPost, "/api/households/1/split", `{"name":"Família Souza Lima","guest_ids":[12]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var split SplitResult
+	if err := json.NewDecoder(w.Body).Decode(&split); err != nil || len(split.Source.Members) != 2 || len(split.Created.Members) != 1 {
+		t.Fatalf("unexpected split %+v (%v)", split, err)
+	}
+
+	w = serveJSON(mux, http.MethodPost, "/api/households/1/merge", `{"household_id":`+strconv.FormatInt(split.Created.ID, 10)+`}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if repo.guests[12] != 1 || len(repo.households) != 1 {
+		t.Fatalf("expected guest 12 back in household 1, got %v", repo.guests)
+	}
+
+	if w := serveJSON(mux, http.MethodDelete, "/api/households/1", ""); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 deleting a household with guests, got %d", w.Code)
+	}
+}
@@ -0,0 +1,128 @@
+// Package household manages the families guests are invited as: a named
+// household with a head, a postal address and notes. A household's id is
+// the family_group of its guests, so the guest package's family routes work
+// on households unchanged.
+package household
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+type Household struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	HeadGuestID *int64    `json:"head_guest_id"`
+	Address     Address   `json:"address"`
+	Notes       *string   `json:"notes"`
+	MemberCount int       `json:"member_count"`
+	CreatedBy   string    `json:"created_by"`
+	UpdatedBy   string    `json:"updated_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Members is only filled on single-household responses.
+	Members []Member `json:"members,omitempty"`
+}
+
+// Address is a Brazilian postal address; every line is optional.
+type Address struct {
+	Line1      *string `json:"line1"       validate:"omitempty,max=200"`
+	Line2      *string `json:"line2"       validate:"omitempty,max=200"`
+	City       *string `json:"city"        validate:"omitempty,max=100"`
+	State      *string `json:"state"       validate:"omitempty,len=2,alpha"`
+	PostalCode *string `json:"postal_code" validate:"omitempty,len=8,numeric"`
+}
+
+var nonDigitRegex = regexp.MustCompile(`\D`)
+
+// normalize trims every line, drops blank ones, upper-cases the state and
+// keeps only the digits of the CEP, so "01310-100" is stored as "01310100".
+func (a *Address) normalize() {
+	for _, line := range []**string{&a.Line1, &a.Line2, &a.City, &a.State, &a.PostalCode} {
+		if *line == nil {
+			continue
+		}
+		v := strings.TrimSpace(**line)
+		if v == "" {
+			*line = nil
+			continue
+		}
+		*line = &v
+	}
+	if a.State != nil {
+		v := strings.ToUpper(*a.State)
+		a.State = &v
+	}
+	if a.PostalCode != nil {
+		v := nonDigitRegex.ReplaceAllString(*a.PostalCode, "")
+		a.PostalCode = &v
+	}
+}
+
+func (a Address) empty() bool {
+	return a.Line1 == nil && a.Line2 == nil && a.City == nil && a.State == nil && a.PostalCode == nil
+}
+
+// Member is a guest as listed under their household.
+type Member struct {
+	ID           int64  `json:"id"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Relationship string `json:"relationship"`
+	Attending    *bool  `json:"attending"`
+}
+
+// UpdateInput replaces a household's details: fields left out are cleared.
+// Membership changes go through the members, split and merge endpoints.
+type UpdateInput struct {
+	Name        string  `json:"name"          validate:"required,max=120"`
+	HeadGuestID *int64  `json:"head_guest_id" validate:"omitempty,gt=0"`
+	Address     Address `json:"address"`
+	Notes       *string `json:"notes"         validate:"omitempty,max=2000"`
+}
+
+// CreateInput adds a household. GuestIDs, when given, are moved into it
+// from wherever they are; the head must be one of them. Splits take the
+// same input, with GuestIDs required.
+type CreateInput struct {
+	UpdateInput
+	GuestIDs []int64 `json:"guest_ids" validate:"max=50,dive,gt=0"`
+}
+
+func (in *UpdateInput) normalize() {
+	in.Name = strings.TrimSpace(in.Name)
+	in.Address.normalize()
+	if in.Notes != nil {
+		if v := strings.TrimSpace(*in.Notes); v != "" {
+			in.Notes = &v
+		} else {
+			in.Notes = nil
+		}
+	}
+}
+
+// MoveInput names the guests moved into a household.
+type MoveInput struct {
+	GuestIDs []int64 `json:"guest_ids" validate:"required,min=1,max=50,dive,gt=0"`
+}
+
+// MergeInput names the household merged into (and then deleted in favour
+// of) the one in the path.
+type MergeInput struct {
+	HouseholdID int64 `json:"household_id" validate:"required,gt=0"`
+}
+
+// SplitResult is the household a split left behind and the one it created.
+type SplitResult struct {
+	Source  Household `json:"source"`
+	Created Household `json:"created"`
+}
+
+// MergeResult is the kept household after a merge, with its new members.
+type MergeResult struct {
+	Household     Household `json:"household"`
+	MergedID      int64     `json:"merged_id"`
+	MovedGuestIDs []int64   `json:"moved_guest_ids"`
+}
@@ -0,0 +1,23 @@
+package household
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type Repository interface {
+	List(ctx context.Context, search string) ([]Household, error)
+	GetByID(ctx context.Context, id int64) (*Household, error)
+	ListMembers(ctx context.Context, id int64) ([]Member, error)
+	Create(ctx context.Context, input UpdateInput, userRACF string) (*Household, error)
+	Update(ctx context.Context, id int64, input UpdateInput, userRACF string) (*Household, error)
+	Delete(ctx context.Context, id int64) error
+	MoveGuests(ctx context.Context, guestIDs []int64, to int64, userRACF string) ([]int64, error)
+	MoveInvitations(ctx context.Context, from, to int64) error
+}
+
+type TxAwareRepository interface {
+	Repository
+	WithTx(tx pgx.Tx) Repository
+}
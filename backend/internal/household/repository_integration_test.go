@@ -0,0 +1,74 @@
+//go:build integration
+// +build integration
+
+package household
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ferjunior7/parasempre/backend/internal/database"
+)
+
+func TestIntegrationHouseholdMoveAndDelete(t *testing.T) {
+	pool := database.NewTestPool(t)
+	tx := database.BeginTestTx(t, pool)
+	repo := NewPostgresRepository(pool).WithTx(tx)
+	ctx := context.Background()
+
+	souza, err := repo.Create(ctx, UpdateInput{Name: "Família Souzaintegra"}, "TST01")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	lima, err := repo.Create(ctx, UpdateInput{Name: "Família Limaintegra"}, "TST01")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	var guestID int64
+	if err := tx.QueryRow(ctx,
+		`INSERT INTO guests (first_name, last_name, relationship, family_group, created_by, updated_by)
+		 VALUES ('Ana', 'Souzaintegra', 'P', $1, 'TST01', 'TST01') RETURNING id`, souza.ID).Scan(&guestID); err != nil {
+		t.Fatalf("insert guest failed: %v", err)
+	}
+	head := guestID
+	if _, err := repo.Update(ctx, souza.ID, UpdateInput{Name: souza.Name, HeadGuestID: &head}, "TST01"); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	// The failed delete aborts whatever transaction runs it, so use a savepoint.
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		t.Fatalf("savepoint failed: %v", err)
+	}
+	if err := NewPostgresRepository(pool).WithTx(sp).Delete(ctx, souza.ID); err == nil {
+		t.Fatal("expected deleting a household with guests to fail")
+	}
+	if err := sp.Rollback(ctx); err != nil {
+		t.Fatalf("rollback to savepoint failed: %v", err)
+	}
+
+	moved, err := repo.MoveGuests(ctx, []int64{guestID, -1}, lima.ID, "TST01")
+	if err != nil {
+		t.Fatalf("MoveGuests failed: %v", err)
+	}
+	if len(moved) != 1 || moved[0] != guestID {
+		t.Fatalf("expected only the existing guest moved, got %v", moved)
+	}
+
+	after, err := repo.GetByID(ctx, souza.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if after.HeadGuestID != nil || after.MemberCount != 0 {
+		t.Fatalf("expected the source household headless and empty, got %+v", after)
+	}
+	members, err := repo.ListMembers(ctx, lima.ID)
+	if err != nil || len(members) != 1 || members[0].ID != guestID {
+		t.Fatalf("expected the guest under the new household, got %v (%v)", members, err)
+	}
+
+	if err := repo.Delete(ctx, souza.ID); err != nil {
+		t.Fatalf("Delete of the emptied household failed: %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package household
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/database"
+	"github.com/ferjunior7/parasempre/backend/internal/search"
+)
+
+const householdColumns = `h.id, h.name, h.head_guest_id, h.address_line1, h.address_line2, h.city, h.state, h.postal_code, h.notes,
+	(SELECT COUNT(*) FROM guests g WHERE g.family_group = h.id),
+	h.created_by, h.updated_by, h.created_at, h.updated_at`
+
+func scanHousehold(row pgx.Row) (Household, error) {
+	var h Household
+	err := row.Scan(&h.ID, &h.Name, &h.HeadGuestID,
+		&h.Address.Line1, &h.Address.Line2, &h.Address.City, &h.Address.State, &h.Address.PostalCode, &h.Notes,
+		&h.MemberCount, &h.CreatedBy, &h.UpdatedBy, &h.CreatedAt, &h.UpdatedAt)
+	return h, err
+}
+
+type PostgresRepository struct {
+	db database.DBTX
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{db: pool}
+}
+
+func (r *PostgresRepository) WithTx(tx pgx.Tx) Repository {
+	return &PostgresRepository{db: tx}
+}
+
+// List returns every household by name, or those whose name matches q.
+func (r *PostgresRepository) List(ctx context.Context, q string) ([]Household, error) {
+	query := `SELECT ` + householdColumns + ` FROM households h`
+	var args []any
+	if q != "" {
+		query += ` WHERE ` + search.Match("h.name", 1, 2)
+		args = append(args, q, search.LikePattern(q))
+	}
+	rows, err := r.db.Query(ctx, query+` ORDER BY h.name, h.id`, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "household.repo list: query failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	households := []Household{}
+	for rows.Next() {
+		h, err := scanHousehold(rows)
+		if err != nil {
+			return nil, err
+		}
+		households = append(households, h)
+	}
+	return households, rows.Err()
+}
+
+func (r *PostgresRepository) GetByID(ctx context.Context, id int64) (*Household, error) {
+	h, err := scanHousehold(r.db.QueryRow(ctx, `SELECT `+householdColumns+` FROM households h WHERE h.id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.NotFound("household not found")
+		}
+		slog.ErrorContext(ctx, "household.repo get_by_id: query failed", "id", id, "error", err)
+		return nil, err
+	}
+	return &h, nil
+}
+
+func (r *PostgresRepository) ListMembers(ctx context.Context, id int64) ([]Member, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT id, first_name, last_name, relationship, attending
+		 FROM guests WHERE family_group = $1
+		 ORDER BY first_name, last_name, id`, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "household.repo list_members: query failed", "id", id, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []Member{}
+	for rows.Next() {
+		var m Member
+		if err := rows.Scan(&m.ID, &m.FirstName, &m.LastName, &m.Relationship, &m.Attending); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+func (r *PostgresRepository) Create(ctx context.Context, input UpdateInput, userRACF string) (*Household, error) {
+	h, err := scanHousehold(r.db.QueryRow(ctx,
+		`WITH h AS (
+			INSERT INTO households (name, head_guest_id, address_line1, address_line2, city, state, postal_code, notes, created_by, updated_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+			RETURNING *
+		 )
+		 SELECT `+householdColumns+` FROM h`,
+		input.Name, input.HeadGuestID, input.Address.Line1, input.Address.Line2, input.Address.City,
+		input.Address.State, input.Address.PostalCode, input.Notes, userRACF))
+	if err != nil {
+		slog.ErrorContext(ctx, "household.repo create: insert failed", "error", err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "household.repo create: household stored", "id", h.ID)
+	return &h, nil
+}
+
+func (r *PostgresRepository) Update(ctx context.Context, id int64, input UpdateInput, userRACF string) (*Household, error) {
+	h, err := scanHousehold(r.db.QueryRow(ctx,
+		`WITH h AS (
+			UPDATE households SET
+				name = $1,
+				head_guest_id = $2,
+				address_line1 = $3,
+				address_line2 = $4,
+				city = $5,
+				state = $6,
+				postal_code = $7,
+				notes = $8,
+				updated_by = $9,
+				updated_at = now()
+			 WHERE id = $10
+			 RETURNING *
+		 )
+		 SELECT `+householdColumns+` FROM h`,
+		input.Name, input.HeadGuestID, input.Address.Line1, input.Address.Line2, input.Address.City,
+		input.Address.State, input.Address.PostalCode, input.Notes, userRACF, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.NotFound("household not found")
+		}
+		slog.ErrorContext(ctx, "household.repo update: update failed", "id", id, "error", err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "household.repo update: household updated", "id", h.ID)
+	return &h, nil
+}
+
+// Delete removes an empty household; guests.family_group keeps one that
+// still has guests.
+func (r *PostgresRepository) Delete(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM households WHERE id = $1`, id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return apperror.Conflict("household still has guests")
+		}
+		slog.ErrorContext(ctx, "household.repo delete: delete failed", "id", id, "error", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.NotFound("household not found")
+	}
+	slog.InfoContext(ctx, "household.repo delete: household deleted", "id", id)
+	return nil
+}
+
+// MoveInvitations hands the invitation links of household from over to
+// household to, so links already printed keep working after a merge.
+func (r *PostgresRepository) MoveInvitations(ctx context.Context, from, to int64) error {
+	if _, err := r.db.Exec(ctx,
+		`UPDATE household_invitations SET household_id = $2 WHERE household_id = $1`, from, to); err != nil {
+		slog.ErrorContext(ctx, "household.repo move_invitations: update failed", "from", from, "to", to, "error", err)
+		return err
+	}
+	return nil
+}
+
+// MoveGuests puts guestIDs in household to and returns the ids of those
+// found. A guest leaving a household they headed leaves it without a head.
+func (r *PostgresRepository) MoveGuests(ctx context.Context, guestIDs []int64, to int64, userRACF string) ([]int64, error) {
+	if _, err := r.db.Exec(ctx,
+		`UPDATE households SET head_guest_id = NULL, updated_by = $3, updated_at = now()
+		 WHERE head_guest_id = ANY($1) AND id <> $2`, guestIDs, to, userRACF); err != nil {
+		slog.ErrorContext(ctx, "household.repo move_guests: head reset failed", "to", to, "error", err)
+		return nil, err
+	}
+	rows, err := r.db.Query(ctx,
+		`UPDATE guests SET family_group = $2, updated_by = $3, updated_at = now()
+		 WHERE id = ANY($1)
+		 RETURNING id`, guestIDs, to, userRACF)
+	if err != nil {
+		slog.ErrorContext(ctx, "household.repo move_guests: update failed", "to", to, "error", err)
+		return nil, err
+	}
+	moved, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return nil, apperror.NotFound("household not found")
+		}
+		slog.ErrorContext(ctx, "household.repo move_guests: update failed", "to", to, "error", err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "household.repo move_guests: guests moved", "to", to, "count", len(moved))
+	return moved, nil
+}
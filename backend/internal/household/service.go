@@ -0,0 +1,360 @@
+package household
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/audit"
+	"github.com/ferjunior7/parasempre/backend/internal/database"
+	"github.com/ferjunior7/parasempre/backend/internal/validate"
+)
+
+const (
+	auditHouseholdCreated = "household.created"
+	auditHouseholdUpdated = "household.updated"
+	auditHouseholdDeleted = "household.deleted"
+	auditHouseholdMoved   = "household.members_moved"
+	auditHouseholdSplit   = "household.split"
+	auditHouseholdMerged  = "household.merged"
+)
+
+type Service struct {
+	repo     TxAwareRepository
+	txRunner database.TxRunner
+	audit    audit.Logger
+}
+
+func NewService(repo TxAwareRepository, txRunner database.TxRunner, audit audit.Logger) *Service {
+	return &Service{repo: repo, txRunner: txRunner, audit: audit}
+}
+
+// auditChanges diffs two snapshots, ignoring bookkeeping columns that change
+// on every write.
+func auditChanges(before, after *Household) map[string]audit.Change {
+	return audit.Diff(before, after, "updated_at", "updated_by", "member_count", "members")
+}
+
+func (s *Service) List(ctx context.Context, search string) ([]Household, error) {
+	households, err := s.repo.List(ctx, search)
+	if err != nil {
+		slog.ErrorContext(ctx, "household.service list: failed", "error", err)
+		return nil, apperror.Internal("failed to list households", err)
+	}
+	return households, nil
+}
+
+// Get returns a household with its members.
+func (s *Service) Get(ctx context.Context, id int64) (*Household, error) {
+	h, err := s.get(ctx, s.repo, id)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("failed to get household", err)
+	}
+	return h, nil
+}
+
+func (s *Service) get(ctx context.Context, repo Repository, id int64) (*Household, error) {
+	h, err := repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if h.Members, err = repo.ListMembers(ctx, id); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Create adds a household and moves input.GuestIDs into it.
+func (s *Service) Create(ctx context.Context, input CreateInput, userRACF string) (*Household, error) {
+	input.normalize()
+	if err := validate.Struct(input); err != nil {
+		return nil, err
+	}
+	if err := checkHead(input.HeadGuestID, input.GuestIDs); err != nil {
+		return nil, err
+	}
+
+	var created *Household
+	if err := s.txRunner.RunInTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		created, err = s.create(ctx, s.repo.WithTx(tx), input, userRACF)
+		return err
+	}); err != nil {
+		return nil, apperror.WrapIfNotApp("failed to create household", err)
+	}
+
+	audit.Record(ctx, s.audit, auditHouseholdCreated, audit.Entity(audit.EntityHousehold, created.ID, map[string]any{
+		"changes":   auditChanges(nil, created),
+		"guest_ids": input.GuestIDs,
+	}))
+	slog.InfoContext(ctx, "household.service create: household created", "id", created.ID, "guests", len(input.GuestIDs), "user_racf", userRACF)
+	return created, nil
+}
+
+// create inserts the household without its head, moves the guests in and
+// only then names the head, who must be a member by then.
+func (s *Service) create(ctx context.Context, repo Repository, input CreateInput, userRACF string) (*Household, error) {
+	details := input.UpdateInput
+	details.HeadGuestID = nil
+	h, err := repo.Create(ctx, details, userRACF)
+	if err != nil {
+		return nil, err
+	}
+	if len(input.GuestIDs) > 0 {
+		if err := moveGuests(ctx, repo, input.GuestIDs, h.ID, userRACF); err != nil {
+			return nil, err
+		}
+	}
+	if input.HeadGuestID != nil {
+		if _, err := repo.Update(ctx, h.ID, input.UpdateInput, userRACF); err != nil {
+			return nil, err
+		}
+	}
+	return s.get(ctx, repo, h.ID)
+}
+
+// Update replaces a household's details. The head must already be one of
+// its members.
+func (s *Service) Update(ctx context.Context, id int64, input UpdateInput, userRACF string) (*Household, error) {
+	input.normalize()
+	if err := validate.Struct(input); err != nil {
+		return nil, err
+	}
+
+	before, err := s.get(ctx, s.repo, id)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("failed to get household", err)
+	}
+	if err := checkHead(input.HeadGuestID, memberIDs(before.Members)); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.Update(ctx, id, input, userRACF)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("failed to update household", err)
+	}
+	updated.Members = before.Members
+
+	audit.Record(ctx, s.audit, auditHouseholdUpdated, audit.Entity(audit.EntityHousehold, id, map[string]any{
+		"changes": auditChanges(before, updated),
+	}))
+	slog.InfoContext(ctx, "household.service update: household updated", "id", id, "user_racf", userRACF)
+	return updated, nil
+}
+
+// Delete removes a household that no longer has guests.
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	before, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return apperror.WrapIfNotApp("failed to get household", err)
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return apperror.WrapIfNotApp("failed to delete household", err)
+	}
+	audit.Record(ctx, s.audit, auditHouseholdDeleted, audit.Entity(audit.EntityHousehold, id, map[string]any{
+		"changes": auditChanges(before, nil),
+	}))
+	slog.InfoContext(ctx, "household.service delete: household deleted", "id", id)
+	return nil
+}
+
+// MoveMembers moves guests into household id from wherever they are. The
+// households they leave keep their details, even when left empty.
+func (s *Service) MoveMembers(ctx context.Context, id int64, input MoveInput, userRACF string) (*Household, error) {
+	if err := validate.Struct(input); err != nil {
+		return nil, err
+	}
+
+	var h *Household
+	if err := s.txRunner.RunInTx(ctx, func(tx pgx.Tx) error {
+		txRepo := s.repo.WithTx(tx)
+		if _, err := txRepo.GetByID(ctx, id); err != nil {
+			return err
+		}
+		if err := moveGuests(ctx, txRepo, input.GuestIDs, id, userRACF); err != nil {
+			return err
+		}
+		var err error
+		h, err = s.get(ctx, txRepo, id)
+		return err
+	}); err != nil {
+		return nil, apperror.WrapIfNotApp("failed to move guests", err)
+	}
+
+	audit.Record(ctx, s.audit, auditHouseholdMoved, audit.Entity(audit.EntityHousehold, id, map[string]any{
+		"guest_ids": input.GuestIDs,
+	}))
+	slog.InfoContext(ctx, "household.service move_members: guests moved", "id", id, "count", len(input.GuestIDs), "user_racf", userRACF)
+	return h, nil
+}
+
+// Split moves some of a household's guests into a new household described
+// by input. At least one guest has to stay behind.
+func (s *Service) Split(ctx context.Context, sourceID int64, input CreateInput, userRACF string) (*SplitResult, error) {
+	input.normalize()
+	if err := validate.Struct(input); err != nil {
+		return nil, err
+	}
+	if len(input.GuestIDs) == 0 {
+		return nil, apperror.Validation("guest_ids is required to split a household")
+	}
+	if err := checkHead(input.HeadGuestID, input.GuestIDs); err != nil {
+		return nil, err
+	}
+
+	result := &SplitResult{}
+	if err := s.txRunner.RunInTx(ctx, func(tx pgx.Tx) error {
+		txRepo := s.repo.WithTx(tx)
+		source, err := s.get(ctx, txRepo, sourceID)
+		if err != nil {
+			return err
+		}
+		members := memberIDs(source.Members)
+		for _, id := range input.GuestIDs {
+			if !slices.Contains(members, id) {
+				return apperror.Validation(fmt.Sprintf("guest %d is not a member of household %d", id, sourceID))
+			}
+		}
+		if len(uniqueIDs(input.GuestIDs)) >= len(members) {
+			return apperror.Validation("a split must leave at least one guest in the household")
+		}
+
+		created, err := s.create(ctx, txRepo, input, userRACF)
+		if err != nil {
+			return err
+		}
+		after, err := s.get(ctx, txRepo, sourceID)
+		if err != nil {
+			return err
+		}
+		result.Source, result.Created = *after, *created
+		return nil
+	}); err != nil {
+		return nil, apperror.WrapIfNotApp("failed to split household", err)
+	}
+
+	audit.Record(ctx, s.audit, auditHouseholdSplit, audit.Entity(audit.EntityHousehold, sourceID, map[string]any{
+		"created_id":   result.Created.ID,
+		"created_name": result.Created.Name,
+		"guest_ids":    input.GuestIDs,
+	}))
+	slog.InfoContext(ctx, "household.service split: household split", "id", sourceID, "created_id", result.Created.ID, "user_racf", userRACF)
+	return result, nil
+}
+
+// Merge moves every guest of household input.HouseholdID into keepID and
+// deletes it. The kept household's blank head, address and notes are
+// filled from the merged one; the rest of its details win.
+func (s *Service) Merge(ctx context.Context, keepID int64, input MergeInput, userRACF string) (*MergeResult, error) {
+	if err := validate.Struct(input); err != nil {
+		return nil, err
+	}
+	if input.HouseholdID == keepID {
+		return nil, apperror.Validation("a household cannot be merged into itself")
+	}
+
+	var keep, merged *Household
+	result := &MergeResult{MergedID: input.HouseholdID}
+	if err := s.txRunner.RunInTx(ctx, func(tx pgx.Tx) error {
+		txRepo := s.repo.WithTx(tx)
+		var err error
+		if keep, err = s.get(ctx, txRepo, keepID); err != nil {
+			return err
+		}
+		if merged, err = s.get(ctx, txRepo, input.HouseholdID); err != nil {
+			return err
+		}
+
+		result.MovedGuestIDs = memberIDs(merged.Members)
+		if len(result.MovedGuestIDs) > 0 {
+			if err := moveGuests(ctx, txRepo, result.MovedGuestIDs, keepID, userRACF); err != nil {
+				return err
+			}
+		}
+		if details, changed := fillBlanks(keep, merged); changed {
+			if _, err := txRepo.Update(ctx, keepID, details, userRACF); err != nil {
+				return err
+			}
+		}
+		if err := txRepo.MoveInvitations(ctx, merged.ID, keepID); err != nil {
+			return err
+		}
+		if err := txRepo.Delete(ctx, merged.ID); err != nil {
+			return err
+		}
+		after, err := s.get(ctx, txRepo, keepID)
+		if err != nil {
+			return err
+		}
+		result.Household = *after
+		return nil
+	}); err != nil {
+		return nil, apperror.WrapIfNotApp("failed to merge households", err)
+	}
+
+	audit.Record(ctx, s.audit, auditHouseholdMerged, audit.Entity(audit.EntityHousehold, keepID, map[string]any{
+		"merged_id":       merged.ID,
+		"merged_name":     merged.Name,
+		"changes":         auditChanges(keep, &result.Household),
+		"removed":         auditChanges(merged, nil),
+		"moved_guest_ids": result.MovedGuestIDs,
+	}))
+	slog.InfoContext(ctx, "household.service merge: households merged", "id", keepID, "merged_id", merged.ID, "moved_guests", len(result.MovedGuestIDs), "user_racf", userRACF)
+	return result, nil
+}
+
+// fillBlanks is keep's details with its missing head, address and notes
+// taken from merged, and whether anything was taken.
+func fillBlanks(keep, merged *Household) (UpdateInput, bool) {
+	details := UpdateInput{Name: keep.Name, HeadGuestID: keep.HeadGuestID, Address: keep.Address, Notes: keep.Notes}
+	changed := false
+	if details.HeadGuestID == nil && merged.HeadGuestID != nil {
+		details.HeadGuestID, changed = merged.HeadGuestID, true
+	}
+	if details.Address.empty() && !merged.Address.empty() {
+		details.Address, changed = merged.Address, true
+	}
+	if details.Notes == nil && merged.Notes != nil {
+		details.Notes, changed = merged.Notes, true
+	}
+	return details, changed
+}
+
+// moveGuests moves guestIDs into household to, failing unless every one of
+// them exists.
+func moveGuests(ctx context.Context, repo Repository, guestIDs []int64, to int64, userRACF string) error {
+	moved, err := repo.MoveGuests(ctx, guestIDs, to, userRACF)
+	if err != nil {
+		return err
+	}
+	if len(moved) != len(uniqueIDs(guestIDs)) {
+		return apperror.NotFound("one or more guests not found")
+	}
+	return nil
+}
+
+// checkHead rejects a head of household who is not among members.
+func checkHead(head *int64, members []int64) error {
+	if head != nil && !slices.Contains(members, *head) {
+		return apperror.Validation("head_guest_id must be a member of the household")
+	}
+	return nil
+}
+
+func memberIDs(members []Member) []int64 {
+	ids := make([]int64, len(members))
+	for i, m := range members {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+func uniqueIDs(ids []int64) []int64 {
+	sorted := slices.Clone(ids)
+	slices.Sort(sorted)
+	return slices.Compact(sorted)
+}
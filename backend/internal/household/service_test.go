@@ -0,0 +1,372 @@
+package household
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
+)
+
+// memRepository keeps households and guest memberships in memory, enough
+// to follow guests through moves, splits and merges.
+type memRepository struct {
+	households map[int64]*Household
+	// guests maps a guest id to its household.
+	guests map[int64]int64
+	nextID int64
+	// invitationMoves records MoveInvitations calls as {from, to}.
+	invitationMoves [][2]int64
+}
+
+func newMemRepository() *memRepository {
+	return &memRepository{households: map[int64]*Household{}, guests: map[int64]int64{}, nextID: 100}
+}
+
+func (m *memRepository) add(h Household, guestIDs ...int64) {
+	m.households[h.ID] = &h
+	for _, id := range guestIDs {
+		m.guests[id] = h.ID
+	}
+}
+
+func (m *memRepository) WithTx(tx pgx.Tx) Repository { return m }
+
+func (m *memRepository) count(id int64) int {
+	n := 0
+	for _, hid := range m.guests {
+		if hid == id {
+			n++
+		}
+	}
+	return n
+}
+
+func (m *memRepository) List(ctx context.Context, search string) ([]Household, error) {
+	out := []Household{}
+	for _, h := range m.households {
+		if strings.Contains(strings.ToLower(h.Name), strings.ToLower(search)) {
+			c := *h
+			c.MemberCount = m.count(h.ID)
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (m *memRepository) GetByID(ctx context.Context, id int64) (*Household, error) {
+	h, ok := m.households[id]
+	if !ok {
+		return nil, apperror.NotFound("household not found")
+	}
+	c := *h
+	c.MemberCount = m.count(id)
+	return &c, nil
+}
+
+func (m *memRepository) ListMembers(ctx context.Context, id int64) ([]Member, error) {
+	members := []Member{}
+	for gid, hid := range m.guests {
+		if hid == id {
+			members = append(members, Member{ID: gid})
+		}
+	}
+	slices.SortFunc(members, func(a, b Member) int { return int(a.ID - b.ID) })
+	return members, nil
+}
+
+func (m *memRepository) Create(ctx context.Context, input UpdateInput, userRACF string) (*Household, error) {
+	m.nextID++
+	m.households[m.nextID] = &Household{}
+	return m.Update(ctx, m.nextID, input, userRACF)
+}
+
+func (m *memRepository) Update(ctx context.Context, id int64, input UpdateInput, userRACF string) (*Household, error) {
+	h, ok := m.households[id]
+	if !ok {
+		return nil, apperror.NotFound("household not found")
+	}
+	*h = Household{ID: id, Name: input.Name, HeadGuestID: input.HeadGuestID, Address: input.Address, Notes: input.Notes, CreatedBy: userRACF, UpdatedBy: userRACF}
+	return m.GetByID(ctx, id)
+}
+
+func (m *memRepository) Delete(ctx context.Context, id int64) error {
+	if _, ok := m.households[id]; !ok {
+		return apperror.NotFound("household not found")
+	}
+	if m.count(id) > 0 {
+		return apperror.Conflict("household still has guests")
+	}
+	delete(m.households, id)
+	return nil
+}
+
+func (m *memRepository) MoveInvitations(ctx context.Context, from, to int64) error {
+	m.invitationMoves = append(m.invitationMoves, [2]int64{from, to})
+	return nil
+}
+
+func (m *memRepository) MoveGuests(ctx context.Context, guestIDs []int64, to int64, userRACF string) ([]int64, error) {
+	var moved []int64
+	for _, gid := range uniqueIDs(guestIDs) {
+		from, ok := m.guests[gid]
+		if !ok {
+			continue
+		}
+		if h := m.households[from]; from != to && h.HeadGuestID != nil && *h.HeadGuestID == gid {
+			h.HeadGuestID = nil
+		}
+		m.guests[gid] = to
+		moved = append(moved, gid)
+	}
+	return moved, nil
+}
+
+type mockAudit struct {
+	calls []auditCall
+}
+
+type auditCall struct {
+	action  string
+	details map[string]any
+}
+
+func (m *mockAudit) LogAction(_ context.Context, _ int64, action string, details map[string]any) error {
+	m.calls = append(m.calls, auditCall{action: action, details: details})
+	return nil
+}
+
+type mockTxRunner struct{}
+
+func (m *mockTxRunner) RunInTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	return fn(nil)
+}
+
+func assertAppError(t *testing.T, err error, wantCode int, wantMsg string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected error containing %q, got nil", wantMsg)
+	}
+	ae, ok := apperror.IsAppError(err)
+	if !ok {
+		t.Fatalf("expected AppError, got %T: %v", err, err)
+	}
+	if ae.Code != wantCode {
+		t.Fatalf("expected code %d, got %d (%s)", wantCode, ae.Code, ae.Message)
+	}
+	if !strings.Contains(ae.Message, wantMsg) {
+		t.Fatalf("expected message containing %q, got %q", wantMsg, ae.Message)
+	}
+}
+
+func int64Ptr(v int64) *int64   { return &v }
+func strPtr(v string) *string   { return &v }
+func auditCtx() context.Context { return reqctx.WithUserID(context.Background(), 9) }
+
+// newTestService starts with household 1, "Família Souza": guests 10 (its
+// head), 11 and 12.
+func newTestService() (*Service, *memRepository, *mockAudit) {
+	repo := newMemRepository()
+	repo.add(Household{ID: 1, Name: "Família Souza", HeadGuestID: int64Ptr(10)}, 10, 11, 12)
+	aud := &mockAudit{}
+	return NewService(repo, &mockTxRunner{}, aud), repo, aud
+}
+
+func TestAddressNormalize(t *testing.T) {
+	a := Address{Line1: strPtr("  Rua A, 10 "), Line2: strPtr("   "), State: strPtr("sp"), PostalCode: strPtr("01310-100")}
+	a.normalize()
+	if *a.Line1 != "Rua A, 10" || a.Line2 != nil || *a.State != "SP" || *a.PostalCode != "01310100" {
+		t.Fatalf("unexpected address %+v", a)
+	}
+}
+
+func TestServiceCreateMovesGuestsAndNamesHead(t *testing.T) {
+	svc, repo, aud := newTestService()
+
+	h, err := svc.Create(auditCtx(), CreateInput{
+		UpdateInput: UpdateInput{Name: " Família Lima ", HeadGuestID: int64Ptr(10)},
+		GuestIDs:    []int64{10},
+	}, "TST01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.Name != "Família Lima" || h.HeadGuestID == nil || *h.HeadGuestID != 10 || len(h.Members) != 1 {
+		t.Fatalf("unexpected household %+v", h)
+	}
+	if repo.households[1].HeadGuestID != nil {
+		t.Fatal("expected the old household to lose its head")
+	}
+	if len(aud.calls) != 1 || aud.calls[0].action != auditHouseholdCreated {
+		t.Fatalf("expected one %s audit entry, got %+v", auditHouseholdCreated, aud.calls)
+	}
+}
+
+func TestServiceCreateValidation(t *testing.T) {
+	svc, _, _ := newTestService()
+	tests := []struct {
+		name    string
+		input   CreateInput
+		wantMsg string
+	}{
+		{"head outside guests", CreateInput{UpdateInput: UpdateInput{Name: "Família Lima", HeadGuestID: int64Ptr(10)}}, "head_guest_id must be a member of the household"},
+		{"blank name", CreateInput{UpdateInput: UpdateInput{Name: "  "}}, "name is required"},
+		{"bad state", CreateInput{UpdateInput: UpdateInput{Name: "Família Lima", Address: Address{State: strPtr("São Paulo")}}}, "State failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Create(context.Background(), tt.input, "TST01")
+			assertAppError(t, err, http.StatusBadRequest, tt.wantMsg)
+		})
+	}
+
+	_, err := svc.Create(context.Background(), CreateInput{UpdateInput: UpdateInput{Name: "Família Lima"}, GuestIDs: []int64{99}}, "TST01")
+	assertAppError(t, err, http.StatusNotFound, "one or more guests not found")
+}
+
+func TestServiceUpdateRequiresMemberHead(t *testing.T) {
+	svc, repo, _ := newTestService()
+	repo.add(Household{ID: 2, Name: "Família Lima"}, 20)
+
+	_, err := svc.Update(context.Background(), 1, UpdateInput{Name: "Família Souza", HeadGuestID: int64Ptr(20)}, "TST01")
+	assertAppError(t, err, http.StatusBadRequest, "head_guest_id must be a member of the household")
+
+	h, err := svc.Update(context.Background(), 1, UpdateInput{Name: "Família Souza", HeadGuestID: int64Ptr(11), Notes: strPtr("Mesa 4")}, "TST01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *h.HeadGuestID != 11 || *h.Notes != "Mesa 4" || len(h.Members) != 3 {
+		t.Fatalf("unexpected household %+v", h)
+	}
+}
+
+func TestServiceSplit(t *testing.T) {
+	svc, repo, aud := newTestService()
+
+	result, err := svc.Split(auditCtx(), 1, CreateInput{
+		UpdateInput: UpdateInput{Name: "Família Souza Lima", HeadGuestID: int64Ptr(12)},
+		GuestIDs:    []int64{11, 12},
+	}, "TST01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := memberIDs(result.Source.Members); !reflect.DeepEqual(got, []int64{10}) {
+		t.Fatalf("expected guest 10 to stay, got %v", got)
+	}
+	if got := memberIDs(result.Created.Members); !reflect.DeepEqual(got, []int64{11, 12}) {
+		t.Fatalf("expected guests 11 and 12 to move, got %v", got)
+	}
+	if repo.guests[11] != result.Created.ID || *result.Created.HeadGuestID != 12 {
+		t.Fatalf("unexpected created household %+v", result.Created)
+	}
+	if len(aud.calls) != 1 || aud.calls[0].action != auditHouseholdSplit || aud.calls[0].details["created_id"] != result.Created.ID {
+		t.Fatalf("unexpected audit entries %+v", aud.calls)
+	}
+}
+
+func TestServiceSplitRejects(t *testing.T) {
+	svc, repo, _ := newTestService()
+	repo.add(Household{ID: 2, Name: "Família Lima"}, 20)
+	tests := []struct {
+		name     string
+		guestIDs []int64
+		wantMsg  string
+	}{
+		{"no guests", nil, "guest_ids is required to split a household"},
+		{"outsider", []int64{11, 20}, "guest 20 is not a member of household 1"},
+		{"everyone", []int64{10, 11, 12}, "a split must leave at least one guest in the household"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Split(context.Background(), 1, CreateInput{UpdateInput: UpdateInput{Name: "Família Nova"}, GuestIDs: tt.guestIDs}, "TST01")
+			assertAppError(t, err, http.StatusBadRequest, tt.wantMsg)
+		})
+	}
+	if len(repo.households) != 2 {
+		t.Fatalf("expected no household created, got %d", len(repo.households))
+	}
+}
+
+func TestServiceMerge(t *testing.T) {
+	svc, repo, aud := newTestService()
+	repo.households[1].HeadGuestID = nil
+	address := Address{City: strPtr("Campinas"), State: strPtr("SP")}
+	repo.add(Household{ID: 2, Name: "Família Souza (2)", HeadGuestID: int64Ptr(20), Address: address, Notes: strPtr("Vegetarianos")}, 20, 21)
+
+	result, err := svc.Merge(auditCtx(), 1, MergeInput{HouseholdID: 2}, "TST01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := result.Household
+	if h.Name != "Família Souza" || h.HeadGuestID == nil || *h.HeadGuestID != 20 || *h.Address.City != "Campinas" || *h.Notes != "Vegetarianos" {
+		t.Fatalf("expected blanks filled from the merged household, got %+v", h)
+	}
+	if len(h.Members) != 5 || !reflect.DeepEqual(result.MovedGuestIDs, []int64{20, 21}) {
+		t.Fatalf("unexpected members %v moved %v", h.Members, result.MovedGuestIDs)
+	}
+	if _, ok := repo.households[2]; ok {
+		t.Fatal("expected the merged household to be deleted")
+	}
+	if !reflect.DeepEqual(repo.invitationMoves, [][2]int64{{2, 1}}) {
+		t.Fatalf("expected the merged household's invitations moved to the kept one, got %v", repo.invitationMoves)
+	}
+	if len(aud.calls) != 1 || aud.calls[0].action != auditHouseholdMerged || aud.calls[0].details["merged_name"] != "Família Souza (2)" {
+		t.Fatalf("unexpected audit entries %+v", aud.calls)
+	}
+}
+
+func TestServiceMergeKeepsOwnDetails(t *testing.T) {
+	svc, repo, _ := newTestService()
+	repo.households[1].Notes = strPtr("Mesa 4")
+	repo.add(Household{ID: 2, Name: "Outra", HeadGuestID: int64Ptr(20), Notes: strPtr("Mesa 9")}, 20)
+
+	result, err := svc.Merge(context.Background(), 1, MergeInput{HouseholdID: 2}, "TST01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *result.Household.HeadGuestID != 10 || *result.Household.Notes != "Mesa 4" {
+		t.Fatalf("expected the kept household's details to win, got %+v", result.Household)
+	}
+}
+
+func TestServiceMergeRejectsSelf(t *testing.T) {
+	svc, _, _ := newTestService()
+	_, err := svc.Merge(context.Background(), 1, MergeInput{HouseholdID: 1}, "TST01")
+	assertAppError(t, err, http.StatusBadRequest, "a household cannot be merged into itself")
+}
+
+func TestServiceDeleteRequiresEmpty(t *testing.T) {
+	svc, repo, aud := newTestService()
+	repo.add(Household{ID: 2, Name: "Vazia"})
+
+	err := svc.Delete(auditCtx(), 1)
+	assertAppError(t, err, http.StatusConflict, "household still has guests")
+
+	if err := svc.Delete(auditCtx(), 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(aud.calls) != 1 || aud.calls[0].action != auditHouseholdDeleted {
+		t.Fatalf("unexpected audit entries %+v", aud.calls)
+	}
+}
+
+func TestServiceMoveMembers(t *testing.T) {
+	svc, repo, _ := newTestService()
+	repo.add(Household{ID: 2, Name: "Família Lima"}, 20)
+
+	h, err := svc.MoveMembers(context.Background(), 2, MoveInput{GuestIDs: []int64{10, 10}}, "TST01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(h.Members) != 2 || repo.households[1].HeadGuestID != nil {
+		t.Fatalf("expected the head to move and leave household 1 headless, got %+v", h)
+	}
+
+	_, err = svc.MoveMembers(context.Background(), 3, MoveInput{GuestIDs: []int64{11}}, "TST01")
+	assertAppError(t, err, http.StatusNotFound, "household not found")
+}
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
 )
 
 func TestWriteJSON(t *testing.T) {
@@ -137,3 +138,28 @@ func TestPathIDInvalid(t *testing.T) {
 	w := httptest.NewRecorder()
 	mux.ServeHTTP(w, req)
 }
+
+func TestWriteErrorIncludesRequestID(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/guests/1", nil)
+	r = r.WithContext(reqctx.WithRequestID(r.Context(), "req-abc-123"))
+	WriteError(w, r, apperror.NotFound("guest not found"))
+
+	var body map[string]string
+	json.NewDecoder(w.Body).Decode(&body)
+	if body["request_id"] != "req-abc-123" {
+		t.Fatalf("expected request_id req-abc-123, got %q", body["request_id"])
+	}
+}
+
+func TestWriteErrorOmitsEmptyRequestID(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/guests", nil)
+	WriteError(w, r, http.ErrServerClosed)
+
+	var body map[string]string
+	json.NewDecoder(w.Body).Decode(&body)
+	if _, ok := body["request_id"]; ok {
+		t.Fatalf("expected no request_id key, got %v", body)
+	}
+}
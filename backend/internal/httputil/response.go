@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
 )
 
 func WriteJSON(w http.ResponseWriter, status int, data any) {
@@ -16,30 +17,48 @@ func WriteJSON(w http.ResponseWriter, status int, data any) {
 }
 
 func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	ctx := r.Context()
+	requestID := reqctx.RequestID(ctx)
+
 	var rle *apperror.RateLimitedError
 	if errors.As(err, &rle) {
-		WriteJSON(w, rle.Code, map[string]any{
+		body := map[string]any{
 			"error":               rle.Message,
 			"retry_after_seconds": int(rle.RetryAfter.Seconds()),
-		})
+		}
+		if requestID != "" {
+			body["request_id"] = requestID
+		}
+		WriteJSON(w, rle.Code, body)
 		return
 	}
 
 	var appErr *apperror.AppError
 	if errors.As(err, &appErr) {
 		if appErr.Code >= 500 && appErr.Err != nil {
-			slog.Error("internal app error",
+			slog.ErrorContext(ctx, "internal app error",
 				"path", r.URL.Path, "method", r.Method,
 				"msg", appErr.Message, "cause", appErr.Err)
 		}
-		WriteJSON(w, appErr.Code, map[string]string{"error": appErr.Message})
+		WriteJSON(w, appErr.Code, errorBody(appErr.Message, requestID))
 		return
 	}
 
-	slog.Error("unhandled error", "path", r.URL.Path, "method", r.Method, "err", err)
-	WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal server error"})
+	slog.ErrorContext(ctx, "unhandled error", "path", r.URL.Path, "method", r.Method, "err", err)
+	WriteJSON(w, http.StatusInternalServerError, errorBody("internal server error", requestID))
 }
 
 func WriteErrorMsg(w http.ResponseWriter, status int, msg string) {
 	WriteJSON(w, status, map[string]string{"error": msg})
 }
+
+// errorBody keeps the historical {"error": "..."} shape and adds request_id
+// when the request went through middleware.RequestID, so a user can quote
+// it when reporting a problem.
+func errorBody(msg, requestID string) map[string]string {
+	body := map[string]string{"error": msg}
+	if requestID != "" {
+		body["request_id"] = requestID
+	}
+	return body
+}
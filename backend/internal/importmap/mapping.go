@@ -0,0 +1,218 @@
+package importmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+const maxHeaderLen = 200
+
+// Field is a column an importer understands.
+type Field struct {
+	Key      string `json:"key"`
+	Label    string `json:"label"`
+	Required bool   `json:"required"`
+	// Aliases are header spellings, besides Key, that suggest this field.
+	// Compared after Normalize.
+	Aliases []string `json:"-"`
+}
+
+// Mapping assigns a header of the file to each field key. Fields left out
+// (or mapped to "") are not imported.
+type Mapping map[string]string
+
+// Columns is a resolved Mapping: field key → cell index.
+type Columns map[string]int
+
+// Get returns the row's cell for field key, or "" when the field is not
+// mapped or the row is short.
+func (c Columns) Get(row Row, key string) string {
+	idx, ok := c[key]
+	if !ok || idx >= len(row.Cells) {
+		return ""
+	}
+	return row.Cells[idx]
+}
+
+// Plan describes a file's columns to the client choosing the mapping.
+type Plan struct {
+	Headers []string `json:"headers"`
+	Fields  []Field  `json:"fields"`
+	Mapping Mapping  `json:"mapping"`
+	// Missing lists the required fields Mapping leaves out; rows are only
+	// read once it is empty.
+	Missing []string `json:"missing,omitempty"`
+}
+
+// MissingColumnsError is returned by Resolve when required fields are not
+// mapped to any column.
+type MissingColumnsError struct {
+	Fields []string
+}
+
+func (e *MissingColumnsError) Error() string {
+	quoted := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		quoted[i] = fmt.Sprintf("%q", f)
+	}
+	return "missing required column: " + strings.Join(quoted, ", ")
+}
+
+// ParseMapping reads the JSON object a client sends along with the file.
+// An empty string means no mapping was sent.
+func ParseMapping(raw string) (Mapping, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var m Mapping
+	if err := json.Unmarshal([]byte(raw), &m); err != nil {
+		return nil, fmt.Errorf("mapping must be a JSON object of field to column: %w", err)
+	}
+	return m, nil
+}
+
+// Check rejects keys that are not fields and oversized header names.
+func (m Mapping) Check(fields []Field) error {
+	for key, header := range m {
+		if fieldByKey(fields, key) == nil {
+			return fmt.Errorf("unknown field %q in mapping", key)
+		}
+		if len(header) > maxHeaderLen {
+			return fmt.Errorf("column name for %q too long", key)
+		}
+	}
+	return nil
+}
+
+// Suggest maps each field to a header: first the column the user chose for
+// it last time (remembered, may be nil), then a header matching the field's
+// key or an alias, then a header starting with one ("Valor (R$)" for
+// "valor"). Each header is used at most once.
+func Suggest(fields []Field, headers []string, remembered Mapping) Mapping {
+	normalized := make([]string, len(headers))
+	for i, h := range headers {
+		normalized[i] = Normalize(h)
+	}
+	taken := make([]bool, len(headers))
+	out := Mapping{}
+
+	pick := func(field Field, match func(i int) bool) {
+		if _, done := out[field.Key]; done {
+			return
+		}
+		for i := range headers {
+			if !taken[i] && normalized[i] != "" && match(i) {
+				taken[i] = true
+				out[field.Key] = headers[i]
+				return
+			}
+		}
+	}
+
+	for _, f := range fields {
+		if want := Normalize(remembered[f.Key]); want != "" {
+			pick(f, func(i int) bool { return normalized[i] == want })
+		}
+	}
+	for _, f := range fields {
+		names := fieldNames(f)
+		pick(f, func(i int) bool { return names[normalized[i]] })
+	}
+	for _, f := range fields {
+		names := fieldNames(f)
+		pick(f, func(i int) bool {
+			for name := range names {
+				if strings.HasPrefix(normalized[i], name+" ") {
+					return true
+				}
+			}
+			return false
+		})
+	}
+	return out
+}
+
+// Resolve checks m against the file's headers and returns the column of
+// each mapped field. Headers match after Normalize, so a remembered
+// "Preço" still finds "PREÇO". Unmapped required fields yield a
+// *MissingColumnsError.
+func Resolve(fields []Field, headers []string, m Mapping) (Columns, error) {
+	if err := m.Check(fields); err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(headers))
+	for i, h := range headers {
+		if n := Normalize(h); n != "" {
+			if _, dup := index[n]; !dup {
+				index[n] = i
+			}
+		}
+	}
+
+	cols := Columns{}
+	usedBy := map[int]string{}
+	var missing []string
+	for _, f := range fields {
+		header := strings.TrimSpace(m[f.Key])
+		if header == "" {
+			if f.Required {
+				missing = append(missing, f.Key)
+			}
+			continue
+		}
+		idx, ok := index[Normalize(header)]
+		if !ok {
+			return nil, fmt.Errorf("column %q not found in file", header)
+		}
+		if other, dup := usedBy[idx]; dup {
+			return nil, fmt.Errorf("column %q mapped to both %q and %q", header, other, f.Key)
+		}
+		usedBy[idx] = f.Key
+		cols[f.Key] = idx
+	}
+	if len(missing) > 0 {
+		return nil, &MissingColumnsError{Fields: missing}
+	}
+	return cols, nil
+}
+
+// Normalize folds a header for comparison: accents and case are dropped and
+// runs of anything but letters and digits become one space, so "Preço
+// (R$)", "preco r$" and "PRECO_R" compare equal.
+func Normalize(s string) string {
+	t := transform.Chain(norm.NFD, transform.RemoveFunc(isMark), norm.NFC)
+	if folded, _, err := transform.String(t, s); err == nil {
+		s = folded
+	}
+	s = strings.ToLower(s)
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+func isMark(r rune) bool {
+	return unicode.Is(unicode.Mn, r)
+}
+
+func fieldNames(f Field) map[string]bool {
+	names := map[string]bool{Normalize(f.Key): true}
+	for _, a := range f.Aliases {
+		names[Normalize(a)] = true
+	}
+	return names
+}
+
+func fieldByKey(fields []Field, key string) *Field {
+	for i := range fields {
+		if fields[i].Key == key {
+			return &fields[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package importmap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testFields = []Field{
+	{Key: "name", Required: true, Aliases: []string{"produto", "nome"}},
+	{Key: "price_brl", Required: true, Aliases: []string{"valor", "preco"}},
+	{Key: "store_url", Aliases: []string{"link", "url"}},
+	{Key: "image_url", Aliases: []string{"imagem", "link da imagem"}},
+}
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"Preço (R$)":   "preco r",
+		"  PRECO_R  ":  "preco r",
+		"Link da Loja": "link da loja",
+		"Descrição":    "descricao",
+		"(R$)":         "r",
+		"":             "",
+		"price_brl":    "price brl",
+		"Família 1":    "familia 1",
+	}
+	for in, want := range cases {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	headers := []string{"Produto", "Valor (R$)", "Link da imagem", "Link", "Observação"}
+	got := Suggest(testFields, headers, nil)
+	want := Mapping{"name": "Produto", "price_brl": "Valor (R$)", "image_url": "Link da imagem", "store_url": "Link"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: got %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestSuggestPrefersRemembered(t *testing.T) {
+	headers := []string{"Nome", "Item", "Preço"}
+	got := Suggest(testFields, headers, Mapping{"name": "ITEM", "price_brl": "Coluna que sumiu"})
+	if got["name"] != "Item" {
+		t.Errorf("expected remembered column for name, got %q", got["name"])
+	}
+	if got["price_brl"] != "Preço" {
+		t.Errorf("expected alias fallback when the remembered column is gone, got %q", got["price_brl"])
+	}
+}
+
+func TestResolve(t *testing.T) {
+	headers := []string{"Produto", "Valor", "Link"}
+
+	cols, err := Resolve(testFields, headers, Mapping{"name": "produto", "price_brl": "Valor", "store_url": ""})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	row := Row{Cells: []string{"Panela", "10,00"}}
+	if cols.Get(row, "name") != "Panela" || cols.Get(row, "price_brl") != "10,00" || cols.Get(row, "store_url") != "" {
+		t.Errorf("unexpected columns %v", cols)
+	}
+
+	_, err = Resolve(testFields, headers, Mapping{"name": "Produto"})
+	var missing *MissingColumnsError
+	if !errors.As(err, &missing) || len(missing.Fields) != 1 || missing.Fields[0] != "price_brl" {
+		t.Fatalf("expected price_brl missing, got %v", err)
+	}
+
+	for name, m := range map[string]Mapping{
+		"unknown field":  {"name": "Produto", "price_brl": "Valor", "color": "Link"},
+		"unknown column": {"name": "Produto", "price_brl": "Preço"},
+		"column twice":   {"name": "Produto", "price_brl": "Produto"},
+		"long header":    {"name": strings.Repeat("x", maxHeaderLen+1), "price_brl": "Valor"},
+	} {
+		if _, err := Resolve(testFields, headers, m); err == nil || errors.As(err, &missing) {
+			t.Errorf("%s: expected a mapping error, got %v", name, err)
+		}
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	m, err := ParseMapping(`{"name":"Produto"}`)
+	if err != nil || m["name"] != "Produto" {
+		t.Fatalf("got %v / %v", m, err)
+	}
+	if m, err := ParseMapping("  "); m != nil || err != nil {
+		t.Fatalf("expected no mapping for blank input, got %v / %v", m, err)
+	}
+	if _, err := ParseMapping(`["name"]`); err == nil {
+		t.Fatal("expected error for non-object mapping")
+	}
+}
@@ -0,0 +1,56 @@
+package importmap
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
+)
+
+// Prepare works out the columns of t for an importer. A nil m asks for a
+// suggestion, seeded with the mapping the user in ctx confirmed last. The
+// returned Columns is nil while plan.Missing is not empty; other mapping
+// problems (unknown field or column, a column used twice) are errors.
+func Prepare(ctx context.Context, repo Repository, kind string, fields []Field, t *Table, m Mapping) (Plan, Columns, error) {
+	if m == nil {
+		m = Suggest(fields, t.Headers, remembered(ctx, repo, kind))
+	}
+	plan := Plan{Headers: t.Headers, Fields: fields, Mapping: m}
+
+	cols, err := Resolve(fields, t.Headers, m)
+	var missing *MissingColumnsError
+	switch {
+	case errors.As(err, &missing):
+		plan.Missing = missing.Fields
+		return plan, nil, nil
+	case err != nil:
+		return plan, nil, err
+	}
+	return plan, cols, nil
+}
+
+// Remember stores m as the user's mapping for kind. Failing to remember
+// never fails the import, so errors are only logged.
+func Remember(ctx context.Context, repo Repository, kind string, m Mapping) {
+	userID := reqctx.UserID(ctx)
+	if repo == nil || userID == 0 || len(m) == 0 {
+		return
+	}
+	if err := repo.Save(ctx, userID, kind, m); err != nil {
+		slog.WarnContext(ctx, "importmap remember: save failed", "kind", kind, "user_id", userID, "error", err)
+	}
+}
+
+func remembered(ctx context.Context, repo Repository, kind string) Mapping {
+	userID := reqctx.UserID(ctx)
+	if repo == nil || userID == 0 {
+		return nil
+	}
+	m, err := repo.Get(ctx, userID, kind)
+	if err != nil {
+		slog.WarnContext(ctx, "importmap remembered: lookup failed, suggesting from headers only", "kind", kind, "user_id", userID, "error", err)
+		return nil
+	}
+	return m
+}
@@ -0,0 +1,15 @@
+package importmap
+
+import "context"
+
+// Kinds of import, one remembered mapping per user each.
+const (
+	KindGift  = "gift"
+	KindGuest = "guest"
+)
+
+type Repository interface {
+	// Get returns the user's last mapping for kind, or nil when there is none.
+	Get(ctx context.Context, userID int64, kind string) (Mapping, error)
+	Save(ctx context.Context, userID int64, kind string, m Mapping) error
+}
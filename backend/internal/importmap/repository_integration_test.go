@@ -0,0 +1,46 @@
+//go:build integration
+// +build integration
+
+package importmap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ferjunior7/parasempre/backend/internal/database"
+)
+
+func TestIntegrationMappingRoundTrip(t *testing.T) {
+	pool := database.NewTestPool(t)
+	database.CleanTable(t, pool, "import_column_mappings")
+	database.CleanTable(t, pool, "users")
+	ctx := context.Background()
+
+	var userID int64
+	if err := pool.QueryRow(ctx,
+		`INSERT INTO users (uracf, role) VALUES ('TST01', 'groom') RETURNING id`,
+	).Scan(&userID); err != nil {
+		t.Fatalf("seed user failed: %v", err)
+	}
+	repo := NewPostgresRepository(pool)
+
+	got, err := repo.Get(ctx, userID, KindGift)
+	if err != nil || got != nil {
+		t.Fatalf("expected no mapping yet, got %v / %v", got, err)
+	}
+
+	if err := repo.Save(ctx, userID, KindGift, Mapping{"name": "Produto", "price_brl": "Valor"}); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if err := repo.Save(ctx, userID, KindGift, Mapping{"name": "Item", "price_brl": "Valor"}); err != nil {
+		t.Fatalf("second save failed: %v", err)
+	}
+	got, err = repo.Get(ctx, userID, KindGift)
+	if err != nil || got["name"] != "Item" || got["price_brl"] != "Valor" {
+		t.Fatalf("expected the latest mapping, got %v / %v", got, err)
+	}
+
+	if other, err := repo.Get(ctx, userID, KindGuest); err != nil || other != nil {
+		t.Fatalf("expected mappings kept per kind, got %v / %v", other, err)
+	}
+}
@@ -0,0 +1,47 @@
+package importmap
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ferjunior7/parasempre/backend/internal/database"
+)
+
+type PostgresRepository struct {
+	db database.DBTX
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{db: pool}
+}
+
+func (r *PostgresRepository) Get(ctx context.Context, userID int64, kind string) (Mapping, error) {
+	var m Mapping
+	err := r.db.QueryRow(ctx,
+		`SELECT mapping FROM import_column_mappings WHERE user_id = $1 AND kind = $2`,
+		userID, kind).Scan(&m)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		slog.ErrorContext(ctx, "importmap.repo get: query failed", "user_id", userID, "kind", kind, "error", err)
+		return nil, err
+	}
+	return m, nil
+}
+
+func (r *PostgresRepository) Save(ctx context.Context, userID int64, kind string, m Mapping) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO import_column_mappings (user_id, kind, mapping)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (user_id, kind) DO UPDATE SET mapping = EXCLUDED.mapping, updated_at = now()`,
+		userID, kind, m)
+	if err != nil {
+		slog.ErrorContext(ctx, "importmap.repo save: exec failed", "user_id", userID, "kind", kind, "error", err)
+	}
+	return err
+}
@@ -0,0 +1,138 @@
+// Package importmap reads the spreadsheets the couple uploads (CSV or XLSX)
+// and maps their columns, whatever the headers say, onto the fields an
+// importer expects. The mapping each user confirmed last is remembered so
+// the next file with the same layout maps itself.
+package importmap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxUnzipLimit bounds the decompressed size of an uploaded workbook, so a
+// small zip cannot expand into gigabytes of XML.
+const xlsxUnzipLimit = 64 << 20
+
+var ErrUnsupportedFormat = errors.New("unsupported file format: use .csv or .xlsx")
+
+// Table is a spreadsheet's first sheet: the header row and the data rows
+// below it. Fully blank rows are dropped.
+type Table struct {
+	Headers []string
+	Rows    []Row
+}
+
+type Row struct {
+	// Line is the row's line (CSV) or row number (XLSX) in the file, the
+	// header being line 1, so errors point where the user looks.
+	Line  int
+	Cells []string
+}
+
+// Read picks the reader from the file name's extension.
+func Read(r io.Reader, filename string) (*Table, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return ReadCSV(r)
+	case ".xlsx":
+		return ReadXLSX(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// ReadCSV accepts comma- or semicolon-separated files (the latter is what
+// Excel exports in pt-BR), with or without a UTF-8 BOM.
+func ReadCSV(r io.Reader) (*Table, error) {
+	bufR := bufio.NewReader(r)
+
+	if head, _ := bufR.Peek(3); len(head) >= 3 && head[0] == 0xEF && head[1] == 0xBB && head[2] == 0xBF {
+		_, _ = bufR.Discard(3)
+	}
+
+	reader := csv.NewReader(bufR)
+	reader.Comma = detectDelimiter(bufR)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	t := &Table{Headers: trimCells(header)}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV row: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		t.appendRow(line, record)
+	}
+	return t, nil
+}
+
+func ReadXLSX(r io.Reader) (*Table, error) {
+	f, err := excelize.OpenReader(r, excelize.Options{UnzipSizeLimit: xlsxUnzipLimit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX: %w", err)
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows(f.GetSheetName(0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read XLSX rows: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("XLSX file is empty")
+	}
+
+	t := &Table{Headers: trimCells(rows[0])}
+	for i, row := range rows[1:] {
+		t.appendRow(i+2, row)
+	}
+	return t, nil
+}
+
+func (t *Table) appendRow(line int, cells []string) {
+	cells = trimCells(cells)
+	for _, c := range cells {
+		if c != "" {
+			t.Rows = append(t.Rows, Row{Line: line, Cells: cells})
+			return
+		}
+	}
+}
+
+func trimCells(cells []string) []string {
+	out := make([]string, len(cells))
+	for i, c := range cells {
+		out[i] = strings.TrimSpace(c)
+	}
+	return out
+}
+
+func detectDelimiter(bufR *bufio.Reader) rune {
+	peek, _ := bufR.Peek(4096)
+	if idx := bytes.IndexAny(peek, "\r\n"); idx >= 0 {
+		peek = peek[:idx]
+	}
+	if bytes.Count(peek, []byte(";")) > bytes.Count(peek, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
@@ -0,0 +1,71 @@
+package importmap
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestReadCSV(t *testing.T) {
+	csv := "\xef\xbb\xbfProduto;Valor\nPanela;\"10,00\"\n;\n\"Jogo de\ntaças\";20\n"
+	table, err := ReadCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(table.Headers) != 2 || table.Headers[0] != "Produto" {
+		t.Fatalf("unexpected headers %q", table.Headers)
+	}
+	if len(table.Rows) != 2 {
+		t.Fatalf("expected the blank row dropped, got %+v", table.Rows)
+	}
+	if table.Rows[0].Line != 2 || table.Rows[0].Cells[1] != "10,00" {
+		t.Errorf("unexpected first row %+v", table.Rows[0])
+	}
+	if table.Rows[1].Line != 4 {
+		t.Errorf("expected the second row to start on line 4, got %d", table.Rows[1].Line)
+	}
+
+	if _, err := ReadCSV(strings.NewReader("")); err == nil {
+		t.Fatal("expected error for empty file")
+	}
+}
+
+func TestReadXLSX(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := f.GetSheetName(0)
+	_ = f.SetSheetRow(sheet, "A1", &[]any{" Produto ", "Valor", "Link"})
+	_ = f.SetSheetRow(sheet, "A2", &[]any{"Panela", 199.9, "https://loja.example.com/panela"})
+	_ = f.SetSheetRow(sheet, "A4", &[]any{"Taças", "80,00"})
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("write xlsx: %v", err)
+	}
+
+	table, err := Read(bytes.NewReader(buf.Bytes()), "Lista.XLSX")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if table.Headers[0] != "Produto" || len(table.Rows) != 2 {
+		t.Fatalf("unexpected table %+v", table)
+	}
+	if r := table.Rows[0]; r.Line != 2 || r.Cells[1] != "199.9" {
+		t.Errorf("unexpected first row %+v", r)
+	}
+	if r := table.Rows[1]; r.Line != 4 || len(r.Cells) != 2 {
+		t.Errorf("expected the blank sheet row skipped, got %+v", r)
+	}
+
+	if _, err := ReadXLSX(strings.NewReader("not a workbook")); err == nil {
+		t.Fatal("expected error for invalid XLSX")
+	}
+}
+
+func TestReadUnsupportedFormat(t *testing.T) {
+	_, err := Read(strings.NewReader("x"), "lista.ods")
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package invitation
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/auth"
+	"github.com/ferjunior7/parasempre/backend/internal/httputil"
+	"github.com/ferjunior7/parasempre/backend/internal/middleware"
+)
+
+type Handler struct {
+	svc    *Service
+	jwtSvc *auth.JWTService
+}
+
+func NewHandler(svc *Service, jwtSvc *auth.JWTService) *Handler {
+	return &Handler{svc: svc, jwtSvc: jwtSvc}
+}
+
+// HandleLogin serves POST /api/auth/invitation. The session it returns is
+// limited to RSVP and gifts (auth.ScopeInvitation) and ends no later than
+// the invitation.
+func (h *Handler) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	var input LoginInput
+	if err := httputil.DecodeJSON(r, &input); err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid invitation payload", err))
+		return
+	}
+
+	result, err := h.svc.Login(r.Context(), input)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to sign in with invitation", err))
+		return
+	}
+
+	token, err := h.jwtSvc.GenerateScoped(result.UserID, result.URACF, result.Role, auth.ScopeInvitation, result.ExpiresAt)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.Internal("failed to generate token", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, auth.TokenResponse{
+		Token: token,
+		Role:  result.Role,
+		URACF: result.URACF,
+		Scope: auth.ScopeInvitation,
+	})
+}
+
+func (h *Handler) HandleList(w http.ResponseWriter, r *http.Request) {
+	var householdID int64
+	if v := r.URL.Query().Get("household_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			httputil.WriteError(w, r, apperror.Validation("invalid household_id"))
+			return
+		}
+		householdID = id
+	}
+
+	invitations, err := h.svc.List(r.Context(), householdID)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to list invitations", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, invitations)
+}
+
+func (h *Handler) HandleIssue(w http.ResponseWriter, r *http.Request) {
+	userRACF := middleware.UserRACFFromContext(r.Context())
+
+	var input IssueInput
+	if err := httputil.DecodeJSON(r, &input); err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid invitation payload", err))
+		return
+	}
+
+	inv, err := h.svc.Issue(r.Context(), input, userRACF)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to issue invitation", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusCreated, inv)
+}
+
+func (h *Handler) HandleRevoke(w http.ResponseWriter, r *http.Request) {
+	userRACF := middleware.UserRACFFromContext(r.Context())
+
+	id, err := httputil.PathID(r)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid invitation id", err))
+		return
+	}
+
+	inv, err := h.svc.Revoke(r.Context(), id, userRACF)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to revoke invitation", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, inv)
+}
+
+// HandlePrint serves GET /api/invitations/print, a PDF of invitation cards
+// for the households given as repeated household_id parameters, or for all
+// of them.
+func (h *Handler) HandlePrint(w http.ResponseWriter, r *http.Request) {
+	userRACF := middleware.UserRACFFromContext(r.Context())
+
+	var householdIDs []int64
+	for _, v := range r.URL.Query()["household_id"] {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			httputil.WriteError(w, r, apperror.Validation("invalid household_id"))
+			return
+		}
+		householdIDs = append(householdIDs, id)
+	}
+
+	pdf, err := h.svc.Print(r.Context(), householdIDs, userRACF)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to print invitations", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `attachment; filename="convites.pdf"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(pdf)
+}
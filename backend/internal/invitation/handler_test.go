@@ -0,0 +1,100 @@
+package invitation
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/auth"
+	"github.com/ferjunior7/parasempre/backend/internal/middleware"
+)
+
+func newTestMux() (*http.ServeMux, *Service, *auth.JWTService) {
+	svc, _, _, _ := newTestService()
+	jwtSvc := auth.NewJWTService("test-secret", 3*time.Hour)
+	h := NewHandler(svc, jwtSvc)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/invitation", h.HandleLogin)
+	mux.HandleFunc("GET /api/invitations", h.HandleList)
+	mux.HandleFunc("POST /api/invitations", h.HandleIssue)
+	mux.HandleFunc("POST /api/invitations/{id}/revoke", h.HandleRevoke)
+	mux.HandleFunc("GET /api/invitations/print", h.HandlePrint)
+	return mux, svc, jwtSvc
+}
+
+func serve(mux *http.ServeMux, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(middleware.WithClaims(req.Context(), &auth.Claims{UserID: 1, URACF: "GRM01", Role: "groom"}))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandlerIssueAndLogin(t *testing.T) {
+	mux, _, jwtSvc := newTestMux()
+
+	w := serve(mux, http.MethodPost, "/api/invitations", `{"household_id":1}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var inv Invitation
+	if err := json.NewDecoder(w.Body).Decode(&inv); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+
+	w = serve(mux, http.MethodPost, "/api/auth/invitation", `{"token":"`+tokenOf(t, &inv)+`"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp auth.TokenResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Scope != auth.ScopeInvitation || resp.URACF != "SOU01" {
+		t.Fatalf("unexpected token response %+v (%v)", resp, err)
+	}
+	claims, err := jwtSvc.Parse(resp.Token)
+	if err != nil || claims.Scope != auth.ScopeInvitation || claims.UserID != 100 {
+		t.Fatalf("expected a scoped session for user 100, got %+v (%v)", claims, err)
+	}
+
+	if w := serve(mux, http.MethodPost, "/api/auth/invitation", `{"token":"forged"}`); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a forged token, got %d", w.Code)
+	}
+}
+
+func TestHandlerRevokeAndList(t *testing.T) {
+	mux, _, _ := newTestMux()
+	serve(mux, http.MethodPost, "/api/invitations", `{"household_id":1}`)
+
+	if w := serve(mux, http.MethodPost, "/api/invitations/1/revoke", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := serve(mux, http.MethodGet, "/api/invitations?household_id=1", "")
+	var list []Invitation
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil || len(list) != 1 || list[0].RevokedAt == nil {
+		t.Fatalf("expected the revoked invitation listed, got %+v (%v)", list, err)
+	}
+
+	if w := serve(mux, http.MethodGet, "/api/invitations?household_id=x", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad household_id, got %d", w.Code)
+	}
+}
+
+func TestHandlerPrint(t *testing.T) {
+	mux, _, _ := newTestMux()
+
+	w := serve(mux, http.MethodGet, "/api/invitations/print?household_id=1", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/pdf" {
+		t.Fatalf("expected a PDF, got %q", ct)
+	}
+	if !strings.Contains(w.Header().Get("Content-Disposition"), "convites.pdf") || !bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF-")) {
+		t.Fatalf("unexpected download %q", w.Header().Get("Content-Disposition"))
+	}
+}
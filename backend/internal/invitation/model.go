@@ -0,0 +1,69 @@
+package invitation
+
+import "time"
+
+// Invitation is a signed link that signs a member of a household in
+// without a WhatsApp OTP, for RSVP and gifts only.
+type Invitation struct {
+	ID            int64  `json:"id"`
+	HouseholdID   int64  `json:"household_id"`
+	HouseholdName string `json:"household_name"`
+	// URL is the link to print or share; it carries the token.
+	URL        string     `json:"url"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	RevokedBy  *string    `json:"revoked_by"`
+	UseCount   int        `json:"use_count"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	nonce []byte
+}
+
+// Active reports whether the invitation can still sign someone in.
+func (i *Invitation) Active(now time.Time) bool {
+	return i.RevokedAt == nil && now.Before(i.ExpiresAt)
+}
+
+type IssueInput struct {
+	HouseholdID int64 `json:"household_id" validate:"required"`
+}
+
+type LoginInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// Account is the user an invitation signs in as: the head of the household
+// when they have one, otherwise its first guest.
+type Account struct {
+	UserID  int64
+	URACF   string
+	Role    string
+	GuestID int64
+}
+
+// LoginResult is who an invitation signed in and until when the session
+// may last.
+type LoginResult struct {
+	Account
+	HouseholdID int64
+	ExpiresAt   time.Time
+}
+
+// PrintTarget is a household to print an invitation card for, with its
+// newest active invitation, if any.
+type PrintTarget struct {
+	HouseholdID   int64
+	HouseholdName string
+	Members       []string
+	Invitation    *Invitation
+}
+
+// Card is one household's printed invitation.
+type Card struct {
+	HouseholdName string
+	Members       []string
+	URL           string
+	ExpiresAt     time.Time
+}
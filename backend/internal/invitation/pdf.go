@@ -0,0 +1,81 @@
+package invitation
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"rsc.io/qr"
+)
+
+// Cards are A6 (105×148 mm), one household per page, so they print on card
+// stock or four to an A4 sheet.
+const (
+	cardWidth  = 105.0
+	cardMargin = 10.0
+	qrSize     = 62.0
+)
+
+// brasilia is the wedding's time zone, used for the printed expiry date.
+var brasilia = time.FixedZone("BRT", -3*60*60)
+
+const cardInstructions = "Aponte a câmera do celular para o código acima para confirmar presença " +
+	"e ver a lista de presentes. Não é preciso código pelo WhatsApp."
+
+func renderCards(cards []Card) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A6", "")
+	pdf.SetMargins(cardMargin, cardMargin, cardMargin)
+	pdf.SetAutoPageBreak(false, cardMargin)
+	pdf.SetTitle("Convites", true)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	textWidth := cardWidth - 2*cardMargin
+
+	for _, card := range cards {
+		code, err := qr.Encode(card.URL, qr.M)
+		if err != nil {
+			return nil, err
+		}
+
+		pdf.AddPage()
+		pdf.SetFont("Helvetica", "B", 16)
+		pdf.MultiCell(textWidth, 7, tr(card.HouseholdName), "", "C", false)
+		if len(card.Members) > 0 {
+			pdf.SetFont("Helvetica", "", 9)
+			pdf.MultiCell(textWidth, 4.5, tr(strings.Join(card.Members, ", ")), "", "C", false)
+		}
+
+		pdf.Ln(3)
+		drawQR(pdf, code, (cardWidth-qrSize)/2, pdf.GetY())
+		pdf.SetY(pdf.GetY() + qrSize + 4)
+
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.MultiCell(textWidth, 4.5, tr(cardInstructions), "", "C", false)
+		pdf.Ln(2)
+		pdf.SetFont("Courier", "", 6)
+		pdf.MultiCell(textWidth, 3, card.URL, "", "C", false)
+		pdf.Ln(2)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.MultiCell(textWidth, 4, tr("Válido até "+card.ExpiresAt.In(brasilia).Format("02/01/2006")), "", "C", false)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawQR draws code as a qrSize square at (x, y), one filled rectangle per
+// dark module.
+func drawQR(pdf *fpdf.Fpdf, code *qr.Code, x, y float64) {
+	module := qrSize / float64(code.Size)
+	pdf.SetFillColor(0, 0, 0)
+	for row := 0; row < code.Size; row++ {
+		for col := 0; col < code.Size; col++ {
+			if code.Black(col, row) {
+				pdf.Rect(x+float64(col)*module, y+float64(row)*module, module, module, "F")
+			}
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package invitation
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	// List returns the invitations of householdID, or of every household
+	// when it is zero, newest first.
+	List(ctx context.Context, householdID int64) ([]Invitation, error)
+	GetByID(ctx context.Context, id int64) (*Invitation, error)
+	Create(ctx context.Context, householdID int64, nonce []byte, expiresAt time.Time, userRACF string) (*Invitation, error)
+	Revoke(ctx context.Context, id int64, userRACF string) (*Invitation, error)
+	RecordUse(ctx context.Context, id int64) error
+	FindAccount(ctx context.Context, householdID int64) (*Account, error)
+	// IssuerID is the user who issued the invitation.
+	IssuerID(ctx context.Context, id int64) (int64, error)
+	// ListPrintTargets returns householdIDs, or every household, leaving
+	// out households without guests.
+	ListPrintTargets(ctx context.Context, householdIDs []int64) ([]PrintTarget, error)
+}
@@ -0,0 +1,89 @@
+//go:build integration
+// +build integration
+
+package invitation
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/database"
+)
+
+func TestIntegrationInvitationLifecycle(t *testing.T) {
+	pool := database.NewTestPool(t)
+	tx := database.BeginTestTx(t, pool)
+	repo := NewPostgresRepository(pool).WithTx(tx)
+	ctx := context.Background()
+
+	var householdID, firstID, headID int64
+	if err := tx.QueryRow(ctx,
+		`INSERT INTO households (name, created_by, updated_by) VALUES ('Família Convintegra', 'TST01', 'TST01') RETURNING id`).Scan(&householdID); err != nil {
+		t.Fatalf("insert household failed: %v", err)
+	}
+	for _, g := range []struct {
+		name string
+		id   *int64
+	}{{"Ana", &firstID}, {"Beto", &headID}} {
+		if err := tx.QueryRow(ctx,
+			`INSERT INTO guests (first_name, last_name, relationship, family_group, created_by, updated_by)
+			 VALUES ($1, 'Convintegra', 'P', $2, 'TST01', 'TST01') RETURNING id`, g.name, householdID).Scan(g.id); err != nil {
+			t.Fatalf("insert guest failed: %v", err)
+		}
+	}
+	for i, gid := range []int64{firstID, headID} {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO users (guest_id, role, uracf) VALUES ($1, 'guest', $2)`, gid, []string{"CVA01", "CVB01"}[i]); err != nil {
+			t.Fatalf("insert user failed: %v", err)
+		}
+	}
+
+	account, err := repo.FindAccount(ctx, householdID)
+	if err != nil || account.GuestID != firstID {
+		t.Fatalf("expected the first guest without a head, got %+v (%v)", account, err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE households SET head_guest_id = $2 WHERE id = $1`, householdID, headID); err != nil {
+		t.Fatalf("set head failed: %v", err)
+	}
+	account, err = repo.FindAccount(ctx, householdID)
+	if err != nil || account.GuestID != headID || account.URACF != "CVB01" {
+		t.Fatalf("expected the head of household, got %+v (%v)", account, err)
+	}
+
+	nonce := bytes.Repeat([]byte{3}, nonceLen)
+	inv, err := repo.Create(ctx, householdID, nonce, time.Now().Add(24*time.Hour), "TST01")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if !bytes.Equal(inv.nonce, nonce) || inv.HouseholdName != "Família Convintegra" {
+		t.Fatalf("unexpected invitation %+v", inv)
+	}
+
+	targets, err := repo.ListPrintTargets(ctx, []int64{householdID})
+	if err != nil || len(targets) != 1 || targets[0].Invitation == nil || targets[0].Invitation.ID != inv.ID || len(targets[0].Members) != 2 {
+		t.Fatalf("expected the household with its active invitation, got %+v (%v)", targets, err)
+	}
+
+	if err := repo.RecordUse(ctx, inv.ID); err != nil {
+		t.Fatalf("RecordUse failed: %v", err)
+	}
+	revoked, err := repo.Revoke(ctx, inv.ID, "TST02")
+	if err != nil || revoked.RevokedAt == nil || revoked.UseCount != 1 || revoked.LastUsedAt == nil {
+		t.Fatalf("expected a used, revoked invitation, got %+v (%v)", revoked, err)
+	}
+	again, err := repo.Revoke(ctx, inv.ID, "TST03")
+	if err != nil || *again.RevokedBy != "TST02" {
+		t.Fatalf("expected the first revocation kept, got %+v (%v)", again, err)
+	}
+
+	targets, err = repo.ListPrintTargets(ctx, []int64{householdID})
+	if err != nil || len(targets) != 1 || targets[0].Invitation != nil {
+		t.Fatalf("expected no active invitation after revoking, got %+v (%v)", targets, err)
+	}
+
+	if _, err := repo.Create(ctx, -1, nonce, time.Now().Add(time.Hour), "TST01"); err == nil {
+		t.Fatal("expected an unknown household to be rejected")
+	}
+}
@@ -0,0 +1,189 @@
+package invitation
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/database"
+)
+
+const invitationColumns = `i.id, i.household_id, h.name, i.nonce, i.expires_at, i.revoked_at, i.revoked_by,
+	i.use_count, i.last_used_at, i.created_by, i.created_at`
+
+func scanInvitation(row pgx.Row) (Invitation, error) {
+	var i Invitation
+	err := row.Scan(&i.ID, &i.HouseholdID, &i.HouseholdName, &i.nonce, &i.ExpiresAt, &i.RevokedAt, &i.RevokedBy,
+		&i.UseCount, &i.LastUsedAt, &i.CreatedBy, &i.CreatedAt)
+	return i, err
+}
+
+type PostgresRepository struct {
+	db database.DBTX
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{db: pool}
+}
+
+func (r *PostgresRepository) WithTx(tx pgx.Tx) Repository {
+	return &PostgresRepository{db: tx}
+}
+
+func (r *PostgresRepository) List(ctx context.Context, householdID int64) ([]Invitation, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+invitationColumns+`
+		 FROM household_invitations i JOIN households h ON h.id = i.household_id
+		 WHERE $1 = 0 OR i.household_id = $1
+		 ORDER BY i.id DESC`, householdID)
+	if err != nil {
+		slog.ErrorContext(ctx, "invitation.repo list: query failed", "household_id", householdID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []Invitation{}
+	for rows.Next() {
+		i, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, i)
+	}
+	return invitations, rows.Err()
+}
+
+func (r *PostgresRepository) GetByID(ctx context.Context, id int64) (*Invitation, error) {
+	i, err := scanInvitation(r.db.QueryRow(ctx,
+		`SELECT `+invitationColumns+`
+		 FROM household_invitations i JOIN households h ON h.id = i.household_id
+		 WHERE i.id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.NotFound("invitation not found")
+		}
+		slog.ErrorContext(ctx, "invitation.repo get_by_id: query failed", "id", id, "error", err)
+		return nil, err
+	}
+	return &i, nil
+}
+
+func (r *PostgresRepository) Create(ctx context.Context, householdID int64, nonce []byte, expiresAt time.Time, userRACF string) (*Invitation, error) {
+	var id int64
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO household_invitations (household_id, nonce, expires_at, created_by)
+		 VALUES ($1, $2, $3, $4) RETURNING id`, householdID, nonce, expiresAt, userRACF).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return nil, apperror.NotFound("household not found")
+		}
+		slog.ErrorContext(ctx, "invitation.repo create: insert failed", "household_id", householdID, "error", err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "invitation.repo create: invitation created", "id", id, "household_id", householdID)
+	return r.GetByID(ctx, id)
+}
+
+// Revoke revokes the invitation unless it already was, keeping the first
+// revocation.
+func (r *PostgresRepository) Revoke(ctx context.Context, id int64, userRACF string) (*Invitation, error) {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE household_invitations SET revoked_at = now(), revoked_by = $2
+		 WHERE id = $1 AND revoked_at IS NULL`, id, userRACF)
+	if err != nil {
+		slog.ErrorContext(ctx, "invitation.repo revoke: update failed", "id", id, "error", err)
+		return nil, err
+	}
+	if tag.RowsAffected() > 0 {
+		slog.InfoContext(ctx, "invitation.repo revoke: invitation revoked", "id", id)
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *PostgresRepository) RecordUse(ctx context.Context, id int64) error {
+	if _, err := r.db.Exec(ctx,
+		`UPDATE household_invitations SET use_count = use_count + 1, last_used_at = now() WHERE id = $1`, id); err != nil {
+		slog.ErrorContext(ctx, "invitation.repo record_use: update failed", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresRepository) FindAccount(ctx context.Context, householdID int64) (*Account, error) {
+	var a Account
+	err := r.db.QueryRow(ctx,
+		`SELECT u.id, u.uracf, u.role, g.id
+		 FROM guests g
+		 JOIN users u ON u.guest_id = g.id
+		 JOIN households h ON h.id = g.family_group
+		 WHERE g.family_group = $1 AND u.role = 'guest'
+		 ORDER BY g.id = h.head_guest_id DESC NULLS LAST, g.id
+		 LIMIT 1`, householdID).Scan(&a.UserID, &a.URACF, &a.Role, &a.GuestID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.NotFound("household has no guest to sign in")
+		}
+		slog.ErrorContext(ctx, "invitation.repo find_account: query failed", "household_id", householdID, "error", err)
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *PostgresRepository) IssuerID(ctx context.Context, id int64) (int64, error) {
+	var userID int64
+	err := r.db.QueryRow(ctx,
+		`SELECT u.id FROM household_invitations i JOIN users u ON u.uracf = i.created_by WHERE i.id = $1`, id).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, apperror.NotFound("invitation issuer not found")
+		}
+		slog.ErrorContext(ctx, "invitation.repo issuer_id: query failed", "id", id, "error", err)
+		return 0, err
+	}
+	return userID, nil
+}
+
+func (r *PostgresRepository) ListPrintTargets(ctx context.Context, householdIDs []int64) ([]PrintTarget, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT h.id, h.name,
+		        ARRAY(SELECT g.first_name || ' ' || g.last_name FROM guests g WHERE g.family_group = h.id ORDER BY g.id),
+		        i.id, i.nonce, i.expires_at
+		 FROM households h
+		 LEFT JOIN LATERAL (
+		     SELECT id, nonce, expires_at FROM household_invitations
+		     WHERE household_id = h.id AND revoked_at IS NULL AND expires_at > now()
+		     ORDER BY id DESC LIMIT 1
+		 ) i ON true
+		 WHERE (COALESCE(cardinality($1::bigint[]), 0) = 0 OR h.id = ANY($1))
+		   AND EXISTS (SELECT 1 FROM guests g WHERE g.family_group = h.id)
+		 ORDER BY h.name, h.id`, householdIDs)
+	if err != nil {
+		slog.ErrorContext(ctx, "invitation.repo list_print_targets: query failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []PrintTarget
+	for rows.Next() {
+		var t PrintTarget
+		var invID *int64
+		var nonce []byte
+		var expiresAt *time.Time
+		if err := rows.Scan(&t.HouseholdID, &t.HouseholdName, &t.Members, &invID, &nonce, &expiresAt); err != nil {
+			return nil, err
+		}
+		if invID != nil {
+			t.Invitation = &Invitation{ID: *invID, HouseholdID: t.HouseholdID, HouseholdName: t.HouseholdName, ExpiresAt: *expiresAt, nonce: nonce}
+		}
+		targets = append(targets, t)
+	}
+	return targets, rows.Err()
+}
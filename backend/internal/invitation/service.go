@@ -0,0 +1,235 @@
+package invitation
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/audit"
+	"github.com/ferjunior7/parasempre/backend/internal/auth"
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
+	"github.com/ferjunior7/parasempre/backend/internal/validate"
+)
+
+// LinkPath is the frontend page an invitation link opens; the token follows it.
+const LinkPath = "/convite/"
+
+const (
+	auditInvitationIssued  = "invitation.issued"
+	auditInvitationRevoked = "invitation.revoked"
+	auditInvitationUsed    = "invitation.used"
+	// auditInvitationRejected records a refused use of an existing
+	// invitation; details.reason is one of the reject* values below.
+	auditInvitationRejected = "invitation.rejected"
+)
+
+const (
+	rejectWrongNonce = "wrong_nonce"
+	rejectRevoked    = "revoked"
+	rejectExpired    = "expired"
+	rejectNoAccount  = "no_account"
+)
+
+type Config struct {
+	// ExpiresAt is when new invitations stop working, after the wedding.
+	ExpiresAt time.Time
+	// BaseURL is the frontend origin the links point at.
+	BaseURL string
+}
+
+type Service struct {
+	repo   Repository
+	signer *Signer
+	cfg    Config
+	logins auth.LoginRecorder
+	audit  audit.Logger
+	now    func() time.Time
+}
+
+func NewService(repo Repository, signer *Signer, cfg Config, logins auth.LoginRecorder, audit audit.Logger) *Service {
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	return &Service{repo: repo, signer: signer, cfg: cfg, logins: logins, audit: audit, now: time.Now}
+}
+
+func (s *Service) link(i *Invitation) string {
+	return s.cfg.BaseURL + LinkPath + s.signer.Token(i.ID, i.nonce)
+}
+
+func (s *Service) List(ctx context.Context, householdID int64) ([]Invitation, error) {
+	invitations, err := s.repo.List(ctx, householdID)
+	if err != nil {
+		slog.ErrorContext(ctx, "invitation.service list: failed", "household_id", householdID, "error", err)
+		return nil, apperror.Internal("failed to list invitations", err)
+	}
+	for i := range invitations {
+		invitations[i].URL = s.link(&invitations[i])
+	}
+	return invitations, nil
+}
+
+// Issue creates a new invitation for a household. Earlier ones keep working
+// until revoked.
+func (s *Service) Issue(ctx context.Context, input IssueInput, userRACF string) (*Invitation, error) {
+	if err := validate.Struct(input); err != nil {
+		return nil, err
+	}
+	inv, err := s.issue(ctx, input.HouseholdID, userRACF)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("failed to issue invitation", err)
+	}
+	return inv, nil
+}
+
+func (s *Service) issue(ctx context.Context, householdID int64, userRACF string) (*Invitation, error) {
+	if !s.now().Before(s.cfg.ExpiresAt) {
+		return nil, apperror.Conflict("invitations have expired")
+	}
+	nonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+	inv, err := s.repo.Create(ctx, householdID, nonce, s.cfg.ExpiresAt, userRACF)
+	if err != nil {
+		return nil, err
+	}
+	inv.URL = s.link(inv)
+
+	audit.Record(ctx, s.audit, auditInvitationIssued, audit.Entity(audit.EntityHousehold, householdID, map[string]any{
+		"invitation_id": inv.ID,
+		"expires_at":    inv.ExpiresAt,
+	}))
+	slog.InfoContext(ctx, "invitation.service issue: invitation issued", "id", inv.ID, "household_id", householdID, "user_racf", userRACF)
+	return inv, nil
+}
+
+func (s *Service) Revoke(ctx context.Context, id int64, userRACF string) (*Invitation, error) {
+	inv, err := s.repo.Revoke(ctx, id, userRACF)
+	if err != nil {
+		return nil, apperror.WrapIfNotApp("failed to revoke invitation", err)
+	}
+	inv.URL = s.link(inv)
+
+	audit.Record(ctx, s.audit, auditInvitationRevoked, audit.Entity(audit.EntityHousehold, inv.HouseholdID, map[string]any{
+		"invitation_id": inv.ID,
+	}))
+	slog.InfoContext(ctx, "invitation.service revoke: invitation revoked", "id", id, "household_id", inv.HouseholdID, "user_racf", userRACF)
+	return inv, nil
+}
+
+// Login checks an invitation token and picks the household member it signs
+// in as. Every use is counted and audited as that member; refused uses of an
+// existing invitation are audited too (see rejectUse).
+func (s *Service) Login(ctx context.Context, input LoginInput) (*LoginResult, error) {
+	if err := validate.Struct(input); err != nil {
+		return nil, err
+	}
+
+	id, nonce, err := s.signer.Parse(strings.TrimSpace(input.Token))
+	if err != nil {
+		return nil, apperror.Unauthorized("invalid invitation")
+	}
+	inv, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, apperror.Unauthorized("invalid invitation")
+		}
+		return nil, apperror.WrapIfNotApp("failed to check invitation", err)
+	}
+
+	account, err := s.repo.FindAccount(ctx, inv.HouseholdID)
+	if err != nil && !isNotFound(err) {
+		return nil, apperror.WrapIfNotApp("failed to check invitation", err)
+	}
+	switch {
+	case subtle.ConstantTimeCompare(nonce, inv.nonce) != 1:
+		s.rejectUse(ctx, inv, account, rejectWrongNonce)
+		return nil, apperror.Unauthorized("invalid invitation")
+	case inv.RevokedAt != nil:
+		s.rejectUse(ctx, inv, account, rejectRevoked)
+		return nil, apperror.Unauthorized("invitation has been revoked")
+	case !inv.Active(s.now()):
+		s.rejectUse(ctx, inv, account, rejectExpired)
+		return nil, apperror.Unauthorized("invitation has expired")
+	case account == nil:
+		s.rejectUse(ctx, inv, nil, rejectNoAccount)
+		return nil, apperror.Unauthorized("invitation has no guest to sign in")
+	}
+
+	if err := s.repo.RecordUse(ctx, inv.ID); err != nil {
+		return nil, apperror.Internal("failed to record invitation use", err)
+	}
+
+	ctx = reqctx.WithUserID(ctx, account.UserID)
+	s.logins.RecordLogin(ctx, account.UserID)
+	audit.Record(ctx, s.audit, auditInvitationUsed, audit.Entity(audit.EntityHousehold, inv.HouseholdID, map[string]any{
+		"invitation_id": inv.ID,
+		"guest_id":      account.GuestID,
+	}))
+	slog.InfoContext(ctx, "invitation.service login: invitation used", "id", inv.ID, "household_id", inv.HouseholdID, "user_id", account.UserID)
+
+	return &LoginResult{Account: *account, HouseholdID: inv.HouseholdID, ExpiresAt: inv.ExpiresAt}, nil
+}
+
+// rejectUse audits a refused use against the invitation's household, as the
+// member it would have signed in or, for a household without one, as whoever
+// issued it. Tokens that fail the signature name no invitation and are only
+// logged.
+func (s *Service) rejectUse(ctx context.Context, inv *Invitation, account *Account, reason string) {
+	details := map[string]any{"invitation_id": inv.ID, "reason": reason}
+	var userID int64
+	if account != nil {
+		userID = account.UserID
+		details["guest_id"] = account.GuestID
+	} else {
+		id, err := s.repo.IssuerID(ctx, inv.ID)
+		if err != nil {
+			slog.WarnContext(ctx, "invitation.service login: no user to audit rejection", "id", inv.ID, "reason", reason, "error", err)
+		}
+		userID = id
+	}
+
+	audit.RecordAs(ctx, s.audit, userID, auditInvitationRejected, audit.Entity(audit.EntityHousehold, inv.HouseholdID, details))
+	slog.WarnContext(ctx, "invitation.service login: invitation rejected", "id", inv.ID, "household_id", inv.HouseholdID, "reason", reason)
+}
+
+// Print renders a PDF with one invitation card per household (householdIDs,
+// or all of them), issuing an invitation for households without an active one.
+func (s *Service) Print(ctx context.Context, householdIDs []int64, userRACF string) ([]byte, error) {
+	targets, err := s.repo.ListPrintTargets(ctx, householdIDs)
+	if err != nil {
+		slog.ErrorContext(ctx, "invitation.service print: list failed", "error", err)
+		return nil, apperror.Internal("failed to list households", err)
+	}
+	if len(targets) == 0 {
+		return nil, apperror.NotFound("no households with guests to print")
+	}
+
+	cards := make([]Card, 0, len(targets))
+	for _, t := range targets {
+		inv := t.Invitation
+		if inv == nil {
+			if inv, err = s.issue(ctx, t.HouseholdID, userRACF); err != nil {
+				return nil, apperror.WrapIfNotApp("failed to issue invitation", err)
+			}
+		}
+		cards = append(cards, Card{HouseholdName: t.HouseholdName, Members: t.Members, URL: s.link(inv), ExpiresAt: inv.ExpiresAt})
+	}
+
+	pdf, err := renderCards(cards)
+	if err != nil {
+		slog.ErrorContext(ctx, "invitation.service print: render failed", "error", err)
+		return nil, apperror.Internal("failed to render invitations", err)
+	}
+	slog.InfoContext(ctx, "invitation.service print: invitations rendered", "households", len(cards), "user_racf", userRACF)
+	return pdf, nil
+}
+
+func isNotFound(err error) bool {
+	var ae *apperror.AppError
+	return errors.As(err, &ae) && ae.Code == http.StatusNotFound
+}
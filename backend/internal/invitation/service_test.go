@@ -0,0 +1,310 @@
+package invitation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
+)
+
+var testNow = time.Date(2026, 11, 20, 15, 0, 0, 0, time.UTC)
+
+// memRepository keeps invitations in memory for a single household 1
+// ("Família Souza") whose head is guest 10, user 100.
+type memRepository struct {
+	invitations map[int64]*Invitation
+	accounts    map[int64]*Account
+	members     map[int64][]string
+	nextID      int64
+}
+
+func newMemRepository() *memRepository {
+	return &memRepository{
+		invitations: map[int64]*Invitation{},
+		accounts:    map[int64]*Account{1: {UserID: 100, URACF: "SOU01", Role: "guest", GuestID: 10}},
+		members:     map[int64][]string{1: {"Ana Souza", "João Souza"}},
+		nextID:      1,
+	}
+}
+
+func (m *memRepository) List(ctx context.Context, householdID int64) ([]Invitation, error) {
+	out := []Invitation{}
+	for id := m.nextID - 1; id > 0; id-- {
+		if inv, ok := m.invitations[id]; ok && (householdID == 0 || inv.HouseholdID == householdID) {
+			out = append(out, *inv)
+		}
+	}
+	return out, nil
+}
+
+func (m *memRepository) GetByID(ctx context.Context, id int64) (*Invitation, error) {
+	inv, ok := m.invitations[id]
+	if !ok {
+		return nil, apperror.NotFound("invitation not found")
+	}
+	c := *inv
+	return &c, nil
+}
+
+func (m *memRepository) Create(ctx context.Context, householdID int64, nonce []byte, expiresAt time.Time, userRACF string) (*Invitation, error) {
+	if _, ok := m.members[householdID]; !ok {
+		return nil, apperror.NotFound("household not found")
+	}
+	inv := &Invitation{ID: m.nextID, HouseholdID: householdID, HouseholdName: "Família Souza", ExpiresAt: expiresAt, CreatedBy: userRACF, nonce: nonce}
+	m.invitations[inv.ID] = inv
+	m.nextID++
+	return m.GetByID(ctx, inv.ID)
+}
+
+func (m *memRepository) Revoke(ctx context.Context, id int64, userRACF string) (*Invitation, error) {
+	inv, ok := m.invitations[id]
+	if !ok {
+		return nil, apperror.NotFound("invitation not found")
+	}
+	if inv.RevokedAt == nil {
+		now := testNow
+		inv.RevokedAt, inv.RevokedBy = &now, &userRACF
+	}
+	return m.GetByID(ctx, id)
+}
+
+func (m *memRepository) RecordUse(ctx context.Context, id int64) error {
+	m.invitations[id].UseCount++
+	return nil
+}
+
+func (m *memRepository) FindAccount(ctx context.Context, householdID int64) (*Account, error) {
+	a, ok := m.accounts[householdID]
+	if !ok {
+		return nil, apperror.NotFound("household has no guest to sign in")
+	}
+	return a, nil
+}
+
+func (m *memRepository) IssuerID(ctx context.Context, id int64) (int64, error) {
+	if _, ok := m.invitations[id]; !ok {
+		return 0, apperror.NotFound("invitation issuer not found")
+	}
+	return 1, nil
+}
+
+func (m *memRepository) ListPrintTargets(ctx context.Context, householdIDs []int64) ([]PrintTarget, error) {
+	var targets []PrintTarget
+	for _, id := range []int64{1} {
+		if len(householdIDs) > 0 && householdIDs[0] != id {
+			continue
+		}
+		t := PrintTarget{HouseholdID: id, HouseholdName: "Família Souza", Members: m.members[id]}
+		for iid := m.nextID - 1; iid > 0; iid-- {
+			if inv := m.invitations[iid]; inv != nil && inv.HouseholdID == id && inv.Active(testNow) {
+				c := *inv
+				t.Invitation = &c
+				break
+			}
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+type auditCall struct {
+	userID  int64
+	action  string
+	details map[string]any
+}
+
+type mockAudit struct {
+	calls []auditCall
+}
+
+func (m *mockAudit) LogAction(ctx context.Context, userID int64, action string, details map[string]any) error {
+	m.calls = append(m.calls, auditCall{userID: userID, action: action, details: details})
+	return nil
+}
+
+type mockLogins struct {
+	userIDs []int64
+}
+
+func (m *mockLogins) RecordLogin(ctx context.Context, userID int64) {
+	m.userIDs = append(m.userIDs, userID)
+}
+
+func newTestService() (*Service, *memRepository, *mockAudit, *mockLogins) {
+	repo, aud, logins := newMemRepository(), &mockAudit{}, &mockLogins{}
+	svc := NewService(repo, NewSigner([]byte("invitation-signing-key-for-tests")), Config{
+		ExpiresAt: time.Date(2027, 1, 10, 3, 0, 0, 0, time.UTC),
+		BaseURL:   "https://parasempre.example/",
+	}, logins, aud)
+	svc.now = func() time.Time { return testNow }
+	return svc, repo, aud, logins
+}
+
+func coupleCtx() context.Context {
+	return reqctx.WithUserID(context.Background(), 1)
+}
+
+func assertAppError(t *testing.T, err error, code int, msg string) {
+	t.Helper()
+	var appErr *apperror.AppError
+	if !errors.As(err, &appErr) || appErr.Code != code || appErr.Message != msg {
+		t.Fatalf("expected %d %q, got %v", code, msg, err)
+	}
+}
+
+func tokenOf(t *testing.T, inv *Invitation) string {
+	t.Helper()
+	const prefix = "https://parasempre.example" + LinkPath
+	if len(inv.URL) <= len(prefix) || inv.URL[:len(prefix)] != prefix {
+		t.Fatalf("unexpected invitation URL %q", inv.URL)
+	}
+	return inv.URL[len(prefix):]
+}
+
+func TestServiceIssueAndLogin(t *testing.T) {
+	svc, repo, aud, logins := newTestService()
+
+	inv, err := svc.Issue(coupleCtx(), IssueInput{HouseholdID: 1}, "GRM01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(aud.calls) != 1 || aud.calls[0].action != auditInvitationIssued || aud.calls[0].details["entity_id"] != int64(1) {
+		t.Fatalf("unexpected audit entries %+v", aud.calls)
+	}
+
+	result, err := svc.Login(context.Background(), LoginInput{Token: tokenOf(t, inv)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.UserID != 100 || result.HouseholdID != 1 || !result.ExpiresAt.Equal(inv.ExpiresAt) {
+		t.Fatalf("unexpected login result %+v", result)
+	}
+	if repo.invitations[inv.ID].UseCount != 1 || len(logins.userIDs) != 1 {
+		t.Fatalf("expected the use counted and the login recorded, got %d / %v", repo.invitations[inv.ID].UseCount, logins.userIDs)
+	}
+	used := aud.calls[len(aud.calls)-1]
+	if used.action != auditInvitationUsed || used.userID != 100 || used.details["guest_id"] != int64(10) {
+		t.Fatalf("expected the use audited as the guest, got %+v", used)
+	}
+}
+
+func TestServiceIssueUnknownHousehold(t *testing.T) {
+	svc, _, _, _ := newTestService()
+	_, err := svc.Issue(coupleCtx(), IssueInput{HouseholdID: 9}, "GRM01")
+	assertAppError(t, err, http.StatusNotFound, "household not found")
+}
+
+func TestServiceIssueAfterExpiry(t *testing.T) {
+	svc, _, _, _ := newTestService()
+	svc.now = func() time.Time { return svc.cfg.ExpiresAt }
+	_, err := svc.Issue(coupleCtx(), IssueInput{HouseholdID: 1}, "GRM01")
+	assertAppError(t, err, http.StatusConflict, "invitations have expired")
+}
+
+func TestServiceLoginRejected(t *testing.T) {
+	svc, repo, aud, _ := newTestService()
+	inv, _ := svc.Issue(coupleCtx(), IssueInput{HouseholdID: 1}, "GRM01")
+	token := tokenOf(t, inv)
+
+	// A token for an id that was never issued, signed with the right key.
+	unknown := svc.signer.Token(99, bytes.Repeat([]byte{1}, nonceLen))
+	assertAppError(t, loginErr(svc, unknown), http.StatusUnauthorized, "invalid invitation")
+	assertAppError(t, loginErr(svc, "garbage"), http.StatusUnauthorized, "invalid invitation")
+
+	// Same id, stale nonce: a reissued row must not accept an older link.
+	stale := svc.signer.Token(inv.ID, bytes.Repeat([]byte{1}, nonceLen))
+	assertAppError(t, loginErr(svc, stale), http.StatusUnauthorized, "invalid invitation")
+
+	svc.now = func() time.Time { return inv.ExpiresAt.Add(time.Second) }
+	assertAppError(t, loginErr(svc, token), http.StatusUnauthorized, "invitation has expired")
+	svc.now = func() time.Time { return testNow }
+
+	delete(repo.accounts, 1)
+	assertAppError(t, loginErr(svc, token), http.StatusUnauthorized, "invitation has no guest to sign in")
+
+	if _, err := svc.Revoke(coupleCtx(), inv.ID, "BRD01"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertAppError(t, loginErr(svc, token), http.StatusUnauthorized, "invitation has been revoked")
+
+	if repo.invitations[inv.ID].UseCount != 0 {
+		t.Fatal("expected rejected logins not to count as uses")
+	}
+
+	// Forged and unknown tokens name no invitation; the rest are audited
+	// against the household, as its member or, once it has none, the issuer.
+	var rejected []auditCall
+	for _, c := range aud.calls {
+		if c.action == auditInvitationRejected {
+			rejected = append(rejected, c)
+		}
+	}
+	want := []struct {
+		reason string
+		userID int64
+	}{{rejectWrongNonce, 100}, {rejectExpired, 100}, {rejectNoAccount, 1}, {rejectRevoked, 1}}
+	if len(rejected) != len(want) {
+		t.Fatalf("expected %d rejections audited, got %+v", len(want), rejected)
+	}
+	for i, w := range want {
+		r := rejected[i]
+		if r.details["reason"] != w.reason || r.userID != w.userID || r.details["entity_id"] != int64(1) || r.details["invitation_id"] != inv.ID {
+			t.Fatalf("rejection %d: expected %s as user %d, got %+v", i, w.reason, w.userID, r)
+		}
+	}
+}
+
+func loginErr(svc *Service, token string) error {
+	_, err := svc.Login(context.Background(), LoginInput{Token: token})
+	return err
+}
+
+func TestServiceRevoke(t *testing.T) {
+	svc, _, aud, _ := newTestService()
+	inv, _ := svc.Issue(coupleCtx(), IssueInput{HouseholdID: 1}, "GRM01")
+
+	revoked, err := svc.Revoke(coupleCtx(), inv.ID, "BRD01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if revoked.RevokedBy == nil || *revoked.RevokedBy != "BRD01" || revoked.Active(testNow) {
+		t.Fatalf("expected the invitation revoked by BRD01, got %+v", revoked)
+	}
+	if last := aud.calls[len(aud.calls)-1]; last.action != auditInvitationRevoked || last.details["invitation_id"] != inv.ID {
+		t.Fatalf("unexpected audit entry %+v", last)
+	}
+
+	_, err = svc.Revoke(coupleCtx(), 99, "BRD01")
+	assertAppError(t, err, http.StatusNotFound, "invitation not found")
+}
+
+func TestServicePrintIssuesMissingInvitations(t *testing.T) {
+	svc, repo, _, _ := newTestService()
+
+	pdf, err := svc.Print(coupleCtx(), nil, "GRM01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-")) {
+		t.Fatalf("expected a PDF, got %q", pdf[:min(len(pdf), 16)])
+	}
+	if len(repo.invitations) != 1 {
+		t.Fatalf("expected one invitation issued for the household, got %d", len(repo.invitations))
+	}
+
+	// Reprinting reuses the active invitation instead of issuing another.
+	if _, err := svc.Print(coupleCtx(), []int64{1}, "GRM01"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.invitations) != 1 {
+		t.Fatalf("expected the active invitation reused, got %d", len(repo.invitations))
+	}
+
+	_, err = svc.Print(coupleCtx(), []int64{9}, "GRM01")
+	assertAppError(t, err, http.StatusNotFound, "no households with guests to print")
+}
@@ -0,0 +1,66 @@
+package invitation
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	nonceLen = 16
+	macLen   = 16
+	tokenLen = 8 + nonceLen + macLen
+)
+
+var errInvalidToken = errors.New("invalid invitation token")
+
+// Signer turns an invitation's id and nonce into the token carried by its
+// link and back. The token is the id, the nonce and an HMAC-SHA256 of both
+// truncated to 16 bytes, base64url-encoded in 54 characters so the QR code
+// stays small enough to scan from paper.
+type Signer struct {
+	key []byte
+}
+
+func NewSigner(key []byte) *Signer {
+	return &Signer{key: key}
+}
+
+func (s *Signer) Token(id int64, nonce []byte) string {
+	buf := make([]byte, 8, tokenLen)
+	binary.BigEndian.PutUint64(buf, uint64(id))
+	buf = append(buf, nonce...)
+	buf = append(buf, s.mac(buf)...)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// Parse checks the token's signature and returns the invitation id and
+// nonce it carries, which must still match the stored invitation.
+func (s *Signer) Parse(token string) (int64, []byte, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(buf) != tokenLen {
+		return 0, nil, errInvalidToken
+	}
+	payload, mac := buf[:8+nonceLen], buf[8+nonceLen:]
+	if !hmac.Equal(mac, s.mac(payload)) {
+		return 0, nil, errInvalidToken
+	}
+	return int64(binary.BigEndian.Uint64(payload[:8])), payload[8:], nil
+}
+
+func (s *Signer) mac(payload []byte) []byte {
+	h := hmac.New(sha256.New, s.key)
+	h.Write(payload)
+	return h.Sum(nil)[:macLen]
+}
+
+func newNonce() ([]byte, error) {
+	nonce := make([]byte, nonceLen)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
@@ -0,0 +1,43 @@
+package invitation
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func TestSignerRoundTrip(t *testing.T) {
+	s := NewSigner([]byte("invitation-signing-key-for-tests"))
+	nonce := bytes.Repeat([]byte{7}, nonceLen)
+
+	token := s.Token(42, nonce)
+	if len(token) != 54 {
+		t.Fatalf("expected a 54-character token, got %d", len(token))
+	}
+	id, got, err := s.Parse(token)
+	if err != nil || id != 42 || !bytes.Equal(got, nonce) {
+		t.Fatalf("expected id 42 and the nonce back, got %d %x (%v)", id, got, err)
+	}
+}
+
+func TestSignerRejectsForgedTokens(t *testing.T) {
+	s := NewSigner([]byte("invitation-signing-key-for-tests"))
+	nonce := bytes.Repeat([]byte{7}, nonceLen)
+	token := s.Token(42, nonce)
+
+	raw, _ := base64.RawURLEncoding.DecodeString(token)
+	raw[7] = 43 // another invitation id, same signature
+	tampered := base64.RawURLEncoding.EncodeToString(raw)
+
+	for name, tok := range map[string]string{
+		"tampered id":   tampered,
+		"other key":     NewSigner([]byte("another-signing-key-for-the-tests")).Token(42, nonce),
+		"truncated":     token[:40],
+		"not base64url": token[:53] + "*",
+		"empty":         "",
+	} {
+		if _, _, err := s.Parse(tok); err == nil {
+			t.Fatalf("%s: expected the token to be rejected", name)
+		}
+	}
+}
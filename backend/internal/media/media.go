@@ -0,0 +1,13 @@
+// Package media holds what the packages serving uploaded photos and videos
+// (gift messages, the guestbook, the reception wall) share.
+package media
+
+// SignedURL looks key up in a batch of URLs signed by Storage.SignURLs; nil
+// when there is no object or signing it failed.
+func SignedURL(key *string, urls map[string]string) *string {
+	if key == nil || urls[*key] == "" {
+		return nil
+	}
+	u := urls[*key]
+	return &u
+}
@@ -0,0 +1,18 @@
+package media
+
+import "testing"
+
+func TestSignedURL(t *testing.T) {
+	key, missing := "a.jpg", "b.jpg"
+	urls := map[string]string{key: "https://cdn.example/a.jpg?sig=1", missing: ""}
+
+	if got := SignedURL(&key, urls); got == nil || *got != urls[key] {
+		t.Fatalf("expected the signed URL, got %v", got)
+	}
+	if got := SignedURL(&missing, urls); got != nil {
+		t.Fatalf("expected nil for a failed signature, got %q", *got)
+	}
+	if got := SignedURL(nil, urls); got != nil {
+		t.Fatalf("expected nil without a key, got %q", *got)
+	}
+}
@@ -8,13 +8,26 @@ import (
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
 	"github.com/ferjunior7/parasempre/backend/internal/auth"
 	"github.com/ferjunior7/parasempre/backend/internal/httputil"
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
 )
 
 type contextKey string
 
 const claimsKey contextKey = "claims"
 
+// RequireAuth admits full sessions only. Routes that scoped sessions (e.g.
+// invitation links) may also reach use RequireScopedAuth.
 func RequireAuth(jwtSvc *auth.JWTService) func(http.Handler) http.Handler {
+	return RequireScopedAuth(jwtSvc)
+}
+
+// RequireScopedAuth admits full sessions and sessions limited to one of scopes.
+func RequireScopedAuth(jwtSvc *auth.JWTService, scopes ...string) func(http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(scopes))
+	for _, s := range scopes {
+		allowed[s] = true
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			header := r.Header.Get("Authorization")
@@ -34,9 +47,12 @@ func RequireAuth(jwtSvc *auth.JWTService) func(http.Handler) http.Handler {
 				httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid token", err))
 				return
 			}
+			if claims.Scope != "" && !allowed[claims.Scope] {
+				httputil.WriteError(w, r, apperror.Forbidden("this session cannot access this resource"))
+				return
+			}
 
-			ctx := context.WithValue(r.Context(), claimsKey, claims)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
 		})
 	}
 }
@@ -83,6 +99,9 @@ func ClaimsFromContext(ctx context.Context) *auth.Claims {
 }
 
 func WithClaims(ctx context.Context, claims *auth.Claims) context.Context {
+	if claims != nil {
+		ctx = reqctx.WithUserID(ctx, claims.UserID)
+	}
 	return context.WithValue(ctx, claimsKey, claims)
 }
 
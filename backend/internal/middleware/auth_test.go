@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/ferjunior7/parasempre/backend/internal/auth"
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
 )
 
 func newTestJWT() *auth.JWTService {
@@ -26,6 +27,9 @@ func TestRequireAuthValid(t *testing.T) {
 		if claims.URACF != "USR01" {
 			t.Fatalf("expected URACF USR01, got %q", claims.URACF)
 		}
+		if got := reqctx.UserID(r.Context()); got != 1 {
+			t.Fatalf("expected reqctx user id 1, got %d", got)
+		}
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -86,6 +90,35 @@ func TestRequireAuthInvalidToken(t *testing.T) {
 	}
 }
 
+func TestRequireScopedAuth(t *testing.T) {
+	jwtSvc := newTestJWT()
+	scoped, _ := jwtSvc.GenerateScoped(1, "USR01", "guest", auth.ScopeInvitation, time.Time{})
+	full, _ := jwtSvc.Generate(1, "USR01", "guest")
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	cases := []struct {
+		name  string
+		mw    func(http.Handler) http.Handler
+		token string
+		want  int
+	}{
+		{"scoped session on a scoped route", RequireScopedAuth(jwtSvc, auth.ScopeInvitation), scoped, http.StatusOK},
+		{"full session on a scoped route", RequireScopedAuth(jwtSvc, auth.ScopeInvitation), full, http.StatusOK},
+		{"scoped session on a full route", RequireAuth(jwtSvc), scoped, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+tc.token)
+		w := httptest.NewRecorder()
+		tc.mw(ok).ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d", tc.name, tc.want, w.Code)
+		}
+	}
+}
+
 func TestRequireRoleAllowed(t *testing.T) {
 	jwtSvc := newTestJWT()
 	token, _ := jwtSvc.Generate(1, "GRM01", "groom")
@@ -0,0 +1,260 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// maxCachedBody keeps unusually large responses out of the cache; they are
+// still served, just regenerated every time.
+const maxCachedBody = 1 << 20
+
+type ResponseCacheConfig struct {
+	// TTL is how long a stored response is served without running the
+	// handler. Zero always runs it, but validators still work.
+	TTL time.Duration
+	// MaxAge is the Cache-Control max-age sent to browsers and proxies.
+	MaxAge     time.Duration
+	MaxEntries int
+}
+
+// ResponseCache stores the 200 responses of public GET routes in process,
+// keyed by path and query, and answers conditional requests (If-None-Match,
+// If-Modified-Since) with 304. Invalidate marks everything stale; mutation
+// routes call it through InvalidateOnSuccess.
+//
+// The cache is per replica: a mutation on one replica leaves the others
+// serving the old response for at most TTL.
+type ResponseCache struct {
+	cfg   ResponseCacheConfig
+	now   func() time.Time
+	group singleflight.Group
+
+	mu         sync.Mutex
+	entries    map[string]*cachedResponse
+	generation uint64
+}
+
+type cachedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+
+	// Only set on stored responses.
+	etag         string
+	lastModified time.Time
+	expires      time.Time
+}
+
+func NewResponseCache(cfg ResponseCacheConfig) *ResponseCache {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 1000
+	}
+	return &ResponseCache{cfg: cfg, now: time.Now, entries: make(map[string]*cachedResponse)}
+}
+
+// Invalidate marks every stored response stale, so the next request for each
+// runs the handler again. ETags and Last-Modified dates are kept: when the
+// new body is the same, clients still get their 304.
+func (c *ResponseCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for _, e := range c.entries {
+		e.expires = time.Time{}
+	}
+}
+
+func (c *ResponseCache) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := r.URL.Path + "?" + r.URL.Query().Encode()
+
+		e := c.fresh(key)
+		if e == nil {
+			// Concurrent misses for the same key share one handler run. It
+			// must not die with the request that happened to start it.
+			v, _, _ := c.group.Do(key, func() (any, error) {
+				return c.fill(next, r.WithContext(context.WithoutCancel(r.Context())), key), nil
+			})
+			e = v.(*cachedResponse)
+		}
+		c.write(w, r, e)
+	})
+}
+
+// InvalidateOnSuccess wraps mutation routes: once one answers with a status
+// below 400 the cache is invalidated, before the response reaches the client.
+func (c *ResponseCache) InvalidateOnSuccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&invalidatingWriter{ResponseWriter: w, cache: c}, r)
+	})
+}
+
+func (c *ResponseCache) fresh(key string) *cachedResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok && c.now().Before(e.expires) {
+		return e
+	}
+	return nil
+}
+
+// fill runs the handler and stores a 200 response. A response generated
+// while Invalidate ran may predate the mutation, so it is stored stale.
+func (c *ResponseCache) fill(next http.Handler, r *http.Request, key string) *cachedResponse {
+	c.mu.Lock()
+	gen := c.generation
+	c.mu.Unlock()
+
+	rec := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+	next.ServeHTTP(rec, r)
+	e := &cachedResponse{status: rec.status, header: rec.header, body: rec.body.Bytes()}
+	if e.status != http.StatusOK || len(e.body) > maxCachedBody {
+		return e
+	}
+
+	sum := sha256.Sum256(e.body)
+	e.etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	e.lastModified = now.Truncate(time.Second)
+	if prev, ok := c.entries[key]; ok && prev.etag == e.etag {
+		e.lastModified = prev.lastModified
+	}
+	if gen == c.generation {
+		e.expires = now.Add(c.cfg.TTL)
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.cfg.MaxEntries {
+		c.evict(now)
+	}
+	c.entries[key] = e
+	return e
+}
+
+// evict drops expired entries, or the one closest to expiring when none are.
+// Callers hold mu.
+func (c *ResponseCache) evict(now time.Time) {
+	var oldest string
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+			continue
+		}
+		if oldest == "" || e.expires.Before(c.entries[oldest].expires) {
+			oldest = k
+		}
+	}
+	if len(c.entries) >= c.cfg.MaxEntries {
+		delete(c.entries, oldest)
+	}
+}
+
+func (c *ResponseCache) write(w http.ResponseWriter, r *http.Request, e *cachedResponse) {
+	h := w.Header()
+	for k, v := range e.header {
+		h[k] = v
+	}
+	if e.etag != "" {
+		h.Set("ETag", e.etag)
+		h.Set("Last-Modified", e.lastModified.UTC().Format(http.TimeFormat))
+		h.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(c.cfg.MaxAge.Seconds())))
+		if notModified(r, e) {
+			h.Del("Content-Type")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	h.Set("Content-Length", strconv.Itoa(len(e.body)))
+	w.WriteHeader(e.status)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(e.body)
+	}
+}
+
+// notModified applies RFC 9110 precedence: If-Modified-Since is only looked
+// at when the request has no If-None-Match.
+func notModified(r *http.Request, e *cachedResponse) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == e.etag {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !e.lastModified.After(since)
+}
+
+// responseRecorder buffers a response so it can be stored and then served
+// to every request that waited on it.
+type responseRecorder struct {
+	header http.Header
+	status int
+	wrote  bool
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) Header() http.Header {
+	return rr.header
+}
+
+func (rr *responseRecorder) WriteHeader(code int) {
+	if !rr.wrote {
+		rr.status = code
+		rr.wrote = true
+	}
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.wrote = true
+	return rr.body.Write(b)
+}
+
+type invalidatingWriter struct {
+	http.ResponseWriter
+	cache *ResponseCache
+	wrote bool
+}
+
+func (iw *invalidatingWriter) WriteHeader(code int) {
+	if !iw.wrote {
+		iw.wrote = true
+		if code < http.StatusBadRequest {
+			iw.cache.Invalidate()
+		}
+	}
+	iw.ResponseWriter.WriteHeader(code)
+}
+
+func (iw *invalidatingWriter) Write(b []byte) (int, error) {
+	if !iw.wrote {
+		iw.WriteHeader(http.StatusOK)
+	}
+	return iw.ResponseWriter.Write(b)
+}
+
+func (iw *invalidatingWriter) Unwrap() http.ResponseWriter {
+	return iw.ResponseWriter
+}
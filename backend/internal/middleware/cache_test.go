@@ -0,0 +1,223 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingHandler struct {
+	calls atomic.Int32
+	body  atomic.Value
+}
+
+func newCountingHandler(body string) *countingHandler {
+	h := &countingHandler{}
+	h.body.Store(body)
+	return h
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.calls.Add(1)
+	if r.URL.Query().Get("fail") != "" {
+		http.Error(w, "boom", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(h.body.Load().(string)))
+}
+
+func serve(h http.Handler, method, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestResponseCacheServesStoredResponse(t *testing.T) {
+	c := NewResponseCache(ResponseCacheConfig{TTL: time.Minute, MaxAge: 10 * time.Second})
+	next := newCountingHandler(`{"data":[]}`)
+	h := c.Middleware(next)
+
+	first := serve(h, http.MethodGet, "/api/gifts?b=2&a=1", nil)
+	second := serve(h, http.MethodGet, "/api/gifts?a=1&b=2", nil)
+	if next.calls.Load() != 1 {
+		t.Fatalf("expected one handler run for the same query, got %d", next.calls.Load())
+	}
+	if second.Body.String() != `{"data":[]}` || second.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected cached response %q %v", second.Body.String(), second.Header())
+	}
+	if first.Header().Get("ETag") == "" || first.Header().Get("ETag") != second.Header().Get("ETag") {
+		t.Fatalf("expected a stable ETag, got %q and %q", first.Header().Get("ETag"), second.Header().Get("ETag"))
+	}
+	if got := first.Header().Get("Cache-Control"); got != "public, max-age=10" {
+		t.Fatalf("unexpected Cache-Control %q", got)
+	}
+
+	serve(h, http.MethodGet, "/api/gifts?a=2", nil)
+	if next.calls.Load() != 2 {
+		t.Fatalf("expected another query to run the handler, got %d", next.calls.Load())
+	}
+}
+
+func TestResponseCacheConditionalRequests(t *testing.T) {
+	c := NewResponseCache(ResponseCacheConfig{TTL: time.Minute})
+	h := c.Middleware(newCountingHandler(`{"id":1}`))
+
+	first := serve(h, http.MethodGet, "/api/gifts/1", nil)
+	etag, lastModified := first.Header().Get("ETag"), first.Header().Get("Last-Modified")
+
+	w := serve(h, http.MethodGet, "/api/gifts/1", http.Header{"If-None-Match": {`"other", ` + etag}})
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("expected 304 for a matching ETag, got %d %q", w.Code, w.Body.String())
+	}
+	if w.Header().Get("ETag") != etag {
+		t.Fatal("expected the ETag on the 304")
+	}
+
+	w = serve(h, http.MethodGet, "/api/gifts/1", http.Header{"If-Modified-Since": {lastModified}})
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for If-Modified-Since, got %d", w.Code)
+	}
+
+	// If-None-Match wins over If-Modified-Since.
+	w = serve(h, http.MethodGet, "/api/gifts/1", http.Header{"If-None-Match": {`"other"`}, "If-Modified-Since": {lastModified}})
+	if w.Code != http.StatusOK || w.Body.String() != `{"id":1}` {
+		t.Fatalf("expected 200 for a stale ETag, got %d", w.Code)
+	}
+
+	w = serve(h, http.MethodHead, "/api/gifts/1", nil)
+	if w.Code != http.StatusOK || w.Body.Len() != 0 || w.Header().Get("Content-Length") != "8" {
+		t.Fatalf("unexpected HEAD response %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+}
+
+func TestResponseCacheInvalidate(t *testing.T) {
+	c := NewResponseCache(ResponseCacheConfig{TTL: time.Hour})
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	next := newCountingHandler(`{"v":1}`)
+	h := c.Middleware(next)
+
+	first := serve(h, http.MethodGet, "/api/gifts", nil)
+
+	// An unchanged body keeps its ETag and Last-Modified after invalidation.
+	now = now.Add(time.Minute)
+	c.Invalidate()
+	w := serve(h, http.MethodGet, "/api/gifts", http.Header{"If-None-Match": {first.Header().Get("ETag")}})
+	if next.calls.Load() != 2 || w.Code != http.StatusNotModified {
+		t.Fatalf("expected a rerun answered with 304, got %d runs and %d", next.calls.Load(), w.Code)
+	}
+	if w.Header().Get("Last-Modified") != first.Header().Get("Last-Modified") {
+		t.Fatalf("expected Last-Modified to be kept, got %q", w.Header().Get("Last-Modified"))
+	}
+
+	next.body.Store(`{"v":2}`)
+	now = now.Add(time.Minute)
+	c.Invalidate()
+	w = serve(h, http.MethodGet, "/api/gifts", http.Header{"If-None-Match": {first.Header().Get("ETag")}})
+	if w.Code != http.StatusOK || w.Body.String() != `{"v":2}` {
+		t.Fatalf("expected the new body, got %d %q", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Last-Modified") != now.Format(http.TimeFormat) {
+		t.Fatalf("expected Last-Modified to move, got %q", w.Header().Get("Last-Modified"))
+	}
+}
+
+func TestResponseCacheZeroTTLStillValidates(t *testing.T) {
+	c := NewResponseCache(ResponseCacheConfig{})
+	next := newCountingHandler(`{}`)
+	h := c.Middleware(next)
+
+	first := serve(h, http.MethodGet, "/api/gifts", nil)
+	w := serve(h, http.MethodGet, "/api/gifts", http.Header{"If-None-Match": {first.Header().Get("ETag")}})
+	if next.calls.Load() != 2 || w.Code != http.StatusNotModified {
+		t.Fatalf("expected every request to run the handler and still get 304, got %d runs and %d", next.calls.Load(), w.Code)
+	}
+}
+
+func TestResponseCacheSkipsErrors(t *testing.T) {
+	c := NewResponseCache(ResponseCacheConfig{TTL: time.Minute})
+	next := newCountingHandler(`{}`)
+	h := c.Middleware(next)
+
+	for range 2 {
+		w := serve(h, http.MethodGet, "/api/gifts?fail=1", nil)
+		if w.Code != http.StatusInternalServerError || w.Header().Get("ETag") != "" || w.Header().Get("Cache-Control") != "" {
+			t.Fatalf("expected an uncached 500, got %d %v", w.Code, w.Header())
+		}
+	}
+	if next.calls.Load() != 2 {
+		t.Fatalf("expected errors to rerun the handler, got %d", next.calls.Load())
+	}
+}
+
+func TestResponseCacheSharesConcurrentMisses(t *testing.T) {
+	c := NewResponseCache(ResponseCacheConfig{TTL: time.Minute})
+	release := make(chan struct{})
+	var calls atomic.Int32
+	h := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if w := serve(h, http.MethodGet, "/api/gifts", nil); w.Body.String() != "ok" {
+				t.Errorf("unexpected body %q", w.Body.String())
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Fatalf("expected concurrent misses to share one run, got %d", calls.Load())
+	}
+}
+
+func TestResponseCacheEvictsWhenFull(t *testing.T) {
+	c := NewResponseCache(ResponseCacheConfig{TTL: time.Minute, MaxEntries: 2})
+	h := c.Middleware(newCountingHandler(`{}`))
+	for _, target := range []string{"/a", "/b", "/c", "/d"} {
+		serve(h, http.MethodGet, target, nil)
+	}
+	if len(c.entries) != 2 {
+		t.Fatalf("expected at most 2 entries, got %d", len(c.entries))
+	}
+}
+
+func TestInvalidateOnSuccess(t *testing.T) {
+	c := NewResponseCache(ResponseCacheConfig{TTL: time.Hour})
+	next := newCountingHandler(`{}`)
+	cached := c.Middleware(next)
+	mutate := func(status int) http.Handler {
+		return c.InvalidateOnSuccess(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+	}
+
+	serve(cached, http.MethodGet, "/api/gifts", nil)
+	serve(mutate(http.StatusBadRequest), http.MethodPost, "/api/gifts", nil)
+	serve(mutate(http.StatusOK), http.MethodGet, "/api/gifts/import/urls/1", nil)
+	serve(cached, http.MethodGet, "/api/gifts", nil)
+	if next.calls.Load() != 1 {
+		t.Fatalf("expected failed mutations and reads to keep the cache, got %d runs", next.calls.Load())
+	}
+
+	serve(mutate(http.StatusNoContent), http.MethodDelete, "/api/gifts/1", nil)
+	serve(cached, http.MethodGet, "/api/gifts", nil)
+	if next.calls.Load() != 2 {
+		t.Fatalf("expected a successful mutation to invalidate, got %d runs", next.calls.Load())
+	}
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
+)
+
+// ParseTrustedProxies accepts CIDRs ("172.16.0.0/12") or bare addresses
+// ("10.0.0.5", treated as a single-host prefix).
+func ParseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if strings.Contains(v, "/") {
+			p, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", v, err)
+			}
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", v, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// ClientIP resolves the real client address and stores it in the context
+// (see reqctx.ClientIP). Forwarding headers are only read when the direct
+// peer is a trusted proxy, and X-Forwarded-For / Forwarded are walked from
+// the right, skipping trusted hops, so a client cannot spoof its address by
+// prepending entries that Traefik will happily pass along.
+func ClientIP(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := resolveClientIP(r, trusted)
+			next.ServeHTTP(w, r.WithContext(reqctx.WithClientIP(r.Context(), ip)))
+		})
+	}
+}
+
+func resolveClientIP(r *http.Request, trusted []netip.Prefix) string {
+	peer := remoteHost(r.RemoteAddr)
+	peerAddr, err := netip.ParseAddr(peer)
+	if err != nil || !isTrusted(peerAddr, trusted) {
+		return peer
+	}
+
+	if hops := forwardedForHops(r.Header.Values("X-Forwarded-For")); len(hops) > 0 {
+		if ip, ok := firstUntrusted(hops, trusted); ok {
+			return ip
+		}
+	}
+	if hops := forwardedHops(r.Header.Values("Forwarded")); len(hops) > 0 {
+		if ip, ok := firstUntrusted(hops, trusted); ok {
+			return ip
+		}
+	}
+	if v := strings.TrimSpace(r.Header.Get("X-Real-IP")); v != "" {
+		if addr, err := netip.ParseAddr(v); err == nil {
+			return addr.Unmap().String()
+		}
+	}
+	return peer
+}
+
+// firstUntrusted walks hops right-to-left and returns the first address not
+// belonging to a trusted proxy. Unparseable entries stop the walk: anything
+// left of them is client-controlled.
+func firstUntrusted(hops []string, trusted []netip.Prefix) (string, bool) {
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(hops[i])
+		if err != nil {
+			return "", false
+		}
+		addr = addr.Unmap()
+		if !isTrusted(addr, trusted) {
+			return addr.String(), true
+		}
+	}
+	return "", false
+}
+
+func forwardedForHops(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				hops = append(hops, stripPort(part))
+			}
+		}
+	}
+	return hops
+}
+
+// forwardedHops extracts the for= parameters of an RFC 7239 Forwarded header,
+// e.g. `for=192.0.2.60;proto=https, for="[2001:db8::1]:4711"`.
+func forwardedHops(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, element := range strings.Split(v, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				k, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok || !strings.EqualFold(k, "for") {
+					continue
+				}
+				hops = append(hops, stripPort(strings.Trim(val, `"`)))
+			}
+		}
+	}
+	return hops
+}
+
+func stripPort(hostport string) string {
+	if strings.HasPrefix(hostport, "[") {
+		if end := strings.Index(hostport, "]"); end > 0 {
+			return hostport[1:end]
+		}
+		return hostport
+	}
+	if strings.Count(hostport, ":") == 1 {
+		host, _, _ := strings.Cut(hostport, ":")
+		return host
+	}
+	return hostport
+}
+
+func remoteHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, p := range trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	got, err := ParseTrustedProxies([]string{"172.16.0.0/12", " 10.0.0.5 ", "", "::1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 prefixes, got %v", got)
+	}
+	if got[1].String() != "10.0.0.5/32" {
+		t.Errorf("expected bare IP to become /32, got %s", got[1])
+	}
+
+	if _, err := ParseTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Fatal("expected error for invalid entry")
+	}
+}
+
+func TestClientIPResolution(t *testing.T) {
+	trusted, _ := ParseTrustedProxies([]string{"172.16.0.0/12", "10.0.0.0/8"})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{
+			name:       "untrusted peer ignores headers",
+			remoteAddr: "198.51.100.9:5555",
+			headers:    map[string]string{"X-Forwarded-For": "1.1.1.1", "X-Real-IP": "2.2.2.2"},
+			want:       "198.51.100.9",
+		},
+		{
+			name:       "trusted peer with XFF",
+			remoteAddr: "172.18.0.2:5555",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.7"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "spoofed leftmost XFF entry is skipped",
+			remoteAddr: "172.18.0.2:5555",
+			headers:    map[string]string{"X-Forwarded-For": "6.6.6.6, 203.0.113.7"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "chained trusted hops are skipped",
+			remoteAddr: "172.18.0.2:5555",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.7, 10.0.0.4"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Forwarded header",
+			remoteAddr: "172.18.0.2:5555",
+			headers:    map[string]string{"Forwarded": `for="[2001:db8::1]:4711";proto=https, for=10.0.0.4`},
+			want:       "2001:db8::1",
+		},
+		{
+			name:       "X-Real-IP fallback",
+			remoteAddr: "172.18.0.2:5555",
+			headers:    map[string]string{"X-Real-IP": "203.0.113.8"},
+			want:       "203.0.113.8",
+		},
+		{
+			name:       "garbage XFF falls back to peer",
+			remoteAddr: "172.18.0.2:5555",
+			headers:    map[string]string{"X-Forwarded-For": "unknown"},
+			want:       "172.18.0.2",
+		},
+		{
+			name:       "XFF entry with port",
+			remoteAddr: "172.18.0.2:5555",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.7:1234"},
+			want:       "203.0.113.7",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			handler := ClientIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = reqctx.ClientIP(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIPKeyUsesResolvedClientIP(t *testing.T) {
+	trusted, _ := ParseTrustedProxies([]string{"172.16.0.0/12"})
+	rl := NewRateLimiter(1, 1)
+	defer rl.Close()
+
+	handler := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), ClientIP(trusted), rl.Middleware())
+
+	for _, client := range []string{"203.0.113.7", "203.0.113.8"} {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = "172.18.0.2:5555"
+		req.Header.Set("X-Forwarded-For", client)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("client %s behind the same proxy: expected 200, got %d", client, w.Code)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"regexp"
 	"runtime/debug"
+	"slices"
 	"time"
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
@@ -23,9 +24,10 @@ func Logger(next http.Handler) http.Handler {
 		start := time.Now()
 		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
 		next.ServeHTTP(sw, r)
-		slog.Info("request",
+		slog.InfoContext(r.Context(), "request",
 			"method", r.Method,
 			"path", maskPhonePath(r.URL.Path),
+			"client_ip", IPKey(r),
 			"status", sw.status,
 			"duration", time.Since(start),
 		)
@@ -46,11 +48,17 @@ func (sw *statusWriter) WriteHeader(code int) {
 	sw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, so
+// streaming handlers can flush and lift the write deadline.
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
 func Recovery(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				slog.Error("panic recovered",
+				slog.ErrorContext(r.Context(), "panic recovered",
 					"error", fmt.Sprint(err),
 					"stack", string(debug.Stack()),
 				)
@@ -82,14 +90,26 @@ func SecurityHeaders(appEnv string) func(http.Handler) http.Handler {
 	}
 }
 
-func CORS(origin string) func(http.Handler) http.Handler {
+// Headers of the tus resumable upload protocol (giftmessage.ResumableUploads)
+// that browsers must be allowed to send and read.
+const (
+	tusRequestHeaders  = "Tus-Resumable, Upload-Length, Upload-Offset, Upload-Metadata"
+	tusResponseHeaders = "Location, Tus-Resumable, Tus-Version, Tus-Extension, Tus-Max-Size, Upload-Offset, Upload-Length, Upload-Expires"
+)
+
+// CORS answers OPTIONS requests itself as preflights. The exception is a
+// plain (non-preflight) OPTIONS to one of discoveryPaths, which reaches next:
+// tus clients send those to discover the server's capabilities.
+func CORS(origin string, discoveryPaths ...string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, user-racf")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, user-racf, Last-Event-ID, "+RequestIDHeader+", "+tusRequestHeaders)
+			w.Header().Set("Access-Control-Expose-Headers", RequestIDHeader+", "+tusResponseHeaders)
 
-			if r.Method == http.MethodOptions {
+			preflight := r.Header.Get("Access-Control-Request-Method") != "" || !slices.Contains(discoveryPaths, r.URL.Path)
+			if r.Method == http.MethodOptions && preflight {
 				w.WriteHeader(http.StatusNoContent)
 				return
 			}
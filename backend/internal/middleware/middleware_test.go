@@ -6,6 +6,8 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
 )
 
 func TestLogger(t *testing.T) {
@@ -75,6 +77,27 @@ func TestCORS(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("discovery request", func(t *testing.T) {
+		discovery := CORS("http://localhost:3000", "/tus")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		req := httptest.NewRequest(http.MethodOptions, "/tus", nil)
+		w := httptest.NewRecorder()
+		discovery.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected the discovery request to reach the handler, got %d", w.Code)
+		}
+
+		req = httptest.NewRequest(http.MethodOptions, "/tus", nil)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		w = httptest.NewRecorder()
+		discovery.ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("expected a preflight to be answered by CORS, got %d", w.Code)
+		}
+	})
 }
 
 func TestChain(t *testing.T) {
@@ -153,6 +176,21 @@ func TestStatusWriterDefaultStatus(t *testing.T) {
 	}
 }
 
+func TestStatusWriterAllowsFlush(t *testing.T) {
+	handler := Logger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("flush through Logger: %v", err)
+		}
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !w.Flushed {
+		t.Fatal("expected the recorder to be flushed")
+	}
+}
+
 func TestSecurityHeaders(t *testing.T) {
 	noop := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
 
@@ -187,3 +225,72 @@ func TestSecurityHeaders(t *testing.T) {
 		}
 	})
 }
+
+func TestRequestIDGenerated(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = reqctx.RequestID(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if seen == "" {
+		t.Fatal("expected request id in context")
+	}
+	if got := w.Header().Get(RequestIDHeader); got != seen {
+		t.Fatalf("expected response header %q, got %q", seen, got)
+	}
+}
+
+func TestRequestIDHonorsInbound(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = reqctx.RequestID(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "traefik-abc-123")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if seen != "traefik-abc-123" {
+		t.Fatalf("expected inbound id to be kept, got %q", seen)
+	}
+}
+
+func TestRequestIDRejectsMalformedInbound(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = reqctx.RequestID(r.Context())
+	}))
+
+	for _, bad := range []string{"short", "has spaces in it", strings.Repeat("a", 200), "<script>alert(1)</script>"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RequestIDHeader, bad)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if seen == bad || seen == "" {
+			t.Errorf("expected %q to be replaced by a generated id, got %q", bad, seen)
+		}
+	}
+}
+
+func TestRecoveryIncludesRequestID(t *testing.T) {
+	handler := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}), RequestID, Recovery)
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set(RequestIDHeader, "req-panic-001")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var body map[string]string
+	json.NewDecoder(w.Body).Decode(&body)
+	if body["request_id"] != "req-panic-001" {
+		t.Fatalf("expected request_id in error body, got %v", body)
+	}
+}
@@ -1,7 +1,8 @@
 package middleware
 
 import (
-	"net"
+	"context"
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
@@ -10,14 +11,77 @@ import (
 	"golang.org/x/time/rate"
 
 	"github.com/ferjunior7/parasempre/backend/internal/httputil"
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
 )
 
+// RateLimitBackend stores the token buckets behind a RateLimiter. The
+// in-memory backend is per-process; PostgresRateLimitBackend shares buckets
+// across replicas and survives restarts.
+type RateLimitBackend interface {
+	Allow(ctx context.Context, key string) (bool, error)
+	Close()
+}
+
+type RateLimiter struct {
+	backend RateLimitBackend
+}
+
+// KeyFunc extracts the rate-limit bucket key from a request. Default is IP
+// (see IPKey); the purchase route uses a user-id key so guests on the same
+// network (Wi-Fi de família/escritório) don't share a bucket.
+type KeyFunc func(r *http.Request) string
+
+// NewRateLimiter returns a limiter backed by process-local token buckets.
+func NewRateLimiter(r rate.Limit, burst int) *RateLimiter {
+	return NewRateLimiterWithBackend(NewMemoryRateLimitBackend(r, burst))
+}
+
+func NewRateLimiterWithBackend(backend RateLimitBackend) *RateLimiter {
+	return &RateLimiter{backend: backend}
+}
+
+// IPKey keys by the client IP resolved by the ClientIP middleware, falling
+// back to the direct peer when that middleware is not in the chain.
+func IPKey(r *http.Request) string {
+	if ip := reqctx.ClientIP(r.Context()); ip != "" {
+		return ip
+	}
+	return remoteHost(r.RemoteAddr)
+}
+
+func (rl *RateLimiter) Close() {
+	rl.backend.Close()
+}
+
+func (rl *RateLimiter) Middleware() func(http.Handler) http.Handler {
+	return rl.MiddlewareWithKey(IPKey)
+}
+
+func (rl *RateLimiter) MiddlewareWithKey(keyFn KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allowed, err := rl.backend.Allow(r.Context(), keyFn(r))
+			if err != nil {
+				// Fail open: a limiter outage must not take purchases and
+				// webhooks down with it.
+				slog.ErrorContext(r.Context(), "ratelimit: backend error, allowing request", "error", err)
+				allowed = true
+			}
+			if !allowed {
+				httputil.WriteError(w, r, apperror.TooManyRequests("too many requests, wait 1 minute and try again"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 type bucket struct {
 	limiter  *rate.Limiter
 	lastSeen time.Time
 }
 
-type RateLimiter struct {
+type MemoryRateLimitBackend struct {
 	mu       sync.Mutex
 	buckets  map[string]*bucket
 	rate     rate.Limit
@@ -26,80 +90,55 @@ type RateLimiter struct {
 	stop     chan struct{}
 }
 
-// KeyFunc extracts the rate-limit bucket key from a request. Default is IP
-// (see IPKey); the purchase route uses a user-id key so guests on the same
-// network (Wi-Fi de família/escritório) don't share a bucket.
-type KeyFunc func(r *http.Request) string
-
-func NewRateLimiter(r rate.Limit, burst int) *RateLimiter {
-	rl := &RateLimiter{
+func NewMemoryRateLimitBackend(r rate.Limit, burst int) *MemoryRateLimitBackend {
+	b := &MemoryRateLimitBackend{
 		buckets: make(map[string]*bucket),
 		rate:    r,
 		burst:   burst,
 		stop:    make(chan struct{}),
 	}
 
-	go rl.cleanup()
-	return rl
+	go b.cleanup()
+	return b
+}
+
+func (b *MemoryRateLimitBackend) Allow(_ context.Context, key string) (bool, error) {
+	return b.getLimiter(key).Allow(), nil
 }
 
-func (rl *RateLimiter) getLimiter(key string) *rate.Limiter {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+func (b *MemoryRateLimitBackend) getLimiter(key string) *rate.Limiter {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 
-	v, exists := rl.buckets[key]
+	v, exists := b.buckets[key]
 	if !exists {
-		v = &bucket{limiter: rate.NewLimiter(rl.rate, rl.burst)}
-		rl.buckets[key] = v
+		v = &bucket{limiter: rate.NewLimiter(b.rate, b.burst)}
+		b.buckets[key] = v
 	}
 	v.lastSeen = time.Now()
 	return v.limiter
 }
 
-func IPKey(r *http.Request) string {
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return r.RemoteAddr
-	}
-	return ip
-}
-
-func (rl *RateLimiter) cleanup() {
+func (b *MemoryRateLimitBackend) cleanup() {
 	ticker := time.NewTicker(10 * time.Minute)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			rl.mu.Lock()
-			for key, v := range rl.buckets {
+			b.mu.Lock()
+			for key, v := range b.buckets {
 				if time.Since(v.lastSeen) > 10*time.Minute {
-					delete(rl.buckets, key)
+					delete(b.buckets, key)
 				}
 			}
-			rl.mu.Unlock()
-		case <-rl.stop:
+			b.mu.Unlock()
+		case <-b.stop:
 			return
 		}
 	}
 }
 
-func (rl *RateLimiter) Close() {
-	rl.stopOnce.Do(func() { close(rl.stop) })
-}
-
-func (rl *RateLimiter) Middleware() func(http.Handler) http.Handler {
-	return rl.MiddlewareWithKey(IPKey)
-}
-
-func (rl *RateLimiter) MiddlewareWithKey(keyFn KeyFunc) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if !rl.getLimiter(keyFn(r)).Allow() {
-				httputil.WriteError(w, r, apperror.TooManyRequests("too many requests, wait 1 minute and try again"))
-				return
-			}
-			next.ServeHTTP(w, r)
-		})
-	}
+func (b *MemoryRateLimitBackend) Close() {
+	b.stopOnce.Do(func() { close(b.stop) })
 }
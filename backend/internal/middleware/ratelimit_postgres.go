@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/time/rate"
+
+	"github.com/ferjunior7/parasempre/backend/internal/database"
+)
+
+const rateLimitIdleTTL = 10 * time.Minute
+
+// PostgresRateLimitBackend keeps token buckets in the rate_limits table so
+// every replica behind Traefik draws from the same bucket. Each Allow is a
+// single atomic upsert: the row is refilled from updated_at using the
+// database clock and only written when a token is actually taken, so
+// concurrent requests cannot overspend it.
+type PostgresRateLimitBackend struct {
+	db       database.DBTX
+	name     string
+	rate     rate.Limit
+	burst    int
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewPostgresRateLimitBackend namespaces buckets by name, since the same key
+// (e.g. "user:42") is used by several limiters at once.
+func NewPostgresRateLimitBackend(db database.DBTX, name string, r rate.Limit, burst int) *PostgresRateLimitBackend {
+	b := &PostgresRateLimitBackend{
+		db:    db,
+		name:  name,
+		rate:  r,
+		burst: burst,
+		stop:  make(chan struct{}),
+	}
+
+	go b.cleanup()
+	return b
+}
+
+func (b *PostgresRateLimitBackend) Allow(ctx context.Context, key string) (bool, error) {
+	var tokens float64
+	err := b.db.QueryRow(ctx, `
+		INSERT INTO rate_limits (limiter, key, tokens, updated_at)
+		VALUES ($1, $2, $3::float8 - 1, now())
+		ON CONFLICT (limiter, key) DO UPDATE SET
+			tokens = LEAST($3::float8, rate_limits.tokens + EXTRACT(EPOCH FROM now() - rate_limits.updated_at) * $4::float8) - 1,
+			updated_at = now()
+		WHERE LEAST($3::float8, rate_limits.tokens + EXTRACT(EPOCH FROM now() - rate_limits.updated_at) * $4::float8) >= 1
+		RETURNING tokens`,
+		b.name, key, float64(b.burst), float64(b.rate),
+	).Scan(&tokens)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (b *PostgresRateLimitBackend) cleanup() {
+	ticker := time.NewTicker(rateLimitIdleTTL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			_, err := b.db.Exec(ctx,
+				`DELETE FROM rate_limits WHERE limiter = $1 AND updated_at < now() - make_interval(secs => $2)`,
+				b.name, rateLimitIdleTTL.Seconds())
+			cancel()
+			if err != nil {
+				slog.Error("ratelimit.postgres cleanup: delete failed", "limiter", b.name, "error", err)
+			}
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+func (b *PostgresRateLimitBackend) Close() {
+	b.stopOnce.Do(func() { close(b.stop) })
+}
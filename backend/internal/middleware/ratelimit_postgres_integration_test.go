@@ -0,0 +1,69 @@
+//go:build integration
+// +build integration
+
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/ferjunior7/parasempre/backend/internal/database"
+)
+
+func TestIntegrationPostgresRateLimitBackend(t *testing.T) {
+	pool := database.NewTestPool(t)
+	database.CleanTable(t, pool, "rate_limits")
+	ctx := context.Background()
+
+	b := NewPostgresRateLimitBackend(pool, "test", rate.Every(time.Hour), 2)
+	defer b.Close()
+
+	for i := 0; i < 2; i++ {
+		ok, err := b.Allow(ctx, "user:1")
+		if err != nil {
+			t.Fatalf("Allow #%d failed: %v", i+1, err)
+		}
+		if !ok {
+			t.Fatalf("Allow #%d: expected allowed within burst", i+1)
+		}
+	}
+
+	ok, err := b.Allow(ctx, "user:1")
+	if err != nil {
+		t.Fatalf("Allow failed: %v", err)
+	}
+	if ok {
+		t.Fatal("expected third request to be denied")
+	}
+
+	ok, err = b.Allow(ctx, "user:2")
+	if err != nil || !ok {
+		t.Fatalf("expected other key to be allowed, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestIntegrationPostgresRateLimitBackendSharedAcrossInstances(t *testing.T) {
+	pool := database.NewTestPool(t)
+	database.CleanTable(t, pool, "rate_limits")
+	ctx := context.Background()
+
+	replicaA := NewPostgresRateLimitBackend(pool, "purchase", rate.Every(time.Hour), 1)
+	defer replicaA.Close()
+	replicaB := NewPostgresRateLimitBackend(pool, "purchase", rate.Every(time.Hour), 1)
+	defer replicaB.Close()
+	otherLimiter := NewPostgresRateLimitBackend(pool, "message", rate.Every(time.Hour), 1)
+	defer otherLimiter.Close()
+
+	if ok, err := replicaA.Allow(ctx, "user:1"); err != nil || !ok {
+		t.Fatalf("replica A: expected allowed, got ok=%v err=%v", ok, err)
+	}
+	if ok, err := replicaB.Allow(ctx, "user:1"); err != nil || ok {
+		t.Fatalf("replica B: expected shared bucket to be empty, got ok=%v err=%v", ok, err)
+	}
+	if ok, err := otherLimiter.Allow(ctx, "user:1"); err != nil || !ok {
+		t.Fatalf("other limiter: expected separate namespace, got ok=%v err=%v", ok, err)
+	}
+}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -74,3 +76,53 @@ func TestRateLimiterPerIP(t *testing.T) {
 		t.Fatalf("IP2 first: expected 200, got %d (different IP should not be limited)", w2.Code)
 	}
 }
+
+type stubBackend struct {
+	allow bool
+	err   error
+	keys  []string
+}
+
+func (s *stubBackend) Allow(_ context.Context, key string) (bool, error) {
+	s.keys = append(s.keys, key)
+	return s.allow, s.err
+}
+
+func (s *stubBackend) Close() {}
+
+func TestRateLimiterCustomBackendDenies(t *testing.T) {
+	backend := &stubBackend{allow: false}
+	rl := NewRateLimiterWithBackend(backend)
+
+	handler := rl.MiddlewareWithKey(func(r *http.Request) string { return "user:7" })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("should not reach handler")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if len(backend.keys) != 1 || backend.keys[0] != "user:7" {
+		t.Fatalf("expected key user:7 to reach backend, got %v", backend.keys)
+	}
+}
+
+func TestRateLimiterFailsOpenOnBackendError(t *testing.T) {
+	rl := NewRateLimiterWithBackend(&stubBackend{err: errors.New("db down")})
+
+	handler := rl.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.RemoteAddr = "1.2.3.4:12345"
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 when backend errors, got %d", w.Code)
+	}
+}
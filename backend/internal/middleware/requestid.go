@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// Inbound IDs (from Traefik or the frontend) are echoed back into logs and
+// JSON bodies, so only accept a conservative charset and length.
+var requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._:-]{8,128}$`)
+
+// RequestID honors a well-formed X-Request-ID from the caller or generates a
+// new one, stores it in the context (see reqctx.RequestID) and echoes it on
+// the response. Must run before Recovery and Logger so both can see it.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !requestIDRegex.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(reqctx.WithRequestID(r.Context(), id)))
+	})
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,259 @@
+// Package pagination implements the two ways list endpoints page through
+// results: by page number (LIMIT/OFFSET, what the admin tables use) and by
+// opaque cursor (keyset), which does not skip or repeat rows when rows are
+// inserted or change position between requests.
+//
+// A listing describes its ordering as an Order whose last key is unique (the
+// id), so every row has a distinct position. A cursor carries the ordering
+// keys of a row as text; the next page is the rows after it in that order
+// and the previous page the rows before it.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// maxCursorLen bounds the cursor query parameter before decoding.
+const maxCursorLen = 2048
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Key is one term of an ordering. Expr must never be NULL; Type is the SQL
+// type the cursor's text value is cast back to.
+type Key struct {
+	Expr string
+	Type string
+	Desc bool
+}
+
+// Order is a listing's ordering. Name tells cursors of different orderings
+// apart, so a cursor taken under one sort is rejected under another.
+type Order struct {
+	Name string
+	Keys []Key
+}
+
+// Cursor points at a row: the rows of the next page come after it, those of
+// a Before cursor come before it.
+type Cursor struct {
+	Order  string   `json:"o"`
+	Values []string `json:"v"`
+	Before bool     `json:"b,omitempty"`
+}
+
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// Decode reads a cursor produced by Encode.
+func Decode(s string) (*Cursor, error) {
+	if len(s) > maxCursorLen {
+		return nil, ErrInvalidCursor
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Order == "" || len(c.Values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// Request is how a client asked to page. With a Cursor, Page is ignored.
+type Request struct {
+	Page         int
+	Limit        int
+	Cursor       *Cursor
+	IncludeTotal bool
+}
+
+// FromQuery reads page, limit, cursor and include_total. The total is
+// counted by default only when paging by number, as the page-number tables
+// need it and cursor clients usually do not.
+func FromQuery(q url.Values) (Request, error) {
+	var req Request
+	req.Page, _ = strconv.Atoi(q.Get("page"))
+	req.Limit, _ = strconv.Atoi(q.Get("limit"))
+	if s := strings.TrimSpace(q.Get("cursor")); s != "" {
+		c, err := Decode(s)
+		if err != nil {
+			return Request{}, err
+		}
+		req.Cursor = c
+	}
+	req.IncludeTotal = req.Cursor == nil
+	if v, err := strconv.ParseBool(q.Get("include_total")); err == nil {
+		req.IncludeTotal = v
+	}
+	return req, nil
+}
+
+// Normalize clamps Limit to [1, maxLimit] (def when unset) and Page to at
+// least 1. Page is zeroed when paging by cursor.
+func (r *Request) Normalize(def, maxLimit int) {
+	if r.Limit < 1 {
+		r.Limit = def
+	}
+	if r.Limit > maxLimit {
+		r.Limit = maxLimit
+	}
+	if r.Page < 1 {
+		r.Page = 1
+	}
+	if r.Cursor != nil {
+		r.Page = 0
+	}
+}
+
+// Offset is the OFFSET of the page; cursor pages start at the cursor.
+func (r Request) Offset() int {
+	if r.Cursor != nil {
+		return 0
+	}
+	return (r.Page - 1) * r.Limit
+}
+
+// Meta is the paging part of a list response. Totals are left out unless
+// requested, and a cursor only when there are rows that way.
+type Meta struct {
+	Total      *int    `json:"total,omitempty"`
+	NextCursor *string `json:"next_cursor,omitempty"`
+	PrevCursor *string `json:"prev_cursor,omitempty"`
+}
+
+// Columns selects the ordering keys as text, to follow the row's own
+// columns; Finish reads them back to build cursors.
+func (o Order) Columns() string {
+	var b strings.Builder
+	for _, k := range o.Keys {
+		b.WriteString(", (" + k.Expr + ")::text")
+	}
+	return b.String()
+}
+
+// OrderBy is the ORDER BY list, reversed for a Before cursor (Finish puts
+// the rows back in order).
+func (o Order) OrderBy(c *Cursor) string {
+	terms := make([]string, len(o.Keys))
+	for i, k := range o.Keys {
+		desc := k.Desc != (c != nil && c.Before)
+		dir := "ASC"
+		if desc {
+			dir = "DESC"
+		}
+		terms[i] = "(" + k.Expr + ") " + dir
+	}
+	return strings.Join(terms, ", ")
+}
+
+// Seek is the WHERE condition for the rows past c, appending c's values to
+// args. It returns "" when c is nil, and ErrInvalidCursor when c was taken
+// under another ordering.
+func (o Order) Seek(c *Cursor, args []any) (string, []any, error) {
+	if c == nil {
+		return "", args, nil
+	}
+	if c.Order != o.Name || len(c.Values) != len(o.Keys) {
+		return "", args, ErrInvalidCursor
+	}
+
+	params := make([]string, len(o.Keys))
+	for i, k := range o.Keys {
+		args = append(args, c.Values[i])
+		params[i] = fmt.Sprintf("$%d::%s", len(args), k.Type)
+	}
+
+	op := func(k Key) string {
+		if k.Desc != c.Before {
+			return "<"
+		}
+		return ">"
+	}
+
+	// When every key goes the same way a row comparison says it all, and
+	// reads better to the planner.
+	uniform := true
+	for _, k := range o.Keys[1:] {
+		if k.Desc != o.Keys[0].Desc {
+			uniform = false
+		}
+	}
+	if uniform {
+		exprs := make([]string, len(o.Keys))
+		for i, k := range o.Keys {
+			exprs[i] = "(" + k.Expr + ")"
+		}
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(exprs, ", "), op(o.Keys[0]), strings.Join(params, ", ")), args, nil
+	}
+
+	// k1 > v1 OR (k1 = v1 AND (k2 < v2 OR (k2 = v2 AND ...)))
+	cond := fmt.Sprintf("(%s) %s %s", o.Keys[len(o.Keys)-1].Expr, op(o.Keys[len(o.Keys)-1]), params[len(o.Keys)-1])
+	for i := len(o.Keys) - 2; i >= 0; i-- {
+		k := o.Keys[i]
+		cond = fmt.Sprintf("((%[1]s) %[2]s %[3]s OR ((%[1]s) = %[3]s AND %[4]s))", k.Expr, op(k), params[i], cond)
+	}
+	return cond, args, nil
+}
+
+// Finish takes the rows of a query ordered by OrderBy and limited to
+// req.Limit+1, with keys holding each row's Columns. It drops the extra
+// row, which only tells whether there is more, restores the order of a
+// Before page and builds the cursors around the page.
+func Finish[T any](req Request, o Order, rows []T, keys [][]string) ([]T, Meta) {
+	more := len(rows) > req.Limit
+	if more {
+		rows, keys = rows[:req.Limit], keys[:req.Limit]
+	}
+	before := req.Cursor != nil && req.Cursor.Before
+	if before {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+	}
+
+	var meta Meta
+	if len(rows) == 0 {
+		return rows, meta
+	}
+	cursor := func(values []string, before bool) *string {
+		s := Cursor{Order: o.Name, Values: values, Before: before}.Encode()
+		return &s
+	}
+
+	// Coming from a cursor there are rows on the side it came from; on the
+	// first page by number there are none before.
+	hasNext, hasPrev := more, req.Cursor != nil || req.Page > 1
+	if before {
+		hasNext, hasPrev = true, more
+	}
+	if hasNext {
+		meta.NextCursor = cursor(keys[len(keys)-1], false)
+	}
+	if hasPrev {
+		meta.PrevCursor = cursor(keys[0], true)
+	}
+	return rows, meta
+}
+
+// QueryError turns a failed listing query into ErrInvalidCursor when the
+// cause is a cursor value that does not cast to its key's type (a
+// tampered cursor), and returns err otherwise.
+func QueryError(err error, c *Cursor) error {
+	var pgErr *pgconn.PgError
+	if c != nil && errors.As(err, &pgErr) && strings.HasPrefix(pgErr.Code, "22") {
+		return fmt.Errorf("%w: %s", ErrInvalidCursor, pgErr.Message)
+	}
+	return err
+}
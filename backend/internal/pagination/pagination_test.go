@@ -0,0 +1,160 @@
+package pagination
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+var newest = Order{Name: "newest", Keys: []Key{
+	{Expr: "created_at", Type: "timestamptz", Desc: true},
+	{Expr: "id", Type: "bigint", Desc: true},
+}}
+
+var cheapest = Order{Name: "price_asc", Keys: []Key{
+	{Expr: "price_cents", Type: "bigint"},
+	{Expr: "id", Type: "bigint", Desc: true},
+}}
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := Cursor{Order: "newest", Values: []string{"2026-01-01 00:00:00+00", "7"}, Before: true}
+	got, err := Decode(c.Encode())
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if !reflect.DeepEqual(*got, c) {
+		t.Fatalf("expected %+v, got %+v", c, *got)
+	}
+
+	for _, bad := range []string{"!!", "bm90IGpzb24", Cursor{Order: "newest"}.Encode()} {
+		if _, err := Decode(bad); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Decode(%q): expected ErrInvalidCursor, got %v", bad, err)
+		}
+	}
+}
+
+func TestFromQuery(t *testing.T) {
+	req, err := FromQuery(url.Values{"page": {"2"}, "limit": {"5"}})
+	if err != nil || req.Page != 2 || req.Limit != 5 || req.Cursor != nil || !req.IncludeTotal {
+		t.Fatalf("page request: got %+v, %v", req, err)
+	}
+
+	cursor := Cursor{Order: "newest", Values: []string{"x", "1"}}.Encode()
+	req, err = FromQuery(url.Values{"cursor": {cursor}})
+	if err != nil || req.Cursor == nil || req.IncludeTotal {
+		t.Fatalf("cursor request: got %+v, %v", req, err)
+	}
+	req, _ = FromQuery(url.Values{"cursor": {cursor}, "include_total": {"true"}})
+	if !req.IncludeTotal {
+		t.Fatal("expected include_total=true to count with a cursor")
+	}
+
+	if _, err := FromQuery(url.Values{"cursor": {"!!"}}); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	req := Request{Page: 0, Limit: 500}
+	req.Normalize(20, 100)
+	if req.Page != 1 || req.Limit != 100 || req.Offset() != 0 {
+		t.Fatalf("unexpected %+v", req)
+	}
+	req = Request{Page: 3, Limit: 0}
+	req.Normalize(20, 100)
+	if req.Limit != 20 || req.Offset() != 40 {
+		t.Fatalf("unexpected %+v", req)
+	}
+	req = Request{Page: 3, Limit: 10, Cursor: &Cursor{}}
+	req.Normalize(20, 100)
+	if req.Page != 0 || req.Offset() != 0 {
+		t.Fatalf("expected a cursor to ignore the page, got %+v", req)
+	}
+}
+
+func TestSeek(t *testing.T) {
+	cond, args, err := newest.Seek(&Cursor{Order: "newest", Values: []string{"t", "7"}}, []any{"active"})
+	if err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	if want := "((created_at), (id)) < ($2::timestamptz, $3::bigint)"; cond != want {
+		t.Errorf("expected %q, got %q", want, cond)
+	}
+	if !reflect.DeepEqual(args, []any{"active", "t", "7"}) {
+		t.Errorf("unexpected args %v", args)
+	}
+
+	cond, _, _ = cheapest.Seek(&Cursor{Order: "price_asc", Values: []string{"1000", "7"}, Before: true}, nil)
+	if want := "((price_cents) < $1::bigint OR ((price_cents) = $1::bigint AND (id) > $2::bigint))"; cond != want {
+		t.Errorf("expected %q, got %q", want, cond)
+	}
+
+	if cond, _, err := newest.Seek(nil, nil); cond != "" || err != nil {
+		t.Errorf("expected no condition without a cursor, got %q, %v", cond, err)
+	}
+	if _, _, err := cheapest.Seek(&Cursor{Order: "newest", Values: []string{"t", "7"}}, nil); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor for another ordering, got %v", err)
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	if got := cheapest.OrderBy(nil); got != "(price_cents) ASC, (id) DESC" {
+		t.Errorf("unexpected %q", got)
+	}
+	if got := cheapest.OrderBy(&Cursor{Before: true}); got != "(price_cents) DESC, (id) ASC" {
+		t.Errorf("unexpected reversed %q", got)
+	}
+}
+
+func TestFinish(t *testing.T) {
+	keys := func(ids ...string) [][]string {
+		out := make([][]string, len(ids))
+		for i, id := range ids {
+			out[i] = []string{"t", id}
+		}
+		return out
+	}
+	decode := func(s *string) Cursor {
+		t.Helper()
+		if s == nil {
+			t.Fatal("expected a cursor")
+		}
+		c, err := Decode(*s)
+		if err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		return *c
+	}
+
+	// First page by number, one row more than the limit.
+	rows, meta := Finish(Request{Page: 1, Limit: 2}, newest, []int{5, 4, 3}, keys("5", "4", "3"))
+	if !reflect.DeepEqual(rows, []int{5, 4}) || meta.PrevCursor != nil {
+		t.Fatalf("unexpected first page %v %+v", rows, meta)
+	}
+	if c := decode(meta.NextCursor); c.Values[1] != "4" || c.Before || c.Order != "newest" {
+		t.Fatalf("unexpected next cursor %+v", c)
+	}
+
+	// Last page reached from a cursor.
+	rows, meta = Finish(Request{Limit: 2, Cursor: &Cursor{}}, newest, []int{3}, keys("3"))
+	if !reflect.DeepEqual(rows, []int{3}) || meta.NextCursor != nil {
+		t.Fatalf("unexpected last page %v %+v", rows, meta)
+	}
+	if c := decode(meta.PrevCursor); c.Values[1] != "3" || !c.Before {
+		t.Fatalf("unexpected prev cursor %+v", c)
+	}
+
+	// Going back, rows come reversed and there is more before.
+	rows, meta = Finish(Request{Limit: 2, Cursor: &Cursor{Before: true}}, newest, []int{4, 5, 6}, keys("4", "5", "6"))
+	if !reflect.DeepEqual(rows, []int{5, 4}) {
+		t.Fatalf("expected rows back in order, got %v", rows)
+	}
+	if decode(meta.PrevCursor).Values[1] != "5" || decode(meta.NextCursor).Values[1] != "4" {
+		t.Fatalf("unexpected cursors %+v", meta)
+	}
+
+	if rows, meta = Finish(Request{Page: 1, Limit: 2}, newest, []int{}, nil); len(rows) != 0 || meta != (Meta{}) {
+		t.Fatalf("expected no cursors for an empty page, got %+v", meta)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
 	"github.com/ferjunior7/parasempre/backend/internal/httputil"
 	"github.com/ferjunior7/parasempre/backend/internal/middleware"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
 )
 
 const (
@@ -103,9 +104,12 @@ func (h *Handler) HandleListAll(w http.ResponseWriter, r *http.Request) {
 			filter.GiftID = &v
 		}
 	}
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	resp, err := h.svc.ListAll(r.Context(), filter, page, limit)
+	req, err := pagination.FromQuery(r.URL.Query())
+	if err != nil {
+		httputil.WriteError(w, r, apperror.Validation("cursor inválido"))
+		return
+	}
+	resp, err := h.svc.ListAll(r.Context(), filter, req)
 	if err != nil {
 		httputil.WriteError(w, r, err)
 		return
@@ -139,7 +143,7 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if h.mp == nil || !h.mp.VerifyWebhookSignature(r.Header, dataID) {
-		slog.Warn("payment.webhook: signature verification failed",
+		slog.WarnContext(r.Context(), "payment.webhook: signature verification failed",
 			"path", r.URL.Path,
 			"remote", r.RemoteAddr,
 			"x-request-id", r.Header.Get("x-request-id"),
@@ -150,11 +154,11 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 
 	var payload webhookPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		slog.Warn("payment.webhook: failed to decode body", "error", err)
+		slog.WarnContext(r.Context(), "payment.webhook: failed to decode body", "error", err)
 	}
 
 	if payload.Type != "" && payload.Type != "payment" {
-		slog.Info("payment.webhook: non-payment event ignored", "type", payload.Type, "action", payload.Action)
+		slog.InfoContext(r.Context(), "payment.webhook: non-payment event ignored", "type", payload.Type, "action", payload.Action)
 		w.WriteHeader(http.StatusOK)
 		return
 	}
@@ -163,7 +167,7 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		dataID = payload.Data.ID
 	}
 	if dataID == "" {
-		slog.Warn("payment.webhook: missing data.id")
+		slog.WarnContext(r.Context(), "payment.webhook: missing data.id")
 		w.WriteHeader(http.StatusOK)
 		return
 	}
@@ -172,7 +176,7 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	if err := h.svc.HandleWebhookEvent(ctx, dataID); err != nil {
-		slog.Error("payment.webhook: service error", "data_id", dataID, "error", err)
+		slog.ErrorContext(r.Context(), "payment.webhook: service error", "data_id", dataID, "error", err)
 		var ae *apperror.AppError
 		if app, ok := apperror.IsAppError(err); ok {
 			ae = app
@@ -115,7 +115,7 @@ func (c *MercadoPagoClient) CreatePayment(ctx context.Context, payload CreatePay
 
 	resp, err := c.http.Do(req)
 	if err != nil {
-		slog.Error("mercadopago: create payment request failed", "error", err)
+		slog.ErrorContext(ctx, "mercadopago: create payment request failed", "error", err)
 		return nil, apperror.ServiceUnavailable("Falha ao contactar Mercado Pago. Tente novamente.")
 	}
 	defer resp.Body.Close()
@@ -126,24 +126,24 @@ func (c *MercadoPagoClient) CreatePayment(ctx context.Context, payload CreatePay
 	}
 
 	if resp.StatusCode >= 500 {
-		slog.Error("mercadopago: 5xx from API", "status", resp.StatusCode)
+		slog.ErrorContext(ctx, "mercadopago: 5xx from API", "status", resp.StatusCode)
 		return nil, apperror.ServiceUnavailable("Mercado Pago indisponível. Tente novamente em instantes.")
 	}
 
 	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnprocessableEntity {
 		msg := extractMPError(respBody)
-		slog.Warn("mercadopago: validation error", "status", resp.StatusCode, "message", msg)
+		slog.WarnContext(ctx, "mercadopago: validation error", "status", resp.StatusCode, "message", msg)
 		return nil, apperror.Validation(msg)
 	}
 
 	var parsed MPPayment
 	if err := json.Unmarshal(respBody, &parsed); err != nil {
-		slog.Error("mercadopago: failed to parse response", "error", err, "status", resp.StatusCode, "body_len", len(respBody))
+		slog.ErrorContext(ctx, "mercadopago: failed to parse response", "error", err, "status", resp.StatusCode, "body_len", len(respBody))
 		return nil, apperror.Internal("Resposta inválida do Mercado Pago.", err)
 	}
 
 	if parsed.ID == 0 {
-		slog.Error("mercadopago: response missing id", "status", resp.StatusCode)
+		slog.ErrorContext(ctx, "mercadopago: response missing id", "status", resp.StatusCode)
 		return nil, apperror.Internal("Mercado Pago retornou resposta sem ID.", nil)
 	}
 
@@ -159,7 +159,7 @@ func (c *MercadoPagoClient) GetPayment(ctx context.Context, mpPaymentID string)
 
 	resp, err := c.http.Do(req)
 	if err != nil {
-		slog.Error("mercadopago: get payment request failed", "id", mpPaymentID, "error", err)
+		slog.ErrorContext(ctx, "mercadopago: get payment request failed", "id", mpPaymentID, "error", err)
 		return nil, apperror.ServiceUnavailable("Falha ao consultar Mercado Pago.")
 	}
 	defer resp.Body.Close()
@@ -173,7 +173,7 @@ func (c *MercadoPagoClient) GetPayment(ctx context.Context, mpPaymentID string)
 		return nil, apperror.NotFound("Pagamento não encontrado no Mercado Pago.")
 	}
 	if resp.StatusCode >= 400 {
-		slog.Error("mercadopago: get payment bad response", "status", resp.StatusCode)
+		slog.ErrorContext(ctx, "mercadopago: get payment bad response", "status", resp.StatusCode)
 		return nil, apperror.ServiceUnavailable("Erro ao consultar Mercado Pago.")
 	}
 
@@ -1,6 +1,10 @@
 package payment
 
-import "time"
+import (
+	"time"
+
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
+)
 
 const (
 	PaymentMethodCreditCard = "credit_card"
@@ -83,11 +87,13 @@ type AdminSummary struct {
 	ByStatus           []StatusBreakdown `json:"by_status"`
 }
 
+// PagedTransactions is a page of transactions. Page is 0 when paging by
+// cursor.
 type PagedTransactions[T any] struct {
 	Data  []T `json:"data"`
-	Page  int `json:"page"`
+	Page  int `json:"page,omitempty"`
 	Limit int `json:"limit"`
-	Total int `json:"total"`
+	pagination.Meta
 }
 
 type ListFilter struct {
@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/jackc/pgx/v5"
+
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
 )
 
 type Repository interface {
@@ -12,8 +14,9 @@ type Repository interface {
 	GetByMPPaymentID(ctx context.Context, mpPaymentID string) (*GiftTransaction, error)
 	UpdateAfterCreate(ctx context.Context, id int64, mpPaymentID string, status string) (*GiftTransaction, error)
 	UpdateStatus(ctx context.Context, mpPaymentID string, newStatus string, allowedFrom []string) (int64, error)
+	PublishToWall(ctx context.Context, id int64) error
 	ListByUserID(ctx context.Context, userID int64, limit, offset int) ([]GiftTransaction, int, error)
-	ListAll(ctx context.Context, filter ListFilter, limit, offset int) ([]AdminTransactionRow, int, error)
+	ListAll(ctx context.Context, filter ListFilter, req pagination.Request) ([]AdminTransactionRow, pagination.Meta, error)
 	Summary(ctx context.Context) (*AdminSummary, error)
 }
 
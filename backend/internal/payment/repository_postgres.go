@@ -13,6 +13,8 @@ import (
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
 	"github.com/ferjunior7/parasempre/backend/internal/database"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
+	"github.com/ferjunior7/parasempre/backend/internal/wall"
 )
 
 const txColumns = `id, gift_id, user_id, payment_method, mp_payment_id, mp_preference_id, amount_cents, status, idempotency_key, created_at, updated_at, gift_name_snapshot`
@@ -51,10 +53,10 @@ func (r *PostgresRepository) Create(ctx context.Context, input CreateGiftTransac
 		if appErr := mapPgError(err); appErr != nil {
 			return nil, appErr
 		}
-		slog.Error("payment.repo create: insert failed", "error", err)
+		slog.ErrorContext(ctx, "payment.repo create: insert failed", "error", err)
 		return nil, err
 	}
-	slog.Info("payment.repo create: transaction stored", "id", t.ID, "gift_id", t.GiftID)
+	slog.InfoContext(ctx, "payment.repo create: transaction stored", "id", t.ID, "gift_id", t.GiftID)
 	return &t, nil
 }
 
@@ -65,7 +67,7 @@ func (r *PostgresRepository) GetByID(ctx context.Context, id int64) (*GiftTransa
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, apperror.NotFound("transaction not found")
 		}
-		slog.Error("payment.repo get_by_id: query failed", "id", id, "error", err)
+		slog.ErrorContext(ctx, "payment.repo get_by_id: query failed", "id", id, "error", err)
 		return nil, err
 	}
 	return &t, nil
@@ -78,7 +80,7 @@ func (r *PostgresRepository) GetByMPPaymentID(ctx context.Context, mpPaymentID s
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, apperror.NotFound("transaction not found")
 		}
-		slog.Error("payment.repo get_by_mp_payment_id: query failed", "mp_payment_id", mpPaymentID, "error", err)
+		slog.ErrorContext(ctx, "payment.repo get_by_mp_payment_id: query failed", "mp_payment_id", mpPaymentID, "error", err)
 		return nil, err
 	}
 	return &t, nil
@@ -104,7 +106,7 @@ func (r *PostgresRepository) UpdateAfterCreate(ctx context.Context, id int64, mp
 		if appErr := mapPgError(err); appErr != nil {
 			return nil, appErr
 		}
-		slog.Error("payment.repo update_after_create: failed", "id", id, "error", err)
+		slog.ErrorContext(ctx, "payment.repo update_after_create: failed", "id", id, "error", err)
 		return nil, err
 	}
 	return &t, nil
@@ -120,12 +122,37 @@ func (r *PostgresRepository) UpdateStatus(ctx context.Context, mpPaymentID, newS
 		 WHERE mp_payment_id = $2 AND status = ANY($3)`,
 		newStatus, mpPaymentID, allowedFrom)
 	if err != nil {
-		slog.Error("payment.repo update_status: failed", "mp_payment_id", mpPaymentID, "error", err)
+		slog.ErrorContext(ctx, "payment.repo update_status: failed", "mp_payment_id", mpPaymentID, "error", err)
 		return 0, err
 	}
 	return tag.RowsAffected(), nil
 }
 
+// PublishToWall records an approved purchase in wall_events and notifies the
+// reception wall. Only the buyer's first name is exposed.
+func (r *PostgresRepository) PublishToWall(ctx context.Context, id int64) error {
+	_, err := r.db.Exec(ctx,
+		`WITH `+wall.PublishLock+`, ev AS (
+		     INSERT INTO wall_events (kind, ref_id, payload)
+		     SELECT $2, gt.id, jsonb_build_object(
+		                'gift_id', gt.gift_id,
+		                'gift_name', gt.gift_name_snapshot,
+		                'buyer_name', g.first_name)
+		       FROM wall_lock, gift_transactions gt
+		       JOIN users u ON u.id = gt.user_id
+		       LEFT JOIN guests g ON g.id = u.guest_id
+		      WHERE gt.id = $1 AND gt.status = 'approved'
+		     ON CONFLICT (kind, ref_id) DO NOTHING
+		     RETURNING id)
+		 SELECT pg_notify($3, id::text) FROM ev`,
+		id, wall.KindPurchase, wall.Channel)
+	if err != nil {
+		slog.ErrorContext(ctx, "payment.repo publish_to_wall: failed", "tx_id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
 func (r *PostgresRepository) ListByUserID(ctx context.Context, userID int64, limit, offset int) ([]GiftTransaction, int, error) {
 	rows, err := r.db.Query(ctx,
 		`SELECT `+txColumns+`, COUNT(*) OVER() AS total
@@ -135,7 +162,7 @@ func (r *PostgresRepository) ListByUserID(ctx context.Context, userID int64, lim
 		  LIMIT $2 OFFSET $3`,
 		userID, limit, offset)
 	if err != nil {
-		slog.Error("payment.repo list_by_user_id: query failed", "user_id", userID, "error", err)
+		slog.ErrorContext(ctx, "payment.repo list_by_user_id: query failed", "user_id", userID, "error", err)
 		return nil, 0, err
 	}
 	defer rows.Close()
@@ -149,7 +176,7 @@ func (r *PostgresRepository) ListByUserID(ctx context.Context, userID int64, lim
 			&t.AmountCents, &t.Status, &t.IdempotencyKey, &t.CreatedAt, &t.UpdatedAt, &t.GiftNameSnapshot,
 			&total,
 		); err != nil {
-			slog.Error("payment.repo list_by_user_id: scan failed", "error", err)
+			slog.ErrorContext(ctx, "payment.repo list_by_user_id: scan failed", "error", err)
 			return nil, 0, err
 		}
 		txs = append(txs, t)
@@ -160,42 +187,76 @@ func (r *PostgresRepository) ListByUserID(ctx context.Context, userID int64, lim
 	return txs, total, rows.Err()
 }
 
-func (r *PostgresRepository) ListAll(ctx context.Context, filter ListFilter, limit, offset int) ([]AdminTransactionRow, int, error) {
-	rows, err := r.db.Query(ctx,
-		`SELECT `+gtTxColumns+`, u.uracf, u.phone, COUNT(*) OVER() AS total
+// adminTxOrder is the admin listing's ordering, newest first.
+var adminTxOrder = pagination.Order{Name: "newest", Keys: []pagination.Key{
+	{Expr: "gt.created_at", Type: "timestamptz", Desc: true},
+	{Expr: "gt.id", Type: "bigint", Desc: true},
+}}
+
+func (r *PostgresRepository) ListAll(ctx context.Context, filter ListFilter, req pagination.Request) ([]AdminTransactionRow, pagination.Meta, error) {
+	const from = `
 		   FROM gift_transactions gt
 		   JOIN users u ON u.id = gt.user_id
 		  WHERE ($1::text IS NULL OR gt.status = $1)
-		    AND ($2::bigint IS NULL OR gt.gift_id = $2)
-		  ORDER BY gt.created_at DESC
-		  LIMIT $3 OFFSET $4`,
-		filter.Status, filter.GiftID, limit, offset)
+		    AND ($2::bigint IS NULL OR gt.gift_id = $2)`
+	args := []any{filter.Status, filter.GiftID}
+
+	var meta pagination.Meta
+	if req.IncludeTotal {
+		var total int
+		if err := r.db.QueryRow(ctx, `SELECT COUNT(*)`+from, args...).Scan(&total); err != nil {
+			slog.ErrorContext(ctx, "payment.repo list_all: count failed", "error", err)
+			return nil, meta, err
+		}
+		meta.Total = &total
+	}
+
+	seek, args, err := adminTxOrder.Seek(req.Cursor, args)
 	if err != nil {
-		slog.Error("payment.repo list_all: query failed", "error", err)
-		return nil, 0, err
+		return nil, meta, err
+	}
+	query := `SELECT ` + gtTxColumns + `, u.uracf, u.phone` + adminTxOrder.Columns() + from
+	if seek != "" {
+		query += ` AND ` + seek
+	}
+	query += fmt.Sprintf(` ORDER BY %s LIMIT $%d OFFSET $%d`, adminTxOrder.OrderBy(req.Cursor), len(args)+1, len(args)+2)
+	args = append(args, req.Limit+1, req.Offset())
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "payment.repo list_all: query failed", "error", err)
+		return nil, meta, pagination.QueryError(err, req.Cursor)
 	}
 	defer rows.Close()
 
 	var result []AdminTransactionRow
-	var total int
+	var keys [][]string
 	for rows.Next() {
 		var row AdminTransactionRow
+		key := make([]string, len(adminTxOrder.Keys))
 		t := &row.GiftTransaction
 		if err := rows.Scan(
 			&t.ID, &t.GiftID, &t.UserID, &t.PaymentMethod, &t.MPPaymentID, &t.MPPreferenceID,
 			&t.AmountCents, &t.Status, &t.IdempotencyKey, &t.CreatedAt, &t.UpdatedAt, &t.GiftNameSnapshot,
 			&row.UserURACF, &row.UserPhone,
-			&total,
+			&key[0], &key[1],
 		); err != nil {
-			slog.Error("payment.repo list_all: scan failed", "error", err)
-			return nil, 0, err
+			slog.ErrorContext(ctx, "payment.repo list_all: scan failed", "error", err)
+			return nil, meta, err
 		}
 		result = append(result, row)
+		keys = append(keys, key)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, meta, pagination.QueryError(err, req.Cursor)
+	}
+
+	result, page := pagination.Finish(req, adminTxOrder, result, keys)
+	page.Total = meta.Total
 	if result == nil {
 		result = []AdminTransactionRow{}
 	}
-	return result, total, rows.Err()
+	return result, page, nil
 }
 
 func (r *PostgresRepository) Summary(ctx context.Context) (*AdminSummary, error) {
@@ -205,7 +266,7 @@ func (r *PostgresRepository) Summary(ctx context.Context) (*AdminSummary, error)
 		`SELECT COUNT(*), COALESCE(SUM(amount_cents) FILTER (WHERE status = 'approved'), 0)
 		   FROM gift_transactions`)
 	if err := row.Scan(&summary.Total, &summary.ApprovedTotalCents); err != nil {
-		slog.Error("payment.repo summary: totals query failed", "error", err)
+		slog.ErrorContext(ctx, "payment.repo summary: totals query failed", "error", err)
 		return nil, err
 	}
 
@@ -215,7 +276,7 @@ func (r *PostgresRepository) Summary(ctx context.Context) (*AdminSummary, error)
 		  GROUP BY status
 		  ORDER BY status`)
 	if err != nil {
-		slog.Error("payment.repo summary: by_status query failed", "error", err)
+		slog.ErrorContext(ctx, "payment.repo summary: by_status query failed", "error", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -223,7 +284,7 @@ func (r *PostgresRepository) Summary(ctx context.Context) (*AdminSummary, error)
 	for rows.Next() {
 		var b StatusBreakdown
 		if err := rows.Scan(&b.Status, &b.Count, &b.TotalCents); err != nil {
-			slog.Error("payment.repo summary: scan failed", "error", err)
+			slog.ErrorContext(ctx, "payment.repo summary: scan failed", "error", err)
 			return nil, err
 		}
 		summary.ByStatus = append(summary.ByStatus, b)
@@ -12,7 +12,9 @@ import (
 	"github.com/jackc/pgx/v5"
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/audit"
 	"github.com/ferjunior7/parasempre/backend/internal/database"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
 	"github.com/ferjunior7/parasempre/backend/internal/validate"
 )
 
@@ -29,22 +31,15 @@ type GiftSnapshot struct {
 	Status     string
 }
 
-// AuditLogger records user-attributed payment events into audit_log. Errors
-// are surfaced to the caller; the service uses fire-and-forget (logs on fail)
-// so audit issues never break the user flow.
-type AuditLogger interface {
-	LogAction(ctx context.Context, userID int64, action string, details map[string]any) error
-}
-
 type Service struct {
 	repo     TxAwareRepository
 	txRunner database.TxRunner
 	mp       PaymentGateway
 	gifts    GiftFinder
-	audit    AuditLogger
+	audit    audit.Logger
 }
 
-func NewService(repo TxAwareRepository, txRunner database.TxRunner, mp PaymentGateway, gifts GiftFinder, audit AuditLogger) *Service {
+func NewService(repo TxAwareRepository, txRunner database.TxRunner, mp PaymentGateway, gifts GiftFinder, audit audit.Logger) *Service {
 	return &Service{repo: repo, txRunner: txRunner, mp: mp, gifts: gifts, audit: audit}
 }
 
@@ -56,13 +51,10 @@ const (
 	auditOrphanRecovered       = "payment.orphan_recovered"
 )
 
+// recordAudit takes the actor explicitly: webhooks and reconciliation run
+// without a session user.
 func (s *Service) recordAudit(ctx context.Context, userID int64, action string, details map[string]any) {
-	if s.audit == nil || userID == 0 {
-		return
-	}
-	if err := s.audit.LogAction(ctx, userID, action, details); err != nil {
-		slog.Error("payment.service audit failed", "action", action, "user_id", userID, "error", err)
-	}
+	audit.RecordAs(ctx, s.audit, userID, action, details)
 }
 
 func (s *Service) CreatePurchase(ctx context.Context, giftID, userID int64, input CreatePurchaseInput) (*PurchaseResponse, error) {
@@ -104,7 +96,7 @@ func (s *Service) CreatePurchase(ctx context.Context, giftID, userID int64, inpu
 
 	updated, updErr := s.repo.UpdateAfterCreate(ctx, txRow.ID, mpPaymentID, finalStatus)
 	if updErr != nil {
-		slog.Error("payment.service create_purchase: persist update failed",
+		slog.ErrorContext(ctx, "payment.service create_purchase: persist update failed",
 			"tx_id", txRow.ID,
 			"mp_payment_id", mpPaymentID,
 			"final_status", finalStatus,
@@ -117,25 +109,25 @@ func (s *Service) CreatePurchase(ctx context.Context, giftID, userID int64, inpu
 	}
 
 	if mpErrToReturn != nil {
-		s.recordAudit(ctx, userID, auditPurchaseFailed, map[string]any{
+		s.recordAudit(ctx, userID, auditPurchaseFailed, audit.Entity(audit.EntityTransaction, txRow.ID, map[string]any{
 			"tx_id":        txRow.ID,
 			"gift_id":      g.ID,
 			"method":       method,
 			"amount_cents": g.PriceCents,
 			"final_status": finalStatus,
 			"mp_error":     mpErrToReturn.Error(),
-		})
+		}))
 		return nil, mpErrToReturn
 	}
 
-	s.recordAudit(ctx, userID, auditPurchaseCreated, map[string]any{
+	s.recordAudit(ctx, userID, auditPurchaseCreated, audit.Entity(audit.EntityTransaction, updated.ID, map[string]any{
 		"tx_id":         updated.ID,
 		"gift_id":       g.ID,
 		"method":        method,
 		"amount_cents":  g.PriceCents,
 		"status":        finalStatus,
 		"mp_payment_id": mpPaymentID,
-	})
+	}))
 
 	resp := &PurchaseResponse{
 		TransactionID: updated.ID,
@@ -157,7 +149,7 @@ func (s *Service) CreatePurchase(ctx context.Context, giftID, userID int64, inpu
 		}
 	}
 
-	slog.Info("payment.service create_purchase: done",
+	slog.InfoContext(ctx, "payment.service create_purchase: done",
 		"tx_id", updated.ID,
 		"gift_id", g.ID,
 		"user_id", userID,
@@ -216,7 +208,7 @@ func (s *Service) HandleWebhookEvent(ctx context.Context, dataID string) error {
 			return recErr
 		}
 		if recovered == nil {
-			slog.Warn("payment.service webhook: transaction not recoverable",
+			slog.WarnContext(ctx, "payment.service webhook: transaction not recoverable",
 				"mp_payment_id", dataID,
 				"external_reference", mpPayment.ExternalReference,
 			)
@@ -226,24 +218,24 @@ func (s *Service) HandleWebhookEvent(ctx context.Context, dataID string) error {
 	}
 
 	if CentsFromAmount(mpPayment.TransactionAmount) != row.AmountCents {
-		slog.Error("payment.service webhook: amount mismatch",
+		slog.ErrorContext(ctx, "payment.service webhook: amount mismatch",
 			"mp_payment_id", dataID,
 			"expected_cents", row.AmountCents,
 			"got_amount", mpPayment.TransactionAmount,
 		)
-		s.recordAudit(ctx, row.UserID, auditWebhookAmountMismatch, map[string]any{
+		s.recordAudit(ctx, row.UserID, auditWebhookAmountMismatch, audit.Entity(audit.EntityTransaction, row.ID, map[string]any{
 			"tx_id":          row.ID,
 			"mp_payment_id":  dataID,
 			"expected_cents": row.AmountCents,
 			"got_amount":     mpPayment.TransactionAmount,
-		})
+		}))
 		return apperror.Internal("payment amount mismatch", nil)
 	}
 
 	newStatus := mapMPStatus(mpPayment.Status)
 	allowedFrom := allowedFromStatuses(newStatus)
 	if len(allowedFrom) == 0 {
-		slog.Warn("payment.service webhook: unmappable status", "mp_status", mpPayment.Status)
+		slog.WarnContext(ctx, "payment.service webhook: unmappable status", "mp_status", mpPayment.Status)
 		return nil
 	}
 
@@ -252,23 +244,28 @@ func (s *Service) HandleWebhookEvent(ctx context.Context, dataID string) error {
 		return apperror.WrapIfNotApp("failed to update transaction status", err)
 	}
 	if rows == 0 {
-		slog.Info("payment.service webhook: status transition rejected (replay or terminal)",
+		slog.InfoContext(ctx, "payment.service webhook: status transition rejected (replay or terminal)",
 			"mp_payment_id", dataID,
 			"current_db_status", row.Status,
 			"target_status", newStatus,
 		)
 	} else {
-		slog.Info("payment.service webhook: status updated",
+		slog.InfoContext(ctx, "payment.service webhook: status updated",
 			"mp_payment_id", dataID,
 			"from", row.Status,
 			"to", newStatus,
 		)
-		s.recordAudit(ctx, row.UserID, auditWebhookStatusChanged, map[string]any{
+		s.recordAudit(ctx, row.UserID, auditWebhookStatusChanged, audit.Entity(audit.EntityTransaction, row.ID, map[string]any{
 			"tx_id":         row.ID,
 			"mp_payment_id": dataID,
 			"from":          row.Status,
 			"to":            newStatus,
-		})
+		}))
+		if newStatus == StatusApproved {
+			if err := s.repo.PublishToWall(ctx, row.ID); err != nil {
+				slog.WarnContext(ctx, "payment.service webhook: wall publish failed", "tx_id", row.ID, "error", err)
+			}
+		}
 	}
 	return nil
 }
@@ -290,7 +287,7 @@ func (s *Service) recoverByExternalReference(ctx context.Context, mpPaymentID, e
 		return row, nil
 	}
 	if row.MPPaymentID != nil && *row.MPPaymentID != mpPaymentID {
-		slog.Warn("payment.service webhook: external_reference points to tx with different mp_payment_id",
+		slog.WarnContext(ctx, "payment.service webhook: external_reference points to tx with different mp_payment_id",
 			"tx_id", txID,
 			"expected", mpPaymentID,
 			"have", *row.MPPaymentID,
@@ -301,14 +298,14 @@ func (s *Service) recoverByExternalReference(ctx context.Context, mpPaymentID, e
 	if err != nil {
 		return nil, apperror.WrapIfNotApp("failed to link mp_payment_id", err)
 	}
-	slog.Info("payment.service webhook: recovered orphan tx via external_reference",
+	slog.InfoContext(ctx, "payment.service webhook: recovered orphan tx via external_reference",
 		"tx_id", row.ID,
 		"mp_payment_id", mpPaymentID,
 	)
-	s.recordAudit(ctx, row.UserID, auditOrphanRecovered, map[string]any{
+	s.recordAudit(ctx, row.UserID, auditOrphanRecovered, audit.Entity(audit.EntityTransaction, row.ID, map[string]any{
 		"tx_id":         row.ID,
 		"mp_payment_id": mpPaymentID,
-	})
+	}))
 	return updated, nil
 }
 
@@ -422,7 +419,7 @@ func (s *Service) ListMyPurchases(ctx context.Context, userID int64, page, limit
 	for i, row := range rows {
 		data[i] = row.ToPublic()
 	}
-	return &PagedTransactions[PublicTransaction]{Data: data, Page: page, Limit: limit, Total: total}, nil
+	return &PagedTransactions[PublicTransaction]{Data: data, Page: page, Limit: limit, Meta: pagination.Meta{Total: &total}}, nil
 }
 
 func (s *Service) GetMyPurchase(ctx context.Context, userID, txID int64) (*PublicTransaction, error) {
@@ -453,22 +450,16 @@ func (s *Service) GetMyPurchase(ctx context.Context, userID, txID int64) (*Publi
 	return &pub, nil
 }
 
-func (s *Service) ListAll(ctx context.Context, filter ListFilter, page, limit int) (*PagedTransactions[AdminTransaction], error) {
+func (s *Service) ListAll(ctx context.Context, filter ListFilter, req pagination.Request) (*PagedTransactions[AdminTransaction], error) {
 	if filter.Status != nil && !knownStatuses[*filter.Status] {
 		return nil, apperror.Validation("status inválido")
 	}
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 {
-		limit = 20
-	}
-	if limit > 100 {
-		limit = 100
-	}
-	offset := (page - 1) * limit
+	req.Normalize(20, 100)
 
-	rows, total, err := s.repo.ListAll(ctx, filter, limit, offset)
+	rows, meta, err := s.repo.ListAll(ctx, filter, req)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		return nil, apperror.Validation("cursor inválido")
+	}
 	if err != nil {
 		return nil, apperror.WrapIfNotApp("falha ao listar transações", err)
 	}
@@ -484,7 +475,7 @@ func (s *Service) ListAll(ctx context.Context, filter ListFilter, page, limit in
 			MPPaymentID:       row.GiftTransaction.MPPaymentID,
 		}
 	}
-	return &PagedTransactions[AdminTransaction]{Data: data, Page: page, Limit: limit, Total: total}, nil
+	return &PagedTransactions[AdminTransaction]{Data: data, Page: req.Page, Limit: req.Limit, Meta: meta}, nil
 }
 
 func (s *Service) Summary(ctx context.Context) (*AdminSummary, error) {
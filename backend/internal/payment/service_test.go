@@ -11,6 +11,8 @@ import (
 	"github.com/jackc/pgx/v5"
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
 )
 
 type mockRepository struct {
@@ -19,6 +21,8 @@ type mockRepository struct {
 	getByMPFn      func(ctx context.Context, mpPaymentID string) (*GiftTransaction, error)
 	updateAfterFn  func(ctx context.Context, id int64, mpPaymentID, status string) (*GiftTransaction, error)
 	updateStatusFn func(ctx context.Context, mpPaymentID, newStatus string, allowedFrom []string) (int64, error)
+	listAllFn      func(ctx context.Context, filter ListFilter, req pagination.Request) ([]AdminTransactionRow, pagination.Meta, error)
+	published      []int64
 }
 
 func (m *mockRepository) Create(ctx context.Context, input CreateGiftTransactionInput) (*GiftTransaction, error) {
@@ -36,12 +40,19 @@ func (m *mockRepository) UpdateAfterCreate(ctx context.Context, id int64, mpPaym
 func (m *mockRepository) UpdateStatus(ctx context.Context, mpPaymentID, newStatus string, allowedFrom []string) (int64, error) {
 	return m.updateStatusFn(ctx, mpPaymentID, newStatus, allowedFrom)
 }
+func (m *mockRepository) PublishToWall(_ context.Context, id int64) error {
+	m.published = append(m.published, id)
+	return nil
+}
 func (m *mockRepository) WithTx(_ pgx.Tx) Repository { return m }
 func (m *mockRepository) ListByUserID(_ context.Context, _ int64, _, _ int) ([]GiftTransaction, int, error) {
 	return nil, 0, nil
 }
-func (m *mockRepository) ListAll(_ context.Context, _ ListFilter, _, _ int) ([]AdminTransactionRow, int, error) {
-	return nil, 0, nil
+func (m *mockRepository) ListAll(ctx context.Context, filter ListFilter, req pagination.Request) ([]AdminTransactionRow, pagination.Meta, error) {
+	if m.listAllFn == nil {
+		return nil, pagination.Meta{}, nil
+	}
+	return m.listAllFn(ctx, filter, req)
 }
 func (m *mockRepository) Summary(_ context.Context) (*AdminSummary, error) {
 	return &AdminSummary{}, nil
@@ -364,6 +375,9 @@ func TestHandleWebhookEvent_TransitionsPendingToApproved(t *testing.T) {
 	if len(capturedFrom) != 1 || capturedFrom[0] != StatusPending {
 		t.Errorf("expected allowedFrom=[pending], got %v", capturedFrom)
 	}
+	if len(repo.published) != 1 || repo.published[0] != sampleTx().ID {
+		t.Errorf("expected approved purchase published to wall, got %v", repo.published)
+	}
 }
 
 func TestHandleWebhookEvent_RejectsAmountMismatch(t *testing.T) {
@@ -495,6 +509,9 @@ func TestHandleWebhookEvent_RowsAffectedZeroIsIdempotent(t *testing.T) {
 	if err := svc.HandleWebhookEvent(context.Background(), "999"); err != nil {
 		t.Fatalf("expected nil on replay, got %v", err)
 	}
+	if len(repo.published) != 0 {
+		t.Errorf("expected replay not to republish, got %v", repo.published)
+	}
 }
 
 func TestMapMPStatus(t *testing.T) {
@@ -540,6 +557,36 @@ func equalStrings(a, b []string) bool {
 	return true
 }
 
+func TestListAll_PagesByCursor(t *testing.T) {
+	var got pagination.Request
+	next := "next"
+	repo := &mockRepository{
+		listAllFn: func(_ context.Context, _ ListFilter, req pagination.Request) ([]AdminTransactionRow, pagination.Meta, error) {
+			got = req
+			return []AdminTransactionRow{{GiftTransaction: GiftTransaction{ID: 9, Status: StatusApproved}, UserURACF: "ABC12"}}, pagination.Meta{NextCursor: &next}, nil
+		},
+	}
+	svc := NewService(repo, &mockTxRunner{}, nil, &mockGiftFinder{}, &mockAuditLogger{})
+
+	c := &pagination.Cursor{Order: "newest", Values: []string{"2026-01-01 00:00:00+00", "10"}}
+	resp, err := svc.ListAll(context.Background(), ListFilter{}, pagination.Request{Limit: 500, Cursor: c})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Limit != 100 || got.Cursor != c || got.Offset() != 0 {
+		t.Fatalf("unexpected request %+v", got)
+	}
+	if len(resp.Data) != 1 || resp.NextCursor == nil || resp.Total != nil || resp.Page != 0 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+
+	repo.listAllFn = func(context.Context, ListFilter, pagination.Request) ([]AdminTransactionRow, pagination.Meta, error) {
+		return nil, pagination.Meta{}, pagination.ErrInvalidCursor
+	}
+	_, err = svc.ListAll(context.Background(), ListFilter{}, pagination.Request{Cursor: c})
+	assertAppError(t, err, http.StatusBadRequest, "cursor inválido")
+}
+
 func TestCreatePurchase_ServiceUnavailableWhenMPDisabled(t *testing.T) {
 	svc := NewService(&mockRepository{}, &mockTxRunner{}, nil, &mockGiftFinder{}, &mockAuditLogger{})
 	_, err := svc.CreatePurchase(context.Background(), 1, 42, validCardInput())
@@ -643,3 +690,18 @@ func TestHandleWebhookEvent_RecordsAuditOnAmountMismatch(t *testing.T) {
 		t.Errorf("expected single %q audit, got %v", auditWebhookAmountMismatch, audit.calls)
 	}
 }
+
+func TestRecordAudit_IncludesRequestID(t *testing.T) {
+	audit := &mockAuditLogger{}
+	svc := NewService(&mockRepository{}, &mockTxRunner{}, &mockGateway{}, &mockGiftFinder{}, audit)
+
+	ctx := reqctx.WithRequestID(context.Background(), "req-pay-1")
+	svc.recordAudit(ctx, 42, auditOrphanRecovered, map[string]any{"tx_id": int64(1)})
+
+	if len(audit.calls) != 1 {
+		t.Fatalf("expected 1 audit call, got %d", len(audit.calls))
+	}
+	if got := audit.calls[0].details["request_id"]; got != "req-pay-1" {
+		t.Errorf("expected request_id req-pay-1, got %v", got)
+	}
+}
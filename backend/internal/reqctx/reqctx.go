@@ -0,0 +1,76 @@
+// Package reqctx carries request-scoped values (request ID, client IP,
+// authenticated user) from the HTTP edge down to services, logs and audit
+// entries without those layers having to import the middleware package.
+package reqctx
+
+import "context"
+
+type contextKey string
+
+const (
+	requestIDKey contextKey = "request_id"
+	clientIPKey  contextKey = "client_ip"
+	userIDKey    contextKey = "user_id"
+)
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the ID stored by middleware.RequestID, or "" outside of
+// an HTTP request (startup, background jobs, tests).
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey, ip)
+}
+
+// ClientIP returns the address resolved by middleware.ClientIP, honoring
+// forwarding headers only from trusted proxies. "" when not resolved.
+func ClientIP(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ip, _ := ctx.Value(clientIPKey).(string)
+	return ip
+}
+
+func WithUserID(ctx context.Context, id int64) context.Context {
+	return context.WithValue(ctx, userIDKey, id)
+}
+
+// UserID returns the authenticated user stored alongside the JWT claims by
+// middleware.RequireAuth, or 0 for anonymous requests. Services use it to
+// attribute audit entries without threading the actor through every call.
+func UserID(ctx context.Context) int64 {
+	if ctx == nil {
+		return 0
+	}
+	id, _ := ctx.Value(userIDKey).(int64)
+	return id
+}
+
+// AuditDetails stamps request correlation data onto an audit_log details
+// map, allocating one when details is nil.
+func AuditDetails(ctx context.Context, details map[string]any) map[string]any {
+	id, ip := RequestID(ctx), ClientIP(ctx)
+	if id == "" && ip == "" {
+		return details
+	}
+	if details == nil {
+		details = map[string]any{}
+	}
+	if id != "" {
+		details["request_id"] = id
+	}
+	if ip != "" {
+		details["client_ip"] = ip
+	}
+	return details
+}
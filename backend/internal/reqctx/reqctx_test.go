@@ -0,0 +1,72 @@
+package reqctx
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDRoundTrip(t *testing.T) {
+	if got := RequestID(context.Background()); got != "" {
+		t.Fatalf("expected empty id, got %q", got)
+	}
+	ctx := WithRequestID(context.Background(), "abc123")
+	if got := RequestID(ctx); got != "abc123" {
+		t.Fatalf("expected abc123, got %q", got)
+	}
+}
+
+func TestUserIDRoundTrip(t *testing.T) {
+	if got := UserID(context.Background()); got != 0 {
+		t.Fatalf("expected 0 outside a request, got %d", got)
+	}
+	if got := UserID(WithUserID(context.Background(), 42)); got != 42 {
+		t.Fatalf("expected 42, got %d", got)
+	}
+}
+
+func TestLogHandlerAddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewLogHandler(slog.NewTextHandler(&buf, nil)))
+
+	ctx := WithRequestID(context.Background(), "req-42")
+	logger.InfoContext(ctx, "hello", "k", "v")
+
+	if !strings.Contains(buf.String(), "request_id=req-42") {
+		t.Fatalf("expected request_id in output, got %q", buf.String())
+	}
+}
+
+func TestLogHandlerWithoutRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewLogHandler(slog.NewTextHandler(&buf, nil)))
+
+	logger.With("component", "test").InfoContext(context.Background(), "hello")
+
+	out := buf.String()
+	if strings.Contains(out, "request_id") {
+		t.Fatalf("expected no request_id, got %q", out)
+	}
+	if !strings.Contains(out, "component=test") {
+		t.Fatalf("expected WithAttrs to be preserved, got %q", out)
+	}
+}
+
+func TestAuditDetails(t *testing.T) {
+	if got := AuditDetails(context.Background(), nil); got != nil {
+		t.Fatalf("expected nil details outside a request, got %v", got)
+	}
+
+	ctx := WithClientIP(WithRequestID(context.Background(), "req-1"), "203.0.113.7")
+	got := AuditDetails(ctx, map[string]any{"tx_id": 1})
+	if got["request_id"] != "req-1" || got["client_ip"] != "203.0.113.7" || got["tx_id"] != 1 {
+		t.Fatalf("unexpected details: %v", got)
+	}
+
+	got = AuditDetails(ctx, nil)
+	if got["request_id"] != "req-1" {
+		t.Fatalf("expected details to be allocated, got %v", got)
+	}
+}
@@ -0,0 +1,37 @@
+package reqctx
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogHandler decorates every record logged through a *Context slog call
+// (slog.InfoContext, slog.ErrorContext, ...) with the request_id found in
+// the context, so service-level errors can be correlated with the access log.
+type LogHandler struct {
+	next slog.Handler
+}
+
+func NewLogHandler(next slog.Handler) *LogHandler {
+	return &LogHandler{next: next}
+}
+
+func (h *LogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *LogHandler) Handle(ctx context.Context, rec slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		rec = rec.Clone()
+		rec.AddAttrs(slog.String("request_id", id))
+	}
+	return h.next.Handle(ctx, rec)
+}
+
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LogHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{next: h.next.WithGroup(name)}
+}
@@ -0,0 +1,119 @@
+// Package search holds what the gift and guest listings share for name
+// search: the SQL around search_normalize (migration 023) and the
+// highlighting of matches in results.
+//
+// A row matches when its normalized text contains the normalized query, or
+// when pg_trgm's word similarity between them reaches
+// pg_trgm.word_similarity_threshold (set per connection, see
+// database.Connect), which is what tolerates typos.
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// LikePattern is the argument for the pattern placeholder of Match and
+// Rank: the query with LIKE wildcards escaped, wrapped in %.
+func LikePattern(q string) string {
+	return "%" + likeEscaper.Replace(q) + "%"
+}
+
+// Match is the WHERE condition for expr against the query in placeholder
+// $queryArg and LikePattern(query) in $patternArg. Both branches can use
+// the trigram index on search_normalize(expr).
+func Match(expr string, queryArg, patternArg int) string {
+	return fmt.Sprintf(`(search_normalize(%[1]s) LIKE search_normalize($%[3]d)
+		OR search_normalize($%[2]d) <%% search_normalize(%[1]s))`, expr, queryArg, patternArg)
+}
+
+// RankKeys orders matches best first: substring matches, then by word
+// similarity. Listings append their own tie-breakers.
+func RankKeys(expr string, queryArg, patternArg int) []pagination.Key {
+	return []pagination.Key{
+		{Expr: fmt.Sprintf(`search_normalize(%s) LIKE search_normalize($%d)`, expr, patternArg), Type: "boolean", Desc: true},
+		{Expr: fmt.Sprintf(`word_similarity(search_normalize($%d), search_normalize(%s))`, queryArg, expr), Type: "real", Desc: true},
+	}
+}
+
+// Span is a highlighted stretch of a text, in characters (runes), end
+// exclusive.
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Highlight says which part of a result matched the search.
+type Highlight struct {
+	Field string `json:"field"`
+	Text  string `json:"text"`
+	Spans []Span `json:"spans"`
+}
+
+// Spans finds the words of query in text, ignoring case and accents, so
+// "joao" marks "João". Words shorter than two letters are skipped. Matches
+// found only by similarity (typos) are not marked.
+func Spans(text, query string) []Span {
+	folded, origin := fold(text)
+	marked := make([]bool, len(origin))
+	for _, word := range strings.Fields(string(foldString(query))) {
+		w := []rune(word)
+		if len(w) < 2 {
+			continue
+		}
+		for i := 0; i+len(w) <= len(folded); i++ {
+			if string(folded[i:i+len(w)]) == word {
+				for j := i; j < i+len(w); j++ {
+					marked[j] = true
+				}
+			}
+		}
+	}
+
+	var spans []Span
+	for i := 0; i < len(marked); i++ {
+		if !marked[i] {
+			continue
+		}
+		start := i
+		for i < len(marked) && marked[i] {
+			i++
+		}
+		spans = append(spans, Span{Start: origin[start], End: origin[i-1] + 1})
+	}
+	return spans
+}
+
+// fold lowercases text and strips its accents, returning the folded runes
+// and, for each, the index of the rune of text it came from.
+func fold(text string) ([]rune, []int) {
+	var folded []rune
+	var origin []int
+	for i, r := range []rune(text) {
+		for _, f := range foldString(string(r)) {
+			folded = append(folded, f)
+			origin = append(origin, i)
+		}
+	}
+	return folded, origin
+}
+
+func foldString(s string) []rune {
+	t := transform.Chain(norm.NFD, transform.RemoveFunc(isMark), norm.NFC)
+	if out, _, err := transform.String(t, s); err == nil {
+		s = out
+	}
+	return []rune(strings.ToLower(s))
+}
+
+func isMark(r rune) bool {
+	return unicode.Is(unicode.Mn, r)
+}
@@ -0,0 +1,48 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSpans(t *testing.T) {
+	tests := []struct {
+		text  string
+		query string
+		want  []Span
+	}{
+		{"João Silva", "joao", []Span{{0, 4}}},
+		{"Jogo de Panelas Tramontina", "jogo de panela", []Span{{0, 4}, {5, 7}, {8, 14}}},
+		{"Conceição", "CONCEICAO", []Span{{0, 9}}},
+		{"Panela de Pressão", "panela pressao", []Span{{0, 6}, {10, 17}}},
+		// Adjacent matches merge into one span.
+		{"Batedeira", "bate deira", []Span{{0, 9}}},
+		{"Batedeira Oster", "batedera", nil},
+		{"Ana e Zé", "e", nil},
+	}
+	for _, tt := range tests {
+		if got := Spans(tt.text, tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Spans(%q, %q) = %v, want %v", tt.text, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestLikePattern(t *testing.T) {
+	if got := LikePattern(`50% off_now\`); got != `%50\% off\_now\\%` {
+		t.Fatalf("unexpected pattern %q", got)
+	}
+}
+
+func TestMatchAndRankKeysPlaceholders(t *testing.T) {
+	match := Match("name", 3, 4)
+	keys := RankKeys("name", 3, 4)
+	for _, sql := range []string{match, keys[0].Expr + " " + keys[1].Expr} {
+		if !strings.Contains(sql, "search_normalize($3)") || !strings.Contains(sql, "search_normalize($4)") {
+			t.Errorf("expected both placeholders in %q", sql)
+		}
+	}
+	if !strings.Contains(match, "<% search_normalize(name)") {
+		t.Errorf("expected the indexable word similarity operator in %q", match)
+	}
+}
@@ -68,6 +68,30 @@ func (h *Handler) HandleMe(w http.ResponseWriter, r *http.Request) {
 	httputil.WriteJSON(w, http.StatusOK, me)
 }
 
+func (h *Handler) HandleCreateStaff(w http.ResponseWriter, r *http.Request) {
+	var input StaffInput
+	if err := httputil.DecodeJSON(r, &input); err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("invalid staff payload", err))
+		return
+	}
+
+	u, err := h.svc.CreateStaff(r.Context(), input)
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to create staff user", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusCreated, u)
+}
+
+func (h *Handler) HandleListStaff(w http.ResponseWriter, r *http.Request) {
+	users, err := h.svc.ListStaff(r.Context())
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("failed to list staff", err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, users)
+}
+
 func (h *Handler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
 	id, err := httputil.PathID(r)
 	if err != nil {
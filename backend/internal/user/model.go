@@ -34,8 +34,13 @@ type CoupleData struct {
 	Phone string
 }
 
+// StaffInput registers a reception desk user, who signs in by WhatsApp OTP.
+type StaffInput struct {
+	Phone string `json:"phone" validate:"required,brphone"`
+}
+
 type UpdateInput struct {
-	Role  *string `json:"role" validate:"omitempty,oneof=guest groom bride"`
+	Role  *string `json:"role" validate:"omitempty,oneof=guest groom bride staff"`
 	Phone *string `json:"phone" validate:"omitempty,brphone"`
 }
 
@@ -2,13 +2,13 @@ package user
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"log/slog"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/ferjunior7/parasempre/backend/internal/audit"
 	"github.com/ferjunior7/parasempre/backend/internal/database"
 )
 
@@ -39,7 +39,7 @@ func (r *PostgresRepository) GetByURACF(ctx context.Context, uracf string) (*Use
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
-		slog.Error("user.repo get_by_uracf: query failed", "uracf", uracf, "error", err)
+		slog.ErrorContext(ctx, "user.repo get_by_uracf: query failed", "uracf", uracf, "error", err)
 		return nil, err
 	}
 	return &u, nil
@@ -57,12 +57,32 @@ func (r *PostgresRepository) GetMeByURACF(ctx context.Context, uracf string) (*M
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
-		slog.Error("user.repo get_me_by_uracf: query failed", "uracf", uracf, "error", err)
+		slog.ErrorContext(ctx, "user.repo get_me_by_uracf: query failed", "uracf", uracf, "error", err)
 		return nil, err
 	}
 	return &me, nil
 }
 
+// GetFamilyGroupByUserID returns the family_group of the guest linked to the
+// user, or nil when the user has no guest (e.g. the couple).
+func (r *PostgresRepository) GetFamilyGroupByUserID(ctx context.Context, userID int64) (*int64, error) {
+	var group *int64
+	err := r.db.QueryRow(ctx,
+		`SELECT g.family_group
+		 FROM users u
+		 LEFT JOIN guests g ON g.id = u.guest_id
+		 WHERE u.id = $1`, userID).
+		Scan(&group)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		slog.ErrorContext(ctx, "user.repo get_family_group_by_user_id: query failed", "user_id", userID, "error", err)
+		return nil, err
+	}
+	return group, nil
+}
+
 func (r *PostgresRepository) GetByGuestID(ctx context.Context, guestID int64) (*User, error) {
 	u, err := scanUser(r.db.QueryRow(ctx,
 		`SELECT `+userColumns+` FROM users WHERE guest_id = $1`, guestID))
@@ -70,7 +90,7 @@ func (r *PostgresRepository) GetByGuestID(ctx context.Context, guestID int64) (*
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
-		slog.Error("user.repo get_by_guest_id: query failed", "guest_id", guestID, "error", err)
+		slog.ErrorContext(ctx, "user.repo get_by_guest_id: query failed", "guest_id", guestID, "error", err)
 		return nil, err
 	}
 	return &u, nil
@@ -113,7 +133,7 @@ func (r *PostgresRepository) GetByPhone(ctx context.Context, phone string) (*Use
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
-		slog.Error("user.repo get_by_phone: query failed", "phone", phone, "error", err)
+		slog.ErrorContext(ctx, "user.repo get_by_phone: query failed", "phone", phone, "error", err)
 		return nil, err
 	}
 	return &u, nil
@@ -126,7 +146,7 @@ func (r *PostgresRepository) GetByID(ctx context.Context, id int64) (*User, erro
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
-		slog.Error("user.repo get_by_id: query failed", "id", id, "error", err)
+		slog.ErrorContext(ctx, "user.repo get_by_id: query failed", "id", id, "error", err)
 		return nil, err
 	}
 	return &u, nil
@@ -139,12 +159,32 @@ func (r *PostgresRepository) GetByRole(ctx context.Context, role string) (*User,
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
-		slog.Error("user.repo get_by_role: query failed", "role", role, "error", err)
+		slog.ErrorContext(ctx, "user.repo get_by_role: query failed", "role", role, "error", err)
 		return nil, err
 	}
 	return &u, nil
 }
 
+func (r *PostgresRepository) ListByRole(ctx context.Context, role string) ([]User, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+userColumns+` FROM users WHERE role = $1 ORDER BY id`, role)
+	if err != nil {
+		slog.ErrorContext(ctx, "user.repo list_by_role: query failed", "role", role, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
 func (r *PostgresRepository) Create(ctx context.Context, u *User) (*User, error) {
 	created, err := scanUser(r.db.QueryRow(ctx,
 		`INSERT INTO users (guest_id, role, uracf, phone)
@@ -152,7 +192,7 @@ func (r *PostgresRepository) Create(ctx context.Context, u *User) (*User, error)
 		 RETURNING `+userColumns,
 		u.GuestID, u.Role, u.URACF, u.Phone))
 	if err != nil {
-		slog.Error("user.repo create: insert failed", "uracf", u.URACF, "error", err)
+		slog.ErrorContext(ctx, "user.repo create: insert failed", "uracf", u.URACF, "error", err)
 		return nil, err
 	}
 	return &created, nil
@@ -161,11 +201,29 @@ func (r *PostgresRepository) Create(ctx context.Context, u *User) (*User, error)
 func (r *PostgresRepository) UnlinkGuestID(ctx context.Context, guestID int64) error {
 	_, err := r.db.Exec(ctx, `UPDATE users SET guest_id = NULL, updated_at = now() WHERE guest_id = $1`, guestID)
 	if err != nil {
-		slog.Error("user.repo unlink_guest_id: update failed", "guest_id", guestID, "error", err)
+		slog.ErrorContext(ctx, "user.repo unlink_guest_id: update failed", "guest_id", guestID, "error", err)
 	}
 	return err
 }
 
+// RelinkGuestID moves the users of fromGuestID to toGuestID and returns
+// their ids.
+func (r *PostgresRepository) RelinkGuestID(ctx context.Context, fromGuestID, toGuestID int64) ([]int64, error) {
+	rows, err := r.db.Query(ctx,
+		`UPDATE users SET guest_id = $2, updated_at = now() WHERE guest_id = $1 RETURNING id`,
+		fromGuestID, toGuestID)
+	if err != nil {
+		slog.ErrorContext(ctx, "user.repo relink_guest_id: update failed", "from_guest_id", fromGuestID, "to_guest_id", toGuestID, "error", err)
+		return nil, err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		slog.ErrorContext(ctx, "user.repo relink_guest_id: scan failed", "from_guest_id", fromGuestID, "error", err)
+		return nil, err
+	}
+	return ids, nil
+}
+
 func (r *PostgresRepository) Update(ctx context.Context, id int64, input UpdateInput) (*User, error) {
 	u, err := scanUser(r.db.QueryRow(ctx,
 		`UPDATE users SET
@@ -178,7 +236,7 @@ func (r *PostgresRepository) Update(ctx context.Context, id int64, input UpdateI
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
-		slog.Error("user.repo update: update failed", "id", id, "error", err)
+		slog.ErrorContext(ctx, "user.repo update: update failed", "id", id, "error", err)
 		return nil, err
 	}
 	return &u, nil
@@ -187,7 +245,7 @@ func (r *PostgresRepository) Update(ctx context.Context, id int64, input UpdateI
 func (r *PostgresRepository) Delete(ctx context.Context, id int64) error {
 	result, err := r.db.Exec(ctx, `DELETE FROM users WHERE id = $1`, id)
 	if err != nil {
-		slog.Error("user.repo delete: delete failed", "id", id, "error", err)
+		slog.ErrorContext(ctx, "user.repo delete: delete failed", "id", id, "error", err)
 		return err
 	}
 	if result.RowsAffected() == 0 {
@@ -202,17 +260,7 @@ func (r *PostgresRepository) UpdateLastLogin(ctx context.Context, userID int64)
 	return err
 }
 
+// LogAction appends to the hash-chained audit_log (see audit.Append).
 func (r *PostgresRepository) LogAction(ctx context.Context, userID int64, action string, details map[string]any) error {
-	var detailsJSON []byte
-	if details != nil {
-		var err error
-		detailsJSON, err = json.Marshal(details)
-		if err != nil {
-			return err
-		}
-	}
-	_, err := r.db.Exec(ctx,
-		`INSERT INTO audit_log (user_id, action, details) VALUES ($1, $2, $3)`,
-		userID, action, detailsJSON)
-	return err
+	return audit.Append(ctx, r.db, userID, action, details)
 }
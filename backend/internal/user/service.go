@@ -9,10 +9,17 @@ import (
 	"github.com/jackc/pgx/v5"
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/audit"
 	"github.com/ferjunior7/parasempre/backend/internal/guest"
 	"github.com/ferjunior7/parasempre/backend/internal/validate"
 )
 
+const (
+	auditUserCreated = "user.created"
+	auditUserUpdated = "user.updated"
+	auditUserDeleted = "user.deleted"
+)
+
 type TxAwareRepository interface {
 	Repository
 	WithTx(tx pgx.Tx) Repository
@@ -39,7 +46,7 @@ func (s *Service) Register(ctx context.Context, input RegisterInput) (*User, err
 
 	existing, err := s.repo.GetByPhone(ctx, input.Phone)
 	if err != nil {
-		slog.Error("user.service register: user lookup failed", "phone", input.Phone, "error", err)
+		slog.ErrorContext(ctx, "user.service register: user lookup failed", "phone", input.Phone, "error", err)
 		return nil, apperror.Internal("failed to lookup user", err)
 	}
 	if existing != nil {
@@ -48,7 +55,7 @@ func (s *Service) Register(ctx context.Context, input RegisterInput) (*User, err
 
 	existingByURACF, err := s.repo.GetByURACF(ctx, input.URACF)
 	if err != nil {
-		slog.Error("user.service register: uracf lookup failed", "uracf", input.URACF, "error", err)
+		slog.ErrorContext(ctx, "user.service register: uracf lookup failed", "uracf", input.URACF, "error", err)
 		return nil, apperror.Internal("failed to check uracf", err)
 	}
 	if existingByURACF != nil {
@@ -63,13 +70,55 @@ func (s *Service) Register(ctx context.Context, input RegisterInput) (*User, err
 
 	created, err := s.repo.Create(ctx, u)
 	if err != nil {
-		slog.Error("user.service register: create failed", "uracf", input.URACF, "error", err)
+		slog.ErrorContext(ctx, "user.service register: create failed", "uracf", input.URACF, "error", err)
+		return nil, apperror.Internal("failed to create user", err)
+	}
+	slog.InfoContext(ctx, "user.service register: user created", "id", created.ID)
+	return created, nil
+}
+
+// CreateStaff registers a reception desk user. Staff have no guest and their
+// sessions reach the check-in endpoints only.
+func (s *Service) CreateStaff(ctx context.Context, input StaffInput) (*User, error) {
+	if err := validate.Struct(input); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetByPhone(ctx, input.Phone)
+	if err != nil {
+		slog.ErrorContext(ctx, "user.service create_staff: phone lookup failed", "phone", input.Phone, "error", err)
+		return nil, apperror.Internal("failed to check phone", err)
+	}
+	if existing != nil {
+		return nil, apperror.Conflict("phone already in use")
+	}
+
+	uracf, err := GenerateURACF()
+	if err != nil {
+		slog.ErrorContext(ctx, "user.service create_staff: uracf generation failed", "error", err)
+		return nil, apperror.Internal("failed to generate uracf", err)
+	}
+	created, err := s.repo.Create(ctx, &User{Role: "staff", URACF: uracf, Phone: &input.Phone})
+	if err != nil {
+		slog.ErrorContext(ctx, "user.service create_staff: create failed", "uracf", uracf, "error", err)
 		return nil, apperror.Internal("failed to create user", err)
 	}
-	slog.Info("user.service register: user created", "id", created.ID)
+
+	audit.Record(ctx, s.repo, auditUserCreated, audit.Entity(audit.EntityUser, created.ID, map[string]any{
+		"changes": audit.Diff(nil, created, "updated_at", "last_login_at"),
+	}))
+	slog.InfoContext(ctx, "user.service create_staff: staff user created", "id", created.ID)
 	return created, nil
 }
 
+func (s *Service) ListStaff(ctx context.Context) ([]User, error) {
+	users, err := s.repo.ListByRole(ctx, "staff")
+	if err != nil {
+		return nil, apperror.Internal("failed to list staff", err)
+	}
+	return users, nil
+}
+
 func (s *Service) List(ctx context.Context) ([]UserListItem, error) {
 	items, err := s.repo.List(ctx)
 	if err != nil {
@@ -81,7 +130,7 @@ func (s *Service) List(ctx context.Context) ([]UserListItem, error) {
 func (s *Service) GetMe(ctx context.Context, uracf string) (*User, error) {
 	u, err := s.repo.GetByURACF(ctx, uracf)
 	if err != nil {
-		slog.Error("user.service get_me: lookup failed", "uracf", uracf, "error", err)
+		slog.ErrorContext(ctx, "user.service get_me: lookup failed", "uracf", uracf, "error", err)
 		return nil, apperror.Internal("failed to get user", err)
 	}
 	return u, nil
@@ -90,7 +139,7 @@ func (s *Service) GetMe(ctx context.Context, uracf string) (*User, error) {
 func (s *Service) GetMeDetailed(ctx context.Context, uracf string) (*MeResponse, error) {
 	me, err := s.repo.GetMeByURACF(ctx, uracf)
 	if err != nil {
-		slog.Error("user.service get_me_detailed: lookup failed", "uracf", uracf, "error", err)
+		slog.ErrorContext(ctx, "user.service get_me_detailed: lookup failed", "uracf", uracf, "error", err)
 		return nil, apperror.Internal("failed to get user", err)
 	}
 	return me, nil
@@ -106,7 +155,7 @@ func (s *Service) CheckByPhone(ctx context.Context, phone string) (*CheckRespons
 
 	u, err := s.repo.GetByPhone(ctx, phone)
 	if err != nil {
-		slog.Error("user.service check: user lookup failed", "phone", phone, "error", err)
+		slog.ErrorContext(ctx, "user.service check: user lookup failed", "phone", phone, "error", err)
 		return nil, apperror.Internal("failed to check phone", err)
 	}
 	if u != nil {
@@ -119,7 +168,7 @@ func (s *Service) CheckByPhone(ctx context.Context, phone string) (*CheckRespons
 func (s *Service) FindByURACF(ctx context.Context, uracf string) (int64, string, string, error) {
 	u, err := s.repo.GetByURACF(ctx, uracf)
 	if err != nil {
-		slog.Error("user.service find_by_uracf: lookup failed", "uracf", uracf, "error", err)
+		slog.ErrorContext(ctx, "user.service find_by_uracf: lookup failed", "uracf", uracf, "error", err)
 		return 0, "", "", apperror.Internal("failed to find user", err)
 	}
 	if u == nil {
@@ -131,7 +180,7 @@ func (s *Service) FindByURACF(ctx context.Context, uracf string) (int64, string,
 func (s *Service) FindOrCreateByPhone(ctx context.Context, phone string) (int64, string, string, error) {
 	u, err := s.repo.GetByPhone(ctx, phone)
 	if err != nil {
-		slog.Error("user.service find_or_create: user lookup failed", "phone", phone, "error", err)
+		slog.ErrorContext(ctx, "user.service find_or_create: user lookup failed", "phone", phone, "error", err)
 		return 0, "", "", apperror.Internal("failed to find user", err)
 	}
 	if u != nil {
@@ -192,17 +241,17 @@ func (s *Service) PhoneExists(ctx context.Context, phone string) (bool, error) {
 
 func (s *Service) RecordLogin(ctx context.Context, userID int64) {
 	if err := s.repo.UpdateLastLogin(ctx, userID); err != nil {
-		slog.Error("user.service record_login: update last_login failed", "user_id", userID, "error", err)
+		slog.ErrorContext(ctx, "user.service record_login: update last_login failed", "user_id", userID, "error", err)
 	}
 	if err := s.repo.LogAction(ctx, userID, "login", nil); err != nil {
-		slog.Error("user.service record_login: log action failed", "user_id", userID, "error", err)
+		slog.ErrorContext(ctx, "user.service record_login: log action failed", "user_id", userID, "error", err)
 	}
 }
 
 func (s *Service) CreateGuestUserTx(ctx context.Context, tx pgx.Tx, guestID int64, phone *string) error {
 	uracf, err := GenerateURACF()
 	if err != nil {
-		slog.Error("user.service create_guest_user: uracf generation failed", "error", err)
+		slog.ErrorContext(ctx, "user.service create_guest_user: uracf generation failed", "error", err)
 		return apperror.Internal("failed to generate uracf", err)
 	}
 
@@ -215,24 +264,38 @@ func (s *Service) CreateGuestUserTx(ctx context.Context, tx pgx.Tx, guestID int6
 
 	txRepo := s.txRepo.WithTx(tx)
 	if _, err := txRepo.Create(ctx, u); err != nil {
-		slog.Error("user.service create_guest_user: create failed", "guest_id", guestID, "error", err)
+		slog.ErrorContext(ctx, "user.service create_guest_user: create failed", "guest_id", guestID, "error", err)
 		return apperror.Internal("failed to create guest user", err)
 	}
 
-	slog.Info("user.service create_guest_user: user created", "guest_id", guestID, "uracf", uracf)
+	slog.InfoContext(ctx, "user.service create_guest_user: user created", "guest_id", guestID, "uracf", uracf)
 	return nil
 }
 
 func (s *Service) DeleteGuestUserTx(ctx context.Context, tx pgx.Tx, guestID int64) error {
 	txRepo := s.txRepo.WithTx(tx)
 	if err := txRepo.UnlinkGuestID(ctx, guestID); err != nil {
-		slog.Error("user.service delete_guest_user: unlink failed", "guest_id", guestID, "error", err)
+		slog.ErrorContext(ctx, "user.service delete_guest_user: unlink failed", "guest_id", guestID, "error", err)
 		return apperror.Internal("failed to unlink guest user", err)
 	}
-	slog.Info("user.service delete_guest_user: user unlinked", "guest_id", guestID)
+	slog.InfoContext(ctx, "user.service delete_guest_user: user unlinked", "guest_id", guestID)
 	return nil
 }
 
+// MoveGuestUsersTx links the users of fromGuestID to toGuestID, for guest
+// merges. Users are kept rather than merged: audit_log references them and
+// each keeps its own phone login.
+func (s *Service) MoveGuestUsersTx(ctx context.Context, tx pgx.Tx, fromGuestID, toGuestID int64) ([]int64, error) {
+	txRepo := s.txRepo.WithTx(tx)
+	ids, err := txRepo.RelinkGuestID(ctx, fromGuestID, toGuestID)
+	if err != nil {
+		slog.ErrorContext(ctx, "user.service move_guest_users: relink failed", "from_guest_id", fromGuestID, "to_guest_id", toGuestID, "error", err)
+		return nil, apperror.Internal("failed to move guest users", err)
+	}
+	slog.InfoContext(ctx, "user.service move_guest_users: users moved", "from_guest_id", fromGuestID, "to_guest_id", toGuestID, "user_ids", ids)
+	return ids, nil
+}
+
 const uracfChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 
 func GenerateURACF() (string, error) {
@@ -254,17 +317,17 @@ func (s *Service) SeedCouple(ctx context.Context, groom, bride CoupleData) {
 
 func (s *Service) seedPerson(ctx context.Context, data CoupleData, role string) {
 	if data.URACF == "" {
-		slog.Info("seed: skipping, uracf is empty", "role", role)
+		slog.InfoContext(ctx, "seed: skipping, uracf is empty", "role", role)
 		return
 	}
 
 	existingRole, err := s.repo.GetByRole(ctx, role)
 	if err != nil {
-		slog.Error("seed: failed to check existing role", "role", role, "error", err)
+		slog.ErrorContext(ctx, "seed: failed to check existing role", "role", role, "error", err)
 		return
 	}
 	if existingRole != nil {
-		slog.Info("seed: skipping, role already exists", "role", role, "existing_uracf", existingRole.URACF)
+		slog.InfoContext(ctx, "seed: skipping, role already exists", "role", role, "existing_uracf", existingRole.URACF)
 		return
 	}
 
@@ -286,10 +349,10 @@ func (s *Service) seedPerson(ctx context.Context, data CoupleData, role string)
 
 	created, err := s.repo.Create(ctx, u)
 	if err != nil {
-		slog.Error("seed: failed to create user", "role", role, "error", err)
+		slog.ErrorContext(ctx, "seed: failed to create user", "role", role, "error", err)
 		return
 	}
-	slog.Info("seed: user created", "role", role, "id", created.ID)
+	slog.InfoContext(ctx, "seed: user created", "role", role, "id", created.ID)
 }
 
 func (s *Service) Update(ctx context.Context, id int64, input UpdateInput) (*User, error) {
@@ -299,7 +362,7 @@ func (s *Service) Update(ctx context.Context, id int64, input UpdateInput) (*Use
 
 	existing, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		slog.Error("user.service update: lookup failed", "id", id, "error", err)
+		slog.ErrorContext(ctx, "user.service update: lookup failed", "id", id, "error", err)
 		return nil, apperror.Internal("failed to lookup user", err)
 	}
 	if existing == nil {
@@ -309,7 +372,7 @@ func (s *Service) Update(ctx context.Context, id int64, input UpdateInput) (*Use
 	if input.Phone != nil && *input.Phone != "" {
 		phoneUser, err := s.repo.GetByPhone(ctx, *input.Phone)
 		if err != nil {
-			slog.Error("user.service update: phone lookup failed", "phone", *input.Phone, "error", err)
+			slog.ErrorContext(ctx, "user.service update: phone lookup failed", "phone", *input.Phone, "error", err)
 			return nil, apperror.Internal("failed to check phone", err)
 		}
 		if phoneUser != nil && phoneUser.ID != id {
@@ -320,7 +383,7 @@ func (s *Service) Update(ctx context.Context, id int64, input UpdateInput) (*Use
 	if input.Role != nil && (*input.Role == "groom" || *input.Role == "bride") {
 		existingRole, err := s.repo.GetByRole(ctx, *input.Role)
 		if err != nil {
-			slog.Error("user.service update: role lookup failed", "role", *input.Role, "error", err)
+			slog.ErrorContext(ctx, "user.service update: role lookup failed", "role", *input.Role, "error", err)
 			return nil, apperror.Internal("failed to check role", err)
 		}
 		if existingRole != nil && existingRole.ID != id {
@@ -330,18 +393,21 @@ func (s *Service) Update(ctx context.Context, id int64, input UpdateInput) (*Use
 
 	updated, err := s.repo.Update(ctx, id, input)
 	if err != nil {
-		slog.Error("user.service update: update failed", "id", id, "error", err)
+		slog.ErrorContext(ctx, "user.service update: update failed", "id", id, "error", err)
 		return nil, apperror.Internal("failed to update user", err)
 	}
 
-	slog.Info("user.service update: user updated", "id", id)
+	audit.Record(ctx, s.repo, auditUserUpdated, audit.Entity(audit.EntityUser, id, map[string]any{
+		"changes": audit.Diff(existing, updated, "updated_at", "last_login_at"),
+	}))
+	slog.InfoContext(ctx, "user.service update: user updated", "id", id)
 	return updated, nil
 }
 
 func (s *Service) Delete(ctx context.Context, id int64) error {
 	existing, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		slog.Error("user.service delete: lookup failed", "id", id, "error", err)
+		slog.ErrorContext(ctx, "user.service delete: lookup failed", "id", id, "error", err)
 		return apperror.Internal("failed to lookup user", err)
 	}
 	if existing == nil {
@@ -353,10 +419,13 @@ func (s *Service) Delete(ctx context.Context, id int64) error {
 	}
 
 	if err := s.repo.Delete(ctx, id); err != nil {
-		slog.Error("user.service delete: delete failed", "id", id, "error", err)
+		slog.ErrorContext(ctx, "user.service delete: delete failed", "id", id, "error", err)
 		return apperror.Internal("failed to delete user", err)
 	}
 
-	slog.Info("user.service delete: user deleted", "id", id)
+	audit.Record(ctx, s.repo, auditUserDeleted, audit.Entity(audit.EntityUser, id, map[string]any{
+		"changes": audit.Diff(existing, nil, "updated_at", "last_login_at"),
+	}))
+	slog.InfoContext(ctx, "user.service delete: user deleted", "id", id)
 	return nil
 }
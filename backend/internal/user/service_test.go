@@ -11,7 +11,10 @@ import (
 	"github.com/jackc/pgx/v5"
 
 	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/audit"
 	"github.com/ferjunior7/parasempre/backend/internal/guest"
+	"github.com/ferjunior7/parasempre/backend/internal/pagination"
+	"github.com/ferjunior7/parasempre/backend/internal/reqctx"
 )
 
 type mockUserRepo struct {
@@ -20,6 +23,7 @@ type mockUserRepo struct {
 	getByPhone      func(ctx context.Context, phone string) (*User, error)
 	getByID         func(ctx context.Context, id int64) (*User, error)
 	getByRole       func(ctx context.Context, role string) (*User, error)
+	listByRole      func(ctx context.Context, role string) ([]User, error)
 	getMeByURACF    func(ctx context.Context, uracf string) (*MeResponse, error)
 	createFn        func(ctx context.Context, u *User) (*User, error)
 	updateFn        func(ctx context.Context, id int64, input UpdateInput) (*User, error)
@@ -79,6 +83,13 @@ func (m *mockUserRepo) GetByRole(ctx context.Context, role string) (*User, error
 	return nil, nil
 }
 
+func (m *mockUserRepo) ListByRole(ctx context.Context, role string) ([]User, error) {
+	if m.listByRole != nil {
+		return m.listByRole(ctx, role)
+	}
+	return []User{}, nil
+}
+
 func (m *mockUserRepo) GetMeByURACF(ctx context.Context, uracf string) (*MeResponse, error) {
 	if m.getMeByURACF != nil {
 		return m.getMeByURACF(ctx, uracf)
@@ -104,6 +115,10 @@ func (m *mockUserRepo) UnlinkGuestID(ctx context.Context, guestID int64) error {
 	return nil
 }
 
+func (m *mockUserRepo) RelinkGuestID(ctx context.Context, fromGuestID, toGuestID int64) ([]int64, error) {
+	return nil, nil
+}
+
 func (m *mockUserRepo) LogAction(ctx context.Context, userID int64, action string, details map[string]any) error {
 	if m.logAction != nil {
 		return m.logAction(ctx, userID, action, details)
@@ -116,14 +131,14 @@ func (m *mockUserRepo) WithTx(_ pgx.Tx) Repository {
 }
 
 type mockGuestRepo struct {
-	listFn                      func(ctx context.Context, limit, offset int, filters guest.ListFilters) ([]guest.Guest, int, error)
+	listFn                      func(ctx context.Context, req pagination.Request, filters guest.ListFilters) ([]guest.Guest, pagination.Meta, error)
 	statsFn                     func(ctx context.Context) (guest.Stats, error)
 	listByFamilyGroupFn         func(ctx context.Context, familyGroup int64) ([]guest.Guest, error)
 	getByIDAnyFn                func(ctx context.Context, id int64) (*guest.Guest, error)
 	getByIDsFn                  func(ctx context.Context, ids []int64) ([]guest.Guest, error)
 	getByNameFn                 func(ctx context.Context, firstName, lastName string) (*guest.Guest, error)
 	familyGroupExistsFn         func(ctx context.Context, familyGroup int64) (bool, error)
-	getNextFamilyGroupFn        func(ctx context.Context) (int64, error)
+	createHouseholdFn           func(ctx context.Context, name, userRACF string) (int64, error)
 	createFn                    func(ctx context.Context, input guest.CreateGuestInput, userRACF string) (*guest.Guest, error)
 	updateFn                    func(ctx context.Context, id int64, input guest.UpdateGuestInput, userRACF string) (*guest.Guest, error)
 	deleteFn                    func(ctx context.Context, id int64) error
@@ -133,11 +148,11 @@ type mockGuestRepo struct {
 	getFamilyGroupByPhoneFn     func(ctx context.Context, phone string) (*int64, error)
 }
 
-func (m *mockGuestRepo) List(ctx context.Context, limit, offset int, filters guest.ListFilters) ([]guest.Guest, int, error) {
+func (m *mockGuestRepo) List(ctx context.Context, req pagination.Request, filters guest.ListFilters) ([]guest.Guest, pagination.Meta, error) {
 	if m.listFn != nil {
-		return m.listFn(ctx, limit, offset, filters)
+		return m.listFn(ctx, req, filters)
 	}
-	return []guest.Guest{}, 0, nil
+	return []guest.Guest{}, pagination.Meta{}, nil
 }
 
 func (m *mockGuestRepo) Stats(ctx context.Context) (guest.Stats, error) {
@@ -189,9 +204,9 @@ func (m *mockGuestRepo) FamilyGroupExists(ctx context.Context, familyGroup int64
 	return true, nil
 }
 
-func (m *mockGuestRepo) GetNextFamilyGroup(ctx context.Context) (int64, error) {
-	if m.getNextFamilyGroupFn != nil {
-		return m.getNextFamilyGroupFn(ctx)
+func (m *mockGuestRepo) CreateHousehold(ctx context.Context, name, userRACF string) (int64, error) {
+	if m.createHouseholdFn != nil {
+		return m.createHouseholdFn(ctx, name, userRACF)
 	}
 	return 1, nil
 }
@@ -238,6 +253,18 @@ func (m *mockGuestRepo) GetFamilyGroupByPhone(ctx context.Context, phone string)
 	return nil, nil
 }
 
+func (m *mockGuestRepo) FindDuplicates(ctx context.Context, minSimilarity float64, limit int) ([]guest.DuplicateCandidate, error) {
+	return nil, nil
+}
+
+func (m *mockGuestRepo) ActivityCounts(ctx context.Context, userIDs []int64) (int, int, error) {
+	return 0, 0, nil
+}
+
+func (m *mockGuestRepo) CopyCheckIn(ctx context.Context, id, fromID int64) (*guest.Guest, error) {
+	return nil, nil
+}
+
 func (m *mockGuestRepo) WithTx(_ pgx.Tx) guest.Repository {
 	return m
 }
@@ -832,3 +859,106 @@ func TestGenerateURACF(t *testing.T) {
 		t.Fatalf("expected at least 50 unique URACFs in 100 generations, got %d", len(seen))
 	}
 }
+
+func TestServiceUpdateRecordsAudit(t *testing.T) {
+	existing := &User{ID: 5, Role: "guest", URACF: "ABC12", CreatedAt: time.Now()}
+	var gotActor int64
+	var gotAction string
+	var gotDetails map[string]any
+	userRepo := &mockUserRepo{
+		getByID: func(ctx context.Context, id int64) (*User, error) {
+			return existing, nil
+		},
+		updateFn: func(ctx context.Context, id int64, input UpdateInput) (*User, error) {
+			u := *existing
+			u.Phone = input.Phone
+			u.UpdatedAt = time.Now()
+			return &u, nil
+		},
+		logAction: func(ctx context.Context, userID int64, action string, details map[string]any) error {
+			gotActor, gotAction, gotDetails = userID, action, details
+			return nil
+		},
+	}
+
+	svc := NewService(userRepo, &mockGuestRepo{})
+	ctx := reqctx.WithUserID(context.Background(), 1)
+	phone := "11987654321"
+	if _, err := svc.Update(ctx, 5, UpdateInput{Phone: &phone}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotActor != 1 || gotAction != auditUserUpdated {
+		t.Fatalf("expected user.updated by 1, got %q by %d", gotAction, gotActor)
+	}
+	if gotDetails["entity_type"] != audit.EntityUser || gotDetails["entity_id"] != int64(5) {
+		t.Errorf("expected user entity tags, got %v", gotDetails)
+	}
+	changes := gotDetails["changes"].(map[string]audit.Change)
+	if len(changes) != 1 || changes["phone"].After != "11987654321" {
+		t.Errorf("expected only phone change, got %v", changes)
+	}
+}
+
+func TestServiceDeleteRecordsAudit(t *testing.T) {
+	var gotAction string
+	userRepo := &mockUserRepo{
+		getByID: func(ctx context.Context, id int64) (*User, error) {
+			return &User{ID: id, Role: "guest", URACF: "ABC12"}, nil
+		},
+		logAction: func(ctx context.Context, userID int64, action string, details map[string]any) error {
+			gotAction = action
+			return nil
+		},
+	}
+
+	svc := NewService(userRepo, &mockGuestRepo{})
+	if err := svc.Delete(reqctx.WithUserID(context.Background(), 1), 5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotAction != auditUserDeleted {
+		t.Fatalf("expected user.deleted audit, got %q", gotAction)
+	}
+}
+
+func TestServiceCreateStaff(t *testing.T) {
+	var created *User
+	var gotAction string
+	userRepo := &mockUserRepo{
+		getByPhone: func(ctx context.Context, phone string) (*User, error) {
+			if phone == "11977776666" {
+				return &User{ID: 3, Role: "guest"}, nil
+			}
+			return nil, nil
+		},
+		createFn: func(ctx context.Context, u *User) (*User, error) {
+			c := *u
+			c.ID = 9
+			created = &c
+			return created, nil
+		},
+		logAction: func(ctx context.Context, userID int64, action string, details map[string]any) error {
+			gotAction = action
+			return nil
+		},
+	}
+
+	svc := NewService(userRepo, &mockGuestRepo{})
+	ctx := reqctx.WithUserID(context.Background(), 1)
+	u, err := svc.CreateStaff(ctx, StaffInput{Phone: "11955554444"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if u.Role != "staff" || u.GuestID != nil || len(u.URACF) != 5 || *u.Phone != "11955554444" {
+		t.Fatalf("expected a staff user without a guest, got %+v", u)
+	}
+	if gotAction != auditUserCreated {
+		t.Fatalf("expected user.created audit, got %q", gotAction)
+	}
+
+	_, err = svc.CreateStaff(ctx, StaffInput{Phone: "11977776666"})
+	var appErr *apperror.AppError
+	if !errors.As(err, &appErr) || appErr.Code != http.StatusConflict {
+		t.Fatalf("expected conflict for a phone in use, got %v", err)
+	}
+}
@@ -0,0 +1,333 @@
+package wall
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ferjunior7/parasempre/backend/internal/apperror"
+	"github.com/ferjunior7/parasempre/backend/internal/httputil"
+	"github.com/ferjunior7/parasempre/backend/internal/media"
+)
+
+const (
+	// StreamRoute is where HandleStream is mounted.
+	StreamRoute = "/api/wall/stream"
+
+	heartbeatInterval = 15 * time.Second
+	retryMillis       = 3000
+	historySize       = 50  // events replayed in slideshow mode
+	maxResume         = 500 // events replayed after a reconnect
+	pollBatch         = 100
+	subscriberBuffer  = 64
+	maxListenBackoff  = 30 * time.Second
+)
+
+// Signer is the part of giftmessage.Storage the wall needs.
+type Signer interface {
+	SignURLs(ctx context.Context, keys []string, ttl time.Duration) (map[string]string, error)
+}
+
+// Hub fans wall events out to every connected screen. It LISTENs on
+// Channel and, on each notification, reads the new rows from wall_events,
+// so a missed notification only delays events until the next one. Reading
+// by id > lastID relies on publishers committing in id order (PublishLock).
+type Hub struct {
+	repo      Repository
+	signer    Signer
+	ttl       time.Duration
+	heartbeat time.Duration
+
+	pollMu sync.Mutex
+	lastID int64
+
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+// NewHub builds a hub; signer may be nil when media storage is disabled.
+// Media URLs are signed for ttl, which should outlast a slideshow loop.
+func NewHub(repo Repository, signer Signer, ttl time.Duration) *Hub {
+	return &Hub{
+		repo:      repo,
+		signer:    signer,
+		ttl:       ttl,
+		heartbeat: heartbeatInterval,
+		subs:      make(map[chan Event]struct{}),
+	}
+}
+
+// Listen keeps a dedicated connection LISTENing on Channel until ctx is
+// cancelled, reconnecting with backoff. On return every open stream is
+// ended so server shutdown is not held up by connected screens.
+func (h *Hub) Listen(ctx context.Context, pool *pgxpool.Pool) {
+	defer h.closeAll()
+	latest, err := h.repo.LatestID(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "wall.hub listen: latest id failed", "error", err)
+	}
+	h.pollMu.Lock()
+	h.lastID = latest
+	h.pollMu.Unlock()
+
+	backoff := time.Second
+	for {
+		err := h.listenOnce(ctx, pool)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.WarnContext(ctx, "wall.hub listen: connection lost", "error", err, "retry_in", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxListenBackoff)
+	}
+}
+
+func (h *Hub) listenOnce(ctx context.Context, pool *pgxpool.Pool) error {
+	pc, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// The connection stays in LISTEN mode, so it never goes back to the pool.
+	conn := pc.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+	// Catch up on anything published while disconnected.
+	h.poll(ctx)
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+		h.poll(ctx)
+	}
+}
+
+// poll reads events newer than the last one seen and broadcasts them.
+func (h *Hub) poll(ctx context.Context) {
+	h.pollMu.Lock()
+	defer h.pollMu.Unlock()
+	for {
+		events, err := h.repo.ListAfter(ctx, h.lastID, pollBatch)
+		if err != nil {
+			slog.ErrorContext(ctx, "wall.hub poll: list failed", "after_id", h.lastID, "error", err)
+			return
+		}
+		if len(events) == 0 {
+			return
+		}
+		h.lastID = events[len(events)-1].ID
+		h.broadcast(events)
+		if len(events) < pollBatch {
+			return
+		}
+	}
+}
+
+func (h *Hub) broadcast(events []Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+	send:
+		for _, e := range events {
+			select {
+			case ch <- e:
+			default:
+				// A screen this far behind reconnects and resumes from
+				// Last-Event-ID instead of holding events in memory.
+				delete(h.subs, ch)
+				close(ch)
+				slog.Warn("wall.hub broadcast: dropped slow subscriber")
+				break send
+			}
+		}
+	}
+}
+
+func (h *Hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		delete(h.subs, ch)
+		close(ch)
+	}
+}
+
+func (h *Hub) subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subs[ch]; ok {
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// HandleStream serves the wall as Server-Sent Events. A reconnecting
+// browser sends Last-Event-ID and gets what it missed; ?mode=slideshow
+// starts with the latest history so a freshly opened screen is not empty.
+func (h *Hub) HandleStream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	lastID, err := resumeID(r)
+	if err != nil {
+		httputil.WriteError(w, r, err)
+		return
+	}
+
+	// Subscribe before reading the backlog so nothing falls in between;
+	// duplicates are skipped by id below.
+	events, unsubscribe := h.subscribe()
+	defer unsubscribe()
+
+	var backlog []Event
+	switch {
+	case lastID > 0:
+		backlog, err = h.repo.ListAfter(ctx, lastID, maxResume)
+	case r.URL.Query().Get("mode") == "slideshow":
+		backlog, err = h.repo.ListRecent(ctx, historySize)
+	}
+	if err != nil {
+		httputil.WriteError(w, r, apperror.WrapIfNotApp("falha ao carregar mural", err))
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// The server's WriteTimeout would otherwise cut every stream short.
+	_ = rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", retryMillis)
+
+	sent := lastID
+	if err := h.writeEvents(ctx, w, backlog, &sent); err != nil {
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := h.writeEvents(ctx, w, []Event{e}, &sent); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvents writes the events newer than *sent and advances it.
+func (h *Hub) writeEvents(ctx context.Context, w io.Writer, events []Event, sent *int64) error {
+	fresh := events[:0:0]
+	for _, e := range events {
+		if e.ID > *sent {
+			fresh = append(fresh, e)
+		}
+	}
+	if len(fresh) == 0 {
+		return nil
+	}
+	urls := h.signMedia(ctx, fresh)
+	for _, e := range fresh {
+		data, err := json.Marshal(toItem(e, urls))
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Kind, data); err != nil {
+			return err
+		}
+		*sent = e.ID
+	}
+	return nil
+}
+
+func (h *Hub) signMedia(ctx context.Context, events []Event) map[string]string {
+	if h.signer == nil {
+		return nil
+	}
+	var keys []string
+	for _, e := range events {
+		for _, k := range []*string{e.Payload.MediaKey, e.Payload.ThumbKey} {
+			if k != nil {
+				keys = append(keys, *k)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	urls, err := h.signer.SignURLs(ctx, keys, h.ttl)
+	if err != nil {
+		slog.WarnContext(ctx, "wall.hub sign: failed", "error", err)
+	}
+	return urls
+}
+
+func toItem(e Event, urls map[string]string) Item {
+	return Item{
+		ID:           e.ID,
+		Kind:         e.Kind,
+		RefID:        e.RefID,
+		AuthorName:   e.Payload.AuthorName,
+		Content:      e.Payload.Content,
+		MediaKind:    e.Payload.MediaKind,
+		MediaURL:     media.SignedURL(e.Payload.MediaKey, urls),
+		ThumbnailURL: media.SignedURL(e.Payload.ThumbKey, urls),
+		GiftID:       e.Payload.GiftID,
+		GiftName:     e.Payload.GiftName,
+		BuyerName:    e.Payload.BuyerName,
+		CreatedAt:    e.CreatedAt,
+	}
+}
+
+// resumeID reads Last-Event-ID, falling back to ?last_event_id for clients
+// that cannot set headers on their first connection.
+func resumeID(r *http.Request) (int64, error) {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("last_event_id")
+	}
+	if raw == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		return 0, apperror.Validation("Last-Event-ID inválido")
+	}
+	return id, nil
+}
@@ -0,0 +1,282 @@
+package wall
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockRepo struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (m *mockRepo) add(kind string, p Payload) Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := Event{ID: int64(len(m.events) + 1), Kind: kind, RefID: int64(len(m.events) + 100), Payload: p, CreatedAt: time.Now()}
+	m.events = append(m.events, e)
+	return e
+}
+
+func (m *mockRepo) ListAfter(_ context.Context, afterID int64, limit int) ([]Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []Event
+	for _, e := range m.events {
+		if e.ID > afterID && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockRepo) ListRecent(_ context.Context, limit int) ([]Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	start := max(len(m.events)-limit, 0)
+	return append([]Event(nil), m.events[start:]...), nil
+}
+
+func (m *mockRepo) LatestID(_ context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return int64(len(m.events)), nil
+}
+
+type mockSigner struct{}
+
+func (mockSigner) SignURLs(_ context.Context, keys []string, _ time.Duration) (map[string]string, error) {
+	out := make(map[string]string, len(keys))
+	for _, k := range keys {
+		out[k] = "https://cdn.test/" + k
+	}
+	return out, nil
+}
+
+type frame struct {
+	id, event, data string
+	comment         bool
+}
+
+// openStream connects to the hub and returns a channel of parsed SSE frames.
+func openStream(t *testing.T, h *Hub, query string, header http.Header) <-chan frame {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(h.HandleStream))
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		srv.Close()
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+StreamRoute+query, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %q", ct)
+	}
+
+	frames := make(chan frame, 32)
+	go func() {
+		defer resp.Body.Close()
+		defer close(frames)
+		sc := bufio.NewScanner(resp.Body)
+		var f frame
+		for sc.Scan() {
+			line := sc.Text()
+			switch {
+			case line == "":
+				if f != (frame{}) {
+					frames <- f
+				}
+				f = frame{}
+			case strings.HasPrefix(line, ":"):
+				f.comment = true
+			case strings.HasPrefix(line, "id: "):
+				f.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				f.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				f.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	return frames
+}
+
+// nextEvent skips retry/heartbeat frames and returns the next event frame.
+func nextEvent(t *testing.T, frames <-chan frame) frame {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case f, ok := <-frames:
+			if !ok {
+				t.Fatal("stream closed")
+			}
+			if f.id != "" {
+				return f
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for event")
+		}
+	}
+}
+
+// waitSubscribed blocks until n streams are registered with the hub.
+func waitSubscribed(t *testing.T, h *Hub, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		h.mu.Lock()
+		got := len(h.subs)
+		h.mu.Unlock()
+		if got == n {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("expected %d subscribers", n)
+}
+
+func TestHandleStream_BroadcastsPolledEvents(t *testing.T) {
+	repo := &mockRepo{}
+	h := NewHub(repo, mockSigner{}, time.Hour)
+	frames := openStream(t, h, "", nil)
+	waitSubscribed(t, h, 1)
+
+	key := "messages/1/a.jpg"
+	kind := "image"
+	repo.add(KindGiftMessage, Payload{AuthorName: "Ana", Content: "Felicidades!", MediaKind: &kind, MediaKey: &key})
+	h.poll(context.Background())
+
+	f := nextEvent(t, frames)
+	if f.id != "1" || f.event != KindGiftMessage {
+		t.Fatalf("unexpected frame %+v", f)
+	}
+	var item Item
+	if err := json.Unmarshal([]byte(f.data), &item); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if item.AuthorName != "Ana" || item.MediaURL == nil || *item.MediaURL != "https://cdn.test/"+key {
+		t.Errorf("unexpected item %+v", item)
+	}
+	if strings.Contains(f.data, "media_key") {
+		t.Errorf("storage key leaked to the wall: %s", f.data)
+	}
+}
+
+func TestHandleStream_ResumesFromLastEventID(t *testing.T) {
+	repo := &mockRepo{}
+	for range 3 {
+		repo.add(KindGuestbook, Payload{AuthorName: "Bia", Content: "Oi"})
+	}
+	h := NewHub(repo, nil, time.Hour)
+	frames := openStream(t, h, "", http.Header{"Last-Event-Id": {"1"}})
+
+	if f := nextEvent(t, frames); f.id != "2" {
+		t.Fatalf("expected resume at id 2, got %+v", f)
+	}
+	if f := nextEvent(t, frames); f.id != "3" {
+		t.Fatalf("expected id 3, got %+v", f)
+	}
+
+	// A live broadcast of an event already replayed is not sent twice.
+	h.broadcast(repo.events[2:])
+	repo.add(KindPurchase, Payload{GiftName: "Panela", BuyerName: "Caio"})
+	h.broadcast(repo.events[3:])
+	if f := nextEvent(t, frames); f.id != "4" || f.event != KindPurchase {
+		t.Fatalf("expected purchase id 4, got %+v", f)
+	}
+}
+
+func TestHandleStream_SlideshowReplaysHistory(t *testing.T) {
+	repo := &mockRepo{}
+	for range historySize + 5 {
+		repo.add(KindGuestbook, Payload{AuthorName: "Bia", Content: "Oi"})
+	}
+	h := NewHub(repo, nil, time.Hour)
+	frames := openStream(t, h, "?mode=slideshow", nil)
+
+	if f := nextEvent(t, frames); f.id != "6" {
+		t.Fatalf("expected slideshow to start at the %d newest, got %+v", historySize, f)
+	}
+}
+
+func TestHandleStream_NoHistoryByDefault(t *testing.T) {
+	repo := &mockRepo{}
+	repo.add(KindGuestbook, Payload{AuthorName: "Bia", Content: "Oi"})
+	h := NewHub(repo, nil, time.Hour)
+	h.heartbeat = 20 * time.Millisecond
+	frames := openStream(t, h, "", nil)
+
+	select {
+	case f := <-frames:
+		if f.id != "" {
+			t.Fatalf("expected no replay without slideshow mode, got %+v", f)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+func TestHandleStream_SendsHeartbeats(t *testing.T) {
+	h := NewHub(&mockRepo{}, nil, time.Hour)
+	h.heartbeat = 20 * time.Millisecond
+	frames := openStream(t, h, "", nil)
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case f := <-frames:
+			if f.comment {
+				return
+			}
+		case <-timeout:
+			t.Fatal("no heartbeat received")
+		}
+	}
+}
+
+func TestHandleStream_RejectsInvalidLastEventID(t *testing.T) {
+	h := NewHub(&mockRepo{}, nil, time.Hour)
+	r := httptest.NewRequest(http.MethodGet, StreamRoute+"?last_event_id=abc", nil)
+	w := httptest.NewRecorder()
+	h.HandleStream(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestCloseAll_EndsStreams(t *testing.T) {
+	h := NewHub(&mockRepo{}, nil, time.Hour)
+	frames := openStream(t, h, "", nil)
+	waitSubscribed(t, h, 1)
+
+	h.closeAll()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case _, ok := <-frames:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("stream not closed")
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package wall
+
+import "time"
+
+// Channel is the Postgres NOTIFY channel; the payload is the wall_events id.
+const Channel = "wall_events"
+
+// PublishLock is the first CTE of every insert into wall_events, whose
+// SELECT must also read FROM wall_lock. The advisory lock is taken before
+// an id is drawn and held until commit, so events become visible in id
+// order and tailing by id (the hub, Last-Event-ID) never skips one that
+// committed late.
+const PublishLock = `wall_lock AS (SELECT pg_advisory_xact_lock(hashtext('wall_events')))`
+
+const (
+	KindGiftMessage = "gift_message"
+	KindGuestbook   = "guestbook"
+	KindPurchase    = "purchase"
+)
+
+// Payload is what the publishing repositories store in wall_events.payload.
+// Media keys stay server-side; the hub swaps them for signed URLs.
+type Payload struct {
+	AuthorName string  `json:"author_name"`
+	Content    string  `json:"content"`
+	MediaKind  *string `json:"media_kind"`
+	MediaKey   *string `json:"media_key"`
+	ThumbKey   *string `json:"thumb_key"`
+	GiftID     *int64  `json:"gift_id"`
+	GiftName   string  `json:"gift_name"`
+	BuyerName  string  `json:"buyer_name"`
+}
+
+type Event struct {
+	ID        int64
+	Kind      string
+	RefID     int64
+	Payload   Payload
+	CreatedAt time.Time
+}
+
+// Item is an Event as sent to the screen.
+type Item struct {
+	ID           int64     `json:"id"`
+	Kind         string    `json:"kind"`
+	RefID        int64     `json:"ref_id"`
+	AuthorName   string    `json:"author_name,omitempty"`
+	Content      string    `json:"content,omitempty"`
+	MediaKind    *string   `json:"media_kind,omitempty"`
+	MediaURL     *string   `json:"media_url,omitempty"`
+	ThumbnailURL *string   `json:"thumbnail_url,omitempty"`
+	GiftID       *int64    `json:"gift_id,omitempty"`
+	GiftName     string    `json:"gift_name,omitempty"`
+	BuyerName    string    `json:"buyer_name,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,13 @@
+package wall
+
+import "context"
+
+// Repository reads wall_events. Events whose source was since rejected,
+// deleted or refunded are left out.
+type Repository interface {
+	// ListAfter returns up to limit events with id > afterID, oldest first.
+	ListAfter(ctx context.Context, afterID int64, limit int) ([]Event, error)
+	// ListRecent returns the newest limit events, oldest first.
+	ListRecent(ctx context.Context, limit int) ([]Event, error)
+	LatestID(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,83 @@
+package wall
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ferjunior7/parasempre/backend/internal/database"
+)
+
+const eventColumns = `e.id, e.kind, e.ref_id, e.payload, e.created_at`
+
+// visibleEvent hides events whose source no longer belongs on the wall.
+const visibleEvent = `
+	(e.kind <> 'gift_message' OR EXISTS (
+		SELECT 1 FROM gift_messages m
+		 WHERE m.id = e.ref_id AND m.status = 'approved' AND m.deleted_at IS NULL))
+	AND (e.kind <> 'guestbook' OR EXISTS (
+		SELECT 1 FROM guestbook_entries g
+		 WHERE g.id = e.ref_id AND g.status = 'approved' AND g.deleted_at IS NULL))
+	AND (e.kind <> 'purchase' OR EXISTS (
+		SELECT 1 FROM gift_transactions t
+		 WHERE t.id = e.ref_id AND t.status = 'approved'))`
+
+type PostgresRepository struct {
+	db database.DBTX
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{db: pool}
+}
+
+func (r *PostgresRepository) ListAfter(ctx context.Context, afterID int64, limit int) ([]Event, error) {
+	return r.list(ctx, "list_after",
+		`SELECT `+eventColumns+`
+		   FROM wall_events e
+		  WHERE e.id > $1 AND `+visibleEvent+`
+		  ORDER BY e.id ASC
+		  LIMIT $2`,
+		afterID, limit)
+}
+
+func (r *PostgresRepository) ListRecent(ctx context.Context, limit int) ([]Event, error) {
+	return r.list(ctx, "list_recent",
+		`SELECT * FROM (
+		    SELECT `+eventColumns+`
+		      FROM wall_events e
+		     WHERE `+visibleEvent+`
+		     ORDER BY e.id DESC
+		     LIMIT $1
+		 ) recent
+		 ORDER BY id ASC`,
+		limit)
+}
+
+func (r *PostgresRepository) LatestID(ctx context.Context) (int64, error) {
+	var id int64
+	if err := r.db.QueryRow(ctx, `SELECT COALESCE(MAX(id), 0) FROM wall_events`).Scan(&id); err != nil {
+		slog.ErrorContext(ctx, "wall.repo latest_id: query failed", "error", err)
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *PostgresRepository) list(ctx context.Context, op, query string, args ...any) ([]Event, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "wall.repo "+op+": query failed", "error", err)
+		return nil, err
+	}
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Event, error) {
+		var e Event
+		err := row.Scan(&e.ID, &e.Kind, &e.RefID, &e.Payload, &e.CreatedAt)
+		return e, err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "wall.repo "+op+": scan failed", "error", err)
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS rate_limits (
+    limiter TEXT NOT NULL,
+    key TEXT NOT NULL,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    PRIMARY KEY (limiter, key)
+);
+
+CREATE INDEX IF NOT EXISTS rate_limits_updated_at_idx ON rate_limits (updated_at);
+
+ALTER TABLE rate_limits ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,20 @@
+ALTER TABLE audit_log
+    ADD COLUMN IF NOT EXISTS entity_type TEXT,
+    ADD COLUMN IF NOT EXISTS entity_id BIGINT;
+
+UPDATE audit_log
+   SET entity_type = 'gift_transaction',
+       entity_id = (details->>'tx_id')::bigint
+ WHERE entity_type IS NULL
+   AND action LIKE 'payment.%'
+   AND details ? 'tx_id';
+
+UPDATE audit_log
+   SET entity_type = 'gift_message',
+       entity_id = (details->>'message_id')::bigint
+ WHERE entity_type IS NULL
+   AND action LIKE 'giftmessage.%'
+   AND details ? 'message_id';
+
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (entity_type, entity_id, id DESC);
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON audit_log (created_at);
@@ -0,0 +1,30 @@
+ALTER TABLE audit_log
+    ADD COLUMN IF NOT EXISTS prev_hash BYTEA,
+    ADD COLUMN IF NOT EXISTS hash BYTEA;
+
+CREATE TABLE IF NOT EXISTS audit_chain_head (
+    id SMALLINT PRIMARY KEY CHECK (id = 1),
+    last_id BIGINT NOT NULL DEFAULT 0,
+    last_hash BYTEA NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Genesis: the first row chains from 32 zero bytes. Rows that predate this
+-- migration are linked by audit.Chain.Seal at server startup.
+INSERT INTO audit_chain_head (id, last_id, last_hash)
+VALUES (1, 0, decode(repeat('00', 32), 'hex'))
+ON CONFLICT (id) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS audit_checkpoints (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    last_id BIGINT NOT NULL,
+    last_hash BYTEA NOT NULL,
+    public_key BYTEA NOT NULL,
+    signature BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_checkpoints_last_id_idx ON audit_checkpoints (last_id);
+
+ALTER TABLE audit_chain_head ENABLE ROW LEVEL SECURITY;
+ALTER TABLE audit_checkpoints ENABLE ROW LEVEL SECURITY;
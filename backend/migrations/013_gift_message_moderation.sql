@@ -0,0 +1,19 @@
+-- Existing messages were published immediately, so they start out approved.
+ALTER TABLE gift_messages
+    ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'approved',
+    ADD COLUMN IF NOT EXISTS flagged_terms TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS moderated_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS moderated_by BIGINT REFERENCES users(id);
+
+ALTER TABLE gift_messages DROP CONSTRAINT IF EXISTS gift_messages_status_chk;
+ALTER TABLE gift_messages
+    ADD CONSTRAINT gift_messages_status_chk CHECK (status IN ('pending', 'approved', 'rejected'));
+
+DROP INDEX IF EXISTS gift_messages_gift_active_idx;
+CREATE INDEX IF NOT EXISTS gift_messages_gift_approved_idx
+    ON gift_messages (gift_id, created_at DESC)
+    WHERE deleted_at IS NULL AND status = 'approved';
+
+CREATE INDEX IF NOT EXISTS gift_messages_status_idx
+    ON gift_messages (status, created_at)
+    WHERE deleted_at IS NULL;
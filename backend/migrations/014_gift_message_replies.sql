@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS gift_message_replies (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    message_id BIGINT NOT NULL
+        REFERENCES gift_messages(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL
+        REFERENCES users(id) ON DELETE RESTRICT,
+    content TEXT NOT NULL,
+    visibility TEXT NOT NULL DEFAULT 'public',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT gift_message_replies_content_len CHECK (char_length(content) BETWEEN 1 AND 500),
+    CONSTRAINT gift_message_replies_visibility_chk CHECK (visibility IN ('public', 'private'))
+);
+
+CREATE INDEX IF NOT EXISTS gift_message_replies_message_idx
+    ON gift_message_replies (message_id, created_at);
+
+ALTER TABLE gift_message_replies ENABLE ROW LEVEL SECURITY;
+
+CREATE TABLE IF NOT EXISTS gift_message_reactions (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    message_id BIGINT NOT NULL
+        REFERENCES gift_messages(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL
+        REFERENCES users(id) ON DELETE RESTRICT,
+    emoji TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT gift_message_reactions_emoji_len CHECK (char_length(emoji) BETWEEN 1 AND 16),
+    CONSTRAINT gift_message_reactions_unique UNIQUE (message_id, user_id, emoji)
+);
+
+CREATE INDEX IF NOT EXISTS gift_message_reactions_message_idx
+    ON gift_message_reactions (message_id, created_at);
+
+ALTER TABLE gift_message_reactions ENABLE ROW LEVEL SECURITY;
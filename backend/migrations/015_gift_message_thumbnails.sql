@@ -0,0 +1,8 @@
+ALTER TABLE gift_messages
+    ADD COLUMN IF NOT EXISTS media_thumb_object_key TEXT;
+
+ALTER TABLE gift_messages DROP CONSTRAINT IF EXISTS gift_messages_media_thumb_chk;
+ALTER TABLE gift_messages
+    ADD CONSTRAINT gift_messages_media_thumb_chk CHECK (
+        media_thumb_object_key IS NULL OR media_kind = 'image'
+    );
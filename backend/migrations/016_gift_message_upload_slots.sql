@@ -0,0 +1,27 @@
+-- Reserved object keys for media the browser uploads straight to storage.
+-- A slot is consumed (deleted) when a message links its object; the sweeper
+-- removes expired slots together with whatever was uploaded to them.
+CREATE TABLE IF NOT EXISTS gift_message_upload_slots (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    object_key TEXT NOT NULL UNIQUE,
+    gift_transaction_id BIGINT NOT NULL
+        REFERENCES gift_transactions(id) ON DELETE RESTRICT,
+    user_id BIGINT NOT NULL
+        REFERENCES users(id) ON DELETE RESTRICT,
+    media_kind TEXT NOT NULL,
+    mime_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT gift_message_upload_slots_kind_chk CHECK (media_kind IN ('audio', 'video')),
+    CONSTRAINT gift_message_upload_slots_size_chk CHECK (size_bytes > 0)
+);
+
+CREATE INDEX IF NOT EXISTS gift_message_upload_slots_tx_idx
+    ON gift_message_upload_slots (gift_transaction_id);
+
+CREATE INDEX IF NOT EXISTS gift_message_upload_slots_expires_idx
+    ON gift_message_upload_slots (expires_at);
+
+ALTER TABLE gift_message_upload_slots ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,39 @@
+-- tus uploads of gift message media. Chunks live on the API server's disk
+-- until upload_offset reaches upload_length; the finished file is then
+-- validated and moved to the configured storage under object_key. A message
+-- consumes (deletes) the row; the sweeper removes expired ones.
+CREATE TABLE IF NOT EXISTS gift_message_resumable_uploads (
+    id TEXT PRIMARY KEY,
+    gift_transaction_id BIGINT NOT NULL
+        REFERENCES gift_transactions(id) ON DELETE RESTRICT,
+    user_id BIGINT NOT NULL
+        REFERENCES users(id) ON DELETE RESTRICT,
+    upload_length BIGINT NOT NULL,
+    upload_offset BIGINT NOT NULL DEFAULT 0,
+    declared_mime TEXT NOT NULL DEFAULT '',
+    object_key TEXT,
+    media_kind TEXT,
+    mime_type TEXT,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT gift_message_resumable_uploads_length_chk CHECK (upload_length > 0),
+    CONSTRAINT gift_message_resumable_uploads_offset_chk CHECK (upload_offset BETWEEN 0 AND upload_length),
+    CONSTRAINT gift_message_resumable_uploads_completed_chk CHECK (
+        (completed_at IS NULL AND object_key IS NULL)
+        OR
+        (completed_at IS NOT NULL AND object_key IS NOT NULL
+            AND media_kind IN ('audio', 'video') AND mime_type IS NOT NULL)
+    )
+);
+
+CREATE INDEX IF NOT EXISTS gift_message_resumable_uploads_user_idx
+    ON gift_message_resumable_uploads (user_id)
+    WHERE completed_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS gift_message_resumable_uploads_expires_idx
+    ON gift_message_resumable_uploads (expires_at);
+
+ALTER TABLE gift_message_resumable_uploads ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,59 @@
+CREATE TABLE IF NOT EXISTS guestbook_entries (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id BIGINT NOT NULL
+        REFERENCES users(id) ON DELETE RESTRICT,
+    family_group BIGINT,
+    -- signer_key is "family:<group>" or "user:<id>" depending on
+    -- GUESTBOOK_SIGN_SCOPE; one live entry per key.
+    signer_key TEXT NOT NULL,
+    author_name TEXT NOT NULL,
+    content TEXT NOT NULL,
+    media_object_key TEXT,
+    media_thumb_object_key TEXT,
+    media_kind TEXT,
+    media_size_bytes BIGINT,
+    media_mime_type TEXT,
+    status TEXT NOT NULL DEFAULT 'approved',
+    flagged_terms TEXT[] NOT NULL DEFAULT '{}',
+    moderated_at TIMESTAMPTZ,
+    moderated_by BIGINT REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    deleted_at TIMESTAMPTZ,
+    deleted_by BIGINT REFERENCES users(id),
+
+    CONSTRAINT guestbook_entries_content_len CHECK (char_length(content) BETWEEN 1 AND 1000),
+    CONSTRAINT guestbook_entries_author_name_len CHECK (char_length(author_name) BETWEEN 1 AND 120),
+    CONSTRAINT guestbook_entries_status_chk CHECK (status IN ('pending', 'approved', 'rejected')),
+    CONSTRAINT guestbook_entries_media_kind_chk CHECK (
+        media_kind IS NULL OR media_kind IN ('image', 'audio', 'video')
+    ),
+    CONSTRAINT guestbook_entries_media_consistency CHECK (
+        (media_object_key IS NULL
+            AND media_kind IS NULL
+            AND media_size_bytes IS NULL
+            AND media_mime_type IS NULL)
+        OR
+        (media_object_key IS NOT NULL
+            AND media_kind IS NOT NULL
+            AND media_size_bytes IS NOT NULL
+            AND media_mime_type IS NOT NULL)
+    ),
+    CONSTRAINT guestbook_entries_media_thumb_chk CHECK (
+        media_thumb_object_key IS NULL OR media_kind = 'image'
+    )
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS guestbook_entries_signer_idx
+    ON guestbook_entries (signer_key)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS guestbook_entries_approved_idx
+    ON guestbook_entries (created_at DESC)
+    WHERE deleted_at IS NULL AND status = 'approved';
+
+CREATE INDEX IF NOT EXISTS guestbook_entries_status_idx
+    ON guestbook_entries (status, created_at)
+    WHERE deleted_at IS NULL;
+
+ALTER TABLE guestbook_entries ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,16 @@
+-- Feed for the reception wall. Rows are written next to the change that
+-- triggers them and announced with pg_notify('wall_events', id); the id
+-- doubles as the SSE event id clients resume from.
+CREATE TABLE IF NOT EXISTS wall_events (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    kind TEXT NOT NULL,
+    ref_id BIGINT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT wall_events_kind_chk CHECK (kind IN ('gift_message', 'guestbook', 'purchase')),
+    -- Re-approving a message or replaying a webhook must not show it twice.
+    CONSTRAINT wall_events_ref_unique UNIQUE (kind, ref_id)
+);
+
+ALTER TABLE wall_events ENABLE ROW LEVEL SECURITY;
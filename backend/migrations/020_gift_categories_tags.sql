@@ -0,0 +1,51 @@
+-- Registry curation: categories ("Cozinha", "Lua de mel"), free-form tags,
+-- a manual sort position and a featured flag.
+CREATE TABLE IF NOT EXISTS gift_categories (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL,
+    slug TEXT NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT gift_categories_name_len CHECK (length(trim(name)) BETWEEN 1 AND 60),
+    CONSTRAINT gift_categories_slug_unique UNIQUE (slug)
+);
+
+ALTER TABLE gift_categories ENABLE ROW LEVEL SECURITY;
+
+CREATE TABLE IF NOT EXISTS gift_tags (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL,
+    slug TEXT NOT NULL,
+
+    CONSTRAINT gift_tags_name_len CHECK (length(trim(name)) BETWEEN 1 AND 40),
+    CONSTRAINT gift_tags_slug_unique UNIQUE (slug)
+);
+
+ALTER TABLE gift_tags ENABLE ROW LEVEL SECURITY;
+
+CREATE TABLE IF NOT EXISTS gift_tag_links (
+    gift_id BIGINT NOT NULL REFERENCES gifts(id) ON DELETE CASCADE,
+    tag_id BIGINT NOT NULL REFERENCES gift_tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (gift_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS gift_tag_links_tag_idx ON gift_tag_links (tag_id);
+
+ALTER TABLE gift_tag_links ENABLE ROW LEVEL SECURITY;
+
+-- Deleting a category leaves its gifts uncategorised rather than blocking.
+ALTER TABLE gifts
+    ADD COLUMN IF NOT EXISTS category_id BIGINT REFERENCES gift_categories(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS position INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS featured BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE gifts DROP CONSTRAINT IF EXISTS gifts_position_non_negative;
+ALTER TABLE gifts
+    ADD CONSTRAINT gifts_position_non_negative CHECK (position >= 0);
+
+CREATE INDEX IF NOT EXISTS gifts_category_idx ON gifts (category_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS gifts_curated_idx
+    ON gifts (featured DESC, position ASC, created_at DESC)
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,36 @@
+-- Periodic re-scrape of store links. gift_price_checks is the history, one
+-- row per scrape; gift_price_watch holds the latest verdict per gift and
+-- when to look again.
+CREATE TABLE IF NOT EXISTS gift_price_checks (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    gift_id BIGINT NOT NULL REFERENCES gifts(id) ON DELETE CASCADE,
+    outcome TEXT NOT NULL,
+    listed_price_cents BIGINT NOT NULL,
+    scraped_price_cents BIGINT,
+    change_percent NUMERIC(8, 2),
+    error TEXT,
+    checked_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT gift_price_checks_outcome_chk CHECK (outcome IN ('ok', 'price_changed', 'no_product', 'error'))
+);
+
+CREATE INDEX IF NOT EXISTS gift_price_checks_gift_idx ON gift_price_checks (gift_id, checked_at DESC);
+-- The daily Firecrawl budget counts recent rows.
+CREATE INDEX IF NOT EXISTS gift_price_checks_checked_at_idx ON gift_price_checks (checked_at);
+
+ALTER TABLE gift_price_checks ENABLE ROW LEVEL SECURITY;
+
+CREATE TABLE IF NOT EXISTS gift_price_watch (
+    gift_id BIGINT PRIMARY KEY REFERENCES gifts(id) ON DELETE CASCADE,
+    status TEXT NOT NULL,
+    last_check_id BIGINT REFERENCES gift_price_checks(id) ON DELETE SET NULL,
+    consecutive_failures INT NOT NULL DEFAULT 0,
+    next_check_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT gift_price_watch_status_chk CHECK (status IN ('ok', 'price_changed', 'no_product', 'error'))
+);
+
+CREATE INDEX IF NOT EXISTS gift_price_watch_next_idx ON gift_price_watch (next_check_at);
+
+ALTER TABLE gift_price_watch ENABLE ROW LEVEL SECURITY;
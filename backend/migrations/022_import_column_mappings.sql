@@ -0,0 +1,13 @@
+-- Column mapping each user confirmed on their last spreadsheet import, per
+-- importer, so the next file with the same headers maps itself.
+CREATE TABLE IF NOT EXISTS import_column_mappings (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    mapping JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    PRIMARY KEY (user_id, kind),
+    CONSTRAINT import_column_mappings_kind_chk CHECK (kind IN ('gift', 'guest'))
+);
+
+ALTER TABLE import_column_mappings ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,33 @@
+-- Accent-insensitive fuzzy search over gift and guest names. Queries compare
+-- search_normalize(column) with pg_trgm: LIKE for substrings, <% (word
+-- similarity) for typos.
+CREATE EXTENSION IF NOT EXISTS unaccent;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- unaccent() is only STABLE (its dictionary could change), so indexes need
+-- an IMMUTABLE wrapper naming the dictionary explicitly. The extension may
+-- live outside public (Supabase installs into "extensions"), hence the
+-- lookup.
+DO $do$
+DECLARE
+    ext_schema TEXT;
+BEGIN
+    SELECT n.nspname INTO ext_schema
+      FROM pg_extension e JOIN pg_namespace n ON n.oid = e.extnamespace
+     WHERE e.extname = 'unaccent';
+
+    EXECUTE format(
+        $f$CREATE OR REPLACE FUNCTION search_normalize(TEXT) RETURNS TEXT
+             LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+             AS $b$ SELECT lower(%1$I.unaccent(%2$L::regdictionary, $1)) $b$
+        $f$,
+        ext_schema, ext_schema || '.unaccent');
+END
+$do$;
+
+CREATE INDEX IF NOT EXISTS gifts_name_search_idx
+    ON gifts USING gin (search_normalize(name) gin_trgm_ops)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS guests_name_search_idx
+    ON guests USING gin (search_normalize(first_name || ' ' || last_name) gin_trgm_ops);
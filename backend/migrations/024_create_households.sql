@@ -0,0 +1,54 @@
+-- Households give family_group a row of its own: a display name ("Família
+-- Souza"), a head of household, a postal address and notes. A household's id
+-- is the family_group number its guests already carry, so guests keep the
+-- column (and the /family/{familyGroup} routes keep working) and only gain a
+-- foreign key.
+CREATE TABLE IF NOT EXISTS households (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL,
+    -- Kept a member of the household by the application; deleting the guest
+    -- just leaves the household without a head.
+    head_guest_id BIGINT REFERENCES guests(id) ON DELETE SET NULL,
+    address_line1 TEXT,
+    address_line2 TEXT,
+    city TEXT,
+    state TEXT,
+    postal_code TEXT,
+    notes TEXT,
+    created_by TEXT NOT NULL,
+    updated_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT households_name_len CHECK (length(trim(name)) BETWEEN 1 AND 120),
+    CONSTRAINT households_state_check CHECK (state IS NULL OR state ~ '^[A-Z]{2}$'),
+    CONSTRAINT households_postal_code_check CHECK (postal_code IS NULL OR postal_code ~ '^\d{8}$'),
+    CONSTRAINT households_notes_len CHECK (notes IS NULL OR length(notes) <= 2000),
+    CONSTRAINT households_created_by_racf CHECK (created_by ~ '^[A-Z0-9]{5}$'),
+    CONSTRAINT households_updated_by_racf CHECK (updated_by ~ '^[A-Z0-9]{5}$')
+);
+
+CREATE INDEX IF NOT EXISTS households_head_guest_idx ON households (head_guest_id);
+
+ALTER TABLE households ENABLE ROW LEVEL SECURITY;
+
+-- One household per existing family_group, named after its first guest.
+INSERT INTO households (id, name, created_by, updated_by)
+SELECT family_group,
+       'Família ' || (array_agg(last_name ORDER BY id))[1],
+       (array_agg(created_by ORDER BY id))[1],
+       (array_agg(created_by ORDER BY id))[1]
+  FROM guests
+ WHERE family_group NOT IN (SELECT id FROM households)
+ GROUP BY family_group;
+
+-- New households continue after the backfilled ids.
+SELECT setval(pg_get_serial_sequence('households', 'id'),
+              COALESCE((SELECT MAX(id) FROM households), 1),
+              (SELECT MAX(id) FROM households) IS NOT NULL);
+
+ALTER TABLE guests DROP CONSTRAINT IF EXISTS guests_family_group_fkey;
+ALTER TABLE guests
+    ADD CONSTRAINT guests_family_group_fkey FOREIGN KEY (family_group) REFERENCES households(id);
+
+CREATE INDEX IF NOT EXISTS guests_family_group_idx ON guests (family_group);
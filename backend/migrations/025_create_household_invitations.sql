@@ -0,0 +1,27 @@
+-- Invitation links printed (as QR codes) on each household's invitation: a
+-- link signs a household member in without a WhatsApp OTP, for RSVP and
+-- gifts only. The token in the link is the row id, the nonce and an HMAC of
+-- both under INVITATION_SIGNING_KEY, so the nonce stored here lets the couple
+-- reprint a link but not forge one.
+CREATE TABLE IF NOT EXISTS household_invitations (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    -- Merging households moves their invitations to the household kept.
+    household_id BIGINT NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    nonce BYTEA NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    revoked_by TEXT,
+    use_count INT NOT NULL DEFAULT 0,
+    last_used_at TIMESTAMPTZ,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT household_invitations_nonce_len CHECK (octet_length(nonce) = 16),
+    CONSTRAINT household_invitations_revoked_check CHECK ((revoked_at IS NULL) = (revoked_by IS NULL)),
+    CONSTRAINT household_invitations_created_by_racf CHECK (created_by ~ '^[A-Z0-9]{5}$'),
+    CONSTRAINT household_invitations_revoked_by_racf CHECK (revoked_by IS NULL OR revoked_by ~ '^[A-Z0-9]{5}$')
+);
+
+CREATE INDEX IF NOT EXISTS household_invitations_household_idx ON household_invitations (household_id);
+
+ALTER TABLE household_invitations ENABLE ROW LEVEL SECURITY;
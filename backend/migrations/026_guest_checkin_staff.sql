@@ -0,0 +1,17 @@
+-- Reception desk check-in on the wedding day: when each guest arrived and
+-- which user checked them in.
+ALTER TABLE guests ADD COLUMN IF NOT EXISTS checked_in_at TIMESTAMPTZ;
+ALTER TABLE guests ADD COLUMN IF NOT EXISTS checked_in_by TEXT;
+
+ALTER TABLE guests DROP CONSTRAINT IF EXISTS guests_checked_in_check;
+ALTER TABLE guests
+    ADD CONSTRAINT guests_checked_in_check CHECK ((checked_in_at IS NULL) = (checked_in_by IS NULL));
+ALTER TABLE guests DROP CONSTRAINT IF EXISTS guests_checked_in_by_racf;
+ALTER TABLE guests
+    ADD CONSTRAINT guests_checked_in_by_racf CHECK (checked_in_by IS NULL OR checked_in_by ~ '^[A-Z0-9]{5}$');
+
+-- Staff run the reception desk: users without a guest who can reach the
+-- check-in endpoints only.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users
+    ADD CONSTRAINT users_role_check CHECK (role IN ('guest', 'groom', 'bride', 'staff'));